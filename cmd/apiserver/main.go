@@ -1,39 +1,79 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	// CronJob time zones resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
 
 	"github.com/minik8s/minik8s/pkg/apiserver"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/lifecycle"
 	"github.com/minik8s/minik8s/pkg/store"
 )
 
 var (
-	port           = flag.Int("port", 8080, "Port to listen on")
+	port           = flag.Int("port", 8080, "Port to listen on (0 disables TCP, e.g. with --bind-unix-socket)")
+	bindUnixSocket = flag.String("bind-unix-socket", "", "Also serve the API on a unix domain socket at this path, readable by its owner and group")
 	storeType      = flag.String("store", "memory", "Store type: memory or etcd")
 	etcdEndpoints  = flag.String("etcd-endpoints", "localhost:2379", "Comma-separated list of etcd endpoints")
 	storePrefix    = flag.String("store-prefix", "/minik8s", "Store key prefix")
+	storeRoutes    = flag.String("store-routes", "", "Comma-separated Kind[/namespace]=type[:prefix] routes sending kinds or namespaces to their own store")
+	snapshotFile   = flag.String("snapshot-file", "", "With the memory store, load objects from this file at startup and save them to it on shutdown")
 	enableFallback = flag.Bool("enable-fallback", true, "Enable fallback to in-memory store if etcd fails")
+
+	serviceAccountKeyFile = flag.String("service-account-key-file", "", "File with the HMAC key used to sign service account tokens (empty disables service accounts)")
+	serviceAccountIssuer  = flag.String("service-account-issuer", apiserver.DefaultTokenIssuer, "Issuer of service account tokens")
+	rootCAFile            = flag.String("root-ca-file", "", "CA bundle published to pods alongside their service account token")
+	bootstrapTokenFile    = flag.String("bootstrap-token-file", "", "File of bootstrap tokens nodes may join with, as written by cli admin init (requires --service-account-key-file)")
+	nodeAgentTokenFile    = flag.String("node-agent-token-file", "", "File with the bearer token sent to node agents with exec, attach and log requests, as given to their --server-token-file")
+	tlsCertFile           = flag.String("tls-cert-file", "", "Serve HTTPS with this certificate")
+	tlsPrivateKeyFile     = flag.String("tls-private-key-file", "", "Private key of --tls-cert-file")
+	anonymousAuth         = flag.Bool("anonymous-auth", true, "Allow requests without a bearer token as the anonymous user")
+	secretReaders         = flag.String("secret-readers", "", "Comma-separated users and groups that may read secret data; others get secrets redacted once authentication is enabled")
+	allowedRegistries     = flag.String("allowed-registries", "", "Comma-separated registries or repository prefixes, e.g. docker.io/library,ghcr.io/course, pod images must come from (empty allows any)")
+	requireImageDigests   = flag.Bool("require-image-digests", false, "Only admit pod images pinned to a digest")
+	bannedImageTags       = flag.String("banned-image-tags", "", "Comma-separated image tags pods may not use, e.g. latest; images without a tag count as latest")
+
+	storeTimeout           = flag.Duration("store-timeout", store.DefaultOperationTimeout, "Fail store calls taking longer than this, answering their requests with 504")
+	requestTimeout         = flag.Duration("request-timeout", apiserver.DefaultTimeouts().Request, "Deadline of requests other than watches, exec, attach and logs; expired ones get 504 (0 disables)")
+	readTimeout            = flag.Duration("read-timeout", apiserver.DefaultTimeouts().Read, "How long reading a request, including its body, may take (0 disables)")
+	writeTimeout           = flag.Duration("write-timeout", apiserver.DefaultTimeouts().Write, "How long writing a response may take, counted from reading the request headers (0 disables)")
+	idleTimeout            = flag.Duration("idle-timeout", apiserver.DefaultTimeouts().Idle, "How long an idle keep-alive connection is kept open (0 disables)")
+	slowStoreThreshold     = flag.Duration("slow-store-threshold", store.DefaultSlowThreshold, "Log store calls taking longer than this")
+	watchHeartbeatInterval = flag.Duration("watch-heartbeat-interval", apiserver.DefaultWatchHeartbeatInterval, "How long a watch stream may be idle before a heartbeat is sent")
+	enablePprof            = flag.Bool("enable-pprof", false, "Serve Go runtime profiles under /debug/pprof/")
+	historyLimit           = flag.Int("history-limit", apiserver.DefaultHistoryLimit, "Changes kept per object and served at its history subresource (negative disables)")
+	basePath               = flag.String("base-path", "", "Serve the API under this path prefix, e.g. /minik8s behind a reverse proxy")
+	trustedProxies         = flag.String("trusted-proxies", "", "Comma-separated IPs or CIDRs of reverse proxies whose X-Forwarded-For, -Host and -Proto headers are honored")
+	readOnly               = flag.Bool("read-only", false, "Reject mutating requests with 503, e.g. during store maintenance; toggle at runtime with PUT /admin/read-only")
+	autoCreateNamespaces   = flag.Bool("auto-create-namespaces", true, "Create the namespace of an object created in one that doesn't exist; false fails such requests with 404")
+	shutdownTimeout        = flag.Duration("shutdown-timeout", lifecycle.DefaultStopTimeout, "How long requests in flight get to finish on shutdown before their connections are closed")
 )
 
 func main() {
 	flag.Parse()
 
 	// Create store configuration
+	routes, err := store.ParseStoreRoutes(*storeRoutes)
+	if err != nil {
+		log.Fatalf("Invalid --store-routes: %v", err)
+	}
 	storeConfig := &store.StoreConfig{
 		Type:      store.StoreType(*storeType),
 		Endpoints: []string{*etcdEndpoints},
 		Prefix:    *storePrefix,
 		Options:   store.DefaultOptions(),
+		Routes:    routes,
 	}
 
 	// Create store
 	var s store.Store
-	var err error
 
 	if *enableFallback {
 		s, err = store.NewStoreWithFallback(storeConfig)
@@ -46,30 +86,201 @@ func main() {
 	}
 	defer s.Close()
 
+	if *snapshotFile != "" && storeConfig.Type == store.StoreTypeMemory {
+		if n, err := store.LoadSnapshotFile(context.Background(), s, *snapshotFile); err == nil {
+			fmt.Printf("Loaded %d objects from snapshot %s\n", n, *snapshotFile)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("Failed to load snapshot: %v", err)
+		}
+	}
+
+	// Bound store calls so a hung etcd fails requests instead of blocking
+	// them, and log failing and slow calls with the ID of the request making
+	// them
+	s = store.NewLoggingStore(store.NewTimeoutStore(s, *storeTimeout), *slowStoreThreshold)
+
 	// Log store type
 	fmt.Printf("Using store type: %s\n", storeConfig.Type)
 	if storeConfig.Type == store.StoreTypeEtcd {
 		fmt.Printf("Etcd endpoints: %v\n", storeConfig.Endpoints)
 		fmt.Printf("Store prefix: %s\n", storeConfig.Prefix)
 	}
+	for _, route := range storeConfig.Routes {
+		fmt.Printf("Store route: %s/%s -> %s %s\n", route.Kind, route.Namespace, route.Type, route.Prefix)
+	}
 
 	// Create API server
 	server := apiserver.NewServer(s, *port)
+	server.SetWatchHeartbeatInterval(*watchHeartbeatInterval)
+	timeouts := apiserver.DefaultTimeouts()
+	timeouts.Request = *requestTimeout
+	timeouts.Read = *readTimeout
+	timeouts.Write = *writeTimeout
+	timeouts.Idle = *idleTimeout
+	if timeouts.Write > 0 && (timeouts.Request <= 0 || timeouts.Request >= timeouts.Write) {
+		fmt.Println("Warning: --request-timeout should be shorter than --write-timeout, or timed out requests can't be answered with 504")
+	}
+	server.SetTimeouts(timeouts)
+	if storeConfig.Type == store.StoreTypeEtcd {
+		server.SetStoreName("etcd-0")
+	}
+	if *historyLimit >= 0 {
+		server.EnableHistory(*historyLimit)
+	}
+	// Under systemd socket activation, serve on the sockets systemd opened
+	// instead of our own
+	listeners, err := apiserver.SystemdListeners()
+	if err != nil {
+		log.Fatalf("Failed to use systemd sockets: %v", err)
+	}
+	if len(listeners) > 0 {
+		server.SetListeners(listeners)
+		fmt.Printf("Serving on %d sockets passed by systemd\n", len(listeners))
+	} else if *bindUnixSocket != "" {
+		server.SetUnixSocket(*bindUnixSocket)
+	}
+	if *basePath != "" {
+		server.SetBasePath(*basePath)
+		fmt.Printf("Serving the API under %s\n", *basePath)
+	}
+	if *trustedProxies != "" {
+		proxies := splitList(*trustedProxies)
+		if err := server.SetTrustedProxies(proxies); err != nil {
+			log.Fatalf("Invalid --trusted-proxies: %v", err)
+		}
+		fmt.Printf("Honoring X-Forwarded-* headers from: %s\n", strings.Join(proxies, ", "))
+	}
+	if *enablePprof {
+		server.EnableProfiling()
+		fmt.Println("Profiling enabled under /debug/pprof/")
+	}
+
+	if *serviceAccountKeyFile != "" {
+		key, err := auth.LoadSigningKey(*serviceAccountKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load service account key: %v", err)
+		}
+
+		var rootCA []byte
+		if *rootCAFile != "" {
+			if rootCA, err = os.ReadFile(*rootCAFile); err != nil {
+				log.Fatalf("Failed to read root CA: %v", err)
+			}
+		}
+
+		if err := server.EnableServiceAccounts(&apiserver.ServiceAccountConfig{
+			SigningKey:     key,
+			Issuer:         *serviceAccountIssuer,
+			RootCA:         rootCA,
+			AllowAnonymous: *anonymousAuth,
+		}); err != nil {
+			log.Fatalf("Failed to enable service accounts: %v", err)
+		}
+		fmt.Println("Service account tokens enabled")
+	}
+
+	if *secretReaders != "" {
+		readers := splitList(*secretReaders)
+		server.SetSecretReaders(readers)
+		fmt.Printf("Secret data readable by: %s\n", strings.Join(readers, ", "))
+	}
+
+	imagePolicy := apiserver.ImagePolicy{
+		AllowedRegistries: splitList(*allowedRegistries),
+		RequireDigests:    *requireImageDigests,
+		BannedTags:        splitList(*bannedImageTags),
+	}
+	server.SetImagePolicy(imagePolicy)
+	if len(imagePolicy.AllowedRegistries) > 0 {
+		fmt.Printf("Pod images restricted to: %s\n", strings.Join(imagePolicy.AllowedRegistries, ", "))
+	}
+
+	if *bootstrapTokenFile != "" {
+		tokens, err := auth.LoadBootstrapTokens(*bootstrapTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to load bootstrap tokens: %v", err)
+		}
+		if err := server.EnableBootstrapTokens(tokens); err != nil {
+			log.Fatalf("Failed to enable bootstrap tokens: %v", err)
+		}
+		fmt.Printf("Nodes may join with %d bootstrap tokens\n", len(tokens))
+	}
+
+	if *nodeAgentTokenFile != "" {
+		token, err := os.ReadFile(*nodeAgentTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to read node agent token: %v", err)
+		}
+		server.SetNodeAgentToken(strings.TrimSpace(string(token)))
+	}
 
-	// Start server in goroutine
-	go func() {
-		if err := server.Start(); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
+	if *tlsCertFile != "" {
+		if *tlsPrivateKeyFile == "" {
+			log.Fatal("--tls-private-key-file is required with --tls-cert-file")
 		}
-	}()
+		server.SetTLS(*tlsCertFile, *tlsPrivateKeyFile)
+		fmt.Println("Serving HTTPS")
+	}
+
+	if !*autoCreateNamespaces {
+		server.SetNamespaceAutoCreation(false)
+		fmt.Println("Namespaces must exist before objects are created in them")
+	}
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Create the well-known namespaces before serving any requests, unless
+	// the store must not be written to
+	if *readOnly {
+		server.SetReadOnly(true)
+		fmt.Println("Read-only mode enabled, skipping namespace bootstrap")
+	} else if err := server.Bootstrap(context.Background()); err != nil {
+		log.Fatalf("Failed to bootstrap namespaces: %v", err)
+	}
+
+	// The snapshot is saved once the server stopped serving, so it holds
+	// every write the server accepted
+	components := lifecycle.NewManager(&lifecycle.Config{StopTimeout: *shutdownTimeout})
+	if *snapshotFile != "" && storeConfig.Type == store.StoreTypeMemory {
+		components.OnShutdown("snapshot", func(ctx context.Context) error {
+			if err := store.SaveSnapshotFile(ctx, s, *snapshotFile); err != nil {
+				return fmt.Errorf("failed to save snapshot: %w", err)
+			}
+			fmt.Printf("Saved snapshot to %s\n", *snapshotFile)
+			return nil
+		})
+	}
+	components.Add("API server", lifecycle.Funcs{
+		StartFunc: func(ctx context.Context) error {
+			go func() {
+				if err := server.Start(); err != nil {
+					log.Fatalf("Failed to start server: %v", err)
+				}
+			}()
+			return nil
+		},
+		StopFunc: server.Shutdown,
+	})
+	if err := components.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start API server: %v", err)
+	}
 
-	fmt.Printf("API server started on port %d\n", *port)
+	fmt.Println("API server started")
 	fmt.Println("Press Ctrl+C to stop")
 
-	<-sigChan
+	lifecycle.WaitForSignal()
 	fmt.Println("\nShutting down API server...")
+
+	if err := components.Shutdown(context.Background()); err != nil {
+		fmt.Printf("Error shutting down API server: %v\n", err)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
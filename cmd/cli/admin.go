@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/auth"
+)
+
+const adminInitUsage = "Usage: cli admin init [--dir minik8s-pki] [--hosts localhost,127.0.0.1] [--advertise-address host:port] [--token-ttl 24h]"
+
+// Files written by admin init
+const (
+	caCertFile         = "ca.crt"
+	caKeyFile          = "ca.key"
+	servingCertFile    = "apiserver.crt"
+	servingKeyFile     = "apiserver.key"
+	signingKeyFile     = "sa.key"
+	bootstrapTokenFile = "bootstrap-tokens"
+)
+
+// adminCommand runs cluster administration subcommands
+func adminCommand(args []string) {
+	if len(args) < 1 || args[0] != "init" {
+		fmt.Println(adminInitUsage)
+		os.Exit(1)
+	}
+	adminInitCommand(args[1:])
+}
+
+// adminInitCommand generates the cluster CA, the API server's serving
+// certificate, the token signing key and a bootstrap token, and prints how
+// to start the API server and join nodes with them
+func adminInitCommand(args []string) {
+	fs := flag.NewFlagSet("admin init", flag.ExitOnError)
+	dir := fs.String("dir", "minik8s-pki", "Directory to write the certificates, keys and bootstrap token to")
+	hosts := fs.String("hosts", "localhost,127.0.0.1", "Comma-separated DNS names and IPs the API server certificate is valid for")
+	advertise := fs.String("advertise-address", "", "host:port nodes reach the API server at (defaults to the first host on port 8080)")
+	tokenTTL := fs.Duration("token-ttl", 24*time.Hour, "How long the bootstrap token is valid (0 never expires)")
+
+	positional, _ := parseInterspersed(fs, args)
+	hostList := splitList(*hosts)
+	if len(positional) != 0 || len(hostList) == 0 {
+		fmt.Println(adminInitUsage)
+		os.Exit(1)
+	}
+	if *advertise == "" {
+		*advertise = net.JoinHostPort(hostList[0], "8080")
+	}
+
+	if _, err := os.Stat(filepath.Join(*dir, caCertFile)); err == nil {
+		fmt.Printf("Error: %s already holds a cluster CA\n", *dir)
+		os.Exit(1)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	caCert, caKey, err := auth.GenerateCA("minik8s-ca")
+	if err != nil {
+		fmt.Printf("Error generating CA: %v\n", err)
+		os.Exit(1)
+	}
+	servingCert, servingKey, err := auth.GenerateServingCert(caCert, caKey, hostList)
+	if err != nil {
+		fmt.Printf("Error generating serving certificate: %v\n", err)
+		os.Exit(1)
+	}
+	signingKey := make([]byte, 32)
+	if _, err := rand.Read(signingKey); err != nil {
+		fmt.Printf("Error generating signing key: %v\n", err)
+		os.Exit(1)
+	}
+	token, err := auth.GenerateBootstrapToken(*tokenTTL)
+	if err != nil {
+		fmt.Printf("Error generating bootstrap token: %v\n", err)
+		os.Exit(1)
+	}
+	hash, err := auth.CACertHash(caCert)
+	if err != nil {
+		fmt.Printf("Error hashing CA: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		fmt.Printf("Error creating %s: %v\n", *dir, err)
+		os.Exit(1)
+	}
+	files := []struct {
+		name string
+		data []byte
+		mode os.FileMode
+	}{
+		{caCertFile, caCert, 0644},
+		{caKeyFile, caKey, 0600},
+		{servingCertFile, servingCert, 0644},
+		{servingKeyFile, servingKey, 0600},
+		{signingKeyFile, []byte(hex.EncodeToString(signingKey) + "\n"), 0600},
+		{bootstrapTokenFile, []byte(auth.FormatBootstrapToken(token) + "\n"), 0600},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(*dir, f.name), f.data, f.mode); err != nil {
+			fmt.Printf("Error writing %s: %v\n", f.name, err)
+			os.Exit(1)
+		}
+	}
+
+	path := func(name string) string { return filepath.Join(*dir, name) }
+	fmt.Printf("Wrote cluster certificates and keys to %s\n", *dir)
+	if !token.Expires.IsZero() {
+		fmt.Printf("Bootstrap token %s expires at %s\n", token.ID, token.Expires.Format(time.RFC3339))
+	}
+	fmt.Println("\nStart the API server with:")
+	fmt.Printf("  apiserver --tls-cert-file %s --tls-private-key-file %s \\\n", path(servingCertFile), path(servingKeyFile))
+	fmt.Printf("    --root-ca-file %s --service-account-key-file %s \\\n", path(caCertFile), path(signingKeyFile))
+	fmt.Printf("    --bootstrap-token-file %s\n", path(bootstrapTokenFile))
+	fmt.Println("\nSign approved certificate requests by starting the controller manager with:")
+	fmt.Printf("  controller-manager --cluster-signing-cert-file %s --cluster-signing-key-file %s\n", path(caCertFile), path(caKeyFile))
+	fmt.Println("\nJoin nodes with:")
+	fmt.Printf("  nodeagent --node-name <name> --join %s@%s --ca-cert-hash %s\n", token, *advertise, hash)
+	fmt.Println("\nTalk to the cluster with:")
+	fmt.Printf("  cli --server https://%s --certificate-authority %s get nodes\n", *advertise, path(caCertFile))
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
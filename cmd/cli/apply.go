@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/minik8s/minik8s/pkg/apply"
+)
+
+// applyCommand creates the objects described by manifests, or brings the
+// existing objects in line with them. Fields removed from a manifest since
+// the last apply are removed from the object too. With --prune, objects
+// matching the -l selector that are no longer in the manifests are deleted,
+// so a directory of manifests can declare the state of an application.
+func applyCommand(args []string) {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	filename := fs.String("f", "", "File with JSON manifests to apply, or a directory of them")
+	prune := fs.Bool("prune", false, "Delete objects matching -l that aren't in the manifests")
+	selector := fs.String("l", "", "Label selector (key=value,...) of the objects --prune may delete")
+
+	positional, _ := parseInterspersed(fs, args)
+	if *filename == "" || len(positional) != 0 || (*prune && *selector == "") {
+		fmt.Println("Usage: cli apply -f <filename|dir> [--prune -l key=value]")
+		os.Exit(1)
+	}
+
+	var labels map[string]string
+	if *prune {
+		var err error
+		if labels, err = parseSelector(*selector); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	manifests, err := readManifests(*filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(clusterContexts) > 1 {
+		applyToContexts(manifests, labels)
+		return
+	}
+	if !applyManifests(manifests, labels, func(line string) { fmt.Println(line) }) {
+		os.Exit(1)
+	}
+}
+
+// applyManifests applies manifests and, given labels, then prunes the
+// objects matching them that the manifests no longer hold. Each result and
+// error is passed to report. It returns whether everything succeeded.
+func applyManifests(manifests []map[string]interface{}, labels map[string]string, report func(string)) bool {
+	failed := false
+	for _, manifest := range manifests {
+		result, err := applyManifest(manifest)
+		if err != nil {
+			report(fmt.Sprintf("Error: %v", err))
+			failed = true
+			continue
+		}
+		report(result)
+	}
+	// An object that failed to apply may be one that would be pruned, so
+	// only prune after a clean apply
+	if failed {
+		return false
+	}
+	if labels != nil {
+		if err := pruneObjects(labels, *defaultNamespace, manifests, report); err != nil {
+			report(fmt.Sprintf("Error pruning: %v", err))
+			return false
+		}
+	}
+	return true
+}
+
+// readManifests reads the JSON manifests in path, or in the .json, .yaml
+// and .yml files of the directory path. A file may hold several manifests.
+func readManifests(path string) ([]map[string]interface{}, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = nil
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".json", ".yaml", ".yml":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	var manifests []map[string]interface{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		decoded, err := decodeManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		manifests = append(manifests, decoded...)
+	}
+	return manifests, nil
+}
+
+// decodeManifests decodes the JSON objects in data, one after another
+func decodeManifests(data []byte) ([]map[string]interface{}, error) {
+	var manifests []map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var manifest map[string]interface{}
+		if err := decoder.Decode(&manifest); err == io.EOF {
+			return manifests, nil
+		} else if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+}
+
+// applyManifest creates or updates the object described by manifest and
+// returns what was done, such as "Deployment web configured"
+func applyManifest(manifest map[string]interface{}) (string, error) {
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if kind == "" || name == "" {
+		return "", fmt.Errorf("kind and metadata.name are required to apply a manifest")
+	}
+
+	collection, err := collectionURL(kind, manifest)
+	if err != nil {
+		return "", err
+	}
+	endpoint := collection + "/" + name
+
+	if err := apply.SetLastApplied(manifest); err != nil {
+		return "", err
+	}
+
+	live, found, err := getLiveObject(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+
+	if !found {
+		body, _ := json.Marshal(manifest)
+		resp, err := client.Post(context.Background(), collection, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("failed to create %s %s: %w", kind, name, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			body, _ := io.ReadAll(resp.Body)
+			return "", fmt.Errorf("failed to create %s %s: %s - %s", kind, name, resp.Status, string(body))
+		}
+		return fmt.Sprintf("%s %s created", kind, name), nil
+	}
+
+	original, err := apply.GetLastApplied(live)
+	if err != nil {
+		fmt.Printf("Warning: ignoring the previous configuration of %s %s: %v\n", kind, name, err)
+	}
+	merged := apply.ThreeWayMerge(original, manifest, live)
+
+	body, _ := json.Marshal(merged)
+	resp, err := client.Put(context.Background(), endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to update %s %s: %w", kind, name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to update %s %s: %s - %s", kind, name, resp.Status, string(body))
+	}
+	return fmt.Sprintf("%s %s configured", kind, name), nil
+}
+
+// getLiveObject fetches an object as generic JSON, reporting whether it exists
+func getLiveObject(endpoint string) (map[string]interface{}, bool, error) {
+	resp, err := client.Get(context.Background(), endpoint)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, false, fmt.Errorf("%s - %s", resp.Status, string(body))
+	}
+
+	var live map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&live); err != nil {
+		return nil, false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return live, true, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultBulkParallelism is how many objects bulk commands work on at once
+// unless --parallel says otherwise
+const defaultBulkParallelism = 5
+
+// progressBarWidth is how many characters the progress bar fills
+const progressBarWidth = 30
+
+// bulkTask is the work on one object of a bulk command
+type bulkTask struct {
+	// object names the object in failure messages, e.g. "pod web-1"
+	object string
+
+	// run does the work, returning the line reporting it done
+	run func() (string, error)
+}
+
+// bulkFailure is a task that failed and why
+type bulkFailure struct {
+	object string
+	err    error
+}
+
+// runBulk runs tasks, at most parallelism at once, and returns how many
+// failed. Results are printed as tasks finish. With more than one task, a
+// progress bar is kept on stderr when it's a terminal, and a summary of
+// successes and failures is printed once all are done.
+func runBulk(verb string, tasks []bulkTask, parallelism int) int {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	progress := &bulkProgress{
+		verb:  verb,
+		total: len(tasks),
+		bar:   len(tasks) > 1 && isTerminal(int(os.Stderr.Fd())),
+	}
+
+	work := make(chan bulkTask)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism && i < len(tasks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range work {
+				line, err := task.run()
+				progress.done(task.object, line, err)
+			}
+		}()
+	}
+	progress.draw()
+	for _, task := range tasks {
+		work <- task
+	}
+	close(work)
+	wg.Wait()
+	progress.clear()
+
+	if len(tasks) > 1 {
+		progress.summarize()
+	}
+	return len(progress.failures)
+}
+
+// bulkProgress tracks the tasks of runBulk as they finish
+type bulkProgress struct {
+	mu       sync.Mutex
+	verb     string
+	total    int
+	finished int
+	failures []bulkFailure
+	bar      bool
+}
+
+// done records a finished task and prints its result above the bar
+func (p *bulkProgress) done(object, line string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finished++
+	p.clearLocked()
+	if err != nil {
+		p.failures = append(p.failures, bulkFailure{object: object, err: err})
+		fmt.Printf("Error: %s: %v\n", object, err)
+	} else {
+		fmt.Println(line)
+	}
+	p.drawLocked()
+}
+
+func (p *bulkProgress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.drawLocked()
+}
+
+func (p *bulkProgress) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearLocked()
+}
+
+// drawLocked redraws the progress bar on stderr, e.g.
+// [#########.....................] 3/10 deleted
+func (p *bulkProgress) drawLocked() {
+	if !p.bar {
+		return
+	}
+	filled := progressBarWidth * p.finished / p.total
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d %s",
+		strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled), p.finished, p.total, p.verb)
+}
+
+// clearLocked erases the progress bar so a line can be printed in its place
+func (p *bulkProgress) clearLocked() {
+	if p.bar {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
+
+// summarize prints how many tasks succeeded and which failed
+func (p *bulkProgress) summarize() {
+	fmt.Printf("%d %s, %d failed\n", p.total-len(p.failures), p.verb, len(p.failures))
+	for _, failure := range p.failures {
+		fmt.Printf("  %s: %v\n", failure.object, failure.err)
+	}
+}
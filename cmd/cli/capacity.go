@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// capacityCommand prints how much CPU and memory each node, and the whole
+// cluster, can give pods, how much of that their pods request and how much
+// they use
+func capacityCommand(args []string) {
+	fs := flag.NewFlagSet("capacity", flag.ExitOnError)
+	output := fs.String("o", "", "Output format: json")
+
+	if positional, _ := parseInterspersed(fs, args); len(positional) != 0 || (*output != "" && *output != "json") {
+		fmt.Println("Usage: cli capacity [-o json]")
+		os.Exit(1)
+	}
+
+	var report api.CapacityReport
+	if err := getJSON(*serverURL+"/api/v1alpha1/capacity", &report); err != nil {
+		fmt.Printf("Error getting capacity: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPODS\tCPU REQUESTED\tCPU USED\tMEMORY REQUESTED\tMEMORY USED")
+	for _, node := range report.Nodes {
+		name := node.Name
+		if node.Unschedulable {
+			name += " (cordoned)"
+		}
+		fmt.Fprintln(w, capacityRow(name, node.CapacitySummary))
+	}
+	fmt.Fprintln(w, capacityRow("TOTAL", report.Cluster))
+	w.Flush()
+}
+
+// capacityRow returns the tab-separated columns of a capacity table row
+func capacityRow(name string, summary api.CapacitySummary) string {
+	cpu := summary.Resources[api.ResourceCPU]
+	memory := summary.Resources[api.ResourceMemory]
+	if cpu == nil {
+		cpu = &api.ResourceCapacity{}
+	}
+	if memory == nil {
+		memory = &api.ResourceCapacity{}
+	}
+	return fmt.Sprintf("%s\t%d\t%s\t%s\t%s\t%s", name, summary.Pods,
+		requestedCell(cpu, formatCores), usedCell(cpu, formatCores),
+		requestedCell(memory, formatBytes), usedCell(memory, formatBytes))
+}
+
+// requestedCell formats what's requested of a resource out of what's
+// allocatable, e.g. "1.5/4 (37%)"
+func requestedCell(resource *api.ResourceCapacity, format func(float64) string) string {
+	return fmt.Sprintf("%s/%s (%s)", format(resource.Requested), format(resource.Allocatable), percentOf(resource.Requested, resource.Allocatable))
+}
+
+// usedCell formats what's used of a resource, e.g. "0.25 (6%)"
+func usedCell(resource *api.ResourceCapacity, format func(float64) string) string {
+	if resource.Used == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%s (%s)", format(*resource.Used), percentOf(*resource.Used, resource.Allocatable))
+}
+
+// percentOf formats part as a percentage of total
+func percentOf(part, total float64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", int(math.Round(part/total*100)))
+}
+
+// formatCores formats a number of cores to the millicore
+func formatCores(cores float64) string {
+	return strconv.FormatFloat(math.Round(cores*1000)/1000, 'f', -1, 64)
+}
+
+// formatBytes formats a number of bytes with the largest binary suffix
+// that keeps it at least 1, e.g. "1.5Gi"
+func formatBytes(bytes float64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   float64
+	}{{"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10}} {
+		if bytes >= unit.size {
+			return strconv.FormatFloat(math.Round(bytes/unit.size*10)/10, 'f', -1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatFloat(math.Round(bytes), 'f', -1, 64)
+}
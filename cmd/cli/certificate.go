@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+)
+
+// certificatePollInterval is how often certificate request --wait checks
+// whether the certificate was issued
+const certificatePollInterval = 2 * time.Second
+
+// certificateCommand requests certificates and approves or denies requests
+func certificateCommand(args []string) {
+	if len(args) < 2 || (args[0] != "request" && args[0] != "approve" && args[0] != "deny") {
+		fmt.Println("Usage: cli certificate <request|approve|deny> <name>...")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "request":
+		requestCertificate(args[1:])
+	default:
+		decideCertificates(args[0], args[1:])
+	}
+}
+
+// requestCertificate generates a key and asks the cluster to sign a
+// certificate for it, writing the key to <name>.key and, with --wait, the
+// certificate to <name>.crt once it's issued
+func requestCertificate(args []string) {
+	fs := flag.NewFlagSet("certificate request", flag.ExitOnError)
+	commonName := fs.String("common-name", "", "Common name of the certificate, the user it authenticates as (defaults to the request name)")
+	organizations := fs.String("organizations", "", "Comma-separated organizations of the certificate, the groups it authenticates in")
+	hosts := fs.String("hosts", "", "Comma-separated DNS names and IP addresses of a serving certificate")
+	signer := fs.String("signer", api.SignerAPIServerClient, "Signer to ask: "+api.SignerAPIServerClient+" or "+api.SignerNodeServing)
+	expiration := fs.Duration("expiration", 0, "Requested lifetime of the certificate (0 lets the signer decide)")
+	dir := fs.String("dir", ".", "Directory to write the key and certificate to")
+	wait := fs.Duration("wait", 0, "How long to wait for the certificate to be issued (0 doesn't wait)")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 1 {
+		fmt.Println("Usage: cli certificate request <name> [--common-name cn] [--organizations o1,o2] [--hosts h1,h2] [--signer name] [--expiration duration] [--dir dir] [--wait duration]")
+		os.Exit(1)
+	}
+	name := positional[0]
+	if *commonName == "" {
+		*commonName = name
+	}
+
+	request, key, err := auth.GenerateCertificateRequest(*commonName, splitList(*organizations), splitList(*hosts))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	keyPath := filepath.Join(*dir, name+".key")
+	if err := os.WriteFile(keyPath, key, 0600); err != nil {
+		fmt.Printf("Error writing key: %v\n", err)
+		os.Exit(1)
+	}
+
+	csr := api.CertificateSigningRequest{
+		TypeMeta:   api.TypeMeta{Kind: "CertificateSigningRequest", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Spec: api.CertificateSigningRequestSpec{
+			Request:           string(request),
+			SignerName:        *signer,
+			ExpirationSeconds: int64(expiration.Seconds()),
+		},
+	}
+	data, err := json.Marshal(csr)
+	if err != nil {
+		fmt.Printf("Error encoding certificate signing request: %v\n", err)
+		os.Exit(1)
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/certificatesigningrequests", *serverURL)
+	resp, err := client.Post(context.Background(), endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		fmt.Printf("Error requesting certificate: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error requesting certificate: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+	fmt.Printf("Requested certificate %s; the key is in %s\n", name, keyPath)
+	fmt.Printf("Approve it with: cli certificate approve %s\n", name)
+
+	if *wait > 0 {
+		waitForCertificate(name, filepath.Join(*dir, name+".crt"), *wait)
+	}
+}
+
+// waitForCertificate polls the request until its certificate is issued and
+// writes it to path
+func waitForCertificate(name, path string, timeout time.Duration) {
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/certificatesigningrequests/%s", *serverURL, name)
+	deadline := time.Now().Add(timeout)
+	for {
+		csr, err := getCertificateSigningRequest(endpoint)
+		if err != nil {
+			fmt.Printf("Error getting certificate signing request: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch {
+		case csr.Status.Certificate != "":
+			if err := os.WriteFile(path, []byte(csr.Status.Certificate), 0644); err != nil {
+				fmt.Printf("Error writing certificate: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Certificate %s issued; wrote it to %s\n", name, path)
+			return
+		case csr.CertificateCondition(api.CertificateDenied) != nil:
+			fmt.Printf("Error: certificate %s was denied\n", name)
+			os.Exit(1)
+		case csr.CertificateCondition(api.CertificateFailed) != nil:
+			fmt.Printf("Error: certificate %s could not be issued: %s\n", name, csr.CertificateCondition(api.CertificateFailed).Message)
+			os.Exit(1)
+		}
+
+		if time.Now().After(deadline) {
+			fmt.Printf("Error: timed out waiting for certificate %s to be issued\n", name)
+			os.Exit(1)
+		}
+		time.Sleep(certificatePollInterval)
+	}
+}
+
+// getCertificateSigningRequest fetches a certificate signing request
+func getCertificateSigningRequest(endpoint string) (*api.CertificateSigningRequest, error) {
+	resp, err := client.Get(context.Background(), endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s - %s", resp.Status, string(body))
+	}
+	var csr api.CertificateSigningRequest
+	if err := json.NewDecoder(resp.Body).Decode(&csr); err != nil {
+		return nil, err
+	}
+	return &csr, nil
+}
+
+// decideCertificates approves or denies certificate signing requests
+func decideCertificates(action string, args []string) {
+	fs := flag.NewFlagSet("certificate "+action, flag.ExitOnError)
+	reason := fs.String("reason", "", "Short reason for the decision")
+	message := fs.String("message", "", "Human-readable explanation of the decision")
+
+	names, _ := parseInterspersed(fs, args)
+	if len(names) == 0 {
+		fmt.Printf("Usage: cli certificate %s <name>... [--reason reason] [--message message]\n", action)
+		os.Exit(1)
+	}
+
+	conditionType, decided := api.CertificateApproved, "approved"
+	if action == "deny" {
+		conditionType, decided = api.CertificateDenied, "denied"
+	}
+	update := api.CertificateSigningRequest{Status: api.CertificateSigningRequestStatus{
+		Conditions: []api.CertificateSigningRequestCondition{{Type: conditionType, Reason: *reason, Message: *message}},
+	}}
+	data, err := json.Marshal(update)
+	if err != nil {
+		fmt.Printf("Error encoding approval: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, name := range names {
+		endpoint := fmt.Sprintf("%s/api/v1alpha1/certificatesigningrequests/%s/approval", *serverURL, name)
+		resp, err := client.Put(context.Background(), endpoint, "application/json", bytes.NewReader(data))
+		if err != nil {
+			fmt.Printf("Error updating certificate signing request %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			fmt.Printf("Error updating certificate signing request %s: %s - %s\n", name, resp.Status, string(body))
+			failed = true
+			continue
+		}
+		fmt.Printf("Certificate signing request %s %s\n", name, decided)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// clusterAnnotation records which context an object got from several
+// contexts came from
+const clusterAnnotation = "minik8s.io/cluster"
+
+// clusterContext is a cluster the CLI can talk to, as named in the config
+// file. Its fields take the place of the global flags of the same names.
+type clusterContext struct {
+	Name                 string `json:"-"`
+	Server               string `json:"server"`
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+	ClientCertificate    string `json:"clientCertificate,omitempty"`
+	ClientKey            string `json:"clientKey,omitempty"`
+	Namespace            string `json:"namespace,omitempty"`
+}
+
+// cliConfig is the config file --contexts reads, e.g.
+// {"contexts": {"lab1": {"server": "https://lab1:8443", "namespace": "course-a"}}}
+type cliConfig struct {
+	Contexts map[string]clusterContext `json:"contexts"`
+}
+
+// clusterContexts are the contexts selected with --contexts, if any. get and
+// apply run against each of them in turn.
+var clusterContexts []clusterContext
+
+// configFromEnv returns the default of --config
+func configFromEnv() string {
+	if path := os.Getenv("MINIK8S_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".minik8s", "config.json")
+}
+
+// loadContexts reads the named contexts from the config file at path. A
+// --namespace given on the command line overrides their namespaces.
+func loadContexts(path string, names []string) ([]clusterContext, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("--contexts names no context")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no config file, set --config or $MINIK8S_CONFIG")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config cliConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	namespaceSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "namespace" {
+			namespaceSet = true
+		}
+	})
+
+	contexts := make([]clusterContext, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		ctx, ok := config.Contexts[name]
+		if !ok {
+			return nil, fmt.Errorf("context %q not found in %s", name, path)
+		}
+		if ctx.Server == "" {
+			return nil, fmt.Errorf("context %q has no server", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		ctx.Name = name
+		if ctx.Namespace == "" || namespaceSet {
+			ctx.Namespace = *defaultNamespace
+		}
+		contexts = append(contexts, ctx)
+	}
+	return contexts, nil
+}
+
+// useContext points the global flags and the client at the cluster of ctx
+func useContext(ctx clusterContext) {
+	*serverURL = ctx.Server
+	*caFile = ctx.CertificateAuthority
+	*certFile = ctx.ClientCertificate
+	*keyFile = ctx.ClientKey
+	*defaultNamespace = ctx.Namespace
+	connect()
+}
+
+// getFromContexts gets a resource from every context and prints the
+// results as one list, with a CLUSTER column in front. As JSON, each object
+// carries its context in the minik8s.io/cluster annotation.
+func getFromContexts(resource, name, namespace, output, sortBy string) {
+	var items []interface{}
+	failed := false
+	for _, ctx := range clusterContexts {
+		useContext(ctx)
+		endpoint, err := resourceURL(resource, name, namespace)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		body, err := fetchResource(endpoint)
+		if err != nil {
+			fmt.Printf("Error getting resource from %s: %v\n", ctx.Name, err)
+			failed = true
+			continue
+		}
+		objs, err := responseItems(body)
+		if err != nil {
+			fmt.Printf("Error getting resource from %s: %v\n", ctx.Name, err)
+			failed = true
+			continue
+		}
+		for _, obj := range objs {
+			setClusterAnnotation(obj, ctx.Name)
+		}
+		items = append(items, objs...)
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+
+	merged, err := json.Marshal(map[string]interface{}{"kind": "List", "apiVersion": "v1alpha1", "items": items})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	clusterColumn := fmt.Sprintf("CLUSTER:.metadata.annotations['%s']", clusterAnnotation)
+	switch {
+	case output == "":
+		output = customColumnsPrefix + clusterColumn + "," + defaultClusterColumns(resource)
+	case strings.HasPrefix(output, customColumnsPrefix):
+		output = customColumnsPrefix + clusterColumn + "," + strings.TrimPrefix(output, customColumnsPrefix)
+	}
+	if err := printOutput(merged, output, sortBy); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// defaultClusterColumns returns the columns getFromContexts prints after
+// CLUSTER when no -o is given
+func defaultClusterColumns(resource string) string {
+	switch strings.ToLower(resource) {
+	case "nodes", "namespaces", "certificatesigningrequests", "csr":
+		return "NAME:.metadata.name,CREATED:.metadata.creationTimestamp"
+	case "pods":
+		return "NAMESPACE:.metadata.namespace,NAME:.metadata.name,STATUS:.status.phase,NODE:.spec.nodeName"
+	default:
+		return "NAMESPACE:.metadata.namespace,NAME:.metadata.name,CREATED:.metadata.creationTimestamp"
+	}
+}
+
+// responseItems returns the items of a list response, or the object of any
+// other response
+func responseItems(body []byte) ([]interface{}, error) {
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if list, ok := obj.(map[string]interface{}); ok {
+		if items, ok := list["items"].([]interface{}); ok {
+			return items, nil
+		}
+	}
+	return []interface{}{obj}, nil
+}
+
+// setClusterAnnotation records the context obj came from in its annotations
+func setClusterAnnotation(obj interface{}, cluster string) {
+	object, ok := obj.(map[string]interface{})
+	if !ok {
+		return
+	}
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		object["metadata"] = metadata
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = make(map[string]interface{})
+		metadata["annotations"] = annotations
+	}
+	annotations[clusterAnnotation] = cluster
+}
+
+// applyToContexts applies manifests to every context, pruning as
+// applyManifests does in each, and prints the results with a CLUSTER column
+// in front. A context that fails doesn't stop the others.
+func applyToContexts(manifests []map[string]interface{}, labels map[string]string) {
+	width := len("CLUSTER")
+	for _, ctx := range clusterContexts {
+		if len(ctx.Name) > width {
+			width = len(ctx.Name)
+		}
+	}
+	fmt.Printf("%-*s   %s\n", width, "CLUSTER", "RESULT")
+
+	failed := false
+	for _, ctx := range clusterContexts {
+		useContext(ctx)
+		report := func(line string) { fmt.Printf("%-*s   %s\n", width, ctx.Name, line) }
+		if !applyManifests(copyManifests(manifests), labels, report) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// copyManifests deep-copies manifests, as applying one records it in its
+// own annotations
+func copyManifests(manifests []map[string]interface{}) []map[string]interface{} {
+	data, _ := json.Marshal(manifests)
+	var copied []map[string]interface{}
+	json.Unmarshal(data, &copied)
+	return copied
+}
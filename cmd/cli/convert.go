@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/convert"
+	"gopkg.in/yaml.v3"
+)
+
+// convertCommand moves workloads between minik8s and upstream Kubernetes:
+// export writes minik8s objects as Kubernetes YAML, and import turns
+// Kubernetes YAML into minik8s manifests, or applies them
+func convertCommand(args []string) {
+	if len(args) < 1 {
+		printConvertUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "export":
+		convertExport(args[1:])
+	case "import":
+		convertImport(args[1:])
+	default:
+		printConvertUsage()
+		os.Exit(1)
+	}
+}
+
+func printConvertUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  cli convert export <resource> [name] [-n namespace]  Write live objects as Kubernetes YAML")
+	fmt.Println("  cli convert export -f <filename|dir>                 Write minik8s manifests as Kubernetes YAML")
+	fmt.Println("  cli convert import -f <filename> [--apply]           Turn Kubernetes YAML into minik8s manifests")
+}
+
+// convertExport prints minik8s objects, read from the API server or from
+// manifests, as a multi-document Kubernetes YAML stream
+func convertExport(args []string) {
+	fs := flag.NewFlagSet("convert export", flag.ExitOnError)
+	filename := fs.String("f", "", "File with JSON manifests to export, or a directory of them")
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the objects to export")
+
+	positional, _ := parseInterspersed(fs, args)
+	var objs []map[string]interface{}
+	var err error
+	switch {
+	case *filename != "" && len(positional) == 0:
+		objs, err = readManifests(*filename)
+	case *filename == "" && (len(positional) == 1 || len(positional) == 2):
+		objs, err = getLiveObjects(positional[0], positional[1:], *namespace)
+	default:
+		printConvertUsage()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, obj := range objs {
+		exported, warnings, err := convert.Export(obj)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		name := manifestName(obj)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", name, warning)
+		}
+		if err := encoder.Encode(exported); err != nil {
+			fmt.Printf("Error encoding %s: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+	encoder.Close()
+	os.Stdout.Write(out.Bytes())
+}
+
+// getLiveObjects fetches the named objects of resource, or all of them in
+// namespace when no name is given
+func getLiveObjects(resource string, names []string, namespace string) ([]map[string]interface{}, error) {
+	var collection string
+	switch strings.ToLower(resource) {
+	case "pods", "deployments", "replicasets", "jobs", "cronjobs", "secrets":
+		collection = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/%s", *serverURL, namespace, strings.ToLower(resource))
+	case "nodes", "namespaces":
+		collection = fmt.Sprintf("%s/api/v1alpha1/%s", *serverURL, strings.ToLower(resource))
+	default:
+		return nil, fmt.Errorf("unsupported resource: %s", resource)
+	}
+
+	if len(names) == 1 {
+		obj, found, err := getLiveObject(collection + "/" + names[0])
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("%s %s not found", resource, names[0])
+		}
+		return []map[string]interface{}{obj}, nil
+	}
+
+	resp, err := client.Get(context.Background(), collection)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s - %s", resp.Status, string(body))
+	}
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return list.Items, nil
+}
+
+// convertImport turns the Kubernetes YAML documents of a file into minik8s
+// manifests and prints them, or applies them with --apply. Nothing is
+// applied unless every document converts.
+func convertImport(args []string) {
+	fs := flag.NewFlagSet("convert import", flag.ExitOnError)
+	filename := fs.String("f", "", "File with Kubernetes YAML manifests")
+	applyObjects := fs.Bool("apply", false, "Apply the converted manifests instead of printing them")
+
+	positional, _ := parseInterspersed(fs, args)
+	if *filename == "" || len(positional) != 0 {
+		printConvertUsage()
+		os.Exit(1)
+	}
+
+	docs, err := readYAMLDocuments(*filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var manifests []map[string]interface{}
+	for i, doc := range docs {
+		imported, warnings, err := convert.Import(doc)
+		if err != nil {
+			fmt.Printf("Error: document %d (%s): %v\n", i+1, manifestName(doc), err)
+			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", manifestName(doc), warning)
+		}
+		manifests = append(manifests, imported)
+	}
+
+	if !*applyObjects {
+		for _, manifest := range manifests {
+			data, _ := json.MarshalIndent(manifest, "", "  ")
+			fmt.Println(string(data))
+		}
+		return
+	}
+	failed := false
+	for _, manifest := range manifests {
+		result, err := applyManifest(manifest)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Println(result)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readYAMLDocuments reads the non-empty documents of a YAML stream, as
+// JSON-compatible objects
+func readYAMLDocuments(filename string) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var docs []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			return docs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// manifestName names a manifest in messages, as kind/name
+func manifestName(manifest map[string]interface{}) string {
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return kind + "/" + name
+}
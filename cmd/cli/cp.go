@@ -0,0 +1,260 @@
+package main
+
+import (
+	"archive/tar"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+)
+
+// podPath is a file location inside a pod, written as [namespace/]pod:path
+type podPath struct {
+	Namespace string
+	Pod       string
+	Path      string
+}
+
+// parsePodPath parses a [namespace/]pod:path argument, reporting false for local paths
+func parsePodPath(arg, defaultNamespace string) (*podPath, bool) {
+	if strings.HasPrefix(arg, "/") || strings.HasPrefix(arg, ".") {
+		return nil, false
+	}
+
+	ref, remotePath, ok := strings.Cut(arg, ":")
+	if !ok || ref == "" {
+		return nil, false
+	}
+
+	namespace, pod, ok := strings.Cut(ref, "/")
+	if !ok {
+		namespace, pod = defaultNamespace, ref
+	}
+	if remotePath == "" {
+		remotePath = "."
+	}
+	return &podPath{Namespace: namespace, Pod: pod, Path: remotePath}, true
+}
+
+// cpCommand copies files between the local machine and a container by piping
+// a tar archive through exec
+func cpCommand(args []string) {
+	fs := flag.NewFlagSet("cp", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the pod")
+	container := fs.String("c", "", "Container name (defaults to the pod's first container)")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 2 {
+		fmt.Println("Usage: cli cp [-n namespace] [-c container] <src> <dst>")
+		fmt.Println("  One of src and dst must be [namespace/]pod:path")
+		os.Exit(1)
+	}
+
+	src, srcRemote := parsePodPath(positional[0], *namespace)
+	dst, dstRemote := parsePodPath(positional[1], *namespace)
+
+	var err error
+	switch {
+	case !srcRemote && dstRemote:
+		err = copyToPod(positional[0], dst, *container)
+	case srcRemote && !dstRemote:
+		err = copyFromPod(src, positional[1], *container)
+	default:
+		err = fmt.Errorf("exactly one of src and dst must be a pod path")
+	}
+
+	if err != nil {
+		fmt.Printf("Error copying files: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// copyToPod uploads a local file or directory. A destination ending in "/"
+// is a directory to copy into; otherwise it names the copy itself.
+func copyToPod(localPath string, dst *podPath, container string) error {
+	if _, err := os.Lstat(localPath); err != nil {
+		return err
+	}
+
+	destDir, destName := path.Split(dst.Path)
+	if destName == "" || destName == "." {
+		destName = filepath.Base(localPath)
+	}
+	if destDir == "" {
+		destDir = "."
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTar(writer, localPath, destName))
+	}()
+	defer reader.Close()
+
+	opts := &remotecommand.Options{
+		Container: container,
+		Command:   []string{"tar", "xmf", "-", "-C", destDir},
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+	}
+	exitCode, err := streamPod(dst.Namespace, dst.Pod, "exec", opts, remotecommand.Streams{
+		Stdin:  reader,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("tar exited with code %d in the container", exitCode)
+	}
+	return nil
+}
+
+// copyFromPod downloads a file or directory. An existing local directory
+// receives the copy under the source's name.
+func copyFromPod(src *podPath, localPath, container string) error {
+	srcDir, srcName := path.Split(path.Clean(src.Path))
+	if srcName == "" || srcName == "." || srcName == ".." {
+		return fmt.Errorf("cannot copy %q, name a file or directory", src.Path)
+	}
+	if srcDir == "" {
+		srcDir = "."
+	}
+
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, srcName)
+	}
+
+	reader, writer := io.Pipe()
+	extracted := make(chan error, 1)
+	go func() {
+		err := readTar(reader, srcName, localPath)
+		// Drain so the stream doesn't stall if the archive has trailing data
+		io.Copy(io.Discard, reader)
+		extracted <- err
+	}()
+
+	opts := &remotecommand.Options{
+		Container: container,
+		Command:   []string{"tar", "cf", "-", "-C", srcDir, srcName},
+		Stdout:    true,
+		Stderr:    true,
+	}
+	exitCode, err := streamPod(src.Namespace, src.Pod, "exec", opts, remotecommand.Streams{
+		Stdout: writer,
+		Stderr: os.Stderr,
+	})
+	writer.Close()
+	if extractErr := <-extracted; err == nil {
+		err = extractErr
+	}
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("tar exited with code %d in the container", exitCode)
+	}
+	return nil
+}
+
+// writeTar archives localPath with its entries renamed under name
+func writeTar(w io.Writer, localPath, name string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// readTar extracts an archive whose entries live under name into localPath.
+// Entries outside name are rejected and links are skipped.
+func readTar(r io.Reader, name, localPath string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		entry := path.Clean(header.Name)
+		rel := strings.TrimPrefix(entry, name)
+		if entry != name && !strings.HasPrefix(entry, name+"/") {
+			return fmt.Errorf("unexpected archive entry %q", header.Name)
+		}
+		target := filepath.Join(localPath, filepath.FromSlash(rel))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode).Perm()|0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Skipping %s: unsupported file type\n", header.Name)
+		}
+	}
+}
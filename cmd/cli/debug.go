@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/httpclient"
+	"github.com/minik8s/minik8s/pkg/profiling"
+)
+
+const debugProfileUsage = "Usage: cli debug profile component=<apiserver|scheduler|controller-manager|nodeagent> [--type cpu] [--seconds 30] [--address host:port] [-o file]"
+
+// debugCommand runs troubleshooting subcommands
+func debugCommand(args []string) {
+	if len(args) < 1 || args[0] != "profile" {
+		fmt.Println(debugProfileUsage)
+		os.Exit(1)
+	}
+	profileCommand(args[1:])
+}
+
+// profileCommand fetches a pprof profile from a component started with
+// --enable-pprof and saves it for offline analysis with go tool pprof
+func profileCommand(args []string) {
+	fs := flag.NewFlagSet("debug profile", flag.ExitOnError)
+	profileType := fs.String("type", "cpu", "Profile to fetch: cpu, trace, or a runtime profile such as heap, goroutine, allocs, block or mutex")
+	seconds := fs.Int("seconds", 30, "How long to record cpu profiles and traces")
+	address := fs.String("address", "", "host:port serving the component's profiles (defaults depend on the component)")
+	output := fs.String("o", "", "File to write the profile to (defaults to <component>-<type>-<time>.pprof)")
+
+	positional, _ := parseInterspersed(fs, args)
+	params := make(map[string]string)
+	for _, arg := range positional {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key != "component" {
+			fmt.Println(debugProfileUsage)
+			os.Exit(1)
+		}
+		params[key] = value
+	}
+	component := params["component"]
+	if component == "" || *seconds <= 0 {
+		fmt.Println(debugProfileUsage)
+		os.Exit(1)
+	}
+
+	baseURL, err := profileBaseURL(component, *address)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// cpu profiles and traces are recorded for a while; other profiles are
+	// snapshots of the runtime's state
+	recorded := *profileType == "cpu" || *profileType == "trace"
+	path := *profileType
+	if path == "cpu" {
+		path = "profile"
+	}
+	endpoint := baseURL + profiling.PathPrefix + path
+	timeout := *requestTimeout
+	if recorded {
+		endpoint += fmt.Sprintf("?seconds=%d", *seconds)
+		timeout += time.Duration(*seconds) * time.Second
+	}
+	if *requestTimeout == 0 {
+		timeout = -1
+	}
+
+	// Recording again on a retry would only make things slower
+	profileConfig := &httpclient.Config{Timeout: timeout, MaxRetries: -1}
+	if component == "apiserver" && *address == "" && serverSocket != "" {
+		profileConfig.Transport = httpclient.UnixSocketTransport(serverSocket)
+	}
+	profileClient := httpclient.New(profileConfig)
+
+	if recorded {
+		fmt.Printf("Recording %s profile of %s for %ds...\n", *profileType, component, *seconds)
+	}
+	resp, err := profileClient.Get(context.Background(), endpoint)
+	if err != nil {
+		fmt.Printf("Error fetching profile: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error fetching profile: %s - %s\n", resp.Status, strings.TrimSpace(string(body)))
+		os.Exit(1)
+	}
+
+	filename := *output
+	if filename == "" {
+		filename = fmt.Sprintf("%s-%s-%s.pprof", component, *profileType, time.Now().Format("20060102-150405"))
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		fmt.Printf("Error creating %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+	n, err := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("Error writing %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Wrote %d bytes to %s\n", n, filename)
+	if *profileType == "trace" {
+		fmt.Printf("Inspect it with: go tool trace %s\n", filename)
+	} else {
+		fmt.Printf("Inspect it with: go tool pprof %s\n", filename)
+	}
+}
+
+// profileBaseURL returns the URL a component serves its profiles under. The
+// scheduler runs inside the controller manager and shares its address; node
+// agents serve theirs on localhost only, so run this on the node.
+func profileBaseURL(component, address string) (string, error) {
+	switch component {
+	case "apiserver":
+		if address != "" {
+			return "http://" + address, nil
+		}
+		return strings.TrimSuffix(*serverURL, "/"), nil
+	case "scheduler", "controller-manager":
+		if address == "" {
+			address = profiling.DefaultControllerManagerAddress
+		}
+		return "http://" + address, nil
+	case "nodeagent":
+		if address == "" {
+			address = profiling.DefaultNodeAgentAddress
+		}
+		return "http://" + address, nil
+	default:
+		return "", fmt.Errorf("unknown component %q", component)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+)
+
+// execCommand runs a command in a container of a pod
+func execCommand(args []string) {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the pod")
+	container := fs.String("c", "", "Container name (defaults to the pod's first container)")
+	stdin := fs.Bool("i", false, "Pass stdin to the container")
+	tty := fs.Bool("t", false, "Allocate a terminal for the command")
+
+	positional, command := parseInterspersed(fs, args)
+	if len(positional) != 1 || len(command) == 0 {
+		fmt.Println("Usage: cli exec <pod> [-n namespace] [-c container] [-it] -- <command> [args...]")
+		os.Exit(1)
+	}
+
+	opts := &remotecommand.Options{
+		Container: *container,
+		Command:   command,
+		Stdin:     *stdin,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       *tty,
+	}
+
+	exitCode, err := runInteractive(*namespace, positional[0], "exec", opts)
+	if err != nil {
+		fmt.Printf("Error executing command: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(exitCode)
+}
+
+// attachCommand connects to the main process of a running container
+func attachCommand(args []string) {
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the pod")
+	container := fs.String("c", "", "Container name (defaults to the pod's first container)")
+	stdin := fs.Bool("i", false, "Pass stdin to the container")
+	tty := fs.Bool("t", false, "Stdin is a terminal, requires a container started with tty")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 1 {
+		fmt.Println("Usage: cli attach <pod> [-n namespace] [-c container] [-it]")
+		os.Exit(1)
+	}
+
+	opts := &remotecommand.Options{
+		Container: *container,
+		Stdin:     *stdin,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       *tty,
+	}
+
+	exitCode, err := runInteractive(*namespace, positional[0], "attach", opts)
+	if err != nil {
+		fmt.Printf("Error attaching to container: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(exitCode)
+}
+
+// runInteractive streams a pod subresource to the local terminal, switching
+// it to raw mode for TTY sessions
+func runInteractive(namespace, pod, subresource string, opts *remotecommand.Options) (int, error) {
+	streams := remotecommand.Streams{Stdout: os.Stdout, Stderr: os.Stderr}
+	if opts.Stdin {
+		streams.Stdin = os.Stdin
+	}
+
+	if opts.TTY {
+		fd := int(os.Stdin.Fd())
+		if !opts.Stdin || !isTerminal(fd) {
+			fmt.Fprintln(os.Stderr, "Unable to use a TTY - input is not a terminal or -i was not given")
+			opts.TTY = false
+		} else {
+			restore, err := makeRaw(fd)
+			if err != nil {
+				return -1, fmt.Errorf("failed to set up terminal: %w", err)
+			}
+			defer restore()
+			streams.Stderr = nil
+			streams.Resize = watchResize(fd)
+		}
+	}
+
+	return streamPod(namespace, pod, subresource, opts, streams)
+}
+
+// streamPod streams a pod subresource such as exec through the API server
+func streamPod(namespace, pod, subresource string, opts *remotecommand.Options, streams remotecommand.Streams) (int, error) {
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods/%s/%s?%s",
+		*serverURL, url.PathEscape(namespace), url.PathEscape(pod), subresource, opts.Query().Encode())
+	if serverSocket != "" {
+		return remotecommand.StreamUnix(context.Background(), serverSocket, endpoint, nil, streams)
+	}
+	return remotecommand.Stream(context.Background(), endpoint, nil, streams)
+}
+
+// parseInterspersed parses flags that may appear between positional arguments.
+// Combined boolean flags such as -it are split, and everything after a "--"
+// separator is returned unparsed as the command.
+func parseInterspersed(fs *flag.FlagSet, args []string) (positional, command []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			args, command = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	var expanded []string
+	for _, arg := range args {
+		expanded = append(expanded, splitBoolFlags(fs, arg)...)
+	}
+	args = expanded
+
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional, command
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// splitBoolFlags expands an argument like -it into -i -t when every letter is a boolean flag
+func splitBoolFlags(fs *flag.FlagSet, arg string) []string {
+	letters := strings.TrimPrefix(arg, "-")
+	if len(letters) < 2 || len(letters) == len(arg) || strings.HasPrefix(letters, "-") {
+		return []string{arg}
+	}
+
+	var split []string
+	for _, letter := range letters {
+		f := fs.Lookup(string(letter))
+		if f == nil {
+			return []string{arg}
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			return []string{arg}
+		}
+		split = append(split, "-"+string(letter))
+	}
+	return split
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/httpclient"
+)
+
+// getResource prints one resource or a list of resources
+func getResource(args []string) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	namespace := fs.String("n", "", "Namespace of the pods (defaults to all pods, or default for a named pod)")
+	watch := fs.Bool("watch", false, "Keep a table of pods open and update it as they change")
+	fs.BoolVar(watch, "w", false, "Shorthand for --watch")
+	output := fs.String("o", "", "Output format: json or custom-columns=<header>:<path>,...")
+	sortBy := fs.String("sort-by", "", "JSONPath to sort list output by, e.g. .metadata.creationTimestamp")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) < 1 || len(positional) > 2 {
+		fmt.Println("Usage: cli get <resource> [name] [-n namespace] [--watch] [-o format] [--sort-by path]")
+		os.Exit(1)
+	}
+	resource := positional[0]
+	var name string
+	if len(positional) > 1 {
+		name = positional[1]
+	}
+
+	if *watch {
+		if strings.ToLower(resource) != "pods" || name != "" {
+			fmt.Println("Error: --watch is only supported when listing pods")
+			os.Exit(1)
+		}
+		if *output != "" || *sortBy != "" {
+			fmt.Println("Error: -o and --sort-by can't be combined with --watch")
+			os.Exit(1)
+		}
+		if len(clusterContexts) > 1 {
+			fmt.Println("Error: --watch follows a single context")
+			os.Exit(1)
+		}
+		ns := *namespace
+		if ns == "" {
+			ns = *defaultNamespace
+		}
+		watchPodTable(ns)
+		return
+	}
+
+	if len(clusterContexts) > 1 {
+		getFromContexts(resource, name, *namespace, *output, *sortBy)
+		return
+	}
+
+	endpoint, err := resourceURL(resource, name, *namespace)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	body, err := fetchResource(endpoint)
+	if err != nil {
+		fmt.Printf("Error getting resource: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "" && *sortBy == "" {
+		fmt.Println(string(body))
+		return
+	}
+	if err := printOutput(body, *output, *sortBy); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resourceURL returns the endpoint of a named object of resource, or of the
+// list of them. namespace is the -n flag, which lists that don't need one
+// leave empty to cover every namespace.
+func resourceURL(resource, name, namespace string) (string, error) {
+	var endpoint string
+	switch strings.ToLower(resource) {
+	case "pods":
+		if name != "" {
+			// Get specific pod
+			ns := namespace
+			if ns == "" {
+				ns = *defaultNamespace
+			}
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods/%s", *serverURL, ns, name)
+		} else if namespace != "" {
+			// List pods in one namespace
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods", *serverURL, namespace)
+		} else {
+			// List all pods
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/pods", *serverURL)
+		}
+	case "nodes":
+		if name != "" {
+			// Get specific node
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes/%s", *serverURL, name)
+		} else {
+			// List all nodes
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes", *serverURL)
+		}
+	case "namespaces":
+		endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces", *serverURL)
+		if name != "" {
+			endpoint += "/" + name
+		}
+	case "deployments", "replicasets", "jobs", "cronjobs", "secrets", "podgroups", "services", "endpoints":
+		ns := namespace
+		if ns == "" {
+			ns = *defaultNamespace
+		}
+		endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/%s", *serverURL, ns, strings.ToLower(resource))
+		if name != "" {
+			endpoint += "/" + name
+		}
+	case "certificatesigningrequests", "csr":
+		endpoint = fmt.Sprintf("%s/api/v1alpha1/certificatesigningrequests", *serverURL)
+		if name != "" {
+			endpoint += "/" + name
+		}
+	case "events":
+		if name != "" {
+			ns := namespace
+			if ns == "" {
+				ns = *defaultNamespace
+			}
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/events/%s", *serverURL, ns, name)
+		} else if namespace != "" {
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/events", *serverURL, namespace)
+		} else {
+			endpoint = fmt.Sprintf("%s/api/v1alpha1/events", *serverURL)
+		}
+	default:
+		return "", fmt.Errorf("unsupported resource: %s", resource)
+	}
+	return endpoint, nil
+}
+
+// fetchResource gets endpoint and returns the response body
+func fetchResource(endpoint string) ([]byte, error) {
+	resp, err := client.Get(context.Background(), endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s - %s", resp.Status, string(body))
+	}
+	return body, nil
+}
+
+// podWatchEvent is a watch event as streamed by the API server
+type podWatchEvent struct {
+	Type   string  `json:"type"`
+	Object api.Pod `json:"object"`
+}
+
+// resyncEvent is queued whenever the watch (re)connects. The server starts
+// every watch by replaying the existing pods, so the table starts over.
+const resyncEvent = "RESYNC"
+
+// watchPodTable prints pods in a namespace and keeps the output current as
+// watch events arrive. On a terminal the table is redrawn in place; otherwise
+// each change is appended as a new row.
+func watchPodTable(namespace string) {
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods?watch=true", *serverURL, namespace)
+
+	events := make(chan podWatchEvent)
+	var watchErr error
+	go func() {
+		defer close(events)
+		opts := &httpclient.WatchOptions{
+			OnConnect: func() { events <- podWatchEvent{Type: resyncEvent} },
+		}
+		watchErr = client.Watch(context.Background(), endpoint, opts, func(line []byte) {
+			var event podWatchEvent
+			if json.Unmarshal(line, &event) == nil {
+				events <- event
+			}
+		})
+	}()
+
+	if !isTerminal(int(os.Stdout.Fd())) {
+		appendPodRows(events)
+		fmt.Printf("Error watching pods: %v\n", watchErr)
+		os.Exit(1)
+	}
+
+	table := &podTable{pods: make(map[string]*api.Pod)}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	table.redraw()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				fmt.Printf("Error watching pods: %v\n", watchErr)
+				os.Exit(1)
+			}
+			if event.Type == resyncEvent {
+				table.pods = make(map[string]*api.Pod)
+				continue
+			}
+			pod := event.Object
+			if event.Type == "DELETED" {
+				delete(table.pods, pod.Name)
+			} else {
+				table.pods[pod.Name] = &pod
+			}
+			table.redraw()
+		case <-ticker.C:
+			// Keep the AGE column current
+			table.redraw()
+		}
+	}
+}
+
+// appendPodRows prints a row for every event, for output that isn't a terminal
+func appendPodRows(events <-chan podWatchEvent) {
+	const format = "%-40s %-7s %-12s %-8s %s\n"
+	fmt.Printf(format, "NAME", "READY", "STATUS", "RESTARTS", "AGE")
+	for event := range events {
+		if event.Type == resyncEvent {
+			continue
+		}
+		row := podRow(&event.Object)
+		if event.Type == "DELETED" {
+			row[2] = "Deleted"
+		}
+		fmt.Printf(format, row[0], row[1], row[2], row[3], row[4])
+	}
+}
+
+// podTable is a pod table redrawn in place on a terminal
+type podTable struct {
+	pods  map[string]*api.Pod
+	lines int
+}
+
+// redraw replaces the previously printed table with the current one
+func (t *podTable) redraw() {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREADY\tSTATUS\tRESTARTS\tAGE")
+
+	names := make([]string, 0, len(t.pods))
+	for name := range t.pods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, strings.Join(podRow(t.pods[name]), "\t"))
+	}
+	w.Flush()
+
+	// Move the cursor back over the old table and clear it
+	if t.lines > 0 {
+		fmt.Printf("\033[%dA\033[J", t.lines)
+	}
+	fmt.Print(sb.String())
+	t.lines = len(names) + 1
+}
+
+// podRow returns the NAME, READY, STATUS, RESTARTS and AGE columns for a pod
+func podRow(pod *api.Pod) []string {
+	ready := 0
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+
+	status := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		status = pod.Status.Reason
+	}
+	if status == "" {
+		status = "Unknown"
+	}
+	if pod.DeletionTimestamp != nil {
+		status = "Terminating"
+	}
+
+	return []string{
+		pod.Name,
+		fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+		status,
+		fmt.Sprintf("%d", restarts),
+		formatAge(pod.CreationTimestamp),
+	}
+}
+
+// formatAge renders the time since t the way kubectl does, e.g. 45s, 3m20s, 5h, 2d
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < 0:
+		return "0s"
+	case d < 2*time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < 10*time.Minute:
+		return fmt.Sprintf("%dm%ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 3*time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// historyCommand lists the recorded changes to an object, or prints the
+// object as it was after one of them
+func historyCommand(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the object")
+	revision := fs.Int("revision", 0, "Print the object as recorded by this change number")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 2 {
+		fmt.Println("Usage: cli history <pods|deployments|replicasets|nodes> <name> [--revision n] [-n namespace]")
+		os.Exit(1)
+	}
+	resource, name := positional[0], positional[1]
+
+	endpoint, err := objectURL(resource, *namespace, name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var list api.HistoryList
+	if err := getJSON(endpoint+"/history", &list); err != nil {
+		fmt.Printf("Error getting history of %s %s: %v\n", resource, name, err)
+		os.Exit(1)
+	}
+
+	if *revision != 0 {
+		if *revision < 1 || *revision > len(list.Items) {
+			fmt.Printf("Error: %s %s has no change %d\n", resource, name, *revision)
+			os.Exit(1)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, list.Items[*revision-1].Object, "", "  "); err != nil {
+			fmt.Printf("Error formatting object: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out.String())
+		return
+	}
+
+	if len(list.Items) == 0 {
+		fmt.Printf("No recorded changes to %s %s\n", resource, name)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tTIME\tOPERATION\tUSER\tRESOURCE VERSION\tREQUEST ID")
+	for i, entry := range list.Items {
+		user := entry.User
+		if user == "" {
+			user = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", strconv.Itoa(i+1), entry.Timestamp.Format(time.RFC3339),
+			entry.Operation, user, entry.ResourceVersion, entry.RequestID)
+	}
+	w.Flush()
+}
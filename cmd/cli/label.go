@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// mergePatchContentType is the patch format the API server accepts
+const mergePatchContentType = "application/merge-patch+json"
+
+// labelCommand sets or removes labels on an object
+func labelCommand(args []string) {
+	metadataCommand("label", "labels", "labeled", args)
+}
+
+// annotateCommand sets or removes annotations on an object
+func annotateCommand(args []string) {
+	metadataCommand("annotate", "annotations", "annotated", args)
+}
+
+// metadataCommand edits a string map in an object's metadata with a merge
+// patch. key=value sets a key and key- removes it. Changing the value of an
+// existing key requires --overwrite.
+func metadataCommand(command, field, verb string, args []string) {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the object")
+	overwrite := fs.Bool("overwrite", false, "Allow changing the value of existing keys")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) < 3 {
+		fmt.Printf("Usage: cli %s <resource> <name> key=value... key-... [--overwrite] [-n namespace]\n", command)
+		os.Exit(1)
+	}
+	resource, name := positional[0], positional[1]
+
+	endpoint, err := objectURL(resource, *namespace, name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes := make(map[string]interface{})
+	for _, arg := range positional[2:] {
+		if key, ok := strings.CutSuffix(arg, "-"); ok && !strings.Contains(arg, "=") {
+			changes[key] = nil
+			continue
+		}
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			fmt.Printf("Error: invalid %s %q, expected key=value or key-\n", strings.TrimSuffix(field, "s"), arg)
+			os.Exit(1)
+		}
+		changes[key] = value
+	}
+
+	if !*overwrite {
+		var current struct {
+			Metadata map[string]json.RawMessage `json:"metadata"`
+		}
+		if err := getJSON(endpoint, &current); err != nil {
+			fmt.Printf("Error getting %s %s: %v\n", resource, name, err)
+			os.Exit(1)
+		}
+		existing := make(map[string]string)
+		json.Unmarshal(current.Metadata[field], &existing)
+		for key, value := range changes {
+			if old, ok := existing[key]; ok && value != nil && old != value {
+				fmt.Printf("Error: %s %s already has %s %s=%s, use --overwrite to change it\n", resource, name, strings.TrimSuffix(field, "s"), key, old)
+				os.Exit(1)
+			}
+		}
+	}
+
+	patch := map[string]interface{}{"metadata": map[string]interface{}{field: changes}}
+	if err := patchObject(endpoint, patch); err != nil {
+		fmt.Printf("Error updating %s %s: %v\n", resource, name, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s %s %s\n", resource, name, verb)
+}
+
+// taintCommand adds or removes taints on a node. key[=value]:effect adds a
+// taint, key:effect- removes it and key- removes every taint with that key.
+func taintCommand(args []string) {
+	fs := flag.NewFlagSet("taint", flag.ExitOnError)
+	overwrite := fs.Bool("overwrite", false, "Allow changing the value of an existing taint")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) < 3 || (positional[0] != "node" && positional[0] != "nodes") {
+		fmt.Println("Usage: cli taint node <name> key[=value]:effect... key[:effect]-... [--overwrite]")
+		os.Exit(1)
+	}
+	name := positional[1]
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/nodes/%s", *serverURL, name)
+
+	var node api.Node
+	if err := getJSON(endpoint, &node); err != nil {
+		fmt.Printf("Error getting node %s: %v\n", name, err)
+		os.Exit(1)
+	}
+
+	taints := node.Spec.Taints
+	for _, arg := range positional[2:] {
+		if spec, ok := strings.CutSuffix(arg, "-"); ok {
+			key, effect, _ := strings.Cut(spec, ":")
+			key, _, _ = strings.Cut(key, "=")
+			kept := taints[:0:0]
+			removed := false
+			for _, taint := range taints {
+				if taint.Key == key && (effect == "" || taint.Effect == effect) {
+					removed = true
+					continue
+				}
+				kept = append(kept, taint)
+			}
+			if !removed {
+				fmt.Printf("Error: node %s has no taint %s\n", name, spec)
+				os.Exit(1)
+			}
+			taints = kept
+			continue
+		}
+
+		taint, err := api.ParseTaint(arg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		replaced := false
+		for i, existing := range taints {
+			if existing.Key != taint.Key || existing.Effect != taint.Effect {
+				continue
+			}
+			if existing.Value != taint.Value && !*overwrite {
+				fmt.Printf("Error: node %s already has taint %s, use --overwrite to change it\n", name, existing)
+				os.Exit(1)
+			}
+			taints[i] = taint
+			replaced = true
+		}
+		if !replaced {
+			taints = append(taints, taint)
+		}
+	}
+
+	// Lists can't be merged, so the patch carries the complete set of taints
+	var value interface{} = taints
+	if len(taints) == 0 {
+		value = nil
+	}
+	patch := map[string]interface{}{"spec": map[string]interface{}{"taints": value}}
+	if err := patchObject(endpoint, patch); err != nil {
+		fmt.Printf("Error updating node %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	fmt.Printf("node %s tainted\n", name)
+}
+
+// objectURL returns the endpoint of a named object of a resource type
+func objectURL(resource, namespace, name string) (string, error) {
+	switch strings.ToLower(resource) {
+	case "pod", "pods":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods/%s", *serverURL, namespace, name), nil
+	case "node", "nodes":
+		return fmt.Sprintf("%s/api/v1alpha1/nodes/%s", *serverURL, name), nil
+	case "namespace", "namespaces":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s", *serverURL, name), nil
+	case "deployment", "deployments":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/deployments/%s", *serverURL, namespace, name), nil
+	case "replicaset", "replicasets":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/replicasets/%s", *serverURL, namespace, name), nil
+	default:
+		return "", fmt.Errorf("unsupported resource: %s", resource)
+	}
+}
+
+// getJSON fetches endpoint and decodes the response into v
+func getJSON(endpoint string, v interface{}) error {
+	resp, err := client.Get(context.Background(), endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// patchObject sends a JSON merge patch to endpoint
+func patchObject(endpoint string, patch interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to encode patch: %w", err)
+	}
+
+	resp, err := client.Patch(context.Background(), endpoint, mergePatchContentType, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
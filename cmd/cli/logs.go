@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// logsCommand prints the log of a container of a pod, or with --previous
+// the log of the container it replaced
+func logsCommand(args []string) {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the pod")
+	container := fs.String("c", "", "Container name (defaults to the pod's first container)")
+	previous := fs.Bool("previous", false, "Print the log of the container before it was last recreated")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 1 {
+		fmt.Println("Usage: cli logs <pod> [-n namespace] [-c container] [--previous]")
+		os.Exit(1)
+	}
+
+	query := url.Values{}
+	if *container != "" {
+		query.Set("container", *container)
+	}
+	if *previous {
+		query.Set("previous", "true")
+	}
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods/%s/log", *serverURL, *namespace, positional[0])
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	resp, err := client.Get(context.Background(), endpoint)
+	if err != nil {
+		fmt.Printf("Error getting logs: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error getting logs: %s - %s\n", resp.Status, strings.TrimSpace(string(body)))
+		os.Exit(1)
+	}
+	io.Copy(os.Stdout, resp.Body)
+}
@@ -1,276 +1,561 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/apply"
+	"github.com/minik8s/minik8s/pkg/httpclient"
 )
 
 var (
-	serverURL = flag.String("server", "http://localhost:8080", "API server URL")
+	serverURL        = flag.String("server", "http://localhost:8080", "API server URL, or unix:///path/to/socket")
+	requestTimeout   = flag.Duration("request-timeout", httpclient.DefaultTimeout, "Timeout for each API request attempt (0 disables)")
+	retries          = flag.Int("retries", httpclient.DefaultMaxRetries, "Retries for idempotent API requests on connection errors and 5xx responses")
+	caFile           = flag.String("certificate-authority", "", "CA bundle to verify an https API server with")
+	certFile         = flag.String("client-certificate", "", "Client certificate to authenticate to an https API server with")
+	keyFile          = flag.String("client-key", "", "Private key of --client-certificate")
+	defaultNamespace = flag.String("namespace", namespaceFromEnv(), "Namespace of objects when neither -n nor their manifest names one (default $MINIK8S_NAMESPACE, then default)")
+	configFile       = flag.String("config", configFromEnv(), "File naming the clusters --contexts selects (default $MINIK8S_CONFIG, then ~/.minik8s/config.json)")
+	contextNames     = flag.String("contexts", "", "Comma-separated contexts of --config to talk to instead of --server; get and apply accept several")
 )
 
+// namespaceFromEnv returns the default of --namespace
+func namespaceFromEnv() string {
+	if ns := os.Getenv("MINIK8S_NAMESPACE"); ns != "" {
+		return ns
+	}
+	return "default"
+}
+
+// client talks to the API server
+var client *httpclient.Client
+
+// serverSocket is the unix domain socket of a unix:// --server. Requests are
+// then made to a placeholder http URL, whose host the transport ignores.
+var serverSocket string
+
 func main() {
 	flag.Parse()
 
-	if len(os.Args) < 2 {
+	args := flag.Args()
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
+	command, args := args[0], args[1:]
 
-	switch command {
-	case "create":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: cli create -f <filename>")
+	if *contextNames != "" {
+		contexts, err := loadContexts(*configFile, splitList(*contextNames))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		createResource()
+		if len(contexts) > 1 && command != "get" && command != "apply" {
+			fmt.Printf("Error: only get and apply can use several contexts, %s takes one\n", command)
+			os.Exit(1)
+		}
+		clusterContexts = contexts
+		useContext(contexts[0])
+	} else {
+		connect()
+	}
+
+	switch command {
+	case "apply":
+		applyCommand(args)
+	case "validate":
+		validateCommand(args)
+	case "render":
+		renderCommand(args)
+	case "convert":
+		convertCommand(args)
+	case "create":
+		createResource(args)
 	case "get":
-		if len(os.Args) < 3 {
+		if len(args) < 1 {
 			fmt.Println("Usage: cli get <resource> [name]")
 			os.Exit(1)
 		}
-		getResource()
+		getResource(args)
 	case "delete":
-		if len(os.Args) < 4 {
-			fmt.Println("Usage: cli delete <resource> <name>")
-			os.Exit(1)
-		}
-		deleteResource()
+		deleteResource(args)
 	case "watch":
-		if len(os.Args) < 4 {
-			fmt.Println("Usage: cli watch <resource> <name>")
-			os.Exit(1)
-		}
-		watchResource()
+		watchCommand(args)
+	case "exec":
+		execCommand(args)
+	case "attach":
+		attachCommand(args)
+	case "logs":
+		logsCommand(args)
+	case "cp":
+		cpCommand(args)
+	case "scale":
+		scaleCommand(args)
+	case "rollout":
+		rolloutCommand(args)
+	case "label":
+		labelCommand(args)
+	case "annotate":
+		annotateCommand(args)
+	case "taint":
+		taintCommand(args)
+	case "history":
+		historyCommand(args)
+	case "schedule":
+		scheduleCommand(args)
+	case "debug":
+		debugCommand(args)
+	case "certificate":
+		certificateCommand(args)
+	case "admin":
+		adminCommand(args)
+	case "version":
+		versionCommand(args)
+	case "cluster-info":
+		clusterInfoCommand(args)
+	case "capacity":
+		capacityCommand(args)
 	default:
 		printUsage()
 		os.Exit(1)
 	}
 }
 
+// connect creates the API client for --server
+func connect() {
+	serverSocket = ""
+	if socket, ok := httpclient.UnixSocketPath(*serverURL); ok {
+		serverSocket = socket
+		*serverURL = "http://localhost"
+	}
+	client = newClient()
+}
+
+// newClient creates an API client honoring the global flags. Watches made
+// through it aren't bound by the request timeout and reconnect on their own.
+func newClient() *httpclient.Client {
+	config := &httpclient.Config{Timeout: *requestTimeout, MaxRetries: *retries, WarningHandler: printWarning}
+	if config.Timeout == 0 {
+		config.Timeout = -1
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = -1
+	}
+	if serverSocket != "" {
+		if *caFile != "" || *certFile != "" {
+			fmt.Println("Error: TLS flags can't be used with a unix:// server")
+			os.Exit(1)
+		}
+		config.Transport = httpclient.UnixSocketTransport(serverSocket)
+	} else if *caFile != "" || *certFile != "" {
+		transport, err := tlsTransport(*caFile, *certFile, *keyFile)
+		if err != nil {
+			fmt.Printf("Error loading TLS credentials: %v\n", err)
+			os.Exit(1)
+		}
+		config.Transport = transport
+	}
+	return httpclient.New(config)
+}
+
+// serverName returns the API server as given by --server
+func serverName() string {
+	if serverSocket != "" {
+		return httpclient.UnixSocketScheme + serverSocket
+	}
+	return *serverURL
+}
+
+// printedWarnings holds the warnings already printed, so commands making
+// several requests print each one once
+var printedWarnings = make(map[string]bool)
+
+// printWarning prints a warning from the API server to stderr, keeping
+// stdout parseable
+func printWarning(message string) {
+	if printedWarnings[message] {
+		return
+	}
+	printedWarnings[message] = true
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+}
+
+// tlsTransport returns a transport trusting the CA bundle in caPath, if
+// set, and presenting the client certificate in certPath, if set
+func tlsTransport(caPath, certPath, keyPath string) (http.RoundTripper, error) {
+	config := &tls.Config{}
+	if caPath != "" {
+		data, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", caPath)
+		}
+		config.RootCAs = pool
+	}
+	if certPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return transport, nil
+}
+
 func printUsage() {
 	fmt.Println("Minik8s CLI")
 	fmt.Println("Usage:")
-	fmt.Println("  cli create -f <filename>     Create a resource from file")
-	fmt.Println("  cli get <resource> [name]    Get resources")
-	fmt.Println("  cli delete <resource> <name> Delete a resource")
-	fmt.Println("  cli watch <resource> <name>  Watch a resource")
+	fmt.Println("  cli create -f <filename|dir> Create resources from files, several at a time (--parallel)")
+	fmt.Println("  cli apply -f <filename|dir>  Create or update resources from files (--prune -l deletes the rest)")
+	fmt.Println("  cli validate -f <filename>   Check manifests offline, with best-practice warnings (--strict fails on them)")
+	fmt.Println("  cli render <template>... -f values.json  Render templated manifests, or apply them as a release (--release name --apply)")
+	fmt.Println("  cli convert export|import    Convert workloads to or from upstream Kubernetes YAML")
+	fmt.Println("  cli get <resource> [name]    Get resources (pods support --watch)")
+	fmt.Println("  cli delete <resource> <name>... Delete resources, or those matching -l (--wait until gone)")
+	fmt.Println("  cli watch <resource> <name>  Watch a resource (--output-diff shows changed fields)")
+	fmt.Println("  cli exec <pod> -- <command>  Run a command in a container")
+	fmt.Println("  cli attach <pod> [-it]       Attach to a running container")
+	fmt.Println("  cli logs <pod> [-c container]  Print a container's log (--previous for the one it replaced)")
+	fmt.Println("  cli cp <src> <dst>           Copy files to or from a container")
+	fmt.Println("  cli scale <resource> <name>  Scale a deployment or replicaset")
+	fmt.Println("  cli rollout <action> <name>  Pause, resume or promote a deployment, or wait for its rollout (status)")
+	fmt.Println("  cli label <resource> <name> key=value  Set or remove (key-) labels")
+	fmt.Println("  cli annotate <resource> <name> key=value  Set or remove (key-) annotations")
+	fmt.Println("  cli taint node <name> key=value:effect  Add or remove (key:effect-) node taints")
+	fmt.Println("  cli history <resource> <name>  Show recent changes to an object and who made them")
+	fmt.Println("  cli schedule --dry-run -f <filename>  Show which node a pod would be scheduled to, and why")
+	fmt.Println("  cli debug profile component=<component>  Save a pprof profile of a component")
+	fmt.Println("  cli certificate request <name>  Request a client certificate, keeping its key locally")
+	fmt.Println("  cli certificate approve|deny <name>...  Approve or deny certificate signing requests")
+	fmt.Println("  cli admin init               Generate cluster certificates and a node bootstrap token")
+	fmt.Println("  cli version [--client]       Print the CLI, API server and component versions")
+	fmt.Println("  cli cluster-info             Show the API server address and control plane health")
+	fmt.Println("  cli capacity                 Show the CPU and memory nodes offer, and how much pods request and use")
 	fmt.Println("")
-	fmt.Println("Resources: pods, nodes")
+	fmt.Println("Global flags: --server, --namespace, --certificate-authority, --client-certificate, --client-key, --request-timeout, --retries, --contexts, --config")
+	fmt.Println("Resources: pods, nodes, namespaces, deployments, replicasets, jobs, cronjobs, secrets, podgroups, services, endpoints, events, certificatesigningrequests (csr)")
 	fmt.Println("Examples:")
 	fmt.Println("  cli create -f pod.yaml")
+	fmt.Println("  cli apply -f deployment.json")
+	fmt.Println("  cli apply -f manifests/ --prune -l app=shop")
+	fmt.Println("  cli validate -f deployment.json --strict")
+	fmt.Println("  cli render ./chart -f values.json --set image.tag=1.27 --release web --apply")
+	fmt.Println("  cli convert export deployments web > web.k8s.yaml")
+	fmt.Println("  cli convert import -f k8s.yaml --apply")
 	fmt.Println("  cli get pods")
 	fmt.Println("  cli get pods my-pod")
+	fmt.Println("  cli get pods --watch")
+	fmt.Println("  cli watch pod my-pod --output-diff")
+	fmt.Println("  cli get pods --sort-by=.metadata.creationTimestamp -o custom-columns=NAME:.metadata.name,NODE:.spec.nodeName")
+	fmt.Println("  cli --contexts lab1,lab2 get pods -n course-a")
 	fmt.Println("  cli delete pods my-pod")
+	fmt.Println("  cli delete pods -l app=test --wait")
+	fmt.Println("  cli create -f manifests/ --parallel 10")
+	fmt.Println("  cli exec my-pod -c app -- ls /data")
+	fmt.Println("  cli exec my-pod -it -- sh")
+	fmt.Println("  cli attach my-pod -c app -it")
+	fmt.Println("  cli logs my-pod -c app --previous")
+	fmt.Println("  cli cp ./seed.sql my-pod:/tmp/seed.sql")
+	fmt.Println("  cli cp my-pod:/var/log/app ./app-logs")
+	fmt.Println("  cli scale deployments nginx-deployment --replicas 5")
+	fmt.Println("  cli rollout pause nginx-deployment")
+	fmt.Println("  cli rollout status nginx-deployment --timeout 5m")
+	fmt.Println("  cli rollout promote nginx-deployment")
+	fmt.Println("  cli label node worker-1 pool=gpu")
+	fmt.Println("  cli taint node worker-1 dedicated=gpu:NoSchedule")
+	fmt.Println("  cli history pod my-pod --revision 2")
+	fmt.Println("  cli schedule --dry-run -f pod.json")
+	fmt.Println("  cli debug profile component=scheduler --seconds=30")
+	fmt.Println("  cli certificate request alice --organizations devs --wait")
+	fmt.Println("  cli certificate approve alice")
+	fmt.Println("  cli admin init --hosts master.lab,10.0.0.5")
+	fmt.Println("  cli cluster-info")
+	fmt.Println("  cli capacity")
 }
 
-func createResource() {
-	var filename string
-	for i, arg := range os.Args {
-		if arg == "-f" && i+1 < len(os.Args) {
-			filename = os.Args[i+1]
-			break
-		}
-	}
+// createResource creates the objects described by the manifests in a file
+// or directory, several at a time
+func createResource(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	filename := fs.String("f", "", "File with JSON manifests to create, or a directory of them")
+	parallel := fs.Int("parallel", defaultBulkParallelism, "How many objects to create at once")
 
-	if filename == "" {
-		fmt.Println("Error: -f flag is required")
+	positional, _ := parseInterspersed(fs, args)
+	if *filename == "" || len(positional) != 0 {
+		fmt.Println("Usage: cli create -f <filename|dir> [--parallel n]")
 		os.Exit(1)
 	}
 
-	// Read file
-	data, err := os.ReadFile(filename)
+	manifests, err := readManifests(*filename)
 	if err != nil {
-		fmt.Printf("Error reading file: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Parse YAML/JSON
-	var obj map[string]interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
-		fmt.Printf("Error parsing file: %v\n", err)
+	if len(manifests) == 0 {
+		fmt.Printf("Error: no manifests found in %s\n", *filename)
 		os.Exit(1)
 	}
 
-	kind, ok := obj["kind"].(string)
-	if !ok {
-		fmt.Println("Error: kind field is required")
-		os.Exit(1)
+	tasks := make([]bulkTask, 0, len(manifests))
+	for _, manifest := range manifests {
+		tasks = append(tasks, bulkTask{
+			object: describeManifest(manifest),
+			run:    func() (string, error) { return createManifest(manifest) },
+		})
 	}
-
-	// Determine endpoint based on kind
-	var endpoint string
-	switch strings.ToLower(kind) {
-	case "pod":
-		namespace := getNamespace(obj, "default")
-		endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods", *serverURL, namespace)
-	case "node":
-		endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes", *serverURL)
-	default:
-		fmt.Printf("Error: unsupported resource kind: %s\n", kind)
+	if runBulk("created", tasks, *parallel) > 0 {
 		os.Exit(1)
 	}
+}
 
-	// Send request
-	resp, err := http.Post(endpoint, "application/json", bytes.NewReader(data))
-	if err != nil {
-		fmt.Printf("Error creating resource: %v\n", err)
-		os.Exit(1)
+// describeManifest names the object of manifest for messages, e.g. "Pod web"
+func describeManifest(manifest map[string]interface{}) string {
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if name == "" {
+		if generateName, _ := metadata["generateName"].(string); generateName != "" {
+			name = generateName + "*"
+		}
 	}
-	defer resp.Body.Close()
+	return strings.TrimSpace(kind + " " + name)
+}
 
-	if resp.StatusCode == http.StatusCreated {
-		fmt.Printf("Successfully created %s\n", kind)
-	} else {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Error creating resource: %s - %s\n", resp.Status, string(body))
-		os.Exit(1)
+// createManifest creates the object described by manifest and returns the
+// line reporting it
+func createManifest(manifest map[string]interface{}) (string, error) {
+	kind, ok := manifest["kind"].(string)
+	if !ok {
+		return "", fmt.Errorf("kind field is required")
 	}
-}
 
-func getResource() {
-	resource := os.Args[2]
-	var name string
-	if len(os.Args) > 3 {
-		name = os.Args[3]
+	// Determine endpoint based on kind
+	endpoint, err := collectionURL(kind, manifest)
+	if err != nil {
+		return "", err
 	}
 
-	var endpoint string
-	switch strings.ToLower(resource) {
-	case "pods":
-		if name != "" {
-			// Get specific pod
-			endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/default/pods/%s", *serverURL, name)
-		} else {
-			// List all pods
-			endpoint = fmt.Sprintf("%s/api/v1alpha1/pods", *serverURL)
-		}
-	case "nodes":
-		if name != "" {
-			// Get specific node
-			endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes/%s", *serverURL, name)
-		} else {
-			// List all nodes
-			endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes", *serverURL)
-		}
-	default:
-		fmt.Printf("Error: unsupported resource: %s\n", resource)
-		os.Exit(1)
+	// Remember the manifest so a later apply can tell which fields it dropped
+	if err := apply.SetLastApplied(manifest); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode resource: %w", err)
 	}
 
 	// Send request
-	resp, err := http.Get(endpoint)
+	resp, err := client.Post(context.Background(), endpoint, "application/json", bytes.NewReader(data))
 	if err != nil {
-		fmt.Printf("Error getting resource: %v\n", err)
-		os.Exit(1)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Println(string(body))
-	} else {
+	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Error getting resource: %s - %s\n", resp.Status, string(body))
-		os.Exit(1)
+		return "", fmt.Errorf("%s - %s", resp.Status, strings.TrimSpace(string(body)))
 	}
+	// Report the name the server chose when the object used generateName
+	var created struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return fmt.Sprintf("Successfully created %s %s", kind, created.Metadata.Name), nil
 }
 
-func deleteResource() {
-	resource := os.Args[2]
-	name := os.Args[3]
-
-	var endpoint string
-	switch strings.ToLower(resource) {
-	case "pods":
-		endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/default/pods/%s", *serverURL, name)
-	case "nodes":
-		endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes/%s", *serverURL, name)
-	default:
-		fmt.Printf("Error: unsupported resource: %s\n", resource)
+// deleteWaitInterval is how often delete --wait checks whether an object is
+// gone
+const deleteWaitInterval = 500 * time.Millisecond
+
+// deleteResource deletes the named objects of a resource, or those matching
+// a label selector, several at a time
+func deleteResource(args []string) {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the objects")
+	selector := fs.String("l", "", "Label selector (key=value,...) of the objects to delete, instead of names")
+	wait := fs.Bool("wait", false, "Wait until the deleted objects are gone")
+	timeout := fs.Duration("timeout", time.Minute, "How long --wait waits for each object (0 waits forever)")
+	parallel := fs.Int("parallel", defaultBulkParallelism, "How many objects to delete at once")
+	gracePeriod := fs.Int("grace-period", -1, "Seconds pods get to stop before they're killed (-1 uses each pod's own; 0 requires --force)")
+	force := fs.Bool("force", false, "Remove pods right away, without waiting for their nodes to stop their containers")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) < 1 || (len(positional) == 1) != (*selector != "") {
+		fmt.Println("Usage: cli delete <resource> <name>... | cli delete <resource> -l key=value [--wait] [--parallel n] [--grace-period s] [--force] [-n namespace]")
 		os.Exit(1)
 	}
-
-	// Send request
-	req, err := http.NewRequest("DELETE", endpoint, nil)
-	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
+	resource, names := positional[0], positional[1:]
+
+	// A force-deleted pod's containers may keep running until its node
+	// notices, so that has to be asked for explicitly
+	query := ""
+	switch {
+	case *gracePeriod == 0 && !*force:
+		fmt.Println("Error: --grace-period=0 removes pods before their containers stopped and requires --force")
+		os.Exit(1)
+	case *force && *gracePeriod > 0:
+		fmt.Println("Error: --force removes pods right away and can't be combined with a positive --grace-period")
 		os.Exit(1)
+	case *force:
+		query = "?gracePeriodSeconds=0"
+	case *gracePeriod > 0:
+		query = fmt.Sprintf("?gracePeriodSeconds=%d", *gracePeriod)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	collection, err := resourceCollection(resource, *namespace)
 	if err != nil {
-		fmt.Printf("Error deleting resource: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		fmt.Printf("Successfully deleted %s %s\n", resource, name)
-	} else {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Error deleting resource: %s - %s\n", resp.Status, string(body))
+	// Objects gone by the time their turn comes were deleted all the same
+	ignoreMissing := false
+	if *selector != "" {
+		labels, err := parseSelector(*selector)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		items, err := listMetadata(collection)
+		if err != nil {
+			fmt.Printf("Error listing %s: %v\n", resource, err)
+			os.Exit(1)
+		}
+		for _, item := range items {
+			if matchesSelector(item.Labels, labels) {
+				names = append(names, item.Name)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Printf("No %s match %s\n", resource, *selector)
+			return
+		}
+		ignoreMissing = true
+	}
+
+	tasks := make([]bulkTask, 0, len(names))
+	for _, name := range names {
+		endpoint := collection + "/" + url.PathEscape(name)
+		tasks = append(tasks, bulkTask{
+			object: resource + " " + name,
+			run: func() (string, error) {
+				if err := deleteObject(endpoint+query, ignoreMissing); err != nil {
+					return "", err
+				}
+				if *wait {
+					if err := waitForDeletion(endpoint, *timeout); err != nil {
+						return "", err
+					}
+				}
+				return fmt.Sprintf("Successfully deleted %s %s", resource, name), nil
+			},
+		})
+	}
+	if runBulk("deleted", tasks, *parallel) > 0 {
 		os.Exit(1)
 	}
 }
 
-func watchResource() {
-	resource := os.Args[2]
-	name := os.Args[3]
-
-	var endpoint string
+// resourceCollection returns the endpoint of a resource's objects, those in
+// namespace for namespaced resources
+func resourceCollection(resource, namespace string) (string, error) {
 	switch strings.ToLower(resource) {
 	case "pods":
-		endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/default/pods/%s/watch", *serverURL, name)
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods", *serverURL, namespace), nil
 	case "nodes":
-		endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes/%s/watch", *serverURL, name)
+		return fmt.Sprintf("%s/api/v1alpha1/nodes", *serverURL), nil
+	case "namespaces":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces", *serverURL), nil
+	case "deployments", "replicasets", "jobs", "cronjobs", "secrets", "podgroups", "services":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/%s", *serverURL, namespace, strings.ToLower(resource)), nil
+	case "certificatesigningrequests", "csr":
+		return fmt.Sprintf("%s/api/v1alpha1/certificatesigningrequests", *serverURL), nil
 	default:
-		fmt.Printf("Error: unsupported resource: %s\n", resource)
-		os.Exit(1)
+		return "", fmt.Errorf("unsupported resource: %s", resource)
 	}
+}
 
-	// Send request
-	resp, err := http.Get(endpoint)
+// deleteObject deletes the object at endpoint, or with a pod has its node
+// stop it first (202 Accepted). A missing object is only an error unless
+// ignoreMissing is set.
+func deleteObject(endpoint string, ignoreMissing bool) error {
+	resp, err := client.Delete(context.Background(), endpoint)
 	if err != nil {
-		fmt.Printf("Error watching resource: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Error watching resource: %s - %s\n", resp.Status, string(body))
-		os.Exit(1)
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted || (ignoreMissing && resp.StatusCode == http.StatusNotFound) {
+		return nil
 	}
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("%s - %s", resp.Status, strings.TrimSpace(string(body)))
+}
 
-	fmt.Printf("Watching %s %s... (Press Ctrl+C to stop)\n", resource, name)
-
-	// Stream events
-	reader := bufio.NewReader(resp.Body)
+// waitForDeletion waits until getting the object at endpoint finds nothing,
+// giving up after timeout unless it's 0
+func waitForDeletion(endpoint string, timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
 	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
+		resp, err := client.Get(context.Background(), endpoint)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusNotFound {
+				return nil
 			}
-			fmt.Printf("Error reading event: %v\n", err)
-			break
 		}
-
-		line = strings.TrimSpace(line)
-		if line != "" {
-			fmt.Printf("Event: %s\n", line)
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for deletion")
 		}
+		time.Sleep(deleteWaitInterval)
+	}
+}
+
+// collectionURL returns the endpoint objects of the given kind are created at
+func collectionURL(kind string, obj map[string]interface{}) (string, error) {
+	switch strings.ToLower(kind) {
+	case "pod":
+		namespace := getNamespace(obj, *defaultNamespace)
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods", *serverURL, namespace), nil
+	case "node":
+		return fmt.Sprintf("%s/api/v1alpha1/nodes", *serverURL), nil
+	case "namespace":
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces", *serverURL), nil
+	case "runtimeclass":
+		return fmt.Sprintf("%s/api/v1alpha1/runtimeclasses", *serverURL), nil
+	case "secret":
+		namespace := getNamespace(obj, *defaultNamespace)
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/secrets", *serverURL, namespace), nil
+	case "certificatesigningrequest":
+		return fmt.Sprintf("%s/api/v1alpha1/certificatesigningrequests", *serverURL), nil
+	case "deployment", "replicaset", "job", "cronjob", "podgroup", "service":
+		namespace := getNamespace(obj, *defaultNamespace)
+		return fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/%ss", *serverURL, namespace, strings.ToLower(kind)), nil
+	default:
+		return "", fmt.Errorf("unsupported resource kind: %s", kind)
 	}
 }
 
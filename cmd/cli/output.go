@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/jsonpath"
+)
+
+// customColumnsPrefix selects the custom-columns output format
+const customColumnsPrefix = "custom-columns="
+
+// column is one column of custom-columns output
+type column struct {
+	header string
+	path   *jsonpath.Path
+}
+
+// printOutput renders an API response body according to -o and --sort-by.
+// List responses are sorted by their items; single objects print as-is.
+func printOutput(body []byte, output, sortBy string) error {
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	list, isList := obj.(map[string]interface{})
+	var items []interface{}
+	if isList {
+		items, isList = list["items"].([]interface{})
+	}
+	if !isList {
+		items = []interface{}{obj}
+	}
+
+	if sortBy != "" {
+		path, err := jsonpath.Parse(sortBy)
+		if err != nil {
+			return fmt.Errorf("invalid --sort-by: %w", err)
+		}
+		sortItems(items, path)
+	}
+
+	switch {
+	case output == "" || output == "json":
+		if isList {
+			list["items"] = items
+		}
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case strings.HasPrefix(output, customColumnsPrefix):
+		columns, err := parseCustomColumns(strings.TrimPrefix(output, customColumnsPrefix))
+		if err != nil {
+			return err
+		}
+		printCustomColumns(items, columns)
+	default:
+		return fmt.Errorf("unsupported output format %q, use json or custom-columns=<header>:<path>,...", output)
+	}
+
+	return nil
+}
+
+// parseCustomColumns parses a spec like NAME:.metadata.name,NODE:.spec.nodeName
+func parseCustomColumns(spec string) ([]column, error) {
+	var columns []column
+	for _, part := range strings.Split(spec, ",") {
+		header, expr, ok := strings.Cut(part, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected <header>:<path>", part)
+		}
+		path, err := jsonpath.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom column %q: %w", part, err)
+		}
+		columns = append(columns, column{header: header, path: path})
+	}
+	return columns, nil
+}
+
+// printCustomColumns prints one row per item, joining multiple matches with commas
+func printCustomColumns(items []interface{}, columns []column) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	defer w.Flush()
+
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.header
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			var values []string
+			for _, value := range col.path.Evaluate(item) {
+				values = append(values, jsonpath.Format(value))
+			}
+			cells[i] = strings.Join(values, ",")
+			if cells[i] == "" {
+				cells[i] = "<none>"
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+}
+
+// sortItems orders items by the first value path selects from each. Items
+// without a value sort first; numbers and timestamps compare by value.
+func sortItems(items []interface{}, path *jsonpath.Path) {
+	key := func(item interface{}) interface{} {
+		values := path.Evaluate(item)
+		if len(values) == 0 {
+			return nil
+		}
+		return values[0]
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return lessValue(key(items[i]), key(items[j]))
+	})
+}
+
+// lessValue compares two decoded JSON values
+func lessValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return av < bv
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			at, aerr := time.Parse(time.RFC3339Nano, av)
+			bt, berr := time.Parse(time.RFC3339Nano, bv)
+			if aerr == nil && berr == nil {
+				return at.Before(bt)
+			}
+			return av < bv
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			return !av && bv
+		}
+	}
+
+	return jsonpath.Format(a) < jsonpath.Format(b)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// prunableResources maps the namespaced kinds pruning looks for to their
+// resources. Objects of these kinds are also put in the default namespace
+// of a release when their manifest has none.
+var prunableResources = map[string]string{
+	"Pod":        "pods",
+	"Secret":     "secrets",
+	"Deployment": "deployments",
+	"ReplicaSet": "replicasets",
+	"Job":        "jobs",
+	"CronJob":    "cronjobs",
+	"PodGroup":   "podgroups",
+	"Service":    "services",
+}
+
+// parseSelector parses a label selector of the form key=value,key=value
+func parseSelector(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, requirement := range splitList(selector) {
+		key, value, ok := strings.Cut(requirement, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid selector %q, want key=value[,key=value]", selector)
+		}
+		labels[key] = value
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return labels, nil
+}
+
+// pruneObjects deletes the objects matching selector that aren't among
+// manifests, looking in namespace and the namespaces of manifests. Objects
+// with owners, such as the pods of a deployment, are left to their owners.
+func pruneObjects(selector map[string]string, namespace string, manifests []map[string]interface{}, report func(string)) error {
+	keep := make(map[string]bool)
+	namespaces := map[string]bool{namespace: true}
+	for _, manifest := range manifests {
+		kind, _ := manifest["kind"].(string)
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		ns := getNamespace(manifest, namespace)
+		keep[kind+"/"+ns+"/"+name] = true
+		if _, namespaced := prunableResources[kind]; namespaced {
+			namespaces[ns] = true
+		}
+	}
+
+	kinds := make([]string, 0, len(prunableResources))
+	for kind := range prunableResources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	sortedNamespaces := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		sortedNamespaces = append(sortedNamespaces, ns)
+	}
+	sort.Strings(sortedNamespaces)
+
+	for _, ns := range sortedNamespaces {
+		for _, kind := range kinds {
+			collection := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/%s", *serverURL, url.PathEscape(ns), prunableResources[kind])
+			items, err := listMetadata(collection)
+			if err != nil {
+				return fmt.Errorf("failed to list %s: %w", prunableResources[kind], err)
+			}
+			for _, item := range items {
+				if !matchesSelector(item.Labels, selector) || len(item.OwnerReferences) > 0 || keep[kind+"/"+ns+"/"+item.Name] {
+					continue
+				}
+				resp, err := client.Delete(context.Background(), collection+"/"+url.PathEscape(item.Name))
+				if err != nil {
+					return fmt.Errorf("failed to delete %s %s: %w", kind, item.Name, err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNotFound {
+					return fmt.Errorf("failed to delete %s %s: %s", kind, item.Name, resp.Status)
+				}
+				report(fmt.Sprintf("%s %s pruned", kind, item.Name))
+			}
+		}
+	}
+	return nil
+}
+
+// matchesSelector reports whether labels has every label of selector
+func matchesSelector(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// listMetadata lists the metadata of the objects at collection
+func listMetadata(collection string) ([]api.ObjectMeta, error) {
+	resp, err := client.Get(context.Background(), collection)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s - %s", resp.Status, string(body))
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata api.ObjectMeta `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	metadata := make([]api.ObjectMeta, len(list.Items))
+	for i, item := range list.Items {
+		metadata[i] = item.Metadata
+	}
+	return metadata, nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// labelRelease marks the objects applied as part of a release with its name
+const labelRelease = "release.minik8s.io/name"
+
+// stringList is a flag that may be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// renderCommand fills Go-templated manifests in with values and prints the
+// result, or with --apply applies it as a named release: every object is
+// labeled with the release name, and objects of the release that are no
+// longer rendered are deleted.
+func renderCommand(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	var valueFiles, sets stringList
+	fs.Var(&valueFiles, "f", "JSON file with values; later files override earlier ones")
+	fs.Var(&sets, "set", "Set a value, as key.path=value; overrides value files")
+	release := fs.String("release", "", "Name of the release the objects belong to")
+	namespace := fs.String("namespace", *defaultNamespace, "Namespace of objects whose manifest has none")
+	applyRelease := fs.Bool("apply", false, "Apply the rendered objects as the release instead of printing them")
+
+	templates, _ := parseInterspersed(fs, args)
+	if len(templates) == 0 || (*applyRelease && *release == "") {
+		fmt.Println("Usage: cli render <template|dir>... [-f values.json]... [--set key=value]... [--release name [--apply]] [--namespace ns]")
+		os.Exit(1)
+	}
+
+	values, err := loadValues(valueFiles, sets)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	manifests, err := renderManifests(templates, map[string]interface{}{
+		"Values":  values,
+		"Release": map[string]interface{}{"Name": *release, "Namespace": *namespace},
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, manifest := range manifests {
+		if err := prepareReleaseManifest(manifest, *release, *namespace); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !*applyRelease {
+		for _, manifest := range manifests {
+			data, _ := json.MarshalIndent(manifest, "", "  ")
+			fmt.Println(string(data))
+		}
+		return
+	}
+
+	failed := false
+	for _, manifest := range manifests {
+		result, err := applyManifest(manifest)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Println(result)
+	}
+	// Objects that failed to apply may still be in the release, so only
+	// prune after a clean apply
+	if failed {
+		os.Exit(1)
+	}
+	if err := pruneObjects(map[string]string{labelRelease: *release}, *namespace, manifests, func(line string) { fmt.Println(line) }); err != nil {
+		fmt.Printf("Error pruning release %s: %v\n", *release, err)
+		os.Exit(1)
+	}
+}
+
+// loadValues merges the value files in order and then applies the --set
+// overrides
+func loadValues(files, sets []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values: %w", err)
+		}
+		var fileValues map[string]interface{}
+		if err := json.Unmarshal(data, &fileValues); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", file, err)
+		}
+		mergeValues(values, fileValues)
+	}
+
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set %q, want key.path=value", set)
+		}
+		path := strings.Split(key, ".")
+		parent := values
+		for _, field := range path[:len(path)-1] {
+			child, ok := parent[field].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[field] = child
+			}
+			parent = child
+		}
+		parent[path[len(path)-1]] = parseSetValue(value)
+	}
+	return values, nil
+}
+
+// mergeValues merges src into dst, recursing into maps both have
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// parseSetValue reads a --set value as a boolean or number when it looks
+// like one, and as a string otherwise
+func parseSetValue(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// renderManifests executes the templates, expanding directories to the
+// files in them, and decodes the JSON objects they produce. A template may
+// produce several objects, or none.
+func renderManifests(paths []string, data map[string]interface{}) ([]map[string]interface{}, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	funcs := template.FuncMap{
+		"toJson": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"quote": strconv.Quote,
+		"default": func(def, v interface{}) interface{} {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+	}
+
+	var manifests []map[string]interface{}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(filepath.Base(file)).Funcs(funcs).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", file, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", file, err)
+		}
+
+		decoded, err := decodeManifests(out.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("rendered %s is not JSON: %w", file, err)
+		}
+		manifests = append(manifests, decoded...)
+	}
+	return manifests, nil
+}
+
+// prepareReleaseManifest labels manifest with the release, if any, and
+// puts it in namespace when it's of a namespaced kind and has none
+func prepareReleaseManifest(manifest map[string]interface{}, release, namespace string) error {
+	kind, _ := manifest["kind"].(string)
+	if kind == "" {
+		return fmt.Errorf("rendered object has no kind")
+	}
+	metadata, ok := manifest["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = make(map[string]interface{})
+		manifest["metadata"] = metadata
+	}
+	if _, namespaced := prunableResources[kind]; namespaced && getNamespace(manifest, "") == "" {
+		metadata["namespace"] = namespace
+	}
+	if release != "" {
+		labels, ok := metadata["labels"].(map[string]interface{})
+		if !ok {
+			labels = make(map[string]interface{})
+			metadata["labels"] = labels
+		}
+		labels[labelRelease] = release
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// scaleCommand sets the replica count of a deployment or replicaset through
+// its scale subresource
+func scaleCommand(args []string) {
+	fs := flag.NewFlagSet("scale", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the resource")
+	replicas := fs.Int("replicas", -1, "Desired number of replicas")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 2 || *replicas < 0 {
+		fmt.Println("Usage: cli scale <deployments|replicasets> <name> --replicas <n> [-n namespace]")
+		os.Exit(1)
+	}
+
+	resource := strings.ToLower(positional[0])
+	name := positional[1]
+	if resource != "deployments" && resource != "replicasets" {
+		fmt.Printf("Error: %s can't be scaled, use deployments or replicasets\n", positional[0])
+		os.Exit(1)
+	}
+
+	scale := api.Scale{Spec: api.ScaleSpec{Replicas: int32(*replicas)}}
+	data, err := json.Marshal(scale)
+	if err != nil {
+		fmt.Printf("Error encoding scale: %v\n", err)
+		os.Exit(1)
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/%s/%s/scale", *serverURL, *namespace, resource, name)
+	resp, err := client.Put(context.Background(), endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		fmt.Printf("Error scaling resource: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error scaling resource: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Scaled %s %s to %d replicas\n", resource, name, *replicas)
+}
+
+// rolloutCommand pauses or resumes the rollouts of a deployment, promotes
+// its canary rollout, or waits for its rollout to finish
+func rolloutCommand(args []string) {
+	fs := flag.NewFlagSet("rollout", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the deployment")
+	timeout := fs.Duration("timeout", 0, "How long rollout status waits before giving up (0 waits until the rollout finishes or fails)")
+	full := fs.Bool("full", false, "Skip the remaining canary steps when promoting")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 2 || (positional[0] != "pause" && positional[0] != "resume" && positional[0] != "status" && positional[0] != "promote") {
+		fmt.Println("Usage: cli rollout <pause|resume|status|promote> <deployment> [--timeout duration] [--full] [-n namespace]")
+		os.Exit(1)
+	}
+	action, name := positional[0], positional[1]
+
+	if action == "status" {
+		rolloutStatus(*namespace, name, *timeout)
+		return
+	}
+	if action == "promote" {
+		rolloutPromote(*namespace, name, *full)
+		return
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/deployments/%s", *serverURL, *namespace, name)
+	resp, err := client.Get(context.Background(), endpoint)
+	if err != nil {
+		fmt.Printf("Error getting deployment: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error getting deployment: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+
+	var deployment api.Deployment
+	if err := json.NewDecoder(resp.Body).Decode(&deployment); err != nil {
+		fmt.Printf("Error decoding deployment: %v\n", err)
+		os.Exit(1)
+	}
+
+	paused := action == "pause"
+	if deployment.Spec.Paused == paused {
+		fmt.Printf("Deployment %s is already %sd\n", name, action)
+		return
+	}
+	deployment.Spec.Paused = paused
+
+	data, err := json.Marshal(deployment)
+	if err != nil {
+		fmt.Printf("Error encoding deployment: %v\n", err)
+		os.Exit(1)
+	}
+
+	resp, err = client.Put(context.Background(), endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		fmt.Printf("Error updating deployment: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error updating deployment: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Deployment %s %sd\n", name, action)
+}
+
+// rolloutStatusInterval is how often rollout status checks the deployment
+const rolloutStatusInterval = 2 * time.Second
+
+// rolloutStatus waits until the deployment's rollout finishes, exiting
+// non-zero as soon as it exceeds its progress deadline
+func rolloutStatus(namespace, name string, timeout time.Duration) {
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/deployments/%s", *serverURL, namespace, name)
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	lastMessage := ""
+	for {
+		var deployment api.Deployment
+		if err := getJSON(endpoint, &deployment); err != nil {
+			fmt.Printf("Error getting deployment: %v\n", err)
+			os.Exit(1)
+		}
+
+		if progressing := api.GetDeploymentCondition(&deployment.Status, api.DeploymentProgressing); progressing != nil {
+			switch progressing.Reason {
+			case api.ReasonProgressDeadlineExceeded:
+				fmt.Printf("Error: deployment %s exceeded its progress deadline\n", name)
+				os.Exit(1)
+			case api.ReasonDeploymentPaused:
+				if deployment.Spec.Strategy != nil && !deployment.Spec.Paused {
+					fmt.Printf("Error: %s\n", progressing.Message)
+				} else {
+					fmt.Printf("Error: deployment %s is paused; resume it to continue the rollout\n", name)
+				}
+				os.Exit(1)
+			case api.ReasonNewReplicaSetAvailable:
+				fmt.Printf("Deployment %s successfully rolled out\n", name)
+				return
+			}
+		}
+
+		message := fmt.Sprintf("Waiting for deployment %s rollout to finish: %d of %d updated replicas are available...",
+			name, deployment.Status.AvailableReplicas, deployment.Spec.Replicas)
+		if message != lastMessage {
+			fmt.Println(message)
+			lastMessage = message
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			fmt.Printf("Error: timed out waiting for deployment %s to roll out\n", name)
+			os.Exit(1)
+		}
+		time.Sleep(rolloutStatusInterval)
+	}
+}
+
+// rolloutPromote moves a deployment's canary rollout past the step it's
+// paused at, or with full past all remaining steps, or lets its blue/green
+// rollout switch over
+func rolloutPromote(namespace, name string, full bool) {
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/deployments/%s/promote", *serverURL, namespace, name)
+	if full {
+		endpoint += "?full=true"
+	}
+	resp, err := client.Post(context.Background(), endpoint, "application/json", nil)
+	if err != nil {
+		fmt.Printf("Error promoting deployment: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error promoting deployment: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+
+	var deployment api.Deployment
+	if err := json.NewDecoder(resp.Body).Decode(&deployment); err != nil {
+		fmt.Printf("Error decoding deployment: %v\n", err)
+		os.Exit(1)
+	}
+	if blueGreen := deployment.Status.BlueGreen; blueGreen != nil {
+		fmt.Printf("Deployment %s promoted; its services switch to ReplicaSet %s once it's ready\n", name, blueGreen.PreviewReplicaSet)
+		return
+	}
+	canary := deployment.Status.Canary
+	steps := len(deployment.Spec.Strategy.Canary.Steps)
+	if int(canary.CurrentStep) >= steps {
+		fmt.Printf("Deployment %s promoted to the new ReplicaSet\n", name)
+		return
+	}
+	fmt.Printf("Deployment %s promoted to step %d of %d\n", name, canary.CurrentStep+1, steps)
+}
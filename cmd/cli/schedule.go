@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// scheduleCommand shows where the scheduler would place a pod, and why,
+// without creating it
+func scheduleCommand(args []string) {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	filename := fs.String("f", "", "File with the JSON manifest of the pod")
+	dryRun := fs.Bool("dry-run", false, "Only simulate scheduling; required, as binding is left to the scheduler")
+
+	positional, _ := parseInterspersed(fs, args)
+	if *filename == "" || !*dryRun || len(positional) != 0 {
+		fmt.Println("Usage: cli schedule --dry-run -f <filename>")
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(*filename)
+	if err != nil {
+		fmt.Printf("Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v1alpha1/scheduling/simulate", *serverURL)
+	resp, err := client.Post(context.Background(), endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		fmt.Printf("Error simulating scheduling: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Error simulating scheduling: %s - %s\n", resp.Status, string(body))
+		os.Exit(1)
+	}
+
+	var result api.SchedulingSimulation
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		fmt.Printf("Error decoding response: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NODE\tFEASIBLE\tFAILED CHECKS\tSCORE")
+	for _, node := range result.Nodes {
+		var failed []string
+		for _, filter := range node.Filters {
+			if !filter.Passed {
+				failed = append(failed, filter.Name)
+			}
+		}
+		failedColumn, score := "<none>", "-"
+		if len(failed) > 0 {
+			failedColumn = strings.Join(failed, ",")
+		}
+		if node.Score != nil {
+			score = fmt.Sprintf("%.2f (cpu %.2f + memory %.2f - pods %d)", node.Score.Total, node.Score.CPU, node.Score.Memory, node.Score.Pods)
+		}
+		fmt.Fprintf(w, "%s\t%t\t%s\t%s\n", node.Node, node.Feasible, failedColumn, score)
+	}
+	w.Flush()
+
+	if result.SelectedNode == "" {
+		fmt.Printf("\nNot schedulable: %s\n", result.Message)
+		return
+	}
+	fmt.Printf("\nWould be scheduled to node %s\n", result.SelectedNode)
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+	"golang.org/x/sys/unix"
+)
+
+// isTerminal reports whether fd refers to a terminal
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	return err == nil
+}
+
+// makeRaw puts the terminal into raw mode so keystrokes such as Ctrl-C reach
+// the container, returning a function that restores the previous mode
+func makeRaw(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	previous := *termios
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		unix.IoctlSetTermios(fd, unix.TCSETS, &previous)
+	}, nil
+}
+
+// watchResize reports the terminal's current size and every later change
+func watchResize(fd int) <-chan remotecommand.TerminalSize {
+	sizes := make(chan remotecommand.TerminalSize, 1)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+
+	go func() {
+		for {
+			if ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil {
+				sizes <- remotecommand.TerminalSize{Width: ws.Col, Height: ws.Row}
+			}
+			<-signals
+		}
+	}()
+
+	return sizes
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+)
+
+// isTerminal reports whether fd refers to a terminal; TTY sessions are only supported on Linux
+func isTerminal(fd int) bool {
+	return false
+}
+
+// makeRaw is not supported on this platform
+func makeRaw(fd int) (func(), error) {
+	return nil, fmt.Errorf("raw terminal mode is not supported on this platform")
+}
+
+// watchResize is not supported on this platform
+func watchResize(fd int) <-chan remotecommand.TerminalSize {
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// validateCommand checks manifests against the rules the API server
+// enforces, without contacting it, and warns about missing probes, missing
+// resources and unpinned images. It exits non-zero when a manifest is
+// invalid, or with --strict when one has warnings, so it can run in a
+// pre-commit hook.
+func validateCommand(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	filename := fs.String("f", "", "File with the JSON manifest to validate")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+
+	positional, _ := parseInterspersed(fs, args)
+	filenames := positional
+	if *filename != "" {
+		filenames = append([]string{*filename}, positional...)
+	}
+	if len(filenames) == 0 {
+		fmt.Println("Usage: cli validate -f <filename> [filename...] [--strict]")
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, name := range filenames {
+		warnings, err := validateManifest(name)
+		for _, warning := range warnings {
+			fmt.Printf("%s: warning: %s\n", name, warning)
+		}
+		if err != nil {
+			fmt.Printf("%s: error: %v\n", name, err)
+		}
+		if err != nil || (*strict && len(warnings) > 0) {
+			failed = true
+		} else if err == nil && len(warnings) == 0 {
+			fmt.Printf("%s: valid\n", name)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// validateManifest decodes the manifest in filename by its kind and returns
+// its warnings, or the first rule it breaks
+func validateManifest(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var meta api.TypeMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	obj := newManifestObject(meta.Kind)
+	if obj == nil {
+		return nil, fmt.Errorf("unsupported kind %q", meta.Kind)
+	}
+
+	// Fields the API types don't have are dropped by the server, so a typo
+	// would otherwise go unnoticed
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", meta.Kind, err)
+	}
+
+	warnings := validation.Warnings(obj)
+	if err := validation.Object(obj); err != nil {
+		return warnings, err
+	}
+	return warnings, nil
+}
+
+// newManifestObject returns an empty object of kind to decode a manifest
+// into, or nil if the kind is unknown
+func newManifestObject(kind string) interface{} {
+	switch kind {
+	case "Pod":
+		return &api.Pod{}
+	case "Deployment":
+		return &api.Deployment{}
+	case "ReplicaSet":
+		return &api.ReplicaSet{}
+	case "Job":
+		return &api.Job{}
+	case "CronJob":
+		return &api.CronJob{}
+	case "Node":
+		return &api.Node{}
+	case "HorizontalPodAutoscaler":
+		return &api.HorizontalPodAutoscaler{}
+	case "PodGroup":
+		return &api.PodGroup{}
+	case "Namespace":
+		return &api.Namespace{}
+	case "Secret":
+		return &api.Secret{}
+	case "Service":
+		return &api.Service{}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/version"
+)
+
+// versionCommand prints the versions of the CLI and of the cluster it talks to
+func versionCommand(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	clientOnly := fs.Bool("client", false, "Only print the CLI's version")
+	output := fs.String("o", "", "Output format: json")
+
+	if positional, _ := parseInterspersed(fs, args); len(positional) != 0 || (*output != "" && *output != "json") {
+		fmt.Println("Usage: cli version [--client] [-o json]")
+		os.Exit(1)
+	}
+
+	clientVersion := version.Get()
+	var server *version.Info
+	var serverErr error
+	if !*clientOnly {
+		server = &version.Info{}
+		if serverErr = getJSON(*serverURL+"/version", server); serverErr != nil {
+			server = nil
+		}
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(map[string]interface{}{"clientVersion": clientVersion, "serverVersion": server}, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("Client Version: %s\n", clientVersion)
+		if server != nil {
+			fmt.Printf("Server Version: %s\n", server)
+			if server.BuildDate != "" {
+				fmt.Printf("Server Build Date: %s, %s, %s\n", server.BuildDate, server.GoVersion, server.Platform)
+			}
+			if len(server.Components) > 0 {
+				fmt.Println("Components:")
+				w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+				for _, component := range server.Components {
+					fmt.Fprintf(w, "  %s\t%s\n", component.Name, component.Version)
+				}
+				w.Flush()
+			}
+		}
+	}
+
+	if serverErr != nil {
+		fmt.Printf("Error getting server version: %v\n", serverErr)
+		os.Exit(1)
+	}
+}
+
+// clusterInfoCommand prints where the API server runs, the health of the
+// control plane components and how many nodes are ready
+func clusterInfoCommand(args []string) {
+	if len(args) != 0 {
+		fmt.Println("Usage: cli cluster-info")
+		os.Exit(1)
+	}
+
+	var server version.Info
+	if err := getJSON(*serverURL+"/version", &server); err != nil {
+		fmt.Printf("Error reaching the API server at %s: %v\n", serverName(), err)
+		os.Exit(1)
+	}
+	fmt.Printf("API server is running at %s (%s)\n", serverName(), server.GitVersion)
+
+	var statuses api.ComponentStatusList
+	if err := getJSON(*serverURL+"/api/v1alpha1/componentstatuses", &statuses); err != nil {
+		fmt.Printf("Error getting component statuses: %v\n", err)
+		os.Exit(1)
+	}
+	var nodes struct {
+		Items []api.Node `json:"items"`
+	}
+	if err := getJSON(*serverURL+"/api/v1alpha1/nodes", &nodes); err != nil {
+		fmt.Printf("Error listing nodes: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tSTATUS\tMESSAGE")
+	healthy := true
+	for _, status := range statuses.Items {
+		state, message := "Unknown", ""
+		for _, condition := range status.Conditions {
+			if condition.Type != api.ComponentConditionHealthy {
+				continue
+			}
+			state, message = "Unhealthy", condition.Error
+			if condition.Status == api.ConditionTrue {
+				state, message = "Healthy", condition.Message
+			}
+		}
+		if state != "Healthy" {
+			healthy = false
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status.Name, state, message)
+	}
+	w.Flush()
+
+	ready := 0
+	for _, node := range nodes.Items {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == api.NodeReady && condition.Status == api.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	fmt.Printf("\nNodes: %d ready of %d\n", ready, len(nodes.Items))
+
+	if !healthy {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/jsonpath"
+)
+
+// summaryField is a field shown on the one-line summary of a watch event
+type summaryField struct {
+	label string
+	path  string
+}
+
+// summaryFields lists the fields summarizing an object of each kind. Kinds
+// without an entry are shown by name only.
+var summaryFields = map[string][]summaryField{
+	"Pod": {
+		{"phase", "{.status.phase}"},
+		{"node", "{.spec.nodeName}"},
+		{"podIP", "{.status.podIP}"},
+	},
+	"Node": {
+		{"unschedulable", "{.spec.unschedulable}"},
+	},
+}
+
+// watchEvent is a watch event decoded generically, so any kind can be shown
+type watchEvent struct {
+	Type   string                 `json:"type"`
+	Object map[string]interface{} `json:"object"`
+}
+
+// watchCommand streams changes to one object as one line per event, e.g.
+// MODIFIED pod/my-pod phase=Running podIP=10.2.3.4. With --output-diff each
+// event is followed by the fields that changed since the previous one.
+func watchCommand(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	namespace := fs.String("n", *defaultNamespace, "Namespace of the object")
+	outputDiff := fs.Bool("output-diff", false, "Show the fields that changed between successive versions")
+
+	positional, _ := parseInterspersed(fs, args)
+	if len(positional) != 2 {
+		fmt.Println("Usage: cli watch <resource> <name> [-n namespace] [--output-diff]")
+		os.Exit(1)
+	}
+	resource, name := positional[0], positional[1]
+
+	var endpoint string
+	switch strings.ToLower(resource) {
+	case "pod", "pods":
+		endpoint = fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/pods/%s/watch", *serverURL, *namespace, name)
+	case "node", "nodes":
+		endpoint = fmt.Sprintf("%s/api/v1alpha1/nodes/%s/watch", *serverURL, name)
+	default:
+		fmt.Printf("Error: unsupported resource: %s\n", resource)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Watching %s %s... (Press Ctrl+C to stop)\n", resource, name)
+
+	// Stream events, reconnecting if the stream drops
+	var previous map[string]interface{}
+	err := client.Watch(context.Background(), endpoint, nil, func(line []byte) {
+		var event watchEvent
+		if err := json.Unmarshal(line, &event); err != nil || event.Object == nil {
+			fmt.Printf("Event: %s\n", strings.TrimSpace(string(line)))
+			return
+		}
+
+		fmt.Println(formatWatchEvent(&event))
+		if *outputDiff {
+			if previous != nil {
+				for _, change := range diffObjects(previous, event.Object) {
+					fmt.Printf("    %s\n", change)
+				}
+			}
+			previous = event.Object
+		}
+	})
+	if err != nil {
+		fmt.Printf("Error watching resource: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// formatWatchEvent renders an event as TYPE kind/name followed by the kind's
+// summary fields that are set
+func formatWatchEvent(event *watchEvent) string {
+	kind, _ := event.Object["kind"].(string)
+	metadata, _ := event.Object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+
+	parts := []string{event.Type, fmt.Sprintf("%s/%s", strings.ToLower(kind), name)}
+	for _, field := range summaryFields[kind] {
+		path, err := jsonpath.Parse(field.path)
+		if err != nil {
+			continue
+		}
+		values := path.Evaluate(event.Object)
+		if len(values) == 0 {
+			continue
+		}
+		if value := jsonpath.Format(values[0]); value != "" && value != "false" {
+			parts = append(parts, fmt.Sprintf("%s=%s", field.label, value))
+		}
+	}
+	if kind == "Node" {
+		parts = append(parts, fmt.Sprintf("ready=%s", nodeReadyStatus(event.Object)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// nodeReadyStatus returns the status of a node's Ready condition
+func nodeReadyStatus(node map[string]interface{}) string {
+	status, _ := node["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		if condition["type"] == "Ready" {
+			if value, ok := condition["status"].(string); ok {
+				return value
+			}
+		}
+	}
+	return "Unknown"
+}
+
+// diffObjects lists the fields that differ between two versions of an
+// object as "- path: old" and "+ path: new" lines, sorted by path. The
+// resource version changes on every write, so it's left out.
+func diffObjects(old, new map[string]interface{}) []string {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	flattenJSON("", old, before)
+	flattenJSON("", new, after)
+	delete(before, "metadata.resourceVersion")
+	delete(after, "metadata.resourceVersion")
+
+	paths := make(map[string]bool)
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, path := range sorted {
+		oldValue, hadOld := before[path]
+		newValue, hasNew := after[path]
+		if hadOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if hadOld {
+			changes = append(changes, fmt.Sprintf("- %s: %s", path, jsonpath.Format(oldValue)))
+		}
+		if hasNew {
+			changes = append(changes, fmt.Sprintf("+ %s: %s", path, jsonpath.Format(newValue)))
+		}
+	}
+	return changes
+}
+
+// flattenJSON records every leaf value of a decoded JSON document under its
+// dotted path, with list elements as path[i]
+func flattenJSON(prefix string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = v
+		}
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenJSON(path, child, out)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			out[prefix] = v
+		}
+		for i, child := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
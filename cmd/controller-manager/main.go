@@ -5,12 +5,20 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/netip"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 	"time"
+	// CronJob time zones resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
 
+	"github.com/minik8s/minik8s/pkg/api"
 	"github.com/minik8s/minik8s/pkg/controller"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/lease"
+	"github.com/minik8s/minik8s/pkg/lifecycle"
+	"github.com/minik8s/minik8s/pkg/nodeagent"
+	"github.com/minik8s/minik8s/pkg/profiling"
 	"github.com/minik8s/minik8s/pkg/scheduler"
 	"github.com/minik8s/minik8s/pkg/store"
 )
@@ -19,25 +27,64 @@ var (
 	storeType        = flag.String("store", "memory", "Store type: memory or etcd")
 	etcdEndpoints    = flag.String("etcd-endpoints", "localhost:2379", "Comma-separated list of etcd endpoints")
 	storePrefix      = flag.String("store-prefix", "/minik8s", "Store key prefix")
+	storeRoutes      = flag.String("store-routes", "", "Comma-separated Kind[/namespace]=type[:prefix] routes sending kinds or namespaces to their own store")
 	enableFallback   = flag.Bool("enable-fallback", true, "Enable fallback to in-memory store if etcd fails")
 	syncInterval     = flag.Duration("sync-interval", 30*time.Second, "Controller sync interval")
+	resyncPeriods    = flag.String("controller-resync-periods", "", "Per-controller resync periods, e.g. deployment-controller=1m,replicaset-controller=20s")
+	resyncJitter     = flag.Float64("resync-jitter", controller.DefaultResyncJitter, "Maximum fraction of the resync period added as random delay (negative disables)")
 	scheduleInterval = flag.Duration("schedule-interval", 10*time.Second, "Scheduler sync interval")
+	eventTTL         = flag.Duration("event-ttl", events.DefaultTTL, "How long events are kept after they were last seen")
+	maxObjectEvents  = flag.Int("max-events-per-object", events.DefaultMaxEventsPerObject, "Distinct events kept per object, dropping the least recently seen (negative disables)")
+	nodeGracePeriod  = flag.Duration("node-monitor-grace-period", controller.DefaultNodeMonitorGracePeriod, "How long a node's heartbeat may go without advancing before a check counts as missed")
+	nodeClockSkew    = flag.Duration("node-clock-skew-tolerance", controller.DefaultNodeClockSkewTolerance, "How far a node's clock may be behind before its heartbeat timestamps look stale")
+	nodeMissed       = flag.Int("node-missed-heartbeats", controller.DefaultNodeMissedHeartbeats, "Missed heartbeat checks within --node-heartbeat-window that mark a node NotReady")
+	nodeWindow       = flag.Int("node-heartbeat-window", controller.DefaultNodeHeartbeatWindow, "Number of recent heartbeat checks considered when marking a node NotReady")
+	unreachableWait  = flag.Duration("default-unreachable-toleration", controller.DefaultUnreachableToleration, "How long pods without a toleration for the unreachable taint stay on an unreachable node before they're evicted")
+	recommendWindow  = flag.Duration("recommendation-window", controller.DefaultRecommendationWindow, "How far back pod usage is considered when recommending resource requests")
+	hollowNodes      = flag.Int("max-hollow-nodes", 0, "Add up to this many in-process hollow nodes while pods are unschedulable, removing them once empty (0 disables cluster autoscaling)")
+	deschedule       = flag.Duration("descheduler-interval", 0, "How often to evict pods on cordoned or over-utilized nodes, violating spread constraints or duplicated on a node so they're rescheduled (0 disables the descheduler)")
+	utilization      = flag.Float64("descheduler-utilization-threshold", controller.DefaultUtilizationThreshold, "Fraction of a node's allocatable CPU or memory its pods may request before the descheduler moves some")
+	serviceIPRange   = flag.String("service-cluster-ip-range", controller.DefaultServiceClusterIPRange, "CIDR the cluster IPs of services are allocated from")
+	metricsURL       = flag.String("external-metrics-url", "", "Base URL of the external metrics adapter read by horizontal pod autoscalers; autoscaling is off when unset")
+	eventsMetrics    = flag.String("events-metrics-address", "", "Address to serve event and pod phase metrics on under /metrics (empty disables)")
+	eventsOTLP       = flag.String("events-otlp-endpoint", "", "OTLP/HTTP logs URL to send new events and pod phase transitions to, e.g. http://collector:4318/v1/logs")
+	signingCertFile  = flag.String("cluster-signing-cert-file", "", "PEM CA certificate issuing the certificates of approved certificate signing requests (empty disables the signer)")
+	signingKeyFile   = flag.String("cluster-signing-key-file", "", "PEM private key of --cluster-signing-cert-file")
+	enablePprof      = flag.Bool("enable-pprof", false, "Serve Go runtime profiles of the controllers and scheduler under /debug/pprof/")
+	pprofAddress     = flag.String("pprof-address", profiling.DefaultControllerManagerAddress, "Address to serve profiles on when --enable-pprof is set")
+	shutdownTimeout  = flag.Duration("shutdown-timeout", lifecycle.DefaultStopTimeout, "How long the scheduler and the controllers each get to finish their in-flight work on shutdown")
 )
 
 func main() {
 	flag.Parse()
 
+	periods, err := parseResyncPeriods(*resyncPeriods)
+	if err != nil {
+		log.Fatalf("Invalid --controller-resync-periods: %v", err)
+	}
+	if (*signingCertFile == "") != (*signingKeyFile == "") {
+		log.Fatalf("--cluster-signing-cert-file and --cluster-signing-key-file must be set together")
+	}
+	clusterIPRange, err := netip.ParsePrefix(*serviceIPRange)
+	if err != nil || !clusterIPRange.Addr().Is4() {
+		log.Fatalf("Invalid --service-cluster-ip-range %q: expected an IPv4 CIDR", *serviceIPRange)
+	}
+
 	// Create store configuration
+	routes, err := store.ParseStoreRoutes(*storeRoutes)
+	if err != nil {
+		log.Fatalf("Invalid --store-routes: %v", err)
+	}
 	storeConfig := &store.StoreConfig{
 		Type:      store.StoreType(*storeType),
 		Endpoints: []string{*etcdEndpoints},
 		Prefix:    *storePrefix,
 		Options:   store.DefaultOptions(),
+		Routes:    routes,
 	}
 
 	// Create store
 	var s store.Store
-	var err error
 
 	if *enableFallback {
 		s, err = store.NewStoreWithFallback(storeConfig)
@@ -57,56 +104,187 @@ func main() {
 		fmt.Printf("Etcd endpoints: %v\n", storeConfig.Endpoints)
 		fmt.Printf("Store prefix: %s\n", storeConfig.Prefix)
 	}
+	for _, route := range storeConfig.Routes {
+		fmt.Printf("Store route: %s/%s -> %s %s\n", route.Kind, route.Namespace, route.Type, route.Prefix)
+	}
 	fmt.Printf("Controller sync interval: %v\n", *syncInterval)
+	for name, period := range periods {
+		fmt.Printf("Controller %s resync period: %v\n", name, period)
+	}
 	fmt.Printf("Scheduler sync interval: %v\n", *scheduleInterval)
+	fmt.Printf("Event TTL: %v\n", *eventTTL)
+	fmt.Printf("Node monitor grace period: %v (clock skew tolerance %v, NotReady after %d of %d missed checks)\n",
+		*nodeGracePeriod, *nodeClockSkew, *nodeMissed, *nodeWindow)
+	fmt.Printf("Default unreachable toleration: %v\n", *unreachableWait)
+	fmt.Printf("Service cluster IP range: %s\n", clusterIPRange)
+	if *hollowNodes > 0 {
+		fmt.Printf("Cluster autoscaling: up to %d hollow nodes\n", *hollowNodes)
+	}
+	if *deschedule > 0 {
+		fmt.Printf("Descheduler interval: %v (utilization threshold %.2f)\n", *deschedule, *utilization)
+	}
+	if *metricsURL != "" {
+		fmt.Printf("External metrics adapter: %s\n", *metricsURL)
+	}
+	if *signingCertFile != "" {
+		fmt.Printf("Certificate signer: %s\n", *signingCertFile)
+	}
+
+	if *enablePprof {
+		if _, err := profiling.Serve(*pprofAddress); err != nil {
+			log.Fatalf("Failed to serve profiles: %v", err)
+		}
+	}
+
+	// Event recorders for the scheduler and the controllers
+	hostname, _ := os.Hostname()
+	schedulerEvents := events.NewRecorder(&events.Config{
+		Store:              s,
+		Component:          "scheduler",
+		Host:               hostname,
+		MaxEventsPerObject: *maxObjectEvents,
+	})
+	controllerEvents := events.NewRecorder(&events.Config{
+		Store:              s,
+		Component:          "controller-manager",
+		Host:               hostname,
+		MaxEventsPerObject: *maxObjectEvents,
+	})
 
 	// Create scheduler
 	schedulerConfig := &scheduler.Config{
 		Store:               s,
 		DefaultNodeSelector: map[string]string{},
 		SchedulingInterval:  *scheduleInterval,
+		Recorder:            schedulerEvents,
 	}
 	sched := scheduler.NewScheduler(schedulerConfig)
 
+	// The descheduler runs on its own period rather than the sync interval
+	var descheduler *controller.DeschedulerController
+	if *deschedule > 0 {
+		descheduler = controller.NewDeschedulerController(s, controller.DeschedulerConfig{UtilizationThreshold: *utilization})
+		descheduler.SetEventRecorder(controllerEvents)
+		if _, ok := periods[descheduler.Name()]; !ok {
+			periods[descheduler.Name()] = *deschedule
+		}
+	}
+
 	// Create controller manager
 	controllerConfig := &controller.Config{
-		Store:        s,
-		SyncInterval: *syncInterval,
+		Store:         s,
+		SyncInterval:  *syncInterval,
+		ResyncPeriods: periods,
+		ResyncJitter:  *resyncJitter,
 	}
 	ctrlMgr := controller.NewManager(controllerConfig)
 
 	// Add controllers
 	deploymentCtrl := controller.NewDeploymentController(s)
 	replicaSetCtrl := controller.NewReplicaSetController(s)
+	replicaSetCtrl.SetEventRecorder(controllerEvents)
 	ctrlMgr.AddController(deploymentCtrl)
 	ctrlMgr.AddController(replicaSetCtrl)
+	jobCtrl := controller.NewJobController(s)
+	jobCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(jobCtrl)
+	cronJobCtrl := controller.NewCronJobController(s)
+	cronJobCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(cronJobCtrl)
+	ctrlMgr.AddController(controller.NewEventTTLController(s, *eventTTL))
+	nodeLifecycleCtrl := controller.NewNodeLifecycleController(s, controller.NodeLifecycleConfig{
+		GracePeriod:           *nodeGracePeriod,
+		ClockSkewTolerance:    *nodeClockSkew,
+		MissedHeartbeats:      *nodeMissed,
+		HeartbeatWindow:       *nodeWindow,
+		UnreachableToleration: *unreachableWait,
+	})
+	nodeLifecycleCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(nodeLifecycleCtrl)
+	ctrlMgr.AddController(controller.NewResourceRecommenderController(s, controller.RecommenderConfig{Window: *recommendWindow}))
+	serviceCtrl := controller.NewServiceController(s, controller.ServiceConfig{ClusterIPRange: clusterIPRange})
+	serviceCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(serviceCtrl)
+	var hollow *nodeagent.HollowNodeProvisioner
+	if *hollowNodes > 0 {
+		hollow = nodeagent.NewHollowNodeProvisioner(&nodeagent.HollowNodeConfig{Store: s})
+		ctrlMgr.AddController(controller.NewClusterAutoscalerController(s, hollow, controller.ClusterAutoscalerConfig{MaxNodes: *hollowNodes}))
+	}
+	if *eventsMetrics != "" || *eventsOTLP != "" {
+		exporter := events.NewExporter(&events.ExporterConfig{Store: s, OTLPEndpoint: *eventsOTLP})
+		if *eventsMetrics != "" {
+			if _, err := exporter.Serve(*eventsMetrics); err != nil {
+				log.Fatalf("Failed to serve event metrics: %v", err)
+			}
+		}
+		ctrlMgr.AddController(exporter)
+	}
+	if descheduler != nil {
+		ctrlMgr.AddController(descheduler)
+	}
+	if *signingCertFile != "" {
+		caCert, err := os.ReadFile(*signingCertFile)
+		if err != nil {
+			log.Fatalf("Failed to read --cluster-signing-cert-file: %v", err)
+		}
+		caKey, err := os.ReadFile(*signingKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to read --cluster-signing-key-file: %v", err)
+		}
+		ctrlMgr.AddController(controller.NewCertificateSignerController(s, caCert, caKey))
+	}
+	if *metricsURL != "" {
+		ctrlMgr.AddController(controller.NewHorizontalPodAutoscalerController(s, controller.NewHTTPMetricsAdapter(*metricsURL)))
+	}
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Start scheduler
-	if err := sched.Start(ctx); err != nil {
-		log.Fatalf("Failed to start scheduler: %v", err)
+	// Components start in this order and stop in the reverse one, so the
+	// leases expire before the scheduler and controllers finish their
+	// in-flight work, and hollow nodes outlive the autoscaler using them
+	schedulerLease := lease.NewHeartbeat(&lease.Config{Store: s, Name: api.LeaseScheduler})
+	managerLease := lease.NewHeartbeat(&lease.Config{Store: s, Name: api.LeaseControllerManager})
+	components := lifecycle.NewManager(&lifecycle.Config{StopTimeout: *shutdownTimeout})
+	if hollow != nil {
+		components.Add("hollow nodes", lifecycle.Stopper(nil, hollow.Stop))
 	}
+	components.Add("scheduler", lifecycle.Stopper(sched.Start, sched.Stop))
+	components.Add("scheduler lease", lifecycle.Stopper(schedulerLease.Start, schedulerLease.Stop))
+	components.Add("controller manager", lifecycle.Stopper(ctrlMgr.Start, ctrlMgr.Stop))
+	components.Add("controller manager lease", lifecycle.Stopper(managerLease.Start, managerLease.Stop))
 
-	// Start controller manager
-	if err := ctrlMgr.Start(ctx); err != nil {
+	if err := components.Start(context.Background()); err != nil {
 		log.Fatalf("Failed to start controller manager: %v", err)
 	}
 
 	fmt.Printf("Controller manager started successfully\n")
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	<-sigChan
+	lifecycle.WaitForSignal()
 	fmt.Println("\nShutting down controller manager...")
 
-	// Stop scheduler and controller manager
-	sched.Stop()
-	ctrlMgr.Stop()
+	if err := components.Shutdown(context.Background()); err != nil {
+		fmt.Printf("Error shutting down controller manager: %v\n", err)
+	}
 
 	fmt.Println("Controller manager stopped")
 }
+
+// parseResyncPeriods parses a comma-separated list of name=duration pairs
+func parseResyncPeriods(value string) (map[string]time.Duration, error) {
+	periods := make(map[string]time.Duration)
+	if value == "" {
+		return periods, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, durationStr, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected name=duration, got %q", pair)
+		}
+		period, err := time.ParseDuration(durationStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for %s: %w", name, err)
+		}
+		periods[name] = period
+	}
+
+	return periods, nil
+}
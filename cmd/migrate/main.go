@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// Store kinds accepted by --from and --to
+const (
+	backendEtcd     = "etcd"
+	backendSnapshot = "snapshot"
+)
+
+var (
+	from          = flag.String("from", backendSnapshot, "Source store: etcd or snapshot")
+	fromEndpoints = flag.String("from-endpoints", "localhost:2379", "Comma-separated etcd endpoints of the source store")
+	fromPrefix    = flag.String("from-prefix", "/minik8s", "Key prefix of the source store")
+	fromFile      = flag.String("from-file", "", "Snapshot file to read when --from=snapshot")
+
+	to          = flag.String("to", backendEtcd, "Destination store: etcd or snapshot")
+	toEndpoints = flag.String("to-endpoints", "localhost:2379", "Comma-separated etcd endpoints of the destination store")
+	toPrefix    = flag.String("to-prefix", "/minik8s", "Key prefix of the destination store")
+	toFile      = flag.String("to-file", "", "Snapshot file to write when --to=snapshot")
+
+	overwrite = flag.Bool("overwrite", false, "Migrate even if the destination already holds objects")
+	verify    = flag.Bool("verify", true, "Compare every object with its copy after migrating")
+)
+
+func main() {
+	flag.Parse()
+	ctx := context.Background()
+
+	source, err := openStore(ctx, *from, *fromEndpoints, *fromPrefix, *fromFile, true)
+	if err != nil {
+		log.Fatalf("Failed to open source store: %v", err)
+	}
+	defer source.Close()
+
+	dest, err := openStore(ctx, *to, *toEndpoints, *toPrefix, *toFile, false)
+	if err != nil {
+		log.Fatalf("Failed to open destination store: %v", err)
+	}
+	defer dest.Close()
+
+	if !*overwrite {
+		if kind, err := firstNonEmptyKind(ctx, dest); err != nil {
+			log.Fatalf("Failed to check destination store: %v", err)
+		} else if kind != "" {
+			log.Fatalf("Destination store already holds %s objects; use --overwrite to migrate anyway", kind)
+		}
+	}
+
+	fmt.Printf("Migrating from %s to %s\n", describe(*from, *fromEndpoints, *fromPrefix, *fromFile), describe(*to, *toEndpoints, *toPrefix, *toFile))
+	result, err := store.Migrate(ctx, source, dest)
+	if err != nil {
+		log.Fatalf("Migration failed after %d objects: %v", result.Total(), err)
+	}
+	for _, kind := range store.Kinds() {
+		if n := result.Objects[kind]; n > 0 {
+			fmt.Printf("  %-16s %d\n", kind, n)
+		}
+	}
+	fmt.Printf("Copied %d objects\n", result.Total())
+	if !result.PreservedVersions {
+		fmt.Println("Warning: destination assigned new resource versions")
+	}
+
+	if *verify {
+		problems, err := store.Verify(ctx, source, dest, result.PreservedVersions)
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+		for _, problem := range problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		if len(problems) > 0 {
+			log.Fatalf("Verification found %d mismatched objects", len(problems))
+		}
+		fmt.Println("Verified all objects")
+	}
+
+	if *to == backendSnapshot {
+		if err := store.SaveSnapshotFile(ctx, dest, *toFile); err != nil {
+			log.Fatalf("Failed to write snapshot: %v", err)
+		}
+		fmt.Printf("Wrote snapshot to %s\n", *toFile)
+	}
+}
+
+// openStore opens an etcd store, or an in-memory store holding a snapshot.
+// A snapshot source is loaded from file; a snapshot destination starts empty
+// and is written out once the migration is done.
+func openStore(ctx context.Context, backend, endpoints, prefix, file string, source bool) (store.Store, error) {
+	switch backend {
+	case backendEtcd:
+		return store.NewStore(&store.StoreConfig{
+			Type:      store.StoreTypeEtcd,
+			Endpoints: strings.Split(endpoints, ","),
+			Prefix:    prefix,
+			Options:   store.DefaultOptions(),
+		})
+	case backendSnapshot:
+		if file == "" {
+			return nil, fmt.Errorf("a snapshot file is required")
+		}
+		s := store.NewMemoryStore(nil)
+		if !source {
+			return s, nil
+		}
+		if _, err := store.LoadSnapshotFile(ctx, s, file); err != nil {
+			s.Close()
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown store %q, expected %s or %s", backend, backendEtcd, backendSnapshot)
+	}
+}
+
+// firstNonEmptyKind returns the first kind with objects in s, or "" if s is empty
+func firstNonEmptyKind(ctx context.Context, s store.Store) (string, error) {
+	for _, kind := range store.Kinds() {
+		objects, err := s.List(ctx, kind, "")
+		if err != nil {
+			return "", err
+		}
+		if len(objects) > 0 {
+			return kind, nil
+		}
+	}
+	return "", nil
+}
+
+// describe names a store for the migration log
+func describe(backend, endpoints, prefix, file string) string {
+	if backend == backendSnapshot {
+		return fmt.Sprintf("snapshot %s", file)
+	}
+	return fmt.Sprintf("etcd %s%s", endpoints, prefix)
+}
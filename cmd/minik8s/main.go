@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	// CronJob time zones resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/apiserver"
+	"github.com/minik8s/minik8s/pkg/controller"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/httpclient"
+	"github.com/minik8s/minik8s/pkg/lease"
+	"github.com/minik8s/minik8s/pkg/lifecycle"
+	"github.com/minik8s/minik8s/pkg/nodeagent"
+	"github.com/minik8s/minik8s/pkg/scheduler"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// apiServerStartTimeout bounds how long the node agent waits for the API
+// server to answer before starting anyway
+const apiServerStartTimeout = 10 * time.Second
+
+var (
+	port             = flag.Int("port", 8080, "Port the API server listens on")
+	nodeName         = flag.String("node-name", "minik8s", "Name of the local node")
+	nodePort         = flag.Int("node-port", api.DefaultNodeAgentPort, "Port for exec and other streaming requests to the local node (0 disables)")
+	runtimeName      = flag.String("container-runtime", "mock", "Container runtime: mock, exec to run containers as host processes, or docker to use the Docker daemon of DOCKER_HOST")
+	rootDir          = flag.String("root-dir", nodeagent.DefaultVolumeRootDir, "Directory holding the local node's volumes, checkpoints and exec runtime state")
+	snapshotFile     = flag.String("snapshot-file", "", "Load the cluster state from this file at startup and save it there on shutdown (empty keeps it in memory only)")
+	syncInterval     = flag.Duration("sync-interval", 30*time.Second, "Controller sync interval")
+	scheduleInterval = flag.Duration("schedule-interval", 10*time.Second, "Scheduler sync interval")
+	heartbeat        = flag.Duration("heartbeat-interval", 30*time.Second, "Node heartbeat interval")
+	podSyncInterval  = flag.Duration("pod-sync-interval", nodeagent.DefaultPodSyncInterval, "Node agent pod sync interval; changes to the node's pods are synced right away regardless")
+	eventTTL         = flag.Duration("event-ttl", events.DefaultTTL, "How long events are kept after they were last seen")
+	eventsMetrics    = flag.String("events-metrics-address", "", "Address to serve event and pod phase metrics on under /metrics (empty disables)")
+	hollowNodes      = flag.Int("max-hollow-nodes", 0, "Add up to this many hollow nodes while pods are unschedulable, removing them once empty (0 disables cluster autoscaling)")
+	shutdownTimeout  = flag.Duration("shutdown-timeout", lifecycle.DefaultStopTimeout, "How long each component gets to finish its in-flight work on shutdown")
+)
+
+// minik8s runs a whole single-node cluster in one process: the API server,
+// scheduler and controllers, and a node agent, sharing an in-memory store
+func main() {
+	// --runtime is the former name of --container-runtime
+	flag.StringVar(runtimeName, "runtime", *runtimeName, "Alias of --container-runtime")
+	flag.Parse()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := store.NewMemoryStore(store.DefaultOptions())
+	defer s.Close()
+	if *snapshotFile != "" {
+		if n, err := store.LoadSnapshotFile(ctx, s, *snapshotFile); err == nil {
+			fmt.Printf("Loaded %d objects from snapshot %s\n", n, *snapshotFile)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("Failed to load snapshot: %v", err)
+		}
+	}
+
+	// API server
+	server := apiserver.NewServer(store.NewLoggingStore(s, store.DefaultSlowThreshold), *port)
+	server.EnableHistory(apiserver.DefaultHistoryLimit)
+	if err := server.Bootstrap(ctx); err != nil {
+		log.Fatalf("Failed to bootstrap namespaces: %v", err)
+	}
+	apiServerURL := fmt.Sprintf("http://localhost:%d", *port)
+
+	// Only the API server may stream to the local node
+	var tokenBytes [16]byte
+	if _, err := rand.Read(tokenBytes[:]); err != nil {
+		log.Fatalf("Failed to generate node agent token: %v", err)
+	}
+	nodeAgentToken := hex.EncodeToString(tokenBytes[:])
+	server.SetNodeAgentToken(nodeAgentToken)
+
+	// Scheduler and controllers
+	hostname, _ := os.Hostname()
+	sched := scheduler.NewScheduler(&scheduler.Config{
+		Store:               s,
+		DefaultNodeSelector: map[string]string{},
+		SchedulingInterval:  *scheduleInterval,
+		Recorder:            events.NewRecorder(&events.Config{Store: s, Component: "scheduler", Host: hostname}),
+	})
+	ctrlMgr := controller.NewManager(&controller.Config{
+		Store:        s,
+		SyncInterval: *syncInterval,
+		ResyncJitter: controller.DefaultResyncJitter,
+	})
+	controllerEvents := events.NewRecorder(&events.Config{Store: s, Component: "controller-manager", Host: hostname})
+	replicaSetCtrl := controller.NewReplicaSetController(s)
+	replicaSetCtrl.SetEventRecorder(controllerEvents)
+	jobCtrl := controller.NewJobController(s)
+	jobCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(controller.NewDeploymentController(s))
+	ctrlMgr.AddController(replicaSetCtrl)
+	ctrlMgr.AddController(jobCtrl)
+	cronJobCtrl := controller.NewCronJobController(s)
+	cronJobCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(cronJobCtrl)
+	ctrlMgr.AddController(controller.NewEventTTLController(s, *eventTTL))
+	ctrlMgr.AddController(controller.NewNodeLifecycleController(s, controller.NodeLifecycleConfig{}))
+	ctrlMgr.AddController(controller.NewResourceRecommenderController(s, controller.RecommenderConfig{}))
+	serviceCtrl := controller.NewServiceController(s, controller.ServiceConfig{})
+	serviceCtrl.SetEventRecorder(controllerEvents)
+	ctrlMgr.AddController(serviceCtrl)
+	if *eventsMetrics != "" {
+		exporter := events.NewExporter(&events.ExporterConfig{Store: s})
+		if _, err := exporter.Serve(*eventsMetrics); err != nil {
+			log.Fatalf("Failed to serve event metrics: %v", err)
+		}
+		ctrlMgr.AddController(exporter)
+	}
+	var hollow *nodeagent.HollowNodeProvisioner
+	if *hollowNodes > 0 {
+		hollow = nodeagent.NewHollowNodeProvisioner(&nodeagent.HollowNodeConfig{Store: s, HeartbeatInterval: *heartbeat})
+		ctrlMgr.AddController(controller.NewClusterAutoscalerController(s, hollow, controller.ClusterAutoscalerConfig{MaxNodes: *hollowNodes}))
+	}
+
+	// Local node
+	volumeMgr := nodeagent.NewVolumePluginManager(&nodeagent.VolumePluginConfig{
+		RootDir:      *rootDir,
+		Store:        s,
+		APIServerURL: apiServerURL,
+		HTTPClient:   httpclient.New(nil),
+	})
+	var criRuntime nodeagent.CRIRuntime
+	switch *runtimeName {
+	case "mock":
+		criRuntime = nodeagent.NewMockCRIRuntime()
+	case "exec":
+		criRuntime = nodeagent.NewExecRuntime(&nodeagent.ExecRuntimeConfig{
+			RootDir: filepath.Join(*rootDir, "exec"),
+		})
+	case "docker":
+		docker, err := nodeagent.NewDockerRuntime(&nodeagent.DockerRuntimeConfig{Volumes: volumeMgr})
+		if err != nil {
+			log.Fatalf("Invalid --container-runtime: %v", err)
+		}
+		criRuntime = docker
+	default:
+		log.Fatalf("Invalid --container-runtime %q: use mock, exec or docker", *runtimeName)
+	}
+	agent := nodeagent.NewAgent(&nodeagent.Config{
+		NodeName:          *nodeName,
+		APIServerURL:      apiServerURL,
+		Store:             s,
+		CRIRuntime:        criRuntime,
+		NetworkManager:    &nodeagent.MockNetworkManager{},
+		VolumeManager:     volumeMgr,
+		HeartbeatInterval: *heartbeat,
+		PodSyncInterval:   *podSyncInterval,
+		CheckpointDir:     filepath.Join(*rootDir, "checkpoints"),
+		ContainerLogDir:   filepath.Join(*rootDir, "logs"),
+		ServerPort:        *nodePort,
+		ServerToken:       nodeAgentToken,
+		NodeAddress:       "localhost",
+	})
+
+	// Components start in this order and stop in the reverse one: the node
+	// first, then the controllers and the scheduler once their in-flight
+	// work is done, and the API server last, so the snapshot holds every
+	// write the others made
+	schedulerLease := lease.NewHeartbeat(&lease.Config{Store: s, Name: api.LeaseScheduler})
+	managerLease := lease.NewHeartbeat(&lease.Config{Store: s, Name: api.LeaseControllerManager})
+	components := lifecycle.NewManager(&lifecycle.Config{StopTimeout: *shutdownTimeout})
+	if *snapshotFile != "" {
+		components.OnShutdown("snapshot", func(ctx context.Context) error {
+			if err := store.SaveSnapshotFile(ctx, s, *snapshotFile); err != nil {
+				return fmt.Errorf("failed to save snapshot: %w", err)
+			}
+			fmt.Printf("Saved snapshot to %s\n", *snapshotFile)
+			return nil
+		})
+	}
+	components.Add("API server", lifecycle.Funcs{
+		StartFunc: func(ctx context.Context) error {
+			go func() {
+				if err := server.Start(); err != nil {
+					log.Fatalf("Failed to start API server: %v", err)
+				}
+			}()
+			return waitForAPIServer(apiServerURL, apiServerStartTimeout)
+		},
+		StopFunc: server.Shutdown,
+	})
+	if hollow != nil {
+		components.Add("hollow nodes", lifecycle.Stopper(nil, hollow.Stop))
+	}
+	components.Add("scheduler", lifecycle.Stopper(sched.Start, sched.Stop))
+	components.Add("scheduler lease", lifecycle.Stopper(schedulerLease.Start, schedulerLease.Stop))
+	components.Add("controller manager", lifecycle.Stopper(ctrlMgr.Start, ctrlMgr.Stop))
+	components.Add("controller manager lease", lifecycle.Stopper(managerLease.Start, managerLease.Stop))
+	components.Add("node agent", lifecycle.Stopper(agent.Start, agent.Stop))
+	if err := components.Start(ctx); err != nil {
+		log.Fatalf("Failed to start minik8s: %v", err)
+	}
+
+	fmt.Printf("minik8s started: API server %s, node %s, %s runtime\n", apiServerURL, *nodeName, *runtimeName)
+	fmt.Printf("Try: cli --server %s get nodes\n", apiServerURL)
+	fmt.Println("Press Ctrl+C to stop")
+
+	lifecycle.WaitForSignal()
+	fmt.Println("\nShutting down minik8s...")
+
+	if err := components.Shutdown(context.Background()); err != nil {
+		fmt.Printf("Error shutting down minik8s: %v\n", err)
+	}
+
+	fmt.Println("minik8s stopped")
+}
+
+// waitForAPIServer polls the API server's health check until it answers
+func waitForAPIServer(url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := http.Get(url + "/healthz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			err = fmt.Errorf("health check returned %s", resp.Status)
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
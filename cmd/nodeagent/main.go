@@ -2,29 +2,71 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/httpclient"
+	"github.com/minik8s/minik8s/pkg/lifecycle"
 	"github.com/minik8s/minik8s/pkg/nodeagent"
+	"github.com/minik8s/minik8s/pkg/profiling"
 	"github.com/minik8s/minik8s/pkg/store"
 )
 
 var (
-	nodeName          = flag.String("node-name", "", "Name of this node (required)")
-	apiServerURL      = flag.String("api-server", "http://localhost:8080", "API server URL")
-	storeType         = flag.String("store", "memory", "Store type: memory or etcd")
-	etcdEndpoints     = flag.String("etcd-endpoints", "localhost:2379", "Comma-separated list of etcd endpoints")
-	storePrefix       = flag.String("store-prefix", "/minik8s", "Store key prefix")
-	enableFallback    = flag.Bool("enable-fallback", true, "Enable fallback to in-memory store if etcd fails")
-	heartbeatInterval = flag.Duration("heartbeat-interval", 30*time.Second, "Heartbeat interval")
+	nodeName             = flag.String("node-name", "", "Name of this node (required)")
+	apiServerURL         = flag.String("api-server", "http://localhost:8080", "API server URL")
+	storeType            = flag.String("store", "memory", "Store type: memory or etcd")
+	etcdEndpoints        = flag.String("etcd-endpoints", "localhost:2379", "Comma-separated list of etcd endpoints")
+	storePrefix          = flag.String("store-prefix", "/minik8s", "Store key prefix")
+	storeRoutes          = flag.String("store-routes", "", "Comma-separated Kind[/namespace]=type[:prefix] routes sending kinds or namespaces to their own store")
+	enableFallback       = flag.Bool("enable-fallback", true, "Enable fallback to in-memory store if etcd fails")
+	heartbeatInterval    = flag.Duration("heartbeat-interval", 30*time.Second, "Heartbeat interval")
+	podSyncInterval      = flag.Duration("pod-sync-interval", nodeagent.DefaultPodSyncInterval, "Interval for syncing pods; changes to this node's pods are synced right away regardless")
+	checkpointDir        = flag.String("checkpoint-dir", nodeagent.DefaultCheckpointDir, "Directory for pod checkpoints used to recover after restarts (empty disables)")
+	containerGCInterval  = flag.Duration("container-gc-interval", nodeagent.DefaultContainerGCInterval, "Interval for removing containers of deleted pods (negative disables)")
+	containerLogDir      = flag.String("container-log-dir", nodeagent.DefaultContainerLogDir, "Directory keeping the logs of replaced containers, served as previous logs (empty disables)")
+	containerLogMaxSize  = flag.Int64("container-log-max-size", nodeagent.DefaultContainerLogMaxSize, "Size in bytes past which a container log is rotated (negative disables rotation)")
+	containerLogMaxFiles = flag.Int("container-log-max-files", nodeagent.DefaultContainerLogMaxFiles, "Number of rotated files kept per container log")
+	containerLogMaxAge   = flag.Duration("container-log-max-age", 0, "Age past which rotated container log files are removed (0 keeps them until rotated out)")
+	volumeRootDir        = flag.String("root-dir", nodeagent.DefaultVolumeRootDir, "Directory holding per-pod volume directories")
+	csiDrivers           = flag.String("csi-drivers", "", "Comma-separated CSI-lite drivers as name=endpoint, e.g. nfs.example.com=unix:///run/csi/nfs.sock")
+	apiTimeout           = flag.Duration("api-timeout", httpclient.DefaultTimeout, "Timeout for each API server request attempt")
+	apiRetries           = flag.Int("api-retries", httpclient.DefaultMaxRetries, "Retries for idempotent API server requests on connection errors and 5xx responses (negative disables)")
+	address              = flag.String("address", nodeagent.DefaultServerAddress, "Address to serve exec and other streaming requests on; addresses other than loopback ones require --server-token-file")
+	port                 = flag.Int("port", api.DefaultNodeAgentPort, "Port for exec and other streaming requests (0 disables)")
+	serverTokenFile      = flag.String("server-token-file", "", "File with the bearer token streaming requests must carry, shared with the API server's --node-agent-token-file")
+	nodeIP               = flag.String("node-ip", "", "Address the API server uses to reach this node (defaults to the node name)")
+	runtimeName          = flag.String("container-runtime", "mock", "Container runtime: mock, exec to run containers as host processes, or docker")
+	dockerHost           = flag.String("docker-host", "", "Docker daemon of the docker runtime, unix:///path or tcp://host:port (defaults to DOCKER_HOST, then "+nodeagent.DefaultDockerHost+")")
+	sandboxImage         = flag.String("sandbox-image", nodeagent.DefaultSandboxImage, "Image of the pause containers holding the namespaces of docker runtime pods")
+	runtimeHandlers      = flag.String("runtime-handlers", "", "Comma-separated runtimes RuntimeClasses can choose as handler=runtime, e.g. sandboxed=exec")
+	nodeLabels           = flag.String("node-labels", "", "Comma-separated labels to set on the node, e.g. pool=gpu,zone=lab-1")
+	registerTaints       = flag.String("register-with-taints", "", "Comma-separated taints to add to the node, e.g. dedicated=gpu:NoSchedule")
+	statusMaxStaleness   = flag.Duration("status-max-staleness", nodeagent.DefaultStatusMaxStaleness, "Longest time an unchanged node or pod status goes without being written (negative writes every report)")
+	enablePprof          = flag.Bool("enable-pprof", false, "Serve Go runtime profiles under /debug/pprof/ on --pprof-address")
+	pprofAddress         = flag.String("pprof-address", profiling.DefaultNodeAgentAddress, "Address to serve profiles on when --enable-pprof is set")
+	join                 = flag.String("join", "", "Join the cluster as <bootstrap-token>@<api-server>, obtaining node credentials; overrides --api-server")
+	caCertHash           = flag.String("ca-cert-hash", "", "sha256:<hex> hash pinning the cluster CA when joining, as printed by cli admin init")
+	shutdownTimeout      = flag.Duration("shutdown-timeout", lifecycle.DefaultStopTimeout, "How long the agent gets to stop once its pods are dealt with")
+	shutdownGracePeriod  = flag.Duration("shutdown-grace-period", nodeagent.DefaultShutdownGracePeriod, "How long the pods of this node get to stop on SIGTERM before the agent exits (SIGINT leaves them running)")
+	credentialsDir       = flag.String("credentials-dir", nodeagent.DefaultCredentialsDir, "Directory holding the credentials obtained by joining")
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		runPodCommand(os.Args[2:])
+		return
+	}
+	// --runtime is the former name of --container-runtime
+	flag.StringVar(runtimeName, "runtime", *runtimeName, "Alias of --container-runtime")
 	flag.Parse()
 
 	// Validate required flags
@@ -33,16 +75,20 @@ func main() {
 	}
 
 	// Create store configuration
+	routes, err := store.ParseStoreRoutes(*storeRoutes)
+	if err != nil {
+		log.Fatalf("Invalid --store-routes: %v", err)
+	}
 	storeConfig := &store.StoreConfig{
 		Type:      store.StoreType(*storeType),
 		Endpoints: []string{*etcdEndpoints},
 		Prefix:    *storePrefix,
 		Options:   store.DefaultOptions(),
+		Routes:    routes,
 	}
 
 	// Create store
 	var s store.Store
-	var err error
 
 	if *enableFallback {
 		s, err = store.NewStoreWithFallback(storeConfig)
@@ -63,47 +109,260 @@ func main() {
 		fmt.Printf("Etcd endpoints: %v\n", storeConfig.Endpoints)
 		fmt.Printf("Store prefix: %s\n", storeConfig.Prefix)
 	}
+	for _, route := range storeConfig.Routes {
+		fmt.Printf("Store route: %s/%s -> %s %s\n", route.Kind, route.Namespace, route.Type, route.Prefix)
+	}
 	fmt.Printf("Heartbeat interval: %v\n", *heartbeatInterval)
 
-	// Create mock runtime components for now
-	criRuntime := nodeagent.NewMockCRIRuntime()
+	drivers, err := parseCSIDrivers(*csiDrivers)
+	if err != nil {
+		log.Fatalf("Invalid --csi-drivers: %v", err)
+	}
+	labels, err := parseNodeLabels(*nodeLabels)
+	if err != nil {
+		log.Fatalf("Invalid --node-labels: %v", err)
+	}
+	taints, err := parseTaints(*registerTaints)
+	if err != nil {
+		log.Fatalf("Invalid --register-with-taints: %v", err)
+	}
+
+	// Authenticate to the API server with the credentials obtained by joining
+	httpConfig := &httpclient.Config{Timeout: *apiTimeout, MaxRetries: *apiRetries}
+	if *join != "" {
+		credentials, server, err := joinCluster(*join)
+		if err != nil {
+			log.Fatalf("Failed to join the cluster: %v", err)
+		}
+		*apiServerURL = server
+		httpConfig.BearerToken = credentials.Token
+		if httpConfig.Transport, err = credentials.Transport(); err != nil {
+			log.Fatalf("Invalid cluster CA: %v", err)
+		}
+	}
+
+	// Create mock network components for now
 	networkMgr := &nodeagent.MockNetworkManager{}
-	volumeMgr := &nodeagent.MockVolumeManager{}
+	volumeMgr := nodeagent.NewVolumePluginManager(&nodeagent.VolumePluginConfig{
+		RootDir:      *volumeRootDir,
+		Store:        s,
+		APIServerURL: *apiServerURL,
+		HTTPClient:   httpclient.New(httpConfig),
+		CSIDrivers:   drivers,
+	})
+
+	criRuntime, err := newRuntime(*runtimeName, filepath.Join(*volumeRootDir, "exec"), volumeMgr)
+	if err != nil {
+		log.Fatalf("Invalid --container-runtime: %v", err)
+	}
+	fmt.Printf("Container runtime: %s\n", *runtimeName)
+
+	handlers, err := parseRuntimeHandlers(*runtimeHandlers, volumeMgr)
+	if err != nil {
+		log.Fatalf("Invalid --runtime-handlers: %v", err)
+	}
+
+	var serverToken string
+	if *serverTokenFile != "" {
+		data, err := os.ReadFile(*serverTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to read --server-token-file: %v", err)
+		}
+		serverToken = strings.TrimSpace(string(data))
+	}
+
+	if *enablePprof {
+		if _, err := profiling.Serve(*pprofAddress); err != nil {
+			log.Fatalf("Failed to serve profiles: %v", err)
+		}
+	}
 
 	// Create node agent configuration
 	agentConfig := &nodeagent.Config{
-		NodeName:          *nodeName,
-		APIServerURL:      *apiServerURL,
-		Store:             s,
-		CRIRuntime:        criRuntime,
-		NetworkManager:    networkMgr,
-		VolumeManager:     volumeMgr,
-		HeartbeatInterval: *heartbeatInterval,
+		NodeName:             *nodeName,
+		APIServerURL:         *apiServerURL,
+		Store:                s,
+		CRIRuntime:           criRuntime,
+		NetworkManager:       networkMgr,
+		VolumeManager:        volumeMgr,
+		HeartbeatInterval:    *heartbeatInterval,
+		PodSyncInterval:      *podSyncInterval,
+		CheckpointDir:        *checkpointDir,
+		ContainerGCInterval:  *containerGCInterval,
+		ContainerLogDir:      *containerLogDir,
+		ContainerLogMaxSize:  *containerLogMaxSize,
+		ContainerLogMaxFiles: *containerLogMaxFiles,
+		ContainerLogMaxAge:   *containerLogMaxAge,
+		ServerAddress:        *address,
+		ServerPort:           *port,
+		ServerToken:          serverToken,
+		NodeAddress:          *nodeIP,
+		NodeLabels:           labels,
+		RegisterTaints:       taints,
+		StatusMaxStaleness:   *statusMaxStaleness,
+		RuntimeHandlers:      handlers,
+		ShutdownGracePeriod:  *shutdownGracePeriod,
 	}
 
 	// Create and start node agent
 	agent := nodeagent.NewAgent(agentConfig)
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Start the agent
-	if err := agent.Start(ctx); err != nil {
+	components := lifecycle.NewManager(&lifecycle.Config{StopTimeout: *shutdownTimeout})
+	components.Add("node agent", lifecycle.Stopper(agent.Start, agent.Stop))
+	if err := components.Start(context.Background()); err != nil {
 		log.Fatalf("Failed to start node agent: %v", err)
 	}
 
 	fmt.Printf("Node agent started successfully\n")
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	<-sigChan
+	sig := lifecycle.WaitForSignal()
 	fmt.Println("\nShutting down node agent...")
 
-	// Stop the agent
-	agent.Stop()
+	// SIGTERM means the node goes away, so its pods are stopped gracefully
+	// and marked failed. On SIGINT they keep running, and the agent adopts
+	// them when it starts again.
+	if sig == syscall.SIGTERM {
+		if err := agent.Shutdown(context.Background()); err != nil {
+			fmt.Printf("Error shutting down node: %v\n", err)
+		}
+	}
+
+	if err := components.Shutdown(context.Background()); err != nil {
+		fmt.Printf("Error stopping node agent: %v\n", err)
+	}
 
 	fmt.Println("Node agent stopped")
 }
+
+// joinCluster returns the node's saved credentials, joining with the
+// bootstrap token in target if it hasn't joined before
+func joinCluster(target string) (*nodeagent.Credentials, string, error) {
+	token, server, err := nodeagent.ParseJoinTarget(target)
+	if err != nil {
+		return nil, "", err
+	}
+
+	credentials, err := nodeagent.LoadCredentials(*credentialsDir)
+	if err == nil {
+		fmt.Printf("Using node credentials from %s\n", *credentialsDir)
+		return credentials, server, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+
+	credentials, err = nodeagent.Join(context.Background(), &nodeagent.JoinConfig{
+		Server:     server,
+		Token:      token,
+		NodeName:   *nodeName,
+		CACertHash: *caCertHash,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := credentials.Save(*credentialsDir); err != nil {
+		return nil, "", err
+	}
+	fmt.Printf("Joined %s as node %s, credentials saved to %s\n", server, *nodeName, *credentialsDir)
+	return credentials, server, nil
+}
+
+// parseCSIDrivers parses a comma-separated list of name=endpoint pairs
+func parseCSIDrivers(value string) (map[string]string, error) {
+	drivers := make(map[string]string)
+	if value == "" {
+		return drivers, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, endpoint, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || endpoint == "" {
+			return nil, fmt.Errorf("expected name=endpoint, got %q", pair)
+		}
+		drivers[name] = endpoint
+	}
+
+	return drivers, nil
+}
+
+// parseNodeLabels parses a comma-separated list of key=value labels
+func parseNodeLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if value == "" {
+		return labels, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		key, labelValue, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		labels[key] = labelValue
+	}
+
+	return labels, nil
+}
+
+// parseTaints parses a comma-separated list of key[=value]:effect taints
+func parseTaints(value string) ([]api.Taint, error) {
+	var taints []api.Taint
+	if value == "" {
+		return taints, nil
+	}
+
+	for _, spec := range strings.Split(value, ",") {
+		taint, err := api.ParseTaint(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		taints = append(taints, taint)
+	}
+
+	return taints, nil
+}
+
+// newRuntime creates the container runtime called name; exec runtimes keep
+// their containers under dir, and docker runtimes mount the volumes of
+// volumes into theirs
+func newRuntime(name, dir string, volumes nodeagent.VolumeManager) (nodeagent.CRIRuntime, error) {
+	switch name {
+	case "mock":
+		return nodeagent.NewMockCRIRuntime(), nil
+	case "exec":
+		return nodeagent.NewExecRuntime(&nodeagent.ExecRuntimeConfig{RootDir: dir}), nil
+	case "docker":
+		return nodeagent.NewDockerRuntime(&nodeagent.DockerRuntimeConfig{
+			Host:         *dockerHost,
+			SandboxImage: *sandboxImage,
+			Volumes:      volumes,
+		})
+	default:
+		return nil, fmt.Errorf("unknown runtime %q: use mock, exec or docker", name)
+	}
+}
+
+// parseRuntimeHandlers parses a comma-separated list of handler=runtime
+// pairs. Each handler gets a runtime of its own.
+func parseRuntimeHandlers(value string, volumes nodeagent.VolumeManager) (map[string]nodeagent.CRIRuntime, error) {
+	handlers := make(map[string]nodeagent.CRIRuntime)
+	if value == "" {
+		return handlers, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		handler, name, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || handler == "" {
+			return nil, fmt.Errorf("expected handler=runtime, got %q", pair)
+		}
+		if _, exists := handlers[handler]; exists {
+			return nil, fmt.Errorf("handler %s is listed twice", handler)
+		}
+		runtime, err := newRuntime(name, filepath.Join(*volumeRootDir, "exec-"+handler), volumes)
+		if err != nil {
+			return nil, fmt.Errorf("handler %s: %w", handler, err)
+		}
+		handlers[handler] = runtime
+	}
+
+	return handlers, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/nodeagent"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// runPodCommand implements `nodeagent run -f pod.yaml`: it runs one pod on
+// the configured runtime, without an API server or store, printing its
+// status transitions until it finishes or the command is interrupted. The
+// exit status is 1 if the pod failed.
+func runPodCommand(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	filename := fs.String("f", "", "Pod manifest to run, in YAML or JSON (required)")
+	// The runtime flags share their variables with the agent's, since
+	// newRuntime reads them
+	fs.StringVar(runtimeName, "container-runtime", *runtimeName, "Container runtime: mock, exec to run containers as host processes, or docker")
+	fs.StringVar(runtimeName, "runtime", *runtimeName, "Alias of --container-runtime")
+	fs.StringVar(volumeRootDir, "root-dir", *volumeRootDir, "Directory holding per-pod volume directories")
+	fs.StringVar(dockerHost, "docker-host", *dockerHost, "Docker daemon of the docker runtime, unix:///path or tcp://host:port (defaults to DOCKER_HOST, then "+nodeagent.DefaultDockerHost+")")
+	fs.StringVar(sandboxImage, "sandbox-image", *sandboxImage, "Image of the pause containers holding the namespaces of docker runtime pods")
+	syncInterval := fs.Duration("sync-interval", nodeagent.DefaultRunSyncInterval, "Interval for syncing the pod's status")
+	fs.Parse(args)
+
+	if *filename == "" {
+		log.Fatal("-f is required")
+	}
+	pod, err := readPodManifest(*filename)
+	if err != nil {
+		log.Fatalf("Invalid pod manifest: %v", err)
+	}
+
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+	volumeMgr := nodeagent.NewVolumePluginManager(&nodeagent.VolumePluginConfig{
+		RootDir: *volumeRootDir,
+		Store:   s,
+	})
+	criRuntime, err := newRuntime(*runtimeName, filepath.Join(*volumeRootDir, "exec"), volumeMgr)
+	if err != nil {
+		log.Fatalf("Invalid --container-runtime: %v", err)
+	}
+	fmt.Printf("Running pod %s with the %s runtime\n", pod.Name, *runtimeName)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	pod, err = nodeagent.RunPod(ctx, pod, &nodeagent.RunPodConfig{
+		Store:         s,
+		CRIRuntime:    criRuntime,
+		VolumeManager: volumeMgr,
+		SyncInterval:  *syncInterval,
+	})
+	if err != nil {
+		log.Fatalf("Failed to run pod: %v", err)
+	}
+	if pod.Status.Phase == api.PodFailed {
+		stop()
+		s.Close()
+		os.Exit(1)
+	}
+}
+
+// readPodManifest reads and validates the pod in filename. YAML is
+// converted to JSON first, so the api types' JSON tags apply to both.
+func readPodManifest(filename string) (*api.Pod, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var manifest map[string]interface{}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if kind, _ := manifest["kind"].(string); kind != "" && kind != "Pod" {
+		return nil, fmt.Errorf("%s holds a %s, not a Pod", filename, kind)
+	}
+	data, err = json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", filename, err)
+	}
+
+	var pod api.Pod
+	if err := json.Unmarshal(data, &pod); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filename, err)
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = "default"
+	}
+	if err := validation.Pod(&pod); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import "time"
+
+// MetricSourceExternal is the type of metrics read from an external metrics
+// adapter, such as the length of a queue
+const MetricSourceExternal = "External"
+
+// DefaultScaleToZeroAfterSeconds is how long an autoscaler that may scale to
+// zero waits with no demand before it does
+const DefaultScaleToZeroAfterSeconds = 300
+
+// AnnotationVerticalAutoscaling opts a deployment into resource request
+// recommendations; its value is one of the VerticalAutoscaling modes
+const AnnotationVerticalAutoscaling = "minik8s.io/vertical-autoscaling"
+
+// AnnotationRecommendedRequests holds the requests recommended for a
+// deployment's containers, as a JSON object of container name to
+// ResourceList
+const AnnotationRecommendedRequests = "minik8s.io/recommended-requests"
+
+// Vertical autoscaling modes
+const (
+	// VerticalAutoscalingRecommend only records recommendations
+	VerticalAutoscalingRecommend = "Recommend"
+	// VerticalAutoscalingAuto also applies them to the ReplicaSet created by
+	// the next rollout
+	VerticalAutoscalingAuto = "Auto"
+)
+
+// ScaleTargetReference names the deployment or replicaset an autoscaler scales
+type ScaleTargetReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// ExternalMetricSource is a metric not tied to any object in the cluster.
+// The target scales to the metric's value divided by TargetAverageValue,
+// rounded up.
+type ExternalMetricSource struct {
+	MetricName         string            `json:"metricName"`
+	MetricSelector     map[string]string `json:"metricSelector,omitempty"`
+	TargetAverageValue int64             `json:"targetAverageValue"`
+}
+
+// MetricSpec is one metric an autoscaler scales on
+type MetricSpec struct {
+	Type     string                `json:"type"`
+	External *ExternalMetricSource `json:"external,omitempty"`
+}
+
+// HorizontalPodAutoscalerSpec describes how a target is scaled
+type HorizontalPodAutoscalerSpec struct {
+	ScaleTargetRef ScaleTargetReference `json:"scaleTargetRef"`
+	// MinReplicas defaults to 1; 0 lets the target scale to zero when none
+	// of its metrics show any demand
+	MinReplicas *int32       `json:"minReplicas,omitempty"`
+	MaxReplicas int32        `json:"maxReplicas"`
+	Metrics     []MetricSpec `json:"metrics"`
+	// ScaleToZeroAfterSeconds is how long there must be no demand before
+	// scaling to zero; defaults to DefaultScaleToZeroAfterSeconds
+	ScaleToZeroAfterSeconds int32 `json:"scaleToZeroAfterSeconds,omitempty"`
+}
+
+// ExternalMetricStatus is the last value read for an external metric
+type ExternalMetricStatus struct {
+	MetricName   string `json:"metricName"`
+	CurrentValue int64  `json:"currentValue"`
+}
+
+// HorizontalPodAutoscalerStatus is the last observed state of an autoscaler
+type HorizontalPodAutoscalerStatus struct {
+	CurrentReplicas int32                  `json:"currentReplicas"`
+	DesiredReplicas int32                  `json:"desiredReplicas"`
+	CurrentMetrics  []ExternalMetricStatus `json:"currentMetrics,omitempty"`
+	LastScaleTime   *time.Time             `json:"lastScaleTime,omitempty"`
+	// LastActiveTime is when a metric last showed demand
+	LastActiveTime *time.Time `json:"lastActiveTime,omitempty"`
+}
+
+// HorizontalPodAutoscaler scales a deployment or replicaset on metrics
+type HorizontalPodAutoscaler struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       HorizontalPodAutoscalerSpec   `json:"spec"`
+	Status     HorizontalPodAutoscalerStatus `json:"status"`
+}
+
+// GetKind returns the kind of the autoscaler
+func (h *HorizontalPodAutoscaler) GetKind() string {
+	return h.Kind
+}
+
+// GetAPIVersion returns the API version of the autoscaler
+func (h *HorizontalPodAutoscaler) GetAPIVersion() string {
+	return h.APIVersion
+}
+
+// GetName returns the name of the autoscaler
+func (h *HorizontalPodAutoscaler) GetName() string {
+	return h.Name
+}
+
+// GetNamespace returns the namespace of the autoscaler
+func (h *HorizontalPodAutoscaler) GetNamespace() string {
+	return h.Namespace
+}
+
+// GetUID returns the UID of the autoscaler
+func (h *HorizontalPodAutoscaler) GetUID() string {
+	return h.UID
+}
+
+// GetResourceVersion returns the resource version of the autoscaler
+func (h *HorizontalPodAutoscaler) GetResourceVersion() string {
+	return h.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the autoscaler
+func (h *HorizontalPodAutoscaler) SetResourceVersion(version string) {
+	h.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the autoscaler
+func (h *HorizontalPodAutoscaler) GetCreationTimestamp() time.Time {
+	return h.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the autoscaler
+func (h *HorizontalPodAutoscaler) SetCreationTimestamp(timestamp time.Time) {
+	h.CreationTimestamp = timestamp
+}
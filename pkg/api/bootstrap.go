@@ -0,0 +1,22 @@
+package api
+
+// Paths of the node bootstrap endpoints, outside the versioned API
+const (
+	// BootstrapCAPath serves the cluster CA certificate without authentication
+	BootstrapCAPath = "/admin/bootstrap/ca"
+	// BootstrapJoinPath exchanges a bootstrap token for node credentials
+	BootstrapJoinPath = "/admin/bootstrap/join"
+)
+
+// NodeJoinRequest is sent by a node joining the cluster with a bootstrap token
+type NodeJoinRequest struct {
+	NodeName string `json:"nodeName"`
+}
+
+// NodeJoinResponse carries the credentials issued to a joined node
+type NodeJoinResponse struct {
+	// Token authenticates the node as system:node:<name>
+	Token string `json:"token"`
+	// CACert is the PEM CA bundle the API server's certificate chains to
+	CACert string `json:"caCert,omitempty"`
+}
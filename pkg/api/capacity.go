@@ -0,0 +1,69 @@
+package api
+
+// CapacityReport is how full the cluster is, node by node and in total. It
+// isn't stored; the API server works it out from the nodes and pods when
+// asked.
+type CapacityReport struct {
+	TypeMeta `json:",inline"`
+	Nodes    []NodeCapacity  `json:"nodes"`
+	Cluster  CapacitySummary `json:"cluster"`
+}
+
+// NodeCapacity is how full one node is
+type NodeCapacity struct {
+	Name          string `json:"name"`
+	Unschedulable bool   `json:"unschedulable,omitempty"`
+	CapacitySummary
+}
+
+// CapacitySummary counts the pods running on one or more nodes and, for
+// each resource, how much of it the nodes offer, request and use
+type CapacitySummary struct {
+	Pods      int                                `json:"pods"`
+	Resources map[ResourceName]*ResourceCapacity `json:"resources"`
+}
+
+// ResourceCapacity compares how much of a resource nodes can give pods with
+// how much their pods request and use, in cores for cpu and in bytes for
+// the other resources
+type ResourceCapacity struct {
+	Allocatable float64 `json:"allocatable"`
+	Requested   float64 `json:"requested"`
+	// Used adds up the latest usage samples of the running containers. It's
+	// only known for cpu and memory, once node agents report samples.
+	Used *float64 `json:"used,omitempty"`
+}
+
+// NewCapacitySummary returns an empty summary of the standard resources
+func NewCapacitySummary() CapacitySummary {
+	summary := CapacitySummary{Resources: make(map[ResourceName]*ResourceCapacity, len(StandardResources))}
+	for _, name := range StandardResources {
+		summary.Resources[name] = &ResourceCapacity{}
+	}
+	return summary
+}
+
+// Add adds the pods and quantities of other to s
+func (s *CapacitySummary) Add(other CapacitySummary) {
+	s.Pods += other.Pods
+	for name, resource := range other.Resources {
+		total, ok := s.Resources[name]
+		if !ok {
+			total = &ResourceCapacity{}
+			s.Resources[name] = total
+		}
+		total.Allocatable += resource.Allocatable
+		total.Requested += resource.Requested
+		if resource.Used != nil {
+			total.AddUsed(*resource.Used)
+		}
+	}
+}
+
+// AddUsed adds used to what's known to be used of the resource
+func (r *ResourceCapacity) AddUsed(used float64) {
+	if r.Used == nil {
+		r.Used = new(float64)
+	}
+	*r.Used += used
+}
@@ -0,0 +1,120 @@
+package api
+
+import "time"
+
+// Signers a CertificateSigningRequest can ask for
+const (
+	// SignerAPIServerClient issues client certificates the API server
+	// authenticates as the certificate's common name and organizations
+	SignerAPIServerClient = "minik8s.io/apiserver-client"
+	// SignerNodeServing issues serving certificates to node agents, for
+	// system:node:<name> in the system:nodes organization. Only the node
+	// itself may request one.
+	SignerNodeServing = "minik8s.io/node-serving"
+)
+
+// CertificateSigningRequest condition types
+const (
+	CertificateApproved = "Approved"
+	CertificateDenied   = "Denied"
+	CertificateFailed   = "Failed"
+)
+
+// CertificateSigningRequest asks a signer for a certificate. It's issued
+// once an administrator approves the request with cli certificate approve.
+// CertificateSigningRequests are cluster-scoped.
+type CertificateSigningRequest struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	Spec   CertificateSigningRequestSpec   `json:"spec"`
+	Status CertificateSigningRequestStatus `json:"status,omitempty"`
+}
+
+// CertificateSigningRequestSpec holds the certificate request
+type CertificateSigningRequestSpec struct {
+	// Request is the PEM-encoded PKCS#10 certificate request
+	Request string `json:"request"`
+	// SignerName is the signer asked to issue the certificate
+	SignerName string `json:"signerName"`
+	// ExpirationSeconds is the requested lifetime of the certificate; the
+	// signer may issue a shorter one
+	ExpirationSeconds int64 `json:"expirationSeconds,omitempty"`
+
+	// Username and Groups are those of the requester, set by the API server
+	Username string   `json:"username,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// CertificateSigningRequestStatus holds whether the request was approved and
+// the issued certificate
+type CertificateSigningRequestStatus struct {
+	Conditions []CertificateSigningRequestCondition `json:"conditions,omitempty"`
+	// Certificate is the PEM-encoded certificate, set once it's issued
+	Certificate string `json:"certificate,omitempty"`
+}
+
+// CertificateSigningRequestCondition records an approval, a denial, or why
+// the signer failed to issue the certificate
+type CertificateSigningRequestCondition struct {
+	Type           string          `json:"type"`
+	Status         ConditionStatus `json:"status"`
+	Reason         string          `json:"reason,omitempty"`
+	Message        string          `json:"message,omitempty"`
+	LastUpdateTime time.Time       `json:"lastUpdateTime,omitempty"`
+}
+
+// CertificateCondition returns the condition of the given type, or nil
+func (c *CertificateSigningRequest) CertificateCondition(conditionType string) *CertificateSigningRequestCondition {
+	for i := range c.Status.Conditions {
+		if c.Status.Conditions[i].Type == conditionType {
+			return &c.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// GetKind returns the kind of the certificate signing request
+func (c *CertificateSigningRequest) GetKind() string {
+	return c.Kind
+}
+
+// GetAPIVersion returns the API version of the certificate signing request
+func (c *CertificateSigningRequest) GetAPIVersion() string {
+	return c.APIVersion
+}
+
+// GetName returns the name of the certificate signing request
+func (c *CertificateSigningRequest) GetName() string {
+	return c.Name
+}
+
+// GetNamespace returns the namespace of the certificate signing request, which is always empty
+func (c *CertificateSigningRequest) GetNamespace() string {
+	return c.Namespace
+}
+
+// GetUID returns the UID of the certificate signing request
+func (c *CertificateSigningRequest) GetUID() string {
+	return c.UID
+}
+
+// GetResourceVersion returns the resource version of the certificate signing request
+func (c *CertificateSigningRequest) GetResourceVersion() string {
+	return c.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the certificate signing request
+func (c *CertificateSigningRequest) SetResourceVersion(version string) {
+	c.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the certificate signing request
+func (c *CertificateSigningRequest) GetCreationTimestamp() time.Time {
+	return c.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the certificate signing request
+func (c *CertificateSigningRequest) SetCreationTimestamp(timestamp time.Time) {
+	c.CreationTimestamp = timestamp
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PodConditionType is the type of a pod condition. Besides the standard
+// ones, readiness gates name conditions other controllers post.
+type PodConditionType string
+
+// Standard pod condition types
+const (
+	// PodConditionScheduled reports that the pod was bound to a node
+	PodConditionScheduled PodConditionType = "PodScheduled"
+	// PodConditionInitialized reports that the pod's volumes are mounted and
+	// its containers created
+	PodConditionInitialized PodConditionType = "Initialized"
+	// PodConditionContainersReady reports that all of the pod's containers
+	// are running
+	PodConditionContainersReady PodConditionType = "ContainersReady"
+	// PodConditionReady reports that the pod can serve traffic
+	PodConditionReady PodConditionType = "Ready"
+)
+
+// NodeConditionType is the type of a node condition
+type NodeConditionType string
+
+// NodeReady reports that the node's agent is healthy and can run pods
+const NodeReady NodeConditionType = "Ready"
+
+// ReasonReadinessGatesNotReady is the reason of a False Ready condition of
+// a pod whose containers are ready but whose readiness gates aren't
+const ReasonReadinessGatesNotReady = "ReadinessGatesNotReady"
+
+// IsStandardPodCondition reports whether conditionType is one of the
+// conditions the scheduler and node agent manage
+func IsStandardPodCondition(conditionType PodConditionType) bool {
+	switch conditionType {
+	case PodConditionScheduled, PodConditionInitialized, PodConditionContainersReady, PodConditionReady:
+		return true
+	}
+	return false
+}
+
+// PodReadinessGatesReady reports whether every readiness gate of spec has
+// its condition True in status, and if not, a message naming the first that
+// hasn't
+func PodReadinessGatesReady(spec *PodSpec, status *PodStatus) (bool, string) {
+	for _, gate := range spec.ReadinessGates {
+		condition := GetPodCondition(status, gate.ConditionType)
+		if condition == nil {
+			return false, fmt.Sprintf("condition of readiness gate %q does not exist", gate.ConditionType)
+		}
+		if condition.Status != ConditionTrue {
+			return false, fmt.Sprintf("readiness gate %q is %s", gate.ConditionType, condition.Status)
+		}
+	}
+	return true, ""
+}
+
+// Standard deployment condition types
+const (
+	// DeploymentProgressing reports whether the rollout is making progress,
+	// or has failed to within its progress deadline
+	DeploymentProgressing = "Progressing"
+	// DeploymentAvailable reports whether the desired replicas are available
+	DeploymentAvailable = "Available"
+)
+
+// Reasons of the Progressing deployment condition
+const (
+	// ReasonReplicaSetUpdated means the rollout is under way
+	ReasonReplicaSetUpdated = "ReplicaSetUpdated"
+	// ReasonNewReplicaSetAvailable means the rollout is complete
+	ReasonNewReplicaSetAvailable = "NewReplicaSetAvailable"
+	// ReasonProgressDeadlineExceeded means the rollout stalled
+	ReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	// ReasonDeploymentPaused means rollouts are paused
+	ReasonDeploymentPaused = "DeploymentPaused"
+)
+
+// ReasonUnschedulable is the reason of a False PodScheduled condition,
+// set while no node fits the pod
+const ReasonUnschedulable = "Unschedulable"
+
+// ConditionStatus is the status of a condition of any kind
+type ConditionStatus string
+
+// Condition statuses
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// ValidateConditionStatus checks that status is True, False or Unknown
+func ValidateConditionStatus(status ConditionStatus) error {
+	switch status {
+	case ConditionTrue, ConditionFalse, ConditionUnknown:
+		return nil
+	}
+	return fmt.Errorf("unknown condition status %q, use %s, %s or %s",
+		status, ConditionTrue, ConditionFalse, ConditionUnknown)
+}
+
+// UnmarshalJSON reads a condition status, matching the known ones
+// regardless of case so objects written with e.g. "true" before statuses
+// were checked still read as True
+func (s *ConditionStatus) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = ConditionStatus(canonicalCase(value, ConditionTrue, ConditionFalse, ConditionUnknown))
+	return nil
+}
+
+// canonicalCase returns the one of known matching value regardless of case,
+// or value itself if none does
+func canonicalCase[T ~string](value string, known ...T) string {
+	for _, k := range known {
+		if strings.EqualFold(value, string(k)) {
+			return string(k)
+		}
+	}
+	return value
+}
+
+// GetPodCondition returns the condition of the given type in status, or nil
+func GetPodCondition(status *PodStatus, conditionType PodConditionType) *PodCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsPodConditionTrue reports whether status has a condition of the given
+// type with status True
+func IsPodConditionTrue(status *PodStatus, conditionType PodConditionType) bool {
+	condition := GetPodCondition(status, conditionType)
+	return condition != nil && condition.Status == ConditionTrue
+}
+
+// SetPodCondition adds condition to status or replaces the existing one of
+// its type, dropping any duplicates left by earlier writers. The transition
+// time only moves when the condition's status changes; a zero one is set to
+// now. It reports whether anything besides the probe time changed.
+func SetPodCondition(status *PodStatus, condition PodCondition) bool {
+	existing := GetPodCondition(status, condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = time.Now()
+		}
+		status.Conditions = append(status.Conditions, condition)
+		return true
+	}
+
+	changed := existing.Status != condition.Status ||
+		existing.Reason != condition.Reason ||
+		existing.Message != condition.Message
+	if existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	} else if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = time.Now()
+	}
+
+	conditions := status.Conditions[:0]
+	replaced := false
+	for _, c := range status.Conditions {
+		if c.Type != condition.Type {
+			conditions = append(conditions, c)
+			continue
+		}
+		if replaced {
+			changed = true
+			continue
+		}
+		conditions = append(conditions, condition)
+		replaced = true
+	}
+	status.Conditions = conditions
+	return changed
+}
+
+// GetDeploymentCondition returns the condition of the given type in status,
+// or nil
+func GetDeploymentCondition(status *DeploymentStatus, conditionType string) *DeploymentCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetDeploymentCondition adds condition to status or replaces the existing
+// one of its type. As with SetPodCondition the transition time only moves
+// when the status changes; zero times are set to now.
+func SetDeploymentCondition(status *DeploymentStatus, condition DeploymentCondition) {
+	now := time.Now()
+	if condition.LastUpdateTime.IsZero() {
+		condition.LastUpdateTime = now
+	}
+
+	existing := GetDeploymentCondition(status, condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = condition.LastUpdateTime
+		}
+		status.Conditions = append(status.Conditions, condition)
+		return
+	}
+	if existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	} else if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = condition.LastUpdateTime
+	}
+	*existing = condition
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the schedules that may be given by name
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchLimit bounds how far ahead Next looks for a matching time, so
+// schedules such as "0 0 30 2 *" that never match don't loop forever
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronSchedule is a parsed five-field cron schedule: minute, hour, day of
+// month, month and day of week
+type CronSchedule struct {
+	minutes  []bool
+	hours    []bool
+	days     []bool
+	months   []bool
+	weekdays []bool
+
+	// anyDay and anyWeekday record unrestricted day fields; when both are
+	// restricted a day matching either runs the schedule, as in cron
+	anyDay     bool
+	anyWeekday bool
+}
+
+// ParseCronSchedule parses a cron schedule such as "*/15 9-17 * * 1-5" or
+// one of the macros @hourly, @daily, @weekly, @monthly and @yearly
+func ParseCronSchedule(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields, got %d", spec, len(fields))
+	}
+
+	schedule := &CronSchedule{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if schedule.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if schedule.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month field: %w", err)
+	}
+	if schedule.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	// Sunday may be given as 0 or 7
+	if schedule.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week field: %w", err)
+	}
+	schedule.weekdays[0] = schedule.weekdays[0] || schedule.weekdays[7]
+	return schedule, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+// such as "1,5-10,*/15" into the set of values it matches
+func parseCronField(field string, lo, hi int) ([]bool, error) {
+	set := make([]bool, hi+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = before, n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			before, after, _ := strings.Cut(rangePart, "-")
+			var err1, err2 error
+			start, err1 = strconv.Atoi(before)
+			end, err2 = strconv.Atoi(after)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start, end = n, n
+			if step > 1 {
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for value := start; value <= end; value += step {
+			set[value] = true
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first time after t the schedule matches, on the wall
+// clock of loc, or the zero time if it never does. Local times skipped when
+// clocks go forward never match, and local times repeated when they go
+// back only match once, the first time they occur.
+func (c *CronSchedule) Next(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	// Walk the wall clock as a calendar without DST transitions
+	after := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), 0, 0, time.UTC)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	for limit := day.Add(cronSearchLimit); !day.After(limit); day = day.AddDate(0, 0, 1) {
+		if !c.matchesDay(day) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if !c.hours[hour] {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if !c.minutes[minute] {
+					continue
+				}
+				wall := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+				if !wall.After(after) {
+					continue
+				}
+				if next, ok := wallTime(wall, loc); ok && next.After(t) {
+					return next
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the schedule runs on the given day
+func (c *CronSchedule) matchesDay(day time.Time) bool {
+	if !c.months[day.Month()] {
+		return false
+	}
+	dayMatches, weekdayMatches := c.days[day.Day()], c.weekdays[day.Weekday()]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatches
+	case c.anyWeekday:
+		return dayMatches
+	}
+	return dayMatches || weekdayMatches
+}
+
+// wallTime returns the first instant the wall clock of loc reads wall, or
+// false if it never does because clocks skip it
+func wallTime(wall time.Time, loc *time.Location) (time.Time, bool) {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc)
+	if !sameWallClock(t, wall) {
+		return time.Time{}, false
+	}
+	// time.Date may pick either occurrence of a repeated time
+	if earlier := t.Add(-time.Hour); sameWallClock(earlier, wall) {
+		t = earlier
+	}
+	return t, true
+}
+
+// sameWallClock reports whether t reads the wall clock time of wall
+func sameWallClock(t, wall time.Time) bool {
+	return t.Day() == wall.Day() && t.Hour() == wall.Hour() && t.Minute() == wall.Minute()
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Concurrency policies of a CronJob, for when a run is due while jobs of
+// earlier runs are still active
+const (
+	// ConcurrencyAllow starts the run next to the active jobs
+	ConcurrencyAllow = "Allow"
+	// ConcurrencyForbid skips the run
+	ConcurrencyForbid = "Forbid"
+	// ConcurrencyReplace deletes the active jobs and starts the run
+	ConcurrencyReplace = "Replace"
+)
+
+// Job history kept for a CronJob when its limits are unset
+const (
+	DefaultSuccessfulJobsHistoryLimit = 3
+	DefaultFailedJobsHistoryLimit     = 1
+)
+
+// CronJob creates Jobs on a cron schedule
+type CronJob struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	Spec   CronJobSpec   `json:"spec"`
+	Status CronJobStatus `json:"status,omitempty"`
+}
+
+// CronJobSpec describes when a CronJob runs and the Jobs it creates
+type CronJobSpec struct {
+	// Schedule is a five-field cron schedule or a macro such as @daily
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA time zone the schedule is read in, such as
+	// Europe/Berlin; empty uses the local time zone of the controller
+	// manager
+	TimeZone string `json:"timeZone,omitempty"`
+	// StartingDeadlineSeconds is how late a run may still be started, for
+	// instance after the controller manager was down; runs missed by more
+	// are skipped. Unset means no deadline.
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// ConcurrencyPolicy is Allow (the default), Forbid or Replace
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+	// Suspend stops new runs from starting; active jobs keep running
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// SuccessfulJobsHistoryLimit and FailedJobsHistoryLimit are how many
+	// finished jobs to keep
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32 `json:"failedJobsHistoryLimit,omitempty"`
+
+	JobTemplate JobTemplateSpec `json:"jobTemplate"`
+}
+
+// JobTemplateSpec describes the Jobs a CronJob creates
+type JobTemplateSpec struct {
+	ObjectMeta `json:"metadata,omitempty"`
+	Spec       JobSpec `json:"spec"`
+}
+
+// CronJobStatus records the active jobs of a CronJob and when it last ran
+type CronJobStatus struct {
+	Active             []ObjectReference `json:"active,omitempty"`
+	LastScheduleTime   *time.Time        `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime *time.Time        `json:"lastSuccessfulTime,omitempty"`
+}
+
+// Location returns the time zone the CronJob's schedule is read in, local
+// when spec.timeZone is empty
+func (c *CronJob) Location(local *time.Location) (*time.Location, error) {
+	if c.Spec.TimeZone == "" {
+		return local, nil
+	}
+	loc, err := time.LoadLocation(c.Spec.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", c.Spec.TimeZone, err)
+	}
+	return loc, nil
+}
+
+// GetKind returns the kind of the cronjob
+func (c *CronJob) GetKind() string {
+	return c.Kind
+}
+
+// GetAPIVersion returns the API version of the cronjob
+func (c *CronJob) GetAPIVersion() string {
+	return c.APIVersion
+}
+
+// GetName returns the name of the cronjob
+func (c *CronJob) GetName() string {
+	return c.Name
+}
+
+// GetNamespace returns the namespace of the cronjob
+func (c *CronJob) GetNamespace() string {
+	return c.Namespace
+}
+
+// GetUID returns the UID of the cronjob
+func (c *CronJob) GetUID() string {
+	return c.UID
+}
+
+// GetResourceVersion returns the resource version of the cronjob
+func (c *CronJob) GetResourceVersion() string {
+	return c.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the cronjob
+func (c *CronJob) SetResourceVersion(version string) {
+	c.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the cronjob
+func (c *CronJob) GetCreationTimestamp() time.Time {
+	return c.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the cronjob
+func (c *CronJob) SetCreationTimestamp(timestamp time.Time) {
+	c.CreationTimestamp = timestamp
+}
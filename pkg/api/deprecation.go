@@ -0,0 +1,26 @@
+package api
+
+// DeprecatedField is a field of a kind that is slated for removal
+type DeprecatedField struct {
+	Kind string
+	// Path is the field's JSON path, with "." between names and "[]"
+	// stepping into every element of a list, e.g. spec.containers[].name
+	Path string
+	// Message tells users what to use instead
+	Message string
+}
+
+// DeprecatedVersions maps API versions slated for removal to the message
+// sent with every request made through them. When v1alpha1 is superseded,
+// adding it here gives its users notice before its routes are removed.
+var DeprecatedVersions = map[string]string{}
+
+// DeprecatedFields are the fields slated for removal. Requests setting one
+// are still served, with a warning carrying the field's message.
+var DeprecatedFields = []DeprecatedField{
+	{
+		Kind:    "Node",
+		Path:    "spec.unschedulable",
+		Message: "spec.unschedulable is ignored by the scheduler and will be removed; keep pods off a node with a NoSchedule taint instead",
+	},
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// History operations
+const (
+	HistoryCreate = "CREATE"
+	HistoryUpdate = "UPDATE"
+	HistoryDelete = "DELETE"
+)
+
+// HistoryEntry is one recorded change to an object
+type HistoryEntry struct {
+	Operation       string    `json:"operation"`
+	Timestamp       time.Time `json:"timestamp"`
+	User            string    `json:"user,omitempty"`
+	RequestID       string    `json:"requestID,omitempty"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	// Object is the object as written, or as it was before a delete
+	Object json.RawMessage `json:"object,omitempty"`
+}
+
+// HistoryList lists the recorded changes to an object, oldest first
+type HistoryList struct {
+	TypeMeta `json:",inline"`
+	Items    []HistoryEntry `json:"items"`
+}
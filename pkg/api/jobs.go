@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Completion modes of a Job
+const (
+	// JobCompletionNonIndexed completes the Job once Completions pods
+	// succeeded, whichever they are
+	JobCompletionNonIndexed = "NonIndexed"
+	// JobCompletionIndexed gives each pod an index from 0 to Completions-1
+	// and completes the Job once a pod of every index succeeded
+	JobCompletionIndexed = "Indexed"
+)
+
+// Job condition types
+const (
+	JobComplete = "Complete"
+	JobFailed   = "Failed"
+)
+
+const (
+	// LabelJobName labels the pods of a Job with its name
+	LabelJobName = "job-name"
+	// AnnotationJobCompletionIndex holds the completion index of a pod of an
+	// Indexed Job
+	AnnotationJobCompletionIndex = "batch.minik8s.io/job-completion-index"
+	// EnvJobCompletionIndex is the environment variable the completion index
+	// of a pod of an Indexed Job is passed to its containers in
+	EnvJobCompletionIndex = "JOB_COMPLETION_INDEX"
+)
+
+// DefaultJobBackoffLimit is how many pods of a Job may fail before the Job
+// is marked failed when spec.backoffLimit is unset
+const DefaultJobBackoffLimit = 6
+
+// Job runs pods to completion
+type Job struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	Spec   JobSpec   `json:"spec"`
+	Status JobStatus `json:"status,omitempty"`
+}
+
+// JobSpec describes the pods a Job runs and when it's done
+type JobSpec struct {
+	// Parallelism is the most pods to run at once; defaults to 1
+	Parallelism *int32 `json:"parallelism,omitempty"`
+	// Completions is how many pods must succeed; defaults to 1. Indexed
+	// Jobs require it.
+	Completions *int32 `json:"completions,omitempty"`
+	// CompletionMode is NonIndexed (the default) or Indexed
+	CompletionMode string `json:"completionMode,omitempty"`
+	// BackoffLimit is how many pods may fail before the Job is marked
+	// failed; defaults to DefaultJobBackoffLimit
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	Template PodTemplateSpec `json:"template"`
+}
+
+// JobStatus counts the pods of a Job and records whether it's finished
+type JobStatus struct {
+	Active    int32 `json:"active,omitempty"`
+	Succeeded int32 `json:"succeeded,omitempty"`
+	Failed    int32 `json:"failed,omitempty"`
+
+	// CompletedIndexes lists the indexes of an Indexed Job that succeeded,
+	// as ranges such as "0-3,7"
+	CompletedIndexes string `json:"completedIndexes,omitempty"`
+
+	StartTime      *time.Time     `json:"startTime,omitempty"`
+	CompletionTime *time.Time     `json:"completionTime,omitempty"`
+	Conditions     []JobCondition `json:"conditions,omitempty"`
+}
+
+// JobCondition records that a Job completed or failed
+type JobCondition struct {
+	Type               string          `json:"type"`
+	Status             ConditionStatus `json:"status"`
+	Reason             string          `json:"reason,omitempty"`
+	Message            string          `json:"message,omitempty"`
+	LastTransitionTime time.Time       `json:"lastTransitionTime,omitempty"`
+}
+
+// JobCondition returns the condition of the given type, or nil
+func (j *Job) JobCondition(conditionType string) *JobCondition {
+	for i := range j.Status.Conditions {
+		if j.Status.Conditions[i].Type == conditionType {
+			return &j.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// Finished reports whether the Job completed or failed
+func (j *Job) Finished() bool {
+	return j.JobCondition(JobComplete) != nil || j.JobCondition(JobFailed) != nil
+}
+
+// Parallelism returns spec.parallelism, or its default
+func (j *Job) Parallelism() int32 {
+	if j.Spec.Parallelism == nil {
+		return 1
+	}
+	return *j.Spec.Parallelism
+}
+
+// Completions returns spec.completions, or its default
+func (j *Job) Completions() int32 {
+	if j.Spec.Completions == nil {
+		return 1
+	}
+	return *j.Spec.Completions
+}
+
+// BackoffLimit returns spec.backoffLimit, or its default
+func (j *Job) BackoffLimit() int32 {
+	if j.Spec.BackoffLimit == nil {
+		return DefaultJobBackoffLimit
+	}
+	return *j.Spec.BackoffLimit
+}
+
+// Indexed reports whether the Job's pods are given completion indexes
+func (j *Job) Indexed() bool {
+	return j.Spec.CompletionMode == JobCompletionIndexed
+}
+
+// JobCompletionIndex returns the completion index of a pod of an Indexed
+// Job, or false if it has none
+func JobCompletionIndex(pod *Pod) (int, bool) {
+	value, ok := pod.Annotations[AnnotationJobCompletionIndex]
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
+
+// FormatIndexes formats sorted, distinct indexes as ranges, such as "0-3,7"
+func FormatIndexes(indexes []int) string {
+	var ranges []string
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && indexes[j+1] == indexes[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(indexes[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", indexes[i], indexes[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ",")
+}
+
+// GetKind returns the kind of the job
+func (j *Job) GetKind() string {
+	return j.Kind
+}
+
+// GetAPIVersion returns the API version of the job
+func (j *Job) GetAPIVersion() string {
+	return j.APIVersion
+}
+
+// GetName returns the name of the job
+func (j *Job) GetName() string {
+	return j.Name
+}
+
+// GetNamespace returns the namespace of the job
+func (j *Job) GetNamespace() string {
+	return j.Namespace
+}
+
+// GetUID returns the UID of the job
+func (j *Job) GetUID() string {
+	return j.UID
+}
+
+// GetResourceVersion returns the resource version of the job
+func (j *Job) GetResourceVersion() string {
+	return j.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the job
+func (j *Job) SetResourceVersion(version string) {
+	j.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the job
+func (j *Job) GetCreationTimestamp() time.Time {
+	return j.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the job
+func (j *Job) SetCreationTimestamp(timestamp time.Time) {
+	j.CreationTimestamp = timestamp
+}
@@ -0,0 +1,112 @@
+package api
+
+import "time"
+
+// Lease records that a component is alive: the component renews it while it
+// runs, and a lease not renewed within its duration means the component is
+// gone. The scheduler and controller manager hold leases in kube-system.
+type Lease struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       LeaseSpec `json:"spec"`
+}
+
+// LeaseSpec describes who holds a lease and when it was last renewed
+type LeaseSpec struct {
+	// HolderIdentity names the process renewing the lease, as host_pid
+	HolderIdentity string `json:"holderIdentity,omitempty"`
+
+	// LeaseDurationSeconds is how long the lease lasts after a renewal
+	LeaseDurationSeconds int32 `json:"leaseDurationSeconds,omitempty"`
+
+	// RenewTime is when the holder last renewed the lease
+	RenewTime *time.Time `json:"renewTime,omitempty"`
+}
+
+// Leases of the cluster's control plane components, in kube-system
+const (
+	LeaseScheduler         = "kube-scheduler"
+	LeaseControllerManager = "kube-controller-manager"
+)
+
+// AnnotationComponentVersion is set on a component's lease to the version
+// of the component holding it
+const AnnotationComponentVersion = "minik8s.io/component-version"
+
+// Expired reports whether the lease wasn't renewed within its duration
+func (l *Lease) Expired(now time.Time) bool {
+	if l.Spec.RenewTime == nil {
+		return true
+	}
+	return now.Sub(*l.Spec.RenewTime) > time.Duration(l.Spec.LeaseDurationSeconds)*time.Second
+}
+
+// GetKind returns the kind of the lease
+func (l *Lease) GetKind() string {
+	return l.Kind
+}
+
+// GetAPIVersion returns the API version of the lease
+func (l *Lease) GetAPIVersion() string {
+	return l.APIVersion
+}
+
+// GetName returns the name of the lease
+func (l *Lease) GetName() string {
+	return l.Name
+}
+
+// GetNamespace returns the namespace of the lease
+func (l *Lease) GetNamespace() string {
+	return l.Namespace
+}
+
+// GetUID returns the UID of the lease
+func (l *Lease) GetUID() string {
+	return l.UID
+}
+
+// GetResourceVersion returns the resource version of the lease
+func (l *Lease) GetResourceVersion() string {
+	return l.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the lease
+func (l *Lease) SetResourceVersion(version string) {
+	l.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the lease
+func (l *Lease) GetCreationTimestamp() time.Time {
+	return l.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the lease
+func (l *Lease) SetCreationTimestamp(timestamp time.Time) {
+	l.CreationTimestamp = timestamp
+}
+
+// ComponentStatus reports the health of a control plane component. It isn't
+// stored; the API server works it out when asked.
+type ComponentStatus struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Conditions []ComponentCondition `json:"conditions,omitempty"`
+}
+
+// ComponentConditionHealthy is the condition of a healthy component
+const ComponentConditionHealthy = "Healthy"
+
+// ComponentCondition is a condition of a component
+type ComponentCondition struct {
+	Type    string          `json:"type"`
+	Status  ConditionStatus `json:"status"`
+	Message string          `json:"message,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// ComponentStatusList lists the health of the control plane components
+type ComponentStatusList struct {
+	TypeMeta `json:",inline"`
+	Items    []ComponentStatus `json:"items"`
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// MaxNameLength is the longest name a generated name may have
+	MaxNameLength = 63
+
+	// generatedSuffixLength is the number of random characters appended by GenerateName
+	generatedSuffixLength = 5
+
+	// generatedSuffixAlphabet leaves out vowels and look-alike characters so
+	// suffixes don't spell words or get misread
+	generatedSuffixAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+)
+
+// GenerateName returns base followed by a random suffix, as used for
+// metadata.generateName. base is shortened if the result would exceed
+// MaxNameLength.
+func GenerateName(base string) string {
+	if len(base) > MaxNameLength-generatedSuffixLength {
+		base = base[:MaxNameLength-generatedSuffixLength]
+	}
+
+	suffix := make([]byte, generatedSuffixLength)
+	max := big.NewInt(int64(len(generatedSuffixAlphabet)))
+	for i := range suffix {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic("failed to generate name: " + err.Error())
+		}
+		suffix[i] = generatedSuffixAlphabet[n.Int64()]
+	}
+	return base + string(suffix)
+}
+
+// NewUID returns a random RFC 4122 version 4 UUID, as used for metadata.uid
+func NewUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate UID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// podPhases are the phases a pod can be in
+var podPhases = []PodPhase{PodPending, PodRunning, PodSucceeded, PodFailed, PodUnknown}
+
+// legacyPodScheduled is the phase the scheduler used to move bound pods to.
+// Bound pods stay Pending now, so it's read as Pending.
+const legacyPodScheduled = "Scheduled"
+
+// ValidatePodPhase checks that phase is one of the known pod phases
+func ValidatePodPhase(phase PodPhase) error {
+	for _, known := range podPhases {
+		if phase == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown pod phase %q, use one of %v", phase, podPhases)
+}
+
+// UnmarshalJSON reads a pod phase, matching the known ones regardless of
+// case so objects written with e.g. "running" before phases were checked
+// still read as Running, and the legacy Scheduled phase as Pending
+func (p *PodPhase) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if strings.EqualFold(value, legacyPodScheduled) {
+		*p = PodPending
+		return nil
+	}
+	*p = PodPhase(canonicalCase(value, podPhases...))
+	return nil
+}
@@ -0,0 +1,94 @@
+package api
+
+import "time"
+
+// LabelPodGroup names the PodGroup in the pod's namespace that a pod belongs
+// to. The scheduler binds the pods of a group all or nothing.
+const LabelPodGroup = "scheduling.minik8s.io/pod-group"
+
+// Pod group phases
+const (
+	// PodGroupPending groups have fewer than minMember pods bound
+	PodGroupPending = "Pending"
+	// PodGroupScheduled groups have at least minMember pods bound
+	PodGroupScheduled = "Scheduled"
+)
+
+// PodGroup is a set of pods, such as the workers of a distributed training
+// job, that are only useful when enough of them run at once. Pods join a
+// group with the LabelPodGroup label.
+type PodGroup struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       PodGroupSpec   `json:"spec"`
+	Status     PodGroupStatus `json:"status"`
+}
+
+// PodGroupSpec describes how many pods of a group must fit together
+type PodGroupSpec struct {
+	// MinMember is how many of the group's pods must be bound for any of
+	// them to be. Until that many are pending and fit on the nodes at once,
+	// none are bound and no resources are held for them.
+	MinMember int32 `json:"minMember"`
+}
+
+// PodGroupStatus is the last observed state of a pod group, as seen by the
+// scheduler
+type PodGroupStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// Scheduled is how many of the group's pods are bound to a node
+	Scheduled int32 `json:"scheduled"`
+	// Message explains why a pending group wasn't scheduled
+	Message string `json:"message,omitempty"`
+}
+
+// PodGroupName returns the name of the pod group pod belongs to, or "" if
+// none
+func PodGroupName(pod *Pod) string {
+	return pod.Labels[LabelPodGroup]
+}
+
+// GetKind returns the kind of the pod group
+func (g *PodGroup) GetKind() string {
+	return g.Kind
+}
+
+// GetAPIVersion returns the API version of the pod group
+func (g *PodGroup) GetAPIVersion() string {
+	return g.APIVersion
+}
+
+// GetName returns the name of the pod group
+func (g *PodGroup) GetName() string {
+	return g.Name
+}
+
+// GetNamespace returns the namespace of the pod group
+func (g *PodGroup) GetNamespace() string {
+	return g.Namespace
+}
+
+// GetUID returns the UID of the pod group
+func (g *PodGroup) GetUID() string {
+	return g.UID
+}
+
+// GetResourceVersion returns the resource version of the pod group
+func (g *PodGroup) GetResourceVersion() string {
+	return g.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the pod group
+func (g *PodGroup) SetResourceVersion(version string) {
+	g.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the pod group
+func (g *PodGroup) GetCreationTimestamp() time.Time {
+	return g.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the pod group
+func (g *PodGroup) SetCreationTimestamp(timestamp time.Time) {
+	g.CreationTimestamp = timestamp
+}
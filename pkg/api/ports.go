@@ -0,0 +1,48 @@
+package api
+
+import "strings"
+
+// ProtocolTCP is the protocol of ports that don't name one
+const ProtocolTCP = "TCP"
+
+// PodHostPorts returns the ports of pod's containers that are bound on the
+// node, with their protocol upper-cased and defaulted
+func PodHostPorts(pod *Pod) []ContainerPort {
+	var ports []ContainerPort
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort <= 0 {
+				continue
+			}
+			port.Protocol = strings.ToUpper(port.Protocol)
+			if port.Protocol == "" {
+				port.Protocol = ProtocolTCP
+			}
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// HostPortConflict returns a host port of pod that one of other's host
+// ports already takes, and whether there is one. Ports conflict when they
+// share a number and protocol and their host IPs overlap, an empty or
+// 0.0.0.0 host IP covering every address.
+func HostPortConflict(pod, other *Pod) (ContainerPort, bool) {
+	taken := PodHostPorts(other)
+	for _, port := range PodHostPorts(pod) {
+		for _, used := range taken {
+			if port.HostPort == used.HostPort && port.Protocol == used.Protocol && hostIPsOverlap(port.HostIP, used.HostIP) {
+				return port, true
+			}
+		}
+	}
+	return ContainerPort{}, false
+}
+
+func hostIPsOverlap(a, b string) bool {
+	if a == "" || a == "0.0.0.0" || b == "" || b == "0.0.0.0" {
+		return true
+	}
+	return a == b
+}
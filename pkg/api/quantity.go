@@ -0,0 +1,125 @@
+package api
+
+import "fmt"
+
+// ParseCPU parses a CPU quantity such as "500m" or "2" into cores
+func ParseCPU(cpu string) (float64, error) {
+	if cpu == "" {
+		return 0, nil
+	}
+
+	// Handle millicores (e.g., "100m" = 0.1)
+	if len(cpu) > 1 && cpu[len(cpu)-1] == 'm' {
+		if value, err := parseFloat(cpu[:len(cpu)-1]); err == nil {
+			return value / 1000, nil
+		}
+	}
+
+	// Handle cores (e.g., "1", "0.5")
+	return parseFloat(cpu)
+}
+
+// decimalSuffixes are the multipliers of byte quantities such as "10G"
+var decimalSuffixes = map[byte]float64{'k': 1e3, 'M': 1e6, 'G': 1e9, 'T': 1e12}
+
+// ParseMemory parses a byte quantity such as "512Mi", "1Gi" or "10G" into
+// bytes. Memory, ephemeral storage and huge pages are all measured this way.
+func ParseMemory(memory string) (float64, error) {
+	if memory == "" {
+		return 0, nil
+	}
+
+	// Handle bytes (e.g., "1Gi", "512Mi")
+	if len(memory) > 2 {
+		suffix := memory[len(memory)-2:]
+		value, err := parseFloat(memory[:len(memory)-2])
+		if err != nil {
+			return 0, err
+		}
+
+		switch suffix {
+		case "Ki":
+			return value * 1024, nil
+		case "Mi":
+			return value * 1024 * 1024, nil
+		case "Gi":
+			return value * 1024 * 1024 * 1024, nil
+		case "Ti":
+			return value * 1024 * 1024 * 1024 * 1024, nil
+		}
+	}
+
+	// Handle decimal multiples (e.g., "10G", "500M")
+	if multiplier, ok := decimalSuffixes[memory[len(memory)-1]]; ok && len(memory) > 1 {
+		value, err := parseFloat(memory[:len(memory)-1])
+		if err != nil {
+			return 0, err
+		}
+		return value * multiplier, nil
+	}
+
+	// Assume bytes
+	return parseFloat(memory)
+}
+
+// ParseQuantity parses a quantity of the named resource: cores for cpu,
+// bytes for the others
+func ParseQuantity(name ResourceName, quantity string) (float64, error) {
+	if name == ResourceCPU {
+		return ParseCPU(quantity)
+	}
+	return ParseMemory(quantity)
+}
+
+// PodRequests returns the CPU, in cores, and memory, in bytes, requested by
+// pod. Unparseable quantities are ignored.
+func PodRequests(pod *Pod) (cpu, memory float64) {
+	return PodRequest(pod, ResourceCPU), PodRequest(pod, ResourceMemory)
+}
+
+// PodRequest returns how much of the named resource pod requests: the sum
+// of its containers' requests and its overhead. Huge pages are requested by
+// their limit when a container sets no request. Unparseable quantities are
+// ignored.
+func PodRequest(pod *Pod, name ResourceName) float64 {
+	var total float64
+	for _, container := range pod.Spec.Containers {
+		quantity, ok := container.Resources.Requests[name]
+		if !ok && name == ResourceHugePages2Mi {
+			quantity = container.Resources.Limits[name]
+		}
+		if value, err := ParseQuantity(name, quantity); err == nil {
+			total += value
+		}
+	}
+	if value, err := ParseQuantity(name, pod.Spec.Overhead[name]); err == nil {
+		total += value
+	}
+	return total
+}
+
+// PodLimit returns the sum of pod's containers' limits of the named
+// resource, and whether every container sets one; a pod with a container
+// without a limit isn't limited
+func PodLimit(pod *Pod, name ResourceName) (float64, bool) {
+	var total float64
+	for _, container := range pod.Spec.Containers {
+		quantity, ok := container.Resources.Limits[name]
+		if !ok {
+			return 0, false
+		}
+		if value, err := ParseQuantity(name, quantity); err == nil {
+			total += value
+		}
+	}
+	if value, err := ParseQuantity(name, pod.Spec.Overhead[name]); err == nil {
+		total += value
+	}
+	return total, len(pod.Spec.Containers) > 0
+}
+
+func parseFloat(s string) (float64, error) {
+	var result float64
+	_, err := fmt.Sscanf(s, "%f", &result)
+	return result, err
+}
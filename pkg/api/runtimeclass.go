@@ -0,0 +1,77 @@
+package api
+
+import "time"
+
+// RuntimeClass selects the container runtime node agents run a pod with.
+// RuntimeClasses are cluster-scoped; pods refer to one by name in
+// spec.runtimeClassName.
+type RuntimeClass struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	// Handler names the runtime on the node agent, as configured with its
+	// --runtime-handlers flag
+	Handler string `json:"handler"`
+
+	// Scheduling limits the class's pods to the nodes that have its handler
+	Scheduling *RuntimeClassScheduling `json:"scheduling,omitempty"`
+
+	// Overhead is copied to the spec of the class's pods when they're created
+	Overhead *RuntimeClassOverhead `json:"overhead,omitempty"`
+}
+
+// RuntimeClassOverhead is what the runtime costs per pod
+type RuntimeClassOverhead struct {
+	PodFixed ResourceList `json:"podFixed,omitempty"`
+}
+
+// RuntimeClassScheduling holds the node selector added to the pods of a
+// RuntimeClass when they're created
+type RuntimeClassScheduling struct {
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+// GetKind returns the kind of the runtime class
+func (r *RuntimeClass) GetKind() string {
+	return r.Kind
+}
+
+// GetAPIVersion returns the API version of the runtime class
+func (r *RuntimeClass) GetAPIVersion() string {
+	return r.APIVersion
+}
+
+// GetName returns the name of the runtime class
+func (r *RuntimeClass) GetName() string {
+	return r.Name
+}
+
+// GetNamespace returns the namespace of the runtime class, which is always empty
+func (r *RuntimeClass) GetNamespace() string {
+	return r.Namespace
+}
+
+// GetUID returns the UID of the runtime class
+func (r *RuntimeClass) GetUID() string {
+	return r.UID
+}
+
+// GetResourceVersion returns the resource version of the runtime class
+func (r *RuntimeClass) GetResourceVersion() string {
+	return r.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the runtime class
+func (r *RuntimeClass) SetResourceVersion(version string) {
+	r.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the runtime class
+func (r *RuntimeClass) GetCreationTimestamp() time.Time {
+	return r.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the runtime class
+func (r *RuntimeClass) SetCreationTimestamp(timestamp time.Time) {
+	r.CreationTimestamp = timestamp
+}
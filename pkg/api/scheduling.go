@@ -0,0 +1,49 @@
+package api
+
+// Names of the checks a node must pass to run a pod, in the order the
+// scheduler runs them
+const (
+	FilterNodeReady      = "NodeReady"
+	FilterNodeSelector   = "NodeSelector"
+	FilterPlatform       = "Platform"
+	FilterResources      = "Resources"
+	FilterNodePorts      = "NodePorts"
+	FilterTaints         = "TaintsAndTolerations"
+	FilterTopologySpread = "TopologySpread"
+)
+
+// FilterResult is the outcome of one check of a node
+type FilterResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+}
+
+// NodeScore breaks down how a node that passed every check was scored. The
+// node with the highest total is chosen.
+type NodeScore struct {
+	// CPU is the node's allocatable CPU, in cores
+	CPU float64 `json:"cpu"`
+	// Memory is the node's allocatable memory, in GiB
+	Memory float64 `json:"memory"`
+	// Pods is the number of pods already on the node, which is subtracted
+	Pods  int     `json:"pods"`
+	Total float64 `json:"total"`
+}
+
+// NodeEvaluation is how a node fared for a pod
+type NodeEvaluation struct {
+	Node     string         `json:"node"`
+	Feasible bool           `json:"feasible"`
+	Filters  []FilterResult `json:"filters"`
+	Score    *NodeScore     `json:"score,omitempty"`
+}
+
+// SchedulingSimulation is where a pod would be scheduled and why, as
+// returned by POST /api/v1alpha1/scheduling/simulate
+type SchedulingSimulation struct {
+	// SelectedNode is the node the pod would be bound to, empty if none fits
+	SelectedNode string `json:"selectedNode,omitempty"`
+	// Message explains why no node was selected
+	Message string           `json:"message,omitempty"`
+	Nodes   []NodeEvaluation `json:"nodes"`
+}
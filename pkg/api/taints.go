@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Taint effects
+const (
+	// TaintEffectNoSchedule keeps new pods that don't tolerate the taint off the node
+	TaintEffectNoSchedule = "NoSchedule"
+	// TaintEffectPreferNoSchedule steers new pods away from the node when possible
+	TaintEffectPreferNoSchedule = "PreferNoSchedule"
+	// TaintEffectNoExecute also evicts running pods that don't tolerate the taint
+	TaintEffectNoExecute = "NoExecute"
+)
+
+// TaintNodeUnreachable is the NoExecute taint the node lifecycle controller
+// adds to nodes whose agent stopped posting status
+const TaintNodeUnreachable = "node.minik8s.io/unreachable"
+
+// TaintNodeShutdown is the NoSchedule taint a node agent adds to its node
+// while it shuts down, and removes when it starts again
+const TaintNodeShutdown = "node.minik8s.io/shutdown"
+
+// Toleration operators
+const (
+	TolerationOpEqual  = "Equal"
+	TolerationOpExists = "Exists"
+)
+
+// ParseTaint parses a taint written as key[=value]:effect
+func ParseTaint(spec string) (Taint, error) {
+	keyValue, effect, ok := strings.Cut(spec, ":")
+	if !ok {
+		return Taint{}, fmt.Errorf("invalid taint %q, expected key[=value]:effect", spec)
+	}
+	key, value, _ := strings.Cut(keyValue, "=")
+	if key == "" {
+		return Taint{}, fmt.Errorf("invalid taint %q: key is empty", spec)
+	}
+	if err := ValidateTaintEffect(effect); err != nil {
+		return Taint{}, fmt.Errorf("invalid taint %q: %w", spec, err)
+	}
+	return Taint{Key: key, Value: value, Effect: effect}, nil
+}
+
+// ValidateTaintEffect checks that effect is one of the known taint effects
+func ValidateTaintEffect(effect string) error {
+	switch effect {
+	case TaintEffectNoSchedule, TaintEffectPreferNoSchedule, TaintEffectNoExecute:
+		return nil
+	}
+	return fmt.Errorf("unknown taint effect %q, use %s, %s or %s",
+		effect, TaintEffectNoSchedule, TaintEffectPreferNoSchedule, TaintEffectNoExecute)
+}
+
+// String formats the taint the way ParseTaint reads it
+func (t Taint) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + t.Effect
+	}
+	return t.Key + "=" + t.Value + ":" + t.Effect
+}
+
+// Tolerates reports whether the toleration matches taint
+func (t Toleration) Tolerates(taint Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Key != "" && t.Key != taint.Key {
+		return false
+	}
+	switch t.Operator {
+	case TolerationOpExists:
+		return true
+	case "", TolerationOpEqual:
+		return t.Key != "" && t.Value == taint.Value
+	}
+	return false
+}
+
+// ToleratesTaint reports whether any of tolerations matches taint
+func ToleratesTaint(tolerations []Toleration, taint Taint) bool {
+	for _, toleration := range tolerations {
+		if toleration.Tolerates(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToleratesNode reports whether pod tolerates every NoSchedule and
+// NoExecute taint of node, so it may be scheduled there
+func ToleratesNode(pod *Pod, node *Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !ToleratesTaint(pod.Spec.Tolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+// Cordoned reports whether node has a NoSchedule or NoExecute taint, which
+// keeps new pods off it. Tainting a node this way is how it's cordoned.
+func (n *Node) Cordoned() bool {
+	for _, taint := range n.Spec.Taints {
+		if taint.Effect == TaintEffectNoSchedule || taint.Effect == TaintEffectNoExecute {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package api
+
+// TopologyDomain returns the domain node belongs to under topologyKey, and
+// false if it has none
+func TopologyDomain(node *Node, topologyKey string) (string, bool) {
+	if value, ok := node.Labels[topologyKey]; ok {
+		return value, true
+	}
+	if topologyKey == LabelHostname {
+		return node.Name, true
+	}
+	return "", false
+}
+
+// SpreadDomains counts the pods in namespace matching constraint's selector
+// in each domain of its topology key. Every domain of nodes is present, even
+// without pods; pods on other nodes and pods that finished aren't counted.
+func SpreadDomains(constraint TopologySpreadConstraint, namespace string, pods []*Pod, nodes []*Node) map[string]int {
+	domains := make(map[string]int)
+	nodeDomains := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if domain, ok := TopologyDomain(node, constraint.TopologyKey); ok {
+			if _, seen := domains[domain]; !seen {
+				domains[domain] = 0
+			}
+			nodeDomains[node.Name] = domain
+		}
+	}
+
+	for _, pod := range pods {
+		domain, ok := nodeDomains[pod.Spec.NodeName]
+		if !ok || pod.Namespace != namespace || !constraint.Selects(pod) {
+			continue
+		}
+		if pod.Status.Phase == PodSucceeded || pod.Status.Phase == PodFailed {
+			continue
+		}
+		domains[domain]++
+	}
+	return domains
+}
+
+// AllowedSkew returns the constraint's maximum skew; values below 1 count
+// as 1
+func (c TopologySpreadConstraint) AllowedSkew() int {
+	if c.MaxSkew < 1 {
+		return 1
+	}
+	return int(c.MaxSkew)
+}
+
+// Selects reports whether pod's labels match the constraint's selector. A
+// missing or empty selector selects nothing.
+func (c TopologySpreadConstraint) Selects(pod *Pod) bool {
+	if c.LabelSelector == nil || len(c.LabelSelector.MatchLabels) == 0 {
+		return false
+	}
+	for key, value := range c.LabelSelector.MatchLabels {
+		if pod.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MinDomainCount returns the lowest count in domains, or 0 if there are none
+func MinDomainCount(domains map[string]int) int {
+	first := true
+	min := 0
+	for _, count := range domains {
+		if first || count < min {
+			min, first = count, false
+		}
+	}
+	return min
+}
@@ -13,6 +13,7 @@ type TypeMeta struct {
 // ObjectMeta contains metadata about the object
 type ObjectMeta struct {
 	Name              string            `json:"name"`
+	GenerateName      string            `json:"generateName,omitempty"`
 	Namespace         string            `json:"namespace"`
 	UID               string            `json:"uid,omitempty"`
 	ResourceVersion   string            `json:"resourceVersion,omitempty"`
@@ -21,6 +22,29 @@ type ObjectMeta struct {
 	Labels            map[string]string `json:"labels,omitempty"`
 	Annotations       map[string]string `json:"annotations,omitempty"`
 	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
+
+	// DeletionTimestamp is set on a pod that was deleted while its node
+	// stops its containers; the node agent removes the pod once they have
+	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty"`
+	// DeletionGracePeriodSeconds is how long the containers of a deleted
+	// pod get to exit before they're killed
+	DeletionGracePeriodSeconds *int64 `json:"deletionGracePeriodSeconds,omitempty"`
+}
+
+// GetLabels returns the labels of the object
+func (m *ObjectMeta) GetLabels() map[string]string {
+	return m.Labels
+}
+
+// GetOwnerReferences returns the owners of the object
+func (m *ObjectMeta) GetOwnerReferences() []OwnerReference {
+	return m.OwnerReferences
+}
+
+// GetDeletionTimestamp returns when the object was deleted, or nil if it
+// isn't terminating
+func (m *ObjectMeta) GetDeletionTimestamp() *time.Time {
+	return m.DeletionTimestamp
 }
 
 // ResourceRequirements describes the compute resource requirements
@@ -40,8 +64,17 @@ const (
 	ResourceCPU ResourceName = "cpu"
 	// Memory, in bytes
 	ResourceMemory ResourceName = "memory"
+	// Local disk space for container logs, writable layers and emptyDir
+	// volumes, in bytes
+	ResourceEphemeralStorage ResourceName = "ephemeral-storage"
+	// Memory in 2MiB huge pages, in bytes
+	ResourceHugePages2Mi ResourceName = "hugepages-2Mi"
 )
 
+// StandardResources are the resources nodes report and the scheduler and
+// node agent account pods' requests against
+var StandardResources = []ResourceName{ResourceCPU, ResourceMemory, ResourceEphemeralStorage, ResourceHugePages2Mi}
+
 // Container represents a single container within a pod
 type Container struct {
 	Name            string               `json:"name"`
@@ -56,6 +89,13 @@ type Container struct {
 	LivenessProbe   *Probe               `json:"livenessProbe,omitempty"`
 	ReadinessProbe  *Probe               `json:"readinessProbe,omitempty"`
 	ImagePullPolicy string               `json:"imagePullPolicy,omitempty"`
+
+	// Stdin keeps the container's stdin open so clients can attach to it
+	Stdin bool `json:"stdin,omitempty"`
+	// StdinOnce closes stdin after the first attached client disconnects
+	StdinOnce bool `json:"stdinOnce,omitempty"`
+	// TTY allocates a terminal for the container's main process
+	TTY bool `json:"tty,omitempty"`
 }
 
 // ContainerPort represents a network port in a single container
@@ -116,11 +156,86 @@ type PodSpec struct {
 	HostPID          bool                   `json:"hostPID,omitempty"`
 	HostIPC          bool                   `json:"hostIPC,omitempty"`
 	ImagePullSecrets []LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	ServiceAccountName           string `json:"serviceAccountName,omitempty"`
+	AutomountServiceAccountToken *bool  `json:"automountServiceAccountToken,omitempty"`
+
+	// RuntimeClassName names the RuntimeClass whose runtime the node agent
+	// runs the pod with; empty uses the agent's default runtime
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+
+	// EnableServiceLinks injects environment variables for the namespace's services; defaults to true
+	EnableServiceLinks *bool `json:"enableServiceLinks,omitempty"`
+
+	// TopologySpreadConstraints limit how unevenly matching pods may be
+	// spread across nodes or other topology domains
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Tolerations let the pod onto nodes with matching taints
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+
+	// ActiveDeadlineSeconds is how long the pod may run after it started
+	// before the node agent kills it and marks it Failed with reason
+	// DeadlineExceeded
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// TerminationGracePeriodSeconds is how long the pod's containers get to
+	// exit after being asked to stop before they're killed; nil uses
+	// DefaultTerminationGracePeriodSeconds
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// ReadinessGates name extra conditions, posted to the pod's status by
+	// other controllers, that must be True for the pod to be Ready
+	ReadinessGates []PodReadinessGate `json:"readinessGates,omitempty"`
+
+	// Overhead is what running the pod costs besides its containers, such
+	// as a sandbox VM. It's set from the pod's RuntimeClass when the pod is
+	// created, and counted with its containers' requests.
+	Overhead ResourceList `json:"overhead,omitempty"`
+}
+
+// DefaultTerminationGracePeriodSeconds is the termination grace period of
+// pods that don't set one
+const DefaultTerminationGracePeriodSeconds = 30
+
+// TerminationGracePeriod returns how many seconds the pod's containers get
+// to exit when stopped
+func (s *PodSpec) TerminationGracePeriod() int64 {
+	if s.TerminationGracePeriodSeconds != nil {
+		return *s.TerminationGracePeriodSeconds
+	}
+	return DefaultTerminationGracePeriodSeconds
+}
+
+// TerminationGracePeriod returns how many seconds the pod's containers get
+// to exit when stopped: the grace period it was deleted with, if any, or its
+// spec's
+func (p *Pod) TerminationGracePeriod() int64 {
+	if p.DeletionGracePeriodSeconds != nil {
+		return *p.DeletionGracePeriodSeconds
+	}
+	return p.Spec.TerminationGracePeriod()
+}
+
+// PodReadinessGate names a pod condition the pod's readiness waits for
+type PodReadinessGate struct {
+	ConditionType PodConditionType `json:"conditionType"`
+}
+
+// TopologySpreadConstraint limits the skew of the pods matching
+// LabelSelector across the domains of TopologyKey: the difference between
+// the number of them in the most and least populated domain
+type TopologySpreadConstraint struct {
+	MaxSkew int32 `json:"maxSkew"`
+	// TopologyKey is the node label whose values are the domains, such as
+	// kubernetes.io/hostname or topology.kubernetes.io/zone
+	TopologyKey   string         `json:"topologyKey"`
+	LabelSelector *LabelSelector `json:"labelSelector,omitempty"`
 }
 
 // PodStatus represents information about the status of a pod
 type PodStatus struct {
-	Phase             string            `json:"phase"`
+	Phase             PodPhase          `json:"phase"`
 	Conditions        []PodCondition    `json:"conditions,omitempty"`
 	Message           string            `json:"message,omitempty"`
 	Reason            string            `json:"reason,omitempty"`
@@ -132,12 +247,12 @@ type PodStatus struct {
 
 // PodCondition contains details for the current condition of this pod
 type PodCondition struct {
-	Type               string    `json:"type"`
-	Status             string    `json:"status"`
-	LastProbeTime      time.Time `json:"lastProbeTime,omitempty"`
-	LastTransitionTime time.Time `json:"lastTransitionTime,omitempty"`
-	Reason             string    `json:"reason,omitempty"`
-	Message            string    `json:"message,omitempty"`
+	Type               PodConditionType `json:"type"`
+	Status             ConditionStatus  `json:"status"`
+	LastProbeTime      time.Time        `json:"lastProbeTime,omitempty"`
+	LastTransitionTime time.Time        `json:"lastTransitionTime,omitempty"`
+	Reason             string           `json:"reason,omitempty"`
+	Message            string           `json:"message,omitempty"`
 }
 
 // ContainerStatus describes the current state of a container
@@ -149,6 +264,17 @@ type ContainerStatus struct {
 	Image        string         `json:"image"`
 	ImageID      string         `json:"imageID,omitempty"`
 	Started      *bool          `json:"started,omitempty"`
+	// Usage is the container's most recent resource usage sample, reported
+	// by node agents whose runtime can measure it
+	Usage *ContainerUsage `json:"usage,omitempty"`
+}
+
+// ContainerUsage is a sample of a container's resource usage
+type ContainerUsage struct {
+	Timestamp time.Time `json:"timestamp"`
+	// CPUMillicores is the CPU used since the previous sample
+	CPUMillicores int64 `json:"cpuMillicores"`
+	MemoryBytes   int64 `json:"memoryBytes"`
 }
 
 // ContainerState holds a possible state of a container
@@ -179,6 +305,12 @@ type ContainerStateTerminated struct {
 	FinishedAt time.Time `json:"finishedAt,omitempty"`
 }
 
+// AnnotationCheckpointRestore set to "true" on a pod makes its node agent
+// snapshot the pod's container filesystems when it shuts down and restore
+// them when it starts the pod again, e.g. after a node reboot. Experimental;
+// only runtimes that support checkpoints honor it.
+const AnnotationCheckpointRestore = "minik8s.io/checkpoint-restore"
+
 // Pod represents a pod in the system
 type Pod struct {
 	TypeMeta   `json:",inline"`
@@ -304,12 +436,12 @@ func (n *Node) SetCreationTimestamp(timestamp time.Time) {
 
 // NodeCondition contains condition information for a node
 type NodeCondition struct {
-	Type               string    `json:"type"`
-	Status             string    `json:"status"`
-	LastHeartbeatTime  time.Time `json:"lastHeartbeatTime,omitempty"`
-	LastTransitionTime time.Time `json:"lastTransitionTime,omitempty"`
-	Reason             string    `json:"reason,omitempty"`
-	Message            string    `json:"message,omitempty"`
+	Type               NodeConditionType `json:"type"`
+	Status             ConditionStatus   `json:"status"`
+	LastHeartbeatTime  time.Time         `json:"lastHeartbeatTime,omitempty"`
+	LastTransitionTime time.Time         `json:"lastTransitionTime,omitempty"`
+	Reason             string            `json:"reason,omitempty"`
+	Message            string            `json:"message,omitempty"`
 }
 
 // NodeAddress contains information for the node's address
@@ -318,6 +450,12 @@ type NodeAddress struct {
 	Address string `json:"address"`
 }
 
+// Node address types
+const (
+	NodeInternalIP = "InternalIP"
+	NodeHostName   = "Hostname"
+)
+
 // NodeDaemonEndpoints lists ports opened by daemons running on the Node
 type NodeDaemonEndpoints struct {
 	KubeletEndpoint DaemonEndpoint `json:"kubeletEndpoint,omitempty"`
@@ -328,6 +466,19 @@ type DaemonEndpoint struct {
 	Port int32 `json:"port"`
 }
 
+// DefaultNodeAgentPort is where the node agent serves streaming requests when the node doesn't report a port
+const DefaultNodeAgentPort = 10250
+
+// Well-known node labels the node agent sets from NodeSystemInfo
+const (
+	LabelArch = "kubernetes.io/arch"
+	LabelOS   = "kubernetes.io/os"
+)
+
+// LabelHostname is the topology key of single-node domains; nodes without
+// the label are their own domain under their name
+const LabelHostname = "kubernetes.io/hostname"
+
 // NodeSystemInfo is a set of ids/uuids to uniquely identify the node
 type NodeSystemInfo struct {
 	MachineID               string `json:"machineID"`
@@ -346,6 +497,25 @@ type Taint struct {
 	Key    string `json:"key"`
 	Value  string `json:"value,omitempty"`
 	Effect string `json:"effect"`
+	// TimeAdded is when a NoExecute taint was added, which tolerationSeconds
+	// count from
+	TimeAdded *time.Time `json:"timeAdded,omitempty"`
+}
+
+// Toleration lets a pod be scheduled to and keep running on nodes with
+// matching taints
+type Toleration struct {
+	// Key is the taint key tolerated; empty with the Exists operator
+	// tolerates every taint
+	Key string `json:"key,omitempty"`
+	// Operator is Equal, the default, or Exists, which tolerates any value
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+	// Effect is the taint effect tolerated; empty tolerates all of them
+	Effect string `json:"effect,omitempty"`
+	// TolerationSeconds is how long a NoExecute taint is tolerated before
+	// the pod is evicted; nil tolerates it forever
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty"`
 }
 
 // Volume represents a named volume in a pod
@@ -356,8 +526,13 @@ type Volume struct {
 
 // VolumeSource represents the source of a volume
 type VolumeSource struct {
-	HostPath *HostPathVolumeSource `json:"hostPath,omitempty"`
-	EmptyDir *EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	HostPath  *HostPathVolumeSource  `json:"hostPath,omitempty"`
+	EmptyDir  *EmptyDirVolumeSource  `json:"emptyDir,omitempty"`
+	ConfigMap *ConfigMapVolumeSource `json:"configMap,omitempty"`
+	Secret    *SecretVolumeSource    `json:"secret,omitempty"`
+	NFS       *NFSVolumeSource       `json:"nfs,omitempty"`
+	CSI       *CSIVolumeSource       `json:"csi,omitempty"`
+	Projected *ProjectedVolumeSource `json:"projected,omitempty"`
 }
 
 // HostPathVolumeSource represents a host path mapped into a pod
@@ -368,7 +543,100 @@ type HostPathVolumeSource struct {
 
 // EmptyDirVolumeSource represents an empty directory for a pod
 type EmptyDirVolumeSource struct {
-	Medium string `json:"medium,omitempty"`
+	Medium    string `json:"medium,omitempty"`
+	SizeLimit string `json:"sizeLimit,omitempty"`
+}
+
+// Storage media for empty dir volumes
+const (
+	// StorageMediumDefault uses the node's disk
+	StorageMediumDefault = ""
+	// StorageMediumMemory uses a tmpfs backed by RAM
+	StorageMediumMemory = "Memory"
+)
+
+// ConfigMapVolumeSource projects the keys of a config map into a pod as files
+type ConfigMapVolumeSource struct {
+	Name        string      `json:"name"`
+	Items       []KeyToPath `json:"items,omitempty"`
+	DefaultMode *int32      `json:"defaultMode,omitempty"`
+	Optional    bool        `json:"optional,omitempty"`
+}
+
+// SecretVolumeSource projects the keys of a secret into a pod as files
+type SecretVolumeSource struct {
+	SecretName  string      `json:"secretName"`
+	Items       []KeyToPath `json:"items,omitempty"`
+	DefaultMode *int32      `json:"defaultMode,omitempty"`
+	Optional    bool        `json:"optional,omitempty"`
+}
+
+// KeyToPath maps a config map or secret key to a relative file path
+type KeyToPath struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+	Mode *int32 `json:"mode,omitempty"`
+}
+
+// NFSVolumeSource represents an NFS export mounted into a pod
+type NFSVolumeSource struct {
+	Server   string `json:"server"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+}
+
+// ProjectedVolumeSource combines several volume sources into one directory
+type ProjectedVolumeSource struct {
+	Sources     []VolumeProjection `json:"sources"`
+	DefaultMode *int32             `json:"defaultMode,omitempty"`
+}
+
+// VolumeProjection is a single source of a projected volume; exactly one field is set
+type VolumeProjection struct {
+	Secret              *SecretProjection              `json:"secret,omitempty"`
+	ConfigMap           *ConfigMapProjection           `json:"configMap,omitempty"`
+	DownwardAPI         *DownwardAPIProjection         `json:"downwardAPI,omitempty"`
+	ServiceAccountToken *ServiceAccountTokenProjection `json:"serviceAccountToken,omitempty"`
+}
+
+// SecretProjection projects secret keys into a projected volume
+type SecretProjection struct {
+	Name     string      `json:"name"`
+	Items    []KeyToPath `json:"items,omitempty"`
+	Optional bool        `json:"optional,omitempty"`
+}
+
+// ConfigMapProjection projects config map keys into a projected volume
+type ConfigMapProjection struct {
+	Name     string      `json:"name"`
+	Items    []KeyToPath `json:"items,omitempty"`
+	Optional bool        `json:"optional,omitempty"`
+}
+
+// DownwardAPIProjection projects pod metadata fields into a projected volume
+type DownwardAPIProjection struct {
+	Items []DownwardAPIVolumeFile `json:"items"`
+}
+
+// DownwardAPIVolumeFile writes one pod field to a file
+type DownwardAPIVolumeFile struct {
+	Path      string `json:"path"`
+	FieldPath string `json:"fieldPath"`
+}
+
+// ServiceAccountTokenProjection writes a token for the pod's service account
+type ServiceAccountTokenProjection struct {
+	Path              string `json:"path"`
+	ExpirationSeconds int64  `json:"expirationSeconds,omitempty"`
+}
+
+// CSIVolumeSource represents a volume provided by an external CSI-lite driver
+type CSIVolumeSource struct {
+	Driver           string            `json:"driver"`
+	VolumeHandle     string            `json:"volumeHandle,omitempty"`
+	ReadOnly         bool              `json:"readOnly,omitempty"`
+	FSType           string            `json:"fsType,omitempty"`
+	VolumeAttributes map[string]string `json:"volumeAttributes,omitempty"`
 }
 
 // LocalObjectReference contains enough information to let you locate the referenced object
@@ -381,10 +649,9 @@ type PodPhase string
 
 const (
 	// PodPending means the pod has been accepted by the system, but one or more of the
-	// containers has not been started
+	// containers has not been started. Whether it was bound to a node yet is
+	// told by its spec.nodeName and PodScheduled condition.
 	PodPending PodPhase = "Pending"
-	// PodScheduled means the pod has been scheduled to a node
-	PodScheduled PodPhase = "Scheduled"
 	// PodRunning means the pod has been bound to a node and all of the containers have been started
 	PodRunning PodPhase = "Running"
 	// PodSucceeded means that all containers in the pod have voluntarily terminated
@@ -433,14 +700,119 @@ type DeploymentSpec struct {
 	Replicas int32           `json:"replicas,omitempty"`
 	Selector *LabelSelector  `json:"selector"`
 	Template PodTemplateSpec `json:"template"`
+
+	// Paused stops template changes from being rolled out; scaling still applies
+	Paused bool `json:"paused,omitempty"`
+
+	// MinReadySeconds is how long a new pod must be ready before it counts as available
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+
+	// ProgressDeadlineSeconds is how long a rollout may go without progress
+	// before it's reported as failed; defaults to DefaultProgressDeadlineSeconds
+	ProgressDeadlineSeconds int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// Strategy is how template changes are rolled out
+	Strategy *DeploymentStrategy `json:"strategy,omitempty"`
 }
 
+// Deployment strategy types. Deployments without a strategy replace their
+// ReplicaSet as soon as the template changes.
+const (
+	// DeploymentStrategyCanary shifts replicas to the new ReplicaSet in steps
+	DeploymentStrategyCanary = "Canary"
+	// DeploymentStrategyBlueGreen runs a full-size new ReplicaSet next to the
+	// old one and switches a Service over to it once it's ready
+	DeploymentStrategyBlueGreen = "BlueGreen"
+)
+
+// LabelTemplateHash tells apart the ReplicaSets of a BlueGreen deployment so
+// Services can select one of them
+const LabelTemplateHash = "minik8s.io/template-hash"
+
+// DeploymentStrategy describes how template changes are rolled out
+type DeploymentStrategy struct {
+	Type      string             `json:"type,omitempty"`
+	Canary    *CanaryStrategy    `json:"canary,omitempty"`
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+}
+
+// CanaryStrategy rolls out a new template by running the old and new
+// ReplicaSets side by side at the ratios given by its steps
+type CanaryStrategy struct {
+	Steps []CanaryStep `json:"steps"`
+}
+
+// CanaryStep is one step of a canary rollout: either shifting the share of
+// replicas in the new ReplicaSet, or pausing until the rollout is promoted.
+// Weight steps complete once the new replicas are available; once all steps
+// are done, the new ReplicaSet takes all replicas.
+type CanaryStep struct {
+	// SetWeight is the percentage of replicas to run from the new ReplicaSet
+	SetWeight *int32 `json:"setWeight,omitempty"`
+	// Pause waits for `cli rollout promote` before the next step
+	Pause bool `json:"pause,omitempty"`
+}
+
+// CanaryStatus is the progress of a canary rollout
+type CanaryStatus struct {
+	// ReplicaSet is the new ReplicaSet being rolled out
+	ReplicaSet string `json:"replicaSet"`
+	// CurrentStep is the index of the step in progress; it equals the number
+	// of steps once the rollout is complete
+	CurrentStep int32 `json:"currentStep"`
+	// Weight is the current percentage of replicas in the new ReplicaSet
+	Weight int32 `json:"weight"`
+}
+
+// BlueGreenStrategy rolls out a new template by bringing up a full-size new
+// ReplicaSet and, once it's ready and promoted, pointing the active Service
+// at it and scaling the old one down
+type BlueGreenStrategy struct {
+	// ActiveService is the Service whose selector is switched to the new
+	// ReplicaSet on promotion
+	ActiveService string `json:"activeService"`
+	// PreviewService, if set, selects the new ReplicaSet while it waits
+	PreviewService string `json:"previewService,omitempty"`
+	// AutoPromote switches as soon as the new ReplicaSet is ready instead of
+	// waiting for `cli rollout promote`
+	AutoPromote bool `json:"autoPromote,omitempty"`
+}
+
+// BlueGreenStatus is the progress of a blue/green rollout
+type BlueGreenStatus struct {
+	// ActiveReplicaSet is the ReplicaSet the active Service selects
+	ActiveReplicaSet string `json:"activeReplicaSet,omitempty"`
+	// PreviewReplicaSet is the new ReplicaSet waiting to be switched to
+	PreviewReplicaSet string `json:"previewReplicaSet,omitempty"`
+	// Promoted is set once the preview may be switched to when it's ready
+	Promoted bool `json:"promoted,omitempty"`
+}
+
+// DefaultProgressDeadlineSeconds is the progress deadline of deployments
+// that don't set one
+const DefaultProgressDeadlineSeconds = 600
+
 // DeploymentStatus represents the current state of a Deployment
 type DeploymentStatus struct {
-	Replicas            int32 `json:"replicas,omitempty"`
-	UpdatedReplicas     int32 `json:"updatedReplicas,omitempty"`
-	AvailableReplicas   int32 `json:"availableReplicas,omitempty"`
-	UnavailableReplicas int32 `json:"unavailableReplicas,omitempty"`
+	Replicas            int32                 `json:"replicas,omitempty"`
+	UpdatedReplicas     int32                 `json:"updatedReplicas,omitempty"`
+	AvailableReplicas   int32                 `json:"availableReplicas,omitempty"`
+	UnavailableReplicas int32                 `json:"unavailableReplicas,omitempty"`
+	Conditions          []DeploymentCondition `json:"conditions,omitempty"`
+	Canary              *CanaryStatus         `json:"canary,omitempty"`
+	BlueGreen           *BlueGreenStatus      `json:"blueGreen,omitempty"`
+}
+
+// DeploymentCondition describes the state of a deployment at a certain point
+type DeploymentCondition struct {
+	Type   string          `json:"type"`
+	Status ConditionStatus `json:"status"`
+	// LastUpdateTime is when the condition was last confirmed; for
+	// Progressing, when the rollout last made progress
+	LastUpdateTime     time.Time `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
 }
 
 // Deployment represents a deployment
@@ -496,11 +868,35 @@ func (d *Deployment) SetCreationTimestamp(timestamp time.Time) {
 	d.CreationTimestamp = timestamp
 }
 
+// ScaleSpec describes the desired replica count of a scalable resource
+type ScaleSpec struct {
+	Replicas int32 `json:"replicas"`
+}
+
+// ScaleStatus represents the current replica count of a scalable resource
+type ScaleStatus struct {
+	Replicas int32 `json:"replicas"`
+	// Selector is the label selector of the pods, in key=value,... form
+	Selector string `json:"selector,omitempty"`
+}
+
+// Scale is the scale subresource of deployments and replicasets. It lets
+// tooling read and change the replica count without knowing the full spec.
+type Scale struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       ScaleSpec   `json:"spec"`
+	Status     ScaleStatus `json:"status"`
+}
+
 // ReplicaSetSpec describes the desired state of a ReplicaSet
 type ReplicaSetSpec struct {
 	Replicas int32           `json:"replicas,omitempty"`
 	Selector *LabelSelector  `json:"selector"`
 	Template PodTemplateSpec `json:"template"`
+
+	// MinReadySeconds is how long a new pod must be ready before it counts as available
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
 }
 
 // ReplicaSetStatus represents the current state of a ReplicaSet
@@ -563,3 +959,509 @@ func (r *ReplicaSet) GetCreationTimestamp() time.Time {
 func (r *ReplicaSet) SetCreationTimestamp(timestamp time.Time) {
 	r.CreationTimestamp = timestamp
 }
+
+// ConfigMap holds non-confidential configuration data for pods
+type ConfigMap struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Data       map[string]string `json:"data,omitempty"`
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+}
+
+// GetKind returns the kind of the config map
+func (c *ConfigMap) GetKind() string {
+	return c.Kind
+}
+
+// GetAPIVersion returns the API version of the config map
+func (c *ConfigMap) GetAPIVersion() string {
+	return c.APIVersion
+}
+
+// GetName returns the name of the config map
+func (c *ConfigMap) GetName() string {
+	return c.Name
+}
+
+// GetNamespace returns the namespace of the config map
+func (c *ConfigMap) GetNamespace() string {
+	return c.Namespace
+}
+
+// GetUID returns the UID of the config map
+func (c *ConfigMap) GetUID() string {
+	return c.UID
+}
+
+// GetResourceVersion returns the resource version of the config map
+func (c *ConfigMap) GetResourceVersion() string {
+	return c.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the config map
+func (c *ConfigMap) SetResourceVersion(version string) {
+	c.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the config map
+func (c *ConfigMap) GetCreationTimestamp() time.Time {
+	return c.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the config map
+func (c *ConfigMap) SetCreationTimestamp(timestamp time.Time) {
+	c.CreationTimestamp = timestamp
+}
+
+// Secret holds sensitive data such as credentials for pods
+type Secret struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+// GetKind returns the kind of the secret
+func (s *Secret) GetKind() string {
+	return s.Kind
+}
+
+// GetAPIVersion returns the API version of the secret
+func (s *Secret) GetAPIVersion() string {
+	return s.APIVersion
+}
+
+// GetName returns the name of the secret
+func (s *Secret) GetName() string {
+	return s.Name
+}
+
+// GetNamespace returns the namespace of the secret
+func (s *Secret) GetNamespace() string {
+	return s.Namespace
+}
+
+// GetUID returns the UID of the secret
+func (s *Secret) GetUID() string {
+	return s.UID
+}
+
+// GetResourceVersion returns the resource version of the secret
+func (s *Secret) GetResourceVersion() string {
+	return s.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the secret
+func (s *Secret) SetResourceVersion(version string) {
+	s.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the secret
+func (s *Secret) GetCreationTimestamp() time.Time {
+	return s.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the secret
+func (s *Secret) SetCreationTimestamp(timestamp time.Time) {
+	s.CreationTimestamp = timestamp
+}
+
+// ServiceAccount provides an identity for processes running in pods
+type ServiceAccount struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+}
+
+// GetKind returns the kind of the service account
+func (sa *ServiceAccount) GetKind() string {
+	return sa.Kind
+}
+
+// GetAPIVersion returns the API version of the service account
+func (sa *ServiceAccount) GetAPIVersion() string {
+	return sa.APIVersion
+}
+
+// GetName returns the name of the service account
+func (sa *ServiceAccount) GetName() string {
+	return sa.Name
+}
+
+// GetNamespace returns the namespace of the service account
+func (sa *ServiceAccount) GetNamespace() string {
+	return sa.Namespace
+}
+
+// GetUID returns the UID of the service account
+func (sa *ServiceAccount) GetUID() string {
+	return sa.UID
+}
+
+// GetResourceVersion returns the resource version of the service account
+func (sa *ServiceAccount) GetResourceVersion() string {
+	return sa.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the service account
+func (sa *ServiceAccount) SetResourceVersion(version string) {
+	sa.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the service account
+func (sa *ServiceAccount) GetCreationTimestamp() time.Time {
+	return sa.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the service account
+func (sa *ServiceAccount) SetCreationTimestamp(timestamp time.Time) {
+	sa.CreationTimestamp = timestamp
+}
+
+// Service account token defaults shared by the API server and node agents
+const (
+	// DefaultServiceAccountName is the service account pods run as when none is set
+	DefaultServiceAccountName = "default"
+	// ServiceAccountVolumeName is the projected volume holding a pod's API credentials
+	ServiceAccountVolumeName = "minik8s-api-access"
+	// ServiceAccountMountPath is where containers find the token, CA and namespace
+	ServiceAccountMountPath = "/var/run/secrets/minik8s.io/serviceaccount"
+	// RootCAConfigMapName is the config map holding the API server's CA bundle
+	RootCAConfigMapName = "minik8s-root-ca.crt"
+	// RootCAConfigMapKey is the key of the CA bundle in the root CA config map
+	RootCAConfigMapKey = "ca.crt"
+)
+
+// TokenRequest requests a token for a service account. It is not persisted.
+type TokenRequest struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       TokenRequestSpec   `json:"spec"`
+	Status     TokenRequestStatus `json:"status"`
+}
+
+// TokenRequestSpec describes the requested token
+type TokenRequestSpec struct {
+	ExpirationSeconds int64                 `json:"expirationSeconds,omitempty"`
+	BoundObjectRef    *BoundObjectReference `json:"boundObjectRef,omitempty"`
+	// Namespaces scopes the token to objects in these namespaces; empty
+	// allows all the requester may access
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// BoundObjectReference ties a token's validity to an object, such as the pod using it
+type BoundObjectReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	UID  string `json:"uid,omitempty"`
+}
+
+// TokenRequestStatus holds the issued token
+type TokenRequestStatus struct {
+	Token               string    `json:"token"`
+	ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+}
+
+// ServiceSpec describes how a service exposes a set of pods
+type ServiceSpec struct {
+	Selector  map[string]string `json:"selector,omitempty"`
+	Ports     []ServicePort     `json:"ports,omitempty"`
+	ClusterIP string            `json:"clusterIP,omitempty"`
+	Type      string            `json:"type,omitempty"`
+}
+
+// ServicePort is a port exposed by a service
+type ServicePort struct {
+	Name       string `json:"name,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	Port       int32  `json:"port"`
+	TargetPort int32  `json:"targetPort,omitempty"`
+}
+
+// ClusterIPNone marks a headless service that gets no virtual IP
+const ClusterIPNone = "None"
+
+// Service gives a stable address to a set of pods selected by labels
+type Service struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       ServiceSpec `json:"spec"`
+}
+
+// GetKind returns the kind of the service
+func (s *Service) GetKind() string {
+	return s.Kind
+}
+
+// GetAPIVersion returns the API version of the service
+func (s *Service) GetAPIVersion() string {
+	return s.APIVersion
+}
+
+// GetName returns the name of the service
+func (s *Service) GetName() string {
+	return s.Name
+}
+
+// GetNamespace returns the namespace of the service
+func (s *Service) GetNamespace() string {
+	return s.Namespace
+}
+
+// GetUID returns the UID of the service
+func (s *Service) GetUID() string {
+	return s.UID
+}
+
+// GetResourceVersion returns the resource version of the service
+func (s *Service) GetResourceVersion() string {
+	return s.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the service
+func (s *Service) SetResourceVersion(version string) {
+	s.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the service
+func (s *Service) GetCreationTimestamp() time.Time {
+	return s.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the service
+func (s *Service) SetCreationTimestamp(timestamp time.Time) {
+	s.CreationTimestamp = timestamp
+}
+
+// ServiceTypeClusterIP is the only service type: a virtual IP reachable
+// inside the cluster
+const ServiceTypeClusterIP = "ClusterIP"
+
+// Endpoints lists the addresses of the pods a service selects. The service
+// controller keeps one, named after the service, for every service with a
+// selector.
+type Endpoints struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	// Addresses are the selected pods that are ready, NotReadyAddresses
+	// those that aren't ready yet
+	Addresses         []EndpointAddress `json:"addresses,omitempty"`
+	NotReadyAddresses []EndpointAddress `json:"notReadyAddresses,omitempty"`
+	Ports             []EndpointPort    `json:"ports,omitempty"`
+}
+
+// EndpointAddress is the address of one pod behind a service
+type EndpointAddress struct {
+	IP        string           `json:"ip"`
+	NodeName  string           `json:"nodeName,omitempty"`
+	TargetRef *ObjectReference `json:"targetRef,omitempty"`
+}
+
+// EndpointPort is a port the pods behind a service listen on, named after
+// the service port it serves
+type EndpointPort struct {
+	Name     string `json:"name,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Port     int32  `json:"port"`
+}
+
+// GetKind returns the kind of the endpoints
+func (e *Endpoints) GetKind() string {
+	return e.Kind
+}
+
+// GetAPIVersion returns the API version of the endpoints
+func (e *Endpoints) GetAPIVersion() string {
+	return e.APIVersion
+}
+
+// GetName returns the name of the endpoints
+func (e *Endpoints) GetName() string {
+	return e.Name
+}
+
+// GetNamespace returns the namespace of the endpoints
+func (e *Endpoints) GetNamespace() string {
+	return e.Namespace
+}
+
+// GetUID returns the UID of the endpoints
+func (e *Endpoints) GetUID() string {
+	return e.UID
+}
+
+// GetResourceVersion returns the resource version of the endpoints
+func (e *Endpoints) GetResourceVersion() string {
+	return e.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the endpoints
+func (e *Endpoints) SetResourceVersion(version string) {
+	e.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the endpoints
+func (e *Endpoints) GetCreationTimestamp() time.Time {
+	return e.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the endpoints
+func (e *Endpoints) SetCreationTimestamp(timestamp time.Time) {
+	e.CreationTimestamp = timestamp
+}
+
+const (
+	// EventTypeNormal is for events reporting things going as expected
+	EventTypeNormal = "Normal"
+	// EventTypeWarning is for events reporting something that may need attention
+	EventTypeWarning = "Warning"
+)
+
+// ObjectReference identifies the object an event is about
+type ObjectReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// EventSource names the component that reported an event
+type EventSource struct {
+	Component string `json:"component,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+// Event reports something that happened to an object. Repeats of the same
+// event are folded into one Event whose Count and LastTimestamp grow.
+type Event struct {
+	TypeMeta       `json:",inline"`
+	ObjectMeta     `json:"metadata"`
+	InvolvedObject ObjectReference `json:"involvedObject"`
+	Type           string          `json:"type,omitempty"`
+	Reason         string          `json:"reason,omitempty"`
+	Message        string          `json:"message,omitempty"`
+	Source         EventSource     `json:"source,omitempty"`
+	Count          int32           `json:"count,omitempty"`
+	FirstTimestamp time.Time       `json:"firstTimestamp,omitempty"`
+	LastTimestamp  time.Time       `json:"lastTimestamp,omitempty"`
+}
+
+// GetKind returns the kind of the event
+func (e *Event) GetKind() string {
+	return e.Kind
+}
+
+// GetAPIVersion returns the API version of the event
+func (e *Event) GetAPIVersion() string {
+	return e.APIVersion
+}
+
+// GetName returns the name of the event
+func (e *Event) GetName() string {
+	return e.Name
+}
+
+// GetNamespace returns the namespace of the event
+func (e *Event) GetNamespace() string {
+	return e.Namespace
+}
+
+// GetUID returns the UID of the event
+func (e *Event) GetUID() string {
+	return e.UID
+}
+
+// GetResourceVersion returns the resource version of the event
+func (e *Event) GetResourceVersion() string {
+	return e.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the event
+func (e *Event) SetResourceVersion(version string) {
+	e.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the event
+func (e *Event) GetCreationTimestamp() time.Time {
+	return e.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the event
+func (e *Event) SetCreationTimestamp(timestamp time.Time) {
+	e.CreationTimestamp = timestamp
+}
+
+// Well-known namespaces created when the API server starts
+const (
+	// NamespaceDefault holds objects created without a namespace
+	NamespaceDefault = "default"
+	// NamespaceSystem holds objects belonging to the cluster's own components
+	NamespaceSystem = "kube-system"
+)
+
+// NamespacePhase is the lifecycle phase of a namespace
+type NamespacePhase string
+
+const (
+	// NamespaceActive means the namespace is in use
+	NamespaceActive NamespacePhase = "Active"
+)
+
+// NamespaceStatus represents the current state of a Namespace
+type NamespaceStatus struct {
+	Phase NamespacePhase `json:"phase,omitempty"`
+}
+
+// Namespace groups objects and scopes their names
+type Namespace struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Status     NamespaceStatus `json:"status,omitempty"`
+}
+
+// GetKind returns the kind of the namespace
+func (n *Namespace) GetKind() string {
+	return n.Kind
+}
+
+// GetAPIVersion returns the API version of the namespace
+func (n *Namespace) GetAPIVersion() string {
+	return n.APIVersion
+}
+
+// GetName returns the name of the namespace
+func (n *Namespace) GetName() string {
+	return n.Name
+}
+
+// GetNamespace returns the namespace of the namespace, which is always empty
+func (n *Namespace) GetNamespace() string {
+	return n.ObjectMeta.Namespace
+}
+
+// GetUID returns the UID of the namespace
+func (n *Namespace) GetUID() string {
+	return n.UID
+}
+
+// GetResourceVersion returns the resource version of the namespace
+func (n *Namespace) GetResourceVersion() string {
+	return n.ResourceVersion
+}
+
+// SetResourceVersion sets the resource version of the namespace
+func (n *Namespace) SetResourceVersion(version string) {
+	n.ResourceVersion = version
+}
+
+// GetCreationTimestamp returns the creation timestamp of the namespace
+func (n *Namespace) GetCreationTimestamp() time.Time {
+	return n.CreationTimestamp
+}
+
+// SetCreationTimestamp sets the creation timestamp of the namespace
+func (n *Namespace) SetCreationTimestamp(timestamp time.Time) {
+	n.CreationTimestamp = timestamp
+}
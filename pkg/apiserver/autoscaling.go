@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createAutoscaler handles horizontal pod autoscaler creation
+func (s *Server) createAutoscaler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var hpa api.HorizontalPodAutoscaler
+	if err := json.NewDecoder(r.Body).Decode(&hpa); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&hpa.TypeMeta, &hpa.ObjectMeta, "HorizontalPodAutoscaler", vars["namespace"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.HorizontalPodAutoscaler(&hpa); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &hpa, &hpa.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hpa)
+}
+
+// getAutoscaler handles horizontal pod autoscaler retrieval
+func (s *Server) getAutoscaler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	hpa, err := s.store.Get(r.Context(), "HorizontalPodAutoscaler", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hpa)
+}
+
+// listAutoscalers handles horizontal pod autoscaler listing
+func (s *Server) listAutoscalers(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "HorizontalPodAutoscaler", "HorizontalPodAutoscalerList")
+}
+
+// updateAutoscaler handles horizontal pod autoscaler updates. The status
+// belongs to the autoscaler controller and is kept.
+func (s *Server) updateAutoscaler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var hpa api.HorizontalPodAutoscaler
+	if err := json.NewDecoder(r.Body).Decode(&hpa); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hpa.Kind = "HorizontalPodAutoscaler"
+	hpa.APIVersion = "v1alpha1"
+	hpa.Namespace = vars["namespace"]
+	hpa.Name = vars["name"]
+	if err := validation.HorizontalPodAutoscaler(&hpa); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := s.preserveUID(ctx, "HorizontalPodAutoscaler", &hpa.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if existing, err := s.store.Get(ctx, "HorizontalPodAutoscaler", hpa.Namespace, hpa.Name); err == nil {
+		if stored, ok := existing.(*api.HorizontalPodAutoscaler); ok {
+			hpa.Status = stored.Status
+		}
+	}
+	if err := s.store.Update(ctx, &hpa); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hpa)
+}
+
+// deleteAutoscaler handles horizontal pod autoscaler deletion
+func (s *Server) deleteAutoscaler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "HorizontalPodAutoscaler", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
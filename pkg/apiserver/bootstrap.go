@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// EnableBootstrapTokens lets nodes join with one of tokens, exchanging it
+// for node credentials at api.BootstrapJoinPath. Once enabled, nodes can only
+// be registered through the API by themselves. Service accounts must be
+// enabled first, since node credentials are signed with the same key.
+func (s *Server) EnableBootstrapTokens(tokens []*auth.BootstrapToken) error {
+	if s.tokenSigner == nil {
+		return fmt.Errorf("bootstrap tokens require service accounts to be enabled")
+	}
+
+	s.bootstrapTokens = true
+	s.authenticator = auth.Union(s.authenticator, auth.NewBootstrapTokenAuthenticator(tokens))
+	return nil
+}
+
+// restrictBootstrappers rejects requests authenticated with a bootstrap
+// token; those may only be used to join
+func (s *Server) restrictBootstrappers(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := auth.UserFrom(r.Context()); ok && auth.IsBootstrapper(user) {
+			http.Error(w, "bootstrap tokens may only be used to join nodes", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canRegisterNode reports whether the request may create the node. Nodes may
+// only register themselves. Until bootstrap tokens are enabled anyone else
+// may; afterwards nobody else.
+func (s *Server) canRegisterNode(r *http.Request, name string) bool {
+	if nodeName, isNode := requestingNode(r.Context()); isNode {
+		return nodeName == name
+	}
+	return !s.bootstrapTokens
+}
+
+// bootstrapCAHandler serves the cluster CA so joining nodes can check it
+// against their pinned hash before trusting the server
+func (s *Server) bootstrapCAHandler(w http.ResponseWriter, r *http.Request) {
+	if len(s.rootCA) == 0 {
+		http.Error(w, "the API server has no root CA configured", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Write(s.rootCA)
+}
+
+// joinNode registers the node of a request authenticated with a bootstrap
+// token and returns credentials for it
+func (s *Server) joinNode(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFrom(r.Context())
+	if !s.bootstrapTokens || !ok || !auth.IsBootstrapper(user) {
+		http.Error(w, "joining requires a bootstrap token", http.StatusUnauthorized)
+		return
+	}
+
+	var req api.NodeJoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.NodeName == "" || len(req.NodeName) > api.MaxNameLength || strings.ContainsAny(req.NodeName, "/ ") {
+		http.Error(w, fmt.Sprintf("invalid node name %q", req.NodeName), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Register the node unless it is rejoining
+	ctx := r.Context()
+	if _, err := s.store.Get(ctx, "Node", "", req.NodeName); err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			writeStoreError(w, err)
+			return
+		}
+		node := &api.Node{ObjectMeta: api.ObjectMeta{Name: req.NodeName}}
+		if err := populateMetadata(&node.TypeMeta, &node.ObjectMeta, "Node", ""); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := s.store.Create(ctx, node); err != nil && !errors.Is(err, store.ErrAlreadyExists) {
+			writeStoreError(w, err)
+			return
+		}
+	}
+
+	token, err := s.tokenSigner.Sign(&auth.Claims{
+		Subject:  auth.NodeUsername(req.NodeName),
+		NodeName: req.NodeName,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("Node %s joined with bootstrap token %s\n", req.NodeName, user.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.NodeJoinResponse{Token: token, CACert: string(s.rootCA)})
+}
@@ -0,0 +1,77 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// getCapacity reports, for each node and for the whole cluster, how much of
+// each resource is allocatable, how much the pods bound there request and,
+// from the usage samples node agents report, how much they use. Finished
+// pods don't count, and neither do pods bound to nodes that are gone.
+func (s *Server) getCapacity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	nodes, err := s.store.List(ctx, "Node", "")
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	pods, err := s.store.List(ctx, "Pod", store.AllNamespaces)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	summaries := make(map[string]*api.NodeCapacity, len(nodes))
+	for _, obj := range nodes {
+		node, ok := obj.(*api.Node)
+		if !ok {
+			continue
+		}
+		summary := &api.NodeCapacity{Name: node.Name, Unschedulable: node.Spec.Unschedulable, CapacitySummary: api.NewCapacitySummary()}
+		for name, resource := range summary.Resources {
+			resource.Allocatable, _ = api.ParseQuantity(name, node.Status.Allocatable[name])
+		}
+		summaries[node.Name] = summary
+	}
+
+	for _, obj := range pods {
+		pod, ok := obj.(*api.Pod)
+		if !ok || pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		summary, ok := summaries[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		summary.Pods++
+		for name, resource := range summary.Resources {
+			resource.Requested += api.PodRequest(pod, name)
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Usage == nil || status.State.Running == nil {
+				continue
+			}
+			summary.Resources[api.ResourceCPU].AddUsed(float64(status.Usage.CPUMillicores) / 1000)
+			summary.Resources[api.ResourceMemory].AddUsed(float64(status.Usage.MemoryBytes))
+		}
+	}
+
+	report := api.CapacityReport{
+		TypeMeta: api.TypeMeta{Kind: "CapacityReport", APIVersion: "v1alpha1"},
+		Nodes:    []api.NodeCapacity{},
+		Cluster:  api.NewCapacitySummary(),
+	}
+	for _, summary := range summaries {
+		report.Nodes = append(report.Nodes, *summary)
+		report.Cluster.Add(summary.CapacitySummary)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool { return report.Nodes[i].Name < report.Nodes[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestGetCapacity(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+
+	newNode := func(name, cpu, memory string) *api.Node {
+		return &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Status:     api.NodeStatus{Allocatable: api.ResourceList{api.ResourceCPU: cpu, api.ResourceMemory: memory}},
+		}
+	}
+	newPod := func(namespace, name, nodeName string, phase api.PodPhase, cpu string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: api.PodSpec{NodeName: nodeName, Containers: []api.Container{{
+				Name: "app", Image: "nginx",
+				Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: cpu, api.ResourceMemory: "1Gi"}},
+			}}},
+			Status: api.PodStatus{Phase: phase},
+		}
+	}
+	measured := newPod("team-a", "measured", "node-1", api.PodRunning, "500m")
+	measured.Status.ContainerStatuses = []api.ContainerStatus{{
+		Name:  "app",
+		State: api.ContainerState{Running: &api.ContainerStateRunning{}},
+		Usage: &api.ContainerUsage{CPUMillicores: 250, MemoryBytes: 512 << 20},
+	}}
+	objs := []store.Object{
+		newNode("node-1", "4", "8Gi"),
+		newNode("node-2", "2", "4Gi"),
+		measured,
+		newPod("default", "web", "node-1", api.PodRunning, "1"),
+		newPod("default", "done", "node-1", api.PodSucceeded, "1"),
+		newPod("default", "pending", "", api.PodPending, "1"),
+		newPod("default", "orphan", "gone", api.PodRunning, "1"),
+	}
+	for _, obj := range objs {
+		if err := s.store.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/capacity", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report api.CapacityReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Nodes) != 2 || report.Nodes[0].Name != "node-1" || report.Nodes[1].Name != "node-2" {
+		t.Fatalf("Expected node-1 and node-2, got %+v", report.Nodes)
+	}
+	node := report.Nodes[0]
+	cpu, memory := node.Resources[api.ResourceCPU], node.Resources[api.ResourceMemory]
+	if node.Pods != 2 || cpu.Allocatable != 4 || cpu.Requested != 1.5 || memory.Requested != 2<<30 {
+		t.Errorf("Expected node-1 to run 2 pods requesting 1.5 of 4 cores and 2Gi, got %d pods, %+v and %+v", node.Pods, cpu, memory)
+	}
+	if cpu.Used == nil || *cpu.Used != 0.25 || memory.Used == nil || *memory.Used != 512<<20 {
+		t.Errorf("Expected node-1 to use 0.25 cores and 512Mi, got %v and %v", cpu.Used, memory.Used)
+	}
+	if idle := report.Nodes[1]; idle.Pods != 0 || idle.Resources[api.ResourceCPU].Used != nil {
+		t.Errorf("Expected node-2 to be idle with no usage reported, got %+v", idle)
+	}
+
+	cluster := report.Cluster
+	if cluster.Pods != 2 || cluster.Resources[api.ResourceCPU].Allocatable != 6 || cluster.Resources[api.ResourceMemory].Allocatable != 12<<30 {
+		t.Errorf("Expected the cluster to total 2 pods, 6 cores and 12Gi, got %d pods, %+v and %+v", cluster.Pods, cluster.Resources[api.ResourceCPU], cluster.Resources[api.ResourceMemory])
+	}
+	if used := cluster.Resources[api.ResourceCPU].Used; used == nil || *used != 0.25 {
+		t.Errorf("Expected the cluster to use 0.25 cores, got %v", used)
+	}
+}
@@ -0,0 +1,165 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// signers are the signer names a CertificateSigningRequest may ask for
+var signers = map[string]bool{
+	api.SignerAPIServerClient: true,
+	api.SignerNodeServing:     true,
+}
+
+// createCertificateSigningRequest handles certificate signing request
+// creation. The requester is recorded in the spec so the signer and
+// approvers can check the request against it.
+func (s *Server) createCertificateSigningRequest(w http.ResponseWriter, r *http.Request) {
+	var csr api.CertificateSigningRequest
+	if err := json.NewDecoder(r.Body).Decode(&csr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&csr.TypeMeta, &csr.ObjectMeta, "CertificateSigningRequest", ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCertificateSigningRequest(&csr); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctx := r.Context()
+	csr.Spec.Username, csr.Spec.Groups = "", nil
+	if user, ok := auth.UserFrom(ctx); ok {
+		csr.Spec.Username = user.Name
+		csr.Spec.Groups = user.Groups
+	}
+	csr.Status = api.CertificateSigningRequestStatus{}
+
+	if err := store.CreateWithGeneratedName(ctx, s.store, &csr, &csr.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(csr)
+}
+
+// validateCertificateSigningRequest checks that csr asks a known signer for
+// a well-formed certificate request
+func validateCertificateSigningRequest(csr *api.CertificateSigningRequest) error {
+	if !signers[csr.Spec.SignerName] {
+		return fmt.Errorf("unknown signer %q", csr.Spec.SignerName)
+	}
+	if csr.Spec.ExpirationSeconds < 0 {
+		return fmt.Errorf("expirationSeconds must not be negative")
+	}
+	if _, err := auth.ParseCertificateRequest([]byte(csr.Spec.Request)); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	return nil
+}
+
+// getCertificateSigningRequest handles certificate signing request retrieval
+func (s *Server) getCertificateSigningRequest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	csr, err := s.store.Get(r.Context(), "CertificateSigningRequest", "", vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(csr)
+}
+
+// listCertificateSigningRequests handles certificate signing request listing
+func (s *Server) listCertificateSigningRequests(w http.ResponseWriter, r *http.Request) {
+	objs, err := s.store.List(r.Context(), "CertificateSigningRequest", "")
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeList(w, "CertificateSigningRequestList", objs)
+}
+
+// deleteCertificateSigningRequest handles certificate signing request
+// deletion. Certificates already issued stay valid until they expire.
+func (s *Server) deleteCertificateSigningRequest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "CertificateSigningRequest", "", vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// updateCertificateApproval handles approving or denying a certificate
+// signing request. Only the Approved and Denied conditions of the body are
+// taken; a request can't be both, and a decision can't be reversed.
+func (s *Server) updateCertificateApproval(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var update api.CertificateSigningRequest
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	obj, err := s.store.Get(ctx, "CertificateSigningRequest", "", vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	current, ok := obj.(*api.CertificateSigningRequest)
+	if !ok {
+		http.Error(w, "stored object is not a certificate signing request", http.StatusInternalServerError)
+		return
+	}
+	if update.UID != "" && update.UID != current.UID {
+		writeStoreError(w, errUIDChanged)
+		return
+	}
+
+	csr := *current
+	csr.Status.Conditions = append([]api.CertificateSigningRequestCondition(nil), current.Status.Conditions...)
+	now := time.Now()
+	for _, condition := range update.Status.Conditions {
+		if condition.Type != api.CertificateApproved && condition.Type != api.CertificateDenied {
+			continue
+		}
+		if csr.CertificateCondition(condition.Type) != nil {
+			continue
+		}
+		condition.Status = "True"
+		condition.LastUpdateTime = now
+		csr.Status.Conditions = append(csr.Status.Conditions, condition)
+	}
+	if csr.CertificateCondition(api.CertificateApproved) != nil && csr.CertificateCondition(api.CertificateDenied) != nil {
+		http.Error(w, "a certificate signing request can't be both approved and denied", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.store.Update(ctx, &csr); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(csr)
+}
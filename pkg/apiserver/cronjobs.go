@@ -0,0 +1,128 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createCronJob handles cronjob creation. The status is left to the
+// cronjob controller.
+func (s *Server) createCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var cronJob api.CronJob
+	if err := json.NewDecoder(r.Body).Decode(&cronJob); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&cronJob.TypeMeta, &cronJob.ObjectMeta, "CronJob", vars["namespace"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.CronJob(&cronJob); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&cronJob); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	cronJob.Status = api.CronJobStatus{}
+
+	if err := store.CreateWithGeneratedName(r.Context(), s.store, &cronJob, &cronJob.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cronJob)
+}
+
+// getCronJob handles cronjob retrieval
+func (s *Server) getCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	cronJob, err := s.store.Get(r.Context(), "CronJob", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cronJob)
+}
+
+// listCronJobs handles cronjob listing
+func (s *Server) listCronJobs(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "CronJob", "CronJobList")
+}
+
+// updateCronJob handles cronjob updates. The status is kept, so an update
+// doesn't start runs that were already started.
+func (s *Server) updateCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var cronJob api.CronJob
+	if err := json.NewDecoder(r.Body).Decode(&cronJob); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cronJob.Kind = "CronJob"
+	cronJob.APIVersion = "v1alpha1"
+	cronJob.Namespace = vars["namespace"]
+	cronJob.Name = vars["name"]
+	if err := validation.CronJob(&cronJob); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&cronJob); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	existing, err := s.store.Get(ctx, "CronJob", cronJob.Namespace, cronJob.Name)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	current, ok := existing.(*api.CronJob)
+	if !ok {
+		http.Error(w, "stored object is not a cronjob", http.StatusInternalServerError)
+		return
+	}
+	if cronJob.UID != "" && cronJob.UID != current.UID {
+		writeStoreError(w, errUIDChanged)
+		return
+	}
+	cronJob.UID = current.UID
+	cronJob.Status = current.Status
+
+	if err := s.store.Update(ctx, &cronJob); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cronJob)
+}
+
+// deleteCronJob handles cronjob deletion. Its jobs are kept.
+func (s *Server) deleteCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "CronJob", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// resourceKinds maps the resources in API paths to the kind of object their
+// request bodies hold
+var resourceKinds = map[string]string{
+	"namespaces":                 "Namespace",
+	"pods":                       "Pod",
+	"deployments":                "Deployment",
+	"replicasets":                "ReplicaSet",
+	"jobs":                       "Job",
+	"cronjobs":                   "CronJob",
+	"horizontalpodautoscalers":   "HorizontalPodAutoscaler",
+	"podgroups":                  "PodGroup",
+	"serviceaccounts":            "ServiceAccount",
+	"secrets":                    "Secret",
+	"services":                   "Service",
+	"nodes":                      "Node",
+	"runtimeclasses":             "RuntimeClass",
+	"certificatesigningrequests": "CertificateSigningRequest",
+}
+
+// warnDeprecations adds a Warning header for every deprecated API version
+// or field a request uses, as registered in api.DeprecatedVersions and
+// api.DeprecatedFields. The request is served as usual.
+func (s *Server) warnDeprecations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var template string
+		if route := mux.CurrentRoute(r); route != nil {
+			template, _ = route.GetPathTemplate()
+		}
+		segments := strings.Split(strings.TrimPrefix(template, "/"), "/")
+
+		var warnings []string
+		if len(segments) >= 2 && segments[0] == "api" {
+			if message, ok := api.DeprecatedVersions[segments[1]]; ok {
+				warnings = append(warnings, message)
+			}
+		}
+
+		if kind := bodyKind(segments); kind != "" && r.Body != nil {
+			var fields []api.DeprecatedField
+			for _, field := range api.DeprecatedFields {
+				if field.Kind == kind {
+					fields = append(fields, field)
+				}
+			}
+			if len(fields) > 0 {
+				body, err := io.ReadAll(r.Body)
+				r.Body.Close()
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+
+				var obj interface{}
+				if json.Unmarshal(body, &obj) == nil {
+					for _, field := range fields {
+						if fieldSet(obj, strings.Split(field.Path, ".")) {
+							warnings = append(warnings, field.Message)
+						}
+					}
+				}
+			}
+		}
+
+		for _, message := range warnings {
+			w.Header().Add("Warning", formatWarning(message))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyKind returns the kind of object sent to the route with the given path
+// template segments, or "" for routes without object bodies such as
+// subresources
+func bodyKind(segments []string) string {
+	if len(segments) < 3 {
+		return ""
+	}
+	rest := segments[2:]
+	if len(rest) >= 3 && rest[0] == "namespaces" && rest[1] == "{namespace}" {
+		rest = rest[2:]
+	}
+	if len(rest) > 2 {
+		return ""
+	}
+	return resourceKinds[rest[0]]
+}
+
+// fieldSet reports whether the field at path holds a non-zero value in obj.
+// A path element ending in [] checks every element of that list.
+func fieldSet(obj interface{}, path []string) bool {
+	if len(path) == 0 {
+		switch value := obj.(type) {
+		case nil:
+			return false
+		case bool:
+			return value
+		case float64:
+			return value != 0
+		case string:
+			return value != ""
+		case []interface{}:
+			return len(value) > 0
+		case map[string]interface{}:
+			return len(value) > 0
+		}
+		return true
+	}
+
+	fields, ok := obj.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	name, list := strings.CutSuffix(path[0], "[]")
+	value, ok := fields[name]
+	if !ok {
+		return false
+	}
+	if !list {
+		return fieldSet(value, path[1:])
+	}
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		if fieldSet(item, path[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatWarning formats message as the value of a Warning header, using
+// the 299 "miscellaneous persistent warning" code and no agent
+func formatWarning(message string) string {
+	escaped := strings.ReplaceAll(message, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `299 - "` + escaped + `"`
+}
@@ -0,0 +1,43 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Events are written by the components that report them, straight to the
+// store, and expired by the event TTL controller. The API only exposes them
+// for reading and manual cleanup.
+
+// listEvents handles event listing in a namespace, or in all namespaces
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "Event", "EventList")
+}
+
+// getEvent handles event retrieval
+func (s *Server) getEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	event, err := s.store.Get(r.Context(), "Event", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
+
+// deleteEvent handles event deletion
+func (s *Server) deleteEvent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "Event", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
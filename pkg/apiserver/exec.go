@@ -0,0 +1,109 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+)
+
+// SetNodeAgentToken sets the bearer token sent to node agents with the exec,
+// attach and log requests proxied to them
+func (s *Server) SetNodeAgentToken(token string) {
+	s.nodeAgentToken = token
+}
+
+// execPod proxies an exec stream to the node agent running the pod
+func (s *Server) execPod(w http.ResponseWriter, r *http.Request) {
+	s.proxyPodStream(w, r, "exec")
+}
+
+// attachPod proxies an attach stream to the node agent running the pod
+func (s *Server) attachPod(w http.ResponseWriter, r *http.Request) {
+	s.proxyPodStream(w, r, "attach")
+}
+
+// proxyPodStream forwards a streaming subresource request for a pod to its node agent
+func (s *Server) proxyPodStream(w http.ResponseWriter, r *http.Request, subresource string) {
+	nodeURL, ok := s.podNodeAgentURL(w, r, subresource)
+	if !ok {
+		return
+	}
+	remotecommand.Proxy(w, r, nodeURL, s.nodeAgentToken)
+}
+
+// podNodeAgentURL returns the URL of a pod's subresource on the node agent
+// running it, with the request's query. Errors are written to w.
+func (s *Server) podNodeAgentURL(w http.ResponseWriter, r *http.Request, subresource string) (*url.URL, bool) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	ctx := r.Context()
+	obj, err := s.store.Get(ctx, "Pod", namespace, name)
+	if err != nil {
+		writeStoreError(w, err)
+		return nil, false
+	}
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		http.Error(w, "stored object is not a pod", http.StatusInternalServerError)
+		return nil, false
+	}
+	if pod.Spec.NodeName == "" {
+		http.Error(w, fmt.Sprintf("pod %s/%s is not scheduled to a node", namespace, name), http.StatusBadRequest)
+		return nil, false
+	}
+
+	nodeURL, err := s.nodeAgentURL(ctx, pod.Spec.NodeName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	nodeURL.Path = fmt.Sprintf("/%s/%s/%s", subresource, namespace, name)
+	nodeURL.RawQuery = r.URL.RawQuery
+	return nodeURL, true
+}
+
+// nodeAgentURL returns the base URL of the node agent on the named node
+func (s *Server) nodeAgentURL(ctx context.Context, nodeName string) (*url.URL, error) {
+	obj, err := s.store.Get(ctx, "Node", "", nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	node, ok := obj.(*api.Node)
+	if !ok {
+		return nil, fmt.Errorf("stored object is not a node")
+	}
+
+	host := node.Name
+	for _, addrType := range []string{api.NodeInternalIP, api.NodeHostName} {
+		if address := findNodeAddress(node, addrType); address != "" {
+			host = address
+			break
+		}
+	}
+
+	port := int(node.Status.DaemonEndpoints.KubeletEndpoint.Port)
+	if port == 0 {
+		port = api.DefaultNodeAgentPort
+	}
+
+	return &url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(port))}, nil
+}
+
+// findNodeAddress returns the node's first address of the given type
+func findNodeAddress(node *api.Node, addrType string) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == addrType {
+			return address.Address
+		}
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/requestid"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultHistoryLimit is how many changes are kept per object
+const DefaultHistoryLimit = 10
+
+// history keeps the most recent changes made through the API to each object,
+// along with who made them
+type history struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string][]api.HistoryEntry
+}
+
+// historyKey identifies an object in the history
+func historyKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// record notes a change to obj made by the request of ctx. Secret data is
+// never recorded.
+func (h *history) record(ctx context.Context, operation string, obj store.Object) {
+	recorded := obj
+	if secret, ok := obj.(*api.Secret); ok {
+		recorded = redactSecret(secret)
+	}
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		return
+	}
+	entry := api.HistoryEntry{
+		Operation:       operation,
+		Timestamp:       time.Now(),
+		RequestID:       requestid.From(ctx),
+		ResourceVersion: obj.GetResourceVersion(),
+		Object:          data,
+	}
+	if user, ok := auth.UserFrom(ctx); ok {
+		entry.User = user.Name
+	}
+
+	key := historyKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := append(h.entries[key], entry)
+	if len(entries) > h.limit {
+		entries = entries[len(entries)-h.limit:]
+	}
+	h.entries[key] = entries
+}
+
+// get returns the recorded changes to an object, oldest first
+func (h *history) get(kind, namespace, name string) []api.HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]api.HistoryEntry{}, h.entries[historyKey(kind, namespace, name)]...)
+}
+
+// historyStore records the writes made through it in a history. Events
+// change too often to be worth keeping.
+type historyStore struct {
+	store.Store
+	history *history
+}
+
+// Create creates a new object in the store
+func (s *historyStore) Create(ctx context.Context, obj store.Object) error {
+	if err := s.Store.Create(ctx, obj); err != nil {
+		return err
+	}
+	if obj.GetKind() != "Event" {
+		s.history.record(ctx, api.HistoryCreate, obj)
+	}
+	return nil
+}
+
+// Update updates an existing object
+func (s *historyStore) Update(ctx context.Context, obj store.Object) error {
+	if err := s.Store.Update(ctx, obj); err != nil {
+		return err
+	}
+	if obj.GetKind() != "Event" {
+		s.history.record(ctx, api.HistoryUpdate, obj)
+	}
+	return nil
+}
+
+// Delete deletes an object, recording its last state
+func (s *historyStore) Delete(ctx context.Context, kind, namespace, name string) error {
+	var last store.Object
+	if kind != "Event" {
+		last, _ = s.Store.Get(ctx, kind, namespace, name)
+	}
+	if err := s.Store.Delete(ctx, kind, namespace, name); err != nil {
+		return err
+	}
+	if last != nil {
+		s.history.record(ctx, api.HistoryDelete, last)
+	}
+	return nil
+}
+
+// EnableHistory records the last limit changes made through the API to each
+// object, served at the objects' history subresources. The history is kept
+// in memory, so it starts empty whenever the server restarts.
+func (s *Server) EnableHistory(limit int) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	s.history = &history{limit: limit, entries: make(map[string][]api.HistoryEntry)}
+	s.store = &historyStore{Store: s.store, history: s.history}
+}
+
+// objectHistory returns a handler serving the recorded changes to an object
+// of kind
+func (s *Server) objectHistory(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.history == nil {
+			http.Error(w, "object history is not enabled", http.StatusNotFound)
+			return
+		}
+
+		vars := mux.Vars(r)
+		list := api.HistoryList{
+			TypeMeta: api.TypeMeta{Kind: "HistoryList", APIVersion: "v1alpha1"},
+			Items:    s.history.get(kind, vars["namespace"], vars["name"]),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
@@ -0,0 +1,117 @@
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// defaultRegistry is the registry of images that don't name one
+const defaultRegistry = "docker.io"
+
+// ImagePolicy restricts the images pods may run, e.g. on nodes shared by a
+// class. The zero policy allows every image.
+type ImagePolicy struct {
+	// AllowedRegistries are the registries, or repository prefixes such as
+	// ghcr.io/course, images must come from; empty allows any. Images
+	// without a registry are from docker.io, and official ones from
+	// docker.io/library.
+	AllowedRegistries []string
+	// RequireDigests only admits images pinned to a digest, e.g.
+	// nginx@sha256:...
+	RequireDigests bool
+	// BannedTags are tags images may not use, such as latest. Images with
+	// neither a tag nor a digest are taken to use latest.
+	BannedTags []string
+}
+
+// SetImagePolicy makes the server reject pods, and workloads whose pod
+// template, run images policy doesn't allow
+func (s *Server) SetImagePolicy(policy ImagePolicy) {
+	s.imagePolicy = policy
+}
+
+// admitImages checks the images of obj's pod spec, if it has one, against
+// the image policy
+func (s *Server) admitImages(obj store.Object) error {
+	var spec *api.PodSpec
+	var path string
+	switch obj := obj.(type) {
+	case *api.Pod:
+		spec, path = &obj.Spec, "spec"
+	case *api.Deployment:
+		spec, path = &obj.Spec.Template.Spec, "spec.template.spec"
+	case *api.ReplicaSet:
+		spec, path = &obj.Spec.Template.Spec, "spec.template.spec"
+	case *api.Job:
+		spec, path = &obj.Spec.Template.Spec, "spec.template.spec"
+	case *api.CronJob:
+		spec, path = &obj.Spec.JobTemplate.Spec.Template.Spec, "spec.jobTemplate.spec.template.spec"
+	default:
+		return nil
+	}
+
+	for _, container := range spec.Containers {
+		if err := s.imagePolicy.Check(container.Image); err != nil {
+			return fmt.Errorf("%s rejected: %s.containers[%s]: %w", obj.GetKind(), path, container.Name, err)
+		}
+	}
+	return nil
+}
+
+// Check reports why the policy doesn't allow image, or nil if it does
+func (p *ImagePolicy) Check(image string) error {
+	repository, tag, digest := parseImage(image)
+	if len(p.AllowedRegistries) > 0 && !p.allowsRepository(repository) {
+		return fmt.Errorf("image %q is not from an allowed registry (%s)", image, strings.Join(p.AllowedRegistries, ", "))
+	}
+	if p.RequireDigests && digest == "" {
+		return fmt.Errorf("image %q must be pinned to a digest", image)
+	}
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+	for _, banned := range p.BannedTags {
+		if tag == banned {
+			return fmt.Errorf("image %q uses banned tag %q", image, tag)
+		}
+	}
+	return nil
+}
+
+// allowsRepository reports whether repository is in one of the allowed
+// registries or under one of the allowed prefixes
+func (p *ImagePolicy) allowsRepository(repository string) bool {
+	for _, allowed := range p.AllowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if repository == allowed || strings.HasPrefix(repository, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseImage splits an image reference into its repository, qualified with
+// its registry, its tag and its digest. The first path component is the
+// registry if it looks like a host: it has a dot or a port, or is localhost.
+// Official images are qualified as docker.io/library/<name>.
+func parseImage(image string) (repository, tag, digest string) {
+	repository = image
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, digest = repository[:i], repository[i+1:]
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+
+	registry, path := defaultRegistry, repository
+	if first, rest, found := strings.Cut(repository, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		registry, path = first, rest
+	}
+	if registry == defaultRegistry && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return registry + "/" + path, tag, digest
+}
@@ -0,0 +1,65 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestImagePolicy_Check(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name    string
+		policy  ImagePolicy
+		image   string
+		wantErr bool
+	}{
+		{name: "zero policy", image: "nginx"},
+		{name: "official image from docker.io/library", policy: ImagePolicy{AllowedRegistries: []string{"docker.io/library"}}, image: "nginx:1.27"},
+		{name: "qualified official image", policy: ImagePolicy{AllowedRegistries: []string{"docker.io/library"}}, image: "docker.io/nginx:1.27"},
+		{name: "user image outside the allowed prefix", policy: ImagePolicy{AllowedRegistries: []string{"docker.io/library"}}, image: "someone/miner:1.0", wantErr: true},
+		{name: "allowed registry", policy: ImagePolicy{AllowedRegistries: []string{"ghcr.io/course"}}, image: "ghcr.io/course/app:v1"},
+		{name: "registry prefix isn't a path prefix", policy: ImagePolicy{AllowedRegistries: []string{"ghcr.io/course"}}, image: "ghcr.io/course-old/app:v1", wantErr: true},
+		{name: "registry with a port", policy: ImagePolicy{AllowedRegistries: []string{"localhost:5000"}}, image: "localhost:5000/app:v1"},
+		{name: "digest required", policy: ImagePolicy{RequireDigests: true}, image: "nginx:1.27", wantErr: true},
+		{name: "digest given", policy: ImagePolicy{RequireDigests: true}, image: "nginx@" + digest},
+		{name: "banned tag", policy: ImagePolicy{BannedTags: []string{"latest"}}, image: "nginx:latest", wantErr: true},
+		{name: "no tag counts as latest", policy: ImagePolicy{BannedTags: []string{"latest"}}, image: "nginx", wantErr: true},
+		{name: "digest without a tag isn't latest", policy: ImagePolicy{BannedTags: []string{"latest"}}, image: "nginx@" + digest},
+		{name: "port isn't a tag", policy: ImagePolicy{BannedTags: []string{"5000"}}, image: "localhost:5000/app:v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q) error = %v, wantErr %v", tt.image, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImagePolicy_Admission(t *testing.T) {
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	s.SetImagePolicy(ImagePolicy{BannedTags: []string{"latest"}})
+	create := func(path, body string) int {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1alpha1/namespaces/default/"+path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := create("pods", `{"metadata":{"name":"web"},"spec":{"containers":[{"name":"web","image":"nginx"}]}}`); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a pod running latest, got %d", code)
+	}
+	if code := create("pods", `{"metadata":{"name":"web"},"spec":{"containers":[{"name":"web","image":"nginx:1.27"}]}}`); code != http.StatusCreated {
+		t.Errorf("Expected a pod with a pinned tag to be created, got %d", code)
+	}
+	deployment := `{"metadata":{"name":"web"},"spec":{"replicas":1,"selector":{"matchLabels":{"app":"web"}},
+		"template":{"metadata":{"labels":{"app":"web"}},"spec":{"containers":[{"name":"web","image":"nginx:latest"}]}}}}`
+	if code := create("deployments", deployment); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a deployment whose template runs latest, got %d", code)
+	}
+}
@@ -0,0 +1,77 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createJob handles job creation. The status is left to the job controller.
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var job api.Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&job.TypeMeta, &job.ObjectMeta, "Job", vars["namespace"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.Job(&job); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&job); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	job.Status = api.JobStatus{}
+
+	if err := store.CreateWithGeneratedName(r.Context(), s.store, &job, &job.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job)
+}
+
+// getJob handles job retrieval
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.store.Get(r.Context(), "Job", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// listJobs handles job listing
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "Job", "JobList")
+}
+
+// deleteJob handles job deletion. Its pods are kept, like those of a
+// deleted replicaset, so their logs can still be read.
+func (s *Server) deleteJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "Job", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
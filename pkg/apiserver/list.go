@@ -0,0 +1,34 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// writeList writes objs as a list of kind listKind. The envelope is written
+// around the items and each item is encoded straight to w, so only one item
+// is ever held encoded in memory rather than the whole list, which with
+// thousands of pods would be many megabytes per request.
+func writeList(w http.ResponseWriter, listKind string, objs []store.Object) {
+	kind, _ := json.Marshal(listKind)
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"apiVersion":"v1alpha1","kind":%s,"items":[`, kind)
+
+	encoder := json.NewEncoder(w)
+	for i, obj := range objs {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		if err := encoder.Encode(obj); err != nil {
+			// The status is already sent, so cut the response short rather
+			// than end it as valid JSON missing items
+			fmt.Printf("Error encoding %s item: %v\n", listKind, err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+	io.WriteString(w, "]}\n")
+}
@@ -0,0 +1,137 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// discardResponse is a ResponseWriter that throws the body away, so only the
+// memory the encoding itself takes is measured
+type discardResponse struct {
+	header http.Header
+}
+
+func (d *discardResponse) Header() http.Header         { return d.header }
+func (d *discardResponse) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardResponse) WriteHeader(int)             {}
+
+func listPods(n int) []store.Object {
+	objs := make([]store.Object, 0, n)
+	for i := 0; i < n; i++ {
+		objs = append(objs, &api.Pod{
+			TypeMeta: api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{
+				Name:              fmt.Sprintf("web-%d", i),
+				Namespace:         "default",
+				UID:               fmt.Sprintf("uid-%d", i),
+				Labels:            map[string]string{"app": "web", "tier": "frontend"},
+				CreationTimestamp: time.Now(),
+			},
+			Spec: api.PodSpec{
+				NodeName: "node-1",
+				Containers: []api.Container{{
+					Name:      "app",
+					Image:     "nginx:1.25",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: "100m", api.ResourceMemory: "128Mi"}},
+				}},
+			},
+			Status: api.PodStatus{Phase: api.PodRunning, PodIP: "10.0.0.1"},
+		})
+	}
+	return objs
+}
+
+func TestWriteList(t *testing.T) {
+	for _, n := range []int{0, 1, 3} {
+		rec := httptest.NewRecorder()
+		writeList(rec, "PodList", listPods(n))
+
+		var list struct {
+			APIVersion string    `json:"apiVersion"`
+			Kind       string    `json:"kind"`
+			Items      []api.Pod `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+			t.Fatalf("Failed to decode list of %d: %v\n%s", n, err, rec.Body.String())
+		}
+		if list.Kind != "PodList" || list.APIVersion != "v1alpha1" || list.Items == nil || len(list.Items) != n {
+			t.Errorf("Unexpected list of %d: %+v", n, list)
+		}
+		for i, pod := range list.Items {
+			if pod.Name != fmt.Sprintf("web-%d", i) {
+				t.Errorf("Expected item %d to be web-%d, got %s", i, i, pod.Name)
+			}
+		}
+	}
+}
+
+// BenchmarkListEncoding compares encoding 10k pods in one shot, as list
+// handlers used to, with streaming them. peak-heap-MB is the most heap in
+// use during one encoding, which the streaming encoder keeps to about one
+// item.
+func BenchmarkListEncoding(b *testing.B) {
+	objs := listPods(10000)
+	encoders := []struct {
+		name   string
+		encode func(w http.ResponseWriter)
+	}{
+		{"buffered", func(w http.ResponseWriter) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"apiVersion": "v1alpha1",
+				"kind":       "PodList",
+				"items":      objs,
+			})
+		}},
+		{"streaming", func(w http.ResponseWriter) {
+			writeList(w, "PodList", objs)
+		}},
+	}
+
+	for _, encoder := range encoders {
+		b.Run(encoder.name, func(b *testing.B) {
+			w := &discardResponse{header: make(http.Header)}
+			b.ReportAllocs()
+
+			var peak uint64
+			var stats runtime.MemStats
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				before := stats.HeapInuse
+
+				done := make(chan struct{})
+				sampled := make(chan uint64)
+				go func() {
+					var max uint64
+					var sample runtime.MemStats
+					for {
+						runtime.ReadMemStats(&sample)
+						if sample.HeapInuse > max {
+							max = sample.HeapInuse
+						}
+						select {
+						case <-done:
+							sampled <- max
+							return
+						default:
+						}
+					}
+				}()
+				encoder.encode(w)
+				close(done)
+				if max := <-sampled; max > before && max-before > peak {
+					peak = max - before
+				}
+			}
+			b.ReportMetric(float64(peak)/(1024*1024), "peak-heap-MB")
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// UnixSocketMode is the file mode of the socket created by SetUnixSocket:
+// its owner and group may connect
+const UnixSocketMode os.FileMode = 0660
+
+// systemdListenFDsStart is the first file descriptor systemd passes
+// activated sockets as
+const systemdListenFDsStart = 3
+
+// SetUnixSocket also serves the API on a unix domain socket at path, which
+// only local users with access to the file can connect to. A port of 0 given
+// to NewServer leaves it the only address served.
+func (s *Server) SetUnixSocket(path string) {
+	s.unixSocket = path
+}
+
+// SetListeners serves the API on listeners opened by someone else, such as
+// systemd, instead of the port and unix socket
+func (s *Server) SetListeners(listeners []net.Listener) {
+	s.listeners = listeners
+}
+
+// SystemdListeners returns the sockets systemd passed the process under
+// socket activation, or none if it wasn't socket activated. The environment
+// variables describing them are cleared so child processes don't take them
+// for their own.
+func SystemdListeners() ([]net.Listener, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := systemdListenFDsStart; fd < systemdListenFDsStart+count; fd++ {
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("LISTEN_FD_%d", fd))
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("socket %d passed by systemd is not a listening socket: %w", fd, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// listen opens the listeners the API is served on
+func (s *Server) listen() ([]net.Listener, error) {
+	if len(s.listeners) > 0 {
+		return s.listeners, nil
+	}
+
+	var listeners []net.Listener
+	if s.port != 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	if s.unixSocket != "" {
+		// A socket left behind by an earlier run would make Listen fail
+		if err := os.Remove(s.unixSocket); err != nil && !errors.Is(err, os.ErrNotExist) {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", s.unixSocket, err)
+		}
+		listener, err := net.Listen("unix", s.unixSocket)
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+		if err := os.Chmod(s.unixSocket, UnixSocketMode); err != nil {
+			listener.Close()
+			closeListeners(listeners)
+			return nil, fmt.Errorf("failed to set mode of socket %s: %w", s.unixSocket, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("no port or unix socket to listen on")
+	}
+	return listeners, nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
@@ -0,0 +1,137 @@
+package apiserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/requestid"
+)
+
+// accessLogEntry collects what the access log reports about a request that
+// is only known deeper in the handler chain
+type accessLogEntry struct {
+	user string
+}
+
+type accessLogEntryKey struct{}
+
+// logRequests assigns every request an ID, taken from its X-Request-ID header
+// when it carries a valid one, and writes an access log line once it's done.
+// The ID is echoed in the response, forwarded with proxied exec and attach
+// requests and stored in the request context, where the store picks it up for
+// its own log lines.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		r.Header.Set(requestid.Header, id)
+		w.Header().Set(requestid.Header, id)
+
+		entry := &accessLogEntry{}
+		ctx := requestid.WithID(r.Context(), id)
+		ctx = context.WithValue(ctx, accessLogEntryKey{}, entry)
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		// Health probes would drown out everything else
+		if path, _ := trimBasePath(r.URL.Path, s.basePath); path == "/healthz" || path == "/readyz" {
+			return
+		}
+
+		user := entry.user
+		if user == "" {
+			user = "-"
+		}
+		client := "-"
+		if ip := remoteIP(r.RemoteAddr); ip != nil {
+			client = ip.String()
+		}
+		fmt.Printf("[%s] %s %s client=%s user=%s status=%d bytes=%d latency=%s\n",
+			id, r.Method, r.URL.RequestURI(), client, user, recorder.statusCode(), recorder.bytes, time.Since(start))
+	})
+}
+
+// recordUser notes the authenticated user of r for the access log
+func recordUser(r *http.Request) {
+	entry, ok := r.Context().Value(accessLogEntryKey{}).(*accessLogEntry)
+	if !ok {
+		return
+	}
+	if user, ok := auth.UserFrom(r.Context()); ok {
+		entry.user = user.Name
+	}
+}
+
+// responseRecorder records the status and size of a response. It passes
+// flushes and hijacks through so watches and exec streams keep working.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends buffered data to the client
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// FlushError sends buffered data to the client, reporting failures
+func (r *responseRecorder) FlushError() error {
+	return http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection; the request is logged as switching protocols
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// statusCode returns the recorded status, defaulting to 200 like net/http
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
@@ -0,0 +1,37 @@
+package apiserver
+
+import (
+	"io"
+	"net/http"
+)
+
+// getPodLog proxies a request for a container's log to the node agent
+// running the pod. The container and previous query parameters are passed
+// along.
+func (s *Server) getPodLog(w http.ResponseWriter, r *http.Request) {
+	nodeURL, ok := s.podNodeAgentURL(w, r, "containerLogs")
+	if !ok {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, nodeURL.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if s.nodeAgentToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.nodeAgentToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		http.Error(w, "failed to reach node agent: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
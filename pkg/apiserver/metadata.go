@@ -0,0 +1,90 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// errUIDProvided is returned when a client tries to choose an object's UID
+var errUIDProvided = errors.New("metadata.uid must not be set on create; the API server assigns it")
+
+// errNameRequired is returned when an object being created has no way to get a name
+var errNameRequired = errors.New("metadata.name or metadata.generateName is required")
+
+// errUIDChanged is returned when an update tries to change an object's UID
+var errUIDChanged = fmt.Errorf("metadata.uid is immutable: %w", store.ErrConflict)
+
+// populateMetadata fills in the server-owned metadata of an object being
+// created: its type, its namespace and a freshly generated UID, clearing any
+// deletion metadata. Objects arriving with a UID are rejected so every UID is
+// one the server issued. Objects named by generateName get their name when
+// they're stored.
+func populateMetadata(typeMeta *api.TypeMeta, meta *api.ObjectMeta, kind, namespace string) error {
+	if meta.UID != "" {
+		return errUIDProvided
+	}
+	if meta.Name == "" && meta.GenerateName == "" {
+		return errNameRequired
+	}
+
+	typeMeta.Kind = kind
+	typeMeta.APIVersion = "v1alpha1"
+	meta.Namespace = namespace
+	meta.UID = api.NewUID()
+	// Only deleting an object marks it terminating
+	meta.DeletionTimestamp = nil
+	meta.DeletionGracePeriodSeconds = nil
+	return nil
+}
+
+// preserveUID carries the stored UID of an object over to its update. An
+// update naming a different UID is rejected; one without a UID keeps the
+// stored one.
+func (s *Server) preserveUID(ctx context.Context, kind string, meta *api.ObjectMeta) error {
+	existing, err := s.store.Get(ctx, kind, meta.Namespace, meta.Name)
+	if err != nil {
+		return err
+	}
+
+	if meta.UID != "" && meta.UID != existing.GetUID() {
+		return errUIDChanged
+	}
+	meta.UID = existing.GetUID()
+	return nil
+}
+
+// sameDeletion reports whether updated is terminating exactly when existing
+// is, deleting being the only way to mark an object terminating
+func sameDeletion(updated, existing store.Object) bool {
+	type terminating interface{ GetDeletionTimestamp() *time.Time }
+	u, uok := updated.(terminating)
+	e, eok := existing.(terminating)
+	if !uok || !eok {
+		return uok == eok
+	}
+	a, b := u.GetDeletionTimestamp(), e.GetDeletionTimestamp()
+	return (a == nil) == (b == nil) && (a == nil || a.Equal(*b))
+}
+
+// writeStoreError reports a failed store call with the status its error
+// calls for
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, store.ErrAlreadyExists), errors.Is(err, store.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, store.ErrInvalidKind):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,171 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// bootstrapNamespaces are created when the server starts and can't be deleted
+var bootstrapNamespaces = []string{api.NamespaceDefault, api.NamespaceSystem}
+
+// Bootstrap creates the well-known namespaces and their default service
+// accounts if they're missing, so a fresh cluster is usable right away. It
+// is safe to call on every start.
+func (s *Server) Bootstrap(ctx context.Context) error {
+	for _, name := range bootstrapNamespaces {
+		if err := s.ensureNamespace(ctx, name); err != nil {
+			return err
+		}
+		if _, err := s.ensureServiceAccount(ctx, name, api.DefaultServiceAccountName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureNamespace creates the named namespace unless it exists
+func (s *Server) ensureNamespace(ctx context.Context, name string) error {
+	_, err := s.store.Get(ctx, "Namespace", "", name)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+
+	namespace := &api.Namespace{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Status:     api.NamespaceStatus{Phase: api.NamespaceActive},
+	}
+	if err := populateMetadata(&namespace.TypeMeta, &namespace.ObjectMeta, "Namespace", ""); err != nil {
+		return err
+	}
+	if err := s.store.Create(ctx, namespace); err != nil {
+		// Another request created it first
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return nil
+		}
+		return fmt.Errorf("failed to create namespace %s: %w", name, err)
+	}
+
+	fmt.Printf("Created namespace %s\n", name)
+	return nil
+}
+
+// SetNamespaceAutoCreation chooses what creating an object in a namespace
+// that doesn't exist does: create the namespace, with its default service
+// account, or fail with 404 Not Found. Namespaces are created by default.
+func (s *Server) SetNamespaceAutoCreation(enabled bool) {
+	s.rejectMissingNamespaces = !enabled
+}
+
+// requireNamespace makes sure the namespace an object is created in exists,
+// creating it or failing the request as SetNamespaceAutoCreation chose
+func (s *Server) requireNamespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		namespace := vars["namespace"]
+		if r.Method != http.MethodPost || namespace == "" || vars["name"] != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		_, err := s.store.Get(ctx, "Namespace", "", namespace)
+		switch {
+		case err == nil:
+		case !errors.Is(err, store.ErrNotFound):
+			writeStoreError(w, err)
+			return
+		case s.rejectMissingNamespaces:
+			http.Error(w, fmt.Sprintf("namespace %s not found", namespace), http.StatusNotFound)
+			return
+		default:
+			if err := s.ensureNamespace(ctx, namespace); err != nil {
+				writeStoreError(w, err)
+				return
+			}
+			if _, err := s.ensureServiceAccount(ctx, namespace, api.DefaultServiceAccountName); err != nil {
+				fmt.Printf("Failed to create default service account in namespace %s: %v\n", namespace, err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// createNamespace handles namespace creation. Every new namespace gets a
+// default service account.
+func (s *Server) createNamespace(w http.ResponseWriter, r *http.Request) {
+	var namespace api.Namespace
+	if err := json.NewDecoder(r.Body).Decode(&namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&namespace.TypeMeta, &namespace.ObjectMeta, "Namespace", ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	namespace.Status.Phase = api.NamespaceActive
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &namespace, &namespace.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	if _, err := s.ensureServiceAccount(ctx, namespace.Name, api.DefaultServiceAccountName); err != nil {
+		fmt.Printf("Failed to create default service account in namespace %s: %v\n", namespace.Name, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(namespace)
+}
+
+// getNamespace handles namespace retrieval
+func (s *Server) getNamespace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	namespace, err := s.store.Get(r.Context(), "Namespace", "", vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(namespace)
+}
+
+// listNamespaces handles namespace listing
+func (s *Server) listNamespaces(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "Namespace", "NamespaceList")
+}
+
+// deleteNamespace handles namespace deletion. The bootstrap namespaces are
+// always kept.
+func (s *Server) deleteNamespace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	for _, protected := range bootstrapNamespaces {
+		if name == protected {
+			http.Error(w, fmt.Sprintf("namespace %s can't be deleted", name), http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := s.store.Delete(r.Context(), "Namespace", "", name); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,53 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const teamPod = `{"kind":"Pod","apiVersion":"v1alpha1","metadata":{"name":"web","namespace":"team-a"},"spec":{"containers":[{"name":"app","image":"nginx"}]}}`
+
+func createTeamPod(s *Server) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1alpha1/namespaces/team-a/pods", strings.NewReader(teamPod))
+	req.Header.Set("Content-Type", "application/json")
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireNamespace(t *testing.T) {
+	ctx := context.Background()
+
+	// Namespaces are created along with the first object in them
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	if rec := createTeamPod(s); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a pod in a new namespace, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := s.store.Get(ctx, "Namespace", "", "team-a"); err != nil {
+		t.Errorf("Expected the namespace to be created: %v", err)
+	}
+	if _, err := s.store.Get(ctx, "ServiceAccount", "team-a", "default"); err != nil {
+		t.Errorf("Expected the namespace's default service account to be created: %v", err)
+	}
+
+	// Unless the server requires them to exist
+	s = NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	s.SetNamespaceAutoCreation(false)
+	if rec := createTeamPod(s); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 creating a pod in a missing namespace, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := s.store.Get(ctx, "Pod", "team-a", "web"); err == nil {
+		t.Error("Expected the pod not to be created")
+	}
+	if err := s.ensureNamespace(ctx, "team-a"); err != nil {
+		t.Fatal(err)
+	}
+	if rec := createTeamPod(s); rec.Code != http.StatusCreated {
+		t.Errorf("Expected 201 once the namespace exists, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,94 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// restrictNodes limits what node credentials can do, so a compromised node
+// can't tamper with the rest of the cluster. Nodes may read anything but
+// can't exec into pods, and may only change their own Node object, and the
+// status of and delete the pods bound to them. They may also request tokens
+// for the service accounts of their pods; see createServiceAccountToken, and
+// request certificates.
+func (s *Server) restrictNodes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nodeName, isNode := requestingNode(r.Context())
+		if !isNode {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status, err := s.authorizeNode(r, nodeName)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestingNode returns the name of the node whose credentials the request
+// was made with, and whether it was made by a node at all
+func requestingNode(ctx context.Context) (string, bool) {
+	user, ok := auth.UserFrom(ctx)
+	if !ok {
+		return "", false
+	}
+	return auth.NodeName(user)
+}
+
+// authorizeNode checks a request made with the credentials of node
+// nodeName, returning the status to fail it with if it's not allowed
+func (s *Server) authorizeNode(r *http.Request, nodeName string) (int, error) {
+	vars := mux.Vars(r)
+	var template string
+	if route := mux.CurrentRoute(r); route != nil {
+		template, _ = route.GetPathTemplate()
+	}
+
+	switch template {
+	case "/api/v1alpha1/namespaces/{namespace}/pods/{name}/exec", "/api/v1alpha1/namespaces/{namespace}/pods/{name}/attach":
+		return http.StatusForbidden, fmt.Errorf("node %s may not exec into pods", nodeName)
+	}
+	if r.Method == http.MethodGet {
+		return 0, nil
+	}
+
+	switch {
+	case template == "/api/v1alpha1/nodes" && r.Method == http.MethodPost:
+		// createNode only lets a node register itself
+		return 0, nil
+	case template == "/api/v1alpha1/nodes/{name}":
+		if vars["name"] != nodeName {
+			return http.StatusForbidden, fmt.Errorf("node %s may not change node %s", nodeName, vars["name"])
+		}
+		return 0, nil
+	case template == "/api/v1alpha1/namespaces/{namespace}/pods/{name}/status" && r.Method == http.MethodPut,
+		template == "/api/v1alpha1/namespaces/{namespace}/pods/{name}" && r.Method == http.MethodDelete:
+		obj, err := s.store.Get(r.Context(), "Pod", vars["namespace"], vars["name"])
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return http.StatusNotFound, err
+			}
+			return http.StatusInternalServerError, err
+		}
+		if pod, ok := obj.(*api.Pod); !ok || pod.Spec.NodeName != nodeName {
+			return http.StatusForbidden, fmt.Errorf("pod %s/%s is not bound to node %s", vars["namespace"], vars["name"], nodeName)
+		}
+		return 0, nil
+	case template == "/api/v1alpha1/namespaces/{namespace}/serviceaccounts/{name}/token":
+		return 0, nil
+	case template == "/api/v1alpha1/certificatesigningrequests" && r.Method == http.MethodPost:
+		// The signer only issues node certificates for the node that asked
+		return 0, nil
+	}
+	return http.StatusForbidden, fmt.Errorf("node %s may not %s %s", nodeName, r.Method, r.URL.Path)
+}
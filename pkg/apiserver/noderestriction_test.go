@@ -0,0 +1,123 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// newNodeRestrictionServer returns a server with service accounts enabled,
+// the nodes node-a and node-b, and a pod bound to each, along with the
+// credentials of node-a
+func newNodeRestrictionServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	if err := s.EnableServiceAccounts(&ServiceAccountConfig{SigningKey: []byte(strings.Repeat("k", 32))}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Bootstrap(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"node-a", "node-b"} {
+		node := &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name},
+		}
+		if err := s.store.Create(ctx, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, nodeName := range map[string]string{"mine": "node-a", "theirs": "node-b"} {
+		pod := &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+			Spec: api.PodSpec{
+				NodeName:           nodeName,
+				ServiceAccountName: api.DefaultServiceAccountName,
+				Containers:         []api.Container{{Name: "app", Image: "nginx"}},
+			},
+			Status: api.PodStatus{Phase: api.PodRunning},
+		}
+		if err := s.store.Create(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token, err := s.tokenSigner.Sign(&auth.Claims{Subject: auth.NodeUsername("node-a"), NodeName: "node-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, token
+}
+
+func TestRestrictNodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		// forbidden is whether node-a must be refused
+		forbidden bool
+	}{
+		{name: "read anything", method: http.MethodGet, path: "/api/v1alpha1/namespaces/default/pods/theirs"},
+		{name: "update own node", method: http.MethodPut, path: "/api/v1alpha1/nodes/node-a", body: `{"metadata":{"name":"node-a"}}`},
+		{name: "update another node", method: http.MethodPut, path: "/api/v1alpha1/nodes/node-b", body: `{"metadata":{"name":"node-b"}}`, forbidden: true},
+		{name: "write status of own pod", method: http.MethodPut, path: "/api/v1alpha1/namespaces/default/pods/mine/status", body: `{"status":{"phase":"Succeeded"}}`},
+		{name: "write status of another node's pod", method: http.MethodPut, path: "/api/v1alpha1/namespaces/default/pods/theirs/status", body: `{"status":{"phase":"Failed"}}`, forbidden: true},
+		{name: "delete own pod", method: http.MethodDelete, path: "/api/v1alpha1/namespaces/default/pods/mine"},
+		{name: "delete another node's pod", method: http.MethodDelete, path: "/api/v1alpha1/namespaces/default/pods/theirs", forbidden: true},
+		{name: "exec into own pod", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/pods/mine/exec?command=sh", forbidden: true},
+		{name: "attach to own pod", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/pods/mine/attach", forbidden: true},
+		{name: "token bound to own pod", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/serviceaccounts/default/token",
+			body: `{"spec":{"boundObjectRef":{"kind":"Pod","name":"mine","uid":"mine-uid"}}}`},
+		{name: "token without a bound pod", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/serviceaccounts/default/token", body: `{"spec":{}}`, forbidden: true},
+		{name: "token bound to another node's pod", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/serviceaccounts/default/token",
+			body: `{"spec":{"boundObjectRef":{"kind":"Pod","name":"theirs","uid":"theirs-uid"}}}`, forbidden: true},
+		{name: "update a pod", method: http.MethodPut, path: "/api/v1alpha1/namespaces/default/pods/mine",
+			body: `{"metadata":{"name":"mine"},"spec":{"nodeName":"node-a","containers":[{"name":"app","image":"nginx"}]}}`, forbidden: true},
+		{name: "create a pod", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/pods", body: teamPod, forbidden: true},
+		{name: "delete another node", method: http.MethodDelete, path: "/api/v1alpha1/nodes/node-b", forbidden: true},
+		{name: "create a secret", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/secrets", body: `{"metadata":{"name":"stolen"}}`, forbidden: true},
+		{name: "create a service account", method: http.MethodPost, path: "/api/v1alpha1/namespaces/default/serviceaccounts", body: `{"metadata":{"name":"spy"}}`, forbidden: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, token := newNodeRestrictionServer(t)
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			s.router.ServeHTTP(rec, req)
+
+			if tt.forbidden {
+				if rec.Code != http.StatusForbidden {
+					t.Errorf("Expected 403, got %d: %s", rec.Code, rec.Body.String())
+				}
+			} else if rec.Code < 200 || rec.Code >= 300 {
+				t.Errorf("Expected success, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRestrictNodes_NodeMayNotDeleteAnotherNodesPod(t *testing.T) {
+	s, token := newNodeRestrictionServer(t)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1alpha1/namespaces/default/pods/theirs", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	s.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	obj, err := s.store.Get(context.Background(), "Pod", "default", "theirs")
+	if err != nil {
+		t.Fatalf("Expected node-b's pod to be kept: %v", err)
+	}
+	if obj.(*api.Pod).DeletionTimestamp != nil {
+		t.Error("Expected node-b's pod not to be marked for deletion")
+	}
+}
@@ -0,0 +1,138 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// mergePatchContentType is the patch format the API accepts, a JSON merge
+// patch as described in RFC 7386
+const mergePatchContentType = "application/merge-patch+json"
+
+// maxPatchSize bounds the body of a patch request
+const maxPatchSize = 1 << 20
+
+// validateObject checks a patched object against the rules of its kind
+func validateObject(obj store.Object) error {
+	return validation.Object(obj)
+}
+
+// patchObject returns a handler applying JSON merge patches to objects of
+// kind. newObject returns an empty object to decode the result into; the
+// namespace comes from the route, so cluster-scoped kinds have none. The
+// patched object keeps its name, namespace, UID and deletion timestamp.
+// validate, if set, checks the result before it's stored.
+func (s *Server) patchObject(kind string, newObject func() store.Object, validate func(store.Object) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		namespace, name := vars["namespace"], vars["name"]
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != mergePatchContentType {
+			http.Error(w, fmt.Sprintf("unsupported patch type %q, use %s", mediaType, mergePatchContentType), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var patch interface{}
+		if err := json.Unmarshal(data, &patch); err != nil {
+			http.Error(w, fmt.Sprintf("invalid patch: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		existing, err := s.store.Get(ctx, kind, namespace, name)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		current, err := json.Marshal(existing)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var doc interface{}
+		if err := json.Unmarshal(current, &doc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		patched, err := json.Marshal(applyMergePatch(doc, patch))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		obj := newObject()
+		if err := json.Unmarshal(patched, obj); err != nil {
+			http.Error(w, fmt.Sprintf("patched object is invalid: %v", err), http.StatusUnprocessableEntity)
+			return
+		}
+
+		switch {
+		case obj.GetUID() != existing.GetUID():
+			http.Error(w, errUIDChanged.Error(), http.StatusConflict)
+			return
+		case obj.GetName() != existing.GetName() || obj.GetNamespace() != existing.GetNamespace():
+			http.Error(w, "metadata.name and metadata.namespace can't be patched", http.StatusBadRequest)
+			return
+		case obj.GetKind() != existing.GetKind():
+			http.Error(w, "kind can't be patched", http.StatusBadRequest)
+			return
+		case !sameDeletion(obj, existing):
+			http.Error(w, "metadata.deletionTimestamp can't be patched; delete the object instead", http.StatusBadRequest)
+			return
+		}
+		if validate != nil {
+			if err := validate(obj); err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+		}
+		if err := s.admitImages(obj); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if err := s.store.Update(ctx, obj); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(obj)
+	}
+}
+
+// applyMergePatch applies a JSON merge patch to target: objects are merged
+// key by key, null removes a key and anything else replaces the target value
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = make(map[string]interface{})
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key] = applyMergePatch(targetMap[key], value)
+	}
+	return targetMap
+}
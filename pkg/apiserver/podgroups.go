@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createPodGroup handles pod group creation
+func (s *Server) createPodGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var group api.PodGroup
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&group.TypeMeta, &group.ObjectMeta, "PodGroup", vars["namespace"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.PodGroup(&group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &group, &group.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// getPodGroup handles pod group retrieval
+func (s *Server) getPodGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	group, err := s.store.Get(r.Context(), "PodGroup", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// listPodGroups handles pod group listing
+func (s *Server) listPodGroups(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "PodGroup", "PodGroupList")
+}
+
+// updatePodGroup handles pod group updates. The status
+// belongs to the scheduler and is kept.
+func (s *Server) updatePodGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var group api.PodGroup
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group.Kind = "PodGroup"
+	group.APIVersion = "v1alpha1"
+	group.Namespace = vars["namespace"]
+	group.Name = vars["name"]
+	if err := validation.PodGroup(&group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := s.preserveUID(ctx, "PodGroup", &group.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if existing, err := s.store.Get(ctx, "PodGroup", group.Namespace, group.Name); err == nil {
+		if stored, ok := existing.(*api.PodGroup); ok {
+			group.Status = stored.Status
+		}
+	}
+	if err := s.store.Update(ctx, &group); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// deletePodGroup handles pod group deletion
+func (s *Server) deletePodGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "PodGroup", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,314 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestDeletePod(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+
+	newPod := func(name, nodeName string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+			Spec:       api.PodSpec{NodeName: nodeName},
+			Status:     api.PodStatus{Phase: api.PodRunning},
+		}
+	}
+	for _, pod := range []*api.Pod{newPod("pending", ""), newPod("running", "node-1"), newPod("stuck", "node-1")} {
+		if err := s.store.Create(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deletePod := func(path string) int {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1alpha1/namespaces/default/pods/"+path, nil))
+		return rec.Code
+	}
+	exists := func(name string) bool {
+		_, err := s.store.Get(ctx, "Pod", "default", name)
+		return !errors.Is(err, store.ErrNotFound)
+	}
+
+	// A pod no node runs is removed right away
+	if code := deletePod("pending"); code != http.StatusOK || exists("pending") {
+		t.Errorf("Expected an unscheduled pod to be removed, got %d", code)
+	}
+
+	// A running pod is left for its node to stop
+	if code := deletePod("running?gracePeriodSeconds=10"); code != http.StatusAccepted {
+		t.Fatalf("Expected 202 deleting a running pod, got %d", code)
+	}
+	obj, err := s.store.Get(ctx, "Pod", "default", "running")
+	if err != nil {
+		t.Fatalf("Expected the running pod to be kept until its node stops it: %v", err)
+	}
+	pod := obj.(*api.Pod)
+	if pod.DeletionTimestamp == nil || pod.TerminationGracePeriod() != 10 {
+		t.Errorf("Expected the pod to be terminating with a 10s grace period, got %v and %d", pod.DeletionTimestamp, pod.TerminationGracePeriod())
+	}
+	if code := deletePod("running"); code != http.StatusAccepted {
+		t.Errorf("Expected 202 deleting a terminating pod again, got %d", code)
+	}
+	obj, _ = s.store.Get(ctx, "Pod", "default", "running")
+	if again := obj.(*api.Pod); !again.DeletionTimestamp.Equal(*pod.DeletionTimestamp) || again.TerminationGracePeriod() != 10 {
+		t.Error("Expected deleting a terminating pod again to leave it as it is")
+	}
+
+	// Force-deleting doesn't wait for the node
+	if code := deletePod("stuck?gracePeriodSeconds=-1"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative grace period, got %d", code)
+	}
+	if code := deletePod("stuck?gracePeriodSeconds=0"); code != http.StatusOK || exists("stuck") {
+		t.Errorf("Expected a force-deleted pod to be removed, got %d", code)
+	}
+}
+
+func TestListPodsAllNamespaces(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	for _, namespace := range []string{"default", "team-a", "team-b"} {
+		pod := &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "web", Namespace: namespace},
+		}
+		if err := s.store.Create(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func(path string) int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/"+path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 listing %s, got %d", path, rec.Code)
+		}
+		var pods struct {
+			Items []api.Pod `json:"items"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&pods); err != nil {
+			t.Fatal(err)
+		}
+		return len(pods.Items)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "pods", want: 3},
+		{path: "pods?allNamespaces=true", want: 3},
+		{path: "namespaces/team-a/pods", want: 1},
+		{path: "namespaces/team-a/pods?allNamespaces=true", want: 3},
+	}
+	for _, tt := range tests {
+		if got := list(tt.path); got != tt.want {
+			t.Errorf("Expected %d pods listing %s, got %d", tt.want, tt.path, got)
+		}
+	}
+}
+
+func TestUpdatePodConflict(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "web", Image: "nginx:1.27"}}},
+	}
+	if err := s.store.Create(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	read := pod.ResourceVersion
+	update := func(resourceVersion string) int {
+		body := `{"metadata":{"name":"web","resourceVersion":"` + resourceVersion + `"},
+			"spec":{"containers":[{"name":"web","image":"nginx:1.27"}]}}`
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1alpha1/namespaces/default/pods/web", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := update(read); code != http.StatusOK {
+		t.Fatalf("Expected an update from the current resourceVersion to succeed, got %d", code)
+	}
+	if code := update(read); code != http.StatusConflict {
+		t.Errorf("Expected 409 for an update from a stale resourceVersion, got %d", code)
+	}
+	if code := update(""); code != http.StatusOK {
+		t.Errorf("Expected an update without a resourceVersion to succeed, got %d", code)
+	}
+}
+
+func TestUpdatePodStatusConflict(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+	if err := s.store.Create(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	read := pod.ResourceVersion
+	updateStatus := func(resourceVersion string, phase api.PodPhase) int {
+		body := `{"metadata":{"resourceVersion":"` + resourceVersion + `"},"status":{"phase":"` + string(phase) + `"}}`
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1alpha1/namespaces/default/pods/web/status", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := updateStatus(read, api.PodRunning); code != http.StatusOK {
+		t.Fatalf("Expected a status update from the current resourceVersion to succeed, got %d", code)
+	}
+	if code := updateStatus(read, api.PodPending); code != http.StatusConflict {
+		t.Errorf("Expected 409 for a status update from a stale resourceVersion, got %d", code)
+	}
+	obj, err := s.store.Get(ctx, "Pod", "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phase := obj.(*api.Pod).Status.Phase; phase != api.PodRunning {
+		t.Errorf("Expected the stale status update to leave the pod Running, got %s", phase)
+	}
+	if code := updateStatus("", api.PodSucceeded); code != http.StatusOK {
+		t.Errorf("Expected a status update without a resourceVersion to succeed, got %d", code)
+	}
+}
+
+func TestPodValidation(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "web", Image: "nginx:1.27"}}},
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+	if err := s.store.Create(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+
+	create := httptest.NewRequest(http.MethodPost, "/api/v1alpha1/namespaces/default/pods", strings.NewReader(
+		`{"metadata":{"name":"api"},"spec":{"activeDeadlineSeconds":-1,"containers":[{"name":"api","image":"nginx:1.27"}]}}`))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, create)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a POST with a negative activeDeadlineSeconds, got %d", rec.Code)
+	}
+
+	put := httptest.NewRequest(http.MethodPut, "/api/v1alpha1/namespaces/default/pods/web", strings.NewReader(
+		`{"metadata":{"name":"web"},"spec":{"containers":[{"name":"web","image":"nginx:1.27"}]},"status":{"phase":"Starting"}}`))
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, put)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a PUT with an unknown phase, got %d", rec.Code)
+	}
+
+	patch := httptest.NewRequest(http.MethodPatch, "/api/v1alpha1/namespaces/default/pods/web", strings.NewReader(`{"status":{"phase":"Starting"}}`))
+	patch.Header.Set("Content-Type", mergePatchContentType)
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, patch)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a PATCH with an unknown phase, got %d", rec.Code)
+	}
+
+	obj, err := s.store.Get(ctx, "Pod", "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phase := obj.(*api.Pod).Status.Phase; phase != api.PodPending {
+		t.Errorf("Expected the stored pod to stay Pending, got %s", phase)
+	}
+}
+
+func TestUpdatePodStatus(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+	if err := s.store.Create(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	updateStatus := func(body string) int {
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/v1alpha1/namespaces/default/pods/web/status", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := updateStatus(`{"status":{"phase":"Starting"}}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an unknown phase, got %d", code)
+	}
+	if code := updateStatus(`{"status":{"phase":"Running","conditions":[{"type":"Ready","status":"yes"}]}}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an unknown condition status, got %d", code)
+	}
+
+	// Phases and statuses written in another case read as the known ones
+	if code := updateStatus(`{"status":{"phase":"running","conditions":[{"type":"Ready","status":"true"}]}}`); code != http.StatusOK {
+		t.Fatalf("Expected 200 for a lowercase phase, got %d", code)
+	}
+	obj, err := s.store.Get(ctx, "Pod", "default", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := obj.(*api.Pod).Status; status.Phase != api.PodRunning || !api.IsPodConditionTrue(&status, api.PodConditionReady) {
+		t.Errorf("Expected a Running pod with a True Ready condition, got %+v", status)
+	}
+}
+
+func TestGetPodLogNodeAgentToken(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	s.SetNodeAgentToken("node-secret")
+
+	var forwarded string
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("Authorization")
+		w.Write([]byte("hello\n"))
+	}))
+	defer agent.Close()
+	host, port, _ := strings.Cut(strings.TrimPrefix(agent.URL, "http://"), ":")
+	agentPort, _ := strconv.Atoi(port)
+
+	node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+	}
+	node.Status.Addresses = []api.NodeAddress{{Type: api.NodeInternalIP, Address: host}}
+	node.Status.DaemonEndpoints.KubeletEndpoint.Port = int32(agentPort)
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec:       api.PodSpec{NodeName: "node-1"},
+	}
+	for _, obj := range []store.Object{node, pod} {
+		if err := s.store.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/namespaces/default/pods/web/log", nil)
+	req.Header.Set("Authorization", "Bearer user-token")
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello\n" {
+		t.Fatalf("Expected the agent's log, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if forwarded != "Bearer node-secret" {
+		t.Errorf("Expected the node agent token to be sent in place of the user's, got %q", forwarded)
+	}
+}
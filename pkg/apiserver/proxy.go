@@ -0,0 +1,149 @@
+package apiserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetBasePath serves the API under path instead of at the root, e.g.
+// "/minik8s" serves /minik8s/api/v1alpha1/..., so a reverse proxy can route a
+// prefix of a shared host to the server without stripping it. Clients include
+// the path in their server URL. /healthz and /readyz stay reachable at the
+// root for local probes.
+func (s *Server) SetBasePath(path string) {
+	s.basePath = strings.TrimSuffix("/"+strings.Trim(path, "/"), "/")
+}
+
+// SetTrustedProxies honors the X-Forwarded-For, X-Forwarded-Host and
+// X-Forwarded-Proto headers of requests coming from the given addresses,
+// given as IPs or CIDRs. Those headers are ignored from anyone else, since
+// clients could set them to anything.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	nets, err := parseTrustedProxies(proxies)
+	if err != nil {
+		return err
+	}
+	s.trustedProxies = nets
+	return nil
+}
+
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy CIDR %q: %w", proxy, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// trustedProxy reports whether ip is one of the trusted proxies
+func (s *Server) trustedProxy(ip net.IP) bool {
+	for _, ipNet := range s.trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// honorForwardedHeaders rewrites requests relayed by a trusted proxy to look
+// like they came straight from the client: the remote address becomes the
+// client's, taken from X-Forwarded-For, and the host and scheme become the
+// ones the client used. The client is the rightmost address in
+// X-Forwarded-For that isn't a trusted proxy, since each proxy appends the
+// address it got the request from and only trusted ones can be believed.
+func (s *Server) honorForwardedHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.trustedProxies) == 0 || !s.trustedProxy(remoteIP(r.RemoteAddr)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r = r.Clone(r.Context())
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(strings.Join(forwarded, ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				ip := net.ParseIP(strings.TrimSpace(hops[i]))
+				if ip == nil {
+					break
+				}
+				r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+				if !s.trustedProxy(ip) {
+					break
+				}
+			}
+		}
+		if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+			r.Host = host
+		}
+		if proto := strings.ToLower(r.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			r.URL.Scheme = proto
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteIP returns the IP of a request's remote address, or nil
+func remoteIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// stripBasePath serves requests under the base path with it removed, and
+// answers any other request with 404
+func (s *Server) stripBasePath(next http.Handler) http.Handler {
+	if s.basePath == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path, ok := trimBasePath(r.URL.Path, s.basePath)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		r = r.Clone(r.Context())
+		r.URL.Path = path
+		if r.URL.RawPath != "" {
+			if r.URL.RawPath, ok = trimBasePath(r.URL.RawPath, s.basePath); !ok {
+				r.URL.RawPath = ""
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// trimBasePath removes base from the front of path, if path is base or below
+// it
+func trimBasePath(path, base string) (string, bool) {
+	if path == base {
+		return "/", true
+	}
+	if !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+	return path[len(base):], true
+}
@@ -0,0 +1,68 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestStripBasePath(t *testing.T) {
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	s.SetBasePath("/minik8s/")
+	handler := s.stripBasePath(s.router)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/minik8s/api/v1alpha1/namespaces", http.StatusOK},
+		{"/minik8s/version", http.StatusOK},
+		{"/healthz", http.StatusOK},
+		{"/api/v1alpha1/namespaces", http.StatusNotFound},
+		{"/minik8sx/version", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: expected %d, got %d", tt.path, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestHonorForwardedHeaders(t *testing.T) {
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	if err := s.SetTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"}); err != nil {
+		t.Fatalf("SetTrustedProxies failed: %v", err)
+	}
+
+	var got *http.Request
+	handler := s.honorForwardedHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r }))
+	request := func(remoteAddr string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/version", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7, 192.168.1.2")
+		r.Header.Set("X-Forwarded-Host", "lab.example.com")
+		r.Header.Set("X-Forwarded-Proto", "https")
+		return r
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("10.0.0.1:41000"))
+	if ip := remoteIP(got.RemoteAddr).String(); ip != "198.51.100.7" {
+		t.Errorf("Expected client 198.51.100.7 behind trusted proxies, got %s", ip)
+	}
+	if got.Host != "lab.example.com" || got.URL.Scheme != "https" {
+		t.Errorf("Expected forwarded host and scheme, got %s and %s", got.Host, got.URL.Scheme)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), request("10.0.0.2:41000"))
+	if got.RemoteAddr != "10.0.0.2:41000" || got.Host == "lab.example.com" {
+		t.Errorf("Expected headers from an untrusted address to be ignored, got %s from %s", got.Host, got.RemoteAddr)
+	}
+
+	if err := s.SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected an invalid proxy address to be rejected")
+	}
+}
@@ -0,0 +1,66 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// promoteDeployment moves a canary rollout past the step it's paused at, or
+// with ?full=true straight to the new ReplicaSet taking all replicas. A
+// blue/green rollout is switched over once its new ReplicaSet is ready.
+func (s *Server) promoteDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ctx := r.Context()
+
+	obj, err := s.store.Get(ctx, "Deployment", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	current, ok := obj.(*api.Deployment)
+	if !ok {
+		http.Error(w, "stored object is not a deployment", http.StatusInternalServerError)
+		return
+	}
+
+	deployment := *current
+	strategy := current.Spec.Strategy
+	switch {
+	case strategy != nil && strategy.Type == api.DeploymentStrategyCanary && strategy.Canary != nil:
+		steps := int32(len(strategy.Canary.Steps))
+		if current.Status.Canary == nil || current.Status.Canary.CurrentStep >= steps {
+			http.Error(w, "deployment has no canary rollout in progress", http.StatusConflict)
+			return
+		}
+		canary := *current.Status.Canary
+		if r.URL.Query().Get("full") == "true" {
+			canary.CurrentStep = steps
+		} else {
+			canary.CurrentStep++
+		}
+		deployment.Status.Canary = &canary
+	case strategy != nil && strategy.Type == api.DeploymentStrategyBlueGreen && strategy.BlueGreen != nil:
+		// The switch itself waits for the preview to be ready
+		if current.Status.BlueGreen == nil || current.Status.BlueGreen.PreviewReplicaSet == "" {
+			http.Error(w, "deployment has no blue/green rollout in progress", http.StatusConflict)
+			return
+		}
+		blueGreen := *current.Status.BlueGreen
+		blueGreen.Promoted = true
+		deployment.Status.BlueGreen = &blueGreen
+	default:
+		http.Error(w, "deployment does not use the Canary or BlueGreen strategy", http.StatusConflict)
+		return
+	}
+
+	if err := s.store.Update(ctx, &deployment); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deployment)
+}
@@ -0,0 +1,105 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createRuntimeClass handles runtime class creation
+func (s *Server) createRuntimeClass(w http.ResponseWriter, r *http.Request) {
+	var runtimeClass api.RuntimeClass
+	if err := json.NewDecoder(r.Body).Decode(&runtimeClass); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&runtimeClass.TypeMeta, &runtimeClass.ObjectMeta, "RuntimeClass", ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if runtimeClass.Handler == "" {
+		http.Error(w, "handler is required", http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validation.RuntimeClass(&runtimeClass); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &runtimeClass, &runtimeClass.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(runtimeClass)
+}
+
+// getRuntimeClass handles runtime class retrieval
+func (s *Server) getRuntimeClass(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	runtimeClass, err := s.store.Get(r.Context(), "RuntimeClass", "", vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runtimeClass)
+}
+
+// listRuntimeClasses handles runtime class listing
+func (s *Server) listRuntimeClasses(w http.ResponseWriter, r *http.Request) {
+	objs, err := s.store.List(r.Context(), "RuntimeClass", "")
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeList(w, "RuntimeClassList", objs)
+}
+
+// deleteRuntimeClass handles runtime class deletion. Pods already using the
+// class keep running; new ones naming it are rejected.
+func (s *Server) deleteRuntimeClass(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "RuntimeClass", "", vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// admitRuntimeClass rejects pods naming a RuntimeClass that doesn't exist,
+// and sets the overhead of the others to their class's. A pod can't choose
+// its own overhead.
+func (s *Server) admitRuntimeClass(ctx context.Context, pod *api.Pod) error {
+	var overhead api.ResourceList
+	if pod.Spec.RuntimeClassName != "" {
+		obj, err := s.store.Get(ctx, "RuntimeClass", "", pod.Spec.RuntimeClassName)
+		if err != nil {
+			return fmt.Errorf("pod rejected: RuntimeClass %q not found", pod.Spec.RuntimeClassName)
+		}
+		if runtimeClass, ok := obj.(*api.RuntimeClass); ok && runtimeClass.Overhead != nil {
+			overhead = runtimeClass.Overhead.PodFixed
+		}
+	}
+	if len(pod.Spec.Overhead) > 0 && !reflect.DeepEqual(pod.Spec.Overhead, overhead) {
+		return fmt.Errorf("pod rejected: spec.overhead must match the overhead of its RuntimeClass")
+	}
+	pod.Spec.Overhead = overhead
+	return nil
+}
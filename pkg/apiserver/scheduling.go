@@ -0,0 +1,36 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/scheduler"
+)
+
+// simulateScheduling runs the scheduling algorithm for the pod in the body
+// against the current nodes and returns the node it would be bound to, with
+// each node's checks and score. Nothing is stored or bound.
+func (s *Server) simulateScheduling(w http.ResponseWriter, r *http.Request) {
+	var pod api.Pod
+	if err := json.NewDecoder(r.Body).Decode(&pod); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(pod.Spec.Containers) == 0 {
+		http.Error(w, "spec.containers must not be empty", http.StatusBadRequest)
+		return
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = "default"
+	}
+
+	result, err := scheduler.NewScheduler(&scheduler.Config{Store: s.store}).Simulate(r.Context(), &pod)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,93 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// crossNamespaceLists are the routes listing objects of several namespaces.
+// Users scoped to namespaces may read them; the handlers filter the items.
+var crossNamespaceLists = map[string]bool{
+	"/api/v1alpha1/namespaces": true,
+	"/api/v1alpha1/pods":       true,
+	"/api/v1alpha1/events":     true,
+	"/api/v1alpha1/watch":      true,
+}
+
+// scopeNamespaces limits users scoped to namespaces to the objects in them.
+// Namespaced routes must name an allowed namespace, a namespace itself may
+// only be read or changed if allowed, cross-namespace lists are filtered and
+// every other route, such as nodes or the admin endpoints, is forbidden.
+func (s *Server) scopeNamespaces(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.UserFrom(r.Context())
+		if !ok || user.Namespaces == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		vars := mux.Vars(r)
+		var template string
+		if route := mux.CurrentRoute(r); route != nil {
+			template, _ = route.GetPathTemplate()
+		}
+
+		switch {
+		case vars["namespace"] != "":
+			if !user.CanAccessNamespace(vars["namespace"]) {
+				http.Error(w, "namespace "+vars["namespace"]+" is outside the token's scope", http.StatusForbidden)
+				return
+			}
+		case template == "/api/v1alpha1/namespaces/{name}":
+			if !user.CanAccessNamespace(vars["name"]) {
+				http.Error(w, "namespace "+vars["name"]+" is outside the token's scope", http.StatusForbidden)
+				return
+			}
+		case crossNamespaceLists[template] && r.Method == http.MethodGet:
+		default:
+			http.Error(w, "tokens scoped to namespaces can't access cluster-wide resources", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedObjects drops the objects outside the requesting user's namespaces
+// from a cross-namespace list
+func allowedObjects(r *http.Request, objs []store.Object) []store.Object {
+	user, ok := auth.UserFrom(r.Context())
+	if !ok || user.Namespaces == nil {
+		return objs
+	}
+
+	allowed := []store.Object{}
+	for _, obj := range objs {
+		if allowedObject(r, obj) {
+			allowed = append(allowed, obj)
+		}
+	}
+	return allowed
+}
+
+// allowedObject reports whether obj is in one of the requesting user's
+// namespaces, or the user isn't scoped to namespaces
+func allowedObject(r *http.Request, obj store.Object) bool {
+	namespace := obj.GetNamespace()
+	if obj.GetKind() == "Namespace" {
+		namespace = obj.GetName()
+	}
+	return allowedNamespace(r, namespace)
+}
+
+// allowedNamespace reports whether the requesting user may access
+// namespace
+func allowedNamespace(r *http.Request, namespace string) bool {
+	user, ok := auth.UserFrom(r.Context())
+	if !ok || user.Namespaces == nil {
+		return true
+	}
+	return user.CanAccessNamespace(namespace)
+}
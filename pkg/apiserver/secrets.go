@@ -0,0 +1,140 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// secretsRedactedWarning is sent with responses whose secret data was
+// redacted
+const secretsRedactedWarning = "secret data redacted; the requesting user is not one of the API server's --secret-readers"
+
+// SetSecretReaders lets the given users and groups read the data of
+// Secrets. Everyone else gets Secrets with their data redacted once
+// authentication is enabled.
+func (s *Server) SetSecretReaders(readers []string) {
+	s.secretReaders = readers
+}
+
+// canReadSecrets reports whether the user of r may read secret data
+func (s *Server) canReadSecrets(r *http.Request) bool {
+	if s.authenticator == nil {
+		return true
+	}
+	user, ok := auth.UserFrom(r.Context())
+	return ok && user.IsAny(s.secretReaders)
+}
+
+// redactSecret returns secret with its values emptied, keeping the keys so
+// callers can still see what it holds
+func redactSecret(secret *api.Secret) *api.Secret {
+	redacted := *secret
+	redacted.Data = nil
+	if len(secret.Data) > 0 {
+		redacted.Data = make(map[string][]byte, len(secret.Data))
+		for key := range secret.Data {
+			redacted.Data[key] = []byte{}
+		}
+	}
+	redacted.StringData = nil
+	return &redacted
+}
+
+// secretResponse returns obj as the user of r may see it, warning when its
+// data is redacted
+func (s *Server) secretResponse(w http.ResponseWriter, r *http.Request, obj store.Object) store.Object {
+	secret, ok := obj.(*api.Secret)
+	if !ok || s.canReadSecrets(r) {
+		return obj
+	}
+	w.Header().Add("Warning", formatWarning(secretsRedactedWarning))
+	return redactSecret(secret)
+}
+
+// createSecret handles secret creation. StringData is merged into Data and
+// not stored.
+func (s *Server) createSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var secret api.Secret
+	if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&secret.TypeMeta, &secret.ObjectMeta, "Secret", vars["namespace"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(secret.StringData) > 0 && secret.Data == nil {
+		secret.Data = make(map[string][]byte, len(secret.StringData))
+	}
+	for key, value := range secret.StringData {
+		secret.Data[key] = []byte(value)
+	}
+	secret.StringData = nil
+
+	if err := store.CreateWithGeneratedName(r.Context(), s.store, &secret, &secret.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := s.secretResponse(w, r, &secret)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// getSecret handles secret retrieval
+func (s *Server) getSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	secret, err := s.store.Get(r.Context(), "Secret", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.secretResponse(w, r, secret))
+}
+
+// listSecrets handles secret listing
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	objs, err := s.store.List(r.Context(), "Secret", vars["namespace"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	if len(objs) > 0 && !s.canReadSecrets(r) {
+		w.Header().Add("Warning", formatWarning(secretsRedactedWarning))
+		redacted := make([]store.Object, 0, len(objs))
+		for _, obj := range objs {
+			if secret, ok := obj.(*api.Secret); ok {
+				redacted = append(redacted, redactSecret(secret))
+			}
+		}
+		objs = redacted
+	}
+	writeList(w, "SecretList", objs)
+}
+
+// deleteSecret handles secret deletion
+func (s *Server) deleteSecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "Secret", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,15 +1,25 @@
 package apiserver
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/profiling"
 	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
 )
 
 // Server represents the API server
@@ -17,6 +27,70 @@ type Server struct {
 	store  store.Store
 	router *mux.Router
 	port   int
+
+	// watchHeartbeat is how long a watch stream may idle before a BOOKMARK line
+	watchHeartbeat time.Duration
+
+	// timeouts bound requests, set by SetTimeouts
+	timeouts Timeouts
+
+	// Service accounts and authentication, set by EnableServiceAccounts
+	tokenSigner        *auth.TokenSigner
+	authenticator      auth.Authenticator
+	allowAnonymous     bool
+	rootCA             []byte
+	maxTokenExpiration time.Duration
+
+	// readOnly rejects mutating requests, set by SetReadOnly
+	readOnly atomic.Bool
+
+	// rejectMissingNamespaces fails creates in namespaces that don't exist
+	// instead of creating them, set by SetNamespaceAutoCreation
+	rejectMissingNamespaces bool
+
+	// history records changes made through the API, set by EnableHistory
+	history *history
+
+	// secretReaders are the users and groups that may read secret data, set
+	// by SetSecretReaders
+	secretReaders []string
+
+	// imagePolicy restricts the images of pods, set by SetImagePolicy
+	imagePolicy ImagePolicy
+
+	// bootstrapTokens is set by EnableBootstrapTokens
+	bootstrapTokens bool
+
+	// TLS serving certificate and key files, set by SetTLS
+	tlsCertFile string
+	tlsKeyFile  string
+
+	// nodeAgentToken authenticates the API server to node agents, set by
+	// SetNodeAgentToken
+	nodeAgentToken string
+
+	// storeName is what componentstatuses calls the store, set by SetStoreName
+	storeName string
+
+	// basePath prefixes every route, set by SetBasePath
+	basePath string
+
+	// trustedProxies may set X-Forwarded-* headers, set by SetTrustedProxies
+	trustedProxies []*net.IPNet
+
+	// unixSocket is served besides the port, set by SetUnixSocket
+	unixSocket string
+
+	// listeners replace the port and unix socket, set by SetListeners
+	listeners []net.Listener
+
+	// httpServer is the server Start runs, stopped by Shutdown
+	httpServer atomic.Pointer[http.Server]
+
+	// shutdownCh is closed by Shutdown to end watch streams, which would
+	// otherwise keep the server from shutting down
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
 }
 
 // NewServer creates a new API server
@@ -25,6 +99,10 @@ func NewServer(store store.Store, port int) *Server {
 		store:  store,
 		router: mux.NewRouter(),
 		port:   port,
+
+		watchHeartbeat: DefaultWatchHeartbeatInterval,
+		timeouts:       DefaultTimeouts(),
+		shutdownCh:     make(chan struct{}),
 	}
 
 	s.setupRoutes()
@@ -36,9 +114,36 @@ func (s *Server) setupRoutes() {
 	// Health check
 	s.router.HandleFunc("/healthz", s.healthHandler).Methods("GET")
 	s.router.HandleFunc("/readyz", s.readyHandler).Methods("GET")
+	s.router.HandleFunc("/version", s.versionHandler).Methods("GET")
 
 	// API v1alpha1
 	apiV1 := s.router.PathPrefix("/api/v1alpha1").Subrouter()
+	apiV1.Use(s.authenticate)
+	apiV1.Use(s.restrictBootstrappers)
+	apiV1.Use(s.restrictNodes)
+	apiV1.Use(s.scopeNamespaces)
+	apiV1.Use(s.rejectWritesWhenReadOnly)
+	apiV1.Use(s.requireNamespace)
+	apiV1.Use(s.warnDeprecations)
+
+	// Node bootstrap, reachable with a bootstrap token or without credentials
+	s.router.HandleFunc(api.BootstrapCAPath, s.bootstrapCAHandler).Methods("GET")
+	s.router.Handle(api.BootstrapJoinPath, s.authenticate(http.HandlerFunc(s.joinNode))).Methods("POST")
+
+	// Read-only mode, outside apiV1 so it can be switched off again
+	admin := s.router.PathPrefix("/admin").Subrouter()
+	admin.Use(s.authenticate)
+	admin.Use(s.restrictBootstrappers)
+	admin.Use(s.restrictNodes)
+	admin.Use(s.scopeNamespaces)
+	admin.HandleFunc("/read-only", s.readOnlyHandler).Methods("GET", "PUT")
+
+	// Namespaces
+	apiV1.HandleFunc("/namespaces", s.createNamespace).Methods("POST")
+	apiV1.HandleFunc("/namespaces", s.listNamespaces).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{name}", s.getNamespace).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{name}", s.deleteNamespace).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{name}", s.patchObject("Namespace", func() store.Object { return &api.Namespace{} }, nil)).Methods("PATCH")
 
 	// Pods
 	apiV1.HandleFunc("/namespaces/{namespace}/pods", s.createPod).Methods("POST")
@@ -46,7 +151,94 @@ func (s *Server) setupRoutes() {
 	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}", s.getPod).Methods("GET")
 	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}", s.updatePod).Methods("PUT")
 	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}", s.deletePod).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}", s.patchObject("Pod", func() store.Object { return &api.Pod{} }, validateObject)).Methods("PATCH")
+	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}/status", s.updatePodStatus).Methods("PUT")
 	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}/watch", s.watchPod).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}/history", s.objectHistory("Pod")).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}/exec", s.execPod).Methods("GET", "POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}/attach", s.attachPod).Methods("GET", "POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/pods/{name}/log", s.getPodLog).Methods("GET")
+
+	// Deployments
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments", s.createDeployment).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments", s.listDeployments).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}", s.getDeployment).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}", s.updateDeployment).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}", s.deleteDeployment).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}", s.patchObject("Deployment", func() store.Object { return &api.Deployment{} }, validateObject)).Methods("PATCH")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}/scale", s.getDeploymentScale).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}/scale", s.updateDeploymentScale).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}/history", s.objectHistory("Deployment")).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}/promote", s.promoteDeployment).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/deployments/{name}/replicasets", s.listOwned("Deployment", "ReplicaSet", "ReplicaSetList")).Methods("GET")
+
+	// ReplicaSets
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets", s.createReplicaSet).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets", s.listReplicaSets).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}", s.getReplicaSet).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}", s.updateReplicaSet).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}", s.deleteReplicaSet).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}", s.patchObject("ReplicaSet", func() store.Object { return &api.ReplicaSet{} }, validateObject)).Methods("PATCH")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}/scale", s.getReplicaSetScale).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}/scale", s.updateReplicaSetScale).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}/pods", s.listOwned("ReplicaSet", "Pod", "PodList")).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/replicasets/{name}/history", s.objectHistory("ReplicaSet")).Methods("GET")
+
+	// Jobs
+	apiV1.HandleFunc("/namespaces/{namespace}/jobs", s.createJob).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/jobs", s.listJobs).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/jobs/{name}", s.getJob).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/jobs/{name}", s.deleteJob).Methods("DELETE")
+
+	// CronJobs
+	apiV1.HandleFunc("/namespaces/{namespace}/cronjobs", s.createCronJob).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/cronjobs", s.listCronJobs).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/cronjobs/{name}", s.getCronJob).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/cronjobs/{name}", s.updateCronJob).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/cronjobs/{name}", s.deleteCronJob).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{namespace}/cronjobs/{name}", s.patchObject("CronJob", func() store.Object { return &api.CronJob{} }, validateObject)).Methods("PATCH")
+
+	// Horizontal pod autoscalers
+	apiV1.HandleFunc("/namespaces/{namespace}/horizontalpodautoscalers", s.createAutoscaler).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/horizontalpodautoscalers", s.listAutoscalers).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/horizontalpodautoscalers/{name}", s.getAutoscaler).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/horizontalpodautoscalers/{name}", s.updateAutoscaler).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/horizontalpodautoscalers/{name}", s.deleteAutoscaler).Methods("DELETE")
+
+	// Pod groups
+	apiV1.HandleFunc("/namespaces/{namespace}/podgroups", s.createPodGroup).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/podgroups", s.listPodGroups).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/podgroups/{name}", s.getPodGroup).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/podgroups/{name}", s.updatePodGroup).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/podgroups/{name}", s.deletePodGroup).Methods("DELETE")
+
+	// Services, and the Endpoints the service controller keeps for them
+	apiV1.HandleFunc("/namespaces/{namespace}/services", s.createService).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/services", s.listServices).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/services/{name}", s.getService).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/services/{name}", s.updateService).Methods("PUT")
+	apiV1.HandleFunc("/namespaces/{namespace}/services/{name}", s.deleteService).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{namespace}/endpoints", s.listEndpoints).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/endpoints/{name}", s.getEndpoints).Methods("GET")
+
+	// Service accounts
+	apiV1.HandleFunc("/namespaces/{namespace}/serviceaccounts", s.createServiceAccount).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/serviceaccounts", s.listServiceAccounts).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/serviceaccounts/{name}", s.getServiceAccount).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/serviceaccounts/{name}", s.deleteServiceAccount).Methods("DELETE")
+	apiV1.HandleFunc("/namespaces/{namespace}/serviceaccounts/{name}/token", s.createServiceAccountToken).Methods("POST")
+
+	// Secrets
+	apiV1.HandleFunc("/namespaces/{namespace}/secrets", s.createSecret).Methods("POST")
+	apiV1.HandleFunc("/namespaces/{namespace}/secrets", s.listSecrets).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/secrets/{name}", s.getSecret).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/secrets/{name}", s.deleteSecret).Methods("DELETE")
+
+	// Events
+	apiV1.HandleFunc("/namespaces/{namespace}/events", s.listEvents).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/events/{name}", s.getEvent).Methods("GET")
+	apiV1.HandleFunc("/namespaces/{namespace}/events/{name}", s.deleteEvent).Methods("DELETE")
+	apiV1.HandleFunc("/events", s.listEvents).Methods("GET")
 
 	// Nodes
 	apiV1.HandleFunc("/nodes", s.createNode).Methods("POST")
@@ -54,17 +246,117 @@ func (s *Server) setupRoutes() {
 	apiV1.HandleFunc("/nodes/{name}", s.getNode).Methods("GET")
 	apiV1.HandleFunc("/nodes/{name}", s.updateNode).Methods("PUT")
 	apiV1.HandleFunc("/nodes/{name}", s.deleteNode).Methods("DELETE")
+	apiV1.HandleFunc("/nodes/{name}", s.patchObject("Node", func() store.Object { return &api.Node{} }, validateObject)).Methods("PATCH")
 	apiV1.HandleFunc("/nodes/{name}/watch", s.watchNode).Methods("GET")
+	apiV1.HandleFunc("/nodes/{name}/history", s.objectHistory("Node")).Methods("GET")
+
+	// Watches of several kinds over one connection
+	apiV1.HandleFunc("/watch", s.watchKinds).Methods("GET")
+
+	// Component statuses, worked out from leases and a store probe
+	apiV1.HandleFunc("/componentstatuses", s.listComponentStatuses).Methods("GET")
+
+	// RuntimeClasses
+	apiV1.HandleFunc("/runtimeclasses", s.createRuntimeClass).Methods("POST")
+	apiV1.HandleFunc("/runtimeclasses", s.listRuntimeClasses).Methods("GET")
+	apiV1.HandleFunc("/runtimeclasses/{name}", s.getRuntimeClass).Methods("GET")
+	apiV1.HandleFunc("/runtimeclasses/{name}", s.deleteRuntimeClass).Methods("DELETE")
+
+	// Certificate signing requests
+	apiV1.HandleFunc("/certificatesigningrequests", s.createCertificateSigningRequest).Methods("POST")
+	apiV1.HandleFunc("/certificatesigningrequests", s.listCertificateSigningRequests).Methods("GET")
+	apiV1.HandleFunc("/certificatesigningrequests/{name}", s.getCertificateSigningRequest).Methods("GET")
+	apiV1.HandleFunc("/certificatesigningrequests/{name}", s.deleteCertificateSigningRequest).Methods("DELETE")
+	apiV1.HandleFunc("/certificatesigningrequests/{name}/approval", s.updateCertificateApproval).Methods("PUT")
+
+	// How full the nodes are, worked out from their pods
+	apiV1.HandleFunc("/capacity", s.getCapacity).Methods("GET")
+
+	// Scheduling what-if
+	apiV1.HandleFunc("/scheduling/simulate", s.simulateScheduling).Methods("POST")
 
 	// All pods (for listing across namespaces)
 	apiV1.HandleFunc("/pods", s.listAllPods).Methods("GET")
 }
 
-// Start starts the API server
+// Start starts the API server, serving until one of its listeners fails
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%d", s.port)
-	fmt.Printf("Starting API server on %s\n", addr)
-	return http.ListenAndServe(addr, s.router)
+	listeners, err := s.listen()
+	if err != nil {
+		return err
+	}
+	defer closeListeners(listeners)
+
+	server := &http.Server{
+		Handler:           s.honorForwardedHeaders(s.logRequests(s.stripBasePath(s.enforceRequestTimeout(s.router)))),
+		ReadHeaderTimeout: s.timeouts.ReadHeader,
+		ReadTimeout:       s.timeouts.Read,
+		WriteTimeout:      s.timeouts.Write,
+		IdleTimeout:       s.timeouts.Idle,
+	}
+	if s.tlsCertFile != "" {
+		// Clients may authenticate with a certificate issued by the root CA
+		// instead of a token
+		if len(s.rootCA) > 0 && s.authenticator != nil {
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(s.rootCA) {
+				return fmt.Errorf("failed to parse root CA")
+			}
+			server.TLSConfig = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: clientCAs}
+		}
+	}
+
+	s.httpServer.Store(server)
+
+	errCh := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		fmt.Printf("Starting API server on %s %s%s\n", listener.Addr().Network(), listener.Addr(), s.basePath)
+		go func(listener net.Listener) {
+			if s.tlsCertFile != "" {
+				errCh <- server.ServeTLS(listener, s.tlsCertFile, s.tlsKeyFile)
+				return
+			}
+			errCh <- server.Serve(listener)
+		}(listener)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections, ends watch streams and waits for
+// the other requests in flight to finish until ctx expires, when the
+// remaining connections are closed. Start then returns nil.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() { close(s.shutdownCh) })
+	server := s.httpServer.Load()
+	if server == nil {
+		return nil
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return err
+	}
+	return nil
+}
+
+// SetTLS serves the API over HTTPS with the given certificate and key files
+func (s *Server) SetTLS(certFile, keyFile string) {
+	s.tlsCertFile = certFile
+	s.tlsKeyFile = keyFile
+}
+
+// EnableProfiling serves the Go runtime profiles under /debug/pprof/ to
+// authenticated users. CPU profiles must be shorter than the server's write
+// timeout.
+func (s *Server) EnableProfiling() {
+	debug := s.router.PathPrefix(profiling.PathPrefix).Subrouter()
+	debug.Use(s.authenticate)
+	debug.Use(s.restrictBootstrappers)
+	debug.Use(s.restrictNodes)
+	debug.Use(s.scopeNamespaces)
+	debug.PathPrefix("/").Handler(profiling.Handler())
 }
 
 // healthHandler handles health check requests
@@ -91,16 +383,34 @@ func (s *Server) createPod(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set metadata
-	pod.Kind = "Pod"
-	pod.APIVersion = "v1alpha1"
-	pod.Namespace = namespace
-	pod.UID = generateUID()
-	pod.Status.Phase = string(api.PodPending)
+	if err := populateMetadata(&pod.TypeMeta, &pod.ObjectMeta, "Pod", namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pod.Status.Phase = api.PodPending
+	if err := validation.Pod(&pod); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.admitImages(&pod); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 
-	// Create in store
 	ctx := r.Context()
-	if err := s.store.Create(ctx, &pod); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := s.admitRuntimeClass(ctx, &pod); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err := s.admitServiceAccount(ctx, &pod); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Create in store
+	if err := store.CreateWithGeneratedName(ctx, s.store, &pod, &pod.ObjectMeta); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 
@@ -118,7 +428,7 @@ func (s *Server) getPod(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	pod, err := s.store.Get(ctx, "Pod", namespace, name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeStoreError(w, err)
 		return
 	}
 
@@ -126,66 +436,41 @@ func (s *Server) getPod(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(pod)
 }
 
-// listPods handles pod listing
+// listPods handles pod listing in a namespace, or in every namespace given
+// ?allNamespaces=true
 func (s *Server) listPods(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	namespace := vars["namespace"]
-
-	ctx := r.Context()
-	pods, err := s.store.List(ctx, "Pod", namespace)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Convert to proper pod slice
-	var podList []*api.Pod
-	for _, obj := range pods {
-		if pod, ok := obj.(*api.Pod); ok {
-			podList = append(podList, pod)
-		}
+	namespace := mux.Vars(r)["namespace"]
+	if r.URL.Query().Get("allNamespaces") == "true" {
+		namespace = store.AllNamespaces
 	}
-
-	response := map[string]interface{}{
-		"apiVersion": "v1alpha1",
-		"kind":       "PodList",
-		"items":      podList,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	s.servePods(w, r, namespace)
 }
 
-// listAllPods handles listing pods across all namespaces
+// listAllPods handles listing, and with ?watch=true watching, the pods of
+// every namespace, as the namespaced pod lists do given ?allNamespaces=true
 func (s *Server) listAllPods(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	s.servePods(w, r, store.AllNamespaces)
+}
 
-	// For now, just list from default namespace
-	// In a real implementation, you'd want to aggregate across namespaces
-	pods, err := s.store.List(ctx, "Pod", "default")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// servePods lists or watches the pods of namespace, leaving out those in
+// namespaces the requesting user may not read
+func (s *Server) servePods(w http.ResponseWriter, r *http.Request, namespace string) {
+	if r.URL.Query().Get("watch") == "true" {
+		s.watchPods(w, r, namespace)
 		return
 	}
 
-	var podList []*api.Pod
-	for _, obj := range pods {
-		if pod, ok := obj.(*api.Pod); ok {
-			podList = append(podList, pod)
-		}
-	}
-
-	response := map[string]interface{}{
-		"apiVersion": "v1alpha1",
-		"kind":       "PodList",
-		"items":      podList,
+	pods, err := s.listObjects(r, "Pod", namespace)
+	if err != nil {
+		writeStoreError(w, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeList(w, "PodList", allowedObjects(r, pods))
 }
 
-// updatePod handles pod updates
+// updatePod handles pod updates. Only the spec and metadata are taken from
+// the request; see updatePodStatus.
 func (s *Server) updatePod(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	namespace := vars["namespace"]
@@ -202,10 +487,33 @@ func (s *Server) updatePod(w http.ResponseWriter, r *http.Request) {
 	pod.APIVersion = "v1alpha1"
 	pod.Namespace = namespace
 	pod.Name = name
+	if err := validation.Pod(&pod); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := s.admitImages(&pod); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 
 	ctx := r.Context()
+	if err := s.preserveUID(ctx, "Pod", &pod.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	// The status belongs to the node agent and is written through the
+	// status subresource, and only deleting the pod marks it terminating; a
+	// PUT of the pod keeps the stored ones
+	if existing, err := s.store.Get(ctx, "Pod", namespace, name); err == nil {
+		if stored, ok := existing.(*api.Pod); ok {
+			pod.Status = stored.Status
+			pod.DeletionTimestamp = stored.DeletionTimestamp
+			pod.DeletionGracePeriodSeconds = stored.DeletionGracePeriodSeconds
+		}
+	}
 	if err := s.store.Update(ctx, &pod); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeStoreError(w, err)
 		return
 	}
 
@@ -213,19 +521,70 @@ func (s *Server) updatePod(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(pod)
 }
 
-// deletePod handles pod deletion
+// deletePod handles pod deletion. A pod a node runs isn't removed right
+// away: it's marked terminating and returned with 202 Accepted, and its node
+// agent removes it once the containers stopped. ?gracePeriodSeconds=
+// overrides how long they get to exit; 0 force-deletes the pod, removing it
+// at once whether or not they did.
 func (s *Server) deletePod(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	namespace := vars["namespace"]
 	name := vars["name"]
 
+	var gracePeriod *int64
+	if value := r.URL.Query().Get("gracePeriodSeconds"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds < 0 {
+			http.Error(w, fmt.Sprintf("invalid gracePeriodSeconds %q", value), http.StatusBadRequest)
+			return
+		}
+		gracePeriod = &seconds
+	}
+
 	ctx := r.Context()
-	if err := s.store.Delete(ctx, "Pod", namespace, name); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	obj, err := s.store.Get(ctx, "Pod", namespace, name)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		http.Error(w, "stored object is not a pod", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	// Pods no node runs, or whose containers all exited, have nothing to
+	// wait for
+	finished := pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed
+	if (gracePeriod != nil && *gracePeriod == 0) || pod.Spec.NodeName == "" || finished {
+		if err := s.store.Delete(ctx, "Pod", namespace, name); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Deleting a terminating pod again leaves it as it is
+	if pod.DeletionTimestamp == nil {
+		terminating := *pod
+		now := time.Now()
+		grace := pod.Spec.TerminationGracePeriod()
+		if gracePeriod != nil {
+			grace = *gracePeriod
+		}
+		terminating.DeletionTimestamp = &now
+		terminating.DeletionGracePeriodSeconds = &grace
+		if err := s.store.Update(ctx, &terminating); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		pod = &terminating
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(pod)
 }
 
 // watchPod handles pod watch requests
@@ -235,42 +594,34 @@ func (s *Server) watchPod(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 
 	ctx := r.Context()
-	watchResult, err := s.store.Watch(ctx, "Pod", namespace)
+	watchResult, err := s.store.WatchObject(ctx, "Pod", namespace, name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer close(watchResult.Stop)
 
-	// Set headers for streaming
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Transfer-Encoding", "chunked")
-
-	// Flush headers
-	if flusher, ok := w.(http.Flusher); ok {
-		flusher.Flush()
-	}
-
-	// Stream events
-	for {
-		select {
-		case event := <-watchResult.Events:
-			// Filter events for the specific pod
-			if pod, ok := event.Object.(*api.Pod); ok && pod.Name == name {
-				eventJSON, _ := json.Marshal(event)
-				w.Write(eventJSON)
-				w.Write([]byte("\n"))
-				if flusher, ok := w.(http.Flusher); ok {
-					flusher.Flush()
-				}
-			}
-		case <-watchResult.Stop:
-			return
-		case <-ctx.Done():
-			return
-		}
+	s.serveWatch(w, r, watchResult, func(obj store.Object) bool {
+		_, ok := obj.(*api.Pod)
+		return ok && allowedObject(r, obj)
+	})
+}
+
+// watchPods streams events for every pod in a namespace, starting with an
+// ADDED event for each existing pod
+func (s *Server) watchPods(w http.ResponseWriter, r *http.Request, namespace string) {
+	ctx := r.Context()
+	watchResult, err := s.store.Watch(ctx, "Pod", namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	defer close(watchResult.Stop)
+
+	s.serveWatch(w, r, watchResult, func(obj store.Object) bool {
+		_, ok := obj.(*api.Pod)
+		return ok && allowedObject(r, obj)
+	})
 }
 
 // createNode handles node creation
@@ -282,13 +633,22 @@ func (s *Server) createNode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set metadata
-	node.Kind = "Node"
-	node.APIVersion = "v1alpha1"
-	node.UID = generateUID()
+	if err := populateMetadata(&node.TypeMeta, &node.ObjectMeta, "Node", ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.Node(&node); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !s.canRegisterNode(r, node.Name) {
+		http.Error(w, fmt.Sprintf("only node %s may register itself; join with a bootstrap token", node.Name), http.StatusForbidden)
+		return
+	}
 
 	ctx := r.Context()
-	if err := s.store.Create(ctx, &node); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := store.CreateWithGeneratedName(ctx, s.store, &node, &node.ObjectMeta); err != nil {
+		writeStoreError(w, err)
 		return
 	}
 
@@ -305,7 +665,7 @@ func (s *Server) getNode(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	node, err := s.store.Get(ctx, "Node", "", name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeStoreError(w, err)
 		return
 	}
 
@@ -318,25 +678,11 @@ func (s *Server) listNodes(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	nodes, err := s.store.List(ctx, "Node", "")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeStoreError(w, err)
 		return
 	}
 
-	var nodeList []*api.Node
-	for _, obj := range nodes {
-		if node, ok := obj.(*api.Node); ok {
-			nodeList = append(nodeList, node)
-		}
-	}
-
-	response := map[string]interface{}{
-		"apiVersion": "v1alpha1",
-		"kind":       "NodeList",
-		"items":      nodeList,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeList(w, "NodeList", nodes)
 }
 
 // updateNode handles node updates
@@ -354,10 +700,18 @@ func (s *Server) updateNode(w http.ResponseWriter, r *http.Request) {
 	node.Kind = "Node"
 	node.APIVersion = "v1alpha1"
 	node.Name = name
+	if err := validation.Node(&node); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 
 	ctx := r.Context()
+	if err := s.preserveUID(ctx, "Node", &node.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
 	if err := s.store.Update(ctx, &node); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeStoreError(w, err)
 		return
 	}
 
@@ -372,7 +726,7 @@ func (s *Server) deleteNode(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	if err := s.store.Delete(ctx, "Node", "", name); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeStoreError(w, err)
 		return
 	}
 
@@ -385,45 +739,15 @@ func (s *Server) watchNode(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 
 	ctx := r.Context()
-	watchResult, err := s.store.Watch(ctx, "Node", "")
+	watchResult, err := s.store.WatchObject(ctx, "Node", "", name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer close(watchResult.Stop)
 
-	// Set headers for streaming
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Transfer-Encoding", "chunked")
-
-	// Flush headers
-	if flusher, ok := w.(http.Flusher); ok {
-		flusher.Flush()
-	}
-
-	// Stream events
-	for {
-		select {
-		case event := <-watchResult.Events:
-			// Filter events for the specific node
-			if node, ok := event.Object.(*api.Node); ok && node.Name == name {
-				eventJSON, _ := json.Marshal(event)
-				w.Write(eventJSON)
-				w.Write([]byte("\n"))
-				if flusher, ok := w.(http.Flusher); ok {
-					flusher.Flush()
-				}
-			}
-		case <-watchResult.Stop:
-			return
-		case <-ctx.Done():
-			return
-		}
-	}
-}
-
-// generateUID generates a unique identifier
-func generateUID() string {
-	return strconv.FormatInt(time.Now().UnixNano(), 10)
+	s.serveWatch(w, r, watchResult, func(obj store.Object) bool {
+		_, ok := obj.(*api.Node)
+		return ok
+	})
 }
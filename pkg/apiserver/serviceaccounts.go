@@ -0,0 +1,400 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const (
+	// DefaultTokenIssuer is the issuer of service account tokens
+	DefaultTokenIssuer = "minik8s"
+	// defaultTokenExpiration is the lifetime of tokens that don't request one
+	defaultTokenExpiration = time.Hour
+	// minTokenExpiration is the shortest token lifetime that may be requested
+	minTokenExpiration = 10 * time.Minute
+	// defaultMaxTokenExpiration caps requested token lifetimes
+	defaultMaxTokenExpiration = 24 * time.Hour
+)
+
+// ServiceAccountConfig configures service account tokens and authentication
+type ServiceAccountConfig struct {
+	// SigningKey is the HMAC key used to sign and verify tokens
+	SigningKey []byte
+	// Issuer is the token issuer; defaults to DefaultTokenIssuer
+	Issuer string
+	// RootCA is the CA bundle published to pods, if the server is served over TLS
+	RootCA []byte
+	// AllowAnonymous lets requests without a token through as the anonymous user
+	AllowAnonymous bool
+	// MaxTokenExpiration caps requested token lifetimes
+	MaxTokenExpiration time.Duration
+}
+
+// EnableServiceAccounts turns on service account token issuance, token
+// authentication and auto-mounting of tokens into pods
+func (s *Server) EnableServiceAccounts(config *ServiceAccountConfig) error {
+	if config.Issuer == "" {
+		config.Issuer = DefaultTokenIssuer
+	}
+	if config.MaxTokenExpiration == 0 {
+		config.MaxTokenExpiration = defaultMaxTokenExpiration
+	}
+
+	signer, err := auth.NewTokenSigner(config.SigningKey, config.Issuer)
+	if err != nil {
+		return fmt.Errorf("failed to create token signer: %w", err)
+	}
+
+	s.tokenSigner = signer
+	s.authenticator = auth.Union(auth.NewServiceAccountAuthenticator(signer, s.store), auth.NewNodeAuthenticator(signer))
+	s.allowAnonymous = config.AllowAnonymous
+	s.rootCA = config.RootCA
+	s.maxTokenExpiration = config.MaxTokenExpiration
+	return nil
+}
+
+// authenticate is the API middleware; it is a no-op until service accounts are enabled
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.authenticator == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		withUser := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recordUser(r)
+			next.ServeHTTP(w, r)
+		})
+		auth.Middleware(s.authenticator, s.allowAnonymous)(withUser).ServeHTTP(w, r)
+	})
+}
+
+// createServiceAccount handles service account creation
+func (s *Server) createServiceAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+
+	var sa api.ServiceAccount
+	if err := json.NewDecoder(r.Body).Decode(&sa); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set metadata
+	if err := populateMetadata(&sa.TypeMeta, &sa.ObjectMeta, "ServiceAccount", namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &sa, &sa.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sa)
+}
+
+// getServiceAccount handles service account retrieval
+func (s *Server) getServiceAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	ctx := r.Context()
+	sa, err := s.store.Get(ctx, "ServiceAccount", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sa)
+}
+
+// listServiceAccounts handles service account listing
+func (s *Server) listServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	ctx := r.Context()
+	objs, err := s.store.List(ctx, "ServiceAccount", vars["namespace"])
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeList(w, "ServiceAccountList", objs)
+}
+
+// deleteServiceAccount handles service account deletion; tokens issued for it stop working
+func (s *Server) deleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	ctx := r.Context()
+	if err := s.store.Delete(ctx, "ServiceAccount", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// createServiceAccountToken issues a signed token for a service account, optionally bound to a pod
+func (s *Server) createServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	if s.tokenSigner == nil {
+		http.Error(w, "service account tokens are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	var req api.TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expiration := defaultTokenExpiration
+	if req.Spec.ExpirationSeconds != 0 {
+		expiration = time.Duration(req.Spec.ExpirationSeconds) * time.Second
+	}
+	if expiration < minTokenExpiration {
+		http.Error(w, fmt.Sprintf("expirationSeconds must be at least %d", int64(minTokenExpiration.Seconds())), http.StatusBadRequest)
+		return
+	}
+	if expiration > s.maxTokenExpiration {
+		expiration = s.maxTokenExpiration
+	}
+
+	ctx := r.Context()
+	obj, err := s.store.Get(ctx, "ServiceAccount", namespace, name)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	sa := obj.(*api.ServiceAccount)
+
+	claims := &auth.Claims{
+		Subject:            auth.ServiceAccountUsername(namespace, name),
+		Namespace:          namespace,
+		ServiceAccountName: name,
+		ServiceAccountUID:  sa.UID,
+	}
+
+	// A scoped requester can only hand out tokens within its own scope
+	claims.Namespaces = req.Spec.Namespaces
+	if user, ok := auth.UserFrom(ctx); ok && user.Namespaces != nil {
+		if len(claims.Namespaces) == 0 {
+			claims.Namespaces = user.Namespaces
+		}
+		for _, ns := range claims.Namespaces {
+			if !user.CanAccessNamespace(ns) {
+				http.Error(w, fmt.Sprintf("namespace %s is outside the requester's scope", ns), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	// Nodes only get tokens bound to the pods bound to them
+	nodeName, isNode := requestingNode(ctx)
+	if isNode && req.Spec.BoundObjectRef == nil {
+		http.Error(w, fmt.Sprintf("node %s may only request tokens bound to its pods", nodeName), http.StatusForbidden)
+		return
+	}
+	if ref := req.Spec.BoundObjectRef; ref != nil {
+		if ref.Kind != "Pod" {
+			http.Error(w, fmt.Sprintf("tokens can't be bound to kind %s", ref.Kind), http.StatusBadRequest)
+			return
+		}
+		obj, err := s.store.Get(ctx, "Pod", namespace, ref.Name)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		pod := obj.(*api.Pod)
+		if ref.UID != "" && ref.UID != pod.UID {
+			http.Error(w, fmt.Sprintf("pod %s has UID %s, not %s", ref.Name, pod.UID, ref.UID), http.StatusConflict)
+			return
+		}
+		if pod.Spec.ServiceAccountName != name {
+			http.Error(w, fmt.Sprintf("pod %s does not run as service account %s", ref.Name, name), http.StatusForbidden)
+			return
+		}
+		if isNode && pod.Spec.NodeName != nodeName {
+			http.Error(w, fmt.Sprintf("pod %s is not bound to node %s", ref.Name, nodeName), http.StatusForbidden)
+			return
+		}
+		claims.PodName = pod.Name
+		claims.PodUID = pod.UID
+	}
+
+	expiresAt := time.Now().Add(expiration)
+	claims.ExpiresAt = expiresAt.Unix()
+	token, err := s.tokenSigner.Sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	req.Kind = "TokenRequest"
+	req.APIVersion = "v1alpha1"
+	req.Namespace = namespace
+	req.Name = name
+	req.Spec.ExpirationSeconds = int64(expiration.Seconds())
+	req.Status = api.TokenRequestStatus{
+		Token:               token,
+		ExpirationTimestamp: time.Unix(claims.ExpiresAt, 0),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(req)
+}
+
+// admitServiceAccount defaults the pod's service account, makes sure it exists and
+// mounts a projected volume with a token, the root CA and the pod's namespace
+func (s *Server) admitServiceAccount(ctx context.Context, pod *api.Pod) error {
+	if s.tokenSigner == nil {
+		return nil
+	}
+
+	if pod.Spec.ServiceAccountName == "" {
+		pod.Spec.ServiceAccountName = api.DefaultServiceAccountName
+	}
+
+	sa, err := s.ensureServiceAccount(ctx, pod.Namespace, pod.Spec.ServiceAccountName)
+	if err != nil {
+		return err
+	}
+
+	if s.rootCA != nil {
+		if err := s.ensureRootCAConfigMap(ctx, pod.Namespace); err != nil {
+			return err
+		}
+	}
+
+	// The pod's own setting wins over the service account's
+	automount := true
+	if sa.AutomountServiceAccountToken != nil {
+		automount = *sa.AutomountServiceAccountToken
+	}
+	if pod.Spec.AutomountServiceAccountToken != nil {
+		automount = *pod.Spec.AutomountServiceAccountToken
+	}
+	if !automount {
+		return nil
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == api.ServiceAccountVolumeName {
+			return nil
+		}
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, serviceAccountVolume())
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		mounted := false
+		for _, mount := range container.VolumeMounts {
+			if mount.MountPath == api.ServiceAccountMountPath {
+				mounted = true
+				break
+			}
+		}
+		if !mounted {
+			container.VolumeMounts = append(container.VolumeMounts, api.VolumeMount{
+				Name:      api.ServiceAccountVolumeName,
+				ReadOnly:  true,
+				MountPath: api.ServiceAccountMountPath,
+			})
+		}
+	}
+
+	return nil
+}
+
+// ensureServiceAccount returns the named service account, creating the default one on demand
+func (s *Server) ensureServiceAccount(ctx context.Context, namespace, name string) (*api.ServiceAccount, error) {
+	obj, err := s.store.Get(ctx, "ServiceAccount", namespace, name)
+	if err == nil {
+		return obj.(*api.ServiceAccount), nil
+	}
+	if !errors.Is(err, store.ErrNotFound) || name != api.DefaultServiceAccountName {
+		return nil, fmt.Errorf("service account %s/%s: %w", namespace, name, err)
+	}
+
+	sa := &api.ServiceAccount{ObjectMeta: api.ObjectMeta{Name: name}}
+	if err := populateMetadata(&sa.TypeMeta, &sa.ObjectMeta, "ServiceAccount", namespace); err != nil {
+		return nil, err
+	}
+	if err := s.store.Create(ctx, sa); err != nil {
+		return nil, fmt.Errorf("failed to create default service account: %w", err)
+	}
+	return sa, nil
+}
+
+// ensureRootCAConfigMap publishes the root CA bundle in the namespace
+func (s *Server) ensureRootCAConfigMap(ctx context.Context, namespace string) error {
+	_, err := s.store.Get(ctx, "ConfigMap", namespace, api.RootCAConfigMapName)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	configMap := &api.ConfigMap{
+		ObjectMeta: api.ObjectMeta{Name: api.RootCAConfigMapName},
+		Data: map[string]string{
+			api.RootCAConfigMapKey: string(s.rootCA),
+		},
+	}
+	if err := populateMetadata(&configMap.TypeMeta, &configMap.ObjectMeta, "ConfigMap", namespace); err != nil {
+		return err
+	}
+	if err := s.store.Create(ctx, configMap); err != nil {
+		return fmt.Errorf("failed to publish root CA: %w", err)
+	}
+	return nil
+}
+
+// serviceAccountVolume returns the projected volume holding a pod's API credentials
+func serviceAccountVolume() api.Volume {
+	return api.Volume{
+		Name: api.ServiceAccountVolumeName,
+		VolumeSource: api.VolumeSource{
+			Projected: &api.ProjectedVolumeSource{
+				Sources: []api.VolumeProjection{
+					{
+						ServiceAccountToken: &api.ServiceAccountTokenProjection{
+							Path:              "token",
+							ExpirationSeconds: int64(defaultTokenExpiration.Seconds()),
+						},
+					},
+					{
+						ConfigMap: &api.ConfigMapProjection{
+							Name:     api.RootCAConfigMapName,
+							Items:    []api.KeyToPath{{Key: api.RootCAConfigMapKey, Path: api.RootCAConfigMapKey}},
+							Optional: true,
+						},
+					},
+					{
+						DownwardAPI: &api.DownwardAPIProjection{
+							Items: []api.DownwardAPIVolumeFile{{Path: "namespace", FieldPath: "metadata.namespace"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createService handles service creation. A service without a cluster IP
+// gets one from the service controller.
+func (s *Server) createService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var service api.Service
+	if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := populateMetadata(&service.TypeMeta, &service.ObjectMeta, "Service", vars["namespace"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if service.Spec.Type == "" {
+		service.Spec.Type = api.ServiceTypeClusterIP
+	}
+	if err := validation.Service(&service); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := store.CreateWithGeneratedName(r.Context(), s.store, &service, &service.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(service)
+}
+
+// getService handles service retrieval
+func (s *Server) getService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	service, err := s.store.Get(r.Context(), "Service", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service)
+}
+
+// listServices handles service listing
+func (s *Server) listServices(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "Service", "ServiceList")
+}
+
+// updateService handles service updates. The cluster IP can't change once
+// allocated; an update leaving it out keeps it.
+func (s *Server) updateService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var service api.Service
+	if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service.Kind = "Service"
+	service.APIVersion = "v1alpha1"
+	service.Namespace = vars["namespace"]
+	service.Name = vars["name"]
+	if service.Spec.Type == "" {
+		service.Spec.Type = api.ServiceTypeClusterIP
+	}
+
+	ctx := r.Context()
+	if err := s.preserveUID(ctx, "Service", &service.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if existing, err := s.store.Get(ctx, "Service", service.Namespace, service.Name); err == nil {
+		if stored, ok := existing.(*api.Service); ok && stored.Spec.ClusterIP != "" {
+			if service.Spec.ClusterIP == "" {
+				service.Spec.ClusterIP = stored.Spec.ClusterIP
+			}
+			if service.Spec.ClusterIP != stored.Spec.ClusterIP {
+				http.Error(w, "spec.clusterIP can't be changed", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if err := validation.Service(&service); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.store.Update(ctx, &service); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service)
+}
+
+// deleteService handles service deletion. The service controller removes
+// its Endpoints and frees its cluster IP.
+func (s *Server) deleteService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "Service", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getEndpoints handles retrieving the Endpoints of a service
+func (s *Server) getEndpoints(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	endpoints, err := s.store.Get(r.Context(), "Endpoints", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoints)
+}
+
+// listEndpoints handles listing the Endpoints of a namespace's services
+func (s *Server) listEndpoints(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "Endpoints", "EndpointsList")
+}
@@ -0,0 +1,58 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// updatePodStatus handles writes to a pod's status subresource. Only the
+// status is taken from the request; the stored spec and metadata are kept,
+// so a status report built from a stale copy can't undo a user's edit.
+func (s *Server) updatePodStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	var update api.Pod
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.PodStatus(&update.Status); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctx := r.Context()
+	obj, err := s.store.Get(ctx, "Pod", namespace, name)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	current, ok := obj.(*api.Pod)
+	if !ok {
+		http.Error(w, "stored object is not a pod", http.StatusInternalServerError)
+		return
+	}
+	if update.UID != "" && update.UID != current.UID {
+		writeStoreError(w, errUIDChanged)
+		return
+	}
+
+	// A status read from an older version of the pod is rejected with a
+	// conflict, as updates of the whole pod are
+	pod := *current
+	pod.Status = update.Status
+	pod.ResourceVersion = update.ResourceVersion
+	if err := s.store.Update(ctx, &pod); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pod)
+}
@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/profiling"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// Timeouts bound how long the server spends on a request. Zero disables a
+// timeout.
+type Timeouts struct {
+	// ReadHeader, Read, Write and Idle are the HTTP server's timeouts. Watch
+	// streams lift Read and Write and bound each write on their own.
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+
+	// Request is the deadline of the context handlers work with, except for
+	// long-running requests such as watches, exec and logs. It should be
+	// shorter than Write, so the 504 sent on expiry can still be written.
+	Request time.Duration
+}
+
+// DefaultTimeouts returns the timeouts a new server starts with
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		ReadHeader: 10 * time.Second,
+		Read:       time.Minute,
+		Write:      time.Minute,
+		Idle:       2 * time.Minute,
+		Request:    30 * time.Second,
+	}
+}
+
+// SetTimeouts replaces the server's timeouts
+func (s *Server) SetTimeouts(timeouts Timeouts) {
+	s.timeouts = timeouts
+}
+
+// enforceRequestTimeout gives requests a context deadline and answers those
+// that fail because it, or the deadline of a store call, passed with 504
+// Gateway Timeout rather than whatever status the handler picked for the
+// error
+func (s *Server) enforceRequestTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if longRunningRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := store.WithTimeoutTracking(r.Context())
+		if s.timeouts.Request > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.timeouts.Request)
+			defer cancel()
+		}
+		next.ServeHTTP(&timeoutResponseWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+	})
+}
+
+// longRunningRequest reports whether r streams for as long as the client
+// wants, so no request deadline applies
+func longRunningRequest(r *http.Request) bool {
+	if r.URL.Query().Get("watch") == "true" || strings.HasPrefix(r.URL.Path, profiling.PathPrefix) {
+		return true
+	}
+	for _, suffix := range []string{"/watch", "/exec", "/attach", "/log"} {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutResponseWriter turns the error status of a request whose deadline
+// passed into 504
+type timeoutResponseWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+}
+
+// WriteHeader writes 504 instead of an error status caused by a timeout
+func (w *timeoutResponseWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest && (errors.Is(w.ctx.Err(), context.DeadlineExceeded) || store.TimedOut(w.ctx)) {
+		status = http.StatusGatewayTimeout
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *timeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
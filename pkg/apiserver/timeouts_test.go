@@ -0,0 +1,61 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// hungStore blocks Get until its context is done, like an unreachable etcd
+type hungStore struct {
+	store.Store
+}
+
+func (s *hungStore) Get(ctx context.Context, kind, namespace, name string) (store.Object, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestEnforceRequestTimeout(t *testing.T) {
+	hung := store.NewTimeoutStore(&hungStore{Store: store.NewMemoryStore(store.DefaultOptions())}, 20*time.Millisecond)
+	s := NewServer(hung, 0)
+	timeouts := DefaultTimeouts()
+	timeouts.Request = 50 * time.Millisecond
+	s.SetTimeouts(timeouts)
+	handler := s.enforceRequestTimeout(s.router)
+
+	// A store call running out of time
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/namespaces/default/pods/web", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504 for a store timeout, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The request itself running out of time
+	slow := s.enforceRequestTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		http.Error(w, r.Context().Err().Error(), http.StatusInternalServerError)
+	}))
+	rec = httptest.NewRecorder()
+	slow.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/nodes", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504 for an expired request, got %d", rec.Code)
+	}
+
+	// Errors in time keep their status, and watches get no deadline
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1alpha1/namespaces/default/deployments/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing deployment, got %d", rec.Code)
+	}
+	watch := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/namespaces/default/pods?watch=true", nil)
+	s.enforceRequestTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("Expected no deadline for a watch")
+		}
+	})).ServeHTTP(httptest.NewRecorder(), watch)
+}
@@ -0,0 +1,137 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/version"
+)
+
+// storeHealthTimeout bounds the store probe of a component status request
+const storeHealthTimeout = 5 * time.Second
+
+// componentLeases are the control plane components whose health is judged
+// by their lease, keyed by component status name
+var componentLeases = map[string]string{
+	"scheduler":          api.LeaseScheduler,
+	"controller-manager": api.LeaseControllerManager,
+}
+
+// SetStoreName sets the name the store is reported under by
+// componentstatuses, e.g. etcd-0; it defaults to "store"
+func (s *Server) SetStoreName(name string) {
+	s.storeName = name
+}
+
+// versionHandler reports the API server's build along with the versions of
+// the components holding leases and of the node agents
+func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	info := version.Get()
+	info.Components = []version.Component{{Name: "kube-apiserver", Version: info.GitVersion}}
+
+	now := time.Now()
+	leases, err := s.store.List(r.Context(), "Lease", api.NamespaceSystem)
+	if err == nil {
+		for _, obj := range leases {
+			lease, ok := obj.(*api.Lease)
+			if !ok || lease.Expired(now) {
+				continue
+			}
+			if v := lease.Annotations[api.AnnotationComponentVersion]; v != "" {
+				info.Components = append(info.Components, version.Component{Name: lease.Name, Version: v})
+			}
+		}
+	}
+	nodes, err := s.store.List(r.Context(), "Node", "")
+	if err == nil {
+		for _, obj := range nodes {
+			if node, ok := obj.(*api.Node); ok && node.Status.NodeInfo.KubeletVersion != "" {
+				info.Components = append(info.Components, version.Component{Name: "node/" + node.Name, Version: node.Status.NodeInfo.KubeletVersion})
+			}
+		}
+	}
+	sort.SliceStable(info.Components[1:], func(i, j int) bool {
+		return info.Components[i+1].Name < info.Components[j+1].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// listComponentStatuses reports whether the scheduler and controller manager
+// are renewing their leases, and whether the store answers
+func (s *Server) listComponentStatuses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+
+	names := make([]string, 0, len(componentLeases))
+	for name := range componentLeases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var items []api.ComponentStatus
+	for _, name := range names {
+		items = append(items, componentStatus(name, s.leaseHealth(ctx, componentLeases[name], now)))
+	}
+	storeName := s.storeName
+	if storeName == "" {
+		storeName = "store"
+	}
+	items = append(items, componentStatus(storeName, s.storeHealth(ctx)))
+
+	list := api.ComponentStatusList{
+		TypeMeta: api.TypeMeta{Kind: "ComponentStatusList", APIVersion: "v1alpha1"},
+		Items:    items,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// leaseHealth returns why the holder of the named lease isn't healthy, or nil
+func (s *Server) leaseHealth(ctx context.Context, name string, now time.Time) error {
+	obj, err := s.store.Get(ctx, "Lease", api.NamespaceSystem, name)
+	if err != nil {
+		return fmt.Errorf("no lease %s found, the component isn't running", name)
+	}
+	lease, ok := obj.(*api.Lease)
+	if !ok {
+		return fmt.Errorf("stored object is not a lease")
+	}
+	if lease.Expired(now) {
+		if lease.Spec.RenewTime == nil {
+			return fmt.Errorf("lease %s was never renewed", name)
+		}
+		return fmt.Errorf("lease %s held by %s was last renewed %s ago", name, lease.Spec.HolderIdentity,
+			now.Sub(*lease.Spec.RenewTime).Round(time.Second))
+	}
+	return nil
+}
+
+// storeHealth returns an error unless the store answers a list in time
+func (s *Server) storeHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, storeHealthTimeout)
+	defer cancel()
+	if _, err := s.store.List(ctx, "Namespace", ""); err != nil {
+		return fmt.Errorf("store check failed: %w", err)
+	}
+	return nil
+}
+
+// componentStatus builds the status of a component from its health error
+func componentStatus(name string, err error) api.ComponentStatus {
+	condition := api.ComponentCondition{Type: api.ComponentConditionHealthy, Status: api.ConditionTrue, Message: "ok"}
+	if err != nil {
+		condition = api.ComponentCondition{Type: api.ComponentConditionHealthy, Status: api.ConditionFalse, Error: err.Error()}
+	}
+	return api.ComponentStatus{
+		TypeMeta:   api.TypeMeta{Kind: "ComponentStatus", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Conditions: []api.ComponentCondition{condition},
+	}
+}
@@ -0,0 +1,196 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultWatchHeartbeatInterval is how long a watch stream may stay idle
+// before the server sends a BOOKMARK line to show it's still alive
+const DefaultWatchHeartbeatInterval = 15 * time.Second
+
+// watchWriteTimeout bounds every write to a watch stream, which lifts the
+// server's read and write timeouts
+const watchWriteTimeout = 30 * time.Second
+
+// SetWatchHeartbeatInterval changes how often idle watch streams get a
+// BOOKMARK line; zero or negative restores the default
+func (s *Server) SetWatchHeartbeatInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchHeartbeatInterval
+	}
+	s.watchHeartbeat = interval
+}
+
+// watchLine is a line of a watch stream. Kind is only set by multiplexed
+// watches, naming the kind of the event's object.
+type watchLine struct {
+	Type   store.EventType `json:"type"`
+	Kind   string          `json:"kind,omitempty"`
+	Object store.Object    `json:"object"`
+}
+
+// eventStreamContentType is the media type of Server-Sent Events, which
+// watches are streamed as when the client accepts it
+const eventStreamContentType = "text/event-stream"
+
+// serveWatch streams events from result as JSON lines until the client goes
+// away, the watch ends or the server shuts down. Events whose object keep
+// rejects are skipped. Whenever nothing was written for the heartbeat
+// interval a BOOKMARK line is sent, so proxies don't drop the connection and
+// clients can tell a quiet watch from a dead one.
+//
+// Clients sending Accept: text/event-stream get the same events framed as
+// Server-Sent Events instead, with the object's resource version as the
+// event ID, so a browser EventSource can consume them; see sseResumeVersion
+// for how a reconnecting one resumes.
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request, result store.WatchResult, keep func(store.Object) bool) {
+	s.streamWatch(w, r, result, keep, false)
+}
+
+// streamWatch is serveWatch, tagging every event with its object's kind if
+// tagKinds is set
+func (s *Server) streamWatch(w http.ResponseWriter, r *http.Request, result store.WatchResult, keep func(store.Object) bool, tagKinds bool) {
+	ctx := r.Context()
+
+	// The server timeouts are meant for ordinary requests; a stalled client
+	// is detected by the per-write deadline below instead
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	sse := acceptsEventStream(r)
+	resumeVersion, resuming := sseResumeVersion(r)
+
+	// Set headers for streaming
+	contentType := "application/json"
+	if sse {
+		contentType = eventStreamContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(event store.WatchEvent) bool {
+		line := watchLine{Type: event.Type, Object: event.Object}
+		if tagKinds && event.Object != nil {
+			line.Kind = event.Object.GetKind()
+		}
+		eventJSON, err := json.Marshal(line)
+		if err != nil {
+			return true
+		}
+		frame := append(eventJSON, '\n')
+		if sse {
+			frame = sseFrame(event, eventJSON)
+		}
+		rc.SetWriteDeadline(time.Now().Add(watchWriteTimeout))
+		if _, err := w.Write(frame); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	// Flush headers
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTimer(s.watchHeartbeat)
+	defer heartbeat.Stop()
+
+	// Stream events
+	for {
+		select {
+		case event, ok := <-result.Events:
+			if !ok {
+				return
+			}
+			if event.Object == nil || !keep(event.Object) {
+				continue
+			}
+			if resuming && event.Type == store.Added && !newerThan(event.Object.GetResourceVersion(), resumeVersion) {
+				continue
+			}
+			if !write(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(store.WatchEvent{Type: store.Bookmark}) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-s.shutdownCh:
+			return
+		}
+
+		if !heartbeat.Stop() {
+			select {
+			case <-heartbeat.C:
+			default:
+			}
+		}
+		heartbeat.Reset(s.watchHeartbeat)
+	}
+}
+
+// acceptsEventStream reports whether the client asked for the watch as
+// Server-Sent Events
+func acceptsEventStream(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == eventStreamContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// sseResumeVersion returns the Last-Event-ID an EventSource sends when it
+// reconnects, the resource version of the last event it got. The resumed
+// watch skips the ADDED events of objects that haven't changed since, so
+// the client only gets what it missed; objects deleted in between aren't
+// reported, so clients that must know should list again.
+func sseResumeVersion(r *http.Request) (uint64, bool) {
+	if !acceptsEventStream(r) {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// newerThan reports whether resourceVersion is after version. Versions that
+// aren't numbers are taken to be newer, so their events are never skipped.
+func newerThan(resourceVersion string, version uint64) bool {
+	parsed, err := strconv.ParseUint(resourceVersion, 10, 64)
+	return err != nil || parsed > version
+}
+
+// sseFrame frames a watch event as a Server-Sent Event whose data is
+// eventJSON. Bookmarks carry no ID, so they don't move the client's
+// Last-Event-ID.
+func sseFrame(event store.WatchEvent, eventJSON []byte) []byte {
+	var frame bytes.Buffer
+	if event.Object != nil {
+		if version := event.Object.GetResourceVersion(); version != "" {
+			frame.WriteString("id: " + version + "\n")
+		}
+	}
+	frame.WriteString("data: ")
+	frame.Write(eventJSON)
+	frame.WriteString("\n\n")
+	return frame.Bytes()
+}
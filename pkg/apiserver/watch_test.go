@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestWatchEventStream(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+
+	createPod := func(name string) *api.Pod {
+		t.Helper()
+		pod := &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		if err := s.store.Create(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+		return pod
+	}
+	watch := func(lastEventID string) (*http.Response, func() (string, string)) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1alpha1/namespaces/default/pods?watch=true", nil)
+		req.Header.Set("Accept", "text/event-stream")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := bufio.NewScanner(resp.Body)
+		// next returns the ID and the name of the pod of the next event
+		next := func() (string, string) {
+			t.Helper()
+			var id, data string
+			for lines.Scan() {
+				line := lines.Text()
+				if line == "" {
+					break
+				}
+				if value, ok := strings.CutPrefix(line, "id: "); ok {
+					id = value
+				} else if value, ok := strings.CutPrefix(line, "data: "); ok {
+					data = value
+				}
+			}
+			var event struct {
+				Type   string  `json:"type"`
+				Object api.Pod `json:"object"`
+			}
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("Invalid event data %q: %v", data, err)
+			}
+			return id, event.Object.Name
+		}
+		return resp, next
+	}
+
+	first := createPod("web")
+	resp, next := watch("")
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected a text/event-stream response, got %q", contentType)
+	}
+	if id, name := next(); id != first.ResourceVersion || name != "web" {
+		t.Errorf("Expected pod web with ID %s, got %s with ID %s", first.ResourceVersion, name, id)
+	}
+	resp.Body.Close()
+
+	// Resuming skips the pods the client already saw
+	second := createPod("api")
+	resp, next = watch(first.ResourceVersion)
+	defer resp.Body.Close()
+	if id, name := next(); id != second.ResourceVersion || name != "api" {
+		t.Errorf("Expected the resumed watch to start with pod api, got %s with ID %s", name, id)
+	}
+}
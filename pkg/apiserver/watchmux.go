@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// multiplexedWatchKinds are the kinds /watch serves. Secrets are left out,
+// since their data is only redacted by the secret routes.
+var multiplexedWatchKinds = []string{
+	"Pod", "Node", "Namespace", "Deployment", "ReplicaSet", "Job", "CronJob",
+	"HorizontalPodAutoscaler", "PodGroup", "Service", "Endpoints", "Event",
+	"RuntimeClass",
+}
+
+// clusterScopedKinds are the kinds of multiplexedWatchKinds that don't
+// belong to a namespace, which ?namespace= doesn't limit
+var clusterScopedKinds = map[string]bool{"Node": true, "Namespace": true, "RuntimeClass": true}
+
+// watchKinds streams the events of several kinds over one connection, e.g.
+// GET /watch?kinds=Pod,Node,Deployment. Each line is tagged with the kind of
+// its object. ?namespace= limits namespaced kinds to one namespace; the
+// stream starts with an ADDED event for every existing object, as the
+// single-kind watches do.
+func (s *Server) watchKinds(w http.ResponseWriter, r *http.Request) {
+	kinds, err := parseWatchKinds(r.URL.Query().Get("kinds"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	namespace := r.URL.Query().Get("namespace")
+	if namespace != "" && !allowedNamespace(r, namespace) {
+		http.Error(w, "namespace "+namespace+" is outside the token's scope", http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	results := make([]store.WatchResult, 0, len(kinds))
+	for _, kind := range kinds {
+		kindNamespace := namespace
+		if clusterScopedKinds[kind] {
+			kindNamespace = ""
+		}
+		result, err := s.store.Watch(ctx, kind, kindNamespace)
+		if err != nil {
+			for _, started := range results {
+				close(started.Stop)
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results = append(results, result)
+	}
+	merged := mergeWatches(ctx, results)
+	defer close(merged.Stop)
+
+	s.streamWatch(w, r, merged, func(obj store.Object) bool {
+		return allowedObject(r, obj)
+	}, true)
+}
+
+// parseWatchKinds parses the comma-separated kinds of a multiplexed watch,
+// matching their names regardless of case
+func parseWatchKinds(value string) ([]string, error) {
+	var kinds []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		kind := ""
+		for _, known := range multiplexedWatchKinds {
+			if strings.EqualFold(name, known) {
+				kind = known
+				break
+			}
+		}
+		if kind == "" {
+			return nil, fmt.Errorf("kind %q can't be watched, use %s", name, strings.Join(multiplexedWatchKinds, ", "))
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("kinds is required, e.g. ?kinds=Pod,Node")
+	}
+	return kinds, nil
+}
+
+// mergeWatches fans the events of several watches into one. The merged
+// watch ends as soon as any of them does, so the client notices and watches
+// again rather than silently missing a kind; stopping it stops them all.
+func mergeWatches(ctx context.Context, results []store.WatchResult) store.WatchResult {
+	merged := store.WatchResult{
+		Events: make(chan store.WatchEvent),
+		Stop:   make(chan struct{}),
+	}
+	done := make(chan struct{})
+	var once sync.Once
+	end := func() { once.Do(func() { close(done) }) }
+
+	var wg sync.WaitGroup
+	for _, result := range results {
+		wg.Add(1)
+		go func(result store.WatchResult) {
+			defer wg.Done()
+			defer end()
+			for {
+				select {
+				case event, ok := <-result.Events:
+					if !ok {
+						return
+					}
+					select {
+					case merged.Events <- event:
+					case <-done:
+						return
+					case <-merged.Stop:
+						return
+					case <-ctx.Done():
+						return
+					}
+				case <-done:
+					return
+				case <-merged.Stop:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(result)
+	}
+
+	go func() {
+		wg.Wait()
+		for _, result := range results {
+			close(result.Stop)
+		}
+		close(merged.Events)
+	}()
+	return merged
+}
@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestWatchKinds(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+
+	newPod := func(name string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+		}
+	}
+	if err := s.store.Create(ctx, newPod("web")); err != nil {
+		t.Fatal(err)
+	}
+	node := &api.Node{TypeMeta: api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"}, ObjectMeta: api.ObjectMeta{Name: "node-1"}}
+	if err := s.store.Create(ctx, node); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kinds := range []string{"", "Pod,Secret", "Widget"} {
+		resp, err := http.Get(server.URL + "/api/v1alpha1/watch?kinds=" + kinds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400 watching kinds %q, got %d", kinds, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/api/v1alpha1/watch?kinds=pod,Node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	next := func() (string, string, string) {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("Watch ended early: %v", lines.Err())
+		}
+		var line struct {
+			Type   string `json:"type"`
+			Kind   string `json:"kind"`
+			Object struct {
+				Metadata api.ObjectMeta `json:"metadata"`
+			} `json:"object"`
+		}
+		if err := json.Unmarshal(lines.Bytes(), &line); err != nil {
+			t.Fatalf("Invalid watch line %q: %v", lines.Text(), err)
+		}
+		return line.Type, line.Kind, line.Object.Metadata.Name
+	}
+
+	// Existing objects of both kinds come first, in no particular order
+	initial := map[string]string{}
+	for i := 0; i < 2; i++ {
+		eventType, kind, name := next()
+		if eventType != string(store.Added) {
+			t.Errorf("Expected ADDED events for existing objects, got %s", eventType)
+		}
+		initial[kind] = name
+	}
+	if initial["Pod"] != "web" || initial["Node"] != "node-1" {
+		t.Errorf("Expected pod web and node node-1 tagged with their kinds, got %v", initial)
+	}
+
+	// Kinds not asked for aren't streamed
+	deployment := &api.Deployment{TypeMeta: api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"}, ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"}}
+	if err := s.store.Create(ctx, deployment); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.Create(ctx, newPod("api")); err != nil {
+		t.Fatal(err)
+	}
+	if eventType, kind, name := next(); eventType != string(store.Added) || kind != "Pod" || name != "api" {
+		t.Errorf("Expected ADDED Pod api, got %s %s %s", eventType, kind, name)
+	}
+}
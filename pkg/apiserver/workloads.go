@@ -0,0 +1,388 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// createDeployment handles deployment creation
+func (s *Server) createDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+
+	var deployment api.Deployment
+	if err := json.NewDecoder(r.Body).Decode(&deployment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set metadata
+	if err := populateMetadata(&deployment.TypeMeta, &deployment.ObjectMeta, "Deployment", namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.Deployment(&deployment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&deployment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &deployment, &deployment.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(deployment)
+}
+
+// getDeployment handles deployment retrieval
+func (s *Server) getDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	deployment, err := s.store.Get(r.Context(), "Deployment", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deployment)
+}
+
+// listDeployments handles deployment listing
+func (s *Server) listDeployments(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "Deployment", "DeploymentList")
+}
+
+// updateDeployment handles deployment updates
+func (s *Server) updateDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var deployment api.Deployment
+	if err := json.NewDecoder(r.Body).Decode(&deployment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set metadata
+	deployment.Kind = "Deployment"
+	deployment.APIVersion = "v1alpha1"
+	deployment.Namespace = vars["namespace"]
+	deployment.Name = vars["name"]
+	if err := validation.Deployment(&deployment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&deployment); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	if err := s.preserveUID(ctx, "Deployment", &deployment.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	// Rollout progress only moves through the controller and promote; a PUT
+	// of the deployment keeps the stored one
+	if existing, err := s.store.Get(ctx, "Deployment", deployment.Namespace, deployment.Name); err == nil {
+		if stored, ok := existing.(*api.Deployment); ok {
+			deployment.Status.Canary = stored.Status.Canary
+			deployment.Status.BlueGreen = stored.Status.BlueGreen
+		}
+	}
+	if err := s.store.Update(ctx, &deployment); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deployment)
+}
+
+// deleteDeployment handles deployment deletion
+func (s *Server) deleteDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "Deployment", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// createReplicaSet handles replicaset creation
+func (s *Server) createReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+
+	var replicaSet api.ReplicaSet
+	if err := json.NewDecoder(r.Body).Decode(&replicaSet); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set metadata
+	if err := populateMetadata(&replicaSet.TypeMeta, &replicaSet.ObjectMeta, "ReplicaSet", namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validation.ReplicaSet(&replicaSet); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&replicaSet); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	if err := store.CreateWithGeneratedName(ctx, s.store, &replicaSet, &replicaSet.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(replicaSet)
+}
+
+// getReplicaSet handles replicaset retrieval
+func (s *Server) getReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	replicaSet, err := s.store.Get(r.Context(), "ReplicaSet", vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(replicaSet)
+}
+
+// listReplicaSets handles replicaset listing
+func (s *Server) listReplicaSets(w http.ResponseWriter, r *http.Request) {
+	s.listWorkloads(w, r, "ReplicaSet", "ReplicaSetList")
+}
+
+// updateReplicaSet handles replicaset updates
+func (s *Server) updateReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var replicaSet api.ReplicaSet
+	if err := json.NewDecoder(r.Body).Decode(&replicaSet); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set metadata
+	replicaSet.Kind = "ReplicaSet"
+	replicaSet.APIVersion = "v1alpha1"
+	replicaSet.Namespace = vars["namespace"]
+	replicaSet.Name = vars["name"]
+	if err := validation.ReplicaSet(&replicaSet); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.admitImages(&replicaSet); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	if err := s.preserveUID(ctx, "ReplicaSet", &replicaSet.ObjectMeta); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := s.store.Update(ctx, &replicaSet); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(replicaSet)
+}
+
+// deleteReplicaSet handles replicaset deletion
+func (s *Server) deleteReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.store.Delete(r.Context(), "ReplicaSet", vars["namespace"], vars["name"]); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// listWorkloads lists the objects of kind in the request's namespace
+func (s *Server) listWorkloads(w http.ResponseWriter, r *http.Request, kind, listKind string) {
+	vars := mux.Vars(r)
+
+	objs, err := s.listObjects(r, kind, vars["namespace"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	if vars["namespace"] == "" {
+		objs = allowedObjects(r, objs)
+	}
+
+	writeList(w, listKind, objs)
+}
+
+// listObjects lists the objects of kind in namespace, only those owned by
+// the object with the UID given by ?ownerUID= if it's set. Owned objects are
+// found through the store's owner index instead of listing the whole kind.
+func (s *Server) listObjects(r *http.Request, kind, namespace string) ([]store.Object, error) {
+	if ownerUID := r.URL.Query().Get("ownerUID"); ownerUID != "" {
+		return store.ListByIndex(r.Context(), s.store, kind, namespace, store.IndexOwner, ownerUID)
+	}
+	return s.store.List(r.Context(), kind, namespace)
+}
+
+// listOwned returns a handler listing the objects of kind owned by the
+// ownerKind object named in the request, such as a deployment's replicasets
+func (s *Server) listOwned(ownerKind, kind, listKind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		ctx := r.Context()
+
+		owner, err := s.store.Get(ctx, ownerKind, vars["namespace"], vars["name"])
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		objs, err := store.ListByIndex(ctx, s.store, kind, vars["namespace"], store.IndexOwner, owner.GetUID())
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+
+		writeList(w, listKind, objs)
+	}
+}
+
+// getDeploymentScale handles reads of a deployment's scale subresource
+func (s *Server) getDeploymentScale(w http.ResponseWriter, r *http.Request) {
+	s.serveScale(w, r, "Deployment", nil)
+}
+
+// updateDeploymentScale handles writes to a deployment's scale subresource
+func (s *Server) updateDeploymentScale(w http.ResponseWriter, r *http.Request) {
+	s.updateScale(w, r, "Deployment")
+}
+
+// getReplicaSetScale handles reads of a replicaset's scale subresource
+func (s *Server) getReplicaSetScale(w http.ResponseWriter, r *http.Request) {
+	s.serveScale(w, r, "ReplicaSet", nil)
+}
+
+// updateReplicaSetScale handles writes to a replicaset's scale subresource
+func (s *Server) updateReplicaSetScale(w http.ResponseWriter, r *http.Request) {
+	s.updateScale(w, r, "ReplicaSet")
+}
+
+// updateScale sets the replica count of a deployment or replicaset from a
+// Scale, leaving the rest of its spec untouched
+func (s *Server) updateScale(w http.ResponseWriter, r *http.Request, kind string) {
+	var scale api.Scale
+	if err := json.NewDecoder(r.Body).Decode(&scale); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if scale.Spec.Replicas < 0 {
+		http.Error(w, "spec.replicas must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	s.serveScale(w, r, kind, &scale.Spec.Replicas)
+}
+
+// serveScale responds with the Scale of a deployment or replicaset. When
+// replicas is set, the object is first updated to that replica count.
+func (s *Server) serveScale(w http.ResponseWriter, r *http.Request, kind string, replicas *int32) {
+	vars := mux.Vars(r)
+	ctx := r.Context()
+
+	obj, err := s.store.Get(ctx, kind, vars["namespace"], vars["name"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	var scale *api.Scale
+	switch workload := obj.(type) {
+	case *api.Deployment:
+		if replicas != nil {
+			workload.Spec.Replicas = *replicas
+		}
+		scale = newScale(workload.ObjectMeta, workload.Spec.Replicas, workload.Status.Replicas, workload.Spec.Selector)
+	case *api.ReplicaSet:
+		if replicas != nil {
+			workload.Spec.Replicas = *replicas
+		}
+		scale = newScale(workload.ObjectMeta, workload.Spec.Replicas, workload.Status.Replicas, workload.Spec.Selector)
+	default:
+		http.Error(w, fmt.Sprintf("%s has no scale subresource", kind), http.StatusInternalServerError)
+		return
+	}
+
+	if replicas != nil {
+		if err := s.store.Update(ctx, obj); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		scale.ResourceVersion = obj.GetResourceVersion()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scale)
+}
+
+// newScale builds the Scale of a workload
+func newScale(meta api.ObjectMeta, specReplicas, statusReplicas int32, selector *api.LabelSelector) *api.Scale {
+	return &api.Scale{
+		TypeMeta: api.TypeMeta{Kind: "Scale", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{
+			Name:              meta.Name,
+			Namespace:         meta.Namespace,
+			UID:               meta.UID,
+			ResourceVersion:   meta.ResourceVersion,
+			CreationTimestamp: meta.CreationTimestamp,
+		},
+		Spec:   api.ScaleSpec{Replicas: specReplicas},
+		Status: api.ScaleStatus{Replicas: statusReplicas, Selector: formatSelector(selector)},
+	}
+}
+
+// formatSelector renders a label selector as sorted key=value pairs
+func formatSelector(selector *api.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(selector.MatchLabels))
+	for key, value := range selector.MatchLabels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
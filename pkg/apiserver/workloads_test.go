@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// listNames gets a list endpoint and returns the names of its items, sorted
+func listNames(t *testing.T, s *Server, path string) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d: %s", path, rec.Code, rec.Body.String())
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata api.ObjectMeta `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("GET %s: failed to decode list: %v", path, err)
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.Metadata.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestListOwnedObjects(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(store.NewMemoryStore(store.DefaultOptions()), 0)
+
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "deployment-uid"},
+	}
+	replicaSets := []*api.ReplicaSet{
+		{
+			TypeMeta: api.TypeMeta{Kind: "ReplicaSet", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "web-1", Namespace: "default", UID: "rs-1",
+				OwnerReferences: []api.OwnerReference{{Kind: "Deployment", Name: "web", UID: "deployment-uid"}}},
+		},
+		{
+			TypeMeta:   api.TypeMeta{Kind: "ReplicaSet", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "standalone", Namespace: "default", UID: "rs-2"},
+		},
+	}
+	pods := []*api.Pod{
+		{
+			TypeMeta: api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "web-1-a", Namespace: "default",
+				OwnerReferences: []api.OwnerReference{{Kind: "ReplicaSet", Name: "web-1", UID: "rs-1"}}},
+		},
+		{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "bare", Namespace: "default"},
+		},
+	}
+	objs := []store.Object{deployment}
+	for _, rs := range replicaSets {
+		objs = append(objs, rs)
+	}
+	for _, pod := range pods {
+		objs = append(objs, pod)
+	}
+	for _, obj := range objs {
+		if err := s.store.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		path     string
+		expected []string
+	}{
+		{"/api/v1alpha1/namespaces/default/replicasets", []string{"standalone", "web-1"}},
+		{"/api/v1alpha1/namespaces/default/replicasets?ownerUID=deployment-uid", []string{"web-1"}},
+		{"/api/v1alpha1/namespaces/default/pods?ownerUID=rs-1", []string{"web-1-a"}},
+		{"/api/v1alpha1/namespaces/default/pods?ownerUID=unknown", []string{}},
+		{"/api/v1alpha1/namespaces/default/deployments/web/replicasets", []string{"web-1"}},
+		{"/api/v1alpha1/namespaces/default/replicasets/web-1/pods", []string{"web-1-a"}},
+		{"/api/v1alpha1/namespaces/default/replicasets/standalone/pods", []string{}},
+	} {
+		names := listNames(t, s, tc.path)
+		if len(names) != len(tc.expected) {
+			t.Errorf("GET %s: expected %v, got %v", tc.path, tc.expected, names)
+			continue
+		}
+		for i := range names {
+			if names[i] != tc.expected[i] {
+				t.Errorf("GET %s: expected %v, got %v", tc.path, tc.expected, names)
+				break
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/namespaces/default/deployments/missing/replicasets", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the replicasets of a missing deployment, got %d", rec.Code)
+	}
+}
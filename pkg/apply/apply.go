@@ -0,0 +1,91 @@
+// Package apply implements the client side of declarative object management:
+// the manifest last applied to an object is kept in an annotation on it, and
+// the next apply merges the new manifest into the live object three ways so
+// that fields dropped from the manifest are removed rather than left behind.
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LastAppliedAnnotation holds the manifest an object was last created or
+// applied from
+const LastAppliedAnnotation = "minik8s.io/last-applied-configuration"
+
+// SetLastApplied records manifest in its own last-applied annotation. The
+// recorded copy leaves out the annotation itself.
+func SetLastApplied(manifest map[string]interface{}) error {
+	metadata, ok := manifest["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = make(map[string]interface{})
+		manifest["metadata"] = metadata
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		annotations = make(map[string]interface{})
+	}
+	delete(annotations, LastAppliedAnnotation)
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	} else {
+		metadata["annotations"] = annotations
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	annotations[LastAppliedAnnotation] = string(data)
+	metadata["annotations"] = annotations
+	return nil
+}
+
+// GetLastApplied returns the manifest recorded on a live object, or nil if it
+// was never applied
+func GetLastApplied(live map[string]interface{}) (map[string]interface{}, error) {
+	metadata, _ := live["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	data, ok := annotations[LastAppliedAnnotation].(string)
+	if !ok || data == "" {
+		return nil, nil
+	}
+
+	var original map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &original); err != nil {
+		return nil, fmt.Errorf("failed to decode %s annotation: %w", LastAppliedAnnotation, err)
+	}
+	return original, nil
+}
+
+// ThreeWayMerge folds the changes from original to modified into live and
+// returns the result. Fields in modified overwrite live ones; fields that
+// were in original but are gone from modified are removed from live; fields
+// neither manifest mentions, such as those set by the server or controllers,
+// are kept. Nested objects are merged field by field, lists are replaced
+// whole. original may be nil when the object was never applied.
+func ThreeWayMerge(original, modified, live map[string]interface{}) map[string]interface{} {
+	if live == nil {
+		live = make(map[string]interface{})
+	}
+
+	for key := range original {
+		if _, ok := modified[key]; !ok {
+			delete(live, key)
+		}
+	}
+
+	for key, modifiedValue := range modified {
+		modifiedMap, modifiedIsMap := modifiedValue.(map[string]interface{})
+		liveMap, liveIsMap := live[key].(map[string]interface{})
+		if modifiedIsMap && liveIsMap {
+			originalMap, _ := original[key].(map[string]interface{})
+			live[key] = ThreeWayMerge(originalMap, modifiedMap, liveMap)
+			continue
+		}
+		live[key] = modifiedValue
+	}
+
+	return live
+}
@@ -0,0 +1,108 @@
+package apply
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &obj); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	return obj
+}
+
+func TestThreeWayMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+		live     string
+		want     string
+	}{
+		{
+			name:     "removed field is deleted",
+			original: `{"metadata":{"labels":{"app":"web","tier":"front"}}}`,
+			modified: `{"metadata":{"labels":{"app":"web"}}}`,
+			live:     `{"metadata":{"labels":{"app":"web","tier":"front"}}}`,
+			want:     `{"metadata":{"labels":{"app":"web"}}}`,
+		},
+		{
+			name:     "fields set by others are kept",
+			original: `{"spec":{"replicas":2}}`,
+			modified: `{"spec":{"replicas":3}}`,
+			live:     `{"metadata":{"uid":"abc"},"spec":{"replicas":2,"paused":true},"status":{"replicas":2}}`,
+			want:     `{"metadata":{"uid":"abc"},"spec":{"replicas":3,"paused":true},"status":{"replicas":2}}`,
+		},
+		{
+			name:     "never applied removes nothing",
+			original: `null`,
+			modified: `{"spec":{"replicas":1}}`,
+			live:     `{"spec":{"replicas":2,"minReadySeconds":5}}`,
+			want:     `{"spec":{"replicas":1,"minReadySeconds":5}}`,
+		},
+		{
+			name:     "lists are replaced",
+			original: `{"spec":{"containers":[{"name":"a"},{"name":"b"}]}}`,
+			modified: `{"spec":{"containers":[{"name":"a"}]}}`,
+			live:     `{"spec":{"containers":[{"name":"a"},{"name":"b"}]}}`,
+			want:     `{"spec":{"containers":[{"name":"a"}]}}`,
+		},
+		{
+			name:     "removed nested object is deleted",
+			original: `{"spec":{"nodeSelector":{"disk":"ssd"}}}`,
+			modified: `{"spec":{}}`,
+			live:     `{"spec":{"nodeSelector":{"disk":"ssd"},"nodeName":"node-1"}}`,
+			want:     `{"spec":{"nodeName":"node-1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original map[string]interface{}
+			if tt.original != "null" {
+				original = decode(t, tt.original)
+			}
+			got := ThreeWayMerge(original, decode(t, tt.modified), decode(t, tt.live))
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("ThreeWayMerge() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLastApplied(t *testing.T) {
+	manifest := decode(t, `{"kind":"Pod","metadata":{"name":"web","annotations":{"team":"a"}}}`)
+	if err := SetLastApplied(manifest); err != nil {
+		t.Fatalf("SetLastApplied: %v", err)
+	}
+
+	annotations := manifest["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if annotations["team"] != "a" {
+		t.Errorf("Expected existing annotations to be kept, got %v", annotations)
+	}
+
+	recorded, err := GetLastApplied(manifest)
+	if err != nil {
+		t.Fatalf("GetLastApplied: %v", err)
+	}
+	want := decode(t, `{"kind":"Pod","metadata":{"name":"web","annotations":{"team":"a"}}}`)
+	if !reflect.DeepEqual(recorded, want) {
+		t.Errorf("GetLastApplied() = %v, want %v", recorded, want)
+	}
+
+	// Applying again records the manifest, not the previous annotation
+	if err := SetLastApplied(manifest); err != nil {
+		t.Fatalf("SetLastApplied: %v", err)
+	}
+	if recorded, _ = GetLastApplied(manifest); !reflect.DeepEqual(recorded, want) {
+		t.Errorf("GetLastApplied() after reapplying = %v, want %v", recorded, want)
+	}
+
+	if recorded, err := GetLastApplied(decode(t, `{"metadata":{}}`)); err != nil || recorded != nil {
+		t.Errorf("Expected nothing for an object never applied, got %v, %v", recorded, err)
+	}
+}
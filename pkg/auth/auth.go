@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// Well-known users and groups
+const (
+	AnonymousUser                = "system:anonymous"
+	UnauthenticatedGroup         = "system:unauthenticated"
+	AuthenticatedGroup           = "system:authenticated"
+	ServiceAccountUsernamePrefix = "system:serviceaccount:"
+	ServiceAccountsGroup         = "system:serviceaccounts"
+)
+
+// ErrNoToken is returned when a request carries no bearer token
+var ErrNoToken = errors.New("no bearer token")
+
+// UserInfo describes an authenticated user
+type UserInfo struct {
+	Name   string
+	UID    string
+	Groups []string
+	// Namespaces limits the user to objects in these namespaces; nil allows all
+	Namespaces []string
+}
+
+// CanAccessNamespace reports whether the user may access objects in namespace
+func (u *UserInfo) CanAccessNamespace(namespace string) bool {
+	if u.Namespaces == nil {
+		return true
+	}
+	for _, allowed := range u.Namespaces {
+		if allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAny reports whether the user is named in subjects, or is in a group
+// named in them
+func (u *UserInfo) IsAny(subjects []string) bool {
+	for _, subject := range subjects {
+		if subject == u.Name {
+			return true
+		}
+		for _, group := range u.Groups {
+			if subject == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authenticator authenticates bearer tokens
+type Authenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (*UserInfo, error)
+}
+
+type userKey struct{}
+
+// WithUser returns a context carrying the user
+func WithUser(ctx context.Context, user *UserInfo) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the user stored in the context, if any
+func UserFrom(ctx context.Context) (*UserInfo, bool) {
+	user, ok := ctx.Value(userKey{}).(*UserInfo)
+	return user, ok
+}
+
+// ServiceAccountUsername returns the username of a service account
+func ServiceAccountUsername(namespace, name string) string {
+	return ServiceAccountUsernamePrefix + namespace + ":" + name
+}
+
+// BearerToken extracts the bearer token from a request
+func BearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", ErrNoToken
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("malformed authorization header")
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// CertificateUser returns the user a verified client certificate
+// authenticates as: its common name, in its organizations
+func CertificateUser(cert *x509.Certificate) *UserInfo {
+	groups := append([]string(nil), cert.Subject.Organization...)
+	return &UserInfo{
+		Name:   cert.Subject.CommonName,
+		Groups: append(groups, AuthenticatedGroup),
+	}
+}
+
+// Middleware authenticates requests, storing the user in the request context.
+// Requests without a token are authenticated by their client certificate if
+// the TLS handshake verified one, and otherwise let through as the anonymous
+// user when allowAnonymous is set; requests with an invalid token are always
+// rejected.
+func Middleware(authenticator Authenticator, allowAnonymous bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := BearerToken(r)
+			if errors.Is(err, ErrNoToken) && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+				user := CertificateUser(r.TLS.VerifiedChains[0][0])
+				next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+				return
+			}
+			if errors.Is(err, ErrNoToken) && allowAnonymous {
+				anonymous := &UserInfo{Name: AnonymousUser, Groups: []string{UnauthenticatedGroup}}
+				next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), anonymous)))
+				return
+			}
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			user, err := authenticator.AuthenticateToken(r.Context(), token)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// ServiceAccountAuthenticator authenticates service account tokens. Besides the
+// signature it checks that the service account, and the pod the token is bound
+// to, still exist so tokens are revoked when either is deleted.
+type ServiceAccountAuthenticator struct {
+	signer *TokenSigner
+	store  store.Store
+}
+
+// NewServiceAccountAuthenticator creates a service account token authenticator
+func NewServiceAccountAuthenticator(signer *TokenSigner, store store.Store) *ServiceAccountAuthenticator {
+	return &ServiceAccountAuthenticator{signer: signer, store: store}
+}
+
+// AuthenticateToken validates a service account token
+func (a *ServiceAccountAuthenticator) AuthenticateToken(ctx context.Context, token string) (*UserInfo, error) {
+	claims, err := a.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.NodeName != "" {
+		return nil, fmt.Errorf("token belongs to node %s, not a service account", claims.NodeName)
+	}
+
+	obj, err := a.store.Get(ctx, "ServiceAccount", claims.Namespace, claims.ServiceAccountName)
+	if err != nil {
+		return nil, fmt.Errorf("service account %s/%s: %w", claims.Namespace, claims.ServiceAccountName, err)
+	}
+	if sa, ok := obj.(*api.ServiceAccount); !ok || sa.UID != claims.ServiceAccountUID {
+		return nil, fmt.Errorf("service account %s/%s has been recreated", claims.Namespace, claims.ServiceAccountName)
+	}
+
+	if claims.PodName != "" {
+		obj, err := a.store.Get(ctx, "Pod", claims.Namespace, claims.PodName)
+		if err != nil {
+			return nil, fmt.Errorf("pod %s/%s: %w", claims.Namespace, claims.PodName, err)
+		}
+		if pod, ok := obj.(*api.Pod); !ok || pod.UID != claims.PodUID {
+			return nil, fmt.Errorf("pod %s/%s has been recreated", claims.Namespace, claims.PodName)
+		}
+	}
+
+	return &UserInfo{
+		Name: ServiceAccountUsername(claims.Namespace, claims.ServiceAccountName),
+		UID:  claims.ServiceAccountUID,
+		Groups: []string{
+			ServiceAccountsGroup,
+			ServiceAccountsGroup + ":" + claims.Namespace,
+			AuthenticatedGroup,
+		},
+		Namespaces: claims.Namespaces,
+	}, nil
+}
+
+// NodeAuthenticator authenticates the credentials issued to joined nodes as
+// system:node:<name> in the system:nodes group
+type NodeAuthenticator struct {
+	signer *TokenSigner
+}
+
+// NewNodeAuthenticator creates a node credential authenticator
+func NewNodeAuthenticator(signer *TokenSigner) *NodeAuthenticator {
+	return &NodeAuthenticator{signer: signer}
+}
+
+// AuthenticateToken validates a node credential
+func (a *NodeAuthenticator) AuthenticateToken(ctx context.Context, token string) (*UserInfo, error) {
+	claims, err := a.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.NodeName == "" {
+		return nil, fmt.Errorf("token does not belong to a node")
+	}
+
+	return &UserInfo{
+		Name:   NodeUsername(claims.NodeName),
+		Groups: []string{NodesGroup, AuthenticatedGroup},
+	}, nil
+}
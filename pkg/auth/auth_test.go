@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServiceAccount(t *testing.T, s store.Store) *api.ServiceAccount {
+	sa := &api.ServiceAccount{
+		TypeMeta: api.TypeMeta{Kind: "ServiceAccount", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "builder",
+			Namespace: "default",
+			UID:       "sa-uid",
+		},
+	}
+	require.NoError(t, s.Create(context.Background(), sa))
+	return sa
+}
+
+func TestServiceAccountAuthenticator(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+	ctx := context.Background()
+
+	sa := newTestServiceAccount(t, s)
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "worker", Namespace: "default", UID: "pod-uid"},
+	}
+	require.NoError(t, s.Create(ctx, pod))
+
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+	authenticator := NewServiceAccountAuthenticator(signer, s)
+
+	token, err := signer.Sign(&Claims{
+		Namespace:          sa.Namespace,
+		ServiceAccountName: sa.Name,
+		ServiceAccountUID:  sa.UID,
+		PodName:            pod.Name,
+		PodUID:             pod.UID,
+		ExpiresAt:          time.Now().Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+
+	user, err := authenticator.AuthenticateToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:default:builder", user.Name)
+	assert.Contains(t, user.Groups, "system:serviceaccounts:default")
+
+	// Deleting the bound pod revokes the token
+	require.NoError(t, s.Delete(ctx, "Pod", "default", "worker"))
+	_, err = authenticator.AuthenticateToken(ctx, token)
+	assert.Error(t, err)
+}
+
+func TestServiceAccountAuthenticator_RecreatedServiceAccount(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+	ctx := context.Background()
+
+	sa := newTestServiceAccount(t, s)
+
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+	authenticator := NewServiceAccountAuthenticator(signer, s)
+
+	token, err := signer.Sign(&Claims{
+		Namespace:          sa.Namespace,
+		ServiceAccountName: sa.Name,
+		ServiceAccountUID:  "old-uid",
+	})
+	require.NoError(t, err)
+
+	_, err = authenticator.AuthenticateToken(ctx, token)
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+
+	sa := newTestServiceAccount(t, s)
+
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+	token, err := signer.Sign(&Claims{
+		Namespace:          sa.Namespace,
+		ServiceAccountName: sa.Name,
+		ServiceAccountUID:  sa.UID,
+	})
+	require.NoError(t, err)
+
+	var seen *UserInfo
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = UserFrom(r.Context())
+	})
+
+	tests := []struct {
+		name           string
+		header         string
+		allowAnonymous bool
+		expectedStatus int
+		expectedUser   string
+	}{
+		{"valid token", "Bearer " + token, false, http.StatusOK, "system:serviceaccount:default:builder"},
+		{"invalid token", "Bearer " + token + "x", true, http.StatusUnauthorized, ""},
+		{"malformed header", "Basic abc", true, http.StatusUnauthorized, ""},
+		{"anonymous allowed", "", true, http.StatusOK, AnonymousUser},
+		{"anonymous denied", "", false, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/pods", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			Middleware(NewServiceAccountAuthenticator(signer, s), tt.allowAnonymous)(handler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedUser != "" {
+				require.NotNil(t, seen)
+				assert.Equal(t, tt.expectedUser, seen.Name)
+			} else {
+				assert.Nil(t, seen)
+			}
+		})
+	}
+}
+
+func TestMiddleware_ClientCertificate(t *testing.T) {
+	caCert, caKey, err := GenerateCA("test-ca")
+	require.NoError(t, err)
+	request, _, err := GenerateCertificateRequest("alice", []string{"devs"}, nil)
+	require.NoError(t, err)
+	csr, err := ParseCertificateRequest(request)
+	require.NoError(t, err)
+	certPEM, err := SignCertificateRequest(caCert, caKey, csr, x509.ExtKeyUsageClientAuth, time.Hour)
+	require.NoError(t, err)
+	cert, err := parseCertificate(certPEM)
+	require.NoError(t, err)
+
+	var seen *UserInfo
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = UserFrom(r.Context())
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/pods", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	rec := httptest.NewRecorder()
+
+	Middleware(NewNodeAuthenticator(nil), false)(handler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "alice", seen.Name)
+	assert.Equal(t, []string{"devs", AuthenticatedGroup}, seen.Groups)
+}
+
+func TestServiceAccountAuthenticator_NamespaceScope(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+	ctx := context.Background()
+
+	sa := newTestServiceAccount(t, s)
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+	authenticator := NewServiceAccountAuthenticator(signer, s)
+
+	sign := func(namespaces []string) string {
+		token, err := signer.Sign(&Claims{
+			Namespace:          sa.Namespace,
+			ServiceAccountName: sa.Name,
+			ServiceAccountUID:  sa.UID,
+			Namespaces:         namespaces,
+			ExpiresAt:          time.Now().Add(time.Hour).Unix(),
+		})
+		require.NoError(t, err)
+		return token
+	}
+
+	user, err := authenticator.AuthenticateToken(ctx, sign([]string{"team-a"}))
+	require.NoError(t, err)
+	assert.True(t, user.CanAccessNamespace("team-a"))
+	assert.False(t, user.CanAccessNamespace("team-b"))
+	assert.False(t, user.CanAccessNamespace("default"))
+
+	user, err = authenticator.AuthenticateToken(ctx, sign(nil))
+	require.NoError(t, err)
+	assert.Nil(t, user.Namespaces)
+	assert.True(t, user.CanAccessNamespace("team-b"), "unscoped tokens reach every namespace")
+}
+
+func TestUserInfo_IsAny(t *testing.T) {
+	user := &UserInfo{Name: "alice", Groups: []string{"devs", AuthenticatedGroup}}
+
+	assert.True(t, user.IsAny([]string{"bob", "alice"}))
+	assert.True(t, user.IsAny([]string{"ops", "devs"}))
+	assert.False(t, user.IsAny([]string{"bob", "ops"}))
+	assert.False(t, user.IsAny(nil))
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Bootstrap token users and groups
+const (
+	BootstrapUsernamePrefix = "system:bootstrap:"
+	BootstrappersGroup      = "system:bootstrappers"
+	NodeUsernamePrefix      = "system:node:"
+	NodesGroup              = "system:nodes"
+)
+
+// bootstrapTokenPattern matches tokens of the form <id>.<secret>
+var bootstrapTokenPattern = regexp.MustCompile(`^([a-z0-9]{6})\.([a-z0-9]{16})$`)
+
+// bootstrapTokenChars are the characters tokens are made of
+const bootstrapTokenChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// BootstrapToken is a short-lived shared secret nodes use to join the cluster
+type BootstrapToken struct {
+	ID     string
+	Secret string
+	// Expires is when the token stops being accepted; zero never expires
+	Expires time.Time
+}
+
+// String returns the token as <id>.<secret>
+func (t *BootstrapToken) String() string {
+	return t.ID + "." + t.Secret
+}
+
+// GenerateBootstrapToken creates a random token valid for ttl; zero or
+// negative never expires
+func GenerateBootstrapToken(ttl time.Duration) (*BootstrapToken, error) {
+	id, err := randomString(6)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &BootstrapToken{ID: id, Secret: secret}
+	if ttl > 0 {
+		token.Expires = time.Now().Add(ttl).UTC().Truncate(time.Second)
+	}
+	return token, nil
+}
+
+// ParseBootstrapToken parses a token of the form <id>.<secret>
+func ParseBootstrapToken(value string) (*BootstrapToken, error) {
+	match := bootstrapTokenPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("bootstrap token must be 6 and 16 lowercase letters or digits joined by a dot")
+	}
+	return &BootstrapToken{ID: match[1], Secret: match[2]}, nil
+}
+
+// LoadBootstrapTokens reads a token file with one <id>.<secret>[,<expiry>]
+// line per token, the expiry in RFC 3339. Blank lines and # comments are skipped.
+func LoadBootstrapTokens(path string) ([]*BootstrapToken, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bootstrap tokens: %w", err)
+	}
+	defer f.Close()
+
+	var tokens []*BootstrapToken
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		value, expiry, _ := strings.Cut(text, ",")
+		token, err := ParseBootstrapToken(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if expiry = strings.TrimSpace(expiry); expiry != "" {
+			if token.Expires, err = time.Parse(time.RFC3339, expiry); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid expiry: %w", path, line, err)
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read bootstrap tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// FormatBootstrapToken renders a token as a line of a token file
+func FormatBootstrapToken(token *BootstrapToken) string {
+	if token.Expires.IsZero() {
+		return token.String()
+	}
+	return token.String() + "," + token.Expires.Format(time.RFC3339)
+}
+
+// BootstrapTokenAuthenticator authenticates bootstrap tokens as
+// system:bootstrap:<id> in the system:bootstrappers group
+type BootstrapTokenAuthenticator struct {
+	tokens map[string]*BootstrapToken
+	now    func() time.Time
+}
+
+// NewBootstrapTokenAuthenticator creates an authenticator accepting tokens
+func NewBootstrapTokenAuthenticator(tokens []*BootstrapToken) *BootstrapTokenAuthenticator {
+	a := &BootstrapTokenAuthenticator{tokens: make(map[string]*BootstrapToken), now: time.Now}
+	for _, token := range tokens {
+		a.tokens[token.ID] = token
+	}
+	return a
+}
+
+// AuthenticateToken validates a bootstrap token
+func (a *BootstrapTokenAuthenticator) AuthenticateToken(ctx context.Context, value string) (*UserInfo, error) {
+	token, err := ParseBootstrapToken(value)
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	known, ok := a.tokens[token.ID]
+	if !ok || subtle.ConstantTimeCompare([]byte(known.Secret), []byte(token.Secret)) != 1 {
+		return nil, fmt.Errorf("unknown bootstrap token %s", token.ID)
+	}
+	if !known.Expires.IsZero() && !a.now().Before(known.Expires) {
+		return nil, ErrTokenExpired
+	}
+
+	return &UserInfo{
+		Name:   BootstrapUsernamePrefix + token.ID,
+		Groups: []string{BootstrappersGroup, AuthenticatedGroup},
+	}, nil
+}
+
+// NodeUsername returns the username of a node
+func NodeUsername(nodeName string) string {
+	return NodeUsernamePrefix + nodeName
+}
+
+// NodeName returns the name of the node user authenticated as, and whether
+// user is a node at all
+func NodeName(user *UserInfo) (string, bool) {
+	if !strings.HasPrefix(user.Name, NodeUsernamePrefix) {
+		return "", false
+	}
+	for _, group := range user.Groups {
+		if group == NodesGroup {
+			return strings.TrimPrefix(user.Name, NodeUsernamePrefix), true
+		}
+	}
+	return "", false
+}
+
+// IsBootstrapper reports whether user authenticated with a bootstrap token
+func IsBootstrapper(user *UserInfo) bool {
+	for _, group := range user.Groups {
+		if group == BootstrappersGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// unionAuthenticator tries each authenticator in turn
+type unionAuthenticator []Authenticator
+
+// Union returns an authenticator accepting a token if any of authenticators does
+func Union(authenticators ...Authenticator) Authenticator {
+	return unionAuthenticator(authenticators)
+}
+
+// AuthenticateToken returns the user of the first authenticator accepting the
+// token, or the first error if none does
+func (u unionAuthenticator) AuthenticateToken(ctx context.Context, token string) (*UserInfo, error) {
+	var firstErr error
+	for _, a := range u {
+		user, err := a.AuthenticateToken(ctx, token)
+		if err == nil {
+			return user, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("no authenticators configured")
+	}
+	return nil, firstErr
+}
+
+// randomString returns n random characters from bootstrapTokenChars. Bytes
+// past the largest multiple of the alphabet size are skipped to avoid bias.
+func randomString(n int) (string, error) {
+	limit := 256 - 256%len(bootstrapTokenChars)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate token: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(out) < n {
+				out = append(out, bootstrapTokenChars[int(b)%len(bootstrapTokenChars)])
+			}
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapToken_GenerateAndParse(t *testing.T) {
+	token, err := GenerateBootstrapToken(time.Hour)
+	require.NoError(t, err)
+	assert.False(t, token.Expires.IsZero())
+
+	parsed, err := ParseBootstrapToken(token.String())
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, parsed.ID)
+	assert.Equal(t, token.Secret, parsed.Secret)
+
+	for _, value := range []string{"", "abcdef", "abcdef.short", "ABCDEF.0123456789abcdef", "abcdef.0123456789abcdef0"} {
+		_, err := ParseBootstrapToken(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLoadBootstrapTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	content := "# node tokens\nabcdef.0123456789abcdef\n\n" +
+		FormatBootstrapToken(&BootstrapToken{ID: "ghijkl", Secret: "0123456789abcdef", Expires: expires}) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	tokens, err := LoadBootstrapTokens(path)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.True(t, tokens[0].Expires.IsZero())
+	assert.Equal(t, "ghijkl", tokens[1].ID)
+	assert.True(t, expires.Equal(tokens[1].Expires))
+
+	require.NoError(t, os.WriteFile(path, []byte("abcdef.0123456789abcdef,tomorrow\n"), 0600))
+	_, err = LoadBootstrapTokens(path)
+	assert.Error(t, err)
+}
+
+func TestBootstrapTokenAuthenticator(t *testing.T) {
+	now := time.Now()
+	a := NewBootstrapTokenAuthenticator([]*BootstrapToken{
+		{ID: "abcdef", Secret: "0123456789abcdef"},
+		{ID: "expird", Secret: "0123456789abcdef", Expires: now.Add(-time.Minute)},
+	})
+	ctx := context.Background()
+
+	user, err := a.AuthenticateToken(ctx, "abcdef.0123456789abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, "system:bootstrap:abcdef", user.Name)
+	assert.True(t, IsBootstrapper(user))
+
+	_, err = a.AuthenticateToken(ctx, "abcdef.0123456789abcdeX")
+	assert.Error(t, err)
+	_, err = a.AuthenticateToken(ctx, "abcdef.aaaaaaaaaaaaaaaa")
+	assert.Error(t, err)
+	_, err = a.AuthenticateToken(ctx, "expird.0123456789abcdef")
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestNodeAuthenticator(t *testing.T) {
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+	a := Union(NewServiceAccountAuthenticator(signer, s), NewNodeAuthenticator(signer))
+	ctx := context.Background()
+
+	token, err := signer.Sign(&Claims{Subject: NodeUsername("worker-1"), NodeName: "worker-1"})
+	require.NoError(t, err)
+	user, err := a.AuthenticateToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "system:node:worker-1", user.Name)
+	assert.Contains(t, user.Groups, NodesGroup)
+	nodeName, isNode := NodeName(user)
+	assert.True(t, isNode)
+	assert.Equal(t, "worker-1", nodeName)
+
+	// Only members of the nodes group are nodes, whatever their name
+	_, isNode = NodeName(&UserInfo{Name: "system:node:worker-1", Groups: []string{AuthenticatedGroup}})
+	assert.False(t, isNode)
+
+	// Service account tokens aren't node credentials
+	saToken, err := signer.Sign(&Claims{Namespace: "default", ServiceAccountName: "builder"})
+	require.NoError(t, err)
+	_, err = NewNodeAuthenticator(signer).AuthenticateToken(ctx, saToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestTokenSigner_SignVerify(t *testing.T) {
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+
+	token, err := signer.Sign(&Claims{
+		Subject:            ServiceAccountUsername("default", "builder"),
+		Namespace:          "default",
+		ServiceAccountName: "builder",
+		ServiceAccountUID:  "sa-uid",
+		ExpiresAt:          time.Now().Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(token, "."), 3)
+
+	claims, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "minik8s", claims.Issuer)
+	assert.Equal(t, "system:serviceaccount:default:builder", claims.Subject)
+	assert.Equal(t, "sa-uid", claims.ServiceAccountUID)
+	assert.NotZero(t, claims.IssuedAt)
+}
+
+func TestTokenSigner_RejectsInvalidTokens(t *testing.T) {
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+
+	token, err := signer.Sign(&Claims{Namespace: "default", ServiceAccountName: "builder"})
+	require.NoError(t, err)
+
+	// Tampered payload
+	parts := strings.Split(token, ".")
+	tampered, err := signer.Sign(&Claims{Namespace: "kube-system", ServiceAccountName: "admin"})
+	require.NoError(t, err)
+	_, err = signer.Verify(parts[0] + "." + strings.Split(tampered, ".")[1] + "." + parts[2])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// Signed with another key
+	other, err := NewTokenSigner([]byte("fedcba9876543210fedcba9876543210"), "minik8s")
+	require.NoError(t, err)
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// Another issuer
+	otherIssuer, err := NewTokenSigner(testKey, "someone-else")
+	require.NoError(t, err)
+	_, err = otherIssuer.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidIssuer)
+
+	// Garbage
+	_, err = signer.Verify("not-a-token")
+	assert.ErrorIs(t, err, ErrMalformedToken)
+}
+
+func TestTokenSigner_Expiry(t *testing.T) {
+	signer, err := NewTokenSigner(testKey, "minik8s")
+	require.NoError(t, err)
+
+	token, err := signer.Sign(&Claims{
+		Namespace:          "default",
+		ServiceAccountName: "builder",
+		ExpiresAt:          time.Now().Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	signer.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err = signer.Verify(token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestNewTokenSigner_ShortKey(t *testing.T) {
+	_, err := NewTokenSigner([]byte("short"), "minik8s")
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// Certificate lifetimes used by GenerateCA and GenerateServingCert, and the
+// longest SignCertificateRequest issues
+const (
+	CAValidity      = 10 * 365 * 24 * time.Hour
+	ServingValidity = 365 * 24 * time.Hour
+)
+
+// caCertHashPrefix prefixes the hex digest returned by CACertHash
+const caCertHashPrefix = "sha256:"
+
+// GenerateCA creates a self-signed CA, returning its PEM certificate and key
+func GenerateCA(commonName string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	template, err := certificateTemplate(commonName, CAValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	return encodeCertificate(der, key)
+}
+
+// GenerateServingCert creates a server certificate for hosts, which may be
+// DNS names or IP addresses, signed by the CA
+func GenerateServingCert(caCertPEM, caKeyPEM []byte, hosts []string) (certPEM, keyPEM []byte, err error) {
+	caCert, caKey, err := parseKeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serving key: %w", err)
+	}
+
+	template, err := certificateTemplate(hosts[0], ServingValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create serving certificate: %w", err)
+	}
+	return encodeCertificate(der, key)
+}
+
+// GenerateCertificateRequest creates a key and a PEM certificate request
+// for commonName in organizations, valid for hosts, which may be DNS names
+// or IP addresses
+func GenerateCertificateRequest(commonName string, organizations, hosts []string) (csrPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName, Organization: organizations}}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	csrPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return csrPEM, keyPEM, nil
+}
+
+// ParseCertificateRequest decodes a PEM certificate request and checks its
+// signature
+func ParseCertificateRequest(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("no PEM certificate request found")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	return csr, nil
+}
+
+// SignCertificateRequest issues a certificate for csr's subject, names and
+// key signed by the CA, usable for usage and valid for validity, capped at
+// ServingValidity
+func SignCertificateRequest(caCertPEM, caKeyPEM []byte, csr *x509.CertificateRequest, usage x509.ExtKeyUsage, validity time.Duration) ([]byte, error) {
+	caCert, caKey, err := parseKeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if validity <= 0 || validity > ServingValidity {
+		validity = ServingValidity
+	}
+
+	template, err := certificateTemplate(csr.Subject.CommonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.Subject = csr.Subject
+	template.DNSNames = csr.DNSNames
+	template.IPAddresses = csr.IPAddresses
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	if template.NotAfter.After(caCert.NotAfter) {
+		template.NotAfter = caCert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// CACertHash returns the sha256:<hex> digest of a PEM certificate's public
+// key, which joining nodes use to pin the cluster CA
+func CACertHash(certPEM []byte) (string, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return caCertHashPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyCACertHash checks that a PEM certificate matches a hash returned by
+// CACertHash
+func VerifyCACertHash(certPEM []byte, hash string) error {
+	if !strings.HasPrefix(hash, caCertHashPrefix) {
+		return fmt.Errorf("CA certificate hash must start with %s", caCertHashPrefix)
+	}
+	actual, err := CACertHash(certPEM)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, hash) {
+		return fmt.Errorf("CA certificate hash %s does not match %s", actual, hash)
+	}
+	return nil
+}
+
+// certificateTemplate returns a template with a random serial number
+func certificateTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// encodeCertificate PEM-encodes a DER certificate and its key
+func encodeCertificate(der []byte, key *ecdsa.PrivateKey) (certPEM, keyPEM []byte, err error) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// parseCertificate decodes the first certificate of a PEM bundle
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parseKeyPair decodes a PEM certificate and its EC private key
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM private key found")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return cert, key, nil
+}
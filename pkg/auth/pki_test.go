@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPKI(t *testing.T) {
+	caCert, caKey, err := GenerateCA("test-ca")
+	require.NoError(t, err)
+	servingCert, _, err := GenerateServingCert(caCert, caKey, []string{"master.lab", "10.0.0.5"})
+	require.NoError(t, err)
+
+	cert, err := parseCertificate(servingCert)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"master.lab"}, cert.DNSNames)
+	require.Len(t, cert.IPAddresses, 1)
+	assert.Equal(t, "10.0.0.5", cert.IPAddresses[0].String())
+	ca, err := parseCertificate(caCert)
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(ca))
+
+	hash, err := CACertHash(caCert)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyCACertHash(caCert, hash))
+	otherCA, _, err := GenerateCA("other-ca")
+	require.NoError(t, err)
+	assert.Error(t, VerifyCACertHash(otherCA, hash))
+	assert.Error(t, VerifyCACertHash(caCert, "md5:abc"))
+}
+
+func TestSignCertificateRequest(t *testing.T) {
+	caCert, caKey, err := GenerateCA("test-ca")
+	require.NoError(t, err)
+	csrPEM, _, err := GenerateCertificateRequest("system:node:node-1", []string{NodesGroup}, []string{"node-1.lab", "10.0.0.7"})
+	require.NoError(t, err)
+
+	csr, err := ParseCertificateRequest(csrPEM)
+	require.NoError(t, err)
+	assert.Equal(t, "system:node:node-1", csr.Subject.CommonName)
+
+	certPEM, err := SignCertificateRequest(caCert, caKey, csr, x509.ExtKeyUsageServerAuth, time.Hour)
+	require.NoError(t, err)
+	cert, err := parseCertificate(certPEM)
+	require.NoError(t, err)
+	ca, err := parseCertificate(caCert)
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(ca))
+	assert.Equal(t, "system:node:node-1", cert.Subject.CommonName)
+	assert.Equal(t, []string{NodesGroup}, cert.Subject.Organization)
+	assert.Equal(t, []string{"node-1.lab"}, cert.DNSNames)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), cert.NotAfter, time.Minute)
+
+	_, err = ParseCertificateRequest([]byte("not a request"))
+	assert.Error(t, err)
+	_, err = ParseCertificateRequest(caCert)
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// HorizontalPodAutoscalerController scales deployments and replicasets on
+// external metrics. Each metric asks for its value divided by its target
+// average value, rounded up, and the target gets the most any metric asks
+// for within the autoscaler's bounds. Autoscalers with minReplicas 0 scale
+// their target to zero once no metric has shown demand for
+// scaleToZeroAfterSeconds, and back up as soon as one does.
+type HorizontalPodAutoscalerController struct {
+	store   store.Store
+	name    string
+	metrics ExternalMetricsAdapter
+	now     func() time.Time
+}
+
+// NewHorizontalPodAutoscalerController creates an autoscaler controller
+// reading metrics from adapter
+func NewHorizontalPodAutoscalerController(store store.Store, adapter ExternalMetricsAdapter) *HorizontalPodAutoscalerController {
+	return &HorizontalPodAutoscalerController{
+		store:   store,
+		name:    "horizontal-pod-autoscaler-controller",
+		metrics: adapter,
+		now:     time.Now,
+	}
+}
+
+// Name returns the name of the controller
+func (h *HorizontalPodAutoscalerController) Name() string {
+	return h.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (h *HorizontalPodAutoscalerController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (h *HorizontalPodAutoscalerController) Stop() error {
+	return nil
+}
+
+// Sync scales the target of every autoscaler once
+func (h *HorizontalPodAutoscalerController) Sync(ctx context.Context) error {
+	objs, err := h.store.List(ctx, "HorizontalPodAutoscaler", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list horizontal pod autoscalers: %w", err)
+	}
+
+	for _, obj := range objs {
+		hpa, ok := obj.(*api.HorizontalPodAutoscaler)
+		if !ok {
+			continue
+		}
+		if err := h.syncAutoscaler(ctx, hpa); err != nil {
+			fmt.Printf("Failed to sync horizontal pod autoscaler %s/%s: %v\n", hpa.Namespace, hpa.Name, err)
+		}
+	}
+	return nil
+}
+
+// syncAutoscaler reads the autoscaler's metrics and scales its target
+func (h *HorizontalPodAutoscalerController) syncAutoscaler(ctx context.Context, hpa *api.HorizontalPodAutoscaler) error {
+	spec := &hpa.Spec
+	target, err := h.store.Get(ctx, spec.ScaleTargetRef.Kind, hpa.Namespace, spec.ScaleTargetRef.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get %s %s: %w", spec.ScaleTargetRef.Kind, spec.ScaleTargetRef.Name, err)
+	}
+	replicas, ok := targetReplicas(target)
+	if !ok {
+		return fmt.Errorf("%s %s can't be scaled", spec.ScaleTargetRef.Kind, spec.ScaleTargetRef.Name)
+	}
+
+	status := hpa.Status
+	status.CurrentReplicas = *replicas
+	status.CurrentMetrics = nil
+
+	// A metric that can't be read leaves the target alone rather than
+	// scaling it on partial information
+	var desired int32
+	for _, metric := range spec.Metrics {
+		if metric.External == nil {
+			continue
+		}
+		value, err := h.metrics.GetExternalMetric(ctx, hpa.Namespace, metric.External.MetricName, metric.External.MetricSelector)
+		if err != nil {
+			return err
+		}
+		status.CurrentMetrics = append(status.CurrentMetrics, api.ExternalMetricStatus{
+			MetricName:   metric.External.MetricName,
+			CurrentValue: value,
+		})
+		wanted := int32((value + metric.External.TargetAverageValue - 1) / metric.External.TargetAverageValue)
+		if wanted > desired {
+			desired = wanted
+		}
+	}
+
+	now := h.now()
+	if desired > 0 {
+		status.LastActiveTime = &now
+	}
+
+	minReplicas := int32(1)
+	if spec.MinReplicas != nil {
+		minReplicas = *spec.MinReplicas
+	}
+	if desired == 0 && minReplicas == 0 {
+		// Scale to zero only once demand has stayed away for a while, so a
+		// momentarily empty queue doesn't throw away warm pods
+		idle := time.Duration(spec.ScaleToZeroAfterSeconds) * time.Second
+		if idle == 0 {
+			idle = api.DefaultScaleToZeroAfterSeconds * time.Second
+		}
+		lastActive := hpa.CreationTimestamp
+		if status.LastActiveTime != nil {
+			lastActive = *status.LastActiveTime
+		}
+		if *replicas > 0 && now.Sub(lastActive) < idle {
+			desired = 1
+		}
+	}
+	if desired < minReplicas {
+		desired = minReplicas
+	}
+	if desired > spec.MaxReplicas {
+		desired = spec.MaxReplicas
+	}
+	status.DesiredReplicas = desired
+
+	if desired != *replicas {
+		if *replicas == 0 {
+			fmt.Printf("Activating %s %s from zero replicas\n", spec.ScaleTargetRef.Kind, spec.ScaleTargetRef.Name)
+		}
+		fmt.Printf("Scaling %s %s from %d to %d replicas\n", spec.ScaleTargetRef.Kind, spec.ScaleTargetRef.Name, *replicas, desired)
+		*replicas = desired
+		if err := h.store.Update(ctx, target); err != nil {
+			return fmt.Errorf("failed to scale %s %s: %w", spec.ScaleTargetRef.Kind, spec.ScaleTargetRef.Name, err)
+		}
+		status.CurrentReplicas = desired
+		status.LastScaleTime = &now
+	}
+
+	if reflect.DeepEqual(hpa.Status, status) {
+		return nil
+	}
+	hpa.Status = status
+	if err := h.store.Update(ctx, hpa); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
+
+// targetReplicas returns a pointer to the replica count of a scale target
+func targetReplicas(obj store.Object) (*int32, bool) {
+	switch target := obj.(type) {
+	case *api.Deployment:
+		return &target.Spec.Replicas, true
+	case *api.ReplicaSet:
+		return &target.Spec.Replicas, true
+	}
+	return nil, false
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestHorizontalPodAutoscalerScaleToZero(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	metrics := NewStaticMetricsAdapter()
+	ctrl := NewHorizontalPodAutoscalerController(mockStore, metrics)
+
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "worker", Namespace: "default"},
+		Spec:       api.DeploymentSpec{Replicas: 1},
+	}
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	zero := int32(0)
+	hpa := &api.HorizontalPodAutoscaler{
+		TypeMeta:   api.TypeMeta{Kind: "HorizontalPodAutoscaler", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "worker", Namespace: "default"},
+		Spec: api.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: api.ScaleTargetReference{Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &zero,
+			MaxReplicas:    5,
+			Metrics: []api.MetricSpec{{
+				Type:     api.MetricSourceExternal,
+				External: &api.ExternalMetricSource{MetricName: "queue-length", TargetAverageValue: 10},
+			}},
+			ScaleToZeroAfterSeconds: 60,
+		},
+	}
+	if err := mockStore.Create(ctx, hpa); err != nil {
+		t.Fatalf("Failed to create autoscaler: %v", err)
+	}
+
+	replicas := func() int32 {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "Deployment", "default", "worker")
+		if err != nil {
+			t.Fatalf("Failed to get deployment: %v", err)
+		}
+		return obj.(*api.Deployment).Spec.Replicas
+	}
+
+	// A metric that can't be read leaves the deployment alone
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := replicas(); got != 1 {
+		t.Fatalf("Expected 1 replica without metrics, got %d", got)
+	}
+
+	for _, step := range []struct {
+		queue int64
+		after time.Duration
+		want  int32
+	}{
+		{25, 0, 3},
+		{100, time.Second, 5},
+		// An empty queue keeps one warm replica until it's been idle long enough
+		{0, time.Second, 1},
+		{0, 30 * time.Second, 1},
+		{0, 31 * time.Second, 0},
+		// Demand activates the deployment again
+		{1, time.Second, 1},
+	} {
+		metrics.Set("default", "queue-length", step.queue)
+		now = now.Add(step.after)
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		if got := replicas(); got != step.want {
+			t.Fatalf("Expected %d replicas for a queue of %d, got %d", step.want, step.queue, got)
+		}
+	}
+}
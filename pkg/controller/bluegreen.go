@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// isBlueGreen reports whether the deployment rolls out with the BlueGreen
+// strategy
+func isBlueGreen(deployment *api.Deployment) bool {
+	strategy := deployment.Spec.Strategy
+	return strategy != nil && strategy.Type == api.DeploymentStrategyBlueGreen && strategy.BlueGreen != nil
+}
+
+// templateHash returns the value of the LabelTemplateHash label for
+// ReplicaSets running template
+func templateHash(template *api.PodTemplateSpec) string {
+	encoded, _ := json.Marshal(template)
+	h := fnv.New32a()
+	h.Write(encoded)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// newBlueGreenReplicaSet returns a ReplicaSet running the deployment's
+// template whose selector and pods also carry the template hash, so it
+// doesn't adopt the pods of the other color
+func newBlueGreenReplicaSet(deployment *api.Deployment, hash string) *api.ReplicaSet {
+	withHash := func(labels map[string]string) map[string]string {
+		result := make(map[string]string, len(labels)+1)
+		for key, value := range labels {
+			result[key] = value
+		}
+		result[api.LabelTemplateHash] = hash
+		return result
+	}
+
+	rs := newReplicaSet(deployment)
+	rs.Labels = withHash(deployment.Spec.Selector.MatchLabels)
+	rs.Spec.Selector = &api.LabelSelector{MatchLabels: withHash(deployment.Spec.Selector.MatchLabels)}
+	rs.Spec.Template.Labels = withHash(deployment.Spec.Template.Labels)
+	return rs
+}
+
+// syncBlueGreen rolls out a BlueGreen deployment. A template change brings
+// up a full-size new ReplicaSet next to the active one; once all of its
+// replicas are available and the rollout is promoted, the active Service's
+// selector is switched to it in a single update and the old ReplicaSet is
+// scaled down.
+func (d *DeploymentController) syncBlueGreen(ctx context.Context, deployment *api.Deployment, state *DeploymentState) error {
+	replicaSets, err := d.ownedReplicaSets(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	hash := templateHash(&deployment.Spec.Template)
+	byName := make(map[string]*api.ReplicaSet)
+	var newRS *api.ReplicaSet
+	for _, rs := range replicaSets {
+		byName[rs.Name] = rs
+		if rs.Labels[api.LabelTemplateHash] == hash {
+			newRS = rs
+		}
+	}
+	if newRS == nil {
+		newRS = newBlueGreenReplicaSet(deployment, hash)
+		if err := store.CreateWithGeneratedName(ctx, d.store, newRS, &newRS.ObjectMeta); err != nil {
+			return fmt.Errorf("failed to create replicaset: %w", err)
+		}
+		fmt.Printf("Created ReplicaSet %s for deployment %s\n", newRS.Name, deployment.Name)
+		byName[newRS.Name] = newRS
+		replicaSets = append(replicaSets, newRS)
+	}
+	state.ReplicaSet = newRS
+
+	blueGreen := &api.BlueGreenStatus{}
+	if deployment.Status.BlueGreen != nil {
+		copied := *deployment.Status.BlueGreen
+		blueGreen = &copied
+	}
+	if byName[blueGreen.ActiveReplicaSet] == nil {
+		// Adopt the ReplicaSet running the most replicas, or the new one on
+		// the first rollout
+		active := newRS
+		for _, rs := range replicaSets {
+			if rs != newRS && rs.Spec.Replicas > 0 && (active == newRS || rs.Spec.Replicas > active.Spec.Replicas) {
+				active = rs
+			}
+		}
+		blueGreen.ActiveReplicaSet = active.Name
+	}
+	preview := ""
+	if newRS.Name != blueGreen.ActiveReplicaSet {
+		preview = newRS.Name
+	}
+	if preview != blueGreen.PreviewReplicaSet {
+		// A promotion only applies to the template it was given for
+		blueGreen.PreviewReplicaSet = preview
+		blueGreen.Promoted = false
+	}
+
+	total := deployment.Spec.Replicas
+	for _, rs := range replicaSets {
+		replicas := int32(0)
+		if rs.Name == blueGreen.ActiveReplicaSet || rs.Name == preview {
+			replicas = total
+		}
+		if err := d.scaleReplicaSet(ctx, rs, replicas); err != nil {
+			return err
+		}
+	}
+
+	pods, err := d.podsByReplicaSet(ctx, deployment.Namespace)
+	if err != nil {
+		return err
+	}
+	var allPods []*api.Pod
+	for _, rs := range replicaSets {
+		allPods = append(allPods, pods[rs.Name]...)
+	}
+	newPods := pods[newRS.Name]
+	state.Pods = newPods
+
+	now := d.now()
+	_, newAvailable := countReadyPods(newPods, deployment.Spec.MinReadySeconds, now)
+	_, available := countReadyPods(allPods, deployment.Spec.MinReadySeconds, now)
+
+	strategy := deployment.Spec.Strategy.BlueGreen
+	pause := ""
+	if preview != "" && newAvailable >= total {
+		switch {
+		case deployment.Spec.Paused:
+			pause = "Deployment is paused"
+		case !blueGreen.Promoted && !strategy.AutoPromote:
+			pause = fmt.Sprintf("Blue/green rollout is waiting with %d replicas available on %s; promote it to switch service %s over",
+				newAvailable, preview, strategy.ActiveService)
+		default:
+			if err := d.selectReplicaSet(ctx, deployment.Namespace, strategy.ActiveService, newRS); err != nil {
+				return err
+			}
+			fmt.Printf("Deployment %s switched service %s to ReplicaSet %s\n", deployment.Name, strategy.ActiveService, newRS.Name)
+			if err := d.scaleReplicaSet(ctx, byName[blueGreen.ActiveReplicaSet], 0); err != nil {
+				return err
+			}
+			blueGreen.ActiveReplicaSet = newRS.Name
+			blueGreen.PreviewReplicaSet = ""
+			blueGreen.Promoted = false
+		}
+	}
+
+	// Keep the Services pointed at the right colors in case they were
+	// created or edited after the last switch
+	if err := d.selectReplicaSet(ctx, deployment.Namespace, strategy.ActiveService, byName[blueGreen.ActiveReplicaSet]); err != nil {
+		fmt.Printf("Failed to update active service of deployment %s: %v\n", deployment.Name, err)
+	}
+	if strategy.PreviewService != "" {
+		previewRS := byName[blueGreen.ActiveReplicaSet]
+		if blueGreen.PreviewReplicaSet != "" {
+			previewRS = newRS
+		}
+		if err := d.selectReplicaSet(ctx, deployment.Namespace, strategy.PreviewService, previewRS); err != nil {
+			fmt.Printf("Failed to update preview service of deployment %s: %v\n", deployment.Name, err)
+		}
+	}
+
+	status := api.DeploymentStatus{
+		Replicas:          int32(len(allPods)),
+		UpdatedReplicas:   int32(len(newPods)),
+		AvailableReplicas: available,
+		Conditions:        append([]api.DeploymentCondition(nil), deployment.Status.Conditions...),
+		BlueGreen:         blueGreen,
+	}
+	if unavailable := total - available; unavailable > 0 {
+		status.UnavailableReplicas = unavailable
+	}
+	setDeploymentConditions(deployment, &status, now, pause)
+
+	if reflect.DeepEqual(deployment.Status, status) {
+		return nil
+	}
+	deployment.Status = status
+	if err := d.store.Update(ctx, deployment); err != nil {
+		return fmt.Errorf("failed to update deployment status: %w", err)
+	}
+	return nil
+}
+
+// selectReplicaSet points the named Service at the pods of rs by setting
+// the template hash in its selector. The selector is changed in one update,
+// so the Service never selects both colors or neither.
+func (d *DeploymentController) selectReplicaSet(ctx context.Context, namespace, name string, rs *api.ReplicaSet) error {
+	obj, err := d.store.Get(ctx, "Service", namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to get service %s: %w", name, err)
+	}
+	service, ok := obj.(*api.Service)
+	if !ok {
+		return fmt.Errorf("stored object %s is not a service", name)
+	}
+
+	hash, hashed := rs.Labels[api.LabelTemplateHash]
+	current, selected := service.Spec.Selector[api.LabelTemplateHash]
+	if hashed == selected && hash == current {
+		return nil
+	}
+
+	updated := *service
+	updated.Spec.Selector = make(map[string]string, len(service.Spec.Selector)+1)
+	for key, value := range service.Spec.Selector {
+		updated.Spec.Selector[key] = value
+	}
+	if hashed {
+		updated.Spec.Selector[api.LabelTemplateHash] = hash
+	} else {
+		// ReplicaSets adopted from before the deployment was blue/green
+		// carry no hash
+		delete(updated.Spec.Selector, api.LabelTemplateHash)
+	}
+	if err := d.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update service %s: %w", name, err)
+	}
+	return nil
+}
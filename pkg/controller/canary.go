@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// isCanary reports whether the deployment rolls out with the Canary strategy
+func isCanary(deployment *api.Deployment) bool {
+	strategy := deployment.Spec.Strategy
+	return strategy != nil && strategy.Type == api.DeploymentStrategyCanary && strategy.Canary != nil
+}
+
+// canaryWeight returns the share of replicas the new ReplicaSet gets at step:
+// the weight set by the last weight step reached, or all of them once every
+// step is done
+func canaryWeight(steps []api.CanaryStep, step int32) int32 {
+	if int(step) >= len(steps) {
+		return 100
+	}
+	var weight int32
+	for _, s := range steps[:step+1] {
+		if s.SetWeight != nil {
+			weight = *s.SetWeight
+		}
+	}
+	return weight
+}
+
+// canaryReplicas returns how many of total replicas weight percent is,
+// rounded up so any non-zero weight runs at least one new replica
+func canaryReplicas(total, weight int32) int32 {
+	return (total*weight + 99) / 100
+}
+
+// syncCanary rolls out a Canary deployment. The new ReplicaSet runs the
+// share of replicas set by the current step and the newest old one the rest.
+// Weight steps advance once the new replicas are available; pause steps wait
+// for the rollout to be promoted.
+func (d *DeploymentController) syncCanary(ctx context.Context, deployment *api.Deployment, state *DeploymentState) error {
+	replicaSets, err := d.ownedReplicaSets(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	newRS, oldRSs, err := d.splitReplicaSets(ctx, deployment, replicaSets)
+	if err != nil {
+		return err
+	}
+	state.ReplicaSet = newRS
+
+	var oldReplicas int32
+	for _, rs := range oldRSs {
+		oldReplicas += rs.Spec.Replicas
+	}
+
+	steps := deployment.Spec.Strategy.Canary.Steps
+	canary := deployment.Status.Canary
+	if canary == nil || canary.ReplicaSet != newRS.Name {
+		canary = &api.CanaryStatus{ReplicaSet: newRS.Name}
+		// The first rollout has nothing to be careful about
+		if oldReplicas == 0 {
+			canary.CurrentStep = int32(len(steps))
+		}
+	} else {
+		copied := *canary
+		canary = &copied
+	}
+
+	total := deployment.Spec.Replicas
+	canary.Weight = canaryWeight(steps, canary.CurrentStep)
+	newReplicas := canaryReplicas(total, canary.Weight)
+	if err := d.scaleReplicaSets(ctx, newRS, oldRSs, newReplicas, total-newReplicas); err != nil {
+		return err
+	}
+
+	pods, err := d.podsByReplicaSet(ctx, deployment.Namespace)
+	if err != nil {
+		return err
+	}
+	newPods := pods[newRS.Name]
+	allPods := append([]*api.Pod(nil), newPods...)
+	for _, rs := range oldRSs {
+		allPods = append(allPods, pods[rs.Name]...)
+	}
+	state.Pods = newPods
+
+	now := d.now()
+	_, newAvailable := countReadyPods(newPods, deployment.Spec.MinReadySeconds, now)
+	_, available := countReadyPods(allPods, deployment.Spec.MinReadySeconds, now)
+
+	// Weight steps are done once the new replicas have proven themselves
+	pause := ""
+	if step := canary.CurrentStep; int(step) < len(steps) {
+		switch {
+		case deployment.Spec.Paused:
+			pause = "Deployment is paused"
+		case steps[step].Pause:
+			pause = fmt.Sprintf("Canary rollout is paused at step %d of %d with %d%% of replicas on %s; promote it to continue",
+				step+1, len(steps), canary.Weight, newRS.Name)
+		case newAvailable >= newReplicas:
+			canary.CurrentStep++
+			fmt.Printf("Deployment %s canary step %d of %d done\n", deployment.Name, step+1, len(steps))
+		}
+	}
+
+	status := api.DeploymentStatus{
+		Replicas:          int32(len(allPods)),
+		UpdatedReplicas:   int32(len(newPods)),
+		AvailableReplicas: available,
+		Conditions:        append([]api.DeploymentCondition(nil), deployment.Status.Conditions...),
+		Canary:            canary,
+	}
+	if unavailable := total - available; unavailable > 0 {
+		status.UnavailableReplicas = unavailable
+	}
+	setDeploymentConditions(deployment, &status, now, pause)
+
+	if reflect.DeepEqual(deployment.Status, status) {
+		return nil
+	}
+	deployment.Status = status
+	if err := d.store.Update(ctx, deployment); err != nil {
+		return fmt.Errorf("failed to update deployment status: %w", err)
+	}
+	return nil
+}
+
+// ownedReplicaSets returns the ReplicaSets created for the deployment
+func (d *DeploymentController) ownedReplicaSets(ctx context.Context, deployment *api.Deployment) ([]*api.ReplicaSet, error) {
+	objs, err := d.store.List(ctx, "ReplicaSet", deployment.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+
+	var owned []*api.ReplicaSet
+	for _, obj := range objs {
+		rs, ok := obj.(*api.ReplicaSet)
+		if !ok {
+			continue
+		}
+		for _, ref := range rs.OwnerReferences {
+			if ref.Kind == "Deployment" && ref.Name == deployment.Name && ref.UID == deployment.UID {
+				owned = append(owned, rs)
+				break
+			}
+		}
+	}
+	return owned, nil
+}
+
+// podsByReplicaSet returns the pods in namespace by the name of the
+// ReplicaSet that owns them
+func (d *DeploymentController) podsByReplicaSet(ctx context.Context, namespace string) (map[string][]*api.Pod, error) {
+	objs, err := d.store.List(ctx, "Pod", namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	pods := make(map[string][]*api.Pod)
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if !ok {
+			continue
+		}
+		for _, ref := range pod.OwnerReferences {
+			if ref.Kind == "ReplicaSet" {
+				pods[ref.Name] = append(pods[ref.Name], pod)
+				break
+			}
+		}
+	}
+	return pods, nil
+}
+
+// splitReplicaSets finds the ReplicaSet running the deployment's current
+// template among replicaSets, creating it with no replicas if there's none,
+// and returns it along with the others
+func (d *DeploymentController) splitReplicaSets(ctx context.Context, deployment *api.Deployment, replicaSets []*api.ReplicaSet) (*api.ReplicaSet, []*api.ReplicaSet, error) {
+	hash := templateHash(&deployment.Spec.Template)
+	var newRS *api.ReplicaSet
+	var oldRSs []*api.ReplicaSet
+	for _, rs := range replicaSets {
+		if newRS == nil && rs.Annotations[api.LabelTemplateHash] == hash {
+			newRS = rs
+			continue
+		}
+		oldRSs = append(oldRSs, rs)
+	}
+	if newRS != nil {
+		return newRS, oldRSs, nil
+	}
+
+	newRS = newReplicaSet(deployment)
+	newRS.Spec.Replicas = 0
+	if err := store.CreateWithGeneratedName(ctx, d.store, newRS, &newRS.ObjectMeta); err != nil {
+		return nil, nil, fmt.Errorf("failed to create replicaset: %w", err)
+	}
+	fmt.Printf("Created ReplicaSet %s for deployment %s\n", newRS.Name, deployment.Name)
+	return newRS, oldRSs, nil
+}
+
+// scaleReplicaSets gives the new ReplicaSet newReplicas and the old one with
+// the most replicas oldReplicas, scaling any other old ReplicaSets down
+func (d *DeploymentController) scaleReplicaSets(ctx context.Context, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet, newReplicas, oldReplicas int32) error {
+	var stable *api.ReplicaSet
+	for _, rs := range oldRSs {
+		if stable == nil || rs.Spec.Replicas > stable.Spec.Replicas {
+			stable = rs
+		}
+	}
+
+	if err := d.scaleReplicaSet(ctx, newRS, newReplicas); err != nil {
+		return err
+	}
+	for _, rs := range oldRSs {
+		replicas := int32(0)
+		if rs == stable {
+			replicas = oldReplicas
+		}
+		if err := d.scaleReplicaSet(ctx, rs, replicas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scaleReplicaSet sets the replica count of rs, leaving the pods to the
+// ReplicaSet controller
+func (d *DeploymentController) scaleReplicaSet(ctx context.Context, rs *api.ReplicaSet, replicas int32) error {
+	if rs.Spec.Replicas == replicas {
+		return nil
+	}
+	rs.Spec.Replicas = replicas
+	if err := d.store.Update(ctx, rs); err != nil {
+		return fmt.Errorf("failed to scale replicaset %s: %w", rs.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// CertificateSignerController issues the certificates of approved
+// CertificateSigningRequests, signed by the cluster CA. Requests it can't
+// issue get a Failed condition instead.
+type CertificateSignerController struct {
+	store     store.Store
+	name      string
+	caCertPEM []byte
+	caKeyPEM  []byte
+	now       func() time.Time
+}
+
+// NewCertificateSignerController creates a controller signing with the
+// PEM-encoded CA certificate and key
+func NewCertificateSignerController(store store.Store, caCertPEM, caKeyPEM []byte) *CertificateSignerController {
+	return &CertificateSignerController{
+		store:     store,
+		name:      "certificate-signer-controller",
+		caCertPEM: caCertPEM,
+		caKeyPEM:  caKeyPEM,
+		now:       time.Now,
+	}
+}
+
+// Name returns the name of the controller
+func (c *CertificateSignerController) Name() string {
+	return c.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (c *CertificateSignerController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (c *CertificateSignerController) Stop() error {
+	return nil
+}
+
+// Sync issues the certificates of approved requests not issued yet
+func (c *CertificateSignerController) Sync(ctx context.Context) error {
+	objs, err := c.store.List(ctx, "CertificateSigningRequest", "")
+	if err != nil {
+		return fmt.Errorf("failed to list certificate signing requests: %w", err)
+	}
+
+	for _, obj := range objs {
+		csr, ok := obj.(*api.CertificateSigningRequest)
+		if !ok || !pendingIssue(csr) {
+			continue
+		}
+
+		updated := *csr
+		updated.Status.Conditions = slices.Clone(csr.Status.Conditions)
+		certPEM, err := c.sign(csr)
+		if err != nil {
+			updated.Status.Conditions = append(updated.Status.Conditions, api.CertificateSigningRequestCondition{
+				Type:           api.CertificateFailed,
+				Status:         api.ConditionTrue,
+				Reason:         "SigningFailed",
+				Message:        err.Error(),
+				LastUpdateTime: c.now(),
+			})
+			fmt.Printf("Failed to issue certificate for %s: %v\n", csr.Name, err)
+		} else {
+			updated.Status.Certificate = string(certPEM)
+			fmt.Printf("Issued certificate for %s to %s\n", csr.Name, csr.Spec.Username)
+		}
+
+		if err := c.store.Update(ctx, &updated); err != nil {
+			fmt.Printf("Failed to update certificate signing request %s: %v\n", csr.Name, err)
+		}
+	}
+	return nil
+}
+
+// pendingIssue reports whether csr is approved but neither issued, denied
+// nor failed
+func pendingIssue(csr *api.CertificateSigningRequest) bool {
+	return csr.Status.Certificate == "" &&
+		csr.CertificateCondition(api.CertificateApproved) != nil &&
+		csr.CertificateCondition(api.CertificateDenied) == nil &&
+		csr.CertificateCondition(api.CertificateFailed) == nil
+}
+
+// sign checks csr against the rules of its signer and issues its
+// certificate
+func (c *CertificateSignerController) sign(csr *api.CertificateSigningRequest) ([]byte, error) {
+	request, err := auth.ParseCertificateRequest([]byte(csr.Spec.Request))
+	if err != nil {
+		return nil, err
+	}
+
+	// Certificates naming a node may only be issued to that node, so one
+	// node can't impersonate another
+	_, isNode := auth.NodeName(&auth.UserInfo{Name: request.Subject.CommonName, Groups: request.Subject.Organization})
+	if isNode && csr.Spec.Username != request.Subject.CommonName {
+		return nil, fmt.Errorf("certificates for %s may only be requested by the node itself, not %q", request.Subject.CommonName, csr.Spec.Username)
+	}
+
+	var usage x509.ExtKeyUsage
+	switch csr.Spec.SignerName {
+	case api.SignerAPIServerClient:
+		usage = x509.ExtKeyUsageClientAuth
+	case api.SignerNodeServing:
+		if !isNode || !slices.Equal(request.Subject.Organization, []string{auth.NodesGroup}) {
+			return nil, fmt.Errorf("node serving certificates must be for %s<name> in organization %s", auth.NodeUsernamePrefix, auth.NodesGroup)
+		}
+		usage = x509.ExtKeyUsageServerAuth
+	default:
+		return nil, fmt.Errorf("unknown signer %q", csr.Spec.SignerName)
+	}
+
+	validity := time.Duration(csr.Spec.ExpirationSeconds) * time.Second
+	return auth.SignCertificateRequest(c.caCertPEM, c.caKeyPEM, request, usage, validity)
+}
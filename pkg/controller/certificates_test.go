@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestCertificateSignerController(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	caCert, caKey, err := auth.GenerateCA("test-ca")
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+	ctrl := NewCertificateSignerController(mockStore, caCert, caKey)
+
+	ctx := context.Background()
+	createCSR := func(name, signer, commonName string, organizations []string, username string, conditions ...string) {
+		request, _, err := auth.GenerateCertificateRequest(commonName, organizations, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate certificate request: %v", err)
+		}
+		csr := &api.CertificateSigningRequest{
+			TypeMeta:   api.TypeMeta{Kind: "CertificateSigningRequest", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Spec: api.CertificateSigningRequestSpec{
+				Request:    string(request),
+				SignerName: signer,
+				Username:   username,
+			},
+		}
+		for _, condition := range conditions {
+			csr.Status.Conditions = append(csr.Status.Conditions, api.CertificateSigningRequestCondition{Type: condition, Status: "True"})
+		}
+		if err := mockStore.Create(ctx, csr); err != nil {
+			t.Fatalf("Failed to create certificate signing request: %v", err)
+		}
+	}
+
+	createCSR("alice", api.SignerAPIServerClient, "alice", []string{"devs"}, "admin", api.CertificateApproved)
+	createCSR("node-1", api.SignerNodeServing, "system:node:node-1", []string{auth.NodesGroup}, "system:node:node-1", api.CertificateApproved)
+	createCSR("pending", api.SignerAPIServerClient, "bob", nil, "admin")
+	createCSR("denied", api.SignerAPIServerClient, "carol", nil, "admin", api.CertificateDenied)
+	createCSR("impersonating", api.SignerNodeServing, "system:node:node-2", []string{auth.NodesGroup}, "system:node:node-1", api.CertificateApproved)
+	createCSR("not-a-node", api.SignerNodeServing, "dave", nil, "dave", api.CertificateApproved)
+
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	get := func(name string) *api.CertificateSigningRequest {
+		obj, err := mockStore.Get(ctx, "CertificateSigningRequest", "", name)
+		if err != nil {
+			t.Fatalf("Failed to get certificate signing request %s: %v", name, err)
+		}
+		return obj.(*api.CertificateSigningRequest)
+	}
+	for _, name := range []string{"alice", "node-1"} {
+		if get(name).Status.Certificate == "" {
+			t.Errorf("Expected a certificate to be issued for %s", name)
+		}
+	}
+	for _, name := range []string{"pending", "denied"} {
+		csr := get(name)
+		if csr.Status.Certificate != "" || csr.CertificateCondition(api.CertificateFailed) != nil {
+			t.Errorf("Expected %s to be left alone, got %+v", name, csr.Status)
+		}
+	}
+	for _, name := range []string{"impersonating", "not-a-node"} {
+		csr := get(name)
+		if csr.Status.Certificate != "" || csr.CertificateCondition(api.CertificateFailed) == nil {
+			t.Errorf("Expected %s to fail, got %+v", name, csr.Status)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const (
+	// DefaultClusterAutoscalerMaxNodes is how many nodes the cluster
+	// autoscaler provisions at most
+	DefaultClusterAutoscalerMaxNodes = 5
+	// DefaultScaleUpDelay is how long a pod must stay unschedulable before a
+	// node is added for it, giving the scheduler time to retry
+	DefaultScaleUpDelay = 30 * time.Second
+	// DefaultScaleDownDelay is how long a provisioned node must stay empty
+	// before it's removed
+	DefaultScaleDownDelay = 10 * time.Minute
+	// DefaultNodeProvisionTimeout is how long a new node may take to become
+	// Ready before it's given up on and removed
+	DefaultNodeProvisionTimeout = 5 * time.Minute
+)
+
+// NodeProvisioner creates and deletes nodes for the cluster autoscaler.
+// Provisioners for new kinds of machines implement it and are passed to
+// NewClusterAutoscalerController.
+type NodeProvisioner interface {
+	// CreateNode starts a new node and returns its name. The node registers
+	// itself with the cluster, so it may not exist yet when this returns.
+	CreateNode(ctx context.Context) (string, error)
+	// DeleteNode stops a node created by CreateNode and removes it from
+	// the cluster
+	DeleteNode(ctx context.Context, name string) error
+	// Nodes returns the names of the nodes created by CreateNode that
+	// haven't been deleted
+	Nodes(ctx context.Context) ([]string, error)
+}
+
+// ClusterAutoscalerConfig holds the cluster autoscaler's parameters
+type ClusterAutoscalerConfig struct {
+	// MinNodes is how many provisioned nodes are kept even when empty
+	MinNodes int
+	// MaxNodes is how many nodes are provisioned at most
+	MaxNodes int
+	// ScaleUpDelay is how long a pod must be unschedulable before a node is
+	// added
+	ScaleUpDelay time.Duration
+	// ScaleDownDelay is how long a provisioned node must be empty before
+	// it's removed
+	ScaleDownDelay time.Duration
+	// ProvisionTimeout is how long a new node may take to become Ready
+	ProvisionTimeout time.Duration
+}
+
+// ClusterAutoscalerController adds nodes through a NodeProvisioner while
+// pods stay unschedulable and removes provisioned nodes that sit empty.
+// Nodes are added one at a time: no node is added while the last one is
+// still coming up, so the scheduler gets to place pods on it first. Only
+// nodes the provisioner created are ever removed.
+type ClusterAutoscalerController struct {
+	store       store.Store
+	name        string
+	provisioner NodeProvisioner
+	config      ClusterAutoscalerConfig
+	// started holds when each provisioned node that hasn't become Ready yet
+	// was created
+	started map[string]time.Time
+	// emptySince holds when each provisioned node was first seen without pods
+	emptySince map[string]time.Time
+	// lastReady is when a provisioned node last became Ready; the scheduler
+	// gets ScaleUpDelay to place pods on it before another one is added
+	lastReady time.Time
+	now       func() time.Time
+}
+
+// NewClusterAutoscalerController creates a cluster autoscaler adding and
+// removing nodes through provisioner. Zero config fields take their
+// defaults.
+func NewClusterAutoscalerController(store store.Store, provisioner NodeProvisioner, config ClusterAutoscalerConfig) *ClusterAutoscalerController {
+	if config.MaxNodes <= 0 {
+		config.MaxNodes = DefaultClusterAutoscalerMaxNodes
+	}
+	if config.MinNodes > config.MaxNodes {
+		config.MinNodes = config.MaxNodes
+	}
+	if config.ScaleUpDelay == 0 {
+		config.ScaleUpDelay = DefaultScaleUpDelay
+	}
+	if config.ScaleDownDelay == 0 {
+		config.ScaleDownDelay = DefaultScaleDownDelay
+	}
+	if config.ProvisionTimeout == 0 {
+		config.ProvisionTimeout = DefaultNodeProvisionTimeout
+	}
+
+	return &ClusterAutoscalerController{
+		store:       store,
+		name:        "cluster-autoscaler-controller",
+		provisioner: provisioner,
+		config:      config,
+		started:     make(map[string]time.Time),
+		emptySince:  make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Name returns the name of the controller
+func (c *ClusterAutoscalerController) Name() string {
+	return c.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (c *ClusterAutoscalerController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (c *ClusterAutoscalerController) Stop() error {
+	return nil
+}
+
+// Sync adds a node if pods have been unschedulable for a while and removes
+// provisioned nodes that have been empty for a while
+func (c *ClusterAutoscalerController) Sync(ctx context.Context) error {
+	provisioned, err := c.provisioner.Nodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list provisioned nodes: %w", err)
+	}
+	sort.Strings(provisioned)
+
+	nodeObjs, err := c.store.List(ctx, "Node", "")
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodes := make(map[string]*api.Node, len(nodeObjs))
+	for _, obj := range nodeObjs {
+		if node, ok := obj.(*api.Node); ok {
+			nodes[node.Name] = node
+		}
+	}
+
+	podObjs, err := c.store.List(ctx, "Pod", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	now := c.now()
+	podsOnNode := make(map[string]int)
+	unschedulable := 0
+	for _, obj := range podObjs {
+		pod, ok := obj.(*api.Pod)
+		if !ok {
+			continue
+		}
+		if pod.Spec.NodeName != "" {
+			if pod.Status.Phase != api.PodSucceeded && pod.Status.Phase != api.PodFailed {
+				podsOnNode[pod.Spec.NodeName]++
+			}
+			continue
+		}
+		condition := api.GetPodCondition(&pod.Status, api.PodConditionScheduled)
+		if condition != nil && condition.Status == api.ConditionFalse && condition.Reason == api.ReasonUnschedulable &&
+			now.Sub(condition.LastTransitionTime) >= c.config.ScaleUpDelay {
+			unschedulable++
+		}
+	}
+
+	// Forget nodes that are gone, and find those still coming up
+	current := make(map[string]bool, len(provisioned))
+	starting := 0
+	var remaining []string
+	for _, name := range provisioned {
+		current[name] = true
+		var ready *api.NodeCondition
+		if node, ok := nodes[name]; ok {
+			ready = nodeCondition(node, api.NodeReady)
+		}
+		if ready != nil && ready.Status == api.ConditionTrue {
+			if _, ok := c.started[name]; ok {
+				delete(c.started, name)
+				c.lastReady = now
+			}
+			remaining = append(remaining, name)
+			continue
+		}
+		delete(c.emptySince, name)
+		startedAt, ok := c.started[name]
+		if !ok {
+			startedAt = now
+			c.started[name] = now
+		}
+		if now.Sub(startedAt) < c.config.ProvisionTimeout {
+			starting++
+			remaining = append(remaining, name)
+			continue
+		}
+		fmt.Printf("Node %s did not become ready within %v, removing it\n", name, c.config.ProvisionTimeout)
+		if err := c.deleteNode(ctx, name); err != nil {
+			fmt.Printf("Failed to remove node %s: %v\n", name, err)
+			remaining = append(remaining, name)
+		}
+	}
+	for name := range c.started {
+		if !current[name] {
+			delete(c.started, name)
+		}
+	}
+	for name := range c.emptySince {
+		if !current[name] {
+			delete(c.emptySince, name)
+		}
+	}
+
+	waited := now.Sub(c.lastReady) >= c.config.ScaleUpDelay
+	if starting == 0 && len(remaining) < c.config.MaxNodes && (unschedulable > 0 && waited || len(remaining) < c.config.MinNodes) {
+		name, err := c.provisioner.CreateNode(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to provision node: %w", err)
+		}
+		c.started[name] = now
+		if unschedulable > 0 {
+			fmt.Printf("Provisioned node %s for %d unschedulable pods\n", name, unschedulable)
+		} else {
+			fmt.Printf("Provisioned node %s to reach %d nodes\n", name, c.config.MinNodes)
+		}
+		return nil
+	}
+
+	// Remove nodes that have been empty for long enough, down to the minimum
+	count := len(remaining)
+	for _, name := range remaining {
+		if _, ok := c.started[name]; ok {
+			continue
+		}
+		if podsOnNode[name] > 0 {
+			delete(c.emptySince, name)
+			continue
+		}
+		emptySince, ok := c.emptySince[name]
+		if !ok {
+			c.emptySince[name] = now
+			continue
+		}
+		if count <= c.config.MinNodes || now.Sub(emptySince) < c.config.ScaleDownDelay {
+			continue
+		}
+		if err := c.deleteNode(ctx, name); err != nil {
+			fmt.Printf("Failed to remove node %s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("Removed node %s, empty for %v\n", name, now.Sub(emptySince).Round(time.Second))
+		count--
+	}
+	return nil
+}
+
+// deleteNode removes a provisioned node and forgets about it
+func (c *ClusterAutoscalerController) deleteNode(ctx context.Context, name string) error {
+	if err := c.provisioner.DeleteNode(ctx, name); err != nil {
+		return err
+	}
+	delete(c.started, name)
+	delete(c.emptySince, name)
+	return nil
+}
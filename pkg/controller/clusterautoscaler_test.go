@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// fakeProvisioner registers a Ready node for every node it creates, unless
+// register is false
+type fakeProvisioner struct {
+	store    store.Store
+	register bool
+	nodes    map[string]bool
+	next     int
+	now      func() time.Time
+}
+
+func (p *fakeProvisioner) CreateNode(ctx context.Context) (string, error) {
+	p.next++
+	name := fmt.Sprintf("auto-%d", p.next)
+	p.nodes[name] = true
+	if !p.register {
+		return name, nil
+	}
+	return name, p.store.Create(ctx, newTestNode(name, p.now()))
+}
+
+func (p *fakeProvisioner) DeleteNode(ctx context.Context, name string) error {
+	delete(p.nodes, name)
+	if !p.register {
+		return nil
+	}
+	return p.store.Delete(ctx, "Node", "", name)
+}
+
+func (p *fakeProvisioner) Nodes(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range p.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func TestClusterAutoscaler(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	now := time.Now()
+	provisioner := &fakeProvisioner{store: mockStore, register: true, nodes: make(map[string]bool), now: func() time.Time { return now }}
+	ctrl := NewClusterAutoscalerController(mockStore, provisioner, ClusterAutoscalerConfig{
+		MaxNodes:       2,
+		ScaleUpDelay:   30 * time.Second,
+		ScaleDownDelay: 10 * time.Minute,
+	})
+	ctrl.now = func() time.Time { return now }
+
+	sync := func() {
+		t.Helper()
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+	provisioned := func() []string {
+		names, _ := provisioner.Nodes(ctx)
+		return names
+	}
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "big", Namespace: "default"},
+		Status: api.PodStatus{
+			Phase: api.PodPending,
+			Conditions: []api.PodCondition{{
+				Type:               api.PodConditionScheduled,
+				Status:             api.ConditionFalse,
+				Reason:             api.ReasonUnschedulable,
+				LastTransitionTime: now.Add(-10 * time.Second),
+			}},
+		},
+	}
+	if err := mockStore.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	// The scheduler gets ScaleUpDelay to place the pod first
+	sync()
+	if got := provisioned(); len(got) != 0 {
+		t.Fatalf("Expected no nodes before the scale up delay, got %v", got)
+	}
+
+	now = now.Add(time.Minute)
+	sync()
+	if got := provisioned(); len(got) != 1 {
+		t.Fatalf("Expected a node for the unschedulable pod, got %v", got)
+	}
+
+	// Once the node is Ready the scheduler gets another ScaleUpDelay
+	sync()
+	if got := provisioned(); len(got) != 1 {
+		t.Fatalf("Expected no second node right after the first became ready, got %v", got)
+	}
+
+	now = now.Add(time.Minute)
+	sync()
+	now = now.Add(time.Minute)
+	sync()
+	sync()
+	if got := provisioned(); len(got) != 2 {
+		t.Fatalf("Expected MaxNodes to cap provisioning at 2, got %v", got)
+	}
+
+	// The pod lands on the first node; the second stays empty and goes
+	pod.Spec.NodeName = "auto-1"
+	pod.Status.Phase = api.PodRunning
+	api.SetPodCondition(&pod.Status, api.PodCondition{Type: api.PodConditionScheduled, Status: api.ConditionTrue})
+	now = now.Add(5 * time.Minute)
+	sync()
+	if got := provisioned(); len(got) != 2 {
+		t.Fatalf("Expected the empty node to be kept before the scale down delay, got %v", got)
+	}
+
+	now = now.Add(10 * time.Minute)
+	sync()
+	if got := provisioned(); len(got) != 1 || got[0] != "auto-1" {
+		t.Fatalf("Expected only the busy node to be kept, got %v", got)
+	}
+	if _, err := mockStore.Get(ctx, "Node", "", "auto-2"); err == nil {
+		t.Error("Expected the removed node to be deleted")
+	}
+}
+
+func TestClusterAutoscaler_ProvisionTimeout(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	now := time.Now()
+	provisioner := &fakeProvisioner{store: mockStore, nodes: make(map[string]bool), now: func() time.Time { return now }}
+	ctrl := NewClusterAutoscalerController(mockStore, provisioner, ClusterAutoscalerConfig{
+		MinNodes:         1,
+		ProvisionTimeout: time.Minute,
+	})
+	ctrl.now = func() time.Time { return now }
+
+	// MinNodes is reached without any unschedulable pods
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, ok := provisioner.nodes["auto-1"]; !ok {
+		t.Fatalf("Expected a node to reach MinNodes, got %v", provisioner.nodes)
+	}
+
+	// A node that never registers is replaced after the timeout
+	now = now.Add(2 * time.Minute)
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, ok := provisioner.nodes["auto-1"]; ok {
+		t.Error("Expected the node that never became ready to be removed")
+	}
+	if _, ok := provisioner.nodes["auto-2"]; !ok {
+		t.Errorf("Expected a replacement node, got %v", provisioner.nodes)
+	}
+}
@@ -0,0 +1,325 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// maxMissedSchedules is how many runs a CronJob may have missed before the
+// controller gives up working out which is the latest; a starting deadline
+// bounds how far back it has to look
+const maxMissedSchedules = 100
+
+// CronJobController creates the Jobs of CronJobs as their schedules come
+// due, in the time zone of each CronJob, and deletes the oldest finished
+// Jobs beyond their history limits
+type CronJobController struct {
+	store    store.Store
+	name     string
+	recorder *events.Recorder
+	now      func() time.Time
+	// local is the time zone of schedules without spec.timeZone
+	local *time.Location
+}
+
+// NewCronJobController creates a new CronJob controller
+func NewCronJobController(store store.Store) *CronJobController {
+	return &CronJobController{
+		store: store,
+		name:  "cronjob-controller",
+		now:   time.Now,
+		local: time.Local,
+	}
+}
+
+// SetEventRecorder makes the controller record the jobs it creates and
+// the runs it skips as events on the CronJob
+func (c *CronJobController) SetEventRecorder(recorder *events.Recorder) {
+	c.recorder = recorder
+}
+
+// Name returns the name of the controller
+func (c *CronJobController) Name() string {
+	return c.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (c *CronJobController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (c *CronJobController) Stop() error {
+	return nil
+}
+
+// Sync starts the runs of all CronJobs that are due
+func (c *CronJobController) Sync(ctx context.Context) error {
+	objs, err := c.store.List(ctx, "CronJob", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+
+	now := c.now()
+	for _, obj := range objs {
+		cronJob, ok := obj.(*api.CronJob)
+		if !ok {
+			continue
+		}
+		if err := c.syncCronJob(ctx, cronJob, now); err != nil {
+			fmt.Printf("Error syncing cronjob %s/%s: %v\n", cronJob.Namespace, cronJob.Name, err)
+		}
+	}
+	return nil
+}
+
+// syncCronJob starts the latest run of cronJob that is due, if any, and
+// updates its status
+func (c *CronJobController) syncCronJob(ctx context.Context, cronJob *api.CronJob, now time.Time) error {
+	jobs, err := c.listJobs(ctx, cronJob)
+	if err != nil {
+		return err
+	}
+
+	status := cronJob.Status
+	status.Active = nil
+	var finished []*api.Job
+	for _, job := range jobs {
+		if !job.Finished() {
+			status.Active = append(status.Active, api.ObjectReference{Kind: "Job", Namespace: job.Namespace, Name: job.Name, UID: job.UID})
+			continue
+		}
+		finished = append(finished, job)
+		if completed := job.Status.CompletionTime; completed != nil && job.JobCondition(api.JobComplete) != nil &&
+			(status.LastSuccessfulTime == nil || completed.After(*status.LastSuccessfulTime)) {
+			status.LastSuccessfulTime = completed
+		}
+	}
+	c.cleanupHistory(ctx, cronJob, finished)
+
+	if err := c.startRun(ctx, cronJob, jobs, &status, now); err != nil {
+		fmt.Printf("Failed to start cronjob %s/%s: %v\n", cronJob.Namespace, cronJob.Name, err)
+	}
+
+	if reflect.DeepEqual(cronJob.Status, status) {
+		return nil
+	}
+	updated := *cronJob
+	updated.Status = status
+	if err := c.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update cronjob status: %w", err)
+	}
+	return nil
+}
+
+// startRun creates the job of the latest run of cronJob that is due, as
+// its concurrency policy allows, and records it in status
+func (c *CronJobController) startRun(ctx context.Context, cronJob *api.CronJob, jobs []*api.Job, status *api.CronJobStatus, now time.Time) error {
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+		return nil
+	}
+	loc, err := cronJob.Location(c.local)
+	if err != nil {
+		return err
+	}
+	schedule, err := api.ParseCronSchedule(cronJob.Spec.Schedule)
+	if err != nil {
+		return err
+	}
+
+	scheduled, err := latestScheduleTime(cronJob, schedule, loc, now)
+	if err != nil {
+		c.recorder.Eventf(ctx, cronJob, api.EventTypeWarning, "TooManyMissedTimes",
+			"%v; set spec.startingDeadlineSeconds to skip runs missed by too much", err)
+		return err
+	}
+	if scheduled.IsZero() {
+		return nil
+	}
+
+	switch cronJob.Spec.ConcurrencyPolicy {
+	case api.ConcurrencyForbid:
+		// The run is retried on later syncs until its starting deadline
+		// passes or a later run is due
+		if len(status.Active) > 0 {
+			fmt.Printf("Not starting cronjob %s/%s: %d jobs still active\n", cronJob.Namespace, cronJob.Name, len(status.Active))
+			return nil
+		}
+	case api.ConcurrencyReplace:
+		for _, job := range jobs {
+			if job.Finished() {
+				continue
+			}
+			if err := c.deleteJob(ctx, job); err != nil {
+				return err
+			}
+			c.recorder.Eventf(ctx, cronJob, api.EventTypeNormal, "SuccessfulDelete", "Deleted job %s", job.Name)
+		}
+		status.Active = nil
+	}
+
+	job, err := c.createJob(ctx, cronJob, scheduled)
+	if err != nil {
+		c.recorder.Eventf(ctx, cronJob, api.EventTypeWarning, "FailedCreate", "Error creating job: %v", err)
+		return err
+	}
+	status.Active = append(status.Active, api.ObjectReference{Kind: "Job", Namespace: job.Namespace, Name: job.Name, UID: job.UID})
+	status.LastScheduleTime = &scheduled
+	return nil
+}
+
+// latestScheduleTime returns the latest time cronJob was due to run since
+// its last run, or the zero time if it isn't due. Runs missed by more than
+// its starting deadline aren't considered.
+func latestScheduleTime(cronJob *api.CronJob, schedule *api.CronSchedule, loc *time.Location, now time.Time) (time.Time, error) {
+	earliest := cronJob.CreationTimestamp
+	if cronJob.Status.LastScheduleTime != nil {
+		earliest = *cronJob.Status.LastScheduleTime
+	}
+	if deadline := cronJob.Spec.StartingDeadlineSeconds; deadline != nil {
+		if start := now.Add(-time.Duration(*deadline) * time.Second); start.After(earliest) {
+			// Runs exactly at the deadline may still start
+			earliest = start.Add(-time.Nanosecond)
+		}
+	}
+
+	var latest time.Time
+	missed := 0
+	for t := schedule.Next(earliest, loc); !t.IsZero() && !t.After(now); t = schedule.Next(t, loc) {
+		latest = t
+		if missed++; missed > maxMissedSchedules {
+			return time.Time{}, fmt.Errorf("more than %d runs missed", maxMissedSchedules)
+		}
+	}
+	return latest, nil
+}
+
+// cronJobName names the job of the run of cronJob scheduled at scheduled,
+// so a run is never started twice
+func cronJobName(cronJob *api.CronJob, scheduled time.Time) string {
+	return fmt.Sprintf("%s-%d", cronJob.Name, scheduled.Unix()/60)
+}
+
+// createJob creates the job of the run of cronJob scheduled at scheduled
+func (c *CronJobController) createJob(ctx context.Context, cronJob *api.CronJob, scheduled time.Time) (*api.Job, error) {
+	template := cronJob.Spec.JobTemplate
+	job := &api.Job{
+		TypeMeta:   api.TypeMeta{Kind: "Job", APIVersion: "v1alpha1"},
+		ObjectMeta: template.ObjectMeta,
+		Spec:       template.Spec,
+	}
+	job.Name = cronJobName(cronJob, scheduled)
+	job.GenerateName = ""
+	job.Namespace = cronJob.Namespace
+	job.Labels = maps.Clone(template.Labels)
+	job.Annotations = maps.Clone(template.Annotations)
+	job.OwnerReferences = []api.OwnerReference{{
+		APIVersion: cronJob.APIVersion,
+		Kind:       "CronJob",
+		Name:       cronJob.Name,
+		UID:        cronJob.UID,
+	}}
+
+	if err := c.store.Create(ctx, job); err != nil {
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return job, nil
+		}
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	fmt.Printf("Created job %s for cronjob %s/%s scheduled at %s\n", job.Name, cronJob.Namespace, cronJob.Name, scheduled)
+	c.recorder.Eventf(ctx, cronJob, api.EventTypeNormal, "SuccessfulCreate", "Created job %s", job.Name)
+	return job, nil
+}
+
+// listJobs returns the jobs owned by cronJob
+func (c *CronJobController) listJobs(ctx context.Context, cronJob *api.CronJob) ([]*api.Job, error) {
+	var objs []store.Object
+	var err error
+	if cronJob.UID != "" {
+		objs, err = store.ListByIndex(ctx, c.store, "Job", cronJob.Namespace, store.IndexOwner, cronJob.UID)
+	} else {
+		objs, err = c.store.List(ctx, "Job", cronJob.Namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	var jobs []*api.Job
+	for _, obj := range objs {
+		job, ok := obj.(*api.Job)
+		if !ok {
+			continue
+		}
+		for _, ownerRef := range job.OwnerReferences {
+			if ownerRef.Kind == "CronJob" && ownerRef.Name == cronJob.Name {
+				jobs = append(jobs, job)
+				break
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// cleanupHistory deletes the oldest finished jobs of cronJob beyond its
+// history limits
+func (c *CronJobController) cleanupHistory(ctx context.Context, cronJob *api.CronJob, finished []*api.Job) {
+	successfulLimit, failedLimit := int32(api.DefaultSuccessfulJobsHistoryLimit), int32(api.DefaultFailedJobsHistoryLimit)
+	if limit := cronJob.Spec.SuccessfulJobsHistoryLimit; limit != nil {
+		successfulLimit = *limit
+	}
+	if limit := cronJob.Spec.FailedJobsHistoryLimit; limit != nil {
+		failedLimit = *limit
+	}
+
+	var successful, failed []*api.Job
+	for _, job := range finished {
+		if job.JobCondition(api.JobComplete) != nil {
+			successful = append(successful, job)
+		} else {
+			failed = append(failed, job)
+		}
+	}
+	for _, history := range []struct {
+		jobs  []*api.Job
+		limit int32
+	}{{successful, successfulLimit}, {failed, failedLimit}} {
+		sort.Slice(history.jobs, func(i, j int) bool {
+			return history.jobs[i].CreationTimestamp.Before(history.jobs[j].CreationTimestamp)
+		})
+		for i := 0; i < len(history.jobs)-int(max(history.limit, 0)); i++ {
+			if err := c.deleteJob(ctx, history.jobs[i]); err != nil {
+				fmt.Printf("Failed to delete job %s/%s of cronjob %s: %v\n", history.jobs[i].Namespace, history.jobs[i].Name, cronJob.Name, err)
+			}
+		}
+	}
+}
+
+// deleteJob deletes job along with its pods, which deleting a job on its
+// own keeps
+func (c *CronJobController) deleteJob(ctx context.Context, job *api.Job) error {
+	objs, err := c.store.List(ctx, "Pod", job.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, obj := range objs {
+		if pod, ok := obj.(*api.Pod); ok && ownedByJob(pod, job) {
+			if err := c.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("failed to delete pod %s: %w", pod.Name, err)
+			}
+		}
+	}
+	if err := c.store.Delete(ctx, "Job", job.Namespace, job.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to delete job %s: %w", job.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		schedule string
+		loc      *time.Location
+		after    time.Time
+		want     []time.Time
+	}{
+		{
+			name:     "weekdays during working hours",
+			schedule: "*/30 9-10 * * 1-5",
+			loc:      time.UTC,
+			after:    time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC), // Friday
+			want: []time.Time{
+				time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "day of month or day of week",
+			schedule: "0 0 13 * 5",
+			loc:      time.UTC,
+			after:    time.Date(2026, 11, 7, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 11, 13, 0, 0, 0, 0, time.UTC), // Friday the 13th
+				time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "time zone",
+			schedule: "@daily",
+			loc:      berlin,
+			after:    time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC),
+			want:     []time.Time{time.Date(2026, 7, 1, 22, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "skipped when clocks go forward",
+			schedule: "30 2 * * *",
+			loc:      newYork,
+			after:    time.Date(2026, 3, 7, 3, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+				time.Date(2026, 3, 10, 2, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name:     "hourly across clocks going forward",
+			schedule: "@hourly",
+			loc:      newYork,
+			after:    time.Date(2026, 3, 8, 0, 30, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 6, 0, 0, 0, time.UTC), // 01:00 EST
+				time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), // 03:00 EDT
+			},
+		},
+		{
+			name:     "once when clocks go back",
+			schedule: "30 1 * * *",
+			loc:      newYork,
+			after:    time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+				time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC), // 01:30 EST
+			},
+		},
+		{
+			name:     "hourly across clocks going back",
+			schedule: "0 * * * *",
+			loc:      newYork,
+			after:    time.Date(2026, 11, 1, 4, 30, 0, 0, time.UTC), // 00:30 EDT
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC), // 01:00 EDT
+				time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC), // 02:00 EST
+			},
+		},
+		{
+			name:     "from within the repeated hour",
+			schedule: "45 1 * * *",
+			loc:      newYork,
+			after:    time.Date(2026, 11, 1, 6, 15, 0, 0, time.UTC), // 01:15 EST
+			want:     []time.Time{time.Date(2026, 11, 2, 6, 45, 0, 0, time.UTC)},
+		},
+		{
+			name:     "never",
+			schedule: "0 0 30 2 *",
+			loc:      time.UTC,
+			after:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:     []time.Time{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := api.ParseCronSchedule(tt.schedule)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.schedule, err)
+			}
+			after := tt.after
+			for _, want := range tt.want {
+				got := schedule.Next(after, tt.loc)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%v) = %v, want %v", after, got, want)
+				}
+				after = got
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		if _, err := api.ParseCronSchedule(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestCronJobController(t *testing.T) {
+	ctx := context.Background()
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	// newController returns a controller whose clock reads *now, and a
+	// store with a cronjob running "30 1 * * *" in New York
+	newController := func(now *time.Time, spec func(*api.CronJobSpec)) (*CronJobController, store.Store) {
+		mockStore := store.NewMemoryStore(store.DefaultOptions())
+		ctrl := NewCronJobController(mockStore)
+		ctrl.now = func() time.Time { return *now }
+		ctrl.local = time.UTC
+
+		cronJob := &api.CronJob{
+			TypeMeta:   api.TypeMeta{Kind: "CronJob", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "backup", Namespace: "default"},
+			Spec: api.CronJobSpec{
+				Schedule: "30 1 * * *",
+				TimeZone: "America/New_York",
+				JobTemplate: api.JobTemplateSpec{Spec: api.JobSpec{Template: api.PodTemplateSpec{
+					Spec: api.PodSpec{Containers: []api.Container{{Name: "backup", Image: "busybox"}}},
+				}}},
+			},
+		}
+		if spec != nil {
+			spec(&cronJob.Spec)
+		}
+		if err := mockStore.Create(ctx, cronJob); err != nil {
+			t.Fatalf("Failed to create cronjob: %v", err)
+		}
+		// The store stamps objects with the current time on create
+		cronJob.CreationTimestamp = time.Date(2026, 10, 31, 12, 0, 0, 0, newYork)
+		if err := mockStore.Update(ctx, cronJob); err != nil {
+			t.Fatalf("Failed to update cronjob: %v", err)
+		}
+		return ctrl, mockStore
+	}
+	sync := func(ctrl *CronJobController) {
+		t.Helper()
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+	jobs := func(s store.Store) map[string]*api.Job {
+		t.Helper()
+		objs, err := s.List(ctx, "Job", "default")
+		if err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		byName := make(map[string]*api.Job)
+		for _, obj := range objs {
+			job := obj.(*api.Job)
+			byName[job.Name] = job
+		}
+		return byName
+	}
+	cronJob := func(s store.Store) *api.CronJob {
+		t.Helper()
+		obj, err := s.Get(ctx, "CronJob", "default", "backup")
+		if err != nil {
+			t.Fatalf("Failed to get cronjob: %v", err)
+		}
+		return obj.(*api.CronJob)
+	}
+	finish := func(s store.Store, job *api.Job) {
+		t.Helper()
+		updated := *job
+		completed := time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC)
+		updated.Status.CompletionTime = &completed
+		updated.Status.Conditions = []api.JobCondition{{Type: api.JobComplete, Status: api.ConditionTrue}}
+		if err := s.Update(ctx, &updated); err != nil {
+			t.Fatalf("Failed to update job: %v", err)
+		}
+	}
+
+	t.Run("runs once when clocks go back", func(t *testing.T) {
+		now := time.Date(2026, 11, 1, 1, 0, 0, 0, newYork)
+		ctrl, s := newController(&now, nil)
+		sync(ctrl)
+		if len(jobs(s)) != 0 {
+			t.Fatalf("Expected no job before the schedule is due, got %d", len(jobs(s)))
+		}
+
+		first := time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC) // 01:30 EDT
+		now = first
+		sync(ctrl)
+		job := jobs(s)[cronJobName(cronJob(s), first)]
+		if job == nil {
+			t.Fatalf("Expected a job for the run at %v, got %v", first, jobs(s))
+		}
+		if job.OwnerReferences[0].Kind != "CronJob" || job.OwnerReferences[0].Name != "backup" {
+			t.Errorf("Expected the job to be owned by the cronjob, got %+v", job.OwnerReferences)
+		}
+		status := cronJob(s).Status
+		if status.LastScheduleTime == nil || !status.LastScheduleTime.Equal(first) || len(status.Active) != 1 {
+			t.Errorf("Unexpected cronjob status: %+v", status)
+		}
+
+		// 01:30 EST reads the same on the wall clock
+		finish(s, job)
+		now = time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC)
+		sync(ctrl)
+		if len(jobs(s)) != 1 {
+			t.Errorf("Expected the repeated 01:30 not to run again, got %d jobs", len(jobs(s)))
+		}
+		status = cronJob(s).Status
+		if len(status.Active) != 0 || status.LastSuccessfulTime == nil {
+			t.Errorf("Expected no active jobs and a last successful time, got %+v", status)
+		}
+
+		now = time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC)
+		sync(ctrl)
+		if len(jobs(s)) != 2 {
+			t.Errorf("Expected the next day's run, got %d jobs", len(jobs(s)))
+		}
+	})
+
+	t.Run("starting deadline", func(t *testing.T) {
+		// Ten minutes late with a five minute deadline: the run is skipped
+		now := time.Date(2026, 11, 1, 5, 40, 0, 0, time.UTC)
+		deadline := int64(300)
+		ctrl, s := newController(&now, func(spec *api.CronJobSpec) { spec.StartingDeadlineSeconds = &deadline })
+		sync(ctrl)
+		if len(jobs(s)) != 0 {
+			t.Errorf("Expected the run missed by more than its deadline to be skipped, got %d jobs", len(jobs(s)))
+		}
+
+		// Without a deadline only the latest missed run starts
+		now = time.Date(2026, 11, 3, 12, 0, 0, 0, newYork)
+		ctrl, s = newController(&now, nil)
+		sync(ctrl)
+		latest := time.Date(2026, 11, 3, 1, 30, 0, 0, newYork)
+		if got := jobs(s); len(got) != 1 || got[cronJobName(cronJob(s), latest)] == nil {
+			t.Errorf("Expected only the run at %v, got %v", latest, got)
+		}
+	})
+
+	t.Run("concurrency policies", func(t *testing.T) {
+		for _, tt := range []struct {
+			policy     string
+			wantJobs   int
+			wantActive int
+		}{
+			{api.ConcurrencyAllow, 2, 2},
+			{api.ConcurrencyForbid, 1, 1},
+			{api.ConcurrencyReplace, 1, 1},
+		} {
+			now := time.Date(2026, 11, 2, 1, 30, 0, 0, newYork)
+			ctrl, s := newController(&now, func(spec *api.CronJobSpec) { spec.ConcurrencyPolicy = tt.policy })
+			sync(ctrl)
+			first := time.Date(2026, 11, 2, 1, 30, 0, 0, newYork)
+
+			// The first run is still active when the next one is due
+			next := time.Date(2026, 11, 3, 1, 30, 0, 0, newYork)
+			now = next
+			sync(ctrl)
+			got := jobs(s)
+			if len(got) != tt.wantJobs || len(cronJob(s).Status.Active) != tt.wantActive {
+				t.Errorf("%s: expected %d jobs, %d active, got %d and %d", tt.policy, tt.wantJobs, tt.wantActive, len(got), len(cronJob(s).Status.Active))
+			}
+			firstJob, nextJob := got[cronJobName(cronJob(s), first)], got[cronJobName(cronJob(s), next)]
+			switch tt.policy {
+			case api.ConcurrencyForbid:
+				if firstJob == nil || nextJob != nil {
+					t.Errorf("Forbid: expected only the first run, got %v", got)
+				}
+				// Once the first run finished, the skipped run starts
+				finish(s, firstJob)
+				now = next.Add(time.Minute)
+				sync(ctrl)
+				if jobs(s)[cronJobName(cronJob(s), next)] == nil {
+					t.Errorf("Forbid: expected the skipped run to start once the first finished")
+				}
+			case api.ConcurrencyReplace:
+				if firstJob != nil || nextJob == nil {
+					t.Errorf("Replace: expected the first run to be replaced, got %v", got)
+				}
+			}
+		}
+	})
+
+	t.Run("suspended", func(t *testing.T) {
+		now := time.Date(2026, 11, 2, 1, 30, 0, 0, newYork)
+		suspend := true
+		ctrl, s := newController(&now, func(spec *api.CronJobSpec) { spec.Suspend = &suspend })
+		sync(ctrl)
+		if len(jobs(s)) != 0 {
+			t.Errorf("Expected a suspended cronjob not to run, got %d jobs", len(jobs(s)))
+		}
+	})
+
+	t.Run("history limits", func(t *testing.T) {
+		now := time.Date(2026, 11, 2, 1, 30, 0, 0, newYork)
+		limit := int32(1)
+		ctrl, s := newController(&now, func(spec *api.CronJobSpec) { spec.SuccessfulJobsHistoryLimit = &limit })
+		for day := 2; day <= 4; day++ {
+			now = time.Date(2026, 11, day, 1, 30, 0, 0, newYork)
+			sync(ctrl)
+			job := jobs(s)[cronJobName(cronJob(s), now)]
+			job.CreationTimestamp = now
+			finish(s, job)
+		}
+		sync(ctrl)
+		got := jobs(s)
+		latest := cronJobName(cronJob(s), time.Date(2026, 11, 4, 1, 30, 0, 0, newYork))
+		if len(got) != 1 || got[latest] == nil {
+			t.Errorf("Expected only the latest successful job to be kept, got %v", got)
+		}
+	})
+}
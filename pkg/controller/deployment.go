@@ -2,8 +2,9 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"reflect"
 	"sync"
 	"time"
 
@@ -20,11 +21,15 @@ type DeploymentController struct {
 	name  string
 
 	// State
-	running bool
-	stopCh  chan struct{}
+	running      bool
+	stopCh       chan struct{}
+	resyncPeriod time.Duration
+	resyncJitter float64
 
 	// Deployment tracking
 	deployments map[string]*DeploymentState
+
+	now func() time.Time
 }
 
 // DeploymentState tracks the state of a deployment
@@ -38,10 +43,13 @@ type DeploymentState struct {
 // NewDeploymentController creates a new deployment controller
 func NewDeploymentController(store store.Store) *DeploymentController {
 	return &DeploymentController{
-		store:       store,
-		name:        "deployment-controller",
-		deployments: make(map[string]*DeploymentState),
-		stopCh:      make(chan struct{}),
+		store:        store,
+		name:         "deployment-controller",
+		deployments:  make(map[string]*DeploymentState),
+		stopCh:       make(chan struct{}),
+		resyncPeriod: defaultWatchResyncPeriod,
+		resyncJitter: DefaultResyncJitter,
+		now:          time.Now,
 	}
 }
 
@@ -85,30 +93,33 @@ func (d *DeploymentController) Sync(ctx context.Context) error {
 	return d.syncDeployments(ctx)
 }
 
+// setResync sets the period and jitter of the controller's own resync
+// loop, a zero period keeping defaultWatchResyncPeriod; it takes effect when
+// the controller starts
+func (d *DeploymentController) setResync(period time.Duration, maxFactor float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if period > 0 {
+		d.resyncPeriod = period
+	}
+	d.resyncJitter = maxFactor
+}
+
 // watchLoop continuously watches for deployment changes
 func (d *DeploymentController) watchLoop(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-d.stopCh:
-			return
-		case <-ticker.C:
-			if err := d.syncDeployments(ctx); err != nil {
-				// Log error but continue
-				fmt.Printf("Error syncing deployments: %v\n", err)
-			}
+	JitterUntil(ctx, d.stopCh, d.resyncPeriod, d.resyncJitter, func() {
+		if err := d.syncDeployments(ctx); err != nil {
+			// Log error but continue
+			fmt.Printf("Error syncing deployments: %v\n", err)
 		}
-	}
+	})
 }
 
 // syncDeployments syncs all deployments
 func (d *DeploymentController) syncDeployments(ctx context.Context) error {
 	// Get all deployments
-	deployments, err := d.store.List(ctx, "Deployment", "")
+	deployments, err := d.store.List(ctx, "Deployment", store.AllNamespaces)
 	if err != nil {
 		return fmt.Errorf("failed to list deployments: %w", err)
 	}
@@ -148,8 +159,21 @@ func (d *DeploymentController) syncDeployment(ctx context.Context, deployment *a
 		state.Updated = time.Now()
 	}
 
-	// Ensure ReplicaSet exists
-	if err := d.ensureReplicaSet(ctx, deployment, state); err != nil {
+	if isCanary(deployment) {
+		return d.syncCanary(ctx, deployment, state)
+	}
+	if isBlueGreen(deployment) {
+		return d.syncBlueGreen(ctx, deployment, state)
+	}
+
+	// A paused deployment keeps its current ReplicaSet: template changes
+	// aren't rolled out until it's resumed, but scaling still applies
+	if deployment.Spec.Paused {
+		if state.ReplicaSet == nil {
+			fmt.Printf("Deployment %s is paused, not creating a ReplicaSet\n", deployment.Name)
+			return nil
+		}
+	} else if err := d.ensureReplicaSet(ctx, deployment, state); err != nil {
 		return fmt.Errorf("failed to ensure replicaset: %w", err)
 	}
 
@@ -172,15 +196,37 @@ func (d *DeploymentController) ensureReplicaSet(ctx context.Context, deployment
 	}
 
 	// Create new ReplicaSet
-	replicaSet := &api.ReplicaSet{
+	replicaSet := newReplicaSet(deployment)
+
+	// Create ReplicaSet in store
+	if err := store.CreateWithGeneratedName(ctx, d.store, replicaSet, &replicaSet.ObjectMeta); err != nil {
+		return fmt.Errorf("failed to create replicaset: %w", err)
+	}
+
+	// Update state
+	state.ReplicaSet = replicaSet
+	state.Updated = time.Now()
+
+	fmt.Printf("Created ReplicaSet %s for deployment %s\n", replicaSet.Name, deployment.Name)
+	return nil
+}
+
+// newReplicaSet returns a ReplicaSet running the deployment's template,
+// with any recommended requests applied, annotated with the hash of the
+// template it was made from
+func newReplicaSet(deployment *api.Deployment) *api.ReplicaSet {
+	return &api.ReplicaSet{
 		TypeMeta: api.TypeMeta{
 			Kind:       "ReplicaSet",
 			APIVersion: "v1alpha1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%d", deployment.Name, time.Now().Unix()),
-			Namespace: deployment.Namespace,
-			Labels:    deployment.Spec.Selector.MatchLabels,
+			GenerateName: deployment.Name + "-",
+			Namespace:    deployment.Namespace,
+			Labels:       deployment.Spec.Selector.MatchLabels,
+			Annotations: map[string]string{
+				api.LabelTemplateHash: templateHash(&deployment.Spec.Template),
+			},
 			OwnerReferences: []api.OwnerReference{
 				{
 					APIVersion: deployment.APIVersion,
@@ -191,26 +237,15 @@ func (d *DeploymentController) ensureReplicaSet(ctx context.Context, deployment
 			},
 		},
 		Spec: api.ReplicaSetSpec{
-			Replicas: deployment.Spec.Replicas,
-			Selector: deployment.Spec.Selector,
-			Template: deployment.Spec.Template,
+			Replicas:        deployment.Spec.Replicas,
+			Selector:        deployment.Spec.Selector,
+			Template:        recommendedTemplate(deployment),
+			MinReadySeconds: deployment.Spec.MinReadySeconds,
 		},
 		Status: api.ReplicaSetStatus{
 			Replicas: 0,
 		},
 	}
-
-	// Create ReplicaSet in store
-	if err := d.store.Create(ctx, replicaSet); err != nil {
-		return fmt.Errorf("failed to create replicaset: %w", err)
-	}
-
-	// Update state
-	state.ReplicaSet = replicaSet
-	state.Updated = time.Now()
-
-	fmt.Printf("Created ReplicaSet %s for deployment %s\n", replicaSet.Name, deployment.Name)
-	return nil
 }
 
 // ensurePods ensures the correct number of pods exist
@@ -220,7 +255,7 @@ func (d *DeploymentController) ensurePods(ctx context.Context, deployment *api.D
 	}
 
 	// Get current pods for this ReplicaSet
-	pods, err := d.store.List(ctx, "Pod", "")
+	pods, err := d.store.List(ctx, "Pod", store.AllNamespaces)
 	if err != nil {
 		return fmt.Errorf("failed to list pods: %w", err)
 	}
@@ -262,12 +297,48 @@ func (d *DeploymentController) ensurePods(ctx context.Context, deployment *api.D
 		}
 	}
 
-	// Update ReplicaSet status
+	// Update ReplicaSet status, carrying over the deployment's scale so the
+	// ReplicaSet controller agrees on the replica count
+	state.ReplicaSet.Spec.Replicas = desiredReplicas
+	state.ReplicaSet.Spec.MinReadySeconds = deployment.Spec.MinReadySeconds
 	state.ReplicaSet.Status.Replicas = int32(len(currentPods))
 	if err := d.store.Update(ctx, state.ReplicaSet); err != nil {
 		return fmt.Errorf("failed to update replicaset status: %w", err)
 	}
 
+	state.Pods = currentPods
+	return d.updateDeploymentStatus(ctx, deployment, currentPods)
+}
+
+// updateDeploymentStatus records how many of the deployment's pods are
+// available. Only pods that have been ready for minReadySeconds count, so a
+// rollout isn't reported healthy until its new pods have proven themselves.
+func (d *DeploymentController) updateDeploymentStatus(ctx context.Context, deployment *api.Deployment, pods []*api.Pod) error {
+	now := d.now()
+	_, available := countReadyPods(pods, deployment.Spec.MinReadySeconds, now)
+
+	status := api.DeploymentStatus{
+		Replicas:          int32(len(pods)),
+		UpdatedReplicas:   int32(len(pods)),
+		AvailableReplicas: available,
+		Conditions:        append([]api.DeploymentCondition(nil), deployment.Status.Conditions...),
+	}
+	if unavailable := deployment.Spec.Replicas - available; unavailable > 0 {
+		status.UnavailableReplicas = unavailable
+	}
+	pause := ""
+	if deployment.Spec.Paused {
+		pause = "Deployment is paused"
+	}
+	setDeploymentConditions(deployment, &status, now, pause)
+
+	if reflect.DeepEqual(deployment.Status, status) {
+		return nil
+	}
+	deployment.Status = status
+	if err := d.store.Update(ctx, deployment); err != nil {
+		return fmt.Errorf("failed to update deployment status: %w", err)
+	}
 	return nil
 }
 
@@ -282,12 +353,15 @@ func (d *DeploymentController) createPod(ctx context.Context, deployment *api.De
 		ObjectMeta: replicaSet.Spec.Template.ObjectMeta,
 		Spec:       replicaSet.Spec.Template.Spec,
 		Status: api.PodStatus{
-			Phase: string(api.PodPending),
+			Phase: api.PodPending,
 		},
 	}
 
-	// Generate unique name
-	pod.Name = fmt.Sprintf("%s-%s", replicaSet.Name, strconv.FormatInt(time.Now().UnixNano(), 10))
+	// Let the store pick a unique name in the ReplicaSet's namespace; the
+	// template usually leaves the namespace empty
+	pod.Name = ""
+	pod.GenerateName = replicaSet.Name + "-"
+	pod.Namespace = replicaSet.Namespace
 
 	// Set owner reference
 	pod.OwnerReferences = []api.OwnerReference{
@@ -300,7 +374,7 @@ func (d *DeploymentController) createPod(ctx context.Context, deployment *api.De
 	}
 
 	// Create pod in store
-	if err := d.store.Create(ctx, pod); err != nil {
+	if err := store.CreateWithGeneratedName(ctx, d.store, pod, &pod.ObjectMeta); err != nil {
 		return fmt.Errorf("failed to create pod: %w", err)
 	}
 
@@ -310,7 +384,8 @@ func (d *DeploymentController) createPod(ctx context.Context, deployment *api.De
 
 // deletePod deletes a pod
 func (d *DeploymentController) deletePod(ctx context.Context, pod *api.Pod) error {
-	if err := d.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil {
+	// A pod deleted by someone else is as good as deleted by us
+	if err := d.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
 		return fmt.Errorf("failed to delete pod: %w", err)
 	}
 
@@ -349,3 +424,66 @@ func (d *DeploymentController) ListDeploymentStates() map[string]*DeploymentStat
 	}
 	return result
 }
+
+// setDeploymentConditions updates the Available and Progressing conditions
+// in status, the deployment's next status. A rollout makes progress whenever
+// its replica counts change; one that doesn't for progressDeadlineSeconds is
+// marked Progressing=False with reason ProgressDeadlineExceeded, so clients
+// waiting for it can give up. A non-empty pause explains why the rollout is
+// paused; paused rollouts have no deadline.
+func setDeploymentConditions(deployment *api.Deployment, status *api.DeploymentStatus, now time.Time, pause string) {
+	availableStatus := api.ConditionFalse
+	if status.AvailableReplicas >= deployment.Spec.Replicas {
+		availableStatus = api.ConditionTrue
+	}
+	if existing := api.GetDeploymentCondition(status, api.DeploymentAvailable); existing == nil || existing.Status != availableStatus {
+		api.SetDeploymentCondition(status, api.DeploymentCondition{
+			Type:           api.DeploymentAvailable,
+			Status:         availableStatus,
+			LastUpdateTime: now,
+		})
+	}
+
+	progressing := api.GetDeploymentCondition(status, api.DeploymentProgressing)
+	setProgressing := func(conditionStatus api.ConditionStatus, reason, message string) {
+		api.SetDeploymentCondition(status, api.DeploymentCondition{
+			Type:           api.DeploymentProgressing,
+			Status:         conditionStatus,
+			LastUpdateTime: now,
+			Reason:         reason,
+			Message:        message,
+		})
+	}
+
+	previous := deployment.Status
+	progressed := status.Replicas != previous.Replicas ||
+		status.UpdatedReplicas != previous.UpdatedReplicas ||
+		status.AvailableReplicas != previous.AvailableReplicas
+	complete := status.UpdatedReplicas == deployment.Spec.Replicas && status.AvailableReplicas >= deployment.Spec.Replicas
+
+	switch {
+	case pause != "":
+		if progressing == nil || progressing.Reason != api.ReasonDeploymentPaused || progressing.Message != pause {
+			setProgressing(api.ConditionUnknown, api.ReasonDeploymentPaused, pause)
+		}
+	case complete:
+		if progressing == nil || progressing.Reason != api.ReasonNewReplicaSetAvailable {
+			setProgressing(api.ConditionTrue, api.ReasonNewReplicaSetAvailable,
+				fmt.Sprintf("Deployment %s has successfully progressed", deployment.Name))
+		}
+	case progressed || progressing == nil || progressing.Reason == api.ReasonNewReplicaSetAvailable ||
+		progressing.Reason == api.ReasonDeploymentPaused:
+		setProgressing(api.ConditionTrue, api.ReasonReplicaSetUpdated,
+			fmt.Sprintf("Deployment %s is progressing", deployment.Name))
+	case progressing.Reason == api.ReasonReplicaSetUpdated:
+		deadline := deployment.Spec.ProgressDeadlineSeconds
+		if deadline <= 0 {
+			deadline = api.DefaultProgressDeadlineSeconds
+		}
+		if now.Sub(progressing.LastUpdateTime) > time.Duration(deadline)*time.Second {
+			fmt.Printf("Deployment %s exceeded its progress deadline of %ds\n", deployment.Name, deadline)
+			setProgressing(api.ConditionFalse, api.ReasonProgressDeadlineExceeded,
+				fmt.Sprintf("Deployment %s has timed out progressing", deployment.Name))
+		}
+	}
+}
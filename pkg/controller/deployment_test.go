@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/minik8s/minik8s/pkg/api"
 	"github.com/minik8s/minik8s/pkg/store"
@@ -112,3 +113,430 @@ func TestDeploymentController_SyncDeployment(t *testing.T) {
 		t.Errorf("Expected 2 pods, got %d", len(pods))
 	}
 }
+
+func TestDeploymentController_Paused(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewDeploymentController(mockStore)
+
+	deployment := &api.Deployment{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			UID:       "web-uid",
+		},
+		Spec: api.DeploymentSpec{
+			Replicas: 2,
+			Selector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: api.PodSpec{
+					Containers: []api.Container{{Name: "web", Image: "nginx:1.25"}},
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+		t.Fatalf("Failed to sync deployment: %v", err)
+	}
+
+	countObjects := func(kind string) int {
+		objs, err := mockStore.List(ctx, kind, "")
+		if err != nil {
+			t.Fatalf("Failed to list %s: %v", kind, err)
+		}
+		return len(objs)
+	}
+
+	// While paused a new image isn't rolled out, but scaling is
+	deployment.Spec.Paused = true
+	deployment.Spec.Replicas = 3
+	deployment.Spec.Template.Spec.Containers = []api.Container{{Name: "web", Image: "nginx:1.26"}}
+	if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+		t.Fatalf("Failed to sync paused deployment: %v", err)
+	}
+
+	if n := countObjects("ReplicaSet"); n != 1 {
+		t.Errorf("Expected 1 replicaset while paused, got %d", n)
+	}
+	if n := countObjects("Pod"); n != 3 {
+		t.Errorf("Expected 3 pods after scaling while paused, got %d", n)
+	}
+
+	state := ctrl.GetDeploymentState("default", "web")
+	if state.ReplicaSet.Spec.Replicas != 3 {
+		t.Errorf("Expected replicaset to be scaled to 3, got %d", state.ReplicaSet.Spec.Replicas)
+	}
+	if image := state.ReplicaSet.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.25" {
+		t.Errorf("Expected replicaset to keep image nginx:1.25 while paused, got %s", image)
+	}
+
+	// Resuming rolls out the new template
+	deployment.Spec.Paused = false
+	if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+		t.Fatalf("Failed to sync resumed deployment: %v", err)
+	}
+	if n := countObjects("ReplicaSet"); n != 2 {
+		t.Errorf("Expected a new replicaset after resuming, got %d replicasets", n)
+	}
+}
+
+func TestDeploymentController_PausedBeforeFirstRollout(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewDeploymentController(mockStore)
+
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: api.DeploymentSpec{
+			Replicas: 1,
+			Paused:   true,
+			Selector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: api.PodSpec{
+					Containers: []api.Container{{Name: "web", Image: "nginx:1.25"}},
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+		t.Fatalf("Failed to sync deployment: %v", err)
+	}
+
+	replicaSets, err := mockStore.List(ctx, "ReplicaSet", "")
+	if err != nil {
+		t.Fatalf("Failed to list replicasets: %v", err)
+	}
+	if len(replicaSets) != 0 {
+		t.Errorf("Expected no replicaset for a paused deployment, got %d", len(replicaSets))
+	}
+}
+
+func TestDeploymentController_ProgressDeadline(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewDeploymentController(mockStore)
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec: api.DeploymentSpec{
+			Replicas:                2,
+			ProgressDeadlineSeconds: 60,
+			Selector:                &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       api.PodSpec{Containers: []api.Container{{Name: "web", Image: "web:missing"}}},
+			},
+		},
+	}
+	ctx := context.Background()
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+
+	progressing := func() *api.DeploymentCondition {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "Deployment", "default", "web")
+		if err != nil {
+			t.Fatalf("Failed to get deployment: %v", err)
+		}
+		condition := api.GetDeploymentCondition(&obj.(*api.Deployment).Status, api.DeploymentProgressing)
+		if condition == nil {
+			t.Fatal("Expected a Progressing condition")
+		}
+		return condition
+	}
+
+	// The pods are created but never become ready, as with a bad image
+	for _, step := range []time.Duration{0, 30 * time.Second, 20 * time.Second} {
+		now = now.Add(step)
+		if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+			t.Fatalf("Failed to sync deployment: %v", err)
+		}
+		if condition := progressing(); condition.Status != api.ConditionTrue {
+			t.Fatalf("Expected rollout to still be progressing, got %s/%s", condition.Status, condition.Reason)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+		t.Fatalf("Failed to sync deployment: %v", err)
+	}
+	condition := progressing()
+	if condition.Status != api.ConditionFalse || condition.Reason != api.ReasonProgressDeadlineExceeded {
+		t.Errorf("Expected ProgressDeadlineExceeded, got %s/%s", condition.Status, condition.Reason)
+	}
+
+	// Once the pods become ready the rollout completes
+	pods, _ := mockStore.List(ctx, "Pod", "default")
+	for _, obj := range pods {
+		pod := obj.(*api.Pod)
+		pod.Status.Phase = api.PodRunning
+	}
+	if err := ctrl.syncDeployment(ctx, deployment); err != nil {
+		t.Fatalf("Failed to sync deployment: %v", err)
+	}
+	condition = progressing()
+	if condition.Status != api.ConditionTrue || condition.Reason != api.ReasonNewReplicaSetAvailable {
+		t.Errorf("Expected completed rollout, got %s/%s", condition.Status, condition.Reason)
+	}
+}
+
+func TestDeploymentController_Canary(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewDeploymentController(mockStore)
+	rsCtrl := NewReplicaSetController(mockStore)
+	ctx := context.Background()
+
+	weight := func(w int32) *int32 { return &w }
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec: api.DeploymentSpec{
+			Replicas: 4,
+			Selector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       api.PodSpec{Containers: []api.Container{{Name: "web", Image: "web:v1"}}},
+			},
+			Strategy: &api.DeploymentStrategy{
+				Type: api.DeploymentStrategyCanary,
+				Canary: &api.CanaryStrategy{Steps: []api.CanaryStep{
+					{SetWeight: weight(25)},
+					{Pause: true},
+					{SetWeight: weight(50)},
+				}},
+			},
+		},
+	}
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+
+	// sync runs both controllers and marks every pod running
+	sync := func() *api.Deployment {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "Deployment", "default", "web")
+		if err != nil {
+			t.Fatalf("Failed to get deployment: %v", err)
+		}
+		if err := ctrl.syncDeployment(ctx, obj.(*api.Deployment)); err != nil {
+			t.Fatalf("Failed to sync deployment: %v", err)
+		}
+		replicaSets, _ := mockStore.List(ctx, "ReplicaSet", "default")
+		for _, rs := range replicaSets {
+			if err := rsCtrl.syncReplicaSet(ctx, rs.(*api.ReplicaSet)); err != nil {
+				t.Fatalf("Failed to sync replicaset: %v", err)
+			}
+		}
+		pods, _ := mockStore.List(ctx, "Pod", "default")
+		for _, pod := range pods {
+			pod.(*api.Pod).Status.Phase = api.PodRunning
+		}
+		obj, _ = mockStore.Get(ctx, "Deployment", "default", "web")
+		return obj.(*api.Deployment)
+	}
+
+	// images returns how many replicas each image's ReplicaSet wants
+	images := func() map[string]int32 {
+		replicas := make(map[string]int32)
+		replicaSets, _ := mockStore.List(ctx, "ReplicaSet", "default")
+		for _, obj := range replicaSets {
+			rs := obj.(*api.ReplicaSet)
+			replicas[rs.Spec.Template.Spec.Containers[0].Image] += rs.Spec.Replicas
+		}
+		return replicas
+	}
+
+	// The first rollout skips the steps
+	sync()
+	current := sync()
+	if got := images()["web:v1"]; got != 4 {
+		t.Fatalf("Expected 4 replicas of v1 after the first rollout, got %d", got)
+	}
+	if current.Status.Canary == nil || current.Status.Canary.Weight != 100 {
+		t.Fatalf("Expected the first rollout to finish, got %+v", current.Status.Canary)
+	}
+
+	current.Spec.Template.Spec.Containers = []api.Container{{Name: "web", Image: "web:v2"}}
+	if err := mockStore.Update(ctx, current); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+
+	// One of four replicas moves to v2, then the rollout waits at the pause
+	current = sync()
+	if got := images(); got["web:v1"] != 3 || got["web:v2"] != 1 {
+		t.Fatalf("Expected 3 v1 and 1 v2 replicas at 25%%, got %v", got)
+	}
+	sync()
+	current = sync()
+	if step := current.Status.Canary.CurrentStep; step != 1 {
+		t.Fatalf("Expected the rollout to wait at step 1, got %d", step)
+	}
+	progressing := api.GetDeploymentCondition(&current.Status, api.DeploymentProgressing)
+	if progressing == nil || progressing.Reason != api.ReasonDeploymentPaused {
+		t.Fatalf("Expected the paused canary to be reported, got %+v", progressing)
+	}
+
+	// Promoting moves past the pause to 50%, then to all replicas
+	current.Status.Canary.CurrentStep++
+	current = sync()
+	if got := images(); got["web:v1"] != 2 || got["web:v2"] != 2 {
+		t.Fatalf("Expected 2 v1 and 2 v2 replicas at 50%%, got %v", got)
+	}
+	sync()
+	sync()
+	current = sync()
+	if got := images(); got["web:v1"] != 0 || got["web:v2"] != 4 {
+		t.Fatalf("Expected all replicas on v2 once promoted, got %v", got)
+	}
+	progressing = api.GetDeploymentCondition(&current.Status, api.DeploymentProgressing)
+	if progressing == nil || progressing.Reason != api.ReasonNewReplicaSetAvailable {
+		t.Errorf("Expected the rollout to complete, got %+v", progressing)
+	}
+}
+
+func TestDeploymentController_BlueGreen(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewDeploymentController(mockStore)
+	rsCtrl := NewReplicaSetController(mockStore)
+	ctx := context.Background()
+
+	for _, name := range []string{"web", "web-preview"} {
+		service := &api.Service{
+			TypeMeta:   api.TypeMeta{Kind: "Service", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       api.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		}
+		if err := mockStore.Create(ctx, service); err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+	}
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec: api.DeploymentSpec{
+			Replicas: 2,
+			Selector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       api.PodSpec{Containers: []api.Container{{Name: "web", Image: "web:v1"}}},
+			},
+			Strategy: &api.DeploymentStrategy{
+				Type:      api.DeploymentStrategyBlueGreen,
+				BlueGreen: &api.BlueGreenStrategy{ActiveService: "web", PreviewService: "web-preview"},
+			},
+		},
+	}
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+
+	// sync runs both controllers and marks every pod running
+	sync := func() *api.Deployment {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "Deployment", "default", "web")
+		if err != nil {
+			t.Fatalf("Failed to get deployment: %v", err)
+		}
+		if err := ctrl.syncDeployment(ctx, obj.(*api.Deployment)); err != nil {
+			t.Fatalf("Failed to sync deployment: %v", err)
+		}
+		replicaSets, _ := mockStore.List(ctx, "ReplicaSet", "default")
+		for _, rs := range replicaSets {
+			if err := rsCtrl.syncReplicaSet(ctx, rs.(*api.ReplicaSet)); err != nil {
+				t.Fatalf("Failed to sync replicaset: %v", err)
+			}
+		}
+		pods, _ := mockStore.List(ctx, "Pod", "default")
+		for _, pod := range pods {
+			pod.(*api.Pod).Status.Phase = api.PodRunning
+		}
+		obj, _ = mockStore.Get(ctx, "Deployment", "default", "web")
+		return obj.(*api.Deployment)
+	}
+
+	// selected returns the image of the ReplicaSet the service selects
+	selected := func(name string) string {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "Service", "default", name)
+		if err != nil {
+			t.Fatalf("Failed to get service: %v", err)
+		}
+		hash := obj.(*api.Service).Spec.Selector[api.LabelTemplateHash]
+		replicaSets, _ := mockStore.List(ctx, "ReplicaSet", "default")
+		for _, obj := range replicaSets {
+			rs := obj.(*api.ReplicaSet)
+			if rs.Labels[api.LabelTemplateHash] == hash {
+				return rs.Spec.Template.Spec.Containers[0].Image
+			}
+		}
+		return ""
+	}
+
+	// images returns how many replicas each image's ReplicaSet wants
+	images := func() map[string]int32 {
+		replicas := make(map[string]int32)
+		replicaSets, _ := mockStore.List(ctx, "ReplicaSet", "default")
+		for _, obj := range replicaSets {
+			rs := obj.(*api.ReplicaSet)
+			replicas[rs.Spec.Template.Spec.Containers[0].Image] += rs.Spec.Replicas
+		}
+		return replicas
+	}
+
+	current := sync()
+	if got := selected("web"); got != "web:v1" {
+		t.Fatalf("Expected the first rollout to be active, got %q", got)
+	}
+
+	current.Spec.Template.Spec.Containers = []api.Container{{Name: "web", Image: "web:v2"}}
+	if err := mockStore.Update(ctx, current); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+
+	// The new ReplicaSet comes up at full size behind the preview service
+	sync()
+	current = sync()
+	if got := images(); got["web:v1"] != 2 || got["web:v2"] != 2 {
+		t.Fatalf("Expected 2 v1 and 2 v2 replicas side by side, got %v", got)
+	}
+	if got := selected("web"); got != "web:v1" {
+		t.Fatalf("Expected the active service to stay on v1 until promoted, got %q", got)
+	}
+	if got := selected("web-preview"); got != "web:v2" {
+		t.Fatalf("Expected the preview service to select v2, got %q", got)
+	}
+	progressing := api.GetDeploymentCondition(&current.Status, api.DeploymentProgressing)
+	if progressing == nil || progressing.Reason != api.ReasonDeploymentPaused {
+		t.Fatalf("Expected the rollout to wait for promotion, got %+v", progressing)
+	}
+
+	// Promoting switches the active service and scales the old color down
+	current.Status.BlueGreen.Promoted = true
+	sync()
+	current = sync()
+	if got := selected("web"); got != "web:v2" {
+		t.Fatalf("Expected the active service to select v2 once promoted, got %q", got)
+	}
+	if got := images(); got["web:v1"] != 0 || got["web:v2"] != 2 {
+		t.Fatalf("Expected the old ReplicaSet to be scaled down, got %v", got)
+	}
+	progressing = api.GetDeploymentCondition(&current.Status, api.DeploymentProgressing)
+	if progressing == nil || progressing.Reason != api.ReasonNewReplicaSetAvailable {
+		t.Errorf("Expected the rollout to complete, got %+v", progressing)
+	}
+}
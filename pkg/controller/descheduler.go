@@ -0,0 +1,455 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const (
+	// DefaultUtilizationThreshold is the fraction of a node's allocatable
+	// CPU or memory its pods may request before the descheduler moves some
+	// of them to nodes below it
+	DefaultUtilizationThreshold = 0.8
+	// DefaultMaxEvictionsPerSync is how many pods the descheduler evicts at
+	// most in one pass
+	DefaultMaxEvictionsPerSync = 5
+)
+
+// DeschedulerConfig holds the descheduler's parameters
+type DeschedulerConfig struct {
+	// UtilizationThreshold is the fraction of a node's allocatable CPU or
+	// memory its pods may request before it counts as over-utilized
+	UtilizationThreshold float64
+	// MaxEvictions bounds how many pods one Sync evicts
+	MaxEvictions int
+}
+
+// DeschedulerController evicts pods that are badly placed: those on
+// cordoned nodes, those violating their topology spread constraints, copies
+// of a pod running on the same node, and pods on over-utilized nodes. Only
+// pods with an owner are evicted, relying on their controllers to recreate
+// them and the scheduler to place them elsewhere, and only when another
+// node could take them.
+type DeschedulerController struct {
+	store    store.Store
+	name     string
+	config   DeschedulerConfig
+	recorder *events.Recorder
+}
+
+// NewDeschedulerController creates a descheduler. Zero config fields take
+// their defaults.
+func NewDeschedulerController(store store.Store, config DeschedulerConfig) *DeschedulerController {
+	if config.UtilizationThreshold <= 0 {
+		config.UtilizationThreshold = DefaultUtilizationThreshold
+	}
+	if config.MaxEvictions <= 0 {
+		config.MaxEvictions = DefaultMaxEvictionsPerSync
+	}
+
+	return &DeschedulerController{
+		store:  store,
+		name:   "descheduler-controller",
+		config: config,
+	}
+}
+
+// SetEventRecorder makes the controller record the pods it evicts as
+// events on them
+func (d *DeschedulerController) SetEventRecorder(recorder *events.Recorder) {
+	d.recorder = recorder
+}
+
+// Name returns the name of the controller
+func (d *DeschedulerController) Name() string {
+	return d.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (d *DeschedulerController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (d *DeschedulerController) Stop() error {
+	return nil
+}
+
+// eviction is a pod the descheduler decided to evict, and why
+type eviction struct {
+	pod    *api.Pod
+	reason string
+}
+
+// deschedulePlan is the state of one descheduling pass: the cluster as
+// listed, and the evictions decided on so far
+type deschedulePlan struct {
+	nodes []*api.Node
+	// targets are the nodes evicted pods may be scheduled to: those that
+	// are Ready and not cordoned
+	targets []*api.Node
+	// pods are the pods bound to nodes that haven't finished
+	pods       []*api.Pod
+	podsOnNode map[string][]*api.Pod
+
+	evictions []eviction
+	// evicted holds the pods in evictions by namespace/name; pods created
+	// by controllers have no UID
+	evicted map[string]bool
+}
+
+// evict adds pod to the plan unless it's already in it
+func (p *deschedulePlan) evict(pod *api.Pod, reason string) {
+	key := pod.Namespace + "/" + pod.Name
+	if p.evicted[key] {
+		return
+	}
+	p.evicted[key] = true
+	p.evictions = append(p.evictions, eviction{pod: pod, reason: reason})
+}
+
+// remaining returns the pods on node that aren't planned to be evicted
+func (p *deschedulePlan) remaining(node string) []*api.Pod {
+	var pods []*api.Pod
+	for _, pod := range p.podsOnNode[node] {
+		if !p.evicted[pod.Namespace+"/"+pod.Name] {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+// Sync evicts up to MaxEvictions badly placed pods
+func (d *DeschedulerController) Sync(ctx context.Context) error {
+	plan, err := d.newPlan(ctx)
+	if err != nil {
+		return err
+	}
+	if len(plan.targets) == 0 {
+		return nil
+	}
+
+	d.evictFromCordonedNodes(plan)
+	d.evictSpreadViolations(plan)
+	d.evictDuplicates(plan)
+	d.evictFromOverUtilizedNodes(plan)
+
+	evicted := 0
+	for _, e := range plan.evictions {
+		if evicted >= d.config.MaxEvictions {
+			break
+		}
+		fmt.Printf("Descheduler evicting pod %s/%s from node %s: %s\n", e.pod.Namespace, e.pod.Name, e.pod.Spec.NodeName, e.reason)
+		if err := d.store.Delete(ctx, "Pod", e.pod.Namespace, e.pod.Name); err != nil {
+			fmt.Printf("Failed to evict pod %s/%s: %v\n", e.pod.Namespace, e.pod.Name, err)
+			continue
+		}
+		d.recorder.Eventf(ctx, e.pod, api.EventTypeNormal, "Descheduled", "Evicted from node %s: %s", e.pod.Spec.NodeName, e.reason)
+		evicted++
+	}
+	return nil
+}
+
+// newPlan lists the nodes and pods a descheduling pass works on
+func (d *DeschedulerController) newPlan(ctx context.Context) (*deschedulePlan, error) {
+	nodeObjs, err := d.store.List(ctx, "Node", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	podObjs, err := d.store.List(ctx, "Pod", store.AllNamespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	plan := &deschedulePlan{
+		podsOnNode: make(map[string][]*api.Pod),
+		evicted:    make(map[string]bool),
+	}
+	for _, obj := range nodeObjs {
+		node, ok := obj.(*api.Node)
+		if !ok {
+			continue
+		}
+		plan.nodes = append(plan.nodes, node)
+		ready := nodeCondition(node, api.NodeReady)
+		if ready != nil && ready.Status == api.ConditionTrue && !node.Cordoned() {
+			plan.targets = append(plan.targets, node)
+		}
+	}
+	for _, obj := range podObjs {
+		pod, ok := obj.(*api.Pod)
+		if !ok || pod.Spec.NodeName == "" {
+			continue
+		}
+		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		plan.pods = append(plan.pods, pod)
+		plan.podsOnNode[pod.Spec.NodeName] = append(plan.podsOnNode[pod.Spec.NodeName], pod)
+	}
+
+	// Newer pods are evicted first, so long-running ones are left alone
+	// where possible
+	for _, pods := range plan.podsOnNode {
+		sort.SliceStable(pods, func(i, j int) bool {
+			return pods[i].CreationTimestamp.After(pods[j].CreationTimestamp)
+		})
+	}
+	return plan, nil
+}
+
+// evictFromCordonedNodes evicts the pods on cordoned nodes that don't
+// tolerate their taints. The unreachable taint is left to the node
+// lifecycle controller, which gives pods time to ride out network blips.
+func (d *DeschedulerController) evictFromCordonedNodes(plan *deschedulePlan) {
+	for _, node := range plan.nodes {
+		if !node.Cordoned() {
+			continue
+		}
+		for _, pod := range plan.podsOnNode[node.Name] {
+			if evictable(pod) && !toleratesCordon(pod, node) {
+				plan.evict(pod, "node is cordoned")
+			}
+		}
+	}
+}
+
+// toleratesCordon reports whether pod tolerates the NoSchedule and
+// NoExecute taints of node other than the unreachable one
+func toleratesCordon(pod *api.Pod, node *api.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == api.TaintEffectPreferNoSchedule || taint.Key == api.TaintNodeUnreachable {
+			continue
+		}
+		if !api.ToleratesTaint(pod.Spec.Tolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+// evictSpreadViolations evicts pods from the most populated domains of
+// spread constraints whose skew is above the maximum, until moving them to
+// the least populated domains would bring it back within bounds
+func (d *DeschedulerController) evictSpreadViolations(plan *deschedulePlan) {
+	checked := make(map[string]bool)
+	for _, pod := range plan.pods {
+		for _, constraint := range pod.Spec.TopologySpreadConstraints {
+			key := fmt.Sprint(pod.Namespace, constraint.TopologyKey, constraint.LabelSelector, pod.Spec.NodeSelector)
+			if checked[key] || constraint.LabelSelector == nil {
+				continue
+			}
+			checked[key] = true
+
+			var eligible []*api.Node
+			for _, node := range plan.targets {
+				if nodeSelected(pod, node) {
+					eligible = append(eligible, node)
+				}
+			}
+			d.rebalanceDomains(plan, pod.Namespace, constraint, eligible)
+		}
+	}
+}
+
+// rebalanceDomains evicts matching pods from the most populated domain of
+// constraint while its skew is above the maximum
+func (d *DeschedulerController) rebalanceDomains(plan *deschedulePlan, namespace string, constraint api.TopologySpreadConstraint, nodes []*api.Node) {
+	var remaining []*api.Pod
+	for _, pod := range plan.pods {
+		if !plan.evicted[pod.Namespace+"/"+pod.Name] {
+			remaining = append(remaining, pod)
+		}
+	}
+	domains := api.SpreadDomains(constraint, namespace, remaining, nodes)
+	if len(domains) < 2 {
+		return
+	}
+
+	for {
+		var most, least string
+		for domain, count := range domains {
+			if most == "" || count > domains[most] || (count == domains[most] && domain < most) {
+				most = domain
+			}
+			if least == "" || count < domains[least] || (count == domains[least] && domain < least) {
+				least = domain
+			}
+		}
+		if domains[most]-domains[least] <= constraint.AllowedSkew() {
+			return
+		}
+
+		pod := d.spreadCandidate(plan, namespace, constraint, nodes, most)
+		if pod == nil {
+			return
+		}
+		plan.evict(pod, fmt.Sprintf("%s %s has %d matching pods and %s %d, above the maximum skew of %d",
+			constraint.TopologyKey, most, domains[most], least, domains[least], constraint.AllowedSkew()))
+		domains[most]--
+		domains[least]++
+	}
+}
+
+// spreadCandidate returns the newest evictable pod selected by constraint
+// in domain, taken from the node with the most of them, or nil
+func (d *DeschedulerController) spreadCandidate(plan *deschedulePlan, namespace string, constraint api.TopologySpreadConstraint, nodes []*api.Node, domain string) *api.Pod {
+	var candidate *api.Pod
+	most := 0
+	for _, node := range nodes {
+		if value, _ := api.TopologyDomain(node, constraint.TopologyKey); value != domain {
+			continue
+		}
+		var newest *api.Pod
+		count := 0
+		for _, pod := range plan.remaining(node.Name) {
+			if pod.Namespace != namespace || !constraint.Selects(pod) {
+				continue
+			}
+			count++
+			if newest == nil && evictable(pod) {
+				newest = pod
+			}
+		}
+		if newest != nil && (count > most || (count == most && node.Name < candidate.Spec.NodeName)) {
+			candidate, most = newest, count
+		}
+	}
+	return candidate
+}
+
+// evictDuplicates evicts pods of an owner that runs several on one node,
+// as long as there are nodes running none of its pods to move them to.
+// Pods with spread constraints are left to evictSpreadViolations.
+func (d *DeschedulerController) evictDuplicates(plan *deschedulePlan) {
+	owners := make(map[string]map[string]bool)
+	for _, pod := range plan.pods {
+		if plan.evicted[pod.Namespace+"/"+pod.Name] {
+			continue
+		}
+		if key := ownerKey(pod); key != "" {
+			if owners[key] == nil {
+				owners[key] = make(map[string]bool)
+			}
+			owners[key][pod.Spec.NodeName] = true
+		}
+	}
+
+	for _, node := range plan.nodes {
+		// Pods are sorted newest first, so the oldest copy stays
+		pods := plan.remaining(node.Name)
+		seen := make(map[string]bool)
+		for i := len(pods) - 1; i >= 0; i-- {
+			pod := pods[i]
+			key := ownerKey(pod)
+			if key == "" || len(pod.Spec.TopologySpreadConstraints) > 0 {
+				continue
+			}
+			if !seen[key] {
+				seen[key] = true
+				continue
+			}
+			target := ""
+			for _, candidate := range plan.targets {
+				if !owners[key][candidate.Name] && nodeSelected(pod, candidate) {
+					target = candidate.Name
+					break
+				}
+			}
+			if target == "" {
+				continue
+			}
+			// Count the target as taken so the next copy goes elsewhere
+			owners[key][target] = true
+			plan.evict(pod, fmt.Sprintf("another pod of %s runs on the same node", key))
+		}
+	}
+}
+
+// evictFromOverUtilizedNodes evicts pods from nodes whose pods request more
+// than the threshold of their CPU or memory, as long as a node staying
+// below the threshold with the pod added could take it
+func (d *DeschedulerController) evictFromOverUtilizedNodes(plan *deschedulePlan) {
+	type usage struct {
+		cpu, memory                       float64
+		allocatableCPU, allocatableMemory float64
+	}
+	threshold := d.config.UtilizationThreshold
+	usages := make(map[string]*usage, len(plan.nodes))
+	for _, node := range plan.nodes {
+		u := &usage{}
+		u.allocatableCPU, _ = api.ParseCPU(node.Status.Allocatable[api.ResourceCPU])
+		u.allocatableMemory, _ = api.ParseMemory(node.Status.Allocatable[api.ResourceMemory])
+		for _, pod := range plan.remaining(node.Name) {
+			cpu, memory := api.PodRequests(pod)
+			u.cpu += cpu
+			u.memory += memory
+		}
+		usages[node.Name] = u
+	}
+	over := func(u *usage, cpu, memory float64) bool {
+		return (u.allocatableCPU > 0 && (u.cpu+cpu)/u.allocatableCPU > threshold) ||
+			(u.allocatableMemory > 0 && (u.memory+memory)/u.allocatableMemory > threshold)
+	}
+
+	for _, node := range plan.nodes {
+		source := usages[node.Name]
+		if node.Cordoned() || !over(source, 0, 0) {
+			continue
+		}
+		for _, pod := range plan.remaining(node.Name) {
+			if !over(source, 0, 0) {
+				break
+			}
+			cpu, memory := api.PodRequests(pod)
+			if !evictable(pod) || (cpu == 0 && memory == 0) {
+				continue
+			}
+			var target *usage
+			for _, candidate := range plan.targets {
+				if candidate.Name != node.Name && nodeSelected(pod, candidate) && !over(usages[candidate.Name], cpu, memory) {
+					target = usages[candidate.Name]
+					break
+				}
+			}
+			if target == nil {
+				continue
+			}
+			source.cpu -= cpu
+			source.memory -= memory
+			target.cpu += cpu
+			target.memory += memory
+			plan.evict(pod, fmt.Sprintf("node requests are above %.0f%% of its allocatable resources", threshold*100))
+		}
+	}
+}
+
+// evictable reports whether pod has an owner to recreate it once evicted
+func evictable(pod *api.Pod) bool {
+	return len(pod.OwnerReferences) > 0
+}
+
+// ownerKey identifies the owner of pod, or returns "" for pods without one
+func ownerKey(pod *api.Pod) string {
+	if len(pod.OwnerReferences) == 0 {
+		return ""
+	}
+	owner := pod.OwnerReferences[0]
+	return fmt.Sprintf("%s %s/%s", owner.Kind, pod.Namespace, owner.Name)
+}
+
+// nodeSelected reports whether node has the labels pod's node selector asks
+// for
+func nodeSelected(pod *api.Pod, node *api.Node) bool {
+	for key, value := range pod.Spec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
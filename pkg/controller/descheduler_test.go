@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func newDeschedulerNode(name, zone string, taints ...api.Taint) *api.Node {
+	node := newTestNode(name, time.Now())
+	node.Labels = map[string]string{"zone": zone}
+	node.Spec.Taints = taints
+	node.Status.Allocatable = api.ResourceList{api.ResourceCPU: "4", api.ResourceMemory: "8Gi"}
+	return node
+}
+
+func newDeschedulerPod(name, node, owner, cpu string, age time.Duration) *api.Pod {
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{"app": owner},
+			CreationTimestamp: time.Now().Add(-age),
+		},
+		Spec: api.PodSpec{
+			NodeName: node,
+			Containers: []api.Container{{
+				Name:      "app",
+				Image:     "nginx",
+				Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: cpu}},
+			}},
+		},
+		Status: api.PodStatus{Phase: api.PodRunning},
+	}
+	if owner != "" {
+		pod.OwnerReferences = []api.OwnerReference{{APIVersion: "v1alpha1", Kind: "ReplicaSet", Name: owner}}
+	}
+	return pod
+}
+
+func remainingPods(t *testing.T, s store.Store) []string {
+	t.Helper()
+	objs, err := s.List(context.Background(), "Pod", "")
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.(*api.Pod).Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDescheduler(t *testing.T) {
+	cordon := api.Taint{Key: "maintenance", Effect: api.TaintEffectNoSchedule}
+	tests := []struct {
+		name   string
+		config DeschedulerConfig
+		nodes  []*api.Node
+		pods   []*api.Pod
+		want   []string
+	}{
+		{
+			name:  "cordoned node",
+			nodes: []*api.Node{newDeschedulerNode("node-1", "a", cordon), newDeschedulerNode("node-2", "a")},
+			pods: []*api.Pod{
+				newDeschedulerPod("owned", "node-1", "web", "100m", time.Hour),
+				newDeschedulerPod("bare", "node-1", "", "100m", time.Hour),
+				newDeschedulerPod("elsewhere", "node-2", "web", "100m", time.Hour),
+			},
+			want: []string{"bare", "elsewhere"},
+		},
+		{
+			name:  "cordoned node with nowhere to go",
+			nodes: []*api.Node{newDeschedulerNode("node-1", "a", cordon)},
+			pods:  []*api.Pod{newDeschedulerPod("owned", "node-1", "web", "100m", time.Hour)},
+			want:  []string{"owned"},
+		},
+		{
+			name:  "duplicates keep the oldest copy",
+			nodes: []*api.Node{newDeschedulerNode("node-1", "a"), newDeschedulerNode("node-2", "a")},
+			pods: []*api.Pod{
+				newDeschedulerPod("web-old", "node-1", "web", "100m", 2*time.Hour),
+				newDeschedulerPod("web-new", "node-1", "web", "100m", time.Hour),
+				newDeschedulerPod("api", "node-1", "api", "100m", time.Hour),
+			},
+			want: []string{"api", "web-old"},
+		},
+		{
+			name:  "duplicates without a free node",
+			nodes: []*api.Node{newDeschedulerNode("node-1", "a"), newDeschedulerNode("node-2", "a")},
+			pods: []*api.Pod{
+				newDeschedulerPod("web-1", "node-1", "web", "100m", 3*time.Hour),
+				newDeschedulerPod("web-2", "node-1", "web", "100m", 2*time.Hour),
+				newDeschedulerPod("web-3", "node-2", "web", "100m", time.Hour),
+			},
+			want: []string{"web-1", "web-2", "web-3"},
+		},
+		{
+			name:  "over-utilized node",
+			nodes: []*api.Node{newDeschedulerNode("node-1", "a"), newDeschedulerNode("node-2", "a")},
+			pods: []*api.Pod{
+				newDeschedulerPod("big", "node-1", "big", "2", 2*time.Hour),
+				newDeschedulerPod("medium", "node-1", "medium", "1500m", time.Hour),
+				newDeschedulerPod("small", "node-2", "small", "500m", time.Hour),
+			},
+			want: []string{"big", "small"},
+		},
+		{
+			name:   "eviction limit",
+			config: DeschedulerConfig{MaxEvictions: 1},
+			nodes:  []*api.Node{newDeschedulerNode("node-1", "a", cordon), newDeschedulerNode("node-2", "a")},
+			pods: []*api.Pod{
+				newDeschedulerPod("web-1", "node-1", "web", "100m", 2*time.Hour),
+				newDeschedulerPod("web-2", "node-1", "web", "100m", time.Hour),
+			},
+			want: []string{"web-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockStore := store.NewMemoryStore(store.DefaultOptions())
+			for _, node := range tt.nodes {
+				if err := mockStore.Create(ctx, node); err != nil {
+					t.Fatalf("Failed to create node: %v", err)
+				}
+			}
+			for _, pod := range tt.pods {
+				if err := mockStore.Create(ctx, pod); err != nil {
+					t.Fatalf("Failed to create pod: %v", err)
+				}
+			}
+
+			if err := NewDeschedulerController(mockStore, tt.config).Sync(ctx); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+			if got := remainingPods(t, mockStore); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected pods %v to remain, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDescheduler_SpreadConstraints(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	for _, node := range []*api.Node{
+		newDeschedulerNode("node-1", "a"),
+		newDeschedulerNode("node-2", "a"),
+		newDeschedulerNode("node-3", "b"),
+	} {
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+	}
+
+	// Four pods in zone a and none in b: two must move to bring the skew
+	// down to 1
+	constraint := api.TopologySpreadConstraint{
+		MaxSkew:       1,
+		TopologyKey:   "zone",
+		LabelSelector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}
+	for i, node := range []string{"node-1", "node-1", "node-2", "node-2"} {
+		pod := newDeschedulerPod(fmt.Sprintf("web-%d", i), node, "web", "100m", time.Duration(4-i)*time.Hour)
+		pod.Spec.TopologySpreadConstraints = []api.TopologySpreadConstraint{constraint}
+		if err := mockStore.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	if err := NewDeschedulerController(mockStore, DeschedulerConfig{}).Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	// The newest pods go first, from the nodes with the most of them; the
+	// copies sharing a node are left alone since their spread is fine now
+	if got := remainingPods(t, mockStore); fmt.Sprint(got) != "[web-0 web-2]" {
+		t.Errorf("Expected web-0 and web-2 to remain, got %v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// EventTTLController deletes Events that haven't been seen for longer than
+// their TTL. It works the same on every store backend, so no etcd leases are
+// involved; the manager's resync period decides how promptly events expire.
+type EventTTLController struct {
+	store store.Store
+	name  string
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewEventTTLController creates a controller expiring events ttl after they
+// were last seen
+func NewEventTTLController(store store.Store, ttl time.Duration) *EventTTLController {
+	return &EventTTLController{
+		store: store,
+		name:  "event-ttl-controller",
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Name returns the name of the controller
+func (e *EventTTLController) Name() string {
+	return e.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (e *EventTTLController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (e *EventTTLController) Stop() error {
+	return nil
+}
+
+// Sync deletes expired events
+func (e *EventTTLController) Sync(ctx context.Context) error {
+	objs, err := e.store.List(ctx, "Event", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+
+	cutoff := e.now().Add(-e.ttl)
+	expired := 0
+	for _, obj := range objs {
+		event, ok := obj.(*api.Event)
+		if !ok {
+			continue
+		}
+
+		lastSeen := event.LastTimestamp
+		if lastSeen.IsZero() {
+			lastSeen = event.CreationTimestamp
+		}
+		if !lastSeen.Before(cutoff) {
+			continue
+		}
+
+		if err := e.store.Delete(ctx, "Event", event.Namespace, event.Name); err != nil {
+			fmt.Printf("Failed to delete expired event %s/%s: %v\n", event.Namespace, event.Name, err)
+			continue
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		fmt.Printf("Deleted %d expired events\n", expired)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestEventTTLController(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewEventTTLController(mockStore, time.Hour)
+
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for name, lastSeen := range map[string]time.Time{
+		"fresh":   now.Add(-time.Minute),
+		"expired": now.Add(-2 * time.Hour),
+	} {
+		event := &api.Event{
+			TypeMeta:      api.TypeMeta{Kind: "Event", APIVersion: "v1alpha1"},
+			ObjectMeta:    api.ObjectMeta{Name: name, Namespace: "default"},
+			Count:         1,
+			LastTimestamp: lastSeen,
+		}
+		if err := mockStore.Create(ctx, event); err != nil {
+			t.Fatalf("Failed to create event: %v", err)
+		}
+	}
+
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if _, err := mockStore.Get(ctx, "Event", "default", "fresh"); err != nil {
+		t.Errorf("Expected fresh event to be kept: %v", err)
+	}
+	if _, err := mockStore.Get(ctx, "Event", "default", "expired"); err == nil {
+		t.Error("Expected expired event to be deleted")
+	}
+}
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// JobController runs the pods of Jobs until enough of them succeed, or too
+// many fail. The pods of Indexed Jobs are each given a completion index,
+// and a failed index is retried until a pod of it succeeds.
+type JobController struct {
+	store    store.Store
+	name     string
+	recorder *events.Recorder
+	now      func() time.Time
+}
+
+// NewJobController creates a new Job controller
+func NewJobController(store store.Store) *JobController {
+	return &JobController{
+		store: store,
+		name:  "job-controller",
+		now:   time.Now,
+	}
+}
+
+// SetEventRecorder makes the controller record the pods it creates and
+// whether Jobs complete or fail as events on the Job
+func (j *JobController) SetEventRecorder(recorder *events.Recorder) {
+	j.recorder = recorder
+}
+
+// Name returns the name of the controller
+func (j *JobController) Name() string {
+	return j.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (j *JobController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (j *JobController) Stop() error {
+	return nil
+}
+
+// Sync syncs all unfinished Jobs
+func (j *JobController) Sync(ctx context.Context) error {
+	objs, err := j.store.List(ctx, "Job", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	for _, obj := range objs {
+		job, ok := obj.(*api.Job)
+		if !ok || job.Finished() {
+			continue
+		}
+		if err := j.syncJob(ctx, job); err != nil {
+			fmt.Printf("Error syncing job %s/%s: %v\n", job.Namespace, job.Name, err)
+		}
+	}
+	return nil
+}
+
+// jobPods are the pods of a Job by what became of them
+type jobPods struct {
+	active    []*api.Pod
+	succeeded []*api.Pod
+	failed    []*api.Pod
+}
+
+// syncJob creates the pods a Job is missing and updates its status
+func (j *JobController) syncJob(ctx context.Context, job *api.Job) error {
+	pods, err := j.listPods(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	now := j.now()
+	status := job.Status
+	status.Conditions = slices.Clone(job.Status.Conditions)
+	if status.StartTime == nil {
+		status.StartTime = &now
+	}
+	status.Succeeded = int32(len(pods.succeeded))
+	status.Failed = int32(len(pods.failed))
+
+	var pending []int
+	complete := status.Succeeded >= job.Completions()
+	if job.Indexed() {
+		var completed []int
+		pending, completed = pendingIndexes(job, pods)
+		status.CompletedIndexes = api.FormatIndexes(completed)
+		complete = len(completed) == int(job.Completions())
+	}
+
+	switch {
+	case status.Failed > job.BackoffLimit():
+		j.deleteActive(ctx, job, pods.active)
+		status.Active = 0
+		status.Conditions = append(status.Conditions, api.JobCondition{
+			Type:               api.JobFailed,
+			Status:             api.ConditionTrue,
+			Reason:             "BackoffLimitExceeded",
+			Message:            fmt.Sprintf("%d pods failed, more than the backoff limit of %d", status.Failed, job.BackoffLimit()),
+			LastTransitionTime: now,
+		})
+		fmt.Printf("Job %s/%s failed: backoff limit exceeded\n", job.Namespace, job.Name)
+		j.recorder.Eventf(ctx, job, api.EventTypeWarning, "BackoffLimitExceeded", "Job has reached the specified backoff limit")
+	case complete:
+		j.deleteActive(ctx, job, pods.active)
+		status.Active = 0
+		status.CompletionTime = &now
+		status.Conditions = append(status.Conditions, api.JobCondition{
+			Type:               api.JobComplete,
+			Status:             api.ConditionTrue,
+			LastTransitionTime: now,
+		})
+		fmt.Printf("Job %s/%s completed\n", job.Namespace, job.Name)
+		j.recorder.Eventf(ctx, job, api.EventTypeNormal, "Completed", "Job completed")
+	default:
+		status.Active = int32(len(pods.active)) + j.createPods(ctx, job, pods, pending)
+	}
+
+	if reflect.DeepEqual(job.Status, status) {
+		return nil
+	}
+	updated := *job
+	updated.Status = status
+	if err := j.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+	return nil
+}
+
+// listPods returns the pods owned by job
+func (j *JobController) listPods(ctx context.Context, job *api.Job) (*jobPods, error) {
+	var objs []store.Object
+	var err error
+	if job.UID != "" {
+		objs, err = store.ListByIndex(ctx, j.store, "Pod", job.Namespace, store.IndexOwner, job.UID)
+	} else {
+		objs, err = j.store.List(ctx, "Pod", job.Namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	pods := &jobPods{}
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if !ok || !ownedByJob(pod, job) {
+			continue
+		}
+		switch pod.Status.Phase {
+		case api.PodSucceeded:
+			pods.succeeded = append(pods.succeeded, pod)
+		case api.PodFailed:
+			pods.failed = append(pods.failed, pod)
+		default:
+			pods.active = append(pods.active, pod)
+		}
+	}
+	return pods, nil
+}
+
+// ownedByJob reports whether pod was created for job
+func ownedByJob(pod *api.Pod, job *api.Job) bool {
+	for _, ownerRef := range pod.OwnerReferences {
+		if ownerRef.Kind == "Job" && ownerRef.Name == job.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingIndexes returns the indexes of an Indexed Job without a pod that
+// succeeded or is still running, lowest first, and those that succeeded
+func pendingIndexes(job *api.Job, pods *jobPods) (pending, completed []int) {
+	completions := int(job.Completions())
+	succeeded := make([]bool, completions)
+	active := make([]bool, completions)
+	for _, pod := range pods.succeeded {
+		if index, ok := api.JobCompletionIndex(pod); ok && index < completions {
+			succeeded[index] = true
+		}
+	}
+	for _, pod := range pods.active {
+		if index, ok := api.JobCompletionIndex(pod); ok && index < completions {
+			active[index] = true
+		}
+	}
+
+	for index := 0; index < completions; index++ {
+		switch {
+		case succeeded[index]:
+			completed = append(completed, index)
+		case !active[index]:
+			pending = append(pending, index)
+		}
+	}
+	return pending, completed
+}
+
+// createPods starts as many pods as the Job's parallelism allows and it
+// still needs, and returns how many it created. The pods of Indexed Jobs
+// take the lowest pending indexes.
+func (j *JobController) createPods(ctx context.Context, job *api.Job, pods *jobPods, pending []int) int32 {
+	active := int32(len(pods.active))
+	want := job.Parallelism() - active
+	if !job.Indexed() {
+		want = min(want, job.Completions()-int32(len(pods.succeeded))-active)
+	} else {
+		want = min(want, int32(len(pending)))
+	}
+
+	var created int32
+	for i := int32(0); i < want; i++ {
+		index := -1
+		if job.Indexed() {
+			index = pending[i]
+		}
+		if err := j.createPod(ctx, job, index); err != nil {
+			fmt.Printf("Failed to create pod for job %s/%s: %v\n", job.Namespace, job.Name, err)
+			j.recorder.Eventf(ctx, job, api.EventTypeWarning, "FailedCreate", "Error creating pod: %v", err)
+			continue
+		}
+		created++
+	}
+	return created
+}
+
+// createPod creates a pod for job from its template. index is the
+// completion index of the pod, or -1 for NonIndexed Jobs.
+func (j *JobController) createPod(ctx context.Context, job *api.Job, index int) error {
+	template := job.Spec.Template
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: template.ObjectMeta,
+		Spec:       template.Spec,
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+	pod.Name = ""
+	pod.GenerateName = job.Name + "-"
+	pod.Namespace = job.Namespace
+	pod.Labels = maps.Clone(template.Labels)
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	pod.Labels[api.LabelJobName] = job.Name
+	pod.Annotations = maps.Clone(template.Annotations)
+	pod.OwnerReferences = []api.OwnerReference{{
+		APIVersion: job.APIVersion,
+		Kind:       "Job",
+		Name:       job.Name,
+		UID:        job.UID,
+	}}
+	if pod.Spec.RestartPolicy == "" {
+		pod.Spec.RestartPolicy = "Never"
+	}
+
+	if index >= 0 {
+		value := strconv.Itoa(index)
+		pod.GenerateName = fmt.Sprintf("%s-%d-", job.Name, index)
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations[api.AnnotationJobCompletionIndex] = value
+		pod.Spec.Containers = slices.Clone(template.Spec.Containers)
+		for i := range pod.Spec.Containers {
+			container := &pod.Spec.Containers[i]
+			container.Env = append(slices.Clone(container.Env), api.EnvVar{Name: api.EnvJobCompletionIndex, Value: value})
+		}
+	}
+
+	if err := store.CreateWithGeneratedName(ctx, j.store, pod, &pod.ObjectMeta); err != nil {
+		return fmt.Errorf("failed to create pod: %w", err)
+	}
+
+	fmt.Printf("Created pod %s for job %s/%s\n", pod.Name, job.Namespace, job.Name)
+	j.recorder.Eventf(ctx, job, api.EventTypeNormal, "SuccessfulCreate", "Created pod: %s", pod.Name)
+	return nil
+}
+
+// deleteActive deletes the pods of a finished Job that are still running
+func (j *JobController) deleteActive(ctx context.Context, job *api.Job, pods []*api.Pod) {
+	for _, pod := range pods {
+		if err := j.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil {
+			fmt.Printf("Failed to delete pod %s/%s of job %s: %v\n", pod.Namespace, pod.Name, job.Name, err)
+			continue
+		}
+		j.recorder.Eventf(ctx, job, api.EventTypeNormal, "SuccessfulDelete", "Deleted pod: %s", pod.Name)
+	}
+}
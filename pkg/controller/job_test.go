@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestJobController_Indexed(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewJobController(mockStore)
+	ctx := context.Background()
+
+	parallelism, completions, backoffLimit := int32(2), int32(3), int32(1)
+	job := &api.Job{
+		TypeMeta:   api.TypeMeta{Kind: "Job", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "shard", Namespace: "default", UID: "job-uid"},
+		Spec: api.JobSpec{
+			Parallelism:    &parallelism,
+			Completions:    &completions,
+			CompletionMode: api.JobCompletionIndexed,
+			BackoffLimit:   &backoffLimit,
+			Template: api.PodTemplateSpec{
+				Spec: api.PodSpec{Containers: []api.Container{{Name: "worker", Image: "busybox"}}},
+			},
+		},
+	}
+	if err := mockStore.Create(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	sync := func() *api.Job {
+		t.Helper()
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		obj, err := mockStore.Get(ctx, "Job", "default", "shard")
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		return obj.(*api.Job)
+	}
+	// pods returns the pods of the job by completion index
+	pods := func() map[int][]*api.Pod {
+		t.Helper()
+		objs, err := mockStore.List(ctx, "Pod", "default")
+		if err != nil {
+			t.Fatalf("Failed to list pods: %v", err)
+		}
+		byIndex := make(map[int][]*api.Pod)
+		for _, obj := range objs {
+			pod := obj.(*api.Pod)
+			index, ok := api.JobCompletionIndex(pod)
+			if !ok {
+				t.Fatalf("Pod %s has no completion index", pod.Name)
+			}
+			byIndex[index] = append(byIndex[index], pod)
+		}
+		return byIndex
+	}
+	setPhase := func(pod *api.Pod, phase api.PodPhase) {
+		t.Helper()
+		updated := *pod
+		updated.Status.Phase = phase
+		if err := mockStore.Update(ctx, &updated); err != nil {
+			t.Fatalf("Failed to update pod %s: %v", pod.Name, err)
+		}
+	}
+
+	// Only parallelism pods run at once, on the lowest indexes
+	got := sync()
+	byIndex := pods()
+	var indexes []int
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	if len(indexes) != 2 || indexes[0] != 0 || indexes[1] != 1 {
+		t.Fatalf("Expected pods for indexes 0 and 1, got %v", indexes)
+	}
+	if got.Status.Active != 2 || got.Status.StartTime == nil {
+		t.Errorf("Expected 2 active pods and a start time, got %+v", got.Status)
+	}
+
+	pod := byIndex[1][0]
+	if pod.Labels[api.LabelJobName] != "shard" || pod.Spec.RestartPolicy != "Never" {
+		t.Errorf("Expected the job-name label and restartPolicy Never, got %v and %q", pod.Labels, pod.Spec.RestartPolicy)
+	}
+	env := pod.Spec.Containers[0].Env
+	if len(env) != 1 || env[0].Name != api.EnvJobCompletionIndex || env[0].Value != "1" {
+		t.Errorf("Expected %s=1 in the container environment, got %v", api.EnvJobCompletionIndex, env)
+	}
+	if len(job.Spec.Template.Spec.Containers[0].Env) != 0 {
+		t.Errorf("Expected the template to be left alone, got env %v", job.Spec.Template.Spec.Containers[0].Env)
+	}
+
+	// Index 0 succeeds and index 1 fails: index 2 starts and index 1 is
+	// retried
+	setPhase(byIndex[0][0], api.PodSucceeded)
+	setPhase(byIndex[1][0], api.PodFailed)
+	got = sync()
+	byIndex = pods()
+	if len(byIndex[1]) != 2 || len(byIndex[2]) != 1 {
+		t.Fatalf("Expected index 1 to be retried and index 2 started, got %d and %d pods", len(byIndex[1]), len(byIndex[2]))
+	}
+	if got.Status.CompletedIndexes != "0" || got.Status.Succeeded != 1 || got.Status.Failed != 1 || got.Status.Active != 2 {
+		t.Errorf("Unexpected status after first completions: %+v", got.Status)
+	}
+
+	// Once every index succeeded the job is complete
+	for _, index := range []int{1, 2} {
+		for _, pod := range byIndex[index] {
+			if pod.Status.Phase != api.PodFailed {
+				setPhase(pod, api.PodSucceeded)
+			}
+		}
+	}
+	got = sync()
+	if got.JobCondition(api.JobComplete) == nil || got.Status.CompletionTime == nil {
+		t.Fatalf("Expected the job to be complete, got %+v", got.Status)
+	}
+	if got.Status.CompletedIndexes != "0-2" || got.Status.Succeeded != 3 || got.Status.Active != 0 {
+		t.Errorf("Unexpected status of the completed job: %+v", got.Status)
+	}
+
+	// Finished jobs are left alone
+	sync()
+	if total := len(pods()[0]) + len(pods()[1]) + len(pods()[2]); total != 4 {
+		t.Errorf("Expected no more pods for the completed job, got %d", total)
+	}
+}
+
+func TestJobController_BackoffLimit(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewJobController(mockStore)
+	ctx := context.Background()
+
+	parallelism, completions, backoffLimit := int32(2), int32(4), int32(0)
+	job := &api.Job{
+		TypeMeta:   api.TypeMeta{Kind: "Job", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "flaky", Namespace: "default", UID: "job-uid"},
+		Spec: api.JobSpec{
+			Parallelism:  &parallelism,
+			Completions:  &completions,
+			BackoffLimit: &backoffLimit,
+			Template: api.PodTemplateSpec{
+				Spec: api.PodSpec{Containers: []api.Container{{Name: "worker", Image: "busybox"}}},
+			},
+		},
+	}
+	if err := mockStore.Create(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	objs, err := mockStore.List(ctx, "Pod", "default")
+	if err != nil || len(objs) != 2 {
+		t.Fatalf("Expected 2 pods, got %d (%v)", len(objs), err)
+	}
+	failed := *objs[0].(*api.Pod)
+	failed.Status.Phase = api.PodFailed
+	if err := mockStore.Update(ctx, &failed); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	obj, err := mockStore.Get(ctx, "Job", "default", "flaky")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	got := obj.(*api.Job)
+	condition := got.JobCondition(api.JobFailed)
+	if condition == nil || condition.Reason != "BackoffLimitExceeded" {
+		t.Fatalf("Expected the job to fail with BackoffLimitExceeded, got %+v", got.Status)
+	}
+	objs, _ = mockStore.List(ctx, "Pod", "default")
+	if len(objs) != 1 || objs[0].(*api.Pod).Status.Phase != api.PodFailed {
+		t.Errorf("Expected only the failed pod to be kept, got %d pods", len(objs))
+	}
+}
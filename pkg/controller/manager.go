@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -20,9 +21,12 @@ type Manager struct {
 	controllers map[string]Controller
 	running     bool
 	stopCh      chan struct{}
+	loops       sync.WaitGroup
 
 	// Configuration
-	syncInterval time.Duration
+	syncInterval  time.Duration
+	resyncPeriods map[string]time.Duration
+	resyncJitter  float64
 }
 
 // Controller defines the interface for all controllers
@@ -40,10 +44,32 @@ type Controller interface {
 	Sync(ctx context.Context) error
 }
 
+const (
+	// DefaultResyncJitter is the jitter factor applied to resync periods when none is configured
+	DefaultResyncJitter = 0.1
+
+	// defaultWatchResyncPeriod is the period of each controller's own reconcile loop
+	defaultWatchResyncPeriod = 10 * time.Second
+)
+
+// resyncSetter is implemented by controllers that run a resync loop of
+// their own, so they get the manager's jitter and the period configured for
+// them. A zero period keeps the controller's own.
+type resyncSetter interface {
+	setResync(period time.Duration, maxFactor float64)
+}
+
 // Config holds the configuration for the controller manager
 type Config struct {
 	Store        store.Store
 	SyncInterval time.Duration
+
+	// ResyncPeriods overrides SyncInterval for individual controllers, keyed by controller name
+	ResyncPeriods map[string]time.Duration
+
+	// ResyncJitter is the maximum fraction of a resync period added as a random delay
+	// to each wakeup. Zero uses DefaultResyncJitter, a negative value disables jitter.
+	ResyncJitter float64
 }
 
 // NewManager creates a new controller manager
@@ -51,12 +77,22 @@ func NewManager(config *Config) *Manager {
 	if config.SyncInterval == 0 {
 		config.SyncInterval = 30 * time.Second
 	}
+	if config.ResyncJitter == 0 {
+		config.ResyncJitter = DefaultResyncJitter
+	}
+
+	resyncPeriods := make(map[string]time.Duration, len(config.ResyncPeriods))
+	for name, period := range config.ResyncPeriods {
+		resyncPeriods[name] = period
+	}
 
 	return &Manager{
-		store:        config.Store,
-		controllers:  make(map[string]Controller),
-		syncInterval: config.SyncInterval,
-		stopCh:       make(chan struct{}),
+		store:         config.Store,
+		controllers:   make(map[string]Controller),
+		syncInterval:  config.SyncInterval,
+		resyncPeriods: resyncPeriods,
+		resyncJitter:  config.ResyncJitter,
+		stopCh:        make(chan struct{}),
 	}
 }
 
@@ -65,6 +101,9 @@ func (m *Manager) AddController(controller Controller) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if setter, ok := controller.(resyncSetter); ok {
+		setter.setResync(m.resyncPeriods[controller.Name()], m.resyncJitter)
+	}
 	m.controllers[controller.Name()] = controller
 }
 
@@ -84,19 +123,25 @@ func (m *Manager) Start(ctx context.Context) error {
 		}
 	}
 
-	// Start background sync loop
-	go m.syncLoop(ctx)
+	// Start a resync loop per controller so they don't wake in lockstep
+	for _, controller := range m.controllers {
+		m.loops.Add(1)
+		go func(controller Controller) {
+			defer m.loops.Done()
+			m.resyncLoop(ctx, controller)
+		}(controller)
+	}
 
 	m.running = true
 	return nil
 }
 
-// Stop stops the controller manager
+// Stop stops the controller manager, returning once the syncs in flight
+// have finished
 func (m *Manager) Stop() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	if !m.running {
+		m.mu.Unlock()
 		return
 	}
 
@@ -107,53 +152,64 @@ func (m *Manager) Stop() {
 
 	close(m.stopCh)
 	m.running = false
+	m.mu.Unlock()
+
+	m.loops.Wait()
+}
+
+// resyncLoop periodically resyncs a single controller using a jittered period
+func (m *Manager) resyncLoop(ctx context.Context, controller Controller) {
+	period := m.ResyncPeriod(controller.Name())
+
+	JitterUntil(ctx, m.stopCh, period, m.resyncJitter, func() {
+		if err := controller.Sync(ctx); err != nil {
+			// Log error but continue
+			fmt.Printf("Error syncing controller %s: %v\n", controller.Name(), err)
+		}
+	})
+}
+
+// ResyncPeriod returns the resync period configured for the named controller
+func (m *Manager) ResyncPeriod(name string) time.Duration {
+	if period, ok := m.resyncPeriods[name]; ok && period > 0 {
+		return period
+	}
+	return m.syncInterval
 }
 
-// syncLoop continuously syncs all controllers
-func (m *Manager) syncLoop(ctx context.Context) {
-	ticker := time.NewTicker(m.syncInterval)
-	defer ticker.Stop()
+// Jitter returns a duration between period and period*(1+maxFactor)
+func Jitter(period time.Duration, maxFactor float64) time.Duration {
+	if maxFactor <= 0 {
+		return period
+	}
+	return period + time.Duration(rand.Float64()*maxFactor*float64(period))
+}
+
+// JitterUntil calls fn every jittered period until ctx is done or stopCh is closed.
+// The period is re-jittered on every wakeup so that loops started together drift apart.
+func JitterUntil(ctx context.Context, stopCh <-chan struct{}, period time.Duration, maxFactor float64, fn func()) {
+	timer := time.NewTimer(Jitter(period, maxFactor))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-m.stopCh:
+		case <-stopCh:
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			// Check context again before syncing
 			select {
 			case <-ctx.Done():
 				return
 			default:
-				if err := m.syncAll(ctx); err != nil {
-					// Log error but continue
-					fmt.Printf("Error syncing controllers: %v\n", err)
-				}
+				fn()
 			}
+			timer.Reset(Jitter(period, maxFactor))
 		}
 	}
 }
 
-// syncAll syncs all controllers
-func (m *Manager) syncAll(ctx context.Context) error {
-	m.mu.RLock()
-	controllers := make([]Controller, 0, len(m.controllers))
-	for _, controller := range m.controllers {
-		controllers = append(controllers, controller)
-	}
-	m.mu.RUnlock()
-
-	// Sync each controller
-	for _, controller := range controllers {
-		if err := controller.Sync(ctx); err != nil {
-			fmt.Printf("Error syncing controller %s: %v\n", controller.Name(), err)
-		}
-	}
-
-	return nil
-}
-
 // GetController returns a controller by name
 func (m *Manager) GetController(name string) Controller {
 	m.mu.RLock()
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ type MockController struct {
 	name    string
 	running bool
 	stopCh  chan struct{}
+	syncs   int32
 }
 
 func NewMockController(name string) *MockController {
@@ -38,6 +40,7 @@ func (m *MockController) Stop() error {
 }
 
 func (m *MockController) Sync(ctx context.Context) error {
+	atomic.AddInt32(&m.syncs, 1)
 	return nil
 }
 
@@ -293,3 +296,152 @@ func TestControllerManager_ContextCancellation(t *testing.T) {
 		t.Error("Manager should not be running after Stop()")
 	}
 }
+
+func TestControllerManager_ResyncPeriod(t *testing.T) {
+	// Create mock store
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+
+	// Create controller manager with a per-controller override
+	config := &Config{
+		Store:        mockStore,
+		SyncInterval: 30 * time.Second,
+		ResyncPeriods: map[string]time.Duration{
+			"fast-controller": 5 * time.Second,
+		},
+	}
+	manager := NewManager(config)
+
+	if got := manager.ResyncPeriod("fast-controller"); got != 5*time.Second {
+		t.Errorf("Expected overridden resync period 5s, got %v", got)
+	}
+
+	if got := manager.ResyncPeriod("other-controller"); got != 30*time.Second {
+		t.Errorf("Expected default resync period 30s, got %v", got)
+	}
+
+	if manager.resyncJitter != DefaultResyncJitter {
+		t.Errorf("Expected default jitter %v, got %v", DefaultResyncJitter, manager.resyncJitter)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	period := 100 * time.Millisecond
+
+	for i := 0; i < 100; i++ {
+		got := Jitter(period, 0.5)
+		if got < period || got > period+period/2 {
+			t.Fatalf("Jittered period %v out of range [%v, %v]", got, period, period+period/2)
+		}
+	}
+
+	if got := Jitter(period, 0); got != period {
+		t.Errorf("Expected no jitter for zero factor, got %v", got)
+	}
+
+	if got := Jitter(period, -1); got != period {
+		t.Errorf("Expected no jitter for negative factor, got %v", got)
+	}
+}
+
+func TestControllerManager_PerControllerResync(t *testing.T) {
+	// Create mock store
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+
+	// Only the fast controller has a short resync period
+	config := &Config{
+		Store:        mockStore,
+		SyncInterval: time.Hour,
+		ResyncPeriods: map[string]time.Duration{
+			"fast-controller": 10 * time.Millisecond,
+		},
+	}
+	manager := NewManager(config)
+
+	fast := NewMockController("fast-controller")
+	slow := NewMockController("slow-controller")
+	manager.AddController(fast)
+	manager.AddController(slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := manager.Start(ctx); err != nil {
+		t.Fatalf("Failed to start manager: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	manager.Stop()
+
+	if atomic.LoadInt32(&fast.syncs) == 0 {
+		t.Error("Fast controller should have been resynced")
+	}
+
+	if syncs := atomic.LoadInt32(&slow.syncs); syncs != 0 {
+		t.Errorf("Slow controller should not have been resynced, got %d syncs", syncs)
+	}
+}
+
+func TestControllerManager_ResyncPassedToControllers(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	manager := NewManager(&Config{
+		Store:         mockStore,
+		ResyncJitter:  -1,
+		ResyncPeriods: map[string]time.Duration{"deployment-controller": time.Minute},
+	})
+
+	deployments := NewDeploymentController(mockStore)
+	replicaSets := NewReplicaSetController(mockStore)
+	manager.AddController(deployments)
+	manager.AddController(replicaSets)
+
+	if deployments.resyncJitter != -1 {
+		t.Errorf("Expected deployment controller jitter -1, got %v", deployments.resyncJitter)
+	}
+	if replicaSets.resyncJitter != -1 {
+		t.Errorf("Expected replicaset controller jitter -1, got %v", replicaSets.resyncJitter)
+	}
+
+	// A configured period replaces the controller's own, which is kept otherwise
+	if deployments.resyncPeriod != time.Minute {
+		t.Errorf("Expected deployment controller period 1m, got %v", deployments.resyncPeriod)
+	}
+	if replicaSets.resyncPeriod != defaultWatchResyncPeriod {
+		t.Errorf("Expected replicaset controller period %v, got %v", defaultWatchResyncPeriod, replicaSets.resyncPeriod)
+	}
+}
+
+// slowController takes a while to sync, recording syncs that finished
+type slowController struct {
+	*MockController
+	started  chan struct{}
+	finished int32
+}
+
+func (c *slowController) Sync(ctx context.Context) error {
+	select {
+	case c.started <- struct{}{}:
+	default:
+	}
+	time.Sleep(50 * time.Millisecond)
+	atomic.AddInt32(&c.finished, 1)
+	return nil
+}
+
+func TestControllerManager_StopWaitsForSyncs(t *testing.T) {
+	manager := NewManager(&Config{
+		Store:        store.NewMemoryStore(store.DefaultOptions()),
+		SyncInterval: time.Millisecond,
+		ResyncJitter: -1,
+	})
+	ctrl := &slowController{MockController: NewMockController("slow-controller"), started: make(chan struct{}, 1)}
+	manager.AddController(ctrl)
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start manager: %v", err)
+	}
+	<-ctrl.started
+	manager.Stop()
+
+	if atomic.LoadInt32(&ctrl.finished) == 0 {
+		t.Error("Stop returned before the sync in flight finished")
+	}
+}
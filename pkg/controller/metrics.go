@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/minik8s/minik8s/pkg/httpclient"
+)
+
+// ExternalMetricsAdapter supplies the values of external metrics, such as
+// queue lengths, to the horizontal pod autoscaler controller. Adapters for
+// new metric sources implement it and are passed to
+// NewHorizontalPodAutoscalerController.
+type ExternalMetricsAdapter interface {
+	// GetExternalMetric returns the current value of the named metric for
+	// an autoscaler in namespace, narrowed down by selector
+	GetExternalMetric(ctx context.Context, namespace, name string, selector map[string]string) (int64, error)
+}
+
+// HTTPMetricsAdapter reads external metrics from an HTTP endpoint. A metric
+// is read with GET <base>/namespaces/<namespace>/<metric>, the selector
+// passed as a labelSelector query parameter, and the response is a JSON
+// object holding the metric's value, e.g. {"value": 42}.
+type HTTPMetricsAdapter struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+// NewHTTPMetricsAdapter creates an adapter reading metrics from baseURL
+func NewHTTPMetricsAdapter(baseURL string) *HTTPMetricsAdapter {
+	return &HTTPMetricsAdapter{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  httpclient.New(nil),
+	}
+}
+
+// GetExternalMetric fetches the metric's current value
+func (a *HTTPMetricsAdapter) GetExternalMetric(ctx context.Context, namespace, name string, selector map[string]string) (int64, error) {
+	endpoint := fmt.Sprintf("%s/namespaces/%s/%s", a.baseURL, url.PathEscape(namespace), url.PathEscape(name))
+	if len(selector) > 0 {
+		endpoint += "?labelSelector=" + url.QueryEscape(formatSelector(selector))
+	}
+
+	resp, err := a.client.Get(ctx, endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get metric %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("failed to get metric %s: %s - %s", name, resp.Status, string(body))
+	}
+
+	var value struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&value); err != nil {
+		return 0, fmt.Errorf("failed to decode metric %s: %w", name, err)
+	}
+	return value.Value, nil
+}
+
+// formatSelector formats selector as key=value pairs in key order
+func formatSelector(selector map[string]string) string {
+	pairs := make([]string, 0, len(selector))
+	for key, value := range selector {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// StaticMetricsAdapter serves metric values set in process, for tests and
+// for embedding programs that already know their queue lengths
+type StaticMetricsAdapter struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+// NewStaticMetricsAdapter creates an adapter with no metrics set
+func NewStaticMetricsAdapter() *StaticMetricsAdapter {
+	return &StaticMetricsAdapter{values: make(map[string]int64)}
+}
+
+// Set sets the value of the named metric in namespace
+func (a *StaticMetricsAdapter) Set(namespace, name string, value int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.values[namespace+"/"+name] = value
+}
+
+// GetExternalMetric returns the metric's value; the selector is ignored
+func (a *StaticMetricsAdapter) GetExternalMetric(ctx context.Context, namespace, name string, selector map[string]string) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	value, ok := a.values[namespace+"/"+name]
+	if !ok {
+		return 0, fmt.Errorf("metric %s not found in namespace %s", name, namespace)
+	}
+	return value, nil
+}
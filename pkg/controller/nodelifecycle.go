@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const (
+	// DefaultNodeMonitorGracePeriod is how long a node's heartbeat may go
+	// without advancing before a check counts as missed. Node agents only
+	// rewrite an unchanged status every few minutes, so this is longer than
+	// their default status max staleness.
+	DefaultNodeMonitorGracePeriod = 6 * time.Minute
+	// DefaultNodeClockSkewTolerance is how far a node's clock may be behind
+	// the controller's before its heartbeat timestamps look stale
+	DefaultNodeClockSkewTolerance = 30 * time.Second
+	// DefaultNodeMissedHeartbeats is how many missed checks within the window
+	// mark a node NotReady
+	DefaultNodeMissedHeartbeats = 3
+	// DefaultNodeHeartbeatWindow is how many of the most recent checks are
+	// considered
+	DefaultNodeHeartbeatWindow = 5
+	// DefaultUnreachableToleration is how long pods without a toleration
+	// for the unreachable taint stay bound to an unreachable node before
+	// they're evicted
+	DefaultUnreachableToleration = 5 * time.Minute
+)
+
+// nodeStatusUnknownReason marks Ready conditions set by the controller
+// rather than the node agent
+const nodeStatusUnknownReason = "NodeStatusUnknown"
+
+// NodeLifecycleConfig holds the node lifecycle controller's heartbeat
+// parameters
+type NodeLifecycleConfig struct {
+	// GracePeriod is how long a heartbeat may go without advancing before a
+	// check counts as missed
+	GracePeriod time.Duration
+	// ClockSkewTolerance is how far heartbeat timestamps are trusted to be
+	// behind the controller's clock
+	ClockSkewTolerance time.Duration
+	// MissedHeartbeats is how many of the last HeartbeatWindow checks must
+	// have missed before the node is marked NotReady
+	MissedHeartbeats int
+	HeartbeatWindow  int
+	// UnreachableToleration is how long pods that don't tolerate the
+	// unreachable taint themselves are kept on an unreachable node
+	UnreachableToleration time.Duration
+}
+
+// nodeHeartbeat is what the controller remembers about a node between checks
+type nodeHeartbeat struct {
+	// heartbeat is the last heartbeat timestamp reported by the node
+	heartbeat time.Time
+	// observed is when that heartbeat was first seen, on the controller's clock
+	observed time.Time
+	// missed holds the outcome of the most recent checks, oldest first
+	missed []bool
+}
+
+// NodeLifecycleController marks nodes whose heartbeats stopped as NotReady
+// and taints them unreachable. Heartbeats are timed on the controller's
+// clock by noticing when they advance, so nodes with skewed clocks aren't
+// judged by their own timestamps, and a node is only marked NotReady after
+// several missed checks rather than the first late one, which keeps loaded
+// machines from flapping. Pods on nodes with NoExecute taints are evicted
+// once they stop tolerating them; see evictTaintedPods.
+type NodeLifecycleController struct {
+	store    store.Store
+	name     string
+	config   NodeLifecycleConfig
+	nodes    map[string]*nodeHeartbeat
+	recorder *events.Recorder
+	now      func() time.Time
+
+	// taintsSeen holds when the controller first saw the NoExecute taints
+	// without a TimeAdded, by taintKey
+	taintsSeen map[string]time.Time
+}
+
+// NewNodeLifecycleController creates a node lifecycle controller. Zero
+// config fields take their defaults.
+func NewNodeLifecycleController(store store.Store, config NodeLifecycleConfig) *NodeLifecycleController {
+	if config.GracePeriod == 0 {
+		config.GracePeriod = DefaultNodeMonitorGracePeriod
+	}
+	if config.ClockSkewTolerance == 0 {
+		config.ClockSkewTolerance = DefaultNodeClockSkewTolerance
+	}
+	if config.HeartbeatWindow <= 0 {
+		config.HeartbeatWindow = DefaultNodeHeartbeatWindow
+	}
+	if config.MissedHeartbeats <= 0 {
+		config.MissedHeartbeats = DefaultNodeMissedHeartbeats
+	}
+	if config.MissedHeartbeats > config.HeartbeatWindow {
+		config.MissedHeartbeats = config.HeartbeatWindow
+	}
+	if config.UnreachableToleration == 0 {
+		config.UnreachableToleration = DefaultUnreachableToleration
+	}
+
+	return &NodeLifecycleController{
+		store:      store,
+		name:       "node-lifecycle-controller",
+		config:     config,
+		nodes:      make(map[string]*nodeHeartbeat),
+		now:        time.Now,
+		taintsSeen: make(map[string]time.Time),
+	}
+}
+
+// SetEventRecorder makes the controller record the pods it evicts as
+// events on them
+func (n *NodeLifecycleController) SetEventRecorder(recorder *events.Recorder) {
+	n.recorder = recorder
+}
+
+// Name returns the name of the controller
+func (n *NodeLifecycleController) Name() string {
+	return n.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (n *NodeLifecycleController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (n *NodeLifecycleController) Stop() error {
+	return nil
+}
+
+// Sync checks every node's heartbeat once, then evicts the pods that no
+// longer tolerate their node's NoExecute taints
+func (n *NodeLifecycleController) Sync(ctx context.Context) error {
+	objs, err := n.store.List(ctx, "Node", "")
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	now := n.now()
+	seen := make(map[string]bool)
+	var nodes []*api.Node
+	for _, obj := range objs {
+		node, ok := obj.(*api.Node)
+		if !ok {
+			continue
+		}
+		seen[node.Name] = true
+		nodes = append(nodes, node)
+
+		if err := n.checkNode(ctx, node, now); err != nil {
+			fmt.Printf("Failed to update node %s: %v\n", node.Name, err)
+		}
+	}
+
+	for name := range n.nodes {
+		if !seen[name] {
+			delete(n.nodes, name)
+		}
+	}
+
+	return n.evictTaintedPods(ctx, nodes, now)
+}
+
+// checkNode records one check of node's heartbeat and updates its Ready
+// condition when the outcome warrants it
+func (n *NodeLifecycleController) checkNode(ctx context.Context, node *api.Node, now time.Time) error {
+	condition := nodeCondition(node, api.NodeReady)
+	if condition == nil {
+		return nil
+	}
+
+	state, known := n.nodes[node.Name]
+	advanced := false
+	switch {
+	case !known:
+		// A heartbeat seen for the first time is only as fresh as its
+		// timestamp, give or take the tolerated skew
+		observed := condition.LastHeartbeatTime.Add(n.config.ClockSkewTolerance)
+		if observed.After(now) {
+			observed = now
+		}
+		state = &nodeHeartbeat{heartbeat: condition.LastHeartbeatTime, observed: observed}
+		n.nodes[node.Name] = state
+	case !condition.LastHeartbeatTime.Equal(state.heartbeat):
+		// Any change counts, so a node whose clock stepped backwards is
+		// still alive
+		state.heartbeat = condition.LastHeartbeatTime
+		state.observed = now
+		advanced = true
+	}
+
+	missed := now.Sub(state.observed) > n.config.GracePeriod
+	state.missed = append(state.missed, missed)
+	if len(state.missed) > n.config.HeartbeatWindow {
+		state.missed = state.missed[len(state.missed)-n.config.HeartbeatWindow:]
+	}
+
+	if !missed {
+		// Restore nodes the controller marked NotReady whose agents resumed
+		// heartbeating without rewriting their status
+		if advanced && condition.Reason == nodeStatusUnknownReason {
+			return n.setReady(ctx, node, api.ConditionTrue, "NodeHeartbeatResumed",
+				"Node agent resumed posting node status.", now)
+		}
+		// Agents rewriting their status mark the node Ready themselves
+		if condition.Status == api.ConditionTrue && removeTaint(node, api.TaintNodeUnreachable, api.TaintEffectNoExecute) {
+			return n.store.Update(ctx, node)
+		}
+		return nil
+	}
+
+	misses := 0
+	for _, m := range state.missed {
+		if m {
+			misses++
+		}
+	}
+	if misses < n.config.MissedHeartbeats || condition.Status != api.ConditionTrue {
+		return nil
+	}
+
+	fmt.Printf("Node %s missed %d of the last %d heartbeat checks, marking it NotReady\n",
+		node.Name, misses, len(state.missed))
+	return n.setReady(ctx, node, api.ConditionUnknown, nodeStatusUnknownReason,
+		fmt.Sprintf("Node agent stopped posting node status; last heartbeat %s.",
+			state.heartbeat.Format(time.RFC3339)), now)
+}
+
+// setReady updates node's Ready condition, tainting the node unreachable
+// while the status is unknown
+func (n *NodeLifecycleController) setReady(ctx context.Context, node *api.Node, status api.ConditionStatus, reason, message string, now time.Time) error {
+	condition := nodeCondition(node, api.NodeReady)
+	condition.Status = status
+	condition.Reason = reason
+	condition.Message = message
+	condition.LastTransitionTime = now
+
+	removeTaint(node, api.TaintNodeUnreachable, api.TaintEffectNoExecute)
+	if status == api.ConditionUnknown {
+		node.Spec.Taints = append(node.Spec.Taints, api.Taint{
+			Key:       api.TaintNodeUnreachable,
+			Effect:    api.TaintEffectNoExecute,
+			TimeAdded: &now,
+		})
+	}
+	return n.store.Update(ctx, node)
+}
+
+// removeTaint removes node's taints with the given key and effect,
+// reporting whether it had any
+func removeTaint(node *api.Node, key, effect string) bool {
+	kept := node.Spec.Taints[:0:0]
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != key || taint.Effect != effect {
+			kept = append(kept, taint)
+		}
+	}
+	removed := len(kept) != len(node.Spec.Taints)
+	if removed {
+		node.Spec.Taints = kept
+	}
+	return removed
+}
+
+// nodeCondition returns node's condition of the given type, or nil
+func nodeCondition(node *api.Node, conditionType api.NodeConditionType) *api.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
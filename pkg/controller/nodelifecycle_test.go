@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func newTestNode(name string, heartbeat time.Time) *api.Node {
+	return &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Status: api.NodeStatus{
+			Conditions: []api.NodeCondition{{
+				Type:              "Ready",
+				Status:            "True",
+				LastHeartbeatTime: heartbeat,
+			}},
+		},
+	}
+}
+
+func readyStatus(t *testing.T, s store.Store, name string) api.ConditionStatus {
+	t.Helper()
+	obj, err := s.Get(context.Background(), "Node", "", name)
+	if err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	return nodeCondition(obj.(*api.Node), api.NodeReady).Status
+}
+
+func TestNodeLifecycleMissedHeartbeats(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewNodeLifecycleController(mockStore, NodeLifecycleConfig{
+		GracePeriod:      time.Minute,
+		MissedHeartbeats: 2,
+		HeartbeatWindow:  3,
+	})
+
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+	if err := mockStore.Create(ctx, newTestNode("worker", now)); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+
+	// The first missed check alone doesn't mark the node NotReady
+	for _, step := range []struct {
+		after time.Duration
+		want  api.ConditionStatus
+	}{
+		{0, "True"},
+		{2 * time.Minute, "True"},
+		{30 * time.Second, "Unknown"},
+	} {
+		now = now.Add(step.after)
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		if got := readyStatus(t, mockStore, "worker"); got != step.want {
+			t.Fatalf("Expected Ready %s after %v, got %s", step.want, step.after, got)
+		}
+	}
+
+	// A heartbeat that advances brings the node back
+	obj, _ := mockStore.Get(ctx, "Node", "", "worker")
+	nodeCondition(obj.(*api.Node), "Ready").LastHeartbeatTime = now
+	now = now.Add(10 * time.Second)
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if got := readyStatus(t, mockStore, "worker"); got != "True" {
+		t.Errorf("Expected Ready True after heartbeat resumed, got %s", got)
+	}
+}
+
+func TestNodeLifecycleClockSkew(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewNodeLifecycleController(mockStore, NodeLifecycleConfig{
+		GracePeriod:        time.Minute,
+		ClockSkewTolerance: 5 * time.Minute,
+		MissedHeartbeats:   1,
+		HeartbeatWindow:    1,
+	})
+
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+
+	// This node's clock runs four minutes behind; its heartbeats keep
+	// advancing, so it stays Ready however old they look
+	skewed := newTestNode("skewed", now.Add(-4*time.Minute))
+	if err := mockStore.Create(ctx, skewed); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	// This one stopped heartbeating long ago
+	if err := mockStore.Create(ctx, newTestNode("dead", now.Add(-time.Hour))); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		if got := readyStatus(t, mockStore, "skewed"); got != "True" {
+			t.Fatalf("Expected skewed node to stay Ready, got %s", got)
+		}
+		now = now.Add(50 * time.Second)
+		nodeCondition(skewed, "Ready").LastHeartbeatTime = now.Add(-4 * time.Minute)
+	}
+
+	if got := readyStatus(t, mockStore, "dead"); got != "Unknown" {
+		t.Errorf("Expected dead node to be marked Unknown, got %s", got)
+	}
+}
+
+func TestNodeLifecycleTaintEviction(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewNodeLifecycleController(mockStore, NodeLifecycleConfig{
+		GracePeriod:           time.Minute,
+		MissedHeartbeats:      1,
+		HeartbeatWindow:       1,
+		UnreachableToleration: 5 * time.Minute,
+	})
+
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+	if err := mockStore.Create(ctx, newTestNode("worker", now)); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+
+	seconds := func(s int64) *int64 { return &s }
+	for name, tolerations := range map[string][]api.Toleration{
+		"default":   nil,
+		"short":     {{Key: api.TaintNodeUnreachable, Operator: api.TolerationOpExists, Effect: api.TaintEffectNoExecute, TolerationSeconds: seconds(60)}},
+		"long":      {{Key: api.TaintNodeUnreachable, Operator: api.TolerationOpExists, TolerationSeconds: seconds(3600)}},
+		"forever":   {{Operator: api.TolerationOpExists}},
+		"elsewhere": nil,
+	} {
+		pod := &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       api.PodSpec{NodeName: "worker", Tolerations: tolerations},
+			Status:     api.PodStatus{Phase: api.PodRunning},
+		}
+		if name == "elsewhere" {
+			pod.Spec.NodeName = "other"
+		}
+		if err := mockStore.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	remaining := func() map[string]bool {
+		objs, err := mockStore.List(ctx, "Pod", "default")
+		if err != nil {
+			t.Fatalf("Failed to list pods: %v", err)
+		}
+		names := make(map[string]bool)
+		for _, obj := range objs {
+			names[obj.GetName()] = true
+		}
+		return names
+	}
+
+	// The node goes unreachable and is tainted; no pod is evicted yet
+	now = now.Add(2 * time.Minute)
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	obj, _ := mockStore.Get(ctx, "Node", "", "worker")
+	node := obj.(*api.Node)
+	if len(node.Spec.Taints) != 1 || node.Spec.Taints[0].Key != api.TaintNodeUnreachable || node.Spec.Taints[0].Effect != api.TaintEffectNoExecute {
+		t.Fatalf("Expected the node to be tainted unreachable, got %+v", node.Spec.Taints)
+	}
+	if got := remaining(); len(got) != 5 {
+		t.Fatalf("Expected no pod to be evicted yet, got %v", got)
+	}
+
+	// Each pod is evicted once its toleration runs out
+	for _, step := range []struct {
+		after   time.Duration
+		evicted []string
+	}{
+		{time.Minute, []string{"short"}},
+		{5 * time.Minute, []string{"short", "default"}},
+		{time.Hour, []string{"short", "default", "long"}},
+	} {
+		now = now.Add(step.after)
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		got := remaining()
+		for _, name := range step.evicted {
+			if got[name] {
+				t.Errorf("Expected pod %s to be evicted after %v", name, step.after)
+			}
+		}
+		if len(got) != 5-len(step.evicted) {
+			t.Errorf("Expected %d pods left after %v, got %v", 5-len(step.evicted), step.after, got)
+		}
+	}
+
+	// The taint is removed once the agent reports the node Ready again
+	obj, _ = mockStore.Get(ctx, "Node", "", "worker")
+	node = obj.(*api.Node)
+	condition := nodeCondition(node, "Ready")
+	condition.Status, condition.Reason, condition.LastHeartbeatTime = "True", "KubeletReady", now
+	now = now.Add(10 * time.Second)
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	obj, _ = mockStore.Get(ctx, "Node", "", "worker")
+	if taints := obj.(*api.Node).Spec.Taints; len(taints) != 0 {
+		t.Errorf("Expected the unreachable taint to be removed, got %+v", taints)
+	}
+}
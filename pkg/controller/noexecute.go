@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// taintKey identifies a NoExecute taint of a node in taintsSeen
+func taintKey(node string, taint api.Taint) string {
+	return node + "/" + taint.Key + "=" + taint.Value
+}
+
+// evictTaintedPods evicts the pods bound to nodes with NoExecute taints
+// they don't tolerate, right away, or once the shortest tolerationSeconds
+// of the tolerations matching a taint has passed since it was added. Pods
+// without a toleration for the unreachable taint tolerate it for
+// UnreachableToleration, so short network blips don't churn workloads.
+// Evictions happen on the first sync after the deadline.
+func (n *NodeLifecycleController) evictTaintedPods(ctx context.Context, nodes []*api.Node, now time.Time) error {
+	tainted := make(map[string][]api.Taint)
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect != api.TaintEffectNoExecute {
+				continue
+			}
+			tainted[node.Name] = append(tainted[node.Name], taint)
+			key := taintKey(node.Name, taint)
+			seen[key] = true
+			if _, ok := n.taintsSeen[key]; !ok {
+				n.taintsSeen[key] = now
+			}
+		}
+	}
+	for key := range n.taintsSeen {
+		if !seen[key] {
+			delete(n.taintsSeen, key)
+		}
+	}
+	if len(tainted) == 0 {
+		return nil
+	}
+
+	objs, err := n.store.List(ctx, "Pod", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if !ok || pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		taints := tainted[pod.Spec.NodeName]
+		if len(taints) == 0 {
+			continue
+		}
+
+		taint, deadline, evict := n.evictionDeadline(pod, taints)
+		if !evict || now.Before(deadline) {
+			continue
+		}
+
+		fmt.Printf("Evicting pod %s/%s from node %s: taint %s no longer tolerated\n",
+			pod.Namespace, pod.Name, pod.Spec.NodeName, taint)
+		if err := n.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil {
+			fmt.Printf("Failed to evict pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+			continue
+		}
+		n.recorder.Eventf(ctx, pod, api.EventTypeNormal, "TaintEviction",
+			"Evicted from node %s: taint %s no longer tolerated", pod.Spec.NodeName, taint)
+	}
+	return nil
+}
+
+// evictionDeadline returns the NoExecute taint of taints that pod stops
+// tolerating first and when, or false if it tolerates them all forever
+func (n *NodeLifecycleController) evictionDeadline(pod *api.Pod, taints []api.Taint) (api.Taint, time.Time, bool) {
+	var first api.Taint
+	var deadline time.Time
+	found := false
+	for _, taint := range taints {
+		toleration, forever := n.tolerationFor(pod, taint)
+		if forever {
+			continue
+		}
+		added := n.taintsSeen[taintKey(pod.Spec.NodeName, taint)]
+		if taint.TimeAdded != nil {
+			added = *taint.TimeAdded
+		}
+		if at := added.Add(toleration); !found || at.Before(deadline) {
+			first, deadline, found = taint, at, true
+		}
+	}
+	return first, deadline, found
+}
+
+// tolerationFor returns how long pod tolerates taint, or true if it does
+// forever. Of several matching tolerations the shortest tolerationSeconds
+// wins; they only tolerate the taint forever if none sets one.
+func (n *NodeLifecycleController) tolerationFor(pod *api.Pod, taint api.Taint) (time.Duration, bool) {
+	matched := false
+	var shortest *int64
+	for _, toleration := range pod.Spec.Tolerations {
+		if !toleration.Tolerates(taint) {
+			continue
+		}
+		matched = true
+		if toleration.TolerationSeconds != nil && (shortest == nil || *toleration.TolerationSeconds < *shortest) {
+			shortest = toleration.TolerationSeconds
+		}
+	}
+
+	switch {
+	case !matched && taint.Key == api.TaintNodeUnreachable:
+		return n.config.UnreachableToleration, false
+	case !matched:
+		return 0, false
+	case shortest == nil:
+		return 0, true
+	case *shortest < 0:
+		return 0, false
+	}
+	return time.Duration(*shortest) * time.Second, false
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// podReadySince reports whether pod is ready and since when. Pods carrying a
+// Ready condition are judged by it; pods without one (no readiness probes
+// reported yet) are ready once they're running.
+func podReadySince(pod *api.Pod) (bool, time.Time) {
+	if condition := api.GetPodCondition(&pod.Status, api.PodConditionReady); condition != nil {
+		return condition.Status == api.ConditionTrue, condition.LastTransitionTime
+	}
+
+	if pod.Status.Phase != api.PodRunning {
+		return false, time.Time{}
+	}
+	if pod.Status.StartTime != nil {
+		return true, *pod.Status.StartTime
+	}
+	return true, time.Time{}
+}
+
+// isPodAvailable reports whether pod has been ready for at least
+// minReadySeconds as of now
+func isPodAvailable(pod *api.Pod, minReadySeconds int32, now time.Time) bool {
+	ready, since := podReadySince(pod)
+	if !ready {
+		return false
+	}
+	if minReadySeconds <= 0 {
+		return true
+	}
+	// A ready pod that doesn't say when it became ready can't be shown to
+	// have stayed ready long enough
+	if since.IsZero() {
+		return false
+	}
+	return !now.Before(since.Add(time.Duration(minReadySeconds) * time.Second))
+}
+
+// countReadyPods returns how many of pods are ready and how many of those are
+// available, i.e. have been ready for minReadySeconds
+func countReadyPods(pods []*api.Pod, minReadySeconds int32, now time.Time) (ready, available int32) {
+	for _, pod := range pods {
+		if ok, _ := podReadySince(pod); !ok {
+			continue
+		}
+		ready++
+		if isPodAvailable(pod, minReadySeconds, now) {
+			available++
+		}
+	}
+	return ready, available
+}
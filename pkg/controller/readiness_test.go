@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func readyPod(name string, ready bool, since time.Time) *api.Pod {
+	status := api.ConditionFalse
+	if ready {
+		status = api.ConditionTrue
+	}
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+		Status: api.PodStatus{
+			Phase: api.PodRunning,
+			Conditions: []api.PodCondition{
+				{Type: "Ready", Status: status, LastTransitionTime: since},
+			},
+		},
+	}
+}
+
+func TestIsPodAvailable(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-5 * time.Second)
+
+	tests := []struct {
+		name            string
+		pod             *api.Pod
+		minReadySeconds int32
+		want            bool
+	}{
+		{"ready", readyPod("a", true, now), 0, true},
+		{"not ready", readyPod("a", false, now.Add(-time.Hour)), 0, false},
+		{"ready long enough", readyPod("a", true, now.Add(-10*time.Second)), 10, true},
+		{"ready too briefly", readyPod("a", true, now.Add(-9*time.Second)), 10, false},
+		{"running without condition", &api.Pod{Status: api.PodStatus{Phase: api.PodRunning, StartTime: &started}}, 5, true},
+		{"running without condition or start time", &api.Pod{Status: api.PodStatus{Phase: api.PodRunning}}, 5, false},
+		{"pending", &api.Pod{Status: api.PodStatus{Phase: api.PodPending}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPodAvailable(tt.pod, tt.minReadySeconds, now); got != tt.want {
+				t.Errorf("isPodAvailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplicaSetController_MinReadySeconds(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewReplicaSetController(mockStore)
+
+	replicaSet := &api.ReplicaSet{
+		TypeMeta:   api.TypeMeta{Kind: "ReplicaSet", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "rs-uid"},
+		Spec: api.ReplicaSetSpec{
+			Replicas:        3,
+			MinReadySeconds: 30,
+			Selector:        &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+
+	ctx := context.Background()
+	if err := mockStore.Create(ctx, replicaSet); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+
+	now := time.Now()
+	state := &ReplicaSetState{
+		ReplicaSet: replicaSet,
+		Pods: []*api.Pod{
+			readyPod("web-1", true, now.Add(-time.Minute)),
+			readyPod("web-2", true, now),
+			readyPod("web-3", false, now),
+		},
+	}
+
+	if err := ctrl.updateReplicaSetStatus(ctx, replicaSet, state); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+
+	if replicaSet.Status.ReadyReplicas != 2 {
+		t.Errorf("Expected 2 ready replicas, got %d", replicaSet.Status.ReadyReplicas)
+	}
+	if replicaSet.Status.AvailableReplicas != 1 {
+		t.Errorf("Expected 1 available replica, got %d", replicaSet.Status.AvailableReplicas)
+	}
+}
+
+func TestDeploymentController_AvailableReplicas(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewDeploymentController(mockStore)
+
+	deployment := &api.Deployment{
+		TypeMeta:   api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "deploy-uid"},
+		Spec: api.DeploymentSpec{
+			Replicas:        2,
+			MinReadySeconds: 30,
+			Selector:        &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+
+	ctx := context.Background()
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+
+	now := time.Now()
+	pods := []*api.Pod{
+		readyPod("web-1", true, now.Add(-time.Minute)),
+		readyPod("web-2", true, now),
+	}
+	if err := ctrl.updateDeploymentStatus(ctx, deployment, pods); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+
+	obj, err := mockStore.Get(ctx, "Deployment", "default", "web")
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	status := obj.(*api.Deployment).Status
+	if status.Replicas != 2 {
+		t.Errorf("Expected 2 replicas, got %d", status.Replicas)
+	}
+	if status.AvailableReplicas != 1 {
+		t.Errorf("Expected 1 available replica, got %d", status.AvailableReplicas)
+	}
+	if status.UnavailableReplicas != 1 {
+		t.Errorf("Expected 1 unavailable replica, got %d", status.UnavailableReplicas)
+	}
+}
@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const (
+	// DefaultRecommendationWindow is how long usage samples are kept
+	DefaultRecommendationWindow = time.Hour
+	// DefaultRecommendationMinSamples is how many samples a container needs
+	// before requests are recommended for it
+	DefaultRecommendationMinSamples = 3
+	// DefaultRecommendationMargin is the headroom added to the peak usage,
+	// as a fraction of it
+	DefaultRecommendationMargin = 0.15
+)
+
+// Floors and rounding of recommended requests, so containers that sat idle
+// aren't starved
+const (
+	minRecommendedCPUMillicores = 10
+	minRecommendedMemoryBytes   = 16 << 20
+	recommendedCPUStep          = 10
+	recommendedMemoryStep       = 1 << 20
+)
+
+// RecommenderConfig holds the resource recommender's parameters
+type RecommenderConfig struct {
+	// Window is how far back usage samples are considered
+	Window time.Duration
+	// MinSamples is how many samples a container needs to get a recommendation
+	MinSamples int
+	// Margin is the headroom added to the peak usage, as a fraction of it
+	Margin float64
+}
+
+// usageSample is a container's usage at one time
+type usageSample struct {
+	at     time.Time
+	cpu    int64
+	memory int64
+}
+
+// containerUsageHistory holds the usage samples seen for one container of a
+// deployment, across all of its pods
+type containerUsageHistory struct {
+	samples []usageSample
+	// seen holds the timestamp of the last sample taken from each pod, as
+	// pods report the same sample until they sample again
+	seen map[string]time.Time
+}
+
+// ResourceRecommenderController recommends resource requests for the
+// containers of deployments annotated with
+// minik8s.io/vertical-autoscaling. It samples the usage pods report over a
+// window and records the peak plus a margin in the
+// minik8s.io/recommended-requests annotation; deployments in Auto mode get
+// the recommendations applied to the ReplicaSet of their next rollout.
+type ResourceRecommenderController struct {
+	store   store.Store
+	name    string
+	config  RecommenderConfig
+	history map[string]*containerUsageHistory
+	now     func() time.Time
+}
+
+// NewResourceRecommenderController creates a resource recommender. Zero
+// config fields take their defaults.
+func NewResourceRecommenderController(store store.Store, config RecommenderConfig) *ResourceRecommenderController {
+	if config.Window <= 0 {
+		config.Window = DefaultRecommendationWindow
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = DefaultRecommendationMinSamples
+	}
+	if config.Margin <= 0 {
+		config.Margin = DefaultRecommendationMargin
+	}
+
+	return &ResourceRecommenderController{
+		store:   store,
+		name:    "resource-recommender-controller",
+		config:  config,
+		history: make(map[string]*containerUsageHistory),
+		now:     time.Now,
+	}
+}
+
+// Name returns the name of the controller
+func (r *ResourceRecommenderController) Name() string {
+	return r.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (r *ResourceRecommenderController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (r *ResourceRecommenderController) Stop() error {
+	return nil
+}
+
+// Sync samples the usage of every opted-in deployment's pods and updates
+// its recommendations
+func (r *ResourceRecommenderController) Sync(ctx context.Context) error {
+	objs, err := r.store.List(ctx, "Deployment", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	now := r.now()
+	tracked := make(map[string]bool)
+	for _, obj := range objs {
+		deployment, ok := obj.(*api.Deployment)
+		if !ok {
+			continue
+		}
+		switch deployment.Annotations[api.AnnotationVerticalAutoscaling] {
+		case api.VerticalAutoscalingRecommend, api.VerticalAutoscalingAuto:
+		default:
+			continue
+		}
+
+		if err := r.syncDeployment(ctx, deployment, now, tracked); err != nil {
+			fmt.Printf("Failed to recommend resources for deployment %s/%s: %v\n", deployment.Namespace, deployment.Name, err)
+		}
+	}
+
+	for key := range r.history {
+		if !tracked[key] {
+			delete(r.history, key)
+		}
+	}
+	return nil
+}
+
+// syncDeployment samples the deployment's pods and writes its
+// recommendations if they changed. The history keys it uses are added to
+// tracked.
+func (r *ResourceRecommenderController) syncDeployment(ctx context.Context, deployment *api.Deployment, now time.Time, tracked map[string]bool) error {
+	pods, err := r.store.List(ctx, "Pod", deployment.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		tracked[r.historyKey(deployment, container.Name)] = true
+	}
+	for _, obj := range pods {
+		pod, ok := obj.(*api.Pod)
+		if !ok || !selectorMatches(deployment.Spec.Selector, pod.Labels) {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Usage == nil {
+				continue
+			}
+			key := r.historyKey(deployment, status.Name)
+			if !tracked[key] {
+				continue
+			}
+			history, ok := r.history[key]
+			if !ok {
+				history = &containerUsageHistory{seen: make(map[string]time.Time)}
+				r.history[key] = history
+			}
+			if !status.Usage.Timestamp.After(history.seen[pod.UID]) {
+				continue
+			}
+			history.seen[pod.UID] = status.Usage.Timestamp
+			history.samples = append(history.samples, usageSample{
+				at:     status.Usage.Timestamp,
+				cpu:    status.Usage.CPUMillicores,
+				memory: status.Usage.MemoryBytes,
+			})
+		}
+	}
+
+	recommendations := make(map[string]api.ResourceList)
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		history, ok := r.history[r.historyKey(deployment, container.Name)]
+		if !ok {
+			continue
+		}
+		history.expire(now.Add(-r.config.Window))
+		if len(history.samples) < r.config.MinSamples {
+			continue
+		}
+		recommendations[container.Name] = r.recommend(history.samples)
+	}
+	if len(recommendations) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(recommendations)
+	if err != nil {
+		return fmt.Errorf("failed to encode recommendations: %w", err)
+	}
+	if deployment.Annotations[api.AnnotationRecommendedRequests] == string(encoded) {
+		return nil
+	}
+
+	updated := *deployment
+	updated.Annotations = make(map[string]string, len(deployment.Annotations)+1)
+	for key, value := range deployment.Annotations {
+		updated.Annotations[key] = value
+	}
+	updated.Annotations[api.AnnotationRecommendedRequests] = string(encoded)
+	if err := r.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update deployment: %w", err)
+	}
+	fmt.Printf("Recommended requests for deployment %s/%s: %s\n", deployment.Namespace, deployment.Name, encoded)
+	return nil
+}
+
+// historyKey identifies a container of a deployment in the history
+func (r *ResourceRecommenderController) historyKey(deployment *api.Deployment, container string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", deployment.Namespace, deployment.Name, deployment.UID, container)
+}
+
+// recommend returns the peak usage in samples plus the margin, rounded up
+func (r *ResourceRecommenderController) recommend(samples []usageSample) api.ResourceList {
+	var cpu, memory int64
+	for _, sample := range samples {
+		cpu = max(cpu, sample.cpu)
+		memory = max(memory, sample.memory)
+	}
+
+	cpu = roundUp(int64(float64(cpu)*(1+r.config.Margin)), recommendedCPUStep)
+	memory = roundUp(int64(float64(memory)*(1+r.config.Margin)), recommendedMemoryStep)
+	return api.ResourceList{
+		api.ResourceCPU:    fmt.Sprintf("%dm", max(cpu, minRecommendedCPUMillicores)),
+		api.ResourceMemory: fmt.Sprintf("%dMi", max(memory, minRecommendedMemoryBytes)/recommendedMemoryStep),
+	}
+}
+
+// expire drops the samples taken before cutoff
+func (h *containerUsageHistory) expire(cutoff time.Time) {
+	kept := h.samples[:0]
+	for _, sample := range h.samples {
+		if !sample.at.Before(cutoff) {
+			kept = append(kept, sample)
+		}
+	}
+	h.samples = kept
+}
+
+// roundUp rounds value up to a multiple of step
+func roundUp(value, step int64) int64 {
+	return (value + step - 1) / step * step
+}
+
+// selectorMatches reports whether labels satisfy selector. A missing or
+// empty selector matches nothing.
+func selectorMatches(selector *api.LabelSelector, labels map[string]string) bool {
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return false
+	}
+	for key, value := range selector.MatchLabels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// recommendedTemplate returns the deployment's pod template with the
+// recommended requests applied when the deployment is in Auto mode, leaving
+// the deployment's own template untouched
+func recommendedTemplate(deployment *api.Deployment) api.PodTemplateSpec {
+	template := deployment.Spec.Template
+	if deployment.Annotations[api.AnnotationVerticalAutoscaling] != api.VerticalAutoscalingAuto {
+		return template
+	}
+	var recommendations map[string]api.ResourceList
+	if err := json.Unmarshal([]byte(deployment.Annotations[api.AnnotationRecommendedRequests]), &recommendations); err != nil {
+		return template
+	}
+
+	template.Spec.Containers = append([]api.Container(nil), template.Spec.Containers...)
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		recommended, ok := recommendations[container.Name]
+		if !ok {
+			continue
+		}
+		requests := make(api.ResourceList, len(container.Resources.Requests)+len(recommended))
+		for name, quantity := range container.Resources.Requests {
+			requests[name] = quantity
+		}
+		for name, quantity := range recommended {
+			requests[name] = quantity
+		}
+		container.Resources.Requests = requests
+	}
+	return template
+}
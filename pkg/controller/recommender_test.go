@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestResourceRecommender(t *testing.T) {
+	ctx := context.Background()
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	ctrl := NewResourceRecommenderController(mockStore, RecommenderConfig{Window: time.Hour, MinSamples: 3})
+
+	now := time.Now()
+	ctrl.now = func() time.Time { return now }
+
+	deployment := &api.Deployment{
+		TypeMeta: api.TypeMeta{Kind: "Deployment", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{
+			Name:        "web",
+			Namespace:   "default",
+			UID:         "web-uid",
+			Annotations: map[string]string{api.AnnotationVerticalAutoscaling: api.VerticalAutoscalingAuto},
+		},
+		Spec: api.DeploymentSpec{
+			Replicas: 1,
+			Selector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: api.PodSpec{Containers: []api.Container{{
+					Name:      "web",
+					Image:     "web:v1",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: "1"}},
+				}}},
+			},
+		},
+	}
+	if err := mockStore.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web-1", Namespace: "default", UID: "pod-uid", Labels: map[string]string{"app": "web"}},
+	}
+	if err := mockStore.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	recommended := func() map[string]api.ResourceList {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "Deployment", "default", "web")
+		if err != nil {
+			t.Fatalf("Failed to get deployment: %v", err)
+		}
+		annotation, ok := obj.(*api.Deployment).Annotations[api.AnnotationRecommendedRequests]
+		if !ok {
+			return nil
+		}
+		var recommendations map[string]api.ResourceList
+		if err := json.Unmarshal([]byte(annotation), &recommendations); err != nil {
+			t.Fatalf("Failed to decode recommendations: %v", err)
+		}
+		return recommendations
+	}
+
+	// The same sample reported twice only counts once
+	for _, sample := range []struct {
+		cpu    int64
+		memory int64
+		repeat bool
+	}{
+		{120, 60 << 20, false},
+		{120, 60 << 20, true},
+		{200, 100 << 20, false},
+		{80, 50 << 20, false},
+	} {
+		if !sample.repeat {
+			now = now.Add(time.Minute)
+		}
+		pod.Status.ContainerStatuses = []api.ContainerStatus{{
+			Name:  "web",
+			Usage: &api.ContainerUsage{Timestamp: now, CPUMillicores: sample.cpu, MemoryBytes: sample.memory},
+		}}
+		if got := recommended(); got != nil {
+			t.Fatalf("Expected no recommendation before 3 samples, got %v", got)
+		}
+		if err := ctrl.Sync(ctx); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+
+	// The peak plus 15%, rounded up
+	got := recommended()["web"]
+	if got[api.ResourceCPU] != "230m" || got[api.ResourceMemory] != "115Mi" {
+		t.Fatalf("Expected 230m CPU and 115Mi memory, got %v", got)
+	}
+
+	// The next rollout's ReplicaSet gets the recommended requests, while
+	// the deployment's template is left alone
+	obj, _ := mockStore.Get(ctx, "Deployment", "default", "web")
+	rs := newReplicaSet(obj.(*api.Deployment))
+	if requests := rs.Spec.Template.Spec.Containers[0].Resources.Requests; requests[api.ResourceCPU] != "230m" {
+		t.Errorf("Expected the ReplicaSet to request 230m CPU, got %v", requests)
+	}
+	if requests := deployment.Spec.Template.Spec.Containers[0].Resources.Requests; requests[api.ResourceCPU] != "1" {
+		t.Errorf("Expected the deployment's requests to be unchanged, got %v", requests)
+	}
+}
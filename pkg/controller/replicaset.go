@@ -2,12 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
 	"github.com/minik8s/minik8s/pkg/store"
 )
 
@@ -16,12 +17,15 @@ type ReplicaSetController struct {
 	mu sync.RWMutex
 
 	// Configuration
-	store store.Store
-	name  string
+	store    store.Store
+	name     string
+	recorder *events.Recorder
 
 	// State
-	running bool
-	stopCh  chan struct{}
+	running      bool
+	stopCh       chan struct{}
+	resyncPeriod time.Duration
+	resyncJitter float64
 
 	// ReplicaSet tracking
 	replicaSets map[string]*ReplicaSetState
@@ -37,13 +41,21 @@ type ReplicaSetState struct {
 // NewReplicaSetController creates a new ReplicaSet controller
 func NewReplicaSetController(store store.Store) *ReplicaSetController {
 	return &ReplicaSetController{
-		store:       store,
-		name:        "replicaset-controller",
-		replicaSets: make(map[string]*ReplicaSetState),
-		stopCh:      make(chan struct{}),
+		store:        store,
+		name:         "replicaset-controller",
+		replicaSets:  make(map[string]*ReplicaSetState),
+		stopCh:       make(chan struct{}),
+		resyncPeriod: defaultWatchResyncPeriod,
+		resyncJitter: DefaultResyncJitter,
 	}
 }
 
+// SetEventRecorder makes the controller record the pods it creates and
+// deletes as events on the ReplicaSet
+func (r *ReplicaSetController) SetEventRecorder(recorder *events.Recorder) {
+	r.recorder = recorder
+}
+
 // Name returns the name of the controller
 func (r *ReplicaSetController) Name() string {
 	return r.name
@@ -84,30 +96,33 @@ func (r *ReplicaSetController) Sync(ctx context.Context) error {
 	return r.syncReplicaSets(ctx)
 }
 
+// setResync sets the period and jitter of the controller's own resync
+// loop, a zero period keeping defaultWatchResyncPeriod; it takes effect when
+// the controller starts
+func (r *ReplicaSetController) setResync(period time.Duration, maxFactor float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if period > 0 {
+		r.resyncPeriod = period
+	}
+	r.resyncJitter = maxFactor
+}
+
 // watchLoop continuously watches for ReplicaSet changes
 func (r *ReplicaSetController) watchLoop(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-r.stopCh:
-			return
-		case <-ticker.C:
-			if err := r.syncReplicaSets(ctx); err != nil {
-				// Log error but continue
-				fmt.Printf("Error syncing replicasets: %v\n", err)
-			}
+	JitterUntil(ctx, r.stopCh, r.resyncPeriod, r.resyncJitter, func() {
+		if err := r.syncReplicaSets(ctx); err != nil {
+			// Log error but continue
+			fmt.Printf("Error syncing replicasets: %v\n", err)
 		}
-	}
+	})
 }
 
 // syncReplicaSets syncs all ReplicaSets
 func (r *ReplicaSetController) syncReplicaSets(ctx context.Context) error {
 	// Get all ReplicaSets
-	replicaSets, err := r.store.List(ctx, "ReplicaSet", "")
+	replicaSets, err := r.store.List(ctx, "ReplicaSet", store.AllNamespaces)
 	if err != nil {
 		return fmt.Errorf("failed to list replicasets: %w", err)
 	}
@@ -162,8 +177,15 @@ func (r *ReplicaSetController) syncReplicaSet(ctx context.Context, replicaSet *a
 
 // ensurePods ensures the correct number of pods exist
 func (r *ReplicaSetController) ensurePods(ctx context.Context, replicaSet *api.ReplicaSet, state *ReplicaSetState) error {
-	// Get current pods for this ReplicaSet
-	pods, err := r.store.List(ctx, "Pod", "")
+	// Get current pods for this ReplicaSet, through the owner index when
+	// its pods can be told apart by its UID
+	var pods []store.Object
+	var err error
+	if replicaSet.UID != "" {
+		pods, err = store.ListByIndex(ctx, r.store, "Pod", replicaSet.Namespace, store.IndexOwner, replicaSet.UID)
+	} else {
+		pods, err = r.store.List(ctx, "Pod", store.AllNamespaces)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to list pods: %w", err)
 	}
@@ -189,6 +211,7 @@ func (r *ReplicaSetController) ensurePods(ctx context.Context, replicaSet *api.R
 		for i := int32(0); i < podsToCreate; i++ {
 			if err := r.createPod(ctx, replicaSet); err != nil {
 				fmt.Printf("Failed to create pod for replicaset %s: %v\n", replicaSet.Name, err)
+				r.recorder.Eventf(ctx, replicaSet, api.EventTypeWarning, "FailedCreate", "Error creating pod: %v", err)
 			}
 		}
 	}
@@ -200,6 +223,8 @@ func (r *ReplicaSetController) ensurePods(ctx context.Context, replicaSet *api.R
 			if int(i) < len(currentPods) {
 				if err := r.deletePod(ctx, currentPods[i]); err != nil {
 					fmt.Printf("Failed to delete pod for replicaset %s: %v\n", replicaSet.Name, err)
+				} else {
+					r.recorder.Eventf(ctx, replicaSet, api.EventTypeNormal, "SuccessfulDelete", "Deleted pod: %s", currentPods[i].Name)
 				}
 			}
 		}
@@ -223,12 +248,15 @@ func (r *ReplicaSetController) createPod(ctx context.Context, replicaSet *api.Re
 		ObjectMeta: replicaSet.Spec.Template.ObjectMeta,
 		Spec:       replicaSet.Spec.Template.Spec,
 		Status: api.PodStatus{
-			Phase: string(api.PodPending),
+			Phase: api.PodPending,
 		},
 	}
 
-	// Generate unique name
-	pod.Name = fmt.Sprintf("%s-%s", replicaSet.Name, strconv.FormatInt(time.Now().UnixNano(), 10))
+	// Let the store pick a unique name in the ReplicaSet's namespace; the
+	// template usually leaves the namespace empty
+	pod.Name = ""
+	pod.GenerateName = replicaSet.Name + "-"
+	pod.Namespace = replicaSet.Namespace
 
 	// Set owner reference
 	pod.OwnerReferences = []api.OwnerReference{
@@ -241,17 +269,19 @@ func (r *ReplicaSetController) createPod(ctx context.Context, replicaSet *api.Re
 	}
 
 	// Create pod in store
-	if err := r.store.Create(ctx, pod); err != nil {
+	if err := store.CreateWithGeneratedName(ctx, r.store, pod, &pod.ObjectMeta); err != nil {
 		return fmt.Errorf("failed to create pod: %w", err)
 	}
 
 	fmt.Printf("Created pod %s for replicaset %s\n", pod.Name, replicaSet.Name)
+	r.recorder.Eventf(ctx, replicaSet, api.EventTypeNormal, "SuccessfulCreate", "Created pod: %s", pod.Name)
 	return nil
 }
 
 // deletePod deletes a pod
 func (r *ReplicaSetController) deletePod(ctx context.Context, pod *api.Pod) error {
-	if err := r.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil {
+	// A pod deleted by someone else is as good as deleted by us
+	if err := r.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
 		return fmt.Errorf("failed to delete pod: %w", err)
 	}
 
@@ -272,18 +302,13 @@ func (r *ReplicaSetController) podBelongsToReplicaSet(pod *api.Pod, replicaSet *
 
 // updateReplicaSetStatus updates the ReplicaSet status
 func (r *ReplicaSetController) updateReplicaSetStatus(ctx context.Context, replicaSet *api.ReplicaSet, state *ReplicaSetState) error {
-	// Count ready pods
-	readyPods := int32(0)
-	for _, pod := range state.Pods {
-		if pod.Status.Phase == string(api.PodRunning) {
-			readyPods++
-		}
-	}
+	// Count ready pods, and those that have stayed ready for minReadySeconds
+	readyPods, availablePods := countReadyPods(state.Pods, replicaSet.Spec.MinReadySeconds, time.Now())
 
 	// Update status
 	replicaSet.Status.Replicas = int32(len(state.Pods))
 	replicaSet.Status.ReadyReplicas = readyPods
-	replicaSet.Status.AvailableReplicas = readyPods
+	replicaSet.Status.AvailableReplicas = availablePods
 
 	// Update in store
 	if err := r.store.Update(ctx, replicaSet); err != nil {
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,6 +119,14 @@ func TestReplicaSetController_SyncReplicaSet(t *testing.T) {
 		if ownerRef.Kind != "ReplicaSet" || ownerRef.Name != "test-replicaset" {
 			t.Errorf("Expected owner reference to ReplicaSet 'test-replicaset', got %s '%s'", ownerRef.Kind, ownerRef.Name)
 		}
+
+		if pod.Namespace != "default" {
+			t.Errorf("Expected pod in the ReplicaSet's namespace 'default', got '%s'", pod.Namespace)
+		}
+
+		if pod.GenerateName != "test-replicaset-" || !strings.HasPrefix(pod.Name, "test-replicaset-") || len(pod.Name) != len("test-replicaset-")+5 {
+			t.Errorf("Expected a name generated from 'test-replicaset-', got '%s'", pod.Name)
+		}
 	}
 }
 
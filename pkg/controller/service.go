@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"sort"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultServiceClusterIPRange is the CIDR cluster IPs are allocated from
+// when none is configured
+const DefaultServiceClusterIPRange = "10.96.0.0/16"
+
+// ServiceConfig configures the service controller
+type ServiceConfig struct {
+	// ClusterIPRange is the CIDR cluster IPs are allocated from. The
+	// zero value uses DefaultServiceClusterIPRange.
+	ClusterIPRange netip.Prefix
+}
+
+// ServiceController gives each service a virtual cluster IP from its range
+// and keeps an Endpoints object listing the addresses of the pods each
+// service's selector matches. Cluster IPs are allocated from what the
+// stored services hold, so there's no separate allocation state to lose:
+// a deleted service's IP is free again on the next sync.
+type ServiceController struct {
+	store    store.Store
+	name     string
+	ipRange  netip.Prefix
+	recorder *events.Recorder
+}
+
+// NewServiceController creates a new service controller
+func NewServiceController(store store.Store, config ServiceConfig) *ServiceController {
+	ipRange := config.ClusterIPRange
+	if !ipRange.IsValid() {
+		ipRange = netip.MustParsePrefix(DefaultServiceClusterIPRange)
+	}
+	return &ServiceController{
+		store:   store,
+		name:    "service-controller",
+		ipRange: ipRange.Masked(),
+	}
+}
+
+// SetEventRecorder makes the controller record the cluster IPs it allocates,
+// and those it can't honor, as events on the service
+func (c *ServiceController) SetEventRecorder(recorder *events.Recorder) {
+	c.recorder = recorder
+}
+
+// Name returns the name of the controller
+func (c *ServiceController) Name() string {
+	return c.name
+}
+
+// Start starts the controller; all of its work happens in Sync
+func (c *ServiceController) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the controller
+func (c *ServiceController) Stop() error {
+	return nil
+}
+
+// Sync allocates missing cluster IPs, then brings the Endpoints of every
+// service in line with its pods and removes those of services that are gone
+func (c *ServiceController) Sync(ctx context.Context) error {
+	objs, err := c.store.List(ctx, "Service", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	var services []*api.Service
+	for _, obj := range objs {
+		if service, ok := obj.(*api.Service); ok {
+			services = append(services, service)
+		}
+	}
+	// The oldest service keeps an IP several ask for
+	sort.Slice(services, func(i, j int) bool {
+		if !services[i].CreationTimestamp.Equal(services[j].CreationTimestamp) {
+			return services[i].CreationTimestamp.Before(services[j].CreationTimestamp)
+		}
+		return services[i].Namespace+"/"+services[i].Name < services[j].Namespace+"/"+services[j].Name
+	})
+
+	c.allocateClusterIPs(ctx, services)
+
+	selected := make(map[string]string)
+	for _, service := range services {
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		selected[service.Namespace+"/"+service.Name] = service.UID
+		if err := c.syncEndpoints(ctx, service); err != nil {
+			fmt.Printf("Error syncing endpoints of service %s/%s: %v\n", service.Namespace, service.Name, err)
+		}
+	}
+	return c.removeStaleEndpoints(ctx, selected)
+}
+
+// allocateClusterIPs gives each service without a cluster IP the lowest free
+// address of the range. Services asking for an address outside the range,
+// or one another service holds, are left alone and warned about.
+func (c *ServiceController) allocateClusterIPs(ctx context.Context, services []*api.Service) {
+	taken := make(map[netip.Addr]bool)
+	for _, service := range services {
+		if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == api.ClusterIPNone {
+			continue
+		}
+		ip, err := netip.ParseAddr(service.Spec.ClusterIP)
+		switch {
+		case err != nil || !c.ipRange.Contains(ip):
+			c.recorder.Eventf(ctx, service, api.EventTypeWarning, "ClusterIPOutOfRange", "Cluster IP %s is outside the service range %s", service.Spec.ClusterIP, c.ipRange)
+		case taken[ip]:
+			c.recorder.Eventf(ctx, service, api.EventTypeWarning, "ClusterIPAlreadyAllocated", "Cluster IP %s is already allocated to another service", ip)
+		default:
+			taken[ip] = true
+		}
+	}
+
+	next := c.ipRange.Addr().Next()
+	for _, service := range services {
+		if service.Spec.ClusterIP != "" {
+			continue
+		}
+		for next.IsValid() && c.ipRange.Contains(next) && taken[next] {
+			next = next.Next()
+		}
+		// The last address of the range is its broadcast address
+		if !next.IsValid() || !c.ipRange.Contains(next) || !c.ipRange.Contains(next.Next()) {
+			c.recorder.Eventf(ctx, service, api.EventTypeWarning, "ClusterIPRangeFull", "No cluster IP is free in the service range %s", c.ipRange)
+			continue
+		}
+
+		updated := *service
+		updated.Spec.ClusterIP = next.String()
+		if err := c.store.Update(ctx, &updated); err != nil {
+			fmt.Printf("Error allocating a cluster IP to service %s/%s: %v\n", service.Namespace, service.Name, err)
+			continue
+		}
+		taken[next] = true
+		*service = updated
+		c.recorder.Eventf(ctx, service, api.EventTypeNormal, "ClusterIPAllocated", "Allocated cluster IP %s", next)
+	}
+}
+
+// syncEndpoints writes the Endpoints of service: the addresses of the
+// pods its selector matches that have an IP, split by readiness, and the
+// ports they serve the service's ports on
+func (c *ServiceController) syncEndpoints(ctx context.Context, service *api.Service) error {
+	objs, err := c.store.List(ctx, "Pod", service.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	desired := &api.Endpoints{
+		TypeMeta: api.TypeMeta{Kind: "Endpoints", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Labels:    service.Labels,
+			OwnerReferences: []api.OwnerReference{{
+				APIVersion: "v1alpha1",
+				Kind:       "Service",
+				Name:       service.Name,
+				UID:        service.UID,
+			}},
+		},
+	}
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if !ok || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil || !labelsMatch(service.Spec.Selector, pod.Labels) {
+			continue
+		}
+		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		address := api.EndpointAddress{
+			IP:        pod.Status.PodIP,
+			NodeName:  pod.Spec.NodeName,
+			TargetRef: &api.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
+		}
+		if ready, _ := podReadySince(pod); ready {
+			desired.Addresses = append(desired.Addresses, address)
+		} else {
+			desired.NotReadyAddresses = append(desired.NotReadyAddresses, address)
+		}
+	}
+	sortAddresses(desired.Addresses)
+	sortAddresses(desired.NotReadyAddresses)
+	for _, port := range service.Spec.Ports {
+		target := port.TargetPort
+		if target == 0 {
+			target = port.Port
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = api.ProtocolTCP
+		}
+		desired.Ports = append(desired.Ports, api.EndpointPort{Name: port.Name, Protocol: protocol, Port: target})
+	}
+
+	obj, err := c.store.Get(ctx, "Endpoints", service.Namespace, service.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		if err := c.store.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create endpoints: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get endpoints: %w", err)
+	}
+	current, ok := obj.(*api.Endpoints)
+	if !ok {
+		return fmt.Errorf("stored object is not an endpoints object")
+	}
+	if reflect.DeepEqual(current.Addresses, desired.Addresses) &&
+		reflect.DeepEqual(current.NotReadyAddresses, desired.NotReadyAddresses) &&
+		reflect.DeepEqual(current.Ports, desired.Ports) &&
+		reflect.DeepEqual(current.OwnerReferences, desired.OwnerReferences) &&
+		reflect.DeepEqual(current.Labels, desired.Labels) {
+		return nil
+	}
+
+	updated := *current
+	updated.Labels = desired.Labels
+	updated.OwnerReferences = desired.OwnerReferences
+	updated.Addresses = desired.Addresses
+	updated.NotReadyAddresses = desired.NotReadyAddresses
+	updated.Ports = desired.Ports
+	if err := c.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update endpoints: %w", err)
+	}
+	return nil
+}
+
+// removeStaleEndpoints deletes the Endpoints kept for services that are
+// gone, were recreated or no longer have a selector. selected maps the
+// namespace/name of every service with a selector to its UID.
+func (c *ServiceController) removeStaleEndpoints(ctx context.Context, selected map[string]string) error {
+	objs, err := c.store.List(ctx, "Endpoints", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	for _, obj := range objs {
+		endpoints, ok := obj.(*api.Endpoints)
+		if !ok {
+			continue
+		}
+		if uid, ok := selected[endpoints.Namespace+"/"+endpoints.Name]; ok && ownedBy(endpoints.OwnerReferences, uid) {
+			continue
+		}
+		if err := c.store.Delete(ctx, "Endpoints", endpoints.Namespace, endpoints.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
+			fmt.Printf("Error deleting endpoints %s/%s: %v\n", endpoints.Namespace, endpoints.Name, err)
+		}
+	}
+	return nil
+}
+
+// labelsMatch reports whether labels has every key and value of selector
+func labelsMatch(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ownedBy reports whether one of owners has the given UID
+func ownedBy(owners []api.OwnerReference, uid string) bool {
+	for _, owner := range owners {
+		if owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// sortAddresses orders endpoint addresses by IP, so unchanged pods produce
+// an unchanged Endpoints object
+func sortAddresses(addresses []api.EndpointAddress) {
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].IP < addresses[j].IP })
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func testService(name, clusterIP string, created time.Time) *api.Service {
+	return &api.Service{
+		TypeMeta:   api.TypeMeta{Kind: "Service", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid", CreationTimestamp: created},
+		Spec:       api.ServiceSpec{ClusterIP: clusterIP, Type: api.ServiceTypeClusterIP},
+	}
+}
+
+func TestServiceController_AllocatesClusterIPs(t *testing.T) {
+	s := store.NewMemoryStore(store.DefaultOptions())
+	ctx := context.Background()
+	ctrl := NewServiceController(s, ServiceConfig{ClusterIPRange: netip.MustParsePrefix("10.0.0.0/29")})
+
+	now := time.Now()
+	services := []*api.Service{
+		testService("requested", "10.0.0.1", now.Add(-3*time.Minute)),
+		testService("first", "", now.Add(-2*time.Minute)),
+		testService("conflict", "10.0.0.1", now.Add(-time.Minute)),
+		testService("outside", "10.1.0.1", now),
+		testService("headless", api.ClusterIPNone, now),
+		testService("second", "", now.Add(time.Minute)),
+	}
+	for _, service := range services {
+		if err := s.Create(ctx, service); err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+	}
+
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	want := map[string]string{
+		"requested": "10.0.0.1",
+		"first":     "10.0.0.2",
+		"conflict":  "10.0.0.1",
+		"outside":   "10.1.0.1",
+		"headless":  api.ClusterIPNone,
+		"second":    "10.0.0.3",
+	}
+	for name, ip := range want {
+		obj, err := s.Get(ctx, "Service", "default", name)
+		if err != nil {
+			t.Fatalf("Failed to get service %s: %v", name, err)
+		}
+		if got := obj.(*api.Service).Spec.ClusterIP; got != ip {
+			t.Errorf("service %s has cluster IP %q, want %q", name, got, ip)
+		}
+	}
+
+	// 10.0.0.4 to 10.0.0.6 are left before the broadcast address
+	for i := 0; i < 4; i++ {
+		if err := s.Create(ctx, testService("extra-"+string(rune('a'+i)), "", now.Add(time.Hour))); err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+	}
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	obj, _ := s.Get(ctx, "Service", "default", "extra-d")
+	if got := obj.(*api.Service).Spec.ClusterIP; got != "" {
+		t.Errorf("service allocated %q from a full range", got)
+	}
+}
+
+func TestServiceController_Endpoints(t *testing.T) {
+	s := store.NewMemoryStore(store.DefaultOptions())
+	ctx := context.Background()
+	ctrl := NewServiceController(s, ServiceConfig{})
+
+	service := testService("web", "", time.Now())
+	service.Spec.Selector = map[string]string{"app": "web"}
+	service.Spec.Ports = []api.ServicePort{{Name: "http", Port: 80, TargetPort: 8080}, {Name: "dns", Protocol: "UDP", Port: 53}}
+	if err := s.Create(ctx, service); err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	ready := readyPod("web-1", true, time.Now())
+	ready.Labels = map[string]string{"app": "web"}
+	ready.Status.PodIP = "10.244.0.5"
+	notReady := readyPod("web-2", false, time.Now())
+	notReady.Labels = map[string]string{"app": "web"}
+	notReady.Status.PodIP = "10.244.0.4"
+	noIP := readyPod("web-3", true, time.Now())
+	noIP.Labels = map[string]string{"app": "web"}
+	other := readyPod("db-1", true, time.Now())
+	other.Labels = map[string]string{"app": "db"}
+	other.Status.PodIP = "10.244.0.6"
+	for _, pod := range []*api.Pod{ready, notReady, noIP, other} {
+		if err := s.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	obj, err := s.Get(ctx, "Endpoints", "default", "web")
+	if err != nil {
+		t.Fatalf("Failed to get endpoints: %v", err)
+	}
+	endpoints := obj.(*api.Endpoints)
+	if len(endpoints.Addresses) != 1 || endpoints.Addresses[0].IP != "10.244.0.5" || endpoints.Addresses[0].TargetRef.Name != "web-1" {
+		t.Errorf("unexpected ready addresses %+v", endpoints.Addresses)
+	}
+	if len(endpoints.NotReadyAddresses) != 1 || endpoints.NotReadyAddresses[0].IP != "10.244.0.4" {
+		t.Errorf("unexpected not ready addresses %+v", endpoints.NotReadyAddresses)
+	}
+	wantPorts := []api.EndpointPort{{Name: "http", Protocol: api.ProtocolTCP, Port: 8080}, {Name: "dns", Protocol: "UDP", Port: 53}}
+	if len(endpoints.Ports) != len(wantPorts) || endpoints.Ports[0] != wantPorts[0] || endpoints.Ports[1] != wantPorts[1] {
+		t.Errorf("ports = %+v, want %+v", endpoints.Ports, wantPorts)
+	}
+	if !ownedBy(endpoints.OwnerReferences, service.UID) {
+		t.Errorf("endpoints not owned by the service: %+v", endpoints.OwnerReferences)
+	}
+
+	if err := s.Delete(ctx, "Service", "default", "web"); err != nil {
+		t.Fatalf("Failed to delete service: %v", err)
+	}
+	if err := ctrl.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := s.Get(ctx, "Endpoints", "default", "web"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("endpoints of a deleted service still exist: %v", err)
+	}
+}
@@ -0,0 +1,436 @@
+// Package convert turns minik8s objects into manifests for upstream
+// Kubernetes and back. Fields Kubernetes has no equivalent for are kept in
+// an annotation on export so importing the manifest restores them; fields
+// of imported manifests minik8s doesn't support are dropped with a warning.
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/apply"
+	"github.com/minik8s/minik8s/pkg/validation"
+)
+
+// AnnotationExportedFields holds the minik8s fields of an exported object
+// Kubernetes has no equivalent for, as a JSON object of field path to value
+const AnnotationExportedFields = "minik8s.io/exported-fields"
+
+// apiVersions are the Kubernetes API versions of the kinds that convert
+var apiVersions = map[string]string{
+	"Pod":                     "v1",
+	"Secret":                  "v1",
+	"Namespace":               "v1",
+	"Node":                    "v1",
+	"Deployment":              "apps/v1",
+	"ReplicaSet":              "apps/v1",
+	"Job":                     "batch/v1",
+	"CronJob":                 "batch/v1",
+	"HorizontalPodAutoscaler": "autoscaling/v2",
+}
+
+// minik8sOnlyFields are the fields of each kind Kubernetes doesn't have
+var minik8sOnlyFields = map[string][]string{
+	"Deployment":              {"spec.strategy"},
+	"HorizontalPodAutoscaler": {"spec.scaleToZeroAfterSeconds"},
+}
+
+// newObject returns an empty object of kind, or nil if kind doesn't convert
+func newObject(kind string) interface{} {
+	switch kind {
+	case "Pod":
+		return &api.Pod{}
+	case "Secret":
+		return &api.Secret{}
+	case "Namespace":
+		return &api.Namespace{}
+	case "Node":
+		return &api.Node{}
+	case "Deployment":
+		return &api.Deployment{}
+	case "ReplicaSet":
+		return &api.ReplicaSet{}
+	case "Job":
+		return &api.Job{}
+	case "CronJob":
+		return &api.CronJob{}
+	case "HorizontalPodAutoscaler":
+		return &api.HorizontalPodAutoscaler{}
+	}
+	return nil
+}
+
+// Export returns the Kubernetes manifest of the minik8s object obj, along
+// with warnings about what won't behave the same on Kubernetes. Status and
+// the metadata set by the API server are left out.
+func Export(obj map[string]interface{}) (map[string]interface{}, []string, error) {
+	kind, _ := obj["kind"].(string)
+	apiVersion, ok := apiVersions[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("kind %q can't be exported", kind)
+	}
+
+	out, err := deepCopy(obj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy %s: %w", kind, err)
+	}
+	out["apiVersion"] = apiVersion
+	delete(out, "status")
+	cleanMetadata(out)
+	if metadata, ok := out["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, apply.LastAppliedAnnotation)
+			delete(annotations, AnnotationExportedFields)
+		}
+	}
+
+	var warnings []string
+	exported := make(map[string]interface{})
+	for _, path := range minik8sOnlyFields[kind] {
+		if value, ok := removeField(out, path); ok {
+			exported[path] = value
+			warnings = append(warnings, fmt.Sprintf("%s has no Kubernetes equivalent; it's kept in the %s annotation", path, AnnotationExportedFields))
+		}
+	}
+
+	if kind == "HorizontalPodAutoscaler" {
+		spec, _ := out["spec"].(map[string]interface{})
+		metrics, _ := spec["metrics"].([]interface{})
+		for i, metric := range metrics {
+			metrics[i] = exportMetric(metric)
+		}
+		if minReplicas, ok := spec["minReplicas"].(float64); ok && minReplicas == 0 {
+			warnings = append(warnings, "spec.minReplicas of 0 needs the HPAScaleToZero feature gate on Kubernetes")
+		}
+	}
+
+	if len(exported) > 0 {
+		data, err := json.Marshal(exported)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode exported fields: %w", err)
+		}
+		setAnnotation(out, AnnotationExportedFields, string(data))
+	}
+	return pruneEmpty(out).(map[string]interface{}), warnings, nil
+}
+
+// exportMetric renames the fields of a minik8s external metric to those of
+// autoscaling/v2
+func exportMetric(metric interface{}) interface{} {
+	m, _ := metric.(map[string]interface{})
+	external, ok := m["external"].(map[string]interface{})
+	if !ok {
+		return metric
+	}
+	identifier := map[string]interface{}{"name": external["metricName"]}
+	if selector, ok := external["metricSelector"].(map[string]interface{}); ok && len(selector) > 0 {
+		identifier["selector"] = map[string]interface{}{"matchLabels": selector}
+	}
+	var averageValue string
+	if value, ok := external["targetAverageValue"].(float64); ok {
+		averageValue = strconv.FormatInt(int64(value), 10)
+	}
+	m["external"] = map[string]interface{}{
+		"metric": identifier,
+		"target": map[string]interface{}{"type": "AverageValue", "averageValue": averageValue},
+	}
+	return m
+}
+
+// Import returns the minik8s manifest of the Kubernetes manifest obj,
+// along with warnings about the fields that were dropped because minik8s
+// doesn't support them. Manifests using features minik8s can't express,
+// or that minik8s would reject, return an error.
+func Import(obj map[string]interface{}) (map[string]interface{}, []string, error) {
+	kind, _ := obj["kind"].(string)
+	apiVersion, ok := apiVersions[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("kind %q can't be imported", kind)
+	}
+	if version, _ := obj["apiVersion"].(string); version != apiVersion {
+		return nil, nil, fmt.Errorf("%s must be %s, not %q", kind, apiVersion, version)
+	}
+
+	in, err := deepCopy(obj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy %s: %w", kind, err)
+	}
+	in["apiVersion"] = "v1alpha1"
+	delete(in, "status")
+	cleanMetadata(in)
+	if metadata, ok := in["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+	}
+
+	var warnings []string
+	switch kind {
+	case "Deployment", "ReplicaSet":
+		spec, _ := in["spec"].(map[string]interface{})
+		if selector, ok := spec["selector"].(map[string]interface{}); ok && selector["matchExpressions"] != nil {
+			return nil, nil, fmt.Errorf("spec.selector.matchExpressions isn't supported; use matchLabels")
+		}
+		// Kubernetes strategies have no minik8s equivalent; minik8s
+		// replaces the ReplicaSet of deployments without one
+		if strategy, ok := removeField(in, "spec.strategy"); ok {
+			strategyType, _ := strategy.(map[string]interface{})["type"].(string)
+			warnings = append(warnings, fmt.Sprintf("spec.strategy %s isn't supported and was dropped", strategyType))
+		}
+	case "HorizontalPodAutoscaler":
+		spec, _ := in["spec"].(map[string]interface{})
+		metrics, _ := spec["metrics"].([]interface{})
+		for i, metric := range metrics {
+			imported, err := importMetric(metric)
+			if err != nil {
+				return nil, nil, fmt.Errorf("spec.metrics[%d]: %w", i, err)
+			}
+			metrics[i] = imported
+		}
+	}
+
+	if metadata, ok := in["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if value, ok := annotations[AnnotationExportedFields].(string); ok {
+				var exported map[string]interface{}
+				if err := json.Unmarshal([]byte(value), &exported); err != nil {
+					return nil, nil, fmt.Errorf("invalid %s annotation: %w", AnnotationExportedFields, err)
+				}
+				for path, field := range exported {
+					setField(in, path, field)
+				}
+				delete(annotations, AnnotationExportedFields)
+			}
+		}
+	}
+	in = pruneEmpty(in).(map[string]interface{})
+
+	// Decoding into the minik8s type drops the fields it doesn't have
+	typed := newObject(kind)
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	if err := json.Unmarshal(data, typed); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", kind, err)
+	}
+	if err := validation.Object(typed); err != nil {
+		return nil, nil, err
+	}
+	if data, err = json.Marshal(typed); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", kind, err)
+	}
+	delete(out, "status")
+	out = pruneEmpty(out).(map[string]interface{})
+
+	for _, path := range droppedFields(in, out, "") {
+		warnings = append(warnings, fmt.Sprintf("%s isn't supported and was dropped", path))
+	}
+	return out, warnings, nil
+}
+
+// importMetric renames the fields of an autoscaling/v2 external metric to
+// those of minik8s
+func importMetric(metric interface{}) (interface{}, error) {
+	m, _ := metric.(map[string]interface{})
+	metricType, _ := m["type"].(string)
+	external, ok := m["external"].(map[string]interface{})
+	if metricType != api.MetricSourceExternal || !ok {
+		return nil, fmt.Errorf("only External metrics are supported, not %q", metricType)
+	}
+	identifier, _ := external["metric"].(map[string]interface{})
+	target, _ := external["target"].(map[string]interface{})
+	if targetType, _ := target["type"].(string); targetType != "AverageValue" {
+		return nil, fmt.Errorf("only AverageValue targets are supported, not %q", targetType)
+	}
+	averageValue, err := wholeNumber(target["averageValue"])
+	if err != nil {
+		return nil, fmt.Errorf("target.averageValue: %w", err)
+	}
+
+	imported := map[string]interface{}{
+		"metricName":         identifier["name"],
+		"targetAverageValue": averageValue,
+	}
+	if selector, ok := identifier["selector"].(map[string]interface{}); ok {
+		if selector["matchExpressions"] != nil {
+			return nil, fmt.Errorf("metric.selector.matchExpressions isn't supported; use matchLabels")
+		}
+		imported["metricSelector"] = selector["matchLabels"]
+	}
+	m["external"] = imported
+	return m, nil
+}
+
+// wholeNumber reads a quantity that is a whole number, such as 30 or "30"
+func wholeNumber(value interface{}) (int64, error) {
+	switch value := value.(type) {
+	case float64:
+		if value == float64(int64(value)) {
+			return int64(value), nil
+		}
+	case string:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("%v isn't a whole number", value)
+}
+
+// cleanMetadata removes the metadata set by an API server, which is
+// meaningless in another cluster
+func cleanMetadata(obj map[string]interface{}) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "ownerReferences"} {
+		delete(metadata, field)
+	}
+}
+
+// setAnnotation sets an annotation of obj
+func setAnnotation(obj map[string]interface{}, key, value string) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = make(map[string]interface{})
+		obj["metadata"] = metadata
+	}
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		annotations = make(map[string]interface{})
+		metadata["annotations"] = annotations
+	}
+	annotations[key] = value
+}
+
+// removeField removes the field at the dotted path from obj and returns its
+// value, if it was set
+func removeField(obj map[string]interface{}, path string) (interface{}, bool) {
+	fields := strings.Split(path, ".")
+	parent := obj
+	for _, field := range fields[:len(fields)-1] {
+		child, ok := parent[field].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		parent = child
+	}
+	value, ok := parent[fields[len(fields)-1]]
+	if !ok || value == nil {
+		return nil, false
+	}
+	delete(parent, fields[len(fields)-1])
+	return value, true
+}
+
+// setField sets the field at the dotted path of obj, creating the objects
+// on the way
+func setField(obj map[string]interface{}, path string, value interface{}) {
+	fields := strings.Split(path, ".")
+	parent := obj
+	for _, field := range fields[:len(fields)-1] {
+		child, ok := parent[field].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			parent[field] = child
+		}
+		parent = child
+	}
+	parent[fields[len(fields)-1]] = value
+}
+
+// droppedFields returns the paths of the fields of in that out lacks, in
+// order. Zero numbers and false are not reported, as the minik8s types
+// omit them.
+func droppedFields(in, out interface{}, path string) []string {
+	switch in := in.(type) {
+	case map[string]interface{}:
+		outMap, _ := out.(map[string]interface{})
+		keys := make([]string, 0, len(in))
+		for key := range in {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var dropped []string
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			value, ok := outMap[key]
+			if !ok {
+				if in[key] != float64(0) && in[key] != false {
+					dropped = append(dropped, child)
+				}
+				continue
+			}
+			dropped = append(dropped, droppedFields(in[key], value, child)...)
+		}
+		return dropped
+	case []interface{}:
+		outSlice, _ := out.([]interface{})
+		var dropped []string
+		for i, value := range in {
+			if i < len(outSlice) {
+				dropped = append(dropped, droppedFields(value, outSlice[i], fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+		return dropped
+	}
+	return nil
+}
+
+// pruneEmpty removes empty strings, nulls, zero timestamps and the objects
+// and lists left empty by them, which the minik8s types write for unset
+// fields
+func pruneEmpty(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if child = pruneEmpty(child); child == nil {
+				delete(value, key)
+			} else {
+				value[key] = child
+			}
+		}
+		if len(value) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+		for i, child := range value {
+			// Keep list items in place, even empty ones
+			if child = pruneEmpty(child); child == nil {
+				child = map[string]interface{}{}
+			}
+			value[i] = child
+		}
+	case string:
+		if value == "" || value == "0001-01-01T00:00:00Z" {
+			return nil
+		}
+	}
+	return value
+}
+
+// deepCopy copies a decoded JSON object
+func deepCopy(obj map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package convert
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &obj); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	return obj
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	deployment := decode(t, `{
+		"kind": "Deployment", "apiVersion": "v1alpha1",
+		"metadata": {"name": "web", "namespace": "shop", "uid": "abc", "resourceVersion": "7",
+			"creationTimestamp": "2026-10-01T10:00:00Z",
+			"annotations": {"minik8s.io/last-applied-configuration": "{}"}},
+		"spec": {
+			"replicas": 3,
+			"selector": {"matchLabels": {"app": "web"}},
+			"template": {
+				"metadata": {"name": "", "namespace": "", "creationTimestamp": "0001-01-01T00:00:00Z", "labels": {"app": "web"}},
+				"spec": {"containers": [{"name": "web", "image": "nginx:1.27", "resources": {}}]}
+			},
+			"strategy": {"type": "Canary", "canary": {"steps": [{"setWeight": 20}, {"pause": true}]}}
+		},
+		"status": {"replicas": 3}
+	}`)
+
+	exported, warnings, err := Export(deployment)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Export() warnings = %q, want one about spec.strategy", warnings)
+	}
+	want := decode(t, `{
+		"kind": "Deployment", "apiVersion": "apps/v1",
+		"metadata": {"name": "web", "namespace": "shop",
+			"annotations": {"minik8s.io/exported-fields": "{\"spec.strategy\":{\"canary\":{\"steps\":[{\"setWeight\":20},{\"pause\":true}]},\"type\":\"Canary\"}}"}},
+		"spec": {
+			"replicas": 3,
+			"selector": {"matchLabels": {"app": "web"}},
+			"template": {
+				"metadata": {"labels": {"app": "web"}},
+				"spec": {"containers": [{"name": "web", "image": "nginx:1.27"}]}
+			}
+		}
+	}`)
+	if !reflect.DeepEqual(exported, want) {
+		got, _ := json.Marshal(exported)
+		t.Fatalf("Export() = %s", got)
+	}
+
+	imported, warnings, err := Import(exported)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Import() warnings = %q, want none", warnings)
+	}
+	strategy, _ := imported["spec"].(map[string]interface{})["strategy"].(map[string]interface{})
+	if strategy["type"] != "Canary" {
+		t.Errorf("Import() strategy = %v, want the exported Canary strategy back", strategy)
+	}
+	if imported["apiVersion"] != "v1alpha1" {
+		t.Errorf("Import() apiVersion = %v, want v1alpha1", imported["apiVersion"])
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Run("unsupported fields are dropped", func(t *testing.T) {
+		imported, warnings, err := Import(decode(t, `{
+			"apiVersion": "apps/v1", "kind": "Deployment",
+			"metadata": {"name": "web", "managedFields": [{}]},
+			"spec": {
+				"replicas": 2,
+				"revisionHistoryLimit": 5,
+				"strategy": {"type": "RollingUpdate"},
+				"selector": {"matchLabels": {"app": "web"}},
+				"template": {
+					"metadata": {"labels": {"app": "web"}},
+					"spec": {"containers": [{"name": "web", "image": "nginx:1.27", "securityContext": {"runAsNonRoot": true}}]}
+				}
+			}
+		}`))
+		if err != nil {
+			t.Fatalf("Import() error = %v", err)
+		}
+		wantWarnings := []string{
+			"spec.strategy RollingUpdate isn't supported and was dropped",
+			"spec.revisionHistoryLimit isn't supported and was dropped",
+			"spec.template.spec.containers[0].securityContext isn't supported and was dropped",
+		}
+		if !reflect.DeepEqual(warnings, wantWarnings) {
+			t.Errorf("Import() warnings = %q, want %q", warnings, wantWarnings)
+		}
+		if replicas := imported["spec"].(map[string]interface{})["replicas"]; replicas != float64(2) {
+			t.Errorf("Import() replicas = %v, want 2", replicas)
+		}
+	})
+
+	t.Run("external metrics are renamed", func(t *testing.T) {
+		imported, _, err := Import(decode(t, `{
+			"apiVersion": "autoscaling/v2", "kind": "HorizontalPodAutoscaler",
+			"metadata": {"name": "worker"},
+			"spec": {
+				"scaleTargetRef": {"apiVersion": "apps/v1", "kind": "Deployment", "name": "worker"},
+				"maxReplicas": 10,
+				"metrics": [{"type": "External", "external": {
+					"metric": {"name": "queue_length", "selector": {"matchLabels": {"queue": "jobs"}}},
+					"target": {"type": "AverageValue", "averageValue": "30"}
+				}}]
+			}
+		}`))
+		if err != nil {
+			t.Fatalf("Import() error = %v", err)
+		}
+		metric := imported["spec"].(map[string]interface{})["metrics"].([]interface{})[0].(map[string]interface{})
+		want := decode(t, `{"type": "External", "external": {"metricName": "queue_length", "metricSelector": {"queue": "jobs"}, "targetAverageValue": 30}}`)
+		if !reflect.DeepEqual(metric, want) {
+			t.Errorf("Import() metric = %v, want %v", metric, want)
+		}
+	})
+
+	for name, manifest := range map[string]string{
+		"unsupported kind":       `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web"}}`,
+		"wrong api version":      `{"apiVersion": "extensions/v1beta1", "kind": "Deployment", "metadata": {"name": "web"}}`,
+		"selector expressions":   `{"apiVersion": "apps/v1", "kind": "ReplicaSet", "metadata": {"name": "web"}, "spec": {"selector": {"matchExpressions": [{"key": "app", "operator": "Exists"}]}}}`,
+		"resource metric":        `{"apiVersion": "autoscaling/v2", "kind": "HorizontalPodAutoscaler", "metadata": {"name": "web"}, "spec": {"metrics": [{"type": "Resource", "resource": {"name": "cpu"}}]}}`,
+		"rejected by validation": `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"}, "spec": {"template": {"spec": {"restartPolicy": "Always", "containers": [{"name": "m", "image": "migrate:1"}]}}}}`,
+		"missing pod containers": `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"selector": {"matchLabels": {"app": "web"}}, "template": {"metadata": {"labels": {"app": "web"}}}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Import(decode(t, manifest)); err == nil {
+				t.Error("Import() succeeded, want an error")
+			}
+		})
+	}
+}
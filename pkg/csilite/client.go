@@ -0,0 +1,61 @@
+package csilite
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client talks to a CSI-lite driver
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// Dial creates a client for the driver listening on endpoint
+func Dial(endpoint string) (*Client, error) {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	target := address
+	if network == "unix" {
+		target = "unix://" + address
+	}
+
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to driver at %s: %w", endpoint, err)
+	}
+
+	return &Client{conn: conn}, nil
+}
+
+// GetPluginInfo returns the driver's identity
+func (c *Client) GetPluginInfo(ctx context.Context) (*GetPluginInfoResponse, error) {
+	resp := &GetPluginInfoResponse{}
+	if err := c.conn.Invoke(ctx, "/"+serviceName+"/GetPluginInfo", &GetPluginInfoRequest{}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// NodePublishVolume asks the driver to publish a volume
+func (c *Client) NodePublishVolume(ctx context.Context, req *NodePublishVolumeRequest) error {
+	return c.conn.Invoke(ctx, "/"+serviceName+"/NodePublishVolume", req, &NodePublishVolumeResponse{})
+}
+
+// NodeUnpublishVolume asks the driver to unpublish a volume
+func (c *Client) NodeUnpublishVolume(ctx context.Context, req *NodeUnpublishVolumeRequest) error {
+	return c.conn.Invoke(ctx, "/"+serviceName+"/NodeUnpublishVolume", req, &NodeUnpublishVolumeResponse{})
+}
+
+// Close closes the connection to the driver
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,26 @@
+package csilite
+
+import (
+	"encoding/json"
+)
+
+// codecName is the content subtype used on the wire
+const codecName = "json"
+
+// jsonCodec marshals csilite messages as JSON
+type jsonCodec struct{}
+
+// Marshal encodes a message as JSON
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes a JSON message
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Name returns the codec name
+func (jsonCodec) Name() string {
+	return codecName
+}
@@ -0,0 +1,99 @@
+package csilite
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// serviceName is the fully qualified gRPC service name
+const serviceName = "csilite.v1.Node"
+
+// Driver is implemented by storage drivers serving the CSI-lite node service
+type Driver interface {
+	GetPluginInfo(ctx context.Context, req *GetPluginInfoRequest) (*GetPluginInfoResponse, error)
+	NodePublishVolume(ctx context.Context, req *NodePublishVolumeRequest) (*NodePublishVolumeResponse, error)
+	NodeUnpublishVolume(ctx context.Context, req *NodeUnpublishVolumeRequest) (*NodeUnpublishVolumeResponse, error)
+}
+
+// serviceDesc describes the CSI-lite node service to gRPC
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*Driver)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPluginInfo",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &GetPluginInfoRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(Driver).GetPluginInfo(ctx, req)
+			},
+		},
+		{
+			MethodName: "NodePublishVolume",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &NodePublishVolumeRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(Driver).NodePublishVolume(ctx, req)
+			},
+		},
+		{
+			MethodName: "NodeUnpublishVolume",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &NodeUnpublishVolumeRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(Driver).NodeUnpublishVolume(ctx, req)
+			},
+		},
+	},
+}
+
+// NewServer creates a gRPC server serving the given driver
+func NewServer(driver Driver, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ForceServerCodec(jsonCodec{}))
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&serviceDesc, driver)
+	return server
+}
+
+// Listen opens a listener for a driver endpoint such as unix:///run/driver.sock
+// or tcp://127.0.0.1:9000. Stale unix sockets are removed first.
+func Listen(endpoint string) (net.Listener, error) {
+	network, address, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "unix" {
+		if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	return net.Listen(network, address)
+}
+
+// parseEndpoint splits an endpoint URL into a network and address
+func parseEndpoint(endpoint string) (string, string, error) {
+	scheme, address, ok := strings.Cut(endpoint, "://")
+	if !ok || address == "" {
+		return "", "", fmt.Errorf("invalid endpoint %q, expected unix://path or tcp://host:port", endpoint)
+	}
+
+	switch scheme {
+	case "unix", "tcp":
+		return scheme, address, nil
+	default:
+		return "", "", fmt.Errorf("unsupported endpoint scheme %q", scheme)
+	}
+}
@@ -0,0 +1,42 @@
+// Package csilite defines a small subset of the Container Storage Interface node
+// service that external storage drivers can implement to provide volumes to the
+// node agent. Messages are plain Go structs carried over gRPC with a JSON codec,
+// so drivers don't need generated protobuf code.
+package csilite
+
+// GetPluginInfoRequest asks a driver to identify itself
+type GetPluginInfoRequest struct{}
+
+// GetPluginInfoResponse identifies a driver
+type GetPluginInfoResponse struct {
+	Name          string `json:"name"`
+	VendorVersion string `json:"vendorVersion,omitempty"`
+}
+
+// NodePublishVolumeRequest asks a driver to make a volume available at TargetPath
+type NodePublishVolumeRequest struct {
+	VolumeID      string            `json:"volumeID"`
+	TargetPath    string            `json:"targetPath"`
+	ReadOnly      bool              `json:"readOnly,omitempty"`
+	FSType        string            `json:"fsType,omitempty"`
+	VolumeContext map[string]string `json:"volumeContext,omitempty"`
+}
+
+// NodePublishVolumeResponse is returned once a volume is published
+type NodePublishVolumeResponse struct{}
+
+// NodeUnpublishVolumeRequest asks a driver to remove a volume from TargetPath
+type NodeUnpublishVolumeRequest struct {
+	VolumeID   string `json:"volumeID"`
+	TargetPath string `json:"targetPath"`
+}
+
+// NodeUnpublishVolumeResponse is returned once a volume is unpublished
+type NodeUnpublishVolumeResponse struct{}
+
+// Pod information passed to drivers through the volume context
+const (
+	VolumeContextPodName      = "csi.minik8s.io/pod.name"
+	VolumeContextPodNamespace = "csi.minik8s.io/pod.namespace"
+	VolumeContextPodUID       = "csi.minik8s.io/pod.uid"
+)
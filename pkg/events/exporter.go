@@ -0,0 +1,339 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// Content types of the metrics endpoint
+const (
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	prometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// ExporterConfig holds the configuration of an Exporter
+type ExporterConfig struct {
+	Store store.Store
+
+	// OTLPEndpoint, if set, is the OTLP/HTTP logs URL new events and pod
+	// phase transitions are sent to, e.g. http://collector:4318/v1/logs
+	OTLPEndpoint string
+}
+
+// eventKey identifies a series of the events counter
+type eventKey struct {
+	namespace, kind, eventType, reason string
+}
+
+// transitionKey identifies a series of the pod phase transitions counter
+type transitionKey struct {
+	namespace, from, to string
+}
+
+// Exporter turns Events and pod phase transitions into metrics served in the
+// OpenMetrics text format, and optionally into an OTLP log stream, so
+// dashboards can alert on them without scraping the API. It polls the store
+// on every Sync and implements the controller manager's controller methods,
+// so it can run there.
+type Exporter struct {
+	mu sync.Mutex
+
+	store store.Store
+	logs  *otlpLogSink
+
+	// synced is set after the first Sync; objects seen then are the
+	// baseline, which is counted but not sent as logs
+	synced bool
+	// eventCounts holds the count of every event as last seen, by namespace/name
+	eventCounts map[string]int32
+	// podPhases holds the phase of every pod as last seen, by UID
+	podPhases map[string]string
+
+	events      map[eventKey]float64
+	transitions map[transitionKey]float64
+	pods        map[[2]string]float64
+	waiting     map[[2]string]float64
+}
+
+// NewExporter creates an exporter
+func NewExporter(config *ExporterConfig) *Exporter {
+	e := &Exporter{
+		store:       config.Store,
+		eventCounts: make(map[string]int32),
+		podPhases:   make(map[string]string),
+		events:      make(map[eventKey]float64),
+		transitions: make(map[transitionKey]float64),
+	}
+	if config.OTLPEndpoint != "" {
+		e.logs = newOTLPLogSink(config.OTLPEndpoint)
+	}
+	return e
+}
+
+// Name returns the name of the exporter
+func (e *Exporter) Name() string {
+	return "events-exporter"
+}
+
+// Start starts the exporter; all of its work happens in Sync
+func (e *Exporter) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the exporter
+func (e *Exporter) Stop() error {
+	return nil
+}
+
+// Sync counts the events and pod phase transitions since the last Sync and
+// sends them to the OTLP endpoint, if any
+func (e *Exporter) Sync(ctx context.Context) error {
+	eventObjs, err := e.store.List(ctx, "Event", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+	podObjs, err := e.store.List(ctx, "Pod", store.AllNamespaces)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	e.mu.Lock()
+	var records []logRecord
+	e.syncEvents(eventObjs, &records)
+	e.syncPods(podObjs, &records)
+	if !e.synced {
+		e.synced = true
+		records = nil
+	}
+	e.mu.Unlock()
+
+	if e.logs == nil || len(records) == 0 {
+		return nil
+	}
+	if err := e.logs.export(ctx, records); err != nil {
+		return fmt.Errorf("failed to export %d log records: %w", len(records), err)
+	}
+	return nil
+}
+
+// syncEvents adds the occurrences of events since they were last seen to
+// the counters. Called with e.mu held.
+func (e *Exporter) syncEvents(objs []store.Object, records *[]logRecord) {
+	current := make(map[string]int32, len(objs))
+	for _, obj := range objs {
+		event, ok := obj.(*api.Event)
+		if !ok {
+			continue
+		}
+		key := event.Namespace + "/" + event.Name
+		current[key] = event.Count
+
+		// Events recreated after expiring start counting from scratch
+		occurrences := event.Count - e.eventCounts[key]
+		if occurrences < 0 {
+			occurrences = event.Count
+		}
+		if occurrences <= 0 {
+			continue
+		}
+		e.events[eventKey{
+			namespace: event.InvolvedObject.Namespace,
+			kind:      event.InvolvedObject.Kind,
+			eventType: event.Type,
+			reason:    event.Reason,
+		}] += float64(occurrences)
+		*records = append(*records, eventRecord(event, occurrences))
+	}
+	e.eventCounts = current
+}
+
+// syncPods counts pod phase transitions and takes the pod gauges. Called
+// with e.mu held.
+func (e *Exporter) syncPods(objs []store.Object, records *[]logRecord) {
+	phases := make(map[string]string, len(objs))
+	pods := make(map[[2]string]float64)
+	waiting := make(map[[2]string]float64)
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if !ok {
+			continue
+		}
+		phase := string(pod.Status.Phase)
+		if phase == "" {
+			phase = string(api.PodPending)
+		}
+		phases[pod.UID] = phase
+		pods[[2]string{pod.Namespace, phase}]++
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+				waiting[[2]string{pod.Namespace, status.State.Waiting.Reason}]++
+			}
+		}
+
+		previous, ok := e.podPhases[pod.UID]
+		if !ok || previous == phase {
+			continue
+		}
+		e.transitions[transitionKey{namespace: pod.Namespace, from: previous, to: phase}]++
+		*records = append(*records, transitionRecord(pod, previous, phase))
+	}
+	e.podPhases = phases
+	e.pods = pods
+	e.waiting = waiting
+}
+
+// ServeHTTP serves the metrics, in the OpenMetrics text format to scrapers
+// that accept it and in the Prometheus text format otherwise
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", prometheusContentType)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	m := &metricsWriter{w: w, openMetrics: openMetrics}
+	m.family("minik8s_events", "counter", "Occurrences of events, by the kind of object they're about")
+	for _, key := range sortedKeys(e.events, func(k eventKey) string {
+		return k.namespace + "\x00" + k.kind + "\x00" + k.eventType + "\x00" + k.reason
+	}) {
+		m.sample("minik8s_events_total", e.events[key], "namespace", key.namespace, "kind", key.kind, "type", key.eventType, "reason", key.reason)
+	}
+
+	m.family("minik8s_pod_phase_transitions", "counter", "Pod phase changes")
+	for _, key := range sortedKeys(e.transitions, func(k transitionKey) string {
+		return k.namespace + "\x00" + k.from + "\x00" + k.to
+	}) {
+		m.sample("minik8s_pod_phase_transitions_total", e.transitions[key], "namespace", key.namespace, "from", key.from, "to", key.to)
+	}
+
+	m.family("minik8s_pods", "gauge", "Pods by phase")
+	for _, key := range sortedKeys(e.pods, func(k [2]string) string { return k[0] + "\x00" + k[1] }) {
+		m.sample("minik8s_pods", e.pods[key], "namespace", key[0], "phase", key[1])
+	}
+
+	m.family("minik8s_containers_waiting", "gauge", "Containers waiting to run, by reason, such as CrashLoopBackOff")
+	for _, key := range sortedKeys(e.waiting, func(k [2]string) string { return k[0] + "\x00" + k[1] }) {
+		m.sample("minik8s_containers_waiting", e.waiting[key], "namespace", key[0], "reason", key[1])
+	}
+
+	m.family("minik8s_watch_relists", "counter", "Lists made again after a watch failed or ended, by consumer and kind")
+	for _, relist := range store.Relists() {
+		m.sample("minik8s_watch_relists_total", float64(relist.Count), "consumer", relist.Name, "kind", relist.Kind)
+	}
+
+	if openMetrics {
+		io.WriteString(w, "# EOF\n")
+	}
+}
+
+// Serve serves the metrics under /metrics on addr in the background. It
+// returns once the address is bound.
+func (e *Exporter) Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error serving event metrics: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("Serving event metrics on http://%s/metrics\n", listener.Addr())
+	return server, nil
+}
+
+// metricsWriter writes metric families in the OpenMetrics or Prometheus
+// text format, which differ in how counters are named in metadata
+type metricsWriter struct {
+	w           io.Writer
+	openMetrics bool
+}
+
+// family writes the metadata of a metric family. Prometheus names counter
+// families after their _total samples.
+func (m *metricsWriter) family(name, metricType, help string) {
+	if metricType == "counter" && !m.openMetrics {
+		name += "_total"
+	}
+	fmt.Fprintf(m.w, "# TYPE %s %s\n# HELP %s %s\n", name, metricType, name, help)
+}
+
+// sample writes one sample with labels given as name, value pairs
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], escapeLabelValue(labels[i+1])))
+	}
+	fmt.Fprintf(m.w, "%s{%s} %g\n", name, strings.Join(pairs, ","), value)
+}
+
+// escapeLabelValue escapes a label value for the text formats
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+// sortedKeys returns the keys of m ordered by the strings key derives from
+// them, so scrapes list series in a stable order
+func sortedKeys[K comparable](m map[K]float64, key func(K) string) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return key(keys[i]) < key(keys[j]) })
+	return keys
+}
+
+// eventRecord returns the log record of new occurrences of event
+func eventRecord(event *api.Event, occurrences int32) logRecord {
+	return logRecord{
+		time:    event.LastTimestamp,
+		warning: event.Type == api.EventTypeWarning,
+		body:    event.Message,
+		attributes: [][2]string{
+			{"event.reason", event.Reason},
+			{"event.type", event.Type},
+			{"event.count", fmt.Sprint(occurrences)},
+			{"k8s.namespace.name", event.InvolvedObject.Namespace},
+			{"k8s.object.kind", event.InvolvedObject.Kind},
+			{"k8s.object.name", event.InvolvedObject.Name},
+			{"source.component", event.Source.Component},
+		},
+	}
+}
+
+// transitionRecord returns the log record of a pod changing phase
+func transitionRecord(pod *api.Pod, from, to string) logRecord {
+	return logRecord{
+		time:    time.Now(),
+		warning: to == string(api.PodFailed),
+		body:    fmt.Sprintf("Pod %s/%s changed phase from %s to %s", pod.Namespace, pod.Name, from, to),
+		attributes: [][2]string{
+			{"k8s.namespace.name", pod.Namespace},
+			{"k8s.pod.name", pod.Name},
+			{"k8s.pod.uid", pod.UID},
+			{"pod.phase.from", from},
+			{"pod.phase.to", to},
+		},
+	}
+}
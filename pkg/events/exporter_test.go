@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func TestExporter(t *testing.T) {
+	var exported []otlpExportRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request otlpExportRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode OTLP request: %v", err)
+		}
+		exported = append(exported, request)
+	}))
+	defer collector.Close()
+
+	s := store.NewMemoryStore(store.DefaultOptions())
+	ctx := context.Background()
+	recorder := NewRecorder(&Config{Store: s, Component: "node-agent"})
+	exporter := NewExporter(&ExporterConfig{Store: s, OTLPEndpoint: collector.URL})
+
+	pod := testPod("web")
+	pod.Status.Phase = api.PodPending
+	if err := s.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	recorder.Eventf(ctx, pod, api.EventTypeWarning, "BackOff", "Back-off restarting failed container")
+
+	// The first sync is the baseline: counted, but not sent as logs
+	if err := exporter.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(exported) != 0 {
+		t.Fatalf("Expected no logs for the baseline, got %d requests", len(exported))
+	}
+
+	recorder.Eventf(ctx, pod, api.EventTypeWarning, "BackOff", "Back-off restarting failed container")
+	recorder.Eventf(ctx, pod, api.EventTypeWarning, "BackOff", "Back-off restarting failed container")
+	pod.Status.Phase = api.PodRunning
+	pod.Status.ContainerStatuses = []api.ContainerStatus{{
+		Name:  "app",
+		State: api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	if err := exporter.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/openmetrics-text") {
+		t.Errorf("Expected an OpenMetrics content type, got %q", got)
+	}
+	metrics := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE minik8s_events counter\n",
+		`minik8s_events_total{namespace="default",kind="Pod",type="Warning",reason="BackOff"} 3` + "\n",
+		`minik8s_pod_phase_transitions_total{namespace="default",from="Pending",to="Running"} 1` + "\n",
+		`minik8s_pods{namespace="default",phase="Running"} 1` + "\n",
+		`minik8s_containers_waiting{namespace="default",reason="CrashLoopBackOff"} 1` + "\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, metrics)
+		}
+	}
+	if !strings.HasSuffix(metrics, "# EOF\n") {
+		t.Error("Expected OpenMetrics output to end with # EOF")
+	}
+
+	// Plain Prometheus scrapers get counter families named after their samples
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "# TYPE minik8s_events_total counter\n") || strings.Contains(rec.Body.String(), "# EOF") {
+		t.Errorf("Expected the Prometheus text format, got:\n%s", rec.Body.String())
+	}
+
+	// Both new occurrences and the transition go out in one request
+	if len(exported) != 1 {
+		t.Fatalf("Expected one OTLP request, got %d", len(exported))
+	}
+	records := exported[0].ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("Expected two log records, got %d", len(records))
+	}
+	if records[0].SeverityText != "WARN" || records[0].Body.StringValue != "Back-off restarting failed container" {
+		t.Errorf("Unexpected event record: %+v", records[0])
+	}
+	if records[1].Body.StringValue != "Pod default/web changed phase from Pending to Running" {
+		t.Errorf("Unexpected transition record: %+v", records[1])
+	}
+}
@@ -0,0 +1,125 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/httpclient"
+)
+
+// OTLP severity numbers of the records sent
+const (
+	otlpSeverityInfo = 9
+	otlpSeverityWarn = 13
+)
+
+// logRecord is an event or pod phase transition to send as an OTLP log
+type logRecord struct {
+	time       time.Time
+	warning    bool
+	body       string
+	attributes [][2]string
+}
+
+// otlpLogSink sends log records to an OTLP/HTTP logs endpoint, encoded as
+// JSON
+type otlpLogSink struct {
+	endpoint string
+	client   *httpclient.Client
+}
+
+// newOTLPLogSink creates a sink posting to endpoint
+func newOTLPLogSink(endpoint string) *otlpLogSink {
+	return &otlpLogSink{
+		endpoint: endpoint,
+		client:   httpclient.New(nil),
+	}
+}
+
+// The OTLP JSON encoding of an ExportLogsServiceRequest, limited to what
+// the sink sends
+type (
+	otlpExportRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano   string         `json:"timeUnixNano"`
+		SeverityNumber int            `json:"severityNumber"`
+		SeverityText   string         `json:"severityText"`
+		Body           otlpAnyValue   `json:"body"`
+		Attributes     []otlpKeyValue `json:"attributes"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+// export sends records in a single request
+func (s *otlpLogSink) export(ctx context.Context, records []logRecord) error {
+	logs := make([]otlpLogRecord, 0, len(records))
+	for _, record := range records {
+		severity, severityText := otlpSeverityInfo, "INFO"
+		if record.warning {
+			severity, severityText = otlpSeverityWarn, "WARN"
+		}
+		attributes := make([]otlpKeyValue, 0, len(record.attributes))
+		for _, attribute := range record.attributes {
+			attributes = append(attributes, otlpKeyValue{Key: attribute[0], Value: otlpAnyValue{StringValue: attribute[1]}})
+		}
+		logs = append(logs, otlpLogRecord{
+			TimeUnixNano:   strconv.FormatInt(record.time.UnixNano(), 10),
+			SeverityNumber: severity,
+			SeverityText:   severityText,
+			Body:           otlpAnyValue{StringValue: record.body},
+			Attributes:     attributes,
+		})
+	}
+
+	body, err := json.Marshal(otlpExportRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: otlpAnyValue{StringValue: "minik8s"}},
+		}},
+		ScopeLogs: []otlpScopeLogs{{
+			Scope:      otlpScope{Name: "minik8s.io/events"},
+			LogRecords: logs,
+		}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode logs: %w", err)
+	}
+
+	resp, err := s.client.Post(ctx, s.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s - %s", resp.Status, string(message))
+	}
+	return nil
+}
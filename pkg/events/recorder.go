@@ -0,0 +1,197 @@
+// Package events records Events about API objects. Repeats of an event are
+// folded into a single Event with a count, and the number of Events kept per
+// object is capped, so a component stuck in a retry loop can't flood the store.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+const (
+	// DefaultTTL is how long an Event is kept after it was last seen
+	DefaultTTL = time.Hour
+
+	// DefaultMaxEventsPerObject is how many distinct Events are kept per object
+	DefaultMaxEventsPerObject = 50
+
+	// clusterEventNamespace holds the events of cluster-scoped objects such as nodes
+	clusterEventNamespace = "default"
+)
+
+// Config holds the configuration of a Recorder
+type Config struct {
+	Store store.Store
+
+	// Component and Host identify the reporter in each event's source
+	Component string
+	Host      string
+
+	// MaxEventsPerObject caps the Events kept per object, dropping the least
+	// recently seen ones first. Zero uses DefaultMaxEventsPerObject, a
+	// negative value disables the cap.
+	MaxEventsPerObject int
+}
+
+// Recorder records events on behalf of one component
+type Recorder struct {
+	mu sync.Mutex
+
+	store        store.Store
+	source       api.EventSource
+	maxPerObject int
+}
+
+// NewRecorder creates a new event recorder
+func NewRecorder(config *Config) *Recorder {
+	if config.MaxEventsPerObject == 0 {
+		config.MaxEventsPerObject = DefaultMaxEventsPerObject
+	}
+
+	return &Recorder{
+		store:        config.Store,
+		source:       api.EventSource{Component: config.Component, Host: config.Host},
+		maxPerObject: config.MaxEventsPerObject,
+	}
+}
+
+// Eventf records an event about obj. Events are informational, so failures
+// are logged rather than returned. A nil Recorder drops events.
+func (r *Recorder) Eventf(ctx context.Context, obj store.Object, eventType, reason, format string, args ...interface{}) {
+	if r == nil {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	if err := r.record(ctx, obj, eventType, reason, message, time.Now()); err != nil {
+		fmt.Printf("Failed to record event %s for %s %s: %v\n", reason, obj.GetKind(), obj.GetName(), err)
+	}
+}
+
+// record stores an event, bumping the count of an identical earlier one
+func (r *Recorder) record(ctx context.Context, obj store.Object, eventType, reason, message string, now time.Time) error {
+	ref := api.ObjectReference{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       obj.GetUID(),
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = clusterEventNamespace
+	}
+	name := eventName(ref, r.source, eventType, reason, message)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Another reporter may create the same event between our get and create,
+	// in which case the second attempt counts it as a repeat
+	for attempt := 0; attempt < 2; attempt++ {
+		existing, err := r.store.Get(ctx, "Event", namespace, name)
+		if err == nil {
+			event, ok := existing.(*api.Event)
+			if !ok {
+				return fmt.Errorf("stored object is not an event")
+			}
+			event.Count++
+			event.LastTimestamp = now
+			return r.store.Update(ctx, event)
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get event: %w", err)
+		}
+
+		if err := r.enforceLimit(ctx, namespace, ref); err != nil {
+			return err
+		}
+
+		event := &api.Event{
+			TypeMeta: api.TypeMeta{Kind: "Event", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			InvolvedObject: ref,
+			Type:           eventType,
+			Reason:         reason,
+			Message:        message,
+			Source:         r.source,
+			Count:          1,
+			FirstTimestamp: now,
+			LastTimestamp:  now,
+		}
+		err = r.store.Create(ctx, event)
+		if !errors.Is(err, store.ErrAlreadyExists) {
+			return err
+		}
+	}
+	return fmt.Errorf("event %s/%s keeps being recreated", namespace, name)
+}
+
+// enforceLimit deletes the least recently seen events about ref until there's
+// room for one more
+func (r *Recorder) enforceLimit(ctx context.Context, namespace string, ref api.ObjectReference) error {
+	if r.maxPerObject < 0 {
+		return nil
+	}
+
+	objs, err := r.store.List(ctx, "Event", namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+
+	var related []*api.Event
+	for _, obj := range objs {
+		if event, ok := obj.(*api.Event); ok && sameObject(event.InvolvedObject, ref) {
+			related = append(related, event)
+		}
+	}
+	if len(related) < r.maxPerObject {
+		return nil
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		return related[i].LastTimestamp.Before(related[j].LastTimestamp)
+	})
+	for _, event := range related[:len(related)-r.maxPerObject+1] {
+		if err := r.store.Delete(ctx, "Event", event.Namespace, event.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to delete event %s: %w", event.Name, err)
+		}
+	}
+	return nil
+}
+
+// sameObject reports whether two references name the same object. UIDs tell
+// apart objects that were deleted and recreated under the same name.
+func sameObject(a, b api.ObjectReference) bool {
+	if a.Kind != b.Kind || a.Namespace != b.Namespace || a.Name != b.Name {
+		return false
+	}
+	return a.UID == "" || b.UID == "" || a.UID == b.UID
+}
+
+// eventName derives the name of an event from everything that makes it
+// distinct, so repeats map onto the same stored Event
+func eventName(ref api.ObjectReference, source api.EventSource, eventType, reason, message string) string {
+	h := fnv.New64a()
+	for _, part := range []string{ref.Kind, ref.Namespace, ref.Name, ref.UID, source.Component, source.Host, eventType, reason, message} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	suffix := fmt.Sprintf(".%016x", h.Sum64())
+
+	base := strings.ToLower(ref.Name)
+	if len(base) > api.MaxNameLength-len(suffix) {
+		base = base[:api.MaxNameLength-len(suffix)]
+	}
+	return base + suffix
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func testPod(name string) *api.Pod {
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+	}
+}
+
+func listEvents(t *testing.T, s store.Store, namespace string) []*api.Event {
+	t.Helper()
+	objs, err := s.List(context.Background(), "Event", namespace)
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	var events []*api.Event
+	for _, obj := range objs {
+		events = append(events, obj.(*api.Event))
+	}
+	return events
+}
+
+func TestRecorder_AggregatesRepeats(t *testing.T) {
+	s := store.NewMemoryStore(store.DefaultOptions())
+	recorder := NewRecorder(&Config{Store: s, Component: "scheduler"})
+	ctx := context.Background()
+	pod := testPod("web")
+
+	first := time.Now().Add(-time.Minute)
+	last := time.Now()
+	for _, now := range []time.Time{first, first.Add(time.Second), last} {
+		if err := recorder.record(ctx, pod, api.EventTypeWarning, "FailedScheduling", "no nodes available", now); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	if err := recorder.record(ctx, pod, api.EventTypeNormal, "Scheduled", "assigned to node-1", last); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	events := listEvents(t, s, "default")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Reason != "FailedScheduling" {
+			continue
+		}
+		if event.Count != 3 {
+			t.Errorf("Expected count 3, got %d", event.Count)
+		}
+		if !event.FirstTimestamp.Equal(first) || !event.LastTimestamp.Equal(last) {
+			t.Errorf("Expected first/last seen %v/%v, got %v/%v", first, last, event.FirstTimestamp, event.LastTimestamp)
+		}
+		if event.InvolvedObject.Name != "web" || event.Source.Component != "scheduler" {
+			t.Errorf("Unexpected involved object or source: %+v %+v", event.InvolvedObject, event.Source)
+		}
+	}
+}
+
+func TestRecorder_CapsEventsPerObject(t *testing.T) {
+	s := store.NewMemoryStore(store.DefaultOptions())
+	recorder := NewRecorder(&Config{Store: s, MaxEventsPerObject: 3})
+	ctx := context.Background()
+	pod := testPod("web")
+	other := testPod("db")
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		reason := []string{"A", "B", "C", "D", "E"}[i]
+		if err := recorder.record(ctx, pod, api.EventTypeNormal, reason, "", start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	if err := recorder.record(ctx, other, api.EventTypeNormal, "A", "", start); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	reasons := make(map[string]bool)
+	for _, event := range listEvents(t, s, "default") {
+		if event.InvolvedObject.Name == "web" {
+			reasons[event.Reason] = true
+		}
+	}
+	if len(reasons) != 3 || !reasons["C"] || !reasons["D"] || !reasons["E"] {
+		t.Errorf("Expected the 3 most recent events to be kept, got %v", reasons)
+	}
+	if len(listEvents(t, s, "default")) != 4 {
+		t.Error("Expected events of other objects to be unaffected by the cap")
+	}
+}
+
+func TestRecorder_ClusterScopedObjects(t *testing.T) {
+	s := store.NewMemoryStore(store.DefaultOptions())
+	recorder := NewRecorder(&Config{Store: s})
+
+	node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+	}
+	recorder.Eventf(context.Background(), node, api.EventTypeWarning, "NotReady", "node %s stopped reporting", node.Name)
+
+	events := listEvents(t, s, clusterEventNamespace)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0].Message != "node node-1 stopped reporting" {
+		t.Errorf("Unexpected message %q", events[0].Message)
+	}
+}
+
+func TestEventName(t *testing.T) {
+	ref := api.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web"}
+	a := eventName(ref, api.EventSource{}, api.EventTypeNormal, "Pulled", "pulled nginx")
+	b := eventName(ref, api.EventSource{}, api.EventTypeNormal, "Pulled", "pulled nginx")
+	c := eventName(ref, api.EventSource{}, api.EventTypeNormal, "Pulled", "pulled redis")
+	if a != b {
+		t.Errorf("Expected identical events to share a name, got %s and %s", a, b)
+	}
+	if a == c {
+		t.Errorf("Expected different messages to get different names, got %s", a)
+	}
+
+	ref.Name = strings.Repeat("a", 100)
+	if name := eventName(ref, api.EventSource{}, "", "", ""); len(name) > api.MaxNameLength {
+		t.Errorf("Expected name of at most %d characters, got %d", api.MaxNameLength, len(name))
+	}
+}
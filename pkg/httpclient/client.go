@@ -0,0 +1,250 @@
+// Package httpclient provides the HTTP client shared by the CLI and the node
+// agent for talking to the API server. It bounds every attempt with a timeout
+// and retries idempotent requests that fail with connection errors or 5xx
+// responses, backing off exponentially between attempts.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/requestid"
+)
+
+// Defaults applied by New for unset fields
+const (
+	DefaultTimeout        = 30 * time.Second
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+)
+
+// Config holds the configuration for a Client
+type Config struct {
+	// Timeout bounds each attempt including reading the body; negative disables it
+	Timeout time.Duration
+
+	// MaxRetries is how often a failed idempotent request is retried; negative disables retries
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry, doubling up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Transport performs the requests; defaults to http.DefaultTransport
+	Transport http.RoundTripper
+
+	// BearerToken, if set, authenticates requests that carry no Authorization header
+	BearerToken string
+
+	// WarningHandler, if set, is called with the text of every Warning
+	// header on responses, such as the API server's deprecation warnings
+	WarningHandler func(message string)
+}
+
+// Client is an HTTP client with timeouts and retries
+type Client struct {
+	httpClient     *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	bearerToken    string
+	warningHandler func(message string)
+}
+
+// New creates a client, applying defaults for unset fields of config
+func New(config *Config) *Client {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.InitialBackoff == 0 {
+		config.InitialBackoff = DefaultInitialBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+
+	httpClient := &http.Client{Transport: config.Transport}
+	if config.Timeout > 0 {
+		httpClient.Timeout = config.Timeout
+	}
+
+	maxRetries := config.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	return &Client{
+		httpClient:     httpClient,
+		maxRetries:     maxRetries,
+		initialBackoff: config.InitialBackoff,
+		maxBackoff:     config.MaxBackoff,
+		bearerToken:    config.BearerToken,
+		warningHandler: config.WarningHandler,
+	}
+}
+
+// Do sends req, retrying idempotent requests on connection errors and 5xx
+// responses. Requests without an X-Request-ID header are given one. Requests with a body are only retried when it can be replayed
+// through req.GetBody, which http.NewRequest sets for in-memory bodies.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// Retries share the ID so the server logs show them as one request
+	if req.Header.Get(requestid.Header) == "" {
+		req.Header.Set(requestid.Header, requestid.New())
+	}
+	if c.bearerToken != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	retryable := isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to replay request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if !retryable || attempt >= c.maxRetries || !shouldRetry(ctx, resp, err) {
+			if resp != nil && c.warningHandler != nil {
+				for _, value := range resp.Header.Values("Warning") {
+					c.warningHandler(parseWarning(value))
+				}
+			}
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain so the connection can be reused for the retry
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// Get sends a GET request
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Post sends a POST request with body; POSTs are never retried
+func (c *Client) Post(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Put sends a PUT request with body. It is retried when body can be replayed,
+// as with *bytes.Reader and *strings.Reader.
+func (c *Client) Put(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Patch sends a PATCH request with body; PATCHes are never retried
+func (c *Client) Patch(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Delete sends a DELETE request
+func (c *Client) Delete(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// backoff returns the jittered delay before retry number attempt+1
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.initialBackoff << attempt
+	if delay <= 0 || delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	// Up to 25% jitter so clients retrying together spread out
+	return delay + time.Duration(rand.Int63n(int64(delay)/4+1))
+}
+
+// parseWarning returns the text of a Warning header value written as
+// code agent "text", or the whole value if it isn't in that form
+func parseWarning(value string) string {
+	start := strings.IndexByte(value, '"')
+	if start < 0 {
+		return value
+	}
+	var text strings.Builder
+	for i := start + 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if i+1 < len(value) {
+				i++
+				text.WriteByte(value[i])
+			}
+		case '"':
+			return text.String()
+		default:
+			text.WriteByte(value[i])
+		}
+	}
+	return value
+}
+
+// isIdempotent reports whether repeating a request with method is safe
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether an attempt failed in a way worth retrying
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// Canceled requests are final; connection errors and timeouts are not
+		return !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode >= 500
+}
@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer fails the first failures requests with 503
+func newFlakyServer(failures int32, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		if n <= failures {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(append([]byte("ok:"), body...))
+	}))
+}
+
+func newTestClient() *Client {
+	return New(&Config{
+		Timeout:        time.Second,
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	})
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(2, &calls)
+	defer server.Close()
+
+	resp, err := newTestClient().Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok:", string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClient_ReplaysBodyOnRetry(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(1, &calls)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, bytes.NewReader([]byte("payload")))
+	require.NoError(t, err)
+
+	resp, err := newTestClient().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok:payload", string(body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClient_DoesNotRetryPost(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(1, &calls)
+	defer server.Close()
+
+	resp, err := newTestClient().Post(context.Background(), server.URL, "application/json", bytes.NewReader([]byte("{}")))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(100, &calls)
+	defer server.Close()
+
+	resp, err := newTestClient().Delete(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "one attempt plus three retries")
+}
+
+func TestClient_RetriesConnectionErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	start := time.Now()
+	_, err := newTestClient().Get(context.Background(), url)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_Timeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := New(&Config{Timeout: 20 * time.Millisecond, MaxRetries: -1})
+	_, err := client.Get(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_ContextCancellationStopsRetries(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(100, &calls)
+	defer server.Close()
+
+	client := New(&Config{InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Get(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_RetriesShareRequestID(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(requestid.Header))
+		if len(ids) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient().Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, ids, 2)
+	assert.True(t, requestid.Valid(ids[0]))
+	assert.Equal(t, ids[0], ids[1])
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	client := New(&Config{BearerToken: "node-token"})
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// An explicit header wins over the client's token
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer other")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"Bearer node-token", "Bearer other"}, headers)
+}
+
+func TestClient_WarningHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Warning", `299 - "spec.unschedulable is \"deprecated\""`)
+		w.Header().Add("Warning", "not quoted")
+	}))
+	defer server.Close()
+
+	var warnings []string
+	client := New(&Config{WarningHandler: func(message string) { warnings = append(warnings, message) }})
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{`spec.unschedulable is "deprecated"`, "not quoted"}, warnings)
+}
+
+func TestClient_UnixSocketTransport(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "apiserver.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok:" + r.URL.Path))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	path, ok := UnixSocketPath("unix://" + socket)
+	require.True(t, ok)
+	_, ok = UnixSocketPath("http://localhost:8080")
+	assert.False(t, ok)
+
+	client := New(&Config{Transport: UnixSocketTransport(path)})
+	resp, err := client.Get(context.Background(), "http://localhost/version")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok:/version", string(body))
+}
@@ -0,0 +1,31 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// UnixSocketScheme prefixes server URLs naming a unix domain socket, as in
+// unix:///run/minik8s/apiserver.sock
+const UnixSocketScheme = "unix://"
+
+// UnixSocketPath returns the socket path of a unix:// server URL, and whether
+// serverURL is one
+func UnixSocketPath(serverURL string) (string, bool) {
+	path, ok := strings.CutPrefix(serverURL, UnixSocketScheme)
+	return path, ok && path != ""
+}
+
+// UnixSocketTransport sends every request over the unix domain socket at
+// path, whatever host its URL names
+func UnixSocketTransport(path string) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", path)
+	}
+	return transport
+}
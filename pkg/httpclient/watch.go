@@ -0,0 +1,189 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/requestid"
+)
+
+// DefaultWatchIdleTimeout is how long a watch stream may stay silent before
+// it's considered dead. It's a few multiples of the API server's default
+// heartbeat interval so a single late heartbeat doesn't cause a reconnect.
+const DefaultWatchIdleTimeout = 45 * time.Second
+
+// bookmarkEventType is the type of the heartbeat lines sent on idle watches
+const bookmarkEventType = "BOOKMARK"
+
+// errWatchClosed reports that the server ended the stream
+var errWatchClosed = errors.New("watch closed by the server")
+
+// StatusError is returned for responses with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s - %s", e.Status, e.Body)
+}
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	// IdleTimeout is how long the stream may go without a line, heartbeats
+	// included, before it's dropped and reopened; defaults to DefaultWatchIdleTimeout
+	IdleTimeout time.Duration
+
+	// OnConnect, if set, is called every time the stream is (re)opened,
+	// before its first event is handled
+	OnConnect func()
+}
+
+// Watch follows the JSON-lines watch stream at url, calling handle for every
+// event and dropping heartbeat bookmarks. Streams that end, fail or go quiet
+// for longer than the idle timeout are reopened with backoff, so callers see
+// one continuous stream; since a watch starts with the current objects,
+// OnConnect tells them when to resynchronize. Until the first stream is
+// open, failures count against the client's retry limit. Watch only
+// returns once ctx is done or the server rejects the request with a 4xx.
+func (c *Client) Watch(ctx context.Context, url string, opts *WatchOptions, handle func(event []byte)) error {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	idleTimeout := opts.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultWatchIdleTimeout
+	}
+
+	// The stream is bounded by the idle timeout rather than the client timeout
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+
+	everConnected := false
+	for attempt := 0; ; attempt++ {
+		connected, err := watchStream(ctx, streamClient, url, idleTimeout, opts.OnConnect, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode < 500 {
+			return err
+		}
+		if connected {
+			everConnected = true
+			attempt = 0
+		}
+		if !everConnected && attempt >= c.maxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// watchStream opens one watch stream and reads it until it fails. It
+// reports whether the stream was opened along with the error ending it.
+func watchStream(ctx context.Context, client *http.Client, url string, idleTimeout time.Duration, onConnect func(), handle func([]byte)) (bool, error) {
+	// Waiting for the response headers counts against the idle timeout too
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	headerTimer := time.AfterFunc(idleTimeout, cancel)
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set(requestid.Header, requestid.New())
+
+	resp, err := client.Do(req)
+	if !headerTimer.Stop() && ctx.Err() == nil {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return false, fmt.Errorf("watch got no response within %s", idleTimeout)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return false, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+
+	if onConnect != nil {
+		onConnect()
+	}
+
+	// Read lines in the background so a silent stream can be timed out
+	done := make(chan struct{})
+	defer close(done)
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case line := <-lines:
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(idleTimeout)
+			if !isBookmark(line) {
+				handle(line)
+			}
+		case err := <-readErr:
+			if err == io.EOF {
+				err = errWatchClosed
+			}
+			return true, err
+		case <-idle.C:
+			return true, fmt.Errorf("watch received nothing for %s", idleTimeout)
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
+
+// isBookmark reports whether line is a heartbeat rather than a real event
+func isBookmark(line []byte) bool {
+	var event struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(line, &event) == nil && event.Type == bookmarkEventType
+}
@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WatchReconnectsAndDropsBookmarks(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		fmt.Fprintln(w, `{"type":"BOOKMARK","object":null}`)
+		fmt.Fprintf(w, "{\"type\":\"ADDED\",\"object\":{\"n\":%d}}\n", n)
+		// Returning ends the stream, as a restarting server would
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var connects int32
+	var events []string
+	err := newTestClient().Watch(ctx, server.URL, &WatchOptions{
+		OnConnect: func() { atomic.AddInt32(&connects, 1) },
+	}, func(event []byte) {
+		events = append(events, string(event))
+		if len(events) == 3 {
+			cancel()
+		}
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{
+		"{\"type\":\"ADDED\",\"object\":{\"n\":1}}\n",
+		"{\"type\":\"ADDED\",\"object\":{\"n\":2}}\n",
+		"{\"type\":\"ADDED\",\"object\":{\"n\":3}}\n",
+	}, events)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&connects))
+}
+
+func TestClient_WatchReconnectsIdleStreams(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			// Never send the response headers
+		case 2:
+			// Open the stream but never send a line
+			w.(http.Flusher).Flush()
+		default:
+			fmt.Fprintln(w, `{"type":"ADDED"}`)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := newTestClient().Watch(ctx, server.URL, &WatchOptions{IdleTimeout: 50 * time.Millisecond}, func(event []byte) {
+		cancel()
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_WatchReturnsClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "pod not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := newTestClient().Watch(context.Background(), server.URL, nil, func(event []byte) {})
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_WatchGivesUpBeforeFirstConnect(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(100, &calls)
+	defer server.Close()
+
+	err := newTestClient().Watch(context.Background(), server.URL, nil, func(event []byte) {})
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "one attempt plus three retries")
+}
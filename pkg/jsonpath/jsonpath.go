@@ -0,0 +1,158 @@
+// Package jsonpath evaluates a small subset of JSONPath against decoded JSON:
+// field access (.name or ['name']), array indexes ([0], [-1]) and wildcards
+// ([*]). Paths may be wrapped in braces and start with $, as in {$.spec.nodeName}.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stepKind is the type of a single path step
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+// step is one element of a compiled path
+type step struct {
+	kind  stepKind
+	field string
+	index int
+}
+
+// Path is a compiled JSONPath expression
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// Parse compiles a JSONPath expression
+func Parse(expr string) (*Path, error) {
+	s := strings.TrimSpace(expr)
+	if strings.HasPrefix(s, "{") {
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("unclosed brace in %q", expr)
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	s = strings.TrimPrefix(s, "$")
+
+	path := &Path{expr: expr}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			if name == "" {
+				return nil, fmt.Errorf("empty field name in %q", expr)
+			}
+			if name == "*" {
+				path.steps = append(path.steps, step{kind: stepWildcard})
+			} else {
+				path.steps = append(path.steps, step{kind: stepField, field: name})
+			}
+			s = s[end:]
+		case '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in %q", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+
+			switch {
+			case inner == "*":
+				path.steps = append(path.steps, step{kind: stepWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path.steps = append(path.steps, step{kind: stepField, field: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in %q", inner, expr)
+				}
+				path.steps = append(path.steps, step{kind: stepIndex, index: index})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q, paths start with . or [", s[0], expr)
+		}
+	}
+
+	return path, nil
+}
+
+// String returns the expression the path was parsed from
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Evaluate returns every value the path selects from data, which must be
+// decoded JSON (maps, slices and scalars). Missing fields select nothing.
+func (p *Path) Evaluate(data interface{}) []interface{} {
+	current := []interface{}{data}
+	for _, st := range p.steps {
+		var next []interface{}
+		for _, value := range current {
+			switch st.kind {
+			case stepField:
+				if m, ok := value.(map[string]interface{}); ok {
+					if v, ok := m[st.field]; ok {
+						next = append(next, v)
+					}
+				}
+			case stepIndex:
+				if list, ok := value.([]interface{}); ok {
+					index := st.index
+					if index < 0 {
+						index += len(list)
+					}
+					if index >= 0 && index < len(list) {
+						next = append(next, list[index])
+					}
+				}
+			case stepWildcard:
+				switch v := value.(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case map[string]interface{}:
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// Format renders a selected value as text: strings verbatim, everything else as JSON
+func Format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
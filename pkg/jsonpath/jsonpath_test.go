@@ -0,0 +1,68 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const podJSON = `{
+	"metadata": {"name": "web-1", "labels": {"app": "web", "minik8s.io/tier": "frontend"}},
+	"spec": {
+		"nodeName": "worker-1",
+		"containers": [
+			{"name": "nginx", "ports": [{"containerPort": 80}]},
+			{"name": "sidecar"}
+		]
+	}
+}`
+
+func decode(t *testing.T, data string) interface{} {
+	var obj interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &obj))
+	return obj
+}
+
+func TestEvaluate(t *testing.T) {
+	pod := decode(t, podJSON)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{".metadata.name", []string{"web-1"}},
+		{"{.spec.nodeName}", []string{"worker-1"}},
+		{"$.spec.containers[0].name", []string{"nginx"}},
+		{".spec.containers[-1].name", []string{"sidecar"}},
+		{".spec.containers[*].name", []string{"nginx", "sidecar"}},
+		{".spec.containers[0].ports[0].containerPort", []string{"80"}},
+		{".metadata.labels['minik8s.io/tier']", []string{"frontend"}},
+		{".metadata.labels.*", []string{"web", "frontend"}},
+		{".spec.containers[*].ports[*].containerPort", []string{"80"}},
+		{".metadata.missing", nil},
+		{".spec.containers[5].name", nil},
+		{".spec.containers[0]", []string{`{"name":"nginx","ports":[{"containerPort":80}]}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := Parse(tt.expr)
+			require.NoError(t, err)
+
+			var got []string
+			for _, value := range path.Evaluate(pod) {
+				got = append(got, Format(value))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"metadata.name", "{.metadata.name", ".spec.containers[0", ".spec..name", ".items[x]"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,141 @@
+// Package lease keeps the lease of a control plane component renewed, so
+// the API server can tell whether the component is running
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/version"
+)
+
+// DefaultDuration is how long a lease lasts without being renewed. Leases
+// are renewed four times per duration.
+const DefaultDuration = 40 * time.Second
+
+// Config holds the configuration of a heartbeat
+type Config struct {
+	Store store.Store
+
+	// Name is the lease's name in kube-system, e.g. api.LeaseScheduler
+	Name string
+
+	// Identity names the holder; defaults to host_pid
+	Identity string
+
+	// Duration is how long the lease lasts; defaults to DefaultDuration
+	Duration time.Duration
+}
+
+// Heartbeat renews a component's lease while the component runs
+type Heartbeat struct {
+	store    store.Store
+	name     string
+	identity string
+	duration time.Duration
+	stopCh   chan struct{}
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+// NewHeartbeat creates a heartbeat for the lease named in config
+func NewHeartbeat(config *Config) *Heartbeat {
+	if config.Identity == "" {
+		hostname, _ := os.Hostname()
+		config.Identity = fmt.Sprintf("%s_%d", hostname, os.Getpid())
+	}
+	if config.Duration <= 0 {
+		config.Duration = DefaultDuration
+	}
+	return &Heartbeat{
+		store:    config.Store,
+		name:     config.Name,
+		identity: config.Identity,
+		duration: config.Duration,
+		stopCh:   make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Start renews the lease right away and then in the background until Stop
+func (h *Heartbeat) Start(ctx context.Context) error {
+	if err := h.Renew(ctx); err != nil {
+		fmt.Printf("Error renewing lease %s: %v\n", h.name, err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(h.duration / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-h.stopCh:
+				return
+			case <-ticker.C:
+				if err := h.Renew(ctx); err != nil {
+					fmt.Printf("Error renewing lease %s: %v\n", h.name, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops renewing the lease, which then expires
+func (h *Heartbeat) Stop() {
+	close(h.stopCh)
+}
+
+// Renew creates the lease, or takes it over and records a renewal now
+func (h *Heartbeat) Renew(ctx context.Context) error {
+	now := h.now()
+	spec := api.LeaseSpec{
+		HolderIdentity:       h.identity,
+		LeaseDurationSeconds: int32(h.duration / time.Second),
+		RenewTime:            &now,
+	}
+
+	obj, err := h.store.Get(ctx, "Lease", api.NamespaceSystem, h.name)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get lease: %w", err)
+		}
+		lease := &api.Lease{
+			TypeMeta: api.TypeMeta{Kind: "Lease", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{
+				Name:              h.name,
+				Namespace:         api.NamespaceSystem,
+				CreationTimestamp: now,
+				Annotations:       map[string]string{api.AnnotationComponentVersion: version.Get().GitVersion},
+			},
+			Spec: spec,
+		}
+		if err := h.store.Create(ctx, lease); err != nil && !errors.Is(err, store.ErrAlreadyExists) {
+			return fmt.Errorf("failed to create lease: %w", err)
+		}
+		return nil
+	}
+
+	existing, ok := obj.(*api.Lease)
+	if !ok {
+		return fmt.Errorf("stored object is not a lease")
+	}
+	lease := *existing
+	lease.Spec = spec
+	lease.Annotations = make(map[string]string, len(existing.Annotations)+1)
+	for key, value := range existing.Annotations {
+		lease.Annotations[key] = value
+	}
+	lease.Annotations[api.AnnotationComponentVersion] = version.Get().GitVersion
+	if err := h.store.Update(ctx, &lease); err != nil {
+		return fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+func getLease(t *testing.T, s store.Store, name string) *api.Lease {
+	t.Helper()
+	obj, err := s.Get(context.Background(), "Lease", api.NamespaceSystem, name)
+	if err != nil {
+		t.Fatalf("Failed to get lease: %v", err)
+	}
+	return obj.(*api.Lease)
+}
+
+func TestHeartbeat_Renew(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	h := NewHeartbeat(&Config{Store: s, Name: api.LeaseScheduler, Identity: "host_1"})
+	h.now = func() time.Time { return now }
+
+	if err := h.Renew(ctx); err != nil {
+		t.Fatalf("Renew() error = %v", err)
+	}
+	lease := getLease(t, s, api.LeaseScheduler)
+	if lease.Spec.HolderIdentity != "host_1" || lease.Spec.LeaseDurationSeconds != 40 {
+		t.Errorf("Renew() lease spec = %+v", lease.Spec)
+	}
+	if lease.Annotations[api.AnnotationComponentVersion] == "" {
+		t.Error("Renew() didn't record the component version")
+	}
+	if lease.Expired(now.Add(30 * time.Second)) {
+		t.Error("Lease expired before its duration")
+	}
+	if !lease.Expired(now.Add(time.Minute)) {
+		t.Error("Lease didn't expire after its duration")
+	}
+
+	// Another process takes the lease over on its next renewal
+	now = now.Add(time.Minute)
+	h = NewHeartbeat(&Config{Store: s, Name: api.LeaseScheduler, Identity: "host_2"})
+	h.now = func() time.Time { return now }
+	if err := h.Renew(ctx); err != nil {
+		t.Fatalf("Renew() error = %v", err)
+	}
+	lease = getLease(t, s, api.LeaseScheduler)
+	if lease.Spec.HolderIdentity != "host_2" || !lease.Spec.RenewTime.Equal(now) {
+		t.Errorf("Renew() lease spec = %+v, want it renewed by host_2", lease.Spec)
+	}
+	if lease.Expired(now) {
+		t.Error("Renewed lease is expired")
+	}
+}
@@ -0,0 +1,190 @@
+// Package lifecycle starts the long-running components of a binary in the
+// order they were added and stops them in the reverse order on shutdown, so
+// that e.g. the controllers finish their in-flight syncs before the store
+// they write to goes away.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultStopTimeout is how long each component gets to stop when none is
+// configured
+const DefaultStopTimeout = 10 * time.Second
+
+// Runnable is a component the manager starts and stops
+type Runnable interface {
+	// Start starts the component without blocking. ctx stays valid until
+	// every component has been stopped.
+	Start(ctx context.Context) error
+
+	// Stop stops the component, returning once its in-flight work is done
+	// or ctx expires
+	Stop(ctx context.Context) error
+}
+
+// Funcs adapts a pair of functions to a Runnable; either may be nil
+type Funcs struct {
+	StartFunc func(ctx context.Context) error
+	StopFunc  func(ctx context.Context) error
+}
+
+// Start calls StartFunc, if set
+func (f Funcs) Start(ctx context.Context) error {
+	if f.StartFunc == nil {
+		return nil
+	}
+	return f.StartFunc(ctx)
+}
+
+// Stop calls StopFunc, if set
+func (f Funcs) Stop(ctx context.Context) error {
+	if f.StopFunc == nil {
+		return nil
+	}
+	return f.StopFunc(ctx)
+}
+
+// Stopper adapts a component whose Stop takes no context and may block, such
+// as the scheduler or the controller manager. start may be nil.
+func Stopper(start func(ctx context.Context) error, stop func()) Runnable {
+	return Funcs{
+		StartFunc: start,
+		StopFunc: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				stop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Config holds the configuration of a lifecycle manager
+type Config struct {
+	// StopTimeout bounds how long each component gets to stop; defaults to
+	// DefaultStopTimeout
+	StopTimeout time.Duration
+}
+
+// Manager starts and stops a binary's components
+type Manager struct {
+	mu          sync.Mutex
+	components  []component
+	started     int
+	stopTimeout time.Duration
+	cancel      context.CancelFunc
+}
+
+type component struct {
+	name     string
+	runnable Runnable
+}
+
+// NewManager creates a lifecycle manager
+func NewManager(config *Config) *Manager {
+	if config.StopTimeout <= 0 {
+		config.StopTimeout = DefaultStopTimeout
+	}
+	return &Manager{stopTimeout: config.StopTimeout}
+}
+
+// Add adds a component, which is started after and stopped before those
+// added earlier
+func (m *Manager) Add(name string, runnable Runnable) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.components = append(m.components, component{name: name, runnable: runnable})
+}
+
+// OnShutdown adds a hook run on shutdown after the components added after
+// it have stopped, e.g. to save a snapshot once nothing writes anymore
+func (m *Manager) OnShutdown(name string, hook func(ctx context.Context) error) {
+	m.Add(name, Funcs{StopFunc: hook})
+}
+
+// Start starts the components in the order they were added. If one fails
+// to start, those already started are stopped again.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("lifecycle manager is already started")
+	}
+	// Components keep their context until they're all stopped, so stopping
+	// one doesn't cancel the work of another
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.cancel = cancel
+	components := m.components
+	m.mu.Unlock()
+
+	for i, c := range components {
+		if err := c.runnable.Start(runCtx); err != nil {
+			m.mu.Lock()
+			m.started = i
+			m.mu.Unlock()
+			m.Shutdown(context.WithoutCancel(ctx))
+			return fmt.Errorf("failed to start %s: %w", c.name, err)
+		}
+	}
+
+	m.mu.Lock()
+	m.started = len(components)
+	m.mu.Unlock()
+	return nil
+}
+
+// Shutdown stops the started components in the reverse order they were
+// started, giving each the stop timeout, and then cancels their context. A
+// component that fails or doesn't stop in time doesn't keep the others from
+// stopping. ctx bounds the whole shutdown.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components[:m.started]
+	m.started = 0
+	cancel := m.cancel
+	m.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	defer cancel()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		stopCtx, stopCancel := context.WithTimeout(ctx, m.stopTimeout)
+		err := c.runnable.Stop(stopCtx)
+		stopCancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("did not stop within %v", m.stopTimeout)
+		}
+		if err != nil {
+			fmt.Printf("Error stopping %s: %v\n", c.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WaitForSignal blocks until the process gets SIGINT or SIGTERM and returns
+// the signal
+func WaitForSignal() os.Signal {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	return <-sigChan
+}
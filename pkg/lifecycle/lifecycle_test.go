@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder returns a runnable appending its start and stop to calls
+func recorder(calls *[]string, name string) Runnable {
+	return Funcs{
+		StartFunc: func(ctx context.Context) error {
+			*calls = append(*calls, "start "+name)
+			return nil
+		},
+		StopFunc: func(ctx context.Context) error {
+			*calls = append(*calls, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestManager_StopsInReverseOrder(t *testing.T) {
+	var calls []string
+	m := NewManager(&Config{})
+	m.OnShutdown("snapshot", func(ctx context.Context) error {
+		calls = append(calls, "snapshot")
+		return nil
+	})
+	m.Add("server", recorder(&calls, "server"))
+	m.Add("controllers", recorder(&calls, "controllers"))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := m.Start(context.Background()); err == nil {
+		t.Error("expected starting twice to fail")
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown failed: %v", err)
+	}
+
+	want := []string{"start server", "start controllers", "stop controllers", "stop server", "snapshot"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestManager_StartFailureStopsStarted(t *testing.T) {
+	var calls []string
+	m := NewManager(&Config{})
+	m.Add("server", recorder(&calls, "server"))
+	m.Add("broken", Funcs{StartFunc: func(ctx context.Context) error { return errors.New("boom") }})
+	m.Add("controllers", recorder(&calls, "controllers"))
+
+	err := m.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("Start error = %v, want one naming the broken component", err)
+	}
+	want := []string{"start server", "stop server"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestManager_ContextOutlivesStops(t *testing.T) {
+	var runCtx context.Context
+	canceledDuringStop := false
+	m := NewManager(&Config{})
+	m.Add("a", Funcs{StartFunc: func(ctx context.Context) error {
+		runCtx = ctx
+		return nil
+	}})
+	m.Add("b", Funcs{StopFunc: func(ctx context.Context) error {
+		canceledDuringStop = runCtx.Err() != nil
+		return nil
+	}})
+
+	parent, cancel := context.WithCancel(context.Background())
+	if err := m.Start(parent); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	cancel()
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if canceledDuringStop {
+		t.Error("components' context was canceled before they were stopped")
+	}
+	if runCtx.Err() == nil {
+		t.Error("components' context not canceled after shutdown")
+	}
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	stopped := false
+
+	m := NewManager(&Config{StopTimeout: 20 * time.Millisecond})
+	m.Add("first", Funcs{StopFunc: func(ctx context.Context) error {
+		stopped = true
+		return nil
+	}})
+	m.Add("stuck", Stopper(nil, func() { <-block }))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	err := m.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "stuck: did not stop within") {
+		t.Errorf("Shutdown error = %v, want a timeout of stuck", err)
+	}
+	if !stopped {
+		t.Error("a stuck component kept the others from stopping")
+	}
+}
@@ -0,0 +1,70 @@
+package nodeagent
+
+import (
+	"fmt"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// Status reasons of pods the agent refused to start because their requests
+// don't fit in what the node has left, or a host port they need is taken
+const (
+	PodReasonOutOfCPU              = "OutOfCPU"
+	PodReasonOutOfMemory           = "OutOfMemory"
+	PodReasonOutOfEphemeralStorage = "OutOfEphemeralStorage"
+	PodReasonOutOfHugePages        = "OutOfHugePages"
+	PodReasonHostPortConflict      = "HostPortConflict"
+)
+
+// outOfResourceReasons are the reasons of pods that don't fit for each of
+// api.StandardResources
+var outOfResourceReasons = map[api.ResourceName]string{
+	api.ResourceCPU:              PodReasonOutOfCPU,
+	api.ResourceMemory:           PodReasonOutOfMemory,
+	api.ResourceEphemeralStorage: PodReasonOutOfEphemeralStorage,
+	api.ResourceHugePages2Mi:     PodReasonOutOfHugePages,
+}
+
+// admitPod checks that pod's requests fit in the node's allocatable
+// resources besides those requested by the pods already running here, and
+// that none of its host ports is taken by one of them. It returns the reason
+// and message to fail the pod with, or "" to admit it. The scheduler should
+// never place a pod that doesn't fit, but it works from the node status and
+// pods it last saw, so the agent has the final say.
+func (a *Agent) admitPod(pod *api.Pod) (string, string) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	used := make(map[api.ResourceName]float64)
+	podKey := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+	for key, podState := range a.pods {
+		if key == podKey || podState.Status.Phase == api.PodSucceeded || podState.Status.Phase == api.PodFailed {
+			continue
+		}
+		if port, conflict := api.HostPortConflict(pod, podState.Pod); conflict {
+			return PodReasonHostPortConflict, fmt.Sprintf("Host port %d/%s is already used by pod %s", port.HostPort, port.Protocol, key)
+		}
+		for _, name := range api.StandardResources {
+			used[name] += api.PodRequest(podState.Pod, name)
+		}
+	}
+
+	if a.nodeStatus == nil {
+		return "", ""
+	}
+	for _, name := range api.StandardResources {
+		requested := api.PodRequest(pod, name)
+		if requested <= 0 {
+			continue
+		}
+		// A node has no huge pages unless it reports them
+		allocatable, ok := a.nodeStatus.Allocatable[name]
+		if !ok && name != api.ResourceHugePages2Mi {
+			continue
+		}
+		if total, err := api.ParseQuantity(name, allocatable); err == nil && used[name]+requested > total {
+			return outOfResourceReasons[name], fmt.Sprintf("Node didn't have enough resource: %s, requested: %g, used: %g, capacity: %g", name, requested, used[name], total)
+		}
+	}
+	return "", ""
+}
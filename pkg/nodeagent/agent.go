@@ -2,14 +2,22 @@ package nodeagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/minik8s/minik8s/pkg/api"
 	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/minik8s/minik8s/pkg/version"
 )
 
+// defaultStopTimeout is the grace period in seconds given to containers
+// whose pod is unknown on stop
+const defaultStopTimeout = 30
+
 // Agent represents a node agent (kubelet-like component)
 type Agent struct {
 	mu sync.RWMutex
@@ -20,29 +28,72 @@ type Agent struct {
 	store        store.Store
 
 	// Runtime components
-	criRuntime CRIRuntime
-	networkMgr NetworkManager
-	volumeMgr  VolumeManager
+	criRuntime      CRIRuntime
+	runtimeHandlers map[string]CRIRuntime
+	networkMgr      NetworkManager
+	volumeMgr       VolumeManager
+	checkpoints     *checkpointStore
 
 	// State
-	pods       map[string]*PodState
-	nodeStatus *api.NodeStatus
-	running    bool
-	stopCh     chan struct{}
+	pods map[string]*PodState
+	// terminating holds the UIDs of deleted pods being stopped
+	terminating map[string]bool
+	nodeStatus  *api.NodeStatus
+	running     bool
+	stopCh      chan struct{}
+
+	// shuttingDown is set once Shutdown started; no pods are started after
+	shuttingDown        bool
+	shutdownGracePeriod time.Duration
 
 	// Heartbeat
 	heartbeatInterval time.Duration
 	lastHeartbeat     time.Time
+
+	// Status writes are skipped while nothing changed, up to maxStaleness
+	statusMaxStaleness time.Duration
+	nodeReport         statusReport
+
+	// Pods are synced this often, and whenever one of them changes
+	podSyncInterval time.Duration
+
+	// Garbage collection
+	containerGCInterval time.Duration
+
+	// Container log rotation and the logs kept of replaced containers
+	containerLogDir      string
+	containerLogMaxSize  int64
+	containerLogMaxFiles int
+	containerLogMaxAge   time.Duration
+
+	// Streaming server for exec and friends
+	serverAddress string
+	serverPort    int
+	serverToken   string
+	nodeAddress   string
+	server        *http.Server
+
+	// Applied to the node object on startup
+	nodeLabels     map[string]string
+	registerTaints []api.Taint
 }
 
 // PodState tracks the runtime state of a pod on this node
 type PodState struct {
 	Pod        *api.Pod
+	SandboxID  string
 	Status     *api.PodStatus
 	Containers map[string]*ContainerRuntimeState
 	Volumes    map[string]*VolumeState
 	Created    time.Time
 	Updated    time.Time
+
+	// RuntimeHandler names the runtime the pod's containers live in, ""
+	// being the default one
+	RuntimeHandler string
+
+	// report is the status last written to the store
+	report statusReport
 }
 
 // ContainerRuntimeState tracks the runtime state of a container
@@ -52,6 +103,14 @@ type ContainerRuntimeState struct {
 	StartedAt time.Time
 	ExitCode  int32
 	Message   string
+
+	// Attempt is the attempt the container was created with, which is how
+	// often the spec container was restarted
+	Attempt uint32
+
+	// stats is the last usage sample, which the next one's CPU usage is
+	// measured against
+	stats *ContainerStats
 }
 
 // VolumeState tracks the state of mounted volumes
@@ -71,6 +130,64 @@ type Config struct {
 	NetworkManager    NetworkManager
 	VolumeManager     VolumeManager
 	HeartbeatInterval time.Duration
+
+	// PodSyncInterval is how often pods are synced when no change to them
+	// triggers a sync sooner
+	PodSyncInterval time.Duration
+
+	// CheckpointDir is where pod checkpoints are persisted; empty disables checkpointing
+	CheckpointDir string
+
+	// ContainerGCInterval is how often leaked containers are removed; negative disables it
+	ContainerGCInterval time.Duration
+
+	// ContainerLogDir is where the logs of replaced containers are kept to
+	// be served as previous logs; empty disables keeping them
+	ContainerLogDir string
+
+	// ContainerLogMaxSize is the size in bytes past which a container log is
+	// rotated; negative disables rotation
+	ContainerLogMaxSize int64
+
+	// ContainerLogMaxFiles is how many rotated files of a container log are kept
+	ContainerLogMaxFiles int
+
+	// ContainerLogMaxAge is how long rotated files are kept; zero keeps them
+	// until they're rotated out
+	ContainerLogMaxAge time.Duration
+
+	// ServerAddress is the address exec requests are served on; empty uses
+	// DefaultServerAddress. Addresses other than loopback ones require a
+	// ServerToken.
+	ServerAddress string
+
+	// ServerPort is where exec requests are served; zero disables the server
+	ServerPort int
+
+	// ServerToken is the bearer token requests to the server must carry;
+	// only the API server should know it
+	ServerToken string
+
+	// NodeAddress is the address the API server uses to reach this node
+	NodeAddress string
+
+	// NodeLabels are set on the node when the agent starts
+	NodeLabels map[string]string
+
+	// RegisterTaints are added to the node when the agent starts
+	RegisterTaints []api.Taint
+
+	// StatusMaxStaleness is how long an unchanged node or pod status goes
+	// without being written; negative writes it on every report
+	StatusMaxStaleness time.Duration
+
+	// RuntimeHandlers are the runtimes besides CRIRuntime pods can choose
+	// with a RuntimeClass, by handler name
+	RuntimeHandlers map[string]CRIRuntime
+
+	// ShutdownGracePeriod bounds how long Shutdown waits for pods to stop;
+	// zero uses DefaultShutdownGracePeriod
+	ShutdownGracePeriod time.Duration
 }
 
 // NewAgent creates a new node agent
@@ -78,17 +195,60 @@ func NewAgent(config *Config) *Agent {
 	if config.HeartbeatInterval == 0 {
 		config.HeartbeatInterval = 30 * time.Second
 	}
+	if config.PodSyncInterval <= 0 {
+		config.PodSyncInterval = DefaultPodSyncInterval
+	}
+	if config.ContainerGCInterval == 0 {
+		config.ContainerGCInterval = DefaultContainerGCInterval
+	}
+	if config.ContainerLogMaxSize == 0 {
+		config.ContainerLogMaxSize = DefaultContainerLogMaxSize
+	}
+	if config.ContainerLogMaxFiles <= 0 {
+		config.ContainerLogMaxFiles = DefaultContainerLogMaxFiles
+	}
+	if config.StatusMaxStaleness == 0 {
+		config.StatusMaxStaleness = DefaultStatusMaxStaleness
+	}
+	if config.ShutdownGracePeriod <= 0 {
+		config.ShutdownGracePeriod = DefaultShutdownGracePeriod
+	}
+	if config.ServerAddress == "" {
+		config.ServerAddress = DefaultServerAddress
+	}
+
+	var checkpoints *checkpointStore
+	if config.CheckpointDir != "" {
+		checkpoints = newCheckpointStore(config.CheckpointDir)
+	}
 
 	return &Agent{
-		nodeName:          config.NodeName,
-		apiServerURL:      config.APIServerURL,
-		store:             config.Store,
-		criRuntime:        config.CRIRuntime,
-		networkMgr:        config.NetworkManager,
-		volumeMgr:         config.VolumeManager,
-		pods:              make(map[string]*PodState),
-		heartbeatInterval: config.HeartbeatInterval,
-		stopCh:            make(chan struct{}),
+		nodeName:             config.NodeName,
+		apiServerURL:         config.APIServerURL,
+		store:                config.Store,
+		criRuntime:           config.CRIRuntime,
+		runtimeHandlers:      config.RuntimeHandlers,
+		networkMgr:           config.NetworkManager,
+		volumeMgr:            config.VolumeManager,
+		checkpoints:          checkpoints,
+		pods:                 make(map[string]*PodState),
+		terminating:          make(map[string]bool),
+		heartbeatInterval:    config.HeartbeatInterval,
+		podSyncInterval:      config.PodSyncInterval,
+		containerGCInterval:  config.ContainerGCInterval,
+		containerLogDir:      config.ContainerLogDir,
+		containerLogMaxSize:  config.ContainerLogMaxSize,
+		containerLogMaxFiles: config.ContainerLogMaxFiles,
+		containerLogMaxAge:   config.ContainerLogMaxAge,
+		serverAddress:        config.ServerAddress,
+		serverPort:           config.ServerPort,
+		serverToken:          config.ServerToken,
+		nodeAddress:          config.NodeAddress,
+		nodeLabels:           config.NodeLabels,
+		registerTaints:       config.RegisterTaints,
+		statusMaxStaleness:   config.StatusMaxStaleness,
+		shutdownGracePeriod:  config.ShutdownGracePeriod,
+		stopCh:               make(chan struct{}),
 	}
 }
 
@@ -106,10 +266,27 @@ func (a *Agent) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize node status: %w", err)
 	}
 
+	// Make sure the node exists and carries the configured labels and taints
+	if err := a.registerNode(ctx); err != nil {
+		fmt.Printf("Error registering node %s: %v\n", a.nodeName, err)
+	}
+
+	// Adopt containers that survived an agent restart instead of recreating them
+	a.restoreCheckpoints(ctx)
+
+	if a.serverPort > 0 {
+		if err := a.startServer(); err != nil {
+			return err
+		}
+	}
+
 	// Start background goroutines
 	go a.podSyncLoop(ctx)
 	go a.heartbeatLoop(ctx)
 	go a.statusReportingLoop(ctx)
+	if a.containerGCInterval > 0 {
+		go a.containerGCLoop(ctx)
+	}
 
 	a.running = true
 	return nil
@@ -124,10 +301,116 @@ func (a *Agent) Stop() {
 		return
 	}
 
+	// Keep the filesystems of pods that asked for it, so they come back
+	// quickly after a reboot
+	a.snapshotPods(context.Background())
+
 	close(a.stopCh)
+	if a.server != nil {
+		a.server.Close()
+		a.server = nil
+	}
 	a.running = false
 }
 
+// registerNode creates this agent's node if it doesn't exist yet and applies
+// the configured labels and taints to it. Labels and taints added by others
+// are left alone, so pools set up with the CLI survive agent restarts.
+func (a *Agent) registerNode(ctx context.Context) error {
+	obj, err := a.store.Get(ctx, "Node", "", a.nodeName)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get node: %w", err)
+		}
+
+		node := &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: a.nodeName},
+			Spec:       api.NodeSpec{Taints: a.registerTaints},
+			Status:     *a.nodeStatus,
+		}
+		mergeLabels(node, a.nodeLabels)
+		mergeLabels(node, a.platformLabels())
+		if err := a.store.Create(ctx, node); err != nil {
+			return fmt.Errorf("failed to create node: %w", err)
+		}
+		fmt.Printf("Registered node %s\n", a.nodeName)
+		return nil
+	}
+
+	node, ok := obj.(*api.Node)
+	if !ok {
+		return fmt.Errorf("stored object is not a node")
+	}
+
+	changed := mergeLabels(node, a.nodeLabels)
+	if mergeLabels(node, a.platformLabels()) {
+		changed = true
+	}
+	for _, taint := range a.registerTaints {
+		if !hasTaint(node, taint) {
+			node.Spec.Taints = append(node.Spec.Taints, taint)
+			changed = true
+		}
+	}
+	// A node that was shut down takes pods again, and is Ready right away
+	// rather than after the next status report
+	if removeShutdownTaint(node) {
+		node.Status = *a.nodeStatus
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := a.store.Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to update node: %w", err)
+	}
+	return nil
+}
+
+// platformLabels returns the os and architecture labels for this node, taken
+// from what the runtime reported so they can't drift from the real platform
+func (a *Agent) platformLabels() map[string]string {
+	labels := make(map[string]string)
+	if a.nodeStatus == nil {
+		return labels
+	}
+	if arch := a.nodeStatus.NodeInfo.Architecture; arch != "" {
+		labels[api.LabelArch] = arch
+	}
+	if osName := a.nodeStatus.NodeInfo.OperatingSystem; osName != "" {
+		labels[api.LabelOS] = osName
+	}
+	return labels
+}
+
+// mergeLabels sets labels on node, reporting whether anything changed
+func mergeLabels(node *api.Node, labels map[string]string) bool {
+	changed := false
+	for key, value := range labels {
+		if current, ok := node.Labels[key]; ok && current == value {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[key] = value
+		changed = true
+	}
+	return changed
+}
+
+// hasTaint reports whether node already has a taint with the same key and effect
+func hasTaint(node *api.Node, taint api.Taint) bool {
+	for _, existing := range node.Spec.Taints {
+		if existing.Key == taint.Key && existing.Effect == taint.Effect {
+			return true
+		}
+	}
+	return false
+}
+
 // initializeNodeStatus initializes the node status
 func (a *Agent) initializeNodeStatus() error {
 	// Get node capacity from runtime
@@ -141,14 +424,15 @@ func (a *Agent) initializeNodeStatus() error {
 	if err != nil {
 		return fmt.Errorf("failed to get node info: %w", err)
 	}
+	nodeInfo.KubeletVersion = version.Get().GitVersion
 
 	a.nodeStatus = &api.NodeStatus{
 		Capacity:    capacity,
 		Allocatable: capacity, // For now, same as capacity
 		Conditions: []api.NodeCondition{
 			{
-				Type:               "Ready",
-				Status:             "True",
+				Type:               api.NodeReady,
+				Status:             api.ConditionTrue,
 				LastHeartbeatTime:  time.Now(),
 				LastTransitionTime: time.Now(),
 			},
@@ -156,14 +440,26 @@ func (a *Agent) initializeNodeStatus() error {
 		NodeInfo: *nodeInfo,
 	}
 
+	if a.nodeAddress != "" {
+		a.nodeStatus.Addresses = []api.NodeAddress{{Type: api.NodeInternalIP, Address: a.nodeAddress}}
+	}
+	if a.serverPort > 0 {
+		a.nodeStatus.DaemonEndpoints.KubeletEndpoint.Port = int32(a.serverPort)
+	}
+
 	return nil
 }
 
 // podSyncLoop continuously syncs pods assigned to this node
 func (a *Agent) podSyncLoop(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(a.podSyncInterval)
 	defer ticker.Stop()
 
+	// Pods scheduled to or removed from this node are synced right away
+	// rather than on the next tick
+	trigger := make(chan struct{}, 1)
+	go a.watchPodChanges(ctx, trigger)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -171,10 +467,13 @@ func (a *Agent) podSyncLoop(ctx context.Context) {
 		case <-a.stopCh:
 			return
 		case <-ticker.C:
-			if err := a.syncPods(ctx); err != nil {
-				// Log error but continue
-				fmt.Printf("Error syncing pods: %v\n", err)
-			}
+		case <-trigger:
+			ticker.Reset(a.podSyncInterval)
+		}
+
+		if err := a.syncPods(ctx); err != nil {
+			// Log error but continue
+			fmt.Printf("Error syncing pods: %v\n", err)
 		}
 	}
 }
@@ -182,16 +481,18 @@ func (a *Agent) podSyncLoop(ctx context.Context) {
 // syncPods syncs all pods assigned to this node
 func (a *Agent) syncPods(ctx context.Context) error {
 	// Get pods assigned to this node
-	pods, err := a.store.List(ctx, "Pod", "")
+	pods, err := store.ListByIndex(ctx, a.store, "Pod", store.AllNamespaces, store.IndexNodeName, a.nodeName)
 	if err != nil {
 		return fmt.Errorf("failed to list pods: %w", err)
 	}
 
 	// Filter pods assigned to this node
 	var nodePods []*api.Pod
+	desired := make(map[string]bool)
 	for _, obj := range pods {
 		if pod, ok := obj.(*api.Pod); ok && pod.Spec.NodeName == a.nodeName {
 			nodePods = append(nodePods, pod)
+			desired[fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)] = true
 		}
 	}
 
@@ -202,25 +503,85 @@ func (a *Agent) syncPods(ctx context.Context) error {
 		}
 	}
 
+	// Tear down pods that were deleted or moved off this node
+	for _, podKey := range a.orphanedPods(desired) {
+		namespace, name, _ := strings.Cut(podKey, "/")
+		fmt.Printf("Pod %s is no longer assigned to node %s, tearing it down\n", podKey, a.nodeName)
+		if err := a.deletePod(ctx, namespace, name); err != nil {
+			fmt.Printf("Error deleting pod %s: %v\n", podKey, err)
+		}
+	}
+
+	// Evict pods whose volumes outgrew their size limits
+	a.enforceVolumeLimits(ctx)
+
+	// Kill pods that ran past their active deadline
+	a.enforceActiveDeadlines(ctx)
+
+	// Keep container logs within their size and age limits
+	a.rotateContainerLogs(ctx)
+
+	// Rewrite volumes with expiring content such as service account tokens
+	a.refreshPodVolumes(ctx)
+
 	return nil
 }
 
+// orphanedPods returns the keys of locally tracked pods missing from the desired set
+func (a *Agent) orphanedPods(desired map[string]bool) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var orphaned []string
+	for podKey := range a.pods {
+		if !desired[podKey] {
+			orphaned = append(orphaned, podKey)
+		}
+	}
+	return orphaned
+}
+
 // syncPod syncs a single pod
 func (a *Agent) syncPod(ctx context.Context, pod *api.Pod) error {
 	podKey := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
 
+	// Deleted pods are stopped, then removed from the store
+	if pod.DeletionTimestamp != nil {
+		a.startTermination(ctx, pod)
+		return nil
+	}
+
 	a.mu.Lock()
 	podState, exists := a.pods[podKey]
 	a.mu.Unlock()
 
 	if !exists {
+		// Pods that finished or were evicted are not started again, and
+		// none are started while the node shuts down
+		if isPodTerminated(pod) || a.isShuttingDown() {
+			return nil
+		}
 		// New pod, create it
 		return a.createPod(ctx, pod)
 	}
 
-	// Existing pod, check if it needs updates
+	// A pod deleted and recreated under the same name is a different pod
+	if podState.Pod.UID != pod.UID {
+		if err := a.deletePod(ctx, pod.Namespace, pod.Name); err != nil {
+			return err
+		}
+		return a.createPod(ctx, pod)
+	}
+
+	// Existing pod, check if it needs updates. Writes that only changed its
+	// status, such as conditions posted for readiness gates, don't.
 	if podState.Pod.ResourceVersion != pod.ResourceVersion {
-		return a.updatePod(ctx, pod)
+		if !statusOnlyChange(podState.Pod, pod) {
+			return a.updatePod(ctx, pod)
+		}
+		a.mu.Lock()
+		podState.Pod = pod
+		a.mu.Unlock()
 	}
 
 	// Sync pod status
@@ -242,53 +603,83 @@ func (a *Agent) createPod(ctx context.Context, pod *api.Pod) error {
 	}
 
 	// Set initial status
-	podState.Status.Phase = string(api.PodPending)
-	podState.Status.Conditions = []api.PodCondition{
-		{
-			Type:               "PodScheduled",
-			Status:             "True",
-			LastTransitionTime: time.Now(),
-		},
+	podState.Status.Phase = api.PodPending
+	podState.Status.Conditions = append([]api.PodCondition(nil), pod.Status.Conditions...)
+	api.SetPodCondition(podState.Status, api.PodCondition{Type: api.PodConditionScheduled, Status: api.ConditionTrue})
+	api.SetPodCondition(podState.Status, api.PodCondition{Type: api.PodConditionInitialized, Status: api.ConditionFalse})
+	api.SetPodCondition(podState.Status, api.PodCondition{Type: api.PodConditionContainersReady, Status: api.ConditionFalse})
+	api.SetPodCondition(podState.Status, api.PodCondition{Type: api.PodConditionReady, Status: api.ConditionFalse})
+
+	// Refuse pods that would overcommit the node rather than start them
+	if reason, message := a.admitPod(pod); reason != "" {
+		fmt.Printf("Rejecting pod %s: %s\n", podKey, message)
+		podState.Status.Phase = api.PodFailed
+		podState.Status.Reason = reason
+		podState.Status.Message = message
+		a.updatePodState(podKey, podState)
+		return nil
+	}
+
+	// Pick the runtime of the pod's RuntimeClass
+	handler, err := a.resolveRuntimeHandler(ctx, pod)
+	if err != nil {
+		podState.Status.Phase = api.PodFailed
+		podState.Status.Message = fmt.Sprintf("Failed to resolve runtime: %v", err)
+		a.updatePodState(podKey, podState)
+		return err
 	}
+	podState.RuntimeHandler = handler
 
 	// Mount volumes
-	if err := a.mountPodVolumes(pod, podState); err != nil {
-		podState.Status.Phase = string(api.PodFailed)
+	if err := a.mountPodVolumes(ctx, pod, podState); err != nil {
+		podState.Status.Phase = api.PodFailed
 		podState.Status.Message = fmt.Sprintf("Failed to mount volumes: %v", err)
 		a.updatePodState(podKey, podState)
 		return err
 	}
 
 	// Create containers
-	if err := a.createPodContainers(pod, podState); err != nil {
-		podState.Status.Phase = string(api.PodFailed)
+	if err := a.createPodContainers(ctx, pod, podState); err != nil {
+		podState.Status.Phase = api.PodFailed
 		podState.Status.Message = fmt.Sprintf("Failed to create containers: %v", err)
 		a.updatePodState(podKey, podState)
 		return err
 	}
+	a.saveCheckpoint(podState)
+	api.SetPodCondition(podState.Status, api.PodCondition{Type: api.PodConditionInitialized, Status: api.ConditionTrue})
 
 	// Start containers
-	if err := a.startPodContainers(pod, podState); err != nil {
-		podState.Status.Phase = string(api.PodFailed)
+	if err := a.startPodContainers(ctx, pod, podState); err != nil {
+		podState.Status.Phase = api.PodFailed
 		podState.Status.Message = fmt.Sprintf("Failed to start containers: %v", err)
 		a.updatePodState(podKey, podState)
 		return err
 	}
 
 	// Set up networking
-	if err := a.setupPodNetworking(pod, podState); err != nil {
-		podState.Status.Phase = string(api.PodFailed)
+	if err := a.setupPodNetworking(ctx, pod, podState); err != nil {
+		podState.Status.Phase = api.PodFailed
 		podState.Status.Message = fmt.Sprintf("Failed to setup networking: %v", err)
 		a.updatePodState(podKey, podState)
 		return err
 	}
 
-	// Update status to running
-	podState.Status.Phase = string(api.PodRunning)
-	podState.Status.StartTime = &time.Time{}
-	*podState.Status.StartTime = time.Now()
+	// Update status to running. Without readiness probes a pod is ready as
+	// soon as its containers are up, and its readiness gates pass.
+	now := time.Now()
+	podState.Status.Phase = api.PodRunning
+	podState.Status.StartTime = &now
+	api.SetPodCondition(podState.Status, api.PodCondition{Type: api.PodConditionContainersReady, Status: api.ConditionTrue, LastTransitionTime: now})
+	setPodReady(podState.Status, &pod.Spec, now)
 
 	a.updatePodState(podKey, podState)
+
+	// A snapshot is only restored once; the next one is taken at shutdown
+	if a.checkpoints != nil && wantsCheckpointRestore(pod) {
+		if err := a.checkpoints.RemoveSnapshot(pod.UID); err != nil {
+			fmt.Printf("Error removing snapshot of pod %s: %v\n", podKey, err)
+		}
+	}
 	return nil
 }
 
@@ -296,6 +687,13 @@ func (a *Agent) createPod(ctx context.Context, pod *api.Pod) error {
 func (a *Agent) updatePod(ctx context.Context, pod *api.Pod) error {
 	// For now, just recreate the pod
 	// In a real implementation, you'd want to handle updates more gracefully
+	a.mu.RLock()
+	podState, exists := a.pods[fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)]
+	a.mu.RUnlock()
+	if exists {
+		// Keep what the containers logged so it can be read once they're replaced
+		a.preservePreviousLogs(ctx, podState)
+	}
 	return a.deletePod(ctx, pod.Namespace, pod.Name)
 }
 
@@ -312,17 +710,17 @@ func (a *Agent) deletePod(ctx context.Context, namespace, name string) error {
 	}
 
 	// Stop containers
-	if err := a.stopPodContainers(podState); err != nil {
+	if err := a.stopPodContainers(ctx, podState); err != nil {
 		fmt.Printf("Error stopping containers for pod %s: %v\n", podKey, err)
 	}
 
 	// Clean up networking
-	if err := a.cleanupPodNetworking(podState); err != nil {
+	if err := a.cleanupPodNetworking(ctx, podState); err != nil {
 		fmt.Printf("Error cleaning up networking for pod %s: %v\n", podKey, err)
 	}
 
 	// Unmount volumes
-	if err := a.unmountPodVolumes(podState); err != nil {
+	if err := a.unmountPodVolumes(ctx, podState); err != nil {
 		fmt.Printf("Error unmounting volumes for pod %s: %v\n", podKey, err)
 	}
 
@@ -331,25 +729,198 @@ func (a *Agent) deletePod(ctx context.Context, namespace, name string) error {
 	delete(a.pods, podKey)
 	a.mu.Unlock()
 
+	if a.checkpoints != nil {
+		if err := a.checkpoints.Remove(namespace, name); err != nil {
+			fmt.Printf("Error removing checkpoint for pod %s: %v\n", podKey, err)
+		}
+		if err := a.checkpoints.RemoveSnapshot(podState.Pod.UID); err != nil {
+			fmt.Printf("Error removing snapshot of pod %s: %v\n", podKey, err)
+		}
+	}
+
 	return nil
 }
 
+// saveCheckpoint persists the runtime identity of a pod
+func (a *Agent) saveCheckpoint(podState *PodState) {
+	if a.checkpoints == nil {
+		return
+	}
+	if err := a.checkpoints.Save(newPodCheckpoint(podState)); err != nil {
+		fmt.Printf("Error saving checkpoint for pod %s/%s: %v\n", podState.Pod.Namespace, podState.Pod.Name, err)
+	}
+}
+
+// restoreCheckpoints rebuilds pod state from checkpoints, adopting containers that are
+// still running. Pods that were deleted or whose containers died are torn down so the
+// next sync starts them from scratch. Must be called with a.mu held.
+func (a *Agent) restoreCheckpoints(ctx context.Context) {
+	if a.checkpoints == nil {
+		return
+	}
+
+	checkpoints, err := a.checkpoints.List()
+	if err != nil {
+		fmt.Printf("Error loading checkpoints: %v\n", err)
+		return
+	}
+
+	for _, cp := range checkpoints {
+		podKey := fmt.Sprintf("%s/%s", cp.Namespace, cp.Name)
+
+		obj, err := a.store.Get(ctx, "Pod", cp.Namespace, cp.Name)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			// Keep the checkpoint, the store may just be unavailable
+			fmt.Printf("Error getting checkpointed pod %s: %v\n", podKey, err)
+			continue
+		}
+
+		pod, ok := obj.(*api.Pod)
+		if err != nil || !ok || pod.UID != cp.UID || pod.Spec.NodeName != a.nodeName {
+			a.discardCheckpoint(ctx, cp)
+			if err := a.checkpoints.RemoveSnapshot(cp.UID); err != nil {
+				fmt.Printf("Error removing snapshot of pod %s: %v\n", podKey, err)
+			}
+			continue
+		}
+
+		podState, alive := a.adoptCheckpoint(ctx, pod, cp)
+		if !alive {
+			a.discardCheckpoint(ctx, cp)
+			continue
+		}
+
+		a.pods[podKey] = podState
+		fmt.Printf("Recovered pod %s from checkpoint\n", podKey)
+	}
+}
+
+// adoptCheckpoint rebuilds pod state from live runtime containers, reporting
+// whether every container of the pod is still running. The containers are
+// found by their labels, so their attempts, and with them the pod's restart
+// counts, come back too.
+func (a *Agent) adoptCheckpoint(ctx context.Context, pod *api.Pod, cp *PodCheckpoint) (*PodState, bool) {
+	status := pod.Status
+	podState := &PodState{
+		Pod:        pod,
+		SandboxID:  cp.SandboxID,
+		Status:     &status,
+		Containers: make(map[string]*ContainerRuntimeState),
+		Volumes:    make(map[string]*VolumeState),
+		Created:    time.Now(),
+		Updated:    time.Now(),
+
+		RuntimeHandler: cp.RuntimeHandler,
+	}
+
+	runtime, err := a.runtimeFor(cp.RuntimeHandler)
+	if err != nil {
+		return nil, false
+	}
+	latest, err := latestContainers(ctx, runtime, pod.UID)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, container := range pod.Spec.Containers {
+		containerStatus, ok := latest[container.Name]
+		if !ok || containerStatus.State != ContainerStateRunning {
+			return nil, false
+		}
+		podState.Containers[container.Name] = &ContainerRuntimeState{
+			ID:        containerStatus.ID,
+			Status:    "Running",
+			StartedAt: time.Unix(0, containerStatus.StartedAt),
+			Attempt:   containerAttempt(containerStatus),
+		}
+	}
+
+	for name, path := range cp.Volumes {
+		podState.Volumes[name] = &VolumeState{
+			Name:    name,
+			Path:    path,
+			Mounted: true,
+		}
+	}
+
+	return podState, true
+}
+
+// discardCheckpoint removes whatever is left of a checkpointed pod from the runtime
+func (a *Agent) discardCheckpoint(ctx context.Context, cp *PodCheckpoint) {
+	// Containers of a handler that's no longer configured are left to
+	// garbage collection, should it come back
+	if runtime, err := a.runtimeFor(cp.RuntimeHandler); err == nil {
+		for _, id := range cp.Containers {
+			runtime.StopContainer(ctx, id, defaultStopTimeout)
+			runtime.RemoveContainer(ctx, id)
+		}
+		if cp.SandboxID != "" {
+			runtime.RemovePodSandbox(ctx, cp.SandboxID)
+		}
+	}
+
+	if err := a.checkpoints.Remove(cp.Namespace, cp.Name); err != nil {
+		fmt.Printf("Error removing checkpoint for pod %s/%s: %v\n", cp.Namespace, cp.Name, err)
+	}
+}
+
 // syncPodStatus syncs the status of a pod
 func (a *Agent) syncPodStatus(ctx context.Context, pod *api.Pod, podState *PodState) error {
 	// Update container statuses
-	if err := a.updateContainerStatuses(podState); err != nil {
+	if err := a.updateContainerStatuses(ctx, podState); err != nil {
 		return err
 	}
+	syncReadinessGates(podState, &pod.Status)
 
-	// Update pod status in store
-	podState.Pod.Status = *podState.Status
-	if err := a.store.Update(ctx, podState.Pod); err != nil {
-		return fmt.Errorf("failed to update pod status: %w", err)
+	// Only write the status when it changed or the last write is stale
+	data, err := podStatusKey(podState.Status)
+	if err != nil {
+		return fmt.Errorf("failed to encode pod status: %w", err)
+	}
+	now := time.Now()
+	if !podState.report.needsUpdate(data, now, a.statusMaxStaleness) {
+		return nil
+	}
+
+	if err := a.writePodStatus(ctx, podState); err != nil {
+		return err
 	}
+	podState.report.record(data, now)
 
 	return nil
 }
 
+// writePodStatus stores the pod's status on a fresh copy of the pod, so
+// edits made to its spec since the agent last read it aren't undone
+func (a *Agent) writePodStatus(ctx context.Context, podState *PodState) error {
+	obj, err := a.store.Get(ctx, "Pod", podState.Pod.Namespace, podState.Pod.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	current, ok := obj.(*api.Pod)
+	if !ok || current.UID != podState.Pod.UID {
+		return fmt.Errorf("pod %s/%s was replaced", podState.Pod.Namespace, podState.Pod.Name)
+	}
+
+	// Conditions of readiness gates may have been posted since the last sync
+	syncReadinessGates(podState, &current.Status)
+	// The store keeps the object it's given, so it gets a copy sharing
+	// nothing with podState, which the agent keeps changing
+	update := *current
+	update.Status = *podState.Status
+	copied, err := store.DeepCopy(&update)
+	if err != nil {
+		return fmt.Errorf("failed to copy pod: %w", err)
+	}
+	pod := copied.(*api.Pod)
+	if err := a.store.Update(ctx, pod); err != nil {
+		return fmt.Errorf("failed to update pod status: %w", err)
+	}
+	a.trackStatusWrite(podState, current, pod)
+	return nil
+}
+
 // heartbeatLoop sends regular heartbeats to the API server
 func (a *Agent) heartbeatLoop(ctx context.Context) {
 	ticker := time.NewTicker(a.heartbeatInterval)
@@ -398,7 +969,7 @@ func (a *Agent) sendHeartbeat(ctx context.Context) error {
 
 	// Update node condition
 	for i, condition := range a.nodeStatus.Conditions {
-		if condition.Type == "Ready" {
+		if condition.Type == api.NodeReady {
 			a.nodeStatus.Conditions[i].LastHeartbeatTime = a.lastHeartbeat
 			break
 		}
@@ -407,8 +978,20 @@ func (a *Agent) sendHeartbeat(ctx context.Context) error {
 	return nil
 }
 
-// reportNodeStatus reports the current node status to the API server
+// reportNodeStatus reports the current node status to the API server. The
+// status is only written when it changed or the last write is stale.
 func (a *Agent) reportNodeStatus(ctx context.Context) error {
+	a.mu.RLock()
+	data, err := nodeStatusKey(a.nodeStatus)
+	a.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode node status: %w", err)
+	}
+	now := time.Now()
+	if !a.nodeReport.needsUpdate(data, now, a.statusMaxStaleness) {
+		return nil
+	}
+
 	// Get current node from store
 	node, err := a.store.Get(ctx, "Node", "", a.nodeName)
 	if err != nil {
@@ -418,9 +1001,11 @@ func (a *Agent) reportNodeStatus(ctx context.Context) error {
 	// Update status
 	if nodeObj, ok := node.(*api.Node); ok {
 		nodeObj.Status = *a.nodeStatus
+		mergeLabels(nodeObj, a.platformLabels())
 		if err := a.store.Update(ctx, nodeObj); err != nil {
 			return fmt.Errorf("failed to update node status: %w", err)
 		}
+		a.nodeReport.record(data, now)
 	}
 
 	return nil
@@ -435,43 +1020,257 @@ func (a *Agent) updatePodState(podKey string, podState *PodState) {
 	a.pods[podKey] = podState
 }
 
-// Helper methods for pod operations (to be implemented)
-func (a *Agent) mountPodVolumes(pod *api.Pod, podState *PodState) error {
-	// TODO: Implement volume mounting
+// mountPodVolumes mounts all volumes declared by the pod
+func (a *Agent) mountPodVolumes(ctx context.Context, pod *api.Pod, podState *PodState) error {
+	for i := range pod.Spec.Volumes {
+		volume := &pod.Spec.Volumes[i]
+		if err := a.volumeMgr.MountVolume(ctx, pod, volume, podState); err != nil {
+			return fmt.Errorf("volume %s: %w", volume.Name, err)
+		}
+
+		path, err := a.volumeMgr.GetVolumePath(ctx, pod, volume)
+		if err != nil {
+			return fmt.Errorf("volume %s: %w", volume.Name, err)
+		}
+
+		podState.Volumes[volume.Name] = &VolumeState{
+			Name:      volume.Name,
+			Path:      path,
+			Mounted:   true,
+			MountTime: time.Now(),
+		}
+	}
 	return nil
 }
 
-func (a *Agent) createPodContainers(pod *api.Pod, podState *PodState) error {
-	// TODO: Implement container creation
+// createPodContainers creates the pod sandbox and one runtime container per spec container
+func (a *Agent) createPodContainers(ctx context.Context, pod *api.Pod, podState *PodState) error {
+	runtime := a.podRuntime(podState)
+	sandboxID, err := runtime.CreatePodSandbox(ctx, pod)
+	if err != nil {
+		return fmt.Errorf("failed to create pod sandbox: %w", err)
+	}
+	podState.SandboxID = sandboxID
+
+	serviceEnv, err := a.serviceEnv(ctx, pod)
+	if err != nil {
+		return err
+	}
+
+	snapshot := a.restoreSnapshot(pod, runtime)
+	if snapshot != "" {
+		fmt.Printf("Restoring pod %s/%s from snapshot\n", pod.Namespace, pod.Name)
+	}
+
+	attempts := nextAttempts(ctx, runtime, pod)
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		attempt := attempts[container.Name]
+		containerID, err := a.createContainer(ctx, runtime, pod, withServiceEnv(container, serviceEnv), attempt, snapshot)
+		if err != nil {
+			return fmt.Errorf("container %s: %w", container.Name, err)
+		}
+
+		podState.Containers[container.Name] = &ContainerRuntimeState{
+			ID:      containerID,
+			Status:  "Created",
+			Attempt: attempt,
+		}
+	}
 	return nil
 }
 
-func (a *Agent) startPodContainers(pod *api.Pod, podState *PodState) error {
-	// TODO: Implement container starting
+// startPodContainers starts all created containers of the pod
+func (a *Agent) startPodContainers(ctx context.Context, pod *api.Pod, podState *PodState) error {
+	runtime := a.podRuntime(podState)
+	for name, container := range podState.Containers {
+		if err := runtime.StartContainer(ctx, container.ID); err != nil {
+			return fmt.Errorf("container %s: %w", name, err)
+		}
+		container.Status = "Running"
+		container.StartedAt = time.Now()
+	}
 	return nil
 }
 
-func (a *Agent) setupPodNetworking(pod *api.Pod, podState *PodState) error {
-	// TODO: Implement networking setup
+// setupPodNetworking attaches the pod to the network and records its IP
+func (a *Agent) setupPodNetworking(ctx context.Context, pod *api.Pod, podState *PodState) error {
+	if pod.Spec.HostNetwork {
+		return nil
+	}
+
+	if err := a.networkMgr.SetupPodNetwork(ctx, pod, podState); err != nil {
+		return err
+	}
+
+	podIP, err := a.networkMgr.GetPodIP(ctx, pod)
+	if err != nil {
+		return err
+	}
+	podState.Status.PodIP = podIP
 	return nil
 }
 
-func (a *Agent) stopPodContainers(podState *PodState) error {
-	// TODO: Implement container stopping
-	return nil
+// stopPodContainers stops and removes all containers and the sandbox of the
+// pod, giving the containers the pod's termination grace period to exit
+func (a *Agent) stopPodContainers(ctx context.Context, podState *PodState) error {
+	runtime := a.podRuntime(podState)
+	grace := podState.Pod.TerminationGracePeriod()
+	var errs []error
+	for name, container := range podState.Containers {
+		if err := runtime.StopContainer(ctx, container.ID, grace); err != nil {
+			errs = append(errs, fmt.Errorf("stop container %s: %w", name, err))
+		}
+		if err := runtime.RemoveContainer(ctx, container.ID); err != nil {
+			errs = append(errs, fmt.Errorf("remove container %s: %w", name, err))
+			continue
+		}
+		delete(podState.Containers, name)
+	}
+
+	if podState.SandboxID != "" {
+		if err := runtime.RemovePodSandbox(ctx, podState.SandboxID); err != nil {
+			errs = append(errs, fmt.Errorf("remove sandbox: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
-func (a *Agent) cleanupPodNetworking(podState *PodState) error {
-	// TODO: Implement networking cleanup
-	return nil
+// cleanupPodNetworking detaches the pod from the network
+func (a *Agent) cleanupPodNetworking(ctx context.Context, podState *PodState) error {
+	if podState.Pod.Spec.HostNetwork {
+		return nil
+	}
+	return a.networkMgr.CleanupPodNetwork(ctx, podState)
 }
 
-func (a *Agent) unmountPodVolumes(podState *PodState) error {
-	// TODO: Implement volume unmounting
-	return nil
+// unmountPodVolumes unmounts all volumes mounted for the pod
+func (a *Agent) unmountPodVolumes(ctx context.Context, podState *PodState) error {
+	var errs []error
+	for name := range podState.Volumes {
+		if err := a.volumeMgr.UnmountVolume(ctx, podState, name); err != nil {
+			errs = append(errs, fmt.Errorf("volume %s: %w", name, err))
+			continue
+		}
+		delete(podState.Volumes, name)
+	}
+	return errors.Join(errs...)
+}
+
+// refreshPodVolumes refreshes stale volumes of the pods running on this node
+func (a *Agent) refreshPodVolumes(ctx context.Context) {
+	refresher, ok := a.volumeMgr.(VolumeRefresher)
+	if !ok {
+		return
+	}
+
+	a.mu.RLock()
+	pods := make([]*api.Pod, 0, len(a.pods))
+	for _, podState := range a.pods {
+		pods = append(pods, podState.Pod)
+	}
+	a.mu.RUnlock()
+
+	for _, pod := range pods {
+		if err := refresher.RefreshVolumes(ctx, pod); err != nil {
+			fmt.Printf("Error refreshing volumes of pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		}
+	}
 }
 
-func (a *Agent) updateContainerStatuses(podState *PodState) error {
-	// TODO: Implement container status updates
+// updateContainerStatuses refreshes the pod's container statuses from the
+// runtime. Once every container has exited the pod is finished: it succeeded
+// if all of them exited with 0 and failed otherwise.
+func (a *Agent) updateContainerStatuses(ctx context.Context, podState *PodState) error {
+	if podState.Status.Phase != api.PodRunning {
+		return nil
+	}
+
+	runtime := a.podRuntime(podState)
+	statuses := make([]api.ContainerStatus, 0, len(podState.Pod.Spec.Containers))
+	exited, failed := 0, 0
+	for _, container := range podState.Pod.Spec.Containers {
+		state, ok := podState.Containers[container.Name]
+		if !ok {
+			continue
+		}
+		runtimeStatus, err := runtime.GetContainerStatus(ctx, state.ID)
+		if err != nil {
+			return fmt.Errorf("container %s: %w", container.Name, err)
+		}
+
+		status := api.ContainerStatus{Name: container.Name, Image: container.Image, RestartCount: int32(state.Attempt)}
+		switch runtimeStatus.State {
+		case ContainerStateRunning:
+			state.Status = "Running"
+			status.Ready = true
+			status.State.Running = &api.ContainerStateRunning{StartedAt: time.Unix(0, runtimeStatus.StartedAt)}
+		case ContainerStateExited:
+			state.Status = "Exited"
+			state.ExitCode = runtimeStatus.ExitCode
+			state.Message = runtimeStatus.Message
+			status.State.Terminated = &api.ContainerStateTerminated{
+				ExitCode:   runtimeStatus.ExitCode,
+				Reason:     runtimeStatus.Reason,
+				Message:    runtimeStatus.Message,
+				StartedAt:  time.Unix(0, runtimeStatus.StartedAt),
+				FinishedAt: time.Unix(0, runtimeStatus.FinishedAt),
+			}
+			exited++
+			if runtimeStatus.ExitCode != 0 {
+				failed++
+			}
+		default:
+			status.State.Waiting = &api.ContainerStateWaiting{Reason: "ContainerCreating"}
+		}
+		started := runtimeStatus.State == ContainerStateRunning
+		status.Started = &started
+		if started {
+			status.Usage = containerUsage(ctx, runtime, state)
+		}
+		statuses = append(statuses, status)
+	}
+	podState.Status.ContainerStatuses = statuses
+
+	// Containers aren't restarted, so the pod is done once all of them exited
+	if len(statuses) == 0 || exited < len(statuses) {
+		return nil
+	}
+	podState.Status.Phase = api.PodSucceeded
+	if failed > 0 {
+		podState.Status.Phase = api.PodFailed
+		podState.Status.Message = fmt.Sprintf("%d of %d containers failed", failed, len(statuses))
+	}
+	for _, conditionType := range []api.PodConditionType{api.PodConditionContainersReady, api.PodConditionReady} {
+		api.SetPodCondition(podState.Status, api.PodCondition{Type: conditionType, Status: api.ConditionFalse, Reason: "PodCompleted"})
+	}
 	return nil
 }
+
+// containerUsage samples a running container's resource usage, or returns
+// nil if the runtime can't measure it. CPU usage is averaged since the
+// previous sample, so the first sample of a container reports none.
+func containerUsage(ctx context.Context, runtime CRIRuntime, state *ContainerRuntimeState) *api.ContainerUsage {
+	provider, ok := runtime.(ContainerStatsProvider)
+	if !ok {
+		return nil
+	}
+	stats, err := provider.ContainerStats(ctx, state.ID)
+	if err != nil {
+		return nil
+	}
+
+	previous := state.stats
+	state.stats = stats
+	if previous == nil || !stats.Timestamp.After(previous.Timestamp) || stats.CPUUsageNanoseconds < previous.CPUUsageNanoseconds {
+		return nil
+	}
+	elapsed := stats.Timestamp.Sub(previous.Timestamp)
+	used := stats.CPUUsageNanoseconds - previous.CPUUsageNanoseconds
+	return &api.ContainerUsage{
+		Timestamp:     stats.Timestamp,
+		CPUMillicores: int64(used * 1000 / uint64(elapsed.Nanoseconds())),
+		MemoryBytes:   int64(stats.MemoryBytes),
+	}
+}
@@ -2,6 +2,8 @@ package nodeagent
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -82,8 +84,8 @@ func TestAgent_InitializeNodeStatus(t *testing.T) {
 	assert.Equal(t, "4", agent.nodeStatus.Capacity["cpu"])
 	assert.Equal(t, "8Gi", agent.nodeStatus.Capacity["memory"])
 	assert.Len(t, agent.nodeStatus.Conditions, 1)
-	assert.Equal(t, "Ready", agent.nodeStatus.Conditions[0].Type)
-	assert.Equal(t, "True", agent.nodeStatus.Conditions[0].Status)
+	assert.Equal(t, api.NodeReady, agent.nodeStatus.Conditions[0].Type)
+	assert.Equal(t, api.ConditionTrue, agent.nodeStatus.Conditions[0].Status)
 }
 
 func TestAgent_SyncPods(t *testing.T) {
@@ -192,7 +194,7 @@ func TestAgent_SyncPod_NewPod(t *testing.T) {
 
 	assert.True(t, exists)
 	assert.NotNil(t, podState)
-	assert.Equal(t, string(api.PodRunning), podState.Status.Phase)
+	assert.Equal(t, api.PodRunning, podState.Status.Phase)
 }
 
 func TestAgent_SyncPod_ExistingPod(t *testing.T) {
@@ -359,3 +361,1145 @@ func TestAgent_UpdatePodState(t *testing.T) {
 	assert.Equal(t, podState, storedState)
 	assert.True(t, storedState.Updated.After(storedState.Created))
 }
+
+func TestAgent_SyncPods_RemovesDeletedPods(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+
+	// First sync creates the pod and its containers
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, containers, 1)
+
+	// Delete the pod from the store and sync again
+	err = store.Delete(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	// The local state and the runtime containers should be gone
+	agent.mu.RLock()
+	_, exists := agent.pods["default/test-pod"]
+	agent.mu.RUnlock()
+	assert.False(t, exists)
+
+	containers, err = runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+}
+
+func TestAgent_SyncPods_RemovesRescheduledPods(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        NewMockCRIRuntime(),
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	// Move the pod to another node
+	moved := *pod
+	moved.Spec.NodeName = "other-node"
+	err = store.Update(ctx, &moved)
+	require.NoError(t, err)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	agent.mu.RLock()
+	_, exists := agent.pods["default/test-pod"]
+	agent.mu.RUnlock()
+	assert.False(t, exists)
+}
+
+func TestAgent_RestoreCheckpoints(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	// The runtime outlives the agent, as a real container runtime would
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+		CheckpointDir:     t.TempDir(),
+	}
+
+	agent := NewAgent(config)
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	containerID := agent.pods["default/test-pod"].Containers["test"].ID
+
+	// A restarted agent adopts the running container instead of recreating it
+	restarted := NewAgent(config)
+	restarted.mu.Lock()
+	restarted.restoreCheckpoints(ctx)
+	restarted.mu.Unlock()
+
+	podState, exists := restarted.pods["default/test-pod"]
+	require.True(t, exists)
+	assert.Equal(t, containerID, podState.Containers["test"].ID)
+
+	err = restarted.syncPods(ctx)
+	require.NoError(t, err)
+
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, containers, 1)
+}
+
+func TestAgent_RestoreCheckpoints_DeletedPod(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+		CheckpointDir:     t.TempDir(),
+	}
+
+	agent := NewAgent(config)
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	// The pod is deleted while the agent is down
+	err = store.Delete(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+
+	restarted := NewAgent(config)
+	restarted.mu.Lock()
+	restarted.restoreCheckpoints(ctx)
+	restarted.mu.Unlock()
+
+	assert.Empty(t, restarted.pods)
+
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+
+	checkpoints, err := restarted.checkpoints.List()
+	require.NoError(t, err)
+	assert.Empty(t, checkpoints)
+}
+
+func TestAgent_GarbageCollectContainers(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	// Simulate containers leaked by a crashed agent for a pod that no longer exists
+	leaked := &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:      "leaked-pod",
+			Namespace: "default",
+			UID:       "leaked-uid",
+		},
+	}
+	_, err = runtime.CreatePodSandbox(ctx, leaked)
+	require.NoError(t, err)
+	leakedID, err := runtime.CreateContainer(ctx, leaked, &api.Container{Name: "test", Image: "nginx:latest"}, 0)
+	require.NoError(t, err)
+	require.NoError(t, runtime.StartContainer(ctx, leakedID))
+
+	err = agent.garbageCollectContainers(ctx)
+	require.NoError(t, err)
+
+	// Only the leaked pod's runtime objects should be removed
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "test-uid", containers[0].Labels[LabelPodUID])
+
+	sandboxes, err := runtime.ListPodSandboxes(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, sandboxes, 1)
+	assert.Equal(t, "test-uid", sandboxes[0].Labels[LabelPodUID])
+}
+
+func TestAgent_GarbageCollectContainers_IgnoresUnlabeled(t *testing.T) {
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store.NewMemoryStore(nil),
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+
+	ctx := context.Background()
+	containerID, err := runtime.CreateContainer(ctx, &api.Pod{}, &api.Container{Name: "unmanaged"}, 0)
+	require.NoError(t, err)
+
+	err = agent.garbageCollectContainers(ctx)
+	require.NoError(t, err)
+
+	_, err = runtime.GetContainerStatus(ctx, containerID)
+	assert.NoError(t, err)
+}
+
+func TestAgent_EvictsPodExceedingEmptyDirLimit(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+				},
+			},
+			Volumes: []api.Volume{
+				{
+					Name: "scratch",
+					VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{
+						SizeLimit: "1Ki",
+					}},
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Mounter: newFakeMounter()}),
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	agent.mu.RLock()
+	podState, exists := agent.pods["default/test-pod"]
+	agent.mu.RUnlock()
+	require.True(t, exists)
+
+	// Fill the volume past its limit
+	volumePath := podState.Volumes["scratch"].Path
+	err = os.WriteFile(filepath.Join(volumePath, "data"), make([]byte, 2048), 0o644)
+	require.NoError(t, err)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	obj, err := store.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	evicted := obj.(*api.Pod)
+	assert.Equal(t, api.PodFailed, evicted.Status.Phase)
+	assert.Equal(t, PodReasonEvicted, evicted.Status.Reason)
+
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+	assert.NoDirExists(t, volumePath)
+
+	// The evicted pod must not be started again
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	agent.mu.RLock()
+	_, exists = agent.pods["default/test-pod"]
+	agent.mu.RUnlock()
+	assert.False(t, exists)
+}
+
+func TestAgent_EvictsPodExceedingEphemeralStorageLimit(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{{
+				Name:      "test",
+				Image:     "nginx:latest",
+				Resources: api.ResourceRequirements{Limits: api.ResourceList{api.ResourceEphemeralStorage: "1Ki"}},
+			}},
+			Volumes: []api.Volume{
+				{Name: "scratch", VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}}},
+				{Name: "cache", VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}}},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		Store:             store,
+		CRIRuntime:        NewMockCRIRuntime(),
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Mounter: newFakeMounter()}),
+		HeartbeatInterval: 30 * time.Second,
+	})
+	require.NoError(t, agent.syncPods(ctx))
+	podState := agent.pods["default/test-pod"]
+	require.NotNil(t, podState)
+
+	// Neither volume has a limit of its own, but together they exceed the pod's
+	for _, name := range []string{"scratch", "cache"} {
+		data := filepath.Join(podState.Volumes[name].Path, "data")
+		require.NoError(t, os.WriteFile(data, make([]byte, 768), 0o644))
+	}
+	require.NoError(t, agent.syncPods(ctx))
+
+	obj, err := store.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	evicted := obj.(*api.Pod)
+	assert.Equal(t, api.PodFailed, evicted.Status.Phase)
+	assert.Equal(t, PodReasonEvicted, evicted.Status.Reason)
+	assert.Contains(t, evicted.Status.Message, "ephemeral local storage")
+}
+
+func TestAgent_InjectsServiceEnv(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	err := store.Create(ctx, &api.Service{
+		TypeMeta:   api.TypeMeta{Kind: "Service", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "redis-master", Namespace: "default"},
+		Spec: api.ServiceSpec{
+			ClusterIP: "10.96.0.11",
+			Ports:     []api.ServicePort{{Name: "redis", Port: 6379}},
+		},
+	})
+	require.NoError(t, err)
+
+	// Headless services and services in other namespaces are not injected
+	err = store.Create(ctx, &api.Service{
+		TypeMeta:   api.TypeMeta{Kind: "Service", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "headless", Namespace: "default"},
+		Spec: api.ServiceSpec{
+			ClusterIP: api.ClusterIPNone,
+			Ports:     []api.ServicePort{{Port: 80}},
+		},
+	})
+	require.NoError(t, err)
+	err = store.Create(ctx, &api.Service{
+		TypeMeta:   api.TypeMeta{Kind: "Service", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "elsewhere", Namespace: "other"},
+		Spec: api.ServiceSpec{
+			ClusterIP: "10.96.0.12",
+			Ports:     []api.ServicePort{{Port: 80}},
+		},
+	})
+	require.NoError(t, err)
+
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "nginx:latest",
+					Env: []api.EnvVar{
+						{Name: "REDIS_MASTER_SERVICE_PORT", Value: "overridden"},
+					},
+				},
+			},
+		},
+	}
+	err = store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	agent.mu.RLock()
+	containerID := agent.pods["default/test-pod"].Containers["test"].ID
+	agent.mu.RUnlock()
+
+	container, ok := runtime.ContainerConfig(containerID)
+	require.True(t, ok)
+
+	env := make(map[string]string)
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "10.96.0.11", env["REDIS_MASTER_SERVICE_HOST"])
+	assert.Equal(t, "overridden", env["REDIS_MASTER_SERVICE_PORT"])
+	assert.Equal(t, "6379", env["REDIS_MASTER_SERVICE_PORT_REDIS"])
+	assert.Equal(t, "tcp://10.96.0.11:6379", env["REDIS_MASTER_PORT"])
+	assert.Equal(t, "10.96.0.11", env["REDIS_MASTER_PORT_6379_TCP_ADDR"])
+	assert.NotContains(t, env, "HEADLESS_SERVICE_HOST")
+	assert.NotContains(t, env, "ELSEWHERE_SERVICE_HOST")
+
+	// The pod spec itself is left untouched
+	assert.Len(t, pod.Spec.Containers[0].Env, 1)
+}
+
+func TestAgent_RegisterNode(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	taint := api.Taint{Key: "dedicated", Value: "gpu", Effect: api.TaintEffectNoSchedule}
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          st,
+		CRIRuntime:     NewMockCRIRuntime(),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+		NodeLabels:     map[string]string{"pool": "gpu"},
+		RegisterTaints: []api.Taint{taint},
+	})
+	agent.initializeNodeStatus()
+
+	// A missing node is created with the configured labels and taints
+	require.NoError(t, agent.registerNode(ctx))
+	obj, err := st.Get(ctx, "Node", "", "test-node")
+	require.NoError(t, err)
+	node := obj.(*api.Node)
+	assert.Equal(t, "gpu", node.Labels["pool"])
+	assert.Equal(t, []api.Taint{taint}, node.Spec.Taints)
+
+	// Labels and taints added by others survive a restart, and nothing is duplicated
+	node.Labels["zone"] = "lab-1"
+	node.Spec.Taints = append(node.Spec.Taints, api.Taint{Key: "maintenance", Effect: api.TaintEffectNoExecute})
+	require.NoError(t, st.Update(ctx, node))
+
+	require.NoError(t, agent.registerNode(ctx))
+	obj, err = st.Get(ctx, "Node", "", "test-node")
+	require.NoError(t, err)
+	node = obj.(*api.Node)
+	assert.Equal(t, map[string]string{
+		"pool":        "gpu",
+		"zone":        "lab-1",
+		api.LabelArch: "amd64",
+		api.LabelOS:   "linux",
+	}, node.Labels)
+	assert.Len(t, node.Spec.Taints, 2)
+}
+
+func TestAgent_RuntimeClass(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	ctx := context.Background()
+	runtimeClass := &api.RuntimeClass{
+		TypeMeta:   api.TypeMeta{Kind: "RuntimeClass", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "sandboxed"},
+		Handler:    "gvisor",
+	}
+	require.NoError(t, store.Create(ctx, runtimeClass))
+
+	newPod := func(name, runtimeClassName string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+			Spec: api.PodSpec{
+				NodeName:         "test-node",
+				RuntimeClassName: runtimeClassName,
+				Containers:       []api.Container{{Name: "app", Image: "nginx:latest"}},
+			},
+		}
+	}
+
+	defaultRuntime := NewMockCRIRuntime()
+	gvisor := NewMockCRIRuntime()
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        defaultRuntime,
+		RuntimeHandlers:   map[string]CRIRuntime{"gvisor": gvisor},
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	})
+
+	require.NoError(t, agent.syncPod(ctx, newPod("plain", "")))
+	require.NoError(t, agent.syncPod(ctx, newPod("sandboxed", "sandboxed")))
+
+	// Each pod's containers live in the runtime its class chose
+	plain, err := defaultRuntime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, plain, 1)
+	assert.Equal(t, "plain-uid", plain[0].Labels[LabelPodUID])
+
+	sandboxed, err := gvisor.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, sandboxed, 1)
+	assert.Equal(t, "sandboxed-uid", sandboxed[0].Labels[LabelPodUID])
+	assert.Equal(t, api.PodRunning, agent.pods["default/sandboxed"].Status.Phase)
+
+	// Pods whose class names a handler this node lacks fail
+	runtimeClass.Handler = "kata"
+	require.NoError(t, store.Update(ctx, runtimeClass))
+	assert.Error(t, agent.syncPod(ctx, newPod("kata", "sandboxed")))
+	assert.Equal(t, api.PodFailed, agent.pods["default/kata"].Status.Phase)
+	assert.Contains(t, agent.pods["default/kata"].Status.Message, `runtime handler "kata" is not configured`)
+
+	// Deleting a pod removes its containers from its own runtime
+	require.NoError(t, agent.deletePod(ctx, "default", "sandboxed"))
+	sandboxed, err = gvisor.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, sandboxed)
+}
+
+func TestAgent_RejectsPodsExceedingAllocatable(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	newPod := func(name, cpu, memory string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+			Spec: api.PodSpec{
+				NodeName: "test-node",
+				Containers: []api.Container{{
+					Name:  "app",
+					Image: "nginx:latest",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{
+						api.ResourceCPU:    cpu,
+						api.ResourceMemory: memory,
+					}},
+				}},
+			},
+		}
+	}
+
+	ctx := context.Background()
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        NewMockCRIRuntime(),
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	})
+	require.NoError(t, agent.initializeNodeStatus())
+
+	// The mock runtime's node has 4 CPUs and 8Gi of memory
+	require.NoError(t, agent.syncPod(ctx, newPod("big", "3", "1Gi")))
+	assert.Equal(t, api.PodRunning, agent.pods["default/big"].Status.Phase)
+
+	require.NoError(t, agent.syncPod(ctx, newPod("too-much-cpu", "2", "1Gi")))
+	rejected := agent.pods["default/too-much-cpu"]
+	assert.Equal(t, api.PodFailed, rejected.Status.Phase)
+	assert.Equal(t, PodReasonOutOfCPU, rejected.Status.Reason)
+	assert.Empty(t, rejected.Containers)
+
+	require.NoError(t, agent.syncPod(ctx, newPod("too-much-memory", "500m", "8Gi")))
+	assert.Equal(t, PodReasonOutOfMemory, agent.pods["default/too-much-memory"].Status.Reason)
+
+	// Rejected pods don't count against the node, so a pod that fits still starts
+	require.NoError(t, agent.syncPod(ctx, newPod("small", "1", "1Gi")))
+	assert.Equal(t, api.PodRunning, agent.pods["default/small"].Status.Phase)
+
+	// The node reports 100Gi of ephemeral storage and no huge pages
+	disk := newPod("too-much-disk", "", "")
+	disk.Spec.Containers[0].Resources.Requests[api.ResourceEphemeralStorage] = "200Gi"
+	require.NoError(t, agent.syncPod(ctx, disk))
+	assert.Equal(t, PodReasonOutOfEphemeralStorage, agent.pods["default/too-much-disk"].Status.Reason)
+
+	hugePages := newPod("huge-pages", "", "")
+	hugePages.Spec.Containers[0].Resources.Limits = api.ResourceList{api.ResourceHugePages2Mi: "64Mi"}
+	require.NoError(t, agent.syncPod(ctx, hugePages))
+	assert.Equal(t, PodReasonOutOfHugePages, agent.pods["default/huge-pages"].Status.Reason)
+
+	// Overhead counts with the containers' requests
+	overhead := newPod("overhead", "500m", "")
+	overhead.Spec.Overhead = api.ResourceList{api.ResourceCPU: "1"}
+	require.NoError(t, agent.syncPod(ctx, overhead))
+	assert.Equal(t, PodReasonOutOfCPU, agent.pods["default/overhead"].Status.Reason)
+}
+
+func TestAgent_SyncsPodChangesRightAway(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	defer s.Close()
+
+	runtime := NewMockCRIRuntime()
+	agent := NewAgent(&Config{
+		NodeName:            "test-node",
+		Store:               s,
+		CRIRuntime:          runtime,
+		NetworkManager:      &MockNetworkManager{},
+		VolumeManager:       &MockVolumeManager{},
+		PodSyncInterval:     time.Hour,
+		ContainerGCInterval: -1,
+	})
+	ctx := context.Background()
+	require.NoError(t, agent.Start(ctx))
+	defer agent.Stop()
+
+	tracked := func() bool {
+		agent.mu.RLock()
+		defer agent.mu.RUnlock()
+		_, exists := agent.pods["default/test-pod"]
+		return exists
+	}
+
+	// Pods scheduled here are started without waiting for the next sync
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "nginx:latest"}},
+		},
+	}
+	require.NoError(t, s.Create(ctx, pod))
+	assert.Eventually(t, tracked, 2*time.Second, 10*time.Millisecond)
+
+	// And torn down as soon as they're deleted
+	require.NoError(t, s.Delete(ctx, "Pod", "default", "test-pod"))
+	assert.Eventually(t, func() bool { return !tracked() }, 2*time.Second, 10*time.Millisecond)
+
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+}
+
+func TestAgent_RejectsPodsWithConflictingHostPorts(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	newPod := func(name string, ports ...api.ContainerPort) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+			Spec: api.PodSpec{
+				NodeName:   "test-node",
+				Containers: []api.Container{{Name: "app", Image: "nginx:latest", Ports: ports}},
+			},
+		}
+	}
+
+	ctx := context.Background()
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        NewMockCRIRuntime(),
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	})
+	require.NoError(t, agent.initializeNodeStatus())
+
+	require.NoError(t, agent.syncPod(ctx, newPod("web", api.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.1"})))
+	assert.Equal(t, api.PodRunning, agent.pods["default/web"].Status.Phase)
+
+	// A pod binding the same port on every address conflicts
+	require.NoError(t, agent.syncPod(ctx, newPod("proxy", api.ContainerPort{ContainerPort: 80, HostPort: 8080})))
+	rejected := agent.pods["default/proxy"]
+	assert.Equal(t, api.PodFailed, rejected.Status.Phase)
+	assert.Equal(t, PodReasonHostPortConflict, rejected.Status.Reason)
+	assert.Contains(t, rejected.Status.Message, "8080/TCP")
+	assert.Empty(t, rejected.Containers)
+
+	// Other addresses and protocols are free
+	require.NoError(t, agent.syncPod(ctx, newPod("internal", api.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.2"})))
+	assert.Equal(t, api.PodRunning, agent.pods["default/internal"].Status.Phase)
+	require.NoError(t, agent.syncPod(ctx, newPod("dns", api.ContainerPort{ContainerPort: 53, HostPort: 8080, Protocol: "UDP"})))
+	assert.Equal(t, api.PodRunning, agent.pods["default/dns"].Status.Phase)
+}
+
+func TestAgent_KillsPodPastActiveDeadline(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	deadline := int64(60)
+	pod := &api.Pod{
+		TypeMeta: api.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1alpha1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{
+					Name:  "test",
+					Image: "busybox:latest",
+				},
+			},
+			ActiveDeadlineSeconds: &deadline,
+		},
+	}
+
+	ctx := context.Background()
+	err := store.Create(ctx, pod)
+	require.NoError(t, err)
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	}
+
+	agent := NewAgent(config)
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	agent.mu.RLock()
+	podState, exists := agent.pods["default/test-pod"]
+	agent.mu.RUnlock()
+	require.True(t, exists)
+
+	// Within the deadline the pod keeps running
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, containers)
+
+	started := time.Now().Add(-2 * time.Minute)
+	podState.Status.StartTime = &started
+
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+
+	obj, err := store.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	killed := obj.(*api.Pod)
+	assert.Equal(t, api.PodFailed, killed.Status.Phase)
+	assert.Equal(t, PodReasonDeadlineExceeded, killed.Status.Reason)
+
+	containers, err = runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+
+	// The killed pod must not be started again
+	err = agent.syncPods(ctx)
+	require.NoError(t, err)
+	containers, err = runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+}
+
+func TestAgent_Shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	newPod := func(name string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", UID: name + "-uid"},
+			Spec: api.PodSpec{
+				NodeName:   "test-node",
+				Containers: []api.Container{{Name: "test", Image: "busybox:latest"}},
+			},
+		}
+	}
+	require.NoError(t, st.Create(ctx, newPod("web")))
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		Store:             st,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+		PodSyncInterval:   time.Hour,
+	}
+	agent := NewAgent(config)
+	require.NoError(t, agent.Start(ctx))
+	require.NoError(t, agent.syncPods(ctx))
+
+	require.NoError(t, agent.Shutdown(ctx))
+
+	obj, err := st.Get(ctx, "Node", "", "test-node")
+	require.NoError(t, err)
+	node := obj.(*api.Node)
+	assert.True(t, hasTaint(node, api.Taint{Key: api.TaintNodeShutdown, Effect: api.TaintEffectNoSchedule}))
+	assert.Equal(t, api.ConditionFalse, node.Status.Conditions[0].Status)
+	assert.Equal(t, NodeReasonShutdown, node.Status.Conditions[0].Reason)
+
+	obj, err = st.Get(ctx, "Pod", "default", "web")
+	require.NoError(t, err)
+	assert.Equal(t, api.PodFailed, obj.(*api.Pod).Status.Phase)
+	assert.Equal(t, PodReasonTerminated, obj.(*api.Pod).Status.Reason)
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+
+	// No pods are started while shutting down
+	require.NoError(t, st.Create(ctx, newPod("late")))
+	require.NoError(t, agent.syncPods(ctx))
+	containers, err = runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+	agent.Stop()
+
+	// The node takes pods again once its agent is back
+	restarted := NewAgent(config)
+	restarted.initializeNodeStatus()
+	require.NoError(t, restarted.registerNode(ctx))
+	obj, err = st.Get(ctx, "Node", "", "test-node")
+	require.NoError(t, err)
+	node = obj.(*api.Node)
+	assert.Empty(t, node.Spec.Taints)
+	assert.Equal(t, api.ConditionTrue, node.Status.Conditions[0].Status)
+}
+
+func TestAgent_RestartCountsSurviveAgentRestart(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "nginx:1.25"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	runtime := NewMockCRIRuntime()
+	config := &Config{
+		NodeName:          "test-node",
+		Store:             st,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+		CheckpointDir:     t.TempDir(),
+	}
+	agent := NewAgent(config)
+	require.NoError(t, agent.syncPods(ctx))
+	require.NoError(t, agent.syncPods(ctx))
+
+	// A spec change replaces the container with its next attempt
+	obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	updated := *obj.(*api.Pod)
+	updated.Spec.Containers = []api.Container{{Name: "test", Image: "nginx:1.26"}}
+	require.NoError(t, st.Update(ctx, &updated))
+	require.NoError(t, agent.syncPods(ctx))
+	require.NoError(t, agent.syncPods(ctx))
+	require.NoError(t, agent.syncPods(ctx))
+
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "1", containers[0].Labels[LabelContainerAttempt])
+
+	// A restarted agent recovers the attempt from the runtime's labels
+	restarted := NewAgent(config)
+	restarted.mu.Lock()
+	restarted.restoreCheckpoints(ctx)
+	restarted.mu.Unlock()
+	podState, exists := restarted.pods["default/test-pod"]
+	require.True(t, exists)
+	assert.Equal(t, containers[0].ID, podState.Containers["test"].ID)
+	assert.Equal(t, uint32(1), podState.Containers["test"].Attempt)
+
+	require.NoError(t, restarted.syncPods(ctx))
+	obj, err = st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	require.Len(t, obj.(*api.Pod).Status.ContainerStatuses, 1)
+	assert.Equal(t, int32(1), obj.(*api.Pod).Status.ContainerStatuses[0].RestartCount)
+}
+
+func TestAgent_DeletedPodStoppedBeforeRemoval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "busybox:latest"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	runtime := NewMockCRIRuntime()
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		Store:             st,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+		PodSyncInterval:   time.Hour,
+	})
+	require.NoError(t, agent.syncPods(ctx))
+	containers, err := runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+
+	watch, err := st.WatchObject(ctx, "Pod", "default", "web")
+	require.NoError(t, err)
+	defer close(watch.Stop)
+
+	// Deleting through the API marks the pod terminating
+	obj, err := st.Get(ctx, "Pod", "default", "web")
+	require.NoError(t, err)
+	terminating := *obj.(*api.Pod)
+	now := time.Now()
+	grace := int64(5)
+	terminating.DeletionTimestamp = &now
+	terminating.DeletionGracePeriodSeconds = &grace
+	require.NoError(t, st.Update(ctx, &terminating))
+	require.NoError(t, agent.syncPods(ctx))
+
+	// The node confirms the containers stopped, then removes the pod
+	var confirmed bool
+	for deleted := false; !deleted; {
+		select {
+		case event := <-watch.Events:
+			switch event.Type {
+			case store.Modified:
+				ready := api.GetPodCondition(&event.Object.(*api.Pod).Status, api.PodConditionReady)
+				confirmed = confirmed || ready != nil && ready.Reason == PodReasonTerminating
+			case store.Deleted:
+				deleted = true
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the agent to remove the terminated pod")
+		}
+	}
+	assert.True(t, confirmed, "Expected the final status to be written before the pod was removed")
+
+	containers, err = runtime.ListContainers(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+}
@@ -0,0 +1,142 @@
+package nodeagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultCheckpointDir is where the node agent persists pod checkpoints
+const DefaultCheckpointDir = defaultStateDir + string(filepath.Separator) + "checkpoints"
+
+// PodCheckpoint records the runtime identity of a pod so it can be recovered after an agent restart
+type PodCheckpoint struct {
+	UID        string            `json:"uid,omitempty"`
+	Namespace  string            `json:"namespace"`
+	Name       string            `json:"name"`
+	SandboxID  string            `json:"sandboxID,omitempty"`
+	Containers map[string]string `json:"containers,omitempty"` // container name -> container ID
+	Volumes    map[string]string `json:"volumes,omitempty"`    // volume name -> host path
+
+	// RuntimeHandler is the runtime the containers live in, "" for the default one
+	RuntimeHandler string `json:"runtimeHandler,omitempty"`
+}
+
+// checkpointStore persists pod checkpoints as one JSON file per pod
+type checkpointStore struct {
+	dir string
+}
+
+// newCheckpointStore creates a checkpoint store rooted at dir
+func newCheckpointStore(dir string) *checkpointStore {
+	return &checkpointStore{dir: dir}
+}
+
+// Save atomically writes the checkpoint for a pod
+func (c *checkpointStore) Save(cp *PodCheckpoint) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create checkpoint dir: %w", err)
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	path := c.path(cp.Namespace, cp.Name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Remove deletes the checkpoint for a pod, if any
+func (c *checkpointStore) Remove(namespace, name string) error {
+	err := os.Remove(c.path(namespace, name))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// List loads all checkpoints, skipping files that can't be parsed
+func (c *checkpointStore) List() ([]*PodCheckpoint, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint dir: %w", err)
+	}
+
+	var checkpoints []*PodCheckpoint
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(c.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var cp PodCheckpoint
+		if err := json.Unmarshal(data, &cp); err != nil {
+			continue // Skip corrupt checkpoints
+		}
+		checkpoints = append(checkpoints, &cp)
+	}
+
+	return checkpoints, nil
+}
+
+// SnapshotDir returns the directory holding the container snapshots of a pod
+func (c *checkpointStore) SnapshotDir(uid string) string {
+	return filepath.Join(c.dir, "snapshots", uid)
+}
+
+// HasSnapshot reports whether a complete snapshot of a pod exists
+func (c *checkpointStore) HasSnapshot(uid string) bool {
+	info, err := os.Stat(c.SnapshotDir(uid))
+	return err == nil && info.IsDir()
+}
+
+// RemoveSnapshot deletes the container snapshots of a pod, if any
+func (c *checkpointStore) RemoveSnapshot(uid string) error {
+	if uid == "" {
+		return nil
+	}
+	if err := os.RemoveAll(c.SnapshotDir(uid)); err != nil {
+		return fmt.Errorf("failed to remove snapshot: %w", err)
+	}
+	return nil
+}
+
+// path returns the checkpoint file path for a pod
+func (c *checkpointStore) path(namespace, name string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%s_%s.json", namespace, name))
+}
+
+// newPodCheckpoint builds a checkpoint from the pod's runtime state
+func newPodCheckpoint(podState *PodState) *PodCheckpoint {
+	cp := &PodCheckpoint{
+		UID:        podState.Pod.UID,
+		Namespace:  podState.Pod.Namespace,
+		Name:       podState.Pod.Name,
+		SandboxID:  podState.SandboxID,
+		Containers: make(map[string]string, len(podState.Containers)),
+		Volumes:    make(map[string]string, len(podState.Volumes)),
+
+		RuntimeHandler: podState.RuntimeHandler,
+	}
+	for name, container := range podState.Containers {
+		cp.Containers[name] = container.ID
+	}
+	for name, volume := range podState.Volumes {
+		cp.Volumes[name] = volume.Path
+	}
+	return cp
+}
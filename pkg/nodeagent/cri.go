@@ -3,10 +3,14 @@ package nodeagent
 import (
 	"context"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/remotecommand"
 )
 
 // CRIRuntime defines the interface for container runtime operations
@@ -15,13 +19,16 @@ type CRIRuntime interface {
 	GetNodeCapacity() (api.ResourceList, error)
 	GetNodeInfo() (*api.NodeSystemInfo, error)
 
-	// Container operations
-	CreateContainer(ctx context.Context, pod *api.Pod, container *api.Container) (string, error)
+	// Container operations. The attempt of a container counts the
+	// containers created for the same spec container of the pod before it.
+	CreateContainer(ctx context.Context, pod *api.Pod, container *api.Container, attempt uint32) (string, error)
 	StartContainer(ctx context.Context, containerID string) error
 	StopContainer(ctx context.Context, containerID string, timeout int64) error
 	RemoveContainer(ctx context.Context, containerID string) error
 	GetContainerStatus(ctx context.Context, containerID string) (*ContainerStatus, error)
 	ListContainers(ctx context.Context, filter *ContainerFilter) ([]*ContainerStatus, error)
+	Exec(ctx context.Context, containerID string, req *ExecRequest) (int, error)
+	Attach(ctx context.Context, containerID string, req *AttachRequest) error
 
 	// Image operations
 	PullImage(ctx context.Context, image string, auth *ImageAuth) error
@@ -32,6 +39,84 @@ type CRIRuntime interface {
 	CreatePodSandbox(ctx context.Context, pod *api.Pod) (string, error)
 	RemovePodSandbox(ctx context.Context, podSandboxID string) error
 	GetPodStatus(ctx context.Context, podSandboxID string) (*PodSandboxStatus, error)
+	ListPodSandboxes(ctx context.Context, filter *PodSandboxFilter) ([]*PodSandboxStatus, error)
+}
+
+// ContainerCheckpointer is implemented by runtimes that can snapshot the
+// filesystem of a container and create containers from such a snapshot
+type ContainerCheckpointer interface {
+	// CheckpointContainer writes a snapshot of the container's filesystem to dir
+	CheckpointContainer(ctx context.Context, containerID, dir string) error
+	// RestoreContainer creates a container like CreateContainer, starting
+	// from the snapshot in dir
+	RestoreContainer(ctx context.Context, pod *api.Pod, container *api.Container, attempt uint32, dir string) (string, error)
+}
+
+// ContainerStatsProvider is implemented by runtimes that can measure the
+// resources their containers use
+type ContainerStatsProvider interface {
+	// ContainerStats returns the container's current resource usage
+	ContainerStats(ctx context.Context, containerID string) (*ContainerStats, error)
+}
+
+// ContainerStats is a container's resource usage at a point in time
+type ContainerStats struct {
+	Timestamp time.Time
+	// CPUUsageNanoseconds is the CPU time used since the container started
+	CPUUsageNanoseconds uint64
+	// MemoryBytes is the memory currently resident
+	MemoryBytes uint64
+}
+
+// Labels set by runtimes on every container and sandbox so they can be traced back to their pod
+const (
+	LabelPodUID        = "minik8s.io/pod-uid"
+	LabelPodName       = "minik8s.io/pod-name"
+	LabelPodNamespace  = "minik8s.io/pod-namespace"
+	LabelContainerName = "minik8s.io/container-name"
+
+	// LabelContainerAttempt holds the attempt a container was created with,
+	// so restart counts can be recovered from the runtime
+	LabelContainerAttempt = "minik8s.io/container-attempt"
+)
+
+// NewSandboxLabels returns the labels a runtime should attach to a pod sandbox
+func NewSandboxLabels(pod *api.Pod) map[string]string {
+	return map[string]string{
+		LabelPodUID:       pod.UID,
+		LabelPodName:      pod.Name,
+		LabelPodNamespace: pod.Namespace,
+	}
+}
+
+// NewContainerLabels returns the labels a runtime should attach to a container
+func NewContainerLabels(pod *api.Pod, container *api.Container, attempt uint32) map[string]string {
+	labels := NewSandboxLabels(pod)
+	labels[LabelContainerName] = container.Name
+	labels[LabelContainerAttempt] = strconv.FormatUint(uint64(attempt), 10)
+	return labels
+}
+
+// containerAttempt returns the attempt a runtime container was created
+// with, from its labels or else its metadata
+func containerAttempt(status *ContainerStatus) uint32 {
+	if attempt, err := strconv.ParseUint(status.Labels[LabelContainerAttempt], 10, 32); err == nil {
+		return uint32(attempt)
+	}
+	if status.Metadata != nil {
+		return status.Metadata.Attempt
+	}
+	return 0
+}
+
+// matchesLabels reports whether labels contain every key/value of selector
+func matchesLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
 }
 
 // ContainerStatus represents the status of a container
@@ -53,6 +138,33 @@ type ContainerStatus struct {
 	LogPath     string
 }
 
+// ExecRequest describes a command to run inside a running container. Nil
+// streams are not attached; the returned int is the command's exit code.
+type ExecRequest struct {
+	Cmd    []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	TTY    bool
+	Resize <-chan remotecommand.TerminalSize
+}
+
+// AttachRequest connects streams to the main process of a running container.
+// Attach returns when the process exits or the client detaches.
+type AttachRequest struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	TTY    bool
+	Resize <-chan remotecommand.TerminalSize
+}
+
+// ExecHandler runs exec requests against mock containers
+type ExecHandler func(ctx context.Context, containerID string, req *ExecRequest) (int, error)
+
+// AttachHandler serves attach requests against mock containers
+type AttachHandler func(ctx context.Context, containerID string, req *AttachRequest) error
+
 // ContainerMetadata contains metadata about a container
 type ContainerMetadata struct {
 	Name    string
@@ -146,6 +258,13 @@ type PodSandboxMetadata struct {
 	Attempt   uint32
 }
 
+// PodSandboxFilter is used to filter pod sandboxes
+type PodSandboxFilter struct {
+	ID            string
+	State         *PodSandboxState
+	LabelSelector map[string]string
+}
+
 // PodSandboxState represents the state of a pod sandbox
 type PodSandboxState int32
 
@@ -183,23 +302,31 @@ const (
 
 // MockCRIRuntime is a mock implementation for testing
 type MockCRIRuntime struct {
+	mu         sync.Mutex
 	containers map[string]*ContainerStatus
+	configs    map[string]*api.Container
 	images     map[string]*Image
+	sandboxes  map[string]*PodSandboxStatus
+	exec       ExecHandler
+	attach     AttachHandler
 }
 
 // NewMockCRIRuntime creates a new mock CRI runtime
 func NewMockCRIRuntime() *MockCRIRuntime {
 	return &MockCRIRuntime{
 		containers: make(map[string]*ContainerStatus),
+		configs:    make(map[string]*api.Container),
 		images:     make(map[string]*Image),
+		sandboxes:  make(map[string]*PodSandboxStatus),
 	}
 }
 
 // GetNodeCapacity returns mock node capacity
 func (m *MockCRIRuntime) GetNodeCapacity() (api.ResourceList, error) {
 	return api.ResourceList{
-		api.ResourceCPU:    "4",
-		api.ResourceMemory: "8Gi",
+		api.ResourceCPU:              "4",
+		api.ResourceMemory:           "8Gi",
+		api.ResourceEphemeralStorage: "100Gi",
 	}, nil
 }
 
@@ -219,27 +346,44 @@ func (m *MockCRIRuntime) GetNodeInfo() (*api.NodeSystemInfo, error) {
 }
 
 // CreateContainer creates a mock container
-func (m *MockCRIRuntime) CreateContainer(ctx context.Context, pod *api.Pod, container *api.Container) (string, error) {
+func (m *MockCRIRuntime) CreateContainer(ctx context.Context, pod *api.Pod, container *api.Container, attempt uint32) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	containerID := fmt.Sprintf("mock-container-%d", time.Now().UnixNano())
 
 	m.containers[containerID] = &ContainerStatus{
 		ID: containerID,
 		Metadata: &ContainerMetadata{
 			Name:    container.Name,
-			Attempt: 0,
+			Attempt: attempt,
 		},
 		State:     ContainerStateCreated,
 		CreatedAt: time.Now().UnixNano(),
 		Image: &ImageSpec{
 			Image: container.Image,
 		},
+		Labels: NewContainerLabels(pod, container, attempt),
 	}
+	m.configs[containerID] = container
 
 	return containerID, nil
 }
 
+// ContainerConfig returns the spec a mock container was created from
+func (m *MockCRIRuntime) ContainerConfig(containerID string) (*api.Container, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	container, ok := m.configs[containerID]
+	return container, ok
+}
+
 // StartContainer starts a mock container
 func (m *MockCRIRuntime) StartContainer(ctx context.Context, containerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if container, exists := m.containers[containerID]; exists {
 		container.State = ContainerStateRunning
 		container.StartedAt = time.Now().UnixNano()
@@ -250,6 +394,9 @@ func (m *MockCRIRuntime) StartContainer(ctx context.Context, containerID string)
 
 // StopContainer stops a mock container
 func (m *MockCRIRuntime) StopContainer(ctx context.Context, containerID string, timeout int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if container, exists := m.containers[containerID]; exists {
 		container.State = ContainerStateExited
 		container.FinishedAt = time.Now().UnixNano()
@@ -260,8 +407,12 @@ func (m *MockCRIRuntime) StopContainer(ctx context.Context, containerID string,
 
 // RemoveContainer removes a mock container
 func (m *MockCRIRuntime) RemoveContainer(ctx context.Context, containerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if _, exists := m.containers[containerID]; exists {
 		delete(m.containers, containerID)
+		delete(m.configs, containerID)
 		return nil
 	}
 	return fmt.Errorf("container %s not found", containerID)
@@ -269,6 +420,9 @@ func (m *MockCRIRuntime) RemoveContainer(ctx context.Context, containerID string
 
 // GetContainerStatus gets the status of a mock container
 func (m *MockCRIRuntime) GetContainerStatus(ctx context.Context, containerID string) (*ContainerStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if container, exists := m.containers[containerID]; exists {
 		return container, nil
 	}
@@ -277,6 +431,9 @@ func (m *MockCRIRuntime) GetContainerStatus(ctx context.Context, containerID str
 
 // ListContainers lists mock containers
 func (m *MockCRIRuntime) ListContainers(ctx context.Context, filter *ContainerFilter) ([]*ContainerStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	var containers []*ContainerStatus
 	for _, container := range m.containers {
 		if filter != nil {
@@ -286,14 +443,72 @@ func (m *MockCRIRuntime) ListContainers(ctx context.Context, filter *ContainerFi
 			if filter.State != nil && container.State != *filter.State {
 				continue
 			}
+			if !matchesLabels(container.Labels, filter.LabelSelector) {
+				continue
+			}
 		}
 		containers = append(containers, container)
 	}
 	return containers, nil
 }
 
+// SetExecHandler installs the function that serves Exec calls
+func (m *MockCRIRuntime) SetExecHandler(handler ExecHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exec = handler
+}
+
+// Exec runs a command in a running mock container through the exec handler
+func (m *MockCRIRuntime) Exec(ctx context.Context, containerID string, req *ExecRequest) (int, error) {
+	m.mu.Lock()
+	container, exists := m.containers[containerID]
+	handler := m.exec
+	m.mu.Unlock()
+
+	if !exists {
+		return -1, fmt.Errorf("container %s not found", containerID)
+	}
+	if container.State != ContainerStateRunning {
+		return -1, fmt.Errorf("container %s is not running", containerID)
+	}
+	if handler == nil {
+		return -1, fmt.Errorf("exec is not supported by the mock runtime")
+	}
+	return handler(ctx, containerID, req)
+}
+
+// SetAttachHandler installs the function that serves Attach calls
+func (m *MockCRIRuntime) SetAttachHandler(handler AttachHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attach = handler
+}
+
+// Attach connects to a running mock container through the attach handler
+func (m *MockCRIRuntime) Attach(ctx context.Context, containerID string, req *AttachRequest) error {
+	m.mu.Lock()
+	container, exists := m.containers[containerID]
+	handler := m.attach
+	m.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("container %s not found", containerID)
+	}
+	if container.State != ContainerStateRunning {
+		return fmt.Errorf("container %s is not running", containerID)
+	}
+	if handler == nil {
+		return fmt.Errorf("attach is not supported by the mock runtime")
+	}
+	return handler(ctx, containerID, req)
+}
+
 // PullImage pulls a mock image
 func (m *MockCRIRuntime) PullImage(ctx context.Context, image string, auth *ImageAuth) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	imageID := fmt.Sprintf("mock-image-%s", strings.ReplaceAll(image, ":", "-"))
 	m.images[imageID] = &Image{
 		ID:       imageID,
@@ -305,6 +520,9 @@ func (m *MockCRIRuntime) PullImage(ctx context.Context, image string, auth *Imag
 
 // RemoveImage removes a mock image
 func (m *MockCRIRuntime) RemoveImage(ctx context.Context, imageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if _, exists := m.images[imageID]; exists {
 		delete(m.images, imageID)
 		return nil
@@ -314,6 +532,9 @@ func (m *MockCRIRuntime) RemoveImage(ctx context.Context, imageID string) error
 
 // ListImages lists mock images
 func (m *MockCRIRuntime) ListImages(ctx context.Context, filter *ImageFilter) ([]*Image, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	var images []*Image
 	for _, image := range m.images {
 		if filter != nil && filter.Image != nil {
@@ -337,17 +558,44 @@ func (m *MockCRIRuntime) ListImages(ctx context.Context, filter *ImageFilter) ([
 
 // CreatePodSandbox creates a mock pod sandbox
 func (m *MockCRIRuntime) CreatePodSandbox(ctx context.Context, pod *api.Pod) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	sandboxID := fmt.Sprintf("mock-sandbox-%s-%s", pod.Namespace, pod.Name)
+	m.sandboxes[sandboxID] = &PodSandboxStatus{
+		ID: sandboxID,
+		Metadata: &PodSandboxMetadata{
+			Name:      pod.Name,
+			UID:       pod.UID,
+			Namespace: pod.Namespace,
+		},
+		State:     PodSandboxStateReady,
+		CreatedAt: time.Now().UnixNano(),
+		Network: &PodSandboxNetworkStatus{
+			IP: "192.168.1.100",
+		},
+		Labels: NewSandboxLabels(pod),
+	}
 	return sandboxID, nil
 }
 
 // RemovePodSandbox removes a mock pod sandbox
 func (m *MockCRIRuntime) RemovePodSandbox(ctx context.Context, podSandboxID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sandboxes, podSandboxID)
 	return nil
 }
 
 // GetPodStatus gets the status of a mock pod sandbox
 func (m *MockCRIRuntime) GetPodStatus(ctx context.Context, podSandboxID string) (*PodSandboxStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if sandbox, exists := m.sandboxes[podSandboxID]; exists {
+		return sandbox, nil
+	}
 	return &PodSandboxStatus{
 		ID:    podSandboxID,
 		State: PodSandboxStateReady,
@@ -356,3 +604,26 @@ func (m *MockCRIRuntime) GetPodStatus(ctx context.Context, podSandboxID string)
 		},
 	}, nil
 }
+
+// ListPodSandboxes lists mock pod sandboxes
+func (m *MockCRIRuntime) ListPodSandboxes(ctx context.Context, filter *PodSandboxFilter) ([]*PodSandboxStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sandboxes []*PodSandboxStatus
+	for _, sandbox := range m.sandboxes {
+		if filter != nil {
+			if filter.ID != "" && sandbox.ID != filter.ID {
+				continue
+			}
+			if filter.State != nil && sandbox.State != *filter.State {
+				continue
+			}
+			if !matchesLabels(sandbox.Labels, filter.LabelSelector) {
+				continue
+			}
+		}
+		sandboxes = append(sandboxes, sandbox)
+	}
+	return sandboxes, nil
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package nodeagent
+
+import "golang.org/x/sys/unix"
+
+// filesystemBytes returns the size of the filesystem holding dir, or 0 if it
+// can't be read
+func filesystemBytes(dir string) int64 {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0
+	}
+	return int64(stat.Blocks) * int64(stat.Bsize)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package nodeagent
+
+// filesystemBytes returns 0, as there's no portable way to read the size of
+// a filesystem; nodes on these hosts don't report ephemeral storage
+func filesystemBytes(dir string) int64 {
+	return 0
+}
@@ -0,0 +1,1048 @@
+package nodeagent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+)
+
+// DefaultDockerHost is the Docker daemon the docker runtime talks to when
+// neither its config nor DOCKER_HOST names one
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// DefaultSandboxImage is the image of the container holding a pod's network
+// and IPC namespaces
+const DefaultSandboxImage = "registry.k8s.io/pause:3.9"
+
+// dockerAPIVersion is the Engine API version requests are made with, that
+// of Docker 20.10
+const dockerAPIVersion = "v1.41"
+
+// Labels the docker runtime adds to tell sandboxes from containers
+const (
+	dockerSandboxLabel   = "minik8s.io/sandbox"
+	dockerSandboxIDLabel = "minik8s.io/sandbox-id"
+)
+
+// DockerRuntimeConfig holds the configuration for the docker runtime
+type DockerRuntimeConfig struct {
+	// Host is the daemon's address, unix:///path or tcp://host:port;
+	// defaults to DOCKER_HOST, then DefaultDockerHost
+	Host string
+	// SandboxImage is the image of pod sandboxes; defaults to DefaultSandboxImage
+	SandboxImage string
+	// Volumes resolves the host paths of pod volumes, which are bind mounted
+	// at the containers' volumeMounts. Nil leaves volumes unmounted.
+	Volumes VolumeManager
+}
+
+// DockerRuntime runs pods as Docker containers through the Engine API. Each
+// pod gets a sandbox container running the pause image, whose network and
+// IPC namespaces its containers join, so they reach each other on localhost
+// and the sandbox publishes the pods' host ports. Images are pulled when a
+// container is created, following its imagePullPolicy. Limits become the
+// container's memory and CPU quota, and CPU requests its CPU shares.
+//
+// The runtime keeps no state of its own: containers and sandboxes are found
+// by their labels, so an agent restarted on the node picks them up again.
+// Container logs stay with Docker's logging driver and aren't served by the
+// agent, and checkpoints aren't supported.
+type DockerRuntime struct {
+	client       *http.Client
+	dial         func(ctx context.Context) (net.Conn, error)
+	sandboxImage string
+	volumes      VolumeManager
+}
+
+// NewDockerRuntime creates a docker runtime
+func NewDockerRuntime(config *DockerRuntimeConfig) (*DockerRuntime, error) {
+	host := config.Host
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = DefaultDockerHost
+	}
+	network, address, ok := strings.Cut(host, "://")
+	if !ok || (network != "unix" && network != "tcp") {
+		return nil, fmt.Errorf("docker host %q must be unix:///path or tcp://host:port", host)
+	}
+	sandboxImage := config.SandboxImage
+	if sandboxImage == "" {
+		sandboxImage = DefaultSandboxImage
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	dial := func(ctx context.Context) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return &DockerRuntime{
+		client: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) { return dial(ctx) },
+		}},
+		dial:         dial,
+		sandboxImage: sandboxImage,
+		volumes:      config.Volumes,
+	}, nil
+}
+
+// dockerError is the body of the daemon's error responses
+type dockerError struct {
+	Message string `json:"message"`
+}
+
+// errDockerNotFound is returned for 404 responses
+var errDockerNotFound = errors.New("not found")
+
+// do sends a request to the Engine API, encoding body as JSON, and decodes a
+// successful response into out. 304 Not Modified, sent when a container is
+// already in the state asked for, counts as success.
+func (r *DockerRuntime) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := r.request(ctx, method, path, query, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("invalid response to %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// request sends a request to the Engine API and returns the response if it
+// succeeded; the caller closes its body
+func (r *DockerRuntime) request(ctx context.Context, method, path string, query url.Values, body interface{}, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := "http://docker/" + dockerAPIVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker daemon unreachable: %w", err)
+	}
+	if resp.StatusCode < 300 || resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, responseError(resp)
+}
+
+// responseError turns an error response of the daemon into an error
+func responseError(resp *http.Response) error {
+	var body dockerError
+	data, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(data, &body) != nil || body.Message == "" {
+		body.Message = strings.TrimSpace(string(data))
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", errDockerNotFound, body.Message)
+	}
+	return fmt.Errorf("docker daemon returned %s: %s", resp.Status, body.Message)
+}
+
+// dockerInfo is the part of GET /info the runtime uses
+type dockerInfo struct {
+	ID              string `json:"ID"`
+	NCPU            int    `json:"NCPU"`
+	MemTotal        int64  `json:"MemTotal"`
+	OperatingSystem string `json:"OperatingSystem"`
+	OSType          string `json:"OSType"`
+	Architecture    string `json:"Architecture"`
+	KernelVersion   string `json:"KernelVersion"`
+	ServerVersion   string `json:"ServerVersion"`
+	DockerRootDir   string `json:"DockerRootDir"`
+}
+
+// info describes the daemon and its host
+func (r *DockerRuntime) info() (*dockerInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var info dockerInfo
+	if err := r.do(ctx, http.MethodGet, "/info", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetNodeCapacity returns the CPUs and memory of the daemon's host and, if
+// the daemon's root directory is on this machine, the size of its
+// filesystem as ephemeral storage
+func (r *DockerRuntime) GetNodeCapacity() (api.ResourceList, error) {
+	info, err := r.info()
+	if err != nil {
+		return nil, err
+	}
+	capacity := api.ResourceList{
+		api.ResourceCPU:    strconv.Itoa(info.NCPU),
+		api.ResourceMemory: fmt.Sprintf("%dKi", info.MemTotal/1024),
+	}
+	if storage := filesystemBytes(info.DockerRootDir); storage > 0 {
+		capacity[api.ResourceEphemeralStorage] = fmt.Sprintf("%dKi", storage/1024)
+	}
+	return capacity, nil
+}
+
+// GetNodeInfo describes the daemon's host, naming architectures as Go does
+func (r *DockerRuntime) GetNodeInfo() (*api.NodeSystemInfo, error) {
+	info, err := r.info()
+	if err != nil {
+		return nil, err
+	}
+	architecture := info.Architecture
+	switch architecture {
+	case "x86_64":
+		architecture = "amd64"
+	case "aarch64":
+		architecture = "arm64"
+	}
+	return &api.NodeSystemInfo{
+		MachineID:               info.ID,
+		KernelVersion:           info.KernelVersion,
+		OSImage:                 info.OperatingSystem,
+		ContainerRuntimeVersion: "docker://" + info.ServerVersion,
+		OperatingSystem:         info.OSType,
+		Architecture:            architecture,
+	}, nil
+}
+
+// dockerContainerConfig is the body of POST /containers/create
+type dockerContainerConfig struct {
+	Image        string              `json:"Image"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	Tty          bool                `json:"Tty,omitempty"`
+	OpenStdin    bool                `json:"OpenStdin,omitempty"`
+	StdinOnce    bool                `json:"StdinOnce,omitempty"`
+	AttachStdin  bool                `json:"AttachStdin,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	HostConfig   dockerHostConfig    `json:"HostConfig"`
+}
+
+// dockerHostConfig is the host-specific part of a container's config
+type dockerHostConfig struct {
+	NetworkMode  string                         `json:"NetworkMode,omitempty"`
+	IpcMode      string                         `json:"IpcMode,omitempty"`
+	Binds        []string                       `json:"Binds,omitempty"`
+	PortBindings map[string][]dockerPortBinding `json:"PortBindings,omitempty"`
+	Memory       int64                          `json:"Memory,omitempty"`
+	NanoCpus     int64                          `json:"NanoCpus,omitempty"`
+	CPUShares    int64                          `json:"CpuShares,omitempty"`
+}
+
+// dockerPortBinding publishes a container port on the host
+type dockerPortBinding struct {
+	HostIP   string `json:"HostIp,omitempty"`
+	HostPort string `json:"HostPort"`
+}
+
+// createContainer creates a container and returns its ID
+func (r *DockerRuntime) createContainer(ctx context.Context, config *dockerContainerConfig) (string, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := r.do(ctx, http.MethodPost, "/containers/create", nil, config, &created); err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+	return created.ID, nil
+}
+
+// CreateContainer pulls the container's image as its imagePullPolicy asks
+// and creates the container in the pod's sandbox. Command replaces the
+// image's entrypoint and args its command, as in Kubernetes.
+func (r *DockerRuntime) CreateContainer(ctx context.Context, pod *api.Pod, container *api.Container, attempt uint32) (string, error) {
+	sandbox, err := r.podSandbox(ctx, pod)
+	if err != nil {
+		return "", err
+	}
+	if err := r.ensureImage(ctx, container); err != nil {
+		return "", err
+	}
+
+	hostConfig, err := containerResources(container)
+	if err != nil {
+		return "", err
+	}
+	hostConfig.NetworkMode = "container:" + sandbox
+	hostConfig.IpcMode = "container:" + sandbox
+	if hostConfig.Binds, err = r.volumeBinds(ctx, pod, container); err != nil {
+		return "", err
+	}
+
+	labels := NewContainerLabels(pod, container, attempt)
+	labels[dockerSandboxIDLabel] = sandbox
+	config := &dockerContainerConfig{
+		Image:       container.Image,
+		Entrypoint:  container.Command,
+		Cmd:         container.Args,
+		WorkingDir:  container.WorkingDir,
+		Labels:      labels,
+		Tty:         container.TTY,
+		OpenStdin:   container.Stdin,
+		StdinOnce:   container.StdinOnce,
+		AttachStdin: container.Stdin,
+		HostConfig:  hostConfig,
+	}
+	for _, v := range container.Env {
+		config.Env = append(config.Env, v.Name+"="+v.Value)
+	}
+	return r.createContainer(ctx, config)
+}
+
+// containerResources turns the container's limits into a memory and CPU
+// quota and its CPU request into CPU shares, 1024 per core
+func containerResources(container *api.Container) (dockerHostConfig, error) {
+	var config dockerHostConfig
+	limits, requests := container.Resources.Limits, container.Resources.Requests
+	if memory, ok := limits[api.ResourceMemory]; ok {
+		bytes, err := api.ParseMemory(memory)
+		if err != nil {
+			return config, fmt.Errorf("invalid memory limit %q: %w", memory, err)
+		}
+		config.Memory = int64(bytes)
+	}
+	if cpu, ok := limits[api.ResourceCPU]; ok {
+		cores, err := api.ParseCPU(cpu)
+		if err != nil {
+			return config, fmt.Errorf("invalid cpu limit %q: %w", cpu, err)
+		}
+		config.NanoCpus = int64(cores * 1e9)
+	}
+	if cpu, ok := requests[api.ResourceCPU]; ok {
+		cores, err := api.ParseCPU(cpu)
+		if err != nil {
+			return config, fmt.Errorf("invalid cpu request %q: %w", cpu, err)
+		}
+		// Docker's minimum is 2 shares
+		config.CPUShares = max(int64(cores*1024), 2)
+	}
+	return config, nil
+}
+
+// volumeBinds returns the bind mounts of the container's volumeMounts
+func (r *DockerRuntime) volumeBinds(ctx context.Context, pod *api.Pod, container *api.Container) ([]string, error) {
+	if r.volumes == nil {
+		return nil, nil
+	}
+	var binds []string
+	for _, mount := range container.VolumeMounts {
+		var volume *api.Volume
+		for i := range pod.Spec.Volumes {
+			if pod.Spec.Volumes[i].Name == mount.Name {
+				volume = &pod.Spec.Volumes[i]
+			}
+		}
+		if volume == nil {
+			return nil, fmt.Errorf("volume %s not found in pod %s/%s", mount.Name, pod.Namespace, pod.Name)
+		}
+		path, err := r.volumes.GetVolumePath(ctx, pod, volume)
+		if err != nil {
+			return nil, fmt.Errorf("volume %s: %w", mount.Name, err)
+		}
+		bind := path + ":" + mount.MountPath
+		if mount.ReadOnly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+	}
+	return binds, nil
+}
+
+// StartContainer starts a container
+func (r *DockerRuntime) StartContainer(ctx context.Context, containerID string) error {
+	if err := r.do(ctx, http.MethodPost, "/containers/"+containerID+"/start", nil, nil, nil); err != nil {
+		return containerError(containerID, err)
+	}
+	return nil
+}
+
+// StopContainer asks the container's main process to exit and kills it if
+// it's still running after timeout seconds
+func (r *DockerRuntime) StopContainer(ctx context.Context, containerID string, timeout int64) error {
+	query := url.Values{"t": {strconv.FormatInt(timeout, 10)}}
+	if err := r.do(ctx, http.MethodPost, "/containers/"+containerID+"/stop", query, nil, nil); err != nil {
+		return containerError(containerID, err)
+	}
+	return nil
+}
+
+// RemoveContainer removes a container, killing it if it's still running,
+// along with its anonymous volumes
+func (r *DockerRuntime) RemoveContainer(ctx context.Context, containerID string) error {
+	query := url.Values{"force": {"1"}, "v": {"1"}}
+	if err := r.do(ctx, http.MethodDelete, "/containers/"+containerID, query, nil, nil); err != nil {
+		return containerError(containerID, err)
+	}
+	return nil
+}
+
+// containerError reports a failed request about a container, saying so
+// plainly when the container doesn't exist
+func containerError(containerID string, err error) error {
+	if errors.Is(err, errDockerNotFound) {
+		return fmt.Errorf("container %s not found", containerID)
+	}
+	return fmt.Errorf("container %s: %w", containerID, err)
+}
+
+// dockerContainer is the part of GET /containers/{id}/json the runtime uses
+type dockerContainer struct {
+	ID      string `json:"Id"`
+	Created string `json:"Created"`
+	Image   string `json:"Image"`
+	State   struct {
+		Status     string `json:"Status"`
+		OOMKilled  bool   `json:"OOMKilled"`
+		ExitCode   int32  `json:"ExitCode"`
+		Error      string `json:"Error"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	NetworkSettings struct {
+		IPAddress string `json:"IPAddress"`
+	} `json:"NetworkSettings"`
+}
+
+// inspect returns the details of a container or sandbox
+func (r *DockerRuntime) inspect(ctx context.Context, id string) (*dockerContainer, error) {
+	var container dockerContainer
+	if err := r.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, &container); err != nil {
+		return nil, err
+	}
+	return &container, nil
+}
+
+// GetContainerStatus gets the status of a container
+func (r *DockerRuntime) GetContainerStatus(ctx context.Context, containerID string) (*ContainerStatus, error) {
+	container, err := r.inspect(ctx, containerID)
+	if err != nil {
+		return nil, containerError(containerID, err)
+	}
+	return container.status(), nil
+}
+
+// status converts an inspected container into its CRI status. Paused and
+// restarting containers count as running, as their process is still there.
+func (c *dockerContainer) status() *ContainerStatus {
+	status := &ContainerStatus{
+		ID:         c.ID,
+		CreatedAt:  dockerTime(c.Created),
+		StartedAt:  dockerTime(c.State.StartedAt),
+		FinishedAt: dockerTime(c.State.FinishedAt),
+		ExitCode:   c.State.ExitCode,
+		Image:      &ImageSpec{Image: c.Config.Image},
+		ImageRef:   c.Image,
+		Labels:     c.Config.Labels,
+		Message:    c.State.Error,
+	}
+	status.Metadata = &ContainerMetadata{Name: c.Config.Labels[LabelContainerName]}
+	status.Metadata.Attempt = containerAttempt(status)
+
+	switch c.State.Status {
+	case "created":
+		status.State = ContainerStateCreated
+	case "running", "paused", "restarting":
+		status.State = ContainerStateRunning
+	case "exited", "dead":
+		status.State = ContainerStateExited
+		switch {
+		case c.State.OOMKilled:
+			status.Reason = "OOMKilled"
+		case c.State.ExitCode == 0:
+			status.Reason = "Completed"
+		default:
+			status.Reason = "Error"
+		}
+	default:
+		status.State = ContainerStateUnknown
+	}
+	return status
+}
+
+// dockerTime converts a time reported by the daemon to Unix nanoseconds;
+// the zero time Docker reports for events that didn't happen becomes 0
+func dockerTime(value string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Year() <= 1 {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// ContainerStats reads a running container's CPU time and memory from its
+// cgroup. Memory leaves out inactive page cache, as the kernel reclaims it
+// before the container would be killed.
+func (r *DockerRuntime) ContainerStats(ctx context.Context, containerID string) (*ContainerStats, error) {
+	var stats struct {
+		Read     time.Time `json:"read"`
+		CPUStats struct {
+			CPUUsage struct {
+				TotalUsage uint64 `json:"total_usage"`
+			} `json:"cpu_usage"`
+		} `json:"cpu_stats"`
+		MemoryStats struct {
+			Usage uint64            `json:"usage"`
+			Stats map[string]uint64 `json:"stats"`
+		} `json:"memory_stats"`
+	}
+	query := url.Values{"stream": {"0"}, "one-shot": {"1"}}
+	if err := r.do(ctx, http.MethodGet, "/containers/"+containerID+"/stats", query, nil, &stats); err != nil {
+		return nil, containerError(containerID, err)
+	}
+
+	memory := stats.MemoryStats.Usage
+	inactive, ok := stats.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		inactive = stats.MemoryStats.Stats["total_inactive_file"]
+	}
+	if inactive < memory {
+		memory -= inactive
+	}
+	return &ContainerStats{
+		Timestamp:           stats.Read,
+		CPUUsageNanoseconds: stats.CPUStats.CPUUsage.TotalUsage,
+		MemoryBytes:         memory,
+	}, nil
+}
+
+// list returns the IDs of the containers, running or not, with all of the
+// given labels; a label without a value only has to be set
+func (r *DockerRuntime) list(ctx context.Context, id string, labels []string) ([]string, error) {
+	filters := map[string][]string{"label": labels}
+	if id != "" {
+		filters["id"] = []string{id}
+	}
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	query := url.Values{"all": {"1"}, "filters": {string(encoded)}}
+	if err := r.do(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// selectorLabels turns a label selector into Docker label filters
+func selectorLabels(required string, selector map[string]string) []string {
+	labels := []string{required}
+	for key, value := range selector {
+		labels = append(labels, key+"="+value)
+	}
+	return labels
+}
+
+// ListContainers lists the containers of pods, leaving out their sandboxes.
+// Containers removed while they're listed are skipped.
+func (r *DockerRuntime) ListContainers(ctx context.Context, filter *ContainerFilter) ([]*ContainerStatus, error) {
+	if filter == nil {
+		filter = &ContainerFilter{}
+	}
+	labels := selectorLabels(LabelContainerName, filter.LabelSelector)
+	if filter.PodSandboxID != "" {
+		labels = append(labels, dockerSandboxIDLabel+"="+filter.PodSandboxID)
+	}
+	ids, err := r.list(ctx, filter.ID, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []*ContainerStatus
+	for _, id := range ids {
+		container, err := r.inspect(ctx, id)
+		if errors.Is(err, errDockerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, containerError(id, err)
+		}
+		status := container.status()
+		if filter.State != nil && status.State != *filter.State {
+			continue
+		}
+		containers = append(containers, status)
+	}
+	return containers, nil
+}
+
+// Exec runs a command in a running container
+func (r *DockerRuntime) Exec(ctx context.Context, containerID string, req *ExecRequest) (int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	config := map[string]interface{}{
+		"Cmd":          req.Cmd,
+		"AttachStdin":  req.Stdin != nil,
+		"AttachStdout": req.Stdout != nil,
+		"AttachStderr": req.Stderr != nil,
+		"Tty":          req.TTY,
+	}
+	if err := r.do(ctx, http.MethodPost, "/containers/"+containerID+"/exec", nil, config, &created); err != nil {
+		return 0, containerError(containerID, err)
+	}
+
+	go r.forwardResizes(ctx, "/exec/"+created.ID+"/resize", req.Resize)
+	start := map[string]interface{}{"Detach": false, "Tty": req.TTY}
+	if err := r.stream(ctx, "/exec/"+created.ID+"/start", start, req.Stdin, req.Stdout, req.Stderr, req.TTY); err != nil {
+		return 0, fmt.Errorf("exec in container %s: %w", containerID, err)
+	}
+
+	var result struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := r.do(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &result); err != nil {
+		return 0, fmt.Errorf("exec in container %s: %w", containerID, err)
+	}
+	return result.ExitCode, nil
+}
+
+// Attach connects streams to the container's main process until it exits
+// or the client detaches
+func (r *DockerRuntime) Attach(ctx context.Context, containerID string, req *AttachRequest) error {
+	query := url.Values{
+		"stream": {"1"},
+		"stdin":  {strconv.FormatBool(req.Stdin != nil)},
+		"stdout": {strconv.FormatBool(req.Stdout != nil)},
+		"stderr": {strconv.FormatBool(req.Stderr != nil)},
+	}
+	go r.forwardResizes(ctx, "/containers/"+containerID+"/resize", req.Resize)
+	path := "/containers/" + containerID + "/attach?" + query.Encode()
+	if err := r.stream(ctx, path, nil, req.Stdin, req.Stdout, req.Stderr, req.TTY); err != nil {
+		return containerError(containerID, err)
+	}
+	return nil
+}
+
+// forwardResizes passes terminal resizes on to the daemon until the channel
+// closes or ctx is done
+func (r *DockerRuntime) forwardResizes(ctx context.Context, path string, resizes <-chan remotecommand.TerminalSize) {
+	if resizes == nil {
+		return
+	}
+	for {
+		select {
+		case size, ok := <-resizes:
+			if !ok {
+				return
+			}
+			query := url.Values{"h": {strconv.Itoa(int(size.Height))}, "w": {strconv.Itoa(int(size.Width))}}
+			if err := r.do(ctx, http.MethodPost, path, query, nil, nil); err != nil {
+				fmt.Printf("Failed to resize terminal: %v\n", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stream sends a request the daemon answers by hijacking the connection,
+// copies stdin to it and its output to stdout and stderr. Without a TTY the
+// daemon multiplexes both outputs into frames with an 8 byte header: the
+// stream, three zero bytes, then the big-endian length of the frame.
+func (r *DockerRuntime) stream(ctx context.Context, path string, body interface{}, stdin io.Reader, stdout, stderr io.Writer, tty bool) error {
+	conn, err := r.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("docker daemon unreachable: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var data []byte
+	if body != nil {
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://docker/"+dockerAPIVersion+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return responseError(resp)
+	}
+
+	if stdin != nil {
+		go func() {
+			io.Copy(conn, stdin)
+			if closer, ok := conn.(interface{ CloseWrite() error }); ok {
+				closer.CloseWrite()
+			}
+		}()
+	}
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	if tty {
+		_, err = io.Copy(stdout, reader)
+	} else {
+		err = demuxStream(reader, stdout, stderr)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// demuxStream splits a multiplexed stream into stdout and stderr until it ends
+func demuxStream(reader io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		out := stdout
+		if header[0] == 2 {
+			out = stderr
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(out, reader, size); err != nil {
+			return err
+		}
+	}
+}
+
+// PullImage pulls an image, its latest tag if it names neither a tag nor a
+// digest
+func (r *DockerRuntime) PullImage(ctx context.Context, image string, auth *ImageAuth) error {
+	header := http.Header{}
+	if auth != nil {
+		encoded, err := json.Marshal(map[string]string{
+			"username":      auth.Username,
+			"password":      auth.Password,
+			"auth":          auth.Auth,
+			"serveraddress": auth.ServerAddress,
+			"identitytoken": auth.IdentityToken,
+			"registrytoken": auth.RegistryToken,
+		})
+		if err != nil {
+			return err
+		}
+		header.Set("X-Registry-Auth", base64.URLEncoding.EncodeToString(encoded))
+	}
+
+	query := url.Values{"fromImage": {imageReference(image)}}
+	resp, err := r.request(ctx, http.MethodPost, "/images/create", query, nil, header)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	defer resp.Body.Close()
+
+	// The daemon streams the pull's progress, ending with an error message
+	// if it failed
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to pull image %s: %w", image, err)
+		}
+		if message.Error != "" {
+			return fmt.Errorf("failed to pull image %s: %s", image, message.Error)
+		}
+	}
+}
+
+// imageReference adds the latest tag to images naming neither a tag nor a
+// digest, as Docker would otherwise pull every tag
+func imageReference(image string) string {
+	if strings.Contains(image, "@") || strings.LastIndex(image, ":") > strings.LastIndex(image, "/") {
+		return image
+	}
+	return image + ":latest"
+}
+
+// hasImage reports whether the daemon has an image
+func (r *DockerRuntime) hasImage(ctx context.Context, image string) (bool, error) {
+	err := r.do(ctx, http.MethodGet, "/images/"+imageReference(image)+"/json", nil, nil, nil)
+	if errors.Is(err, errDockerNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ensureImage pulls the container's image as its imagePullPolicy asks:
+// Always, IfNotPresent, or Never. Without one, images tagged latest, or not
+// tagged at all, are always pulled.
+func (r *DockerRuntime) ensureImage(ctx context.Context, container *api.Container) error {
+	policy := container.ImagePullPolicy
+	if policy == "" {
+		policy = "IfNotPresent"
+		if reference := imageReference(container.Image); strings.HasSuffix(reference, ":latest") {
+			policy = "Always"
+		}
+	}
+	if policy == "Always" {
+		return r.PullImage(ctx, container.Image, nil)
+	}
+
+	present, err := r.hasImage(ctx, container.Image)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image %s: %w", container.Image, err)
+	}
+	switch {
+	case present:
+		return nil
+	case policy == "Never":
+		return fmt.Errorf("image %s is not present and imagePullPolicy is Never", container.Image)
+	default:
+		return r.PullImage(ctx, container.Image, nil)
+	}
+}
+
+// RemoveImage removes an image
+func (r *DockerRuntime) RemoveImage(ctx context.Context, imageID string) error {
+	if err := r.do(ctx, http.MethodDelete, "/images/"+imageID, nil, nil, nil); err != nil {
+		if errors.Is(err, errDockerNotFound) {
+			return fmt.Errorf("image %s not found", imageID)
+		}
+		return fmt.Errorf("failed to remove image %s: %w", imageID, err)
+	}
+	return nil
+}
+
+// ListImages lists the daemon's images, those matching filter.Image if it's set
+func (r *DockerRuntime) ListImages(ctx context.Context, filter *ImageFilter) ([]*Image, error) {
+	query := url.Values{}
+	if filter != nil && filter.Image != nil && filter.Image.Image != "" {
+		encoded, err := json.Marshal(map[string][]string{"reference": {filter.Image.Image}})
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(encoded))
+	}
+	var images []struct {
+		ID          string   `json:"Id"`
+		RepoTags    []string `json:"RepoTags"`
+		RepoDigests []string `json:"RepoDigests"`
+		Size        int64    `json:"Size"`
+	}
+	if err := r.do(ctx, http.MethodGet, "/images/json", query, nil, &images); err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	result := make([]*Image, 0, len(images))
+	for _, image := range images {
+		result = append(result, &Image{
+			ID:          image.ID,
+			RepoTags:    image.RepoTags,
+			RepoDigests: image.RepoDigests,
+			Size:        uint64(image.Size),
+		})
+	}
+	return result, nil
+}
+
+// CreatePodSandbox starts the pod's pause container, pulling its image the
+// first time. It publishes the host ports of the pod's containers, or joins
+// the host's network for hostNetwork pods.
+func (r *DockerRuntime) CreatePodSandbox(ctx context.Context, pod *api.Pod) (string, error) {
+	present, err := r.hasImage(ctx, r.sandboxImage)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", r.sandboxImage, err)
+	}
+	if !present {
+		if err := r.PullImage(ctx, r.sandboxImage, nil); err != nil {
+			return "", err
+		}
+	}
+
+	labels := NewSandboxLabels(pod)
+	labels[dockerSandboxLabel] = "true"
+	config := &dockerContainerConfig{
+		Image:      r.sandboxImage,
+		Labels:     labels,
+		HostConfig: dockerHostConfig{IpcMode: "shareable"},
+	}
+	if pod.Spec.HostNetwork {
+		config.HostConfig.NetworkMode = "host"
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort == 0 || pod.Spec.HostNetwork {
+				continue
+			}
+			protocol := strings.ToLower(port.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			key := fmt.Sprintf("%d/%s", port.ContainerPort, protocol)
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = make(map[string]struct{})
+				config.HostConfig.PortBindings = make(map[string][]dockerPortBinding)
+			}
+			config.ExposedPorts[key] = struct{}{}
+			config.HostConfig.PortBindings[key] = append(config.HostConfig.PortBindings[key],
+				dockerPortBinding{HostIP: port.HostIP, HostPort: strconv.Itoa(int(port.HostPort))})
+		}
+	}
+
+	sandboxID, err := r.createContainer(ctx, config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	if err := r.StartContainer(ctx, sandboxID); err != nil {
+		r.RemovePodSandbox(ctx, sandboxID)
+		return "", err
+	}
+	return sandboxID, nil
+}
+
+// podSandbox returns the ID of the pod's running sandbox
+func (r *DockerRuntime) podSandbox(ctx context.Context, pod *api.Pod) (string, error) {
+	sandboxes, err := r.ListPodSandboxes(ctx, &PodSandboxFilter{LabelSelector: NewSandboxLabels(pod)})
+	if err != nil {
+		return "", err
+	}
+	for _, sandbox := range sandboxes {
+		if sandbox.State == PodSandboxStateReady {
+			return sandbox.ID, nil
+		}
+	}
+	return "", fmt.Errorf("pod %s/%s has no running sandbox", pod.Namespace, pod.Name)
+}
+
+// RemovePodSandbox removes the pod's pause container
+func (r *DockerRuntime) RemovePodSandbox(ctx context.Context, podSandboxID string) error {
+	query := url.Values{"force": {"1"}}
+	if err := r.do(ctx, http.MethodDelete, "/containers/"+podSandboxID, query, nil, nil); err != nil {
+		if errors.Is(err, errDockerNotFound) {
+			return fmt.Errorf("pod sandbox %s not found", podSandboxID)
+		}
+		return fmt.Errorf("failed to remove pod sandbox %s: %w", podSandboxID, err)
+	}
+	return nil
+}
+
+// GetPodStatus gets the status of a pod sandbox
+func (r *DockerRuntime) GetPodStatus(ctx context.Context, podSandboxID string) (*PodSandboxStatus, error) {
+	container, err := r.inspect(ctx, podSandboxID)
+	if errors.Is(err, errDockerNotFound) || (err == nil && container.Config.Labels[dockerSandboxLabel] != "true") {
+		return nil, fmt.Errorf("pod sandbox %s not found", podSandboxID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pod sandbox %s: %w", podSandboxID, err)
+	}
+	return container.sandboxStatus(), nil
+}
+
+// sandboxStatus converts an inspected pause container into its sandbox status
+func (c *dockerContainer) sandboxStatus() *PodSandboxStatus {
+	labels := c.Config.Labels
+	state := PodSandboxStateNotReady
+	if c.State.Status == "running" {
+		state = PodSandboxStateReady
+	}
+	return &PodSandboxStatus{
+		ID: c.ID,
+		Metadata: &PodSandboxMetadata{
+			Name:      labels[LabelPodName],
+			UID:       labels[LabelPodUID],
+			Namespace: labels[LabelPodNamespace],
+		},
+		State:     state,
+		CreatedAt: dockerTime(c.Created),
+		Network:   &PodSandboxNetworkStatus{IP: c.NetworkSettings.IPAddress},
+		Labels:    labels,
+	}
+}
+
+// ListPodSandboxes lists pod sandboxes
+func (r *DockerRuntime) ListPodSandboxes(ctx context.Context, filter *PodSandboxFilter) ([]*PodSandboxStatus, error) {
+	if filter == nil {
+		filter = &PodSandboxFilter{}
+	}
+	ids, err := r.list(ctx, filter.ID, selectorLabels(dockerSandboxLabel+"=true", filter.LabelSelector))
+	if err != nil {
+		return nil, err
+	}
+
+	var sandboxes []*PodSandboxStatus
+	for _, id := range ids {
+		container, err := r.inspect(ctx, id)
+		if errors.Is(err, errDockerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pod sandbox %s: %w", id, err)
+		}
+		sandbox := container.sandboxStatus()
+		if filter.State != nil && sandbox.State != *filter.State {
+			continue
+		}
+		sandboxes = append(sandboxes, sandbox)
+	}
+	return sandboxes, nil
+}
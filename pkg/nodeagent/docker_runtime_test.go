@@ -0,0 +1,237 @@
+package nodeagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker serves the part of the Engine API the docker runtime uses,
+// keeping images and containers in memory. Started containers exit right
+// away with exitCode.
+type fakeDocker struct {
+	mu         sync.Mutex
+	images     map[string]bool
+	pulls      []string
+	containers map[string]*fakeDockerContainer
+	exitCode   int32
+}
+
+type fakeDockerContainer struct {
+	config dockerContainerConfig
+	status string
+}
+
+func (d *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+dockerAPIVersion)
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dockerError{Message: "no such object"})
+	}
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/"):
+		if !d.images[strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")] {
+			notFound()
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"Id": "sha256:image"})
+	case r.Method == http.MethodPost && path == "/images/create":
+		image := r.URL.Query().Get("fromImage")
+		d.pulls = append(d.pulls, image)
+		fmt.Fprintln(w, `{"status":"Pulling fs layer"}`)
+		if strings.HasPrefix(image, "missing") {
+			fmt.Fprintln(w, `{"error":"manifest unknown"}`)
+			return
+		}
+		d.images[image] = true
+	case r.Method == http.MethodPost && path == "/containers/create":
+		var config dockerContainerConfig
+		json.NewDecoder(r.Body).Decode(&config)
+		id := fmt.Sprintf("c%d", len(d.containers))
+		d.containers[id] = &fakeDockerContainer{config: config, status: "created"}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": id})
+	case r.Method == http.MethodGet && path == "/containers/json":
+		var filters map[string][]string
+		json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		list := []map[string]string{}
+		for id, c := range d.containers {
+			matches := true
+			for _, label := range filters["label"] {
+				key, value, hasValue := strings.Cut(label, "=")
+				actual, ok := c.config.Labels[key]
+				matches = matches && ok && (!hasValue || actual == value)
+			}
+			if matches {
+				list = append(list, map[string]string{"Id": id})
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	default:
+		id, action, _ := strings.Cut(strings.TrimPrefix(path, "/containers/"), "/")
+		c, ok := d.containers[id]
+		if !ok {
+			notFound()
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && action == "start":
+			c.status = "running"
+			if c.config.Labels[dockerSandboxLabel] == "" {
+				c.status = "exited"
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete:
+			delete(d.containers, id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && action == "json":
+			var inspected dockerContainer
+			inspected.ID = id
+			inspected.Created = "2026-10-15T10:00:00.5Z"
+			inspected.State.Status = c.status
+			inspected.State.StartedAt = "0001-01-01T00:00:00Z"
+			if c.status == "exited" {
+				inspected.State.ExitCode = d.exitCode
+			}
+			inspected.Config.Image = c.config.Image
+			inspected.Config.Labels = c.config.Labels
+			json.NewEncoder(w).Encode(inspected)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}
+}
+
+func newFakeDockerRuntime(t *testing.T) (*DockerRuntime, *fakeDocker) {
+	t.Helper()
+	daemon := &fakeDocker{
+		images:     map[string]bool{},
+		containers: map[string]*fakeDockerContainer{},
+		exitCode:   3,
+	}
+	server := httptest.NewServer(daemon)
+	t.Cleanup(server.Close)
+
+	r, err := NewDockerRuntime(&DockerRuntimeConfig{
+		Host:    "tcp://" + strings.TrimPrefix(server.URL, "http://"),
+		Volumes: &MockVolumeManager{},
+	})
+	require.NoError(t, err)
+	return r, daemon
+}
+
+func TestDockerRuntime_PodLifecycle(t *testing.T) {
+	ctx := context.Background()
+	r, daemon := newFakeDockerRuntime(t)
+	pod := newExecTestPod()
+	pod.Spec.Volumes = []api.Volume{{Name: "data"}}
+
+	sandboxID, err := r.CreatePodSandbox(ctx, pod)
+	require.NoError(t, err)
+	sandboxes, err := r.ListPodSandboxes(ctx, &PodSandboxFilter{LabelSelector: map[string]string{LabelPodUID: "pod-uid"}})
+	require.NoError(t, err)
+	require.Len(t, sandboxes, 1)
+	assert.Equal(t, PodSandboxStateReady, sandboxes[0].State)
+	assert.Equal(t, "test-pod", sandboxes[0].Metadata.Name)
+
+	container := &api.Container{
+		Name:         "app",
+		Image:        "nginx:1.27",
+		Command:      []string{"nginx"},
+		Args:         []string{"-g", "daemon off;"},
+		Env:          []api.EnvVar{{Name: "MODE", Value: "test"}},
+		VolumeMounts: []api.VolumeMount{{Name: "data", MountPath: "/data", ReadOnly: true}},
+		Resources: api.ResourceRequirements{
+			Limits:   api.ResourceList{api.ResourceCPU: "500m", api.ResourceMemory: "64Mi"},
+			Requests: api.ResourceList{api.ResourceCPU: "250m"},
+		},
+	}
+	containerID, err := r.CreateContainer(ctx, pod, container, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultSandboxImage, "nginx:1.27"}, daemon.pulls, "missing images are pulled")
+
+	config := daemon.containers[containerID].config
+	assert.Equal(t, []string{"nginx"}, config.Entrypoint)
+	assert.Equal(t, []string{"-g", "daemon off;"}, config.Cmd)
+	assert.Equal(t, []string{"MODE=test"}, config.Env)
+	assert.Equal(t, "container:"+sandboxID, config.HostConfig.NetworkMode)
+	assert.Equal(t, int64(500_000_000), config.HostConfig.NanoCpus)
+	assert.Equal(t, int64(64*1024*1024), config.HostConfig.Memory)
+	assert.Equal(t, int64(256), config.HostConfig.CPUShares)
+	assert.Equal(t, []string{"/var/lib/minik8s/volumes/data:/data:ro"}, config.HostConfig.Binds)
+
+	status, err := r.GetContainerStatus(ctx, containerID)
+	require.NoError(t, err)
+	assert.Equal(t, ContainerStateCreated, status.State)
+	assert.Equal(t, &ContainerMetadata{Name: "app", Attempt: 2}, status.Metadata)
+	assert.NotZero(t, status.CreatedAt)
+	assert.Zero(t, status.StartedAt, "Docker's zero time means the container never started")
+
+	require.NoError(t, r.StartContainer(ctx, containerID))
+	status, err = r.GetContainerStatus(ctx, containerID)
+	require.NoError(t, err)
+	assert.Equal(t, ContainerStateExited, status.State)
+	assert.Equal(t, int32(3), status.ExitCode)
+	assert.Equal(t, "Error", status.Reason)
+
+	// Sandboxes aren't listed as containers
+	containers, err := r.ListContainers(ctx, &ContainerFilter{LabelSelector: NewSandboxLabels(pod)})
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, containerID, containers[0].ID)
+
+	require.NoError(t, r.RemoveContainer(ctx, containerID))
+	_, err = r.GetContainerStatus(ctx, containerID)
+	assert.EqualError(t, err, "container "+containerID+" not found")
+	require.NoError(t, r.RemovePodSandbox(ctx, sandboxID))
+}
+
+func TestDockerRuntime_ImagePullPolicy(t *testing.T) {
+	ctx := context.Background()
+	r, daemon := newFakeDockerRuntime(t)
+	pod := newExecTestPod()
+	_, err := r.CreatePodSandbox(ctx, pod)
+	require.NoError(t, err)
+	daemon.pulls = nil
+
+	tests := []struct {
+		name      string
+		container api.Container
+		wantPull  string
+		wantErr   string
+	}{
+		{name: "untagged images are always pulled as latest", container: api.Container{Image: "busybox"}, wantPull: "busybox:latest"},
+		{name: "present images aren't pulled again", container: api.Container{Image: "busybox:latest", ImagePullPolicy: "IfNotPresent"}},
+		{name: "Never fails on a missing image", container: api.Container{Image: "redis:7", ImagePullPolicy: "Never"}, wantErr: "not present"},
+		{name: "pull errors are reported", container: api.Container{Image: "missing:1"}, wantPull: "missing:1", wantErr: "manifest unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemon.pulls = nil
+			tt.container.Name = "app"
+			_, err := r.CreateContainer(ctx, pod, &tt.container, 0)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tt.wantPull != "" {
+				assert.Equal(t, []string{tt.wantPull}, daemon.pulls)
+			} else {
+				assert.Empty(t, daemon.pulls)
+			}
+		})
+	}
+}
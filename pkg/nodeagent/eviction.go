@@ -0,0 +1,157 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// PodReasonEvicted is the status reason of pods the agent evicted
+const PodReasonEvicted = "Evicted"
+
+// PodReasonDeadlineExceeded is the status reason of pods the agent killed
+// for running longer than their activeDeadlineSeconds
+const PodReasonDeadlineExceeded = "DeadlineExceeded"
+
+// enforceVolumeLimits evicts pods whose volumes use more space than their
+// size limit, or whose disk-backed emptyDir volumes together use more than
+// the pod's ephemeral-storage limit
+func (a *Agent) enforceVolumeLimits(ctx context.Context) {
+	a.mu.RLock()
+	podStates := make([]*PodState, 0, len(a.pods))
+	for _, podState := range a.pods {
+		podStates = append(podStates, podState)
+	}
+	a.mu.RUnlock()
+
+	for _, podState := range podStates {
+		volumes, err := a.volumeMgr.ListVolumes(ctx, podState.Pod)
+		if err != nil {
+			fmt.Printf("Error listing volumes of pod %s/%s: %v\n", podState.Pod.Namespace, podState.Pod.Name, err)
+			continue
+		}
+
+		var message string
+		for _, volume := range volumes {
+			if volume.Size > 0 && volume.Used > volume.Size {
+				message = fmt.Sprintf("Usage of %s volume %q (%d bytes) exceeds its size limit of %d bytes",
+					volume.Type, volume.Name, volume.Used, volume.Size)
+				break
+			}
+		}
+		if message == "" {
+			message = ephemeralStorageExceeded(podState.Pod, volumes)
+		}
+		if message != "" {
+			if err := a.evictPod(ctx, podState, message); err != nil {
+				fmt.Printf("Error evicting pod %s/%s: %v\n", podState.Pod.Namespace, podState.Pod.Name, err)
+			}
+		}
+	}
+}
+
+// ephemeralStorageExceeded returns why pod is evicted if its disk-backed
+// emptyDir volumes use more than its ephemeral-storage limit, or ""
+func ephemeralStorageExceeded(pod *api.Pod, volumes []*VolumeInfo) string {
+	limit, limited := api.PodLimit(pod, api.ResourceEphemeralStorage)
+	if !limited {
+		return ""
+	}
+	onDisk := make(map[string]bool)
+	for _, volume := range pod.Spec.Volumes {
+		if emptyDir := volume.VolumeSource.EmptyDir; emptyDir != nil && emptyDir.Medium != api.StorageMediumMemory {
+			onDisk[volume.Name] = true
+		}
+	}
+	var used int64
+	for _, volume := range volumes {
+		if onDisk[volume.Name] {
+			used += volume.Used
+		}
+	}
+	if float64(used) <= limit {
+		return ""
+	}
+	return fmt.Sprintf("Pod ephemeral local storage usage (%d bytes) exceeds the total limit of its containers of %.0f bytes", used, limit)
+}
+
+// enforceActiveDeadlines kills running pods that have been active for
+// longer than their activeDeadlineSeconds
+func (a *Agent) enforceActiveDeadlines(ctx context.Context) {
+	a.mu.RLock()
+	var expired []*PodState
+	now := time.Now()
+	for _, podState := range a.pods {
+		deadline := podState.Pod.Spec.ActiveDeadlineSeconds
+		started := podState.Status.StartTime
+		phase := podState.Status.Phase
+		if deadline == nil || started == nil || phase == api.PodFailed || phase == api.PodSucceeded {
+			continue
+		}
+		if now.Sub(*started) >= time.Duration(*deadline)*time.Second {
+			expired = append(expired, podState)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, podState := range expired {
+		pod := podState.Pod
+		message := fmt.Sprintf("Pod was active on the node longer than the specified deadline of %ds", *pod.Spec.ActiveDeadlineSeconds)
+		fmt.Printf("Killing pod %s/%s: %s\n", pod.Namespace, pod.Name, message)
+		if err := a.failPod(ctx, podState, PodReasonDeadlineExceeded, message); err != nil {
+			fmt.Printf("Error killing pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+// evictPod tears a pod down and marks it failed so it isn't restarted on this node
+func (a *Agent) evictPod(ctx context.Context, podState *PodState, message string) error {
+	pod := podState.Pod
+	fmt.Printf("Evicting pod %s/%s: %s\n", pod.Namespace, pod.Name, message)
+	return a.failPod(ctx, podState, PodReasonEvicted, message)
+}
+
+// failPod tears a pod down and marks it failed with the given reason so it
+// isn't restarted on this node
+func (a *Agent) failPod(ctx context.Context, podState *PodState, reason, message string) error {
+	pod := podState.Pod
+
+	if err := a.deletePod(ctx, pod.Namespace, pod.Name); err != nil {
+		return err
+	}
+	return a.markPodFailed(ctx, pod, reason, message)
+}
+
+// markPodFailed marks pod Failed with the given reason, unless it was
+// deleted or replaced by a pod of the same name
+func (a *Agent) markPodFailed(ctx context.Context, pod *api.Pod, reason, message string) error {
+	obj, err := a.store.Get(ctx, "Pod", pod.Namespace, pod.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	current, ok := obj.(*api.Pod)
+	if !ok || current.UID != pod.UID {
+		return nil
+	}
+
+	updated := *current
+	updated.Status.Phase = api.PodFailed
+	updated.Status.Reason = reason
+	updated.Status.Message = message
+	if err := a.store.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update pod status: %w", err)
+	}
+	return nil
+}
+
+// isPodTerminated reports whether a pod has finished and must not be started again
+func isPodTerminated(pod *api.Pod) bool {
+	return pod.Status.Phase == api.PodFailed || pod.Status.Phase == api.PodSucceeded
+}
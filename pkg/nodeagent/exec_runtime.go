@@ -0,0 +1,563 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// ExecRuntimeConfig holds the configuration for the exec runtime
+type ExecRuntimeConfig struct {
+	// RootDir holds a working directory and the container logs of each pod sandbox
+	RootDir string
+}
+
+// ExecRuntime runs containers as plain host processes, for dev machines and
+// CI hosts without a container runtime. Images are neither pulled nor
+// unpacked: every container must set a command, which is looked up on the
+// host. There is no isolation; processes share the host's filesystem,
+// network and users, and volumes aren't mounted at their container paths.
+// Output goes to a log file per container. Memory limits are applied as
+// process resource limits where the host supports them; CPU limits are
+// ignored, as there are no cgroups to enforce them.
+type ExecRuntime struct {
+	rootDir string
+
+	mu         sync.Mutex
+	nextID     uint64
+	containers map[string]*execContainer
+	sandboxes  map[string]*PodSandboxStatus
+}
+
+// execContainer is a container of the exec runtime and its process
+type execContainer struct {
+	status *ContainerStatus
+	spec   *api.Container
+	dir    string
+	cmd    *exec.Cmd
+	logs   string
+	done   chan struct{}
+}
+
+// NewExecRuntime creates an exec runtime
+func NewExecRuntime(config *ExecRuntimeConfig) *ExecRuntime {
+	rootDir := config.RootDir
+	if rootDir == "" {
+		rootDir = filepath.Join(DefaultVolumeRootDir, "exec")
+	}
+
+	return &ExecRuntime{
+		rootDir:    rootDir,
+		containers: make(map[string]*execContainer),
+		sandboxes:  make(map[string]*PodSandboxStatus),
+	}
+}
+
+// GetNodeCapacity returns the host's CPUs and, where they can be read,
+// memory, 2MiB huge pages, and the size of the filesystem holding the root
+// directory as ephemeral storage
+func (r *ExecRuntime) GetNodeCapacity() (api.ResourceList, error) {
+	capacity := api.ResourceList{
+		api.ResourceCPU: strconv.Itoa(runtime.NumCPU()),
+	}
+	if memory := hostMemoryBytes(); memory > 0 {
+		capacity[api.ResourceMemory] = fmt.Sprintf("%dKi", memory/1024)
+	}
+	if hugePages, ok := hostHugePages2MiBytes(); ok {
+		capacity[api.ResourceHugePages2Mi] = fmt.Sprintf("%dMi", hugePages/(1024*1024))
+	}
+	if err := os.MkdirAll(r.rootDir, 0755); err == nil {
+		if storage := filesystemBytes(r.rootDir); storage > 0 {
+			capacity[api.ResourceEphemeralStorage] = fmt.Sprintf("%dKi", storage/1024)
+		}
+	}
+	return capacity, nil
+}
+
+// GetNodeInfo describes the host platform
+func (r *ExecRuntime) GetNodeInfo() (*api.NodeSystemInfo, error) {
+	return &api.NodeSystemInfo{
+		OSImage:                 runtime.GOOS,
+		ContainerRuntimeVersion: "exec://" + runtime.Version(),
+		OperatingSystem:         runtime.GOOS,
+		Architecture:            runtime.GOARCH,
+	}, nil
+}
+
+// CreateContainer records a container; its process is started by StartContainer
+func (r *ExecRuntime) CreateContainer(ctx context.Context, pod *api.Pod, container *api.Container, attempt uint32) (string, error) {
+	if len(container.Command) == 0 && len(container.Args) == 0 {
+		return "", fmt.Errorf("the exec runtime can't run image %s, container %s needs a command", container.Image, container.Name)
+	}
+
+	sandboxID := execSandboxID(pod)
+	dir := container.WorkingDir
+	if dir == "" {
+		dir = r.sandboxDir(sandboxID)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create working directory: %w", err)
+	}
+	if err := os.MkdirAll(r.logDir(sandboxID), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	logs := filepath.Join(r.logDir(sandboxID), container.Name+".log")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	containerID := fmt.Sprintf("exec-%d-%d", time.Now().UnixNano(), r.nextID)
+	r.containers[containerID] = &execContainer{
+		status: &ContainerStatus{
+			ID:        containerID,
+			Metadata:  &ContainerMetadata{Name: container.Name, Attempt: attempt},
+			State:     ContainerStateCreated,
+			CreatedAt: time.Now().UnixNano(),
+			Image:     &ImageSpec{Image: container.Image},
+			Labels:    NewContainerLabels(pod, container, attempt),
+			LogPath:   logs,
+		},
+		spec: container,
+		dir:  dir,
+		logs: logs,
+		done: make(chan struct{}),
+	}
+	return containerID, nil
+}
+
+// StartContainer starts the container's process. Command and args are joined
+// into the command line, as an image entrypoint isn't available. Stdout and
+// stderr are appended to the container's log file, so the output of earlier
+// runs is kept.
+func (r *ExecRuntime) StartContainer(ctx context.Context, containerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.containers[containerID]
+	if !exists {
+		return fmt.Errorf("container %s not found", containerID)
+	}
+	if c.status.State != ContainerStateCreated {
+		return fmt.Errorf("container %s was already started", containerID)
+	}
+
+	argv := append(append([]string{}, c.spec.Command...), c.spec.Args...)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = c.dir
+	cmd.Env = containerEnv(c.spec)
+	cmd.SysProcAttr = processAttributes()
+
+	logFile, err := os.OpenFile(c.logs, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	// The process keeps its own handle, so ours is closed once it's started
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Start(); err != nil {
+		c.status.State = ContainerStateExited
+		c.status.FinishedAt = time.Now().UnixNano()
+		c.status.ExitCode = -1
+		c.status.Reason = "StartError"
+		c.status.Message = err.Error()
+		close(c.done)
+		return fmt.Errorf("failed to start container %s: %w", containerID, err)
+	}
+
+	if err := applyResourceLimits(cmd.Process.Pid, c.spec); err != nil {
+		fmt.Printf("Failed to apply resource limits to container %s: %v\n", containerID, err)
+	}
+
+	c.cmd = cmd
+	c.status.State = ContainerStateRunning
+	c.status.StartedAt = time.Now().UnixNano()
+	go r.wait(c)
+	return nil
+}
+
+// wait records the exit of a container's process
+func (r *ExecRuntime) wait(c *execContainer) {
+	err := c.cmd.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.status.State = ContainerStateExited
+	c.status.FinishedAt = time.Now().UnixNano()
+	c.status.ExitCode = int32(c.cmd.ProcessState.ExitCode())
+	if c.status.ExitCode == 0 {
+		c.status.Reason = "Completed"
+	} else {
+		c.status.Reason = "Error"
+		if err != nil {
+			c.status.Message = err.Error()
+		}
+	}
+	close(c.done)
+}
+
+// StopContainer asks the container's process to exit and kills it if it's
+// still running after timeout seconds
+func (r *ExecRuntime) StopContainer(ctx context.Context, containerID string, timeout int64) error {
+	r.mu.Lock()
+	c, exists := r.containers[containerID]
+	var running bool
+	if exists {
+		running = c.status.State == ContainerStateRunning
+	}
+	r.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("container %s not found", containerID)
+	}
+	if !running {
+		return nil
+	}
+
+	if err := terminateProcess(c.cmd.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		fmt.Printf("Failed to terminate container %s: %v\n", containerID, err)
+	}
+
+	select {
+	case <-c.done:
+		return nil
+	case <-time.After(time.Duration(timeout) * time.Second):
+	case <-ctx.Done():
+	}
+
+	if err := killProcess(c.cmd.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill container %s: %w", containerID, err)
+	}
+	<-c.done
+	return nil
+}
+
+// RemoveContainer kills the container's process if it's still running and
+// forgets the container
+func (r *ExecRuntime) RemoveContainer(ctx context.Context, containerID string) error {
+	if err := r.StopContainer(ctx, containerID, 0); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.containers, containerID)
+	return nil
+}
+
+// GetContainerStatus gets the status of a container
+func (r *ExecRuntime) GetContainerStatus(ctx context.Context, containerID string) (*ContainerStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.containers[containerID]
+	if !exists {
+		return nil, fmt.Errorf("container %s not found", containerID)
+	}
+	status := *c.status
+	return &status, nil
+}
+
+// ContainerStats measures a running container's process. Only the process
+// itself and the children it has reaped are counted.
+func (r *ExecRuntime) ContainerStats(ctx context.Context, containerID string) (*ContainerStats, error) {
+	r.mu.Lock()
+	c, exists := r.containers[containerID]
+	running := exists && c.status.State == ContainerStateRunning && c.cmd != nil
+	var pid int
+	if running {
+		pid = c.cmd.Process.Pid
+	}
+	r.mu.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("container %s not found", containerID)
+	}
+	if !running {
+		return nil, fmt.Errorf("container %s is not running", containerID)
+	}
+
+	cpu, memory, err := processStats(pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats of container %s: %w", containerID, err)
+	}
+	return &ContainerStats{Timestamp: time.Now(), CPUUsageNanoseconds: cpu, MemoryBytes: memory}, nil
+}
+
+// ListContainers lists containers
+func (r *ExecRuntime) ListContainers(ctx context.Context, filter *ContainerFilter) ([]*ContainerStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var containers []*ContainerStatus
+	for _, c := range r.containers {
+		if filter != nil {
+			if filter.ID != "" && c.status.ID != filter.ID {
+				continue
+			}
+			if filter.State != nil && c.status.State != *filter.State {
+				continue
+			}
+			if !matchesLabels(c.status.Labels, filter.LabelSelector) {
+				continue
+			}
+		}
+		status := *c.status
+		containers = append(containers, &status)
+	}
+	return containers, nil
+}
+
+// CheckpointContainer copies the container's working directory to dir. The
+// process keeps running, so files it's writing may be caught midway.
+func (r *ExecRuntime) CheckpointContainer(ctx context.Context, containerID, dir string) error {
+	r.mu.Lock()
+	c, exists := r.containers[containerID]
+	r.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("container %s not found", containerID)
+	}
+	if err := copyDir(c.dir, dir); err != nil {
+		return fmt.Errorf("failed to checkpoint container %s: %w", containerID, err)
+	}
+	return nil
+}
+
+// RestoreContainer copies a snapshot into the container's working directory
+// and creates the container
+func (r *ExecRuntime) RestoreContainer(ctx context.Context, pod *api.Pod, container *api.Container, attempt uint32, dir string) (string, error) {
+	workDir := container.WorkingDir
+	if workDir == "" {
+		workDir = r.sandboxDir(execSandboxID(pod))
+	}
+	if err := copyDir(dir, workDir); err != nil {
+		return "", fmt.Errorf("failed to restore container %s: %w", container.Name, err)
+	}
+	return r.CreateContainer(ctx, pod, container, attempt)
+}
+
+// Exec runs a command on the host with the container's environment and
+// working directory. Terminals aren't supported, so TTY requests get plain
+// streams.
+func (r *ExecRuntime) Exec(ctx context.Context, containerID string, req *ExecRequest) (int, error) {
+	r.mu.Lock()
+	c, exists := r.containers[containerID]
+	var running bool
+	if exists {
+		running = c.status.State == ContainerStateRunning
+	}
+	r.mu.Unlock()
+
+	if !exists {
+		return -1, fmt.Errorf("container %s not found", containerID)
+	}
+	if !running {
+		return -1, fmt.Errorf("container %s is not running", containerID)
+	}
+	if len(req.Cmd) == 0 {
+		return -1, fmt.Errorf("no command given")
+	}
+
+	cmd := exec.CommandContext(ctx, req.Cmd[0], req.Cmd[1:]...)
+	cmd.Dir = c.dir
+	cmd.Env = containerEnv(c.spec)
+	cmd.Stdin = req.Stdin
+	cmd.Stdout = req.Stdout
+	cmd.Stderr = req.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
+// Attach isn't supported, as container processes are started without
+// streams to connect to
+func (r *ExecRuntime) Attach(ctx context.Context, containerID string, req *AttachRequest) error {
+	return fmt.Errorf("attach is not supported by the exec runtime")
+}
+
+// PullImage does nothing; the exec runtime doesn't use images
+func (r *ExecRuntime) PullImage(ctx context.Context, image string, auth *ImageAuth) error {
+	return nil
+}
+
+// RemoveImage fails, as the exec runtime has no images
+func (r *ExecRuntime) RemoveImage(ctx context.Context, imageID string) error {
+	return fmt.Errorf("image %s not found", imageID)
+}
+
+// ListImages returns no images
+func (r *ExecRuntime) ListImages(ctx context.Context, filter *ImageFilter) ([]*Image, error) {
+	return nil, nil
+}
+
+// CreatePodSandbox creates the pod's working directory. Pods use the host's
+// network, so the sandbox reports the loopback address.
+func (r *ExecRuntime) CreatePodSandbox(ctx context.Context, pod *api.Pod) (string, error) {
+	sandboxID := execSandboxID(pod)
+	if err := os.MkdirAll(r.sandboxDir(sandboxID), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sandboxes[sandboxID] = &PodSandboxStatus{
+		ID: sandboxID,
+		Metadata: &PodSandboxMetadata{
+			Name:      pod.Name,
+			UID:       pod.UID,
+			Namespace: pod.Namespace,
+		},
+		State:     PodSandboxStateReady,
+		CreatedAt: time.Now().UnixNano(),
+		Network:   &PodSandboxNetworkStatus{IP: "127.0.0.1"},
+		Labels:    NewSandboxLabels(pod),
+	}
+	return sandboxID, nil
+}
+
+// RemovePodSandbox removes the pod's working directory and container logs
+func (r *ExecRuntime) RemovePodSandbox(ctx context.Context, podSandboxID string) error {
+	r.mu.Lock()
+	delete(r.sandboxes, podSandboxID)
+	r.mu.Unlock()
+
+	if err := os.RemoveAll(r.sandboxDir(podSandboxID)); err != nil {
+		return fmt.Errorf("failed to remove sandbox directory: %w", err)
+	}
+	if err := os.RemoveAll(r.logDir(podSandboxID)); err != nil {
+		return fmt.Errorf("failed to remove sandbox logs: %w", err)
+	}
+	return nil
+}
+
+// GetPodStatus gets the status of a pod sandbox
+func (r *ExecRuntime) GetPodStatus(ctx context.Context, podSandboxID string) (*PodSandboxStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sandbox, exists := r.sandboxes[podSandboxID]; exists {
+		return sandbox, nil
+	}
+	return nil, fmt.Errorf("pod sandbox %s not found", podSandboxID)
+}
+
+// ListPodSandboxes lists pod sandboxes
+func (r *ExecRuntime) ListPodSandboxes(ctx context.Context, filter *PodSandboxFilter) ([]*PodSandboxStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sandboxes []*PodSandboxStatus
+	for _, sandbox := range r.sandboxes {
+		if filter != nil {
+			if filter.ID != "" && sandbox.ID != filter.ID {
+				continue
+			}
+			if filter.State != nil && sandbox.State != *filter.State {
+				continue
+			}
+			if !matchesLabels(sandbox.Labels, filter.LabelSelector) {
+				continue
+			}
+		}
+		sandboxes = append(sandboxes, sandbox)
+	}
+	return sandboxes, nil
+}
+
+// sandboxDir returns the working directory of a pod sandbox
+func (r *ExecRuntime) sandboxDir(sandboxID string) string {
+	return filepath.Join(r.rootDir, "pods", sandboxID)
+}
+
+// logDir returns the directory holding the container logs of a pod sandbox
+func (r *ExecRuntime) logDir(sandboxID string) string {
+	return filepath.Join(r.rootDir, "logs", sandboxID)
+}
+
+// execSandboxID returns the sandbox ID of a pod, which stays the same for
+// every container so they share a working directory
+func execSandboxID(pod *api.Pod) string {
+	if pod.UID != "" {
+		return pod.UID
+	}
+	return pod.Namespace + "_" + pod.Name
+}
+
+// containerEnv returns the host environment with the container's variables
+// added; the host's PATH is kept so commands can be found
+func containerEnv(container *api.Container) []string {
+	env := os.Environ()
+	for _, v := range container.Env {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
+
+// copyDir copies the regular files and directories under src into dst,
+// keeping their permissions. Other file types, such as sockets, are skipped.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return nil
+		}
+	})
+}
+
+// copyFile copies a regular file, replacing target
+func copyFile(src, target string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
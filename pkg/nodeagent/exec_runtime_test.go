@@ -0,0 +1,244 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExecTestPod() *api.Pod {
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "pod-uid"},
+	}
+}
+
+func waitForContainerState(t *testing.T, r *ExecRuntime, containerID string, state ContainerState) *ContainerStatus {
+	t.Helper()
+	var status *ContainerStatus
+	require.Eventually(t, func() bool {
+		var err error
+		status, err = r.GetContainerStatus(context.Background(), containerID)
+		return err == nil && status.State == state
+	}, 5*time.Second, 10*time.Millisecond)
+	return status
+}
+
+func TestExecRuntime_RunToCompletion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	ctx := context.Background()
+	r := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+	pod := newExecTestPod()
+
+	sandboxID, err := r.CreatePodSandbox(ctx, pod)
+	require.NoError(t, err)
+
+	containerID, err := r.CreateContainer(ctx, pod, &api.Container{
+		Name:    "writer",
+		Command: []string{"sh", "-c"},
+		Args:    []string{`echo "$GREETING" > out.txt; echo started; echo failing >&2; exit 3`},
+		Env:     []api.EnvVar{{Name: "GREETING", Value: "hello"}},
+	}, 0)
+	require.NoError(t, err)
+	require.NoError(t, r.StartContainer(ctx, containerID))
+
+	status := waitForContainerState(t, r, containerID, ContainerStateExited)
+	assert.Equal(t, int32(3), status.ExitCode)
+	assert.Equal(t, "Error", status.Reason)
+
+	// Containers run in the sandbox directory
+	data, err := os.ReadFile(filepath.Join(r.sandboxDir(sandboxID), "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(data))
+
+	// Output is captured in the container's log file
+	logs, err := os.ReadFile(status.LogPath)
+	require.NoError(t, err)
+	assert.Equal(t, "started\nfailing\n", string(logs))
+
+	require.NoError(t, r.RemoveContainer(ctx, containerID))
+	require.NoError(t, r.RemovePodSandbox(ctx, sandboxID))
+	assert.NoDirExists(t, r.sandboxDir(sandboxID))
+	assert.NoFileExists(t, status.LogPath)
+}
+
+func TestExecRuntime_StopAndExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	ctx := context.Background()
+	r := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+	pod := newExecTestPod()
+
+	_, err := r.CreatePodSandbox(ctx, pod)
+	require.NoError(t, err)
+	containerID, err := r.CreateContainer(ctx, pod, &api.Container{
+		Name:    "sleeper",
+		Command: []string{"sleep", "60"},
+		Env:     []api.EnvVar{{Name: "ROLE", Value: "sleeper"}},
+	}, 0)
+	require.NoError(t, err)
+	require.NoError(t, r.StartContainer(ctx, containerID))
+	waitForContainerState(t, r, containerID, ContainerStateRunning)
+
+	var stdout bytes.Buffer
+	code, err := r.Exec(ctx, containerID, &ExecRequest{
+		Cmd:    []string{"sh", "-c", `echo "$ROLE"; exit 2`},
+		Stdout: &stdout,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "sleeper\n", stdout.String())
+
+	if runtime.GOOS == "linux" {
+		stats, err := r.ContainerStats(ctx, containerID)
+		require.NoError(t, err)
+		assert.NotZero(t, stats.MemoryBytes)
+	}
+
+	start := time.Now()
+	require.NoError(t, r.StopContainer(ctx, containerID, 10))
+	assert.Less(t, time.Since(start), 5*time.Second, "sleep should exit on SIGTERM")
+	waitForContainerState(t, r, containerID, ContainerStateExited)
+
+	_, err = r.ContainerStats(ctx, containerID)
+	assert.Error(t, err, "stopped containers have no stats")
+}
+
+func TestExecRuntime_RequiresCommand(t *testing.T) {
+	r := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+
+	_, err := r.CreateContainer(context.Background(), newExecTestPod(), &api.Container{Name: "web", Image: "nginx:1.25"}, 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "needs a command")
+}
+
+func TestExecRuntime_NodeInfo(t *testing.T) {
+	r := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+
+	info, err := r.GetNodeInfo()
+	require.NoError(t, err)
+	assert.Equal(t, runtime.GOOS, info.OperatingSystem)
+	assert.Equal(t, runtime.GOARCH, info.Architecture)
+
+	capacity, err := r.GetNodeCapacity()
+	require.NoError(t, err)
+	assert.NotEmpty(t, capacity[api.ResourceCPU])
+}
+
+func TestAgent_ContainerExitsFinishPod(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := newExecTestPod()
+	pod.Spec = api.PodSpec{
+		NodeName: "test-node",
+		Containers: []api.Container{
+			{Name: "ok", Command: []string{"true"}},
+			{Name: "broken", Command: []string{"sh", "-c", "exit 1"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          st,
+		CRIRuntime:     NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()}),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+	})
+	require.NoError(t, agent.syncPod(ctx, pod))
+
+	podState := agent.pods["default/test-pod"]
+	require.Eventually(t, func() bool {
+		require.NoError(t, agent.syncPodStatus(ctx, pod, podState))
+		return podState.Status.Phase != api.PodRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, api.PodFailed, podState.Status.Phase)
+	require.Len(t, podState.Status.ContainerStatuses, 2)
+	assert.Equal(t, int32(0), podState.Status.ContainerStatuses[0].State.Terminated.ExitCode)
+	assert.Equal(t, int32(1), podState.Status.ContainerStatuses[1].State.Terminated.ExitCode)
+	for _, condition := range podState.Status.Conditions {
+		if condition.Type == "Ready" {
+			assert.Equal(t, api.ConditionFalse, condition.Status)
+		}
+	}
+}
+
+func TestAgent_CheckpointRestore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+	checkpointDir := t.TempDir()
+
+	pod := newExecTestPod()
+	pod.Annotations = map[string]string{api.AnnotationCheckpointRestore: "true"}
+	pod.Spec = api.PodSpec{
+		NodeName: "test-node",
+		Containers: []api.Container{{
+			Name:    "app",
+			Command: []string{"sh", "-c", "test -f state || echo cold > state; sleep 60"},
+		}},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	newAgent := func(r *ExecRuntime) *Agent {
+		return NewAgent(&Config{
+			NodeName:       "test-node",
+			Store:          st,
+			CRIRuntime:     r,
+			NetworkManager: &MockNetworkManager{},
+			VolumeManager:  &MockVolumeManager{},
+			CheckpointDir:  checkpointDir,
+		})
+	}
+
+	first := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+	agent := newAgent(first)
+	require.NoError(t, agent.syncPod(ctx, pod))
+	sandboxDir := first.sandboxDir(execSandboxID(pod))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(sandboxDir, "state"))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(sandboxDir, "state"), []byte("warm\n"), 0o644))
+
+	// Shutting down snapshots the pod; the reboot loses its processes and files
+	agent.mu.Lock()
+	agent.snapshotPods(ctx)
+	agent.mu.Unlock()
+	podState := agent.pods["default/test-pod"]
+	require.NoError(t, first.StopContainer(ctx, podState.Containers["app"].ID, 0))
+	require.NoError(t, first.RemovePodSandbox(ctx, podState.SandboxID))
+
+	second := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+	agent = newAgent(second)
+	agent.restoreCheckpoints(ctx)
+	require.NoError(t, agent.syncPod(ctx, pod))
+
+	data, err := os.ReadFile(filepath.Join(second.sandboxDir(execSandboxID(pod)), "state"))
+	require.NoError(t, err)
+	assert.Equal(t, "warm\n", string(data))
+	assert.False(t, agent.checkpoints.HasSnapshot(pod.UID), "snapshots are restored once")
+
+	require.NoError(t, agent.deletePod(ctx, pod.Namespace, pod.Name))
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package nodeagent
+
+import (
+	"os"
+	"syscall"
+)
+
+// processAttributes starts container processes in their own process group,
+// so stopping a container also stops the processes it spawned
+func processAttributes() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcess asks the process group to exit
+func terminateProcess(process *os.Process) error {
+	if err := syscall.Kill(-process.Pid, syscall.SIGTERM); err != nil {
+		return process.Signal(syscall.SIGTERM)
+	}
+	return nil
+}
+
+// killProcess kills the process group
+func killProcess(process *os.Process) error {
+	if err := syscall.Kill(-process.Pid, syscall.SIGKILL); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package nodeagent
+
+import (
+	"os"
+	"syscall"
+)
+
+// processAttributes starts container processes in a new process group
+func processAttributes() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminateProcess kills the process, as Windows has no signal asking a
+// process to exit
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+// killProcess kills the process
+func killProcess(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,130 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultContainerGCInterval is how often the agent looks for leaked containers
+const DefaultContainerGCInterval = time.Minute
+
+// containerGCLoop periodically removes runtime containers whose pod no longer exists
+func (a *Agent) containerGCLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.containerGCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			if err := a.garbageCollectContainers(ctx); err != nil {
+				fmt.Printf("Error garbage collecting containers: %v\n", err)
+			}
+		}
+	}
+}
+
+// garbageCollectContainers removes containers and sandboxes labeled with a pod UID
+// that is neither assigned to this node nor tracked locally. Runtime objects without
+// a pod UID label weren't created by the agent and are left alone. Every
+// runtime handler's runtime is collected along with the default one.
+// The logs kept of replaced containers of those pods are removed too.
+func (a *Agent) garbageCollectContainers(ctx context.Context) error {
+	desired, err := a.desiredPodUIDs(ctx)
+	if err != nil {
+		// Without a reliable desired set everything would look leaked
+		return err
+	}
+
+	var errs []error
+	for _, runtime := range a.runtimes() {
+		if err := garbageCollectRuntime(ctx, runtime, desired); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := a.removePreviousLogs(desired); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// garbageCollectRuntime removes the containers and sandboxes of runtime
+// whose pod UID isn't desired
+func garbageCollectRuntime(ctx context.Context, runtime CRIRuntime, desired map[string]bool) error {
+	containers, err := runtime.ListContainers(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var errs []error
+	for _, container := range containers {
+		uid := container.Labels[LabelPodUID]
+		if uid == "" || desired[uid] {
+			continue
+		}
+
+		fmt.Printf("Removing orphaned container %s of pod %s/%s\n",
+			container.ID, container.Labels[LabelPodNamespace], container.Labels[LabelPodName])
+		if container.State == ContainerStateRunning {
+			if err := runtime.StopContainer(ctx, container.ID, defaultStopTimeout); err != nil {
+				errs = append(errs, fmt.Errorf("failed to stop container %s: %w", container.ID, err))
+				continue
+			}
+		}
+		if err := runtime.RemoveContainer(ctx, container.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove container %s: %w", container.ID, err))
+		}
+	}
+
+	sandboxes, err := runtime.ListPodSandboxes(ctx, nil)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list pod sandboxes: %w", err))
+		return errors.Join(errs...)
+	}
+
+	for _, sandbox := range sandboxes {
+		uid := sandbox.Labels[LabelPodUID]
+		if uid == "" || desired[uid] {
+			continue
+		}
+
+		fmt.Printf("Removing orphaned sandbox %s of pod %s/%s\n",
+			sandbox.ID, sandbox.Labels[LabelPodNamespace], sandbox.Labels[LabelPodName])
+		if err := runtime.RemovePodSandbox(ctx, sandbox.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove sandbox %s: %w", sandbox.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// desiredPodUIDs returns the UIDs of pods assigned to this node plus those tracked locally
+func (a *Agent) desiredPodUIDs(ctx context.Context) (map[string]bool, error) {
+	desired := make(map[string]bool)
+
+	pods, err := store.ListByIndex(ctx, a.store, "Pod", store.AllNamespaces, store.IndexNodeName, a.nodeName)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, obj := range pods {
+		if pod, ok := obj.(*api.Pod); ok && pod.Spec.NodeName == a.nodeName {
+			desired[pod.UID] = true
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, podState := range a.pods {
+		desired[podState.Pod.UID] = true
+	}
+
+	return desired, nil
+}
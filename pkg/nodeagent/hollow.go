@@ -0,0 +1,125 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultHollowNodePrefix is the name prefix of hollow nodes
+const DefaultHollowNodePrefix = "hollow-node"
+
+// HollowNodeConfig holds the configuration of a hollow node provisioner
+type HollowNodeConfig struct {
+	Store store.Store
+	// NamePrefix is followed by a number in the names of created nodes
+	NamePrefix string
+	// NodeLabels are set on every hollow node
+	NodeLabels        map[string]string
+	HeartbeatInterval time.Duration
+}
+
+// HollowNodeProvisioner provisions hollow nodes: node agents run in process
+// with the mock runtime, which register and report like real nodes but
+// don't run anything. It implements the cluster autoscaler's
+// NodeProvisioner, so autoscaling can be tried out on a single machine.
+type HollowNodeProvisioner struct {
+	mu     sync.Mutex
+	config HollowNodeConfig
+	agents map[string]*Agent
+	next   int
+}
+
+// NewHollowNodeProvisioner creates a hollow node provisioner
+func NewHollowNodeProvisioner(config *HollowNodeConfig) *HollowNodeProvisioner {
+	cfg := *config
+	if cfg.NamePrefix == "" {
+		cfg.NamePrefix = DefaultHollowNodePrefix
+	}
+
+	return &HollowNodeProvisioner{
+		config: cfg,
+		agents: make(map[string]*Agent),
+	}
+}
+
+// CreateNode starts a hollow node under the first free name
+func (p *HollowNodeProvisioner) CreateNode(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var name string
+	for {
+		p.next++
+		name = fmt.Sprintf("%s-%d", p.config.NamePrefix, p.next)
+		if _, err := p.config.Store.Get(ctx, "Node", "", name); err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				return "", fmt.Errorf("failed to get node %s: %w", name, err)
+			}
+			break
+		}
+	}
+
+	agent := NewAgent(&Config{
+		NodeName:            name,
+		Store:               p.config.Store,
+		CRIRuntime:          NewMockCRIRuntime(),
+		NetworkManager:      &MockNetworkManager{},
+		VolumeManager:       &MockVolumeManager{},
+		HeartbeatInterval:   p.config.HeartbeatInterval,
+		ContainerGCInterval: -1,
+		NodeLabels:          p.config.NodeLabels,
+	})
+	// The agent outlives the request that created it; Stop ends it
+	if err := agent.Start(context.Background()); err != nil {
+		return "", fmt.Errorf("failed to start node agent: %w", err)
+	}
+	p.agents[name] = agent
+	return name, nil
+}
+
+// DeleteNode stops a hollow node's agent and deletes its node
+func (p *HollowNodeProvisioner) DeleteNode(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	agent, ok := p.agents[name]
+	if !ok {
+		return fmt.Errorf("node %s is not a hollow node", name)
+	}
+	agent.Stop()
+	delete(p.agents, name)
+
+	if err := p.config.Store.Delete(ctx, "Node", "", name); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to delete node %s: %w", name, err)
+	}
+	return nil
+}
+
+// Nodes returns the names of the running hollow nodes
+func (p *HollowNodeProvisioner) Nodes(ctx context.Context) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.agents))
+	for name := range p.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Stop stops every hollow node's agent, leaving their nodes in place
+func (p *HollowNodeProvisioner) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, agent := range p.agents {
+		agent.Stop()
+	}
+}
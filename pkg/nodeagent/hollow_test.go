@@ -0,0 +1,49 @@
+package nodeagent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHollowNodeProvisioner(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore(store.DefaultOptions())
+
+	// A node already holding the first name is skipped
+	require.NoError(t, s.Create(ctx, &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "hollow-node-1"},
+	}))
+
+	p := NewHollowNodeProvisioner(&HollowNodeConfig{Store: s, NodeLabels: map[string]string{"pool": "hollow"}})
+	defer p.Stop()
+
+	name, err := p.CreateNode(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hollow-node-2", name)
+
+	obj, err := s.Get(ctx, "Node", "", name)
+	require.NoError(t, err)
+	node := obj.(*api.Node)
+	assert.Equal(t, "hollow", node.Labels["pool"])
+	require.NotEmpty(t, node.Status.Conditions)
+	assert.Equal(t, api.ConditionTrue, node.Status.Conditions[0].Status)
+
+	names, err := p.Nodes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{name}, names)
+
+	require.NoError(t, p.DeleteNode(ctx, name))
+	_, err = s.Get(ctx, "Node", "", name)
+	assert.Error(t, err)
+	names, err = p.Nodes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	assert.Error(t, p.DeleteNode(ctx, "hollow-node-1"), "only hollow nodes can be deleted")
+}
@@ -0,0 +1,207 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+)
+
+// DefaultCredentialsDir is where the node agent keeps the credentials it
+// obtained by joining the cluster
+const DefaultCredentialsDir = defaultStateDir + string(filepath.Separator) + "pki"
+
+// Files in the credentials directory
+const (
+	credentialsTokenFile = "node.token"
+	credentialsCAFile    = "ca.crt"
+)
+
+// joinTimeout bounds each request made while joining
+const joinTimeout = 30 * time.Second
+
+// Credentials authenticate the node agent to the API server
+type Credentials struct {
+	// Token authenticates the node as system:node:<name>
+	Token string
+	// CACert is the PEM CA bundle the API server's certificate chains to
+	CACert []byte
+}
+
+// JoinConfig describes how to join a cluster
+type JoinConfig struct {
+	// Server is the API server URL
+	Server string
+	// Token is the bootstrap token
+	Token string
+	// NodeName is the name the node registers with
+	NodeName string
+	// CACertHash pins the cluster CA, as printed by cli admin init; required
+	// for https servers unless CACert is given
+	CACertHash string
+	// CACert is the PEM cluster CA, if already known
+	CACert []byte
+}
+
+// ParseJoinTarget splits a join argument of the form <token>@<server>. A
+// server without a scheme is assumed to be served over https.
+func ParseJoinTarget(value string) (token, server string, err error) {
+	token, server, ok := strings.Cut(value, "@")
+	if !ok || server == "" {
+		return "", "", fmt.Errorf("expected <token>@<server>, got %q", value)
+	}
+	if _, err := auth.ParseBootstrapToken(token); err != nil {
+		return "", "", err
+	}
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	return token, strings.TrimSuffix(server, "/"), nil
+}
+
+// Join registers the node with a bootstrap token and returns the credentials
+// the API server issued for it. The server's CA is fetched and checked
+// against the pinned hash before the token is sent.
+func Join(ctx context.Context, config *JoinConfig) (*Credentials, error) {
+	caCert := config.CACert
+	if strings.HasPrefix(config.Server, "https://") && len(caCert) == 0 {
+		if config.CACertHash == "" {
+			return nil, fmt.Errorf("joining an https server requires the CA certificate hash")
+		}
+		fetched, err := fetchCACert(ctx, config.Server, config.CACertHash)
+		if err != nil {
+			return nil, err
+		}
+		caCert = fetched
+	}
+
+	transport, err := newTransport(caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(api.NodeJoinRequest{NodeName: config.NodeName})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, joinTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.Server+api.BootstrapJoinPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("failed to join: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var joined api.NodeJoinResponse
+	if err := json.NewDecoder(resp.Body).Decode(&joined); err != nil {
+		return nil, fmt.Errorf("failed to decode join response: %w", err)
+	}
+	if joined.CACert != "" {
+		caCert = []byte(joined.CACert)
+	}
+	return &Credentials{Token: joined.Token, CACert: caCert}, nil
+}
+
+// fetchCACert downloads the cluster CA without verifying the server and
+// checks it against the pinned hash. Later requests only trust this CA.
+func fetchCACert(ctx context.Context, server, hash string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, joinTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+api.BootstrapCAPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The CA isn't trusted yet; it is checked against the hash below
+	insecure := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	resp, err := (&http.Client{Transport: insecure}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the cluster CA: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the cluster CA: %s", resp.Status)
+	}
+	caCert, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the cluster CA: %w", err)
+	}
+
+	if err := auth.VerifyCACertHash(caCert, hash); err != nil {
+		return nil, err
+	}
+	return caCert, nil
+}
+
+// newTransport returns a transport trusting caCert, or the default transport
+// if caCert is empty
+func newTransport(caCert []byte) (http.RoundTripper, error) {
+	if len(caCert) == 0 {
+		return http.DefaultTransport, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in the cluster CA")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return transport, nil
+}
+
+// Transport returns a transport trusting the cluster CA
+func (c *Credentials) Transport() (http.RoundTripper, error) {
+	return newTransport(c.CACert)
+}
+
+// LoadCredentials reads credentials saved by Save. An error satisfying
+// errors.Is(err, os.ErrNotExist) means the node hasn't joined yet.
+func LoadCredentials(dir string) (*Credentials, error) {
+	token, err := os.ReadFile(filepath.Join(dir, credentialsTokenFile))
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := os.ReadFile(filepath.Join(dir, credentialsCAFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return &Credentials{Token: strings.TrimSpace(string(token)), CACert: caCert}, nil
+}
+
+// Save writes the credentials to dir, readable only by the node agent's user
+func (c *Credentials) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, credentialsTokenFile), []byte(c.Token+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save node token: %w", err)
+	}
+	if len(c.CACert) > 0 {
+		if err := os.WriteFile(filepath.Join(dir, credentialsCAFile), c.CACert, 0644); err != nil {
+			return fmt.Errorf("failed to save cluster CA: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package nodeagent
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJoinServer serves the bootstrap endpoints over TLS, accepting token
+func newJoinServer(t *testing.T, token string) (*httptest.Server, []byte) {
+	var caCert []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case api.BootstrapCAPath:
+			w.Write(caCert)
+		case api.BootstrapJoinPath:
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var req api.NodeJoinRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			json.NewEncoder(w).Encode(api.NodeJoinResponse{Token: "node-token-for-" + req.NodeName, CACert: string(caCert)})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// The test server's certificate is self-signed, so it is its own CA
+	caCert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, caCert
+}
+
+func TestParseJoinTarget(t *testing.T) {
+	token, server, err := ParseJoinTarget("abcdef.0123456789abcdef@master.lab:8080")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef.0123456789abcdef", token)
+	assert.Equal(t, "https://master.lab:8080", server)
+
+	_, server, err = ParseJoinTarget("abcdef.0123456789abcdef@http://localhost:8080/")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080", server)
+
+	for _, value := range []string{"master.lab:8080", "abcdef.0123456789abcdef@", "secret@master.lab"} {
+		_, _, err := ParseJoinTarget(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	const token = "abcdef.0123456789abcdef"
+	server, caCert := newJoinServer(t, token)
+	hash, err := auth.CACertHash(caCert)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	credentials, err := Join(ctx, &JoinConfig{Server: server.URL, Token: token, NodeName: "worker-1", CACertHash: hash})
+	require.NoError(t, err)
+	assert.Equal(t, "node-token-for-worker-1", credentials.Token)
+	assert.Equal(t, caCert, credentials.CACert)
+
+	// The CA must match the pinned hash
+	otherCA, _, err := auth.GenerateCA("other-ca")
+	require.NoError(t, err)
+	otherHash, err := auth.CACertHash(otherCA)
+	require.NoError(t, err)
+	_, err = Join(ctx, &JoinConfig{Server: server.URL, Token: token, NodeName: "worker-1", CACertHash: otherHash})
+	assert.Error(t, err)
+
+	// An https server can't be joined without pinning its CA
+	_, err = Join(ctx, &JoinConfig{Server: server.URL, Token: token, NodeName: "worker-1"})
+	assert.Error(t, err)
+
+	_, err = Join(ctx, &JoinConfig{Server: server.URL, Token: "ghijkl.0123456789abcdef", NodeName: "worker-1", CACertHash: hash})
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestCredentials_SaveLoad(t *testing.T) {
+	dir := t.TempDir() + "/pki"
+	_, err := LoadCredentials(dir)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	credentials := &Credentials{Token: "node-token", CACert: []byte("ca")}
+	require.NoError(t, credentials.Save(dir))
+
+	loaded, err := LoadCredentials(dir)
+	require.NoError(t, err)
+	assert.Equal(t, credentials, loaded)
+}
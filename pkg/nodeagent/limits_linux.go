@@ -0,0 +1,34 @@
+//go:build linux
+
+package nodeagent
+
+import (
+	"fmt"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"golang.org/x/sys/unix"
+)
+
+// applyResourceLimits caps the data segment of a container process at its
+// memory limit. The limit is set just after the process starts and isn't
+// inherited by processes it already spawned, so it's best-effort.
+func applyResourceLimits(pid int, container *api.Container) error {
+	memory, ok := container.Resources.Limits[api.ResourceMemory]
+	if !ok {
+		return nil
+	}
+
+	bytes, err := parseQuantity(memory)
+	if err != nil {
+		return fmt.Errorf("invalid memory limit %q: %w", memory, err)
+	}
+	if bytes <= 0 {
+		return nil
+	}
+
+	limit := &unix.Rlimit{Cur: uint64(bytes), Max: uint64(bytes)}
+	if err := unix.Prlimit(pid, unix.RLIMIT_DATA, limit, nil); err != nil {
+		return fmt.Errorf("failed to set memory limit: %w", err)
+	}
+	return nil
+}
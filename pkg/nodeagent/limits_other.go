@@ -0,0 +1,11 @@
+//go:build !linux
+
+package nodeagent
+
+import "github.com/minik8s/minik8s/pkg/api"
+
+// applyResourceLimits does nothing, as limits can't be set on another
+// process on this host
+func applyResourceLimits(pid int, container *api.Container) error {
+	return nil
+}
@@ -0,0 +1,224 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// DefaultContainerLogDir is where the node agent keeps the logs of containers
+// it replaced, to serve them as previous logs
+const DefaultContainerLogDir = defaultStateDir + string(filepath.Separator) + "logs"
+
+// DefaultContainerLogMaxSize is the size past which a container log is rotated
+const DefaultContainerLogMaxSize = 10 * 1024 * 1024
+
+// DefaultContainerLogMaxFiles is how many rotated files of a container log are kept
+const DefaultContainerLogMaxFiles = 5
+
+// rotateContainerLogs rotates the log file of every container on this node
+// that outgrew the maximum size, and removes rotated files past the maximum
+// count or age. Containers whose runtime doesn't write a log file are skipped.
+func (a *Agent) rotateContainerLogs(ctx context.Context) {
+	if a.containerLogMaxSize < 0 && a.containerLogMaxAge <= 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, path := range a.containerLogPaths(ctx) {
+		if err := rotateLogFile(path, a.containerLogMaxSize, a.containerLogMaxFiles, a.containerLogMaxAge, now); err != nil {
+			fmt.Printf("Error rotating container log %s: %v\n", path, err)
+		}
+	}
+}
+
+// containerLogPaths returns the log files the runtimes write for the
+// containers of the pods on this node
+func (a *Agent) containerLogPaths(ctx context.Context) []string {
+	type container struct {
+		runtime CRIRuntime
+		id      string
+	}
+	var containers []container
+	a.mu.RLock()
+	for _, podState := range a.pods {
+		runtime := a.podRuntime(podState)
+		for _, state := range podState.Containers {
+			if state.ID != "" {
+				containers = append(containers, container{runtime: runtime, id: state.ID})
+			}
+		}
+	}
+	a.mu.RUnlock()
+
+	var paths []string
+	for _, c := range containers {
+		status, err := c.runtime.GetContainerStatus(ctx, c.id)
+		if err == nil && status.LogPath != "" {
+			paths = append(paths, status.LogPath)
+		}
+	}
+	return paths
+}
+
+// rotateLogFile copies path to path.1, shifting older rotated files up to
+// path.<maxFiles>, and truncates path once it's bigger than maxSize. The
+// runtime keeps appending to the file it opened, which is why the file is
+// copied and truncated rather than renamed; output written between the copy
+// and the truncation is lost. Rotated files older than maxAge are removed.
+func rotateLogFile(path string, maxSize int64, maxFiles int, maxAge time.Duration, now time.Time) error {
+	if maxSize >= 0 {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxSize {
+			if maxFiles > 0 {
+				if err := shiftRotatedLogs(path, maxFiles); err != nil {
+					return err
+				}
+				if err := copyFile(path, rotatedLogPath(path, 1), 0o644); err != nil {
+					return err
+				}
+			}
+			if err := os.Truncate(path, 0); err != nil {
+				return fmt.Errorf("failed to truncate log: %w", err)
+			}
+		}
+	}
+
+	if maxAge > 0 {
+		for i := 1; i <= maxFiles; i++ {
+			rotated := rotatedLogPath(path, i)
+			info, err := os.Stat(rotated)
+			if err != nil || now.Sub(info.ModTime()) <= maxAge {
+				continue
+			}
+			if err := os.Remove(rotated); err != nil {
+				return fmt.Errorf("failed to remove expired log: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// shiftRotatedLogs makes room for a new path.1 by renaming each rotated file
+// of path to the next index, dropping the one at maxFiles
+func shiftRotatedLogs(path string, maxFiles int) error {
+	if err := os.Remove(rotatedLogPath(path, maxFiles)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove oldest log: %w", err)
+	}
+	for i := maxFiles - 1; i >= 1; i-- {
+		err := os.Rename(rotatedLogPath(path, i), rotatedLogPath(path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to shift rotated log: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotatedLogPath returns the path of the i-th rotated file of a log
+func rotatedLogPath(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// preservePreviousLogs copies the current log of each of the pod's containers
+// to the container log dir before they're removed, replacing the log of the
+// containers before them
+func (a *Agent) preservePreviousLogs(ctx context.Context, podState *PodState) {
+	if a.containerLogDir == "" {
+		return
+	}
+
+	runtime := a.podRuntime(podState)
+	for name, state := range podState.Containers {
+		status, err := runtime.GetContainerStatus(ctx, state.ID)
+		if err != nil || status.LogPath == "" {
+			continue
+		}
+		previous := a.previousLogPath(podState.Pod.UID, name)
+		if err := os.MkdirAll(filepath.Dir(previous), 0o755); err != nil {
+			fmt.Printf("Error preserving log of container %s: %v\n", name, err)
+			continue
+		}
+		if err := copyFile(status.LogPath, previous, 0o644); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Error preserving log of container %s: %v\n", name, err)
+		}
+	}
+}
+
+// previousLogPath returns where the previous log of a pod's container is kept
+func (a *Agent) previousLogPath(podUID, container string) string {
+	return filepath.Join(a.containerLogDir, podUID, container+".log")
+}
+
+// previousLogFile returns the preserved log of the named container of a pod,
+// defaulting to the pod's first container. The pod may be between a teardown
+// and its recreation, so it's read from the store when not running here.
+func (a *Agent) previousLogFile(ctx context.Context, namespace, name, container string) (string, error) {
+	if a.containerLogDir == "" {
+		return "", fmt.Errorf("node %s doesn't keep previous container logs", a.nodeName)
+	}
+
+	a.mu.RLock()
+	var pod *api.Pod
+	if podState, exists := a.pods[fmt.Sprintf("%s/%s", namespace, name)]; exists {
+		pod = podState.Pod
+	}
+	a.mu.RUnlock()
+
+	if pod == nil {
+		obj, err := a.store.Get(ctx, "Pod", namespace, name)
+		if err != nil {
+			return "", fmt.Errorf("pod %s/%s not found: %w", namespace, name, err)
+		}
+		var ok bool
+		if pod, ok = obj.(*api.Pod); !ok || pod.Spec.NodeName != a.nodeName {
+			return "", fmt.Errorf("pod %s/%s is not running on node %s", namespace, name, a.nodeName)
+		}
+	}
+
+	if container == "" {
+		if len(pod.Spec.Containers) == 0 {
+			return "", fmt.Errorf("pod %s/%s has no containers", namespace, name)
+		}
+		container = pod.Spec.Containers[0].Name
+	}
+	path := a.previousLogPath(pod.UID, container)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("previous terminated container %s in pod %s/%s not found", container, namespace, name)
+	}
+	return path, nil
+}
+
+// removePreviousLogs removes the preserved logs of pods whose UID isn't desired
+func (a *Agent) removePreviousLogs(desired map[string]bool) error {
+	if a.containerLogDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(a.containerLogDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list previous logs: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || desired[entry.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(a.containerLogDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove previous logs of pod %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package nodeagent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	now := time.Now()
+
+	for _, content := range []string{"first run\n", "second run\n", "third run\n"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		require.NoError(t, rotateLogFile(path, 5, 2, 0, now))
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	data, err = os.ReadFile(rotatedLogPath(path, 1))
+	require.NoError(t, err)
+	assert.Equal(t, "third run\n", string(data))
+	data, err = os.ReadFile(rotatedLogPath(path, 2))
+	require.NoError(t, err)
+	assert.Equal(t, "second run\n", string(data))
+	assert.NoFileExists(t, rotatedLogPath(path, 3))
+
+	// Small logs are left alone, but expired rotated files are removed
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	old := now.Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(rotatedLogPath(path, 2), old, old))
+	require.NoError(t, rotateLogFile(path, 5, 2, time.Hour, now))
+	assert.FileExists(t, rotatedLogPath(path, 1))
+	assert.NoFileExists(t, rotatedLogPath(path, 2))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(data))
+}
+
+func TestAgent_ContainerLogs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+	logDir := t.TempDir()
+
+	pod := newExecTestPod()
+	pod.Spec = api.PodSpec{
+		NodeName:   "test-node",
+		Containers: []api.Container{{Name: "app", Command: []string{"sh", "-c", "echo first; sleep 60"}}},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:        "test-node",
+		Store:           st,
+		CRIRuntime:      NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()}),
+		NetworkManager:  &MockNetworkManager{},
+		VolumeManager:   &MockVolumeManager{},
+		ContainerLogDir: logDir,
+	})
+	defer agent.deletePod(ctx, "default", "test-pod")
+	server := httptest.NewServer(agent.serverHandler())
+	defer server.Close()
+
+	getLogs := func(query string) (int, string) {
+		resp, err := http.Get(server.URL + "/containerLogs/default/test-pod" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	require.NoError(t, agent.syncPods(ctx))
+	require.Eventually(t, func() bool {
+		_, logs := getLogs("")
+		return logs == "first\n"
+	}, 5*time.Second, 10*time.Millisecond)
+	status, _ := getLogs("?previous=true")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// Changing the pod recreates its containers, keeping the old log
+	obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	updated := *obj.(*api.Pod)
+	updated.Spec.Containers = []api.Container{{Name: "app", Command: []string{"sh", "-c", "echo second; sleep 60"}}}
+	require.NoError(t, st.Update(ctx, &updated))
+	require.NoError(t, agent.syncPods(ctx))
+
+	// The previous log can be read while the pod waits to be recreated
+	status, logs := getLogs("?previous=true&container=app")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "first\n", logs)
+
+	require.NoError(t, agent.syncPods(ctx))
+	require.Eventually(t, func() bool {
+		_, logs := getLogs("")
+		return logs == "second\n"
+	}, 5*time.Second, 10*time.Millisecond)
+	_, logs = getLogs("?previous=true")
+	assert.Equal(t, "first\n", logs)
+
+	status, logs = getLogs("?container=sidecar")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.True(t, strings.Contains(logs, "sidecar"), logs)
+
+	// The kept logs go away with the pod
+	require.NoError(t, st.Delete(ctx, "Pod", "default", "test-pod"))
+	require.NoError(t, agent.syncPods(ctx))
+	require.NoError(t, agent.garbageCollectContainers(ctx))
+	assert.NoDirExists(t, filepath.Join(logDir, pod.UID))
+}
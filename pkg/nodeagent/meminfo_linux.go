@@ -0,0 +1,61 @@
+//go:build linux
+
+package nodeagent
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hostMemoryBytes returns the host's total memory from /proc/meminfo, or 0
+// if it can't be read
+func hostMemoryBytes() int64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// hostHugePages2MiBytes returns the memory in the host's pool of 2MiB huge
+// pages from /proc/meminfo, and whether the host's default huge page size
+// is 2MiB
+func hostHugePages2MiBytes() (int64, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	var total int64
+	var size bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "HugePages_Total:":
+			total, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "Hugepagesize:":
+			size = fields[1] == "2048"
+		}
+	}
+	return total * 2 * 1024 * 1024, size
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package nodeagent
+
+// hostMemoryBytes returns 0, as there's no portable way to read the host's
+// memory; nodes on these hosts report CPU capacity only
+func hostMemoryBytes() int64 {
+	return 0
+}
+
+// hostHugePages2MiBytes reports no huge pages, which only Linux hosts have
+func hostHugePages2MiBytes() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package nodeagent
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// execMounter mounts filesystems using the mount(8) and umount(8) commands
+type execMounter struct{}
+
+// NewMounter returns a mounter backed by the host's mount utilities
+func NewMounter() Mounter {
+	return &execMounter{}
+}
+
+// Mount mounts source at target
+func (m *execMounter) Mount(source, target, fstype string, options []string) error {
+	args := []string{}
+	if fstype != "" {
+		args = append(args, "-t", fstype)
+	}
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	args = append(args, source, target)
+
+	if output, err := exec.Command("mount", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("mount %s at %s failed: %w: %s", source, target, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Unmount unmounts target
+func (m *execMounter) Unmount(target string) error {
+	if output, err := exec.Command("umount", target).CombinedOutput(); err != nil {
+		return fmt.Errorf("umount %s failed: %w: %s", target, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+
+package nodeagent
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// errMountUnsupported is returned for mounts on hosts without Linux mount semantics
+var errMountUnsupported = errors.New("mounts are only supported on linux")
+
+// unsupportedMounter rejects every mount, so volumes that need one, such as
+// memory-backed emptyDirs and NFS, fail clearly while plain directory
+// volumes keep working
+type unsupportedMounter struct{}
+
+// NewMounter returns a mounter that reports mounts as unsupported on this host
+func NewMounter() Mounter {
+	return &unsupportedMounter{}
+}
+
+// Mount always fails
+func (m *unsupportedMounter) Mount(source, target, fstype string, options []string) error {
+	return fmt.Errorf("mount %s at %s on %s: %w", source, target, runtime.GOOS, errMountUnsupported)
+}
+
+// Unmount always fails
+func (m *unsupportedMounter) Unmount(target string) error {
+	return fmt.Errorf("umount %s on %s: %w", target, runtime.GOOS, errMountUnsupported)
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package nodeagent
+
+// defaultStateDir holds the node agent's checkpoints and volumes
+const defaultStateDir = `C:\ProgramData\minik8s`
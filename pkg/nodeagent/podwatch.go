@@ -0,0 +1,63 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultPodSyncInterval is how often the agent syncs its pods when no
+// change to them triggers a sync sooner
+const DefaultPodSyncInterval = 10 * time.Second
+
+// watchPodChanges signals trigger whenever a pod change calls for a sync.
+// Whenever the watch fails, pods are listed again and trigger is signalled,
+// since changes may have been missed. Signals are coalesced, so a burst of
+// changes causes one sync.
+func (a *Agent) watchPodChanges(ctx context.Context, trigger chan<- struct{}) {
+	signal := func() {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+
+	reflector := &store.Reflector{
+		Name:    "nodeagent",
+		Store:   a.store,
+		Kind:    "Pod",
+		Replace: func([]store.Object) { signal() },
+		Apply: func(event store.WatchEvent) {
+			pod, isPod := event.Object.(*api.Pod)
+			if isPod && a.podChangeNeedsSync(event.Type, pod) {
+				signal()
+			}
+		},
+	}
+	reflector.Run(ctx, a.stopCh)
+}
+
+// podChangeNeedsSync reports whether a change to pod should be synced right
+// away: a pod newly assigned to this node, replaced by one of the same name,
+// marked terminating, or deleted or moved off it. Other changes, most of them
+// the agent's own status writes, wait for the periodic sync.
+func (a *Agent) podChangeNeedsSync(eventType store.EventType, pod *api.Pod) bool {
+	podKey := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+
+	a.mu.RLock()
+	podState, tracked := a.pods[podKey]
+	var trackedUID string
+	if tracked {
+		trackedUID = podState.Pod.UID
+	}
+	a.mu.RUnlock()
+
+	assigned := eventType != store.Deleted && pod.Spec.NodeName == a.nodeName
+	if !tracked {
+		return assigned && !isPodTerminated(pod)
+	}
+	return !assigned || trackedUID != pod.UID || pod.DeletionTimestamp != nil
+}
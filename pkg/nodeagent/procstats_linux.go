@@ -0,0 +1,64 @@
+//go:build linux
+
+package nodeagent
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// clockTicksPerSecond is the unit of CPU times in /proc/<pid>/stat. It's
+// 100 on every architecture Linux reports USER_HZ for.
+const clockTicksPerSecond = 100
+
+// processStats returns the CPU time used by a process and its reaped
+// children, and its resident memory, from /proc
+func processStats(pid int) (cpuNanoseconds, memoryBytes uint64, err error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name may contain spaces, so fields are counted from the
+	// parenthesis closing it
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed stat of process %d", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// utime, stime, cutime and cstime are fields 14 to 17 of the stat line
+	if len(fields) < 15 {
+		return 0, 0, fmt.Errorf("malformed stat of process %d", pid)
+	}
+	var ticks uint64
+	for _, field := range fields[11:15] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed stat of process %d: %w", pid, err)
+		}
+		ticks += value
+	}
+	cpuNanoseconds = ticks * (1e9 / clockTicksPerSecond)
+
+	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("malformed status of process %d: %w", pid, err)
+			}
+			memoryBytes = kb * 1024
+			break
+		}
+	}
+	return cpuNanoseconds, memoryBytes, nil
+}
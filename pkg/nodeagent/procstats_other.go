@@ -0,0 +1,10 @@
+//go:build !linux
+
+package nodeagent
+
+import "errors"
+
+// processStats isn't supported without /proc
+func processStats(pid int) (cpuNanoseconds, memoryBytes uint64, err error) {
+	return 0, 0, errors.New("process stats are only available on linux")
+}
@@ -0,0 +1,56 @@
+package nodeagent
+
+import (
+	"context"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// latestContainers returns the newest runtime container of each spec
+// container of the pod with the given UID, by container name. Containers
+// are told apart by the labels and attempt runtimes give them, so this
+// works without any state of the agent's own.
+func latestContainers(ctx context.Context, runtime CRIRuntime, podUID string) (map[string]*ContainerStatus, error) {
+	containers, err := runtime.ListContainers(ctx, &ContainerFilter{
+		LabelSelector: map[string]string{LabelPodUID: podUID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]*ContainerStatus)
+	for _, container := range containers {
+		name := container.Labels[LabelContainerName]
+		if name == "" {
+			continue
+		}
+		if current, ok := latest[name]; !ok || containerAttempt(container) > containerAttempt(current) {
+			latest[name] = container
+		}
+	}
+	return latest, nil
+}
+
+// nextAttempts returns the attempt each container of pod is created with:
+// one past the newest container the runtime still has for it, or past the
+// one the pod's status last reported, so restart counts carry on when the
+// pod's containers are replaced, even across agent restarts
+func nextAttempts(ctx context.Context, runtime CRIRuntime, pod *api.Pod) map[string]uint32 {
+	attempts := make(map[string]uint32)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil || status.State.Terminated != nil {
+			attempts[status.Name] = uint32(status.RestartCount) + 1
+		}
+	}
+
+	latest, err := latestContainers(ctx, runtime, pod.UID)
+	if err != nil {
+		return attempts
+	}
+	for name, container := range latest {
+		if next := containerAttempt(container) + 1; next > attempts[name] {
+			attempts[name] = next
+		}
+	}
+	return attempts
+}
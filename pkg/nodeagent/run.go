@@ -0,0 +1,162 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultRunNodeName is the node a locally run pod is placed on
+const DefaultRunNodeName = "local"
+
+// DefaultRunSyncInterval is how often a locally run pod is synced. It is
+// shorter than DefaultPodSyncInterval so status transitions show promptly.
+const DefaultRunSyncInterval = time.Second
+
+// RunPodConfig holds the configuration of RunPod
+type RunPodConfig struct {
+	// Store holds the pod while it runs; it should be private to the run,
+	// and defaults to a new memory store
+	Store         store.Store
+	CRIRuntime    CRIRuntime
+	VolumeManager VolumeManager
+	// NodeName defaults to DefaultRunNodeName
+	NodeName string
+	// SyncInterval defaults to DefaultRunSyncInterval
+	SyncInterval time.Duration
+	// Out receives a line per status transition; defaults to stdout
+	Out io.Writer
+}
+
+// RunPod runs pod on a node agent of its own, without an API server,
+// scheduler or controllers, and prints its status transitions. It returns
+// the pod once it succeeded or failed, or when ctx is done; either way its
+// containers and sandbox are removed first. It is meant for trying out
+// manifests and runtimes in isolation.
+func RunPod(ctx context.Context, pod *api.Pod, config *RunPodConfig) (*api.Pod, error) {
+	cfg := *config
+	if cfg.Store == nil {
+		cfg.Store = store.NewMemoryStore(nil)
+	}
+	if cfg.VolumeManager == nil {
+		cfg.VolumeManager = &MockVolumeManager{}
+	}
+	if cfg.NodeName == "" {
+		cfg.NodeName = DefaultRunNodeName
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = DefaultRunSyncInterval
+	}
+	if cfg.Out == nil {
+		cfg.Out = os.Stdout
+	}
+
+	// Fill in what the API server and scheduler would have
+	copied := *pod
+	pod = &copied
+	if pod.Namespace == "" {
+		pod.Namespace = "default"
+	}
+	if pod.UID == "" {
+		pod.UID = api.NewUID()
+	}
+	pod.Spec.NodeName = cfg.NodeName
+	pod.Status = api.PodStatus{Phase: api.PodPending}
+
+	watch, err := cfg.Store.WatchObject(ctx, "Pod", pod.Namespace, pod.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch pod: %w", err)
+	}
+	defer close(watch.Stop)
+	if err := cfg.Store.Create(ctx, pod); err != nil {
+		return nil, fmt.Errorf("failed to create pod: %w", err)
+	}
+
+	agent := NewAgent(&Config{
+		NodeName:            cfg.NodeName,
+		Store:               cfg.Store,
+		CRIRuntime:          cfg.CRIRuntime,
+		NetworkManager:      &MockNetworkManager{},
+		VolumeManager:       cfg.VolumeManager,
+		PodSyncInterval:     cfg.SyncInterval,
+		ContainerGCInterval: -1,
+	})
+	agentCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := agent.Start(agentCtx); err != nil {
+		return nil, fmt.Errorf("failed to start node agent: %w", err)
+	}
+
+	last := pod
+	printed := ""
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(cfg.Out, "%s %s/%s interrupted, stopping\n", time.Now().Format(time.TimeOnly), pod.Namespace, pod.Name)
+			done = true
+		case event, ok := <-watch.Events:
+			if !ok {
+				return nil, fmt.Errorf("watch of pod %s/%s ended", pod.Namespace, pod.Name)
+			}
+			if _, isPod := event.Object.(*api.Pod); !isPod || event.Type == store.Deleted {
+				continue
+			}
+			// Events carry the object the store keeps, so it's read from a
+			// copy
+			copied, err := store.DeepCopy(event.Object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy pod: %w", err)
+			}
+			current := copied.(*api.Pod)
+			last = current
+			if line := runStatusLine(current); line != printed {
+				fmt.Fprintf(cfg.Out, "%s %s/%s %s\n", time.Now().Format(time.TimeOnly), pod.Namespace, pod.Name, line)
+				printed = line
+			}
+			done = isPodTerminated(current)
+		}
+	}
+
+	// Stop the agent first so it doesn't sync the pod while it is torn
+	// down; the teardown outlives ctx
+	agent.Stop()
+	cancel()
+	if err := agent.deletePod(context.Background(), pod.Namespace, pod.Name); err != nil {
+		return last, fmt.Errorf("failed to remove pod: %w", err)
+	}
+	return last, nil
+}
+
+// runStatusLine summarizes the phase of pod and the states of its
+// containers, e.g. "Running app=Running sidecar=Terminated(1)"
+func runStatusLine(pod *api.Pod) string {
+	parts := []string{string(pod.Status.Phase)}
+	statuses := append([]api.ContainerStatus(nil), pod.Status.ContainerStatuses...)
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	for _, status := range statuses {
+		state := "Unknown"
+		switch {
+		case status.State.Running != nil:
+			state = "Running"
+		case status.State.Terminated != nil:
+			state = fmt.Sprintf("Terminated(%d)", status.State.Terminated.ExitCode)
+		case status.State.Waiting != nil:
+			state = fmt.Sprintf("Waiting(%s)", status.State.Waiting.Reason)
+		}
+		parts = append(parts, status.Name+"="+state)
+	}
+	if pod.Status.Reason != "" {
+		parts = append(parts, "reason="+pod.Status.Reason)
+	}
+	if pod.Status.Message != "" {
+		parts = append(parts, fmt.Sprintf("message=%q", pod.Status.Message))
+	}
+	return strings.Join(parts, " ")
+}
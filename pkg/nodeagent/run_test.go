@@ -0,0 +1,90 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRunTestPod(script string) *api.Pod {
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "hello"},
+		Spec: api.PodSpec{Containers: []api.Container{
+			{Name: "app", Command: []string{"sh", "-c", script}},
+		}},
+	}
+}
+
+func TestRunPod(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	tests := []struct {
+		name      string
+		script    string
+		wantPhase api.PodPhase
+		wantLast  string
+	}{
+		{name: "succeeded", script: "exit 0", wantPhase: api.PodSucceeded, wantLast: "default/hello Succeeded app=Terminated(0)"},
+		{name: "failed", script: "exit 3", wantPhase: api.PodFailed, wantLast: `default/hello Failed app=Terminated(3) message="1 of 1 containers failed"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+			var out bytes.Buffer
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			pod, err := RunPod(ctx, newRunTestPod(tt.script), &RunPodConfig{
+				CRIRuntime:   r,
+				SyncInterval: 50 * time.Millisecond,
+				Out:          &out,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPhase, pod.Status.Phase)
+			assert.Equal(t, DefaultRunNodeName, pod.Spec.NodeName)
+			assert.NotEmpty(t, pod.UID)
+
+			lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+			require.GreaterOrEqual(t, len(lines), 2, out.String())
+			assert.Contains(t, string(lines[0]), "default/hello Pending")
+			assert.Contains(t, string(lines[len(lines)-1]), tt.wantLast)
+
+			// The pod's sandbox is removed once it finished
+			sandboxes, err := r.ListPodSandboxes(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Empty(t, sandboxes)
+		})
+	}
+}
+
+func TestRunPod_Interrupted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	r := NewExecRuntime(&ExecRuntimeConfig{RootDir: t.TempDir()})
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	pod, err := RunPod(ctx, newRunTestPod("sleep 60"), &RunPodConfig{
+		CRIRuntime:   r,
+		SyncInterval: 50 * time.Millisecond,
+		Out:          &out,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, api.PodRunning, pod.Status.Phase)
+	assert.Contains(t, out.String(), "default/hello Running app=Running")
+	assert.Contains(t, out.String(), "default/hello interrupted, stopping")
+
+	containers, err := r.ListContainers(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+}
@@ -0,0 +1,67 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// resolveRuntimeHandler returns the runtime handler a pod runs with: that of
+// its RuntimeClass, or "" for the default runtime when it names none
+func (a *Agent) resolveRuntimeHandler(ctx context.Context, pod *api.Pod) (string, error) {
+	if pod.Spec.RuntimeClassName == "" {
+		return "", nil
+	}
+	obj, err := a.store.Get(ctx, "RuntimeClass", "", pod.Spec.RuntimeClassName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get RuntimeClass %s: %w", pod.Spec.RuntimeClassName, err)
+	}
+	runtimeClass, ok := obj.(*api.RuntimeClass)
+	if !ok {
+		return "", fmt.Errorf("object %s is not a RuntimeClass", pod.Spec.RuntimeClassName)
+	}
+	if _, err := a.runtimeFor(runtimeClass.Handler); err != nil {
+		return "", err
+	}
+	return runtimeClass.Handler, nil
+}
+
+// runtimeFor returns the runtime configured for a handler, or the default
+// runtime for ""
+func (a *Agent) runtimeFor(handler string) (CRIRuntime, error) {
+	if handler == "" {
+		return a.criRuntime, nil
+	}
+	runtime, ok := a.runtimeHandlers[handler]
+	if !ok {
+		return nil, fmt.Errorf("runtime handler %q is not configured on node %s", handler, a.nodeName)
+	}
+	return runtime, nil
+}
+
+// podRuntime returns the runtime a pod's containers live in. The handler was
+// checked when the pod was created, so it's always configured.
+func (a *Agent) podRuntime(podState *PodState) CRIRuntime {
+	if runtime, err := a.runtimeFor(podState.RuntimeHandler); err == nil {
+		return runtime
+	}
+	return a.criRuntime
+}
+
+// runtimes returns the default runtime followed by every handler's, in the
+// order of their names
+func (a *Agent) runtimes() []CRIRuntime {
+	handlers := make([]string, 0, len(a.runtimeHandlers))
+	for handler := range a.runtimeHandlers {
+		handlers = append(handlers, handler)
+	}
+	sort.Strings(handlers)
+
+	runtimes := []CRIRuntime{a.criRuntime}
+	for _, handler := range handlers {
+		runtimes = append(runtimes, a.runtimeHandlers[handler])
+	}
+	return runtimes
+}
@@ -0,0 +1,293 @@
+package nodeagent
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/auth"
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+)
+
+// DefaultServerAddress is where the agent serves streaming requests unless
+// told otherwise: loopback only, as the server runs commands in containers
+const DefaultServerAddress = "127.0.0.1"
+
+// startServer starts serving streaming requests for this node's containers.
+// Without a token only loopback addresses are served, since anyone reaching
+// the server could otherwise get a shell in any container.
+func (a *Agent) startServer() error {
+	if a.serverToken == "" && !isLoopbackAddress(a.serverAddress) {
+		return fmt.Errorf("serving streaming requests on %s requires a server token", a.serverAddress)
+	}
+
+	address := net.JoinHostPort(a.serverAddress, strconv.Itoa(a.serverPort))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	server := &http.Server{Handler: a.serverHandler()}
+	a.server = server
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error serving node agent API: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("Node agent serving streaming requests on %s\n", listener.Addr())
+	return nil
+}
+
+// serverHandler returns the routes served by the node agent
+func (a *Agent) serverHandler() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/exec/{namespace}/{name}", a.execHandler).Methods("GET", "POST")
+	router.HandleFunc("/attach/{namespace}/{name}", a.attachHandler).Methods("GET", "POST")
+	router.HandleFunc("/checkpoint/{namespace}/{name}", a.checkpointHandler).Methods("POST")
+	router.HandleFunc("/containerLogs/{namespace}/{name}", a.containerLogsHandler).Methods("GET")
+	if a.serverToken != "" {
+		router.Use(a.authenticate)
+	}
+	return router
+}
+
+// authenticate rejects requests without the server token. Only the API
+// server has it, so users go through its authentication and authorization.
+func (a *Agent) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.BearerToken(r)
+		if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(a.serverToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackAddress reports whether host only accepts local connections
+func isLoopbackAddress(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// execHandler runs a command in one of the pod's containers over an upgraded connection
+func (a *Agent) execHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	opts, err := remotecommand.ParseOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(opts.Command) == 0 {
+		http.Error(w, "command is required", http.StatusBadRequest)
+		return
+	}
+
+	runtime, containerID, err := a.findContainer(namespace, name, opts.Container)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	remotecommand.Serve(w, r, opts, func(ctx context.Context, streams remotecommand.Streams) (int, error) {
+		return runtime.Exec(ctx, containerID, &ExecRequest{
+			Cmd:    opts.Command,
+			Stdin:  streams.Stdin,
+			Stdout: streams.Stdout,
+			Stderr: streams.Stderr,
+			TTY:    opts.TTY,
+			Resize: streams.Resize,
+		})
+	})
+}
+
+// attachHandler connects to the main process of one of the pod's containers
+func (a *Agent) attachHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	opts, err := remotecommand.ParseOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	runtime, containerID, err := a.findContainer(namespace, name, opts.Container)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	spec := a.findContainerSpec(namespace, name, opts.Container)
+	if opts.Stdin && (spec == nil || !spec.Stdin) {
+		http.Error(w, "container was not started with stdin open, attach without -i", http.StatusBadRequest)
+		return
+	}
+	if opts.TTY && (spec == nil || !spec.TTY) {
+		http.Error(w, "container was not started with a TTY, attach without -t", http.StatusBadRequest)
+		return
+	}
+
+	remotecommand.Serve(w, r, opts, func(ctx context.Context, streams remotecommand.Streams) (int, error) {
+		err := runtime.Attach(ctx, containerID, &AttachRequest{
+			Stdin:  streams.Stdin,
+			Stdout: streams.Stdout,
+			Stderr: streams.Stderr,
+			TTY:    opts.TTY,
+			Resize: streams.Resize,
+		})
+		if err != nil {
+			return -1, err
+		}
+
+		// Report the process's exit code when attach ended because it exited
+		status, err := runtime.GetContainerStatus(ctx, containerID)
+		if err == nil && status.State == ContainerStateExited {
+			return int(status.ExitCode), nil
+		}
+		return 0, nil
+	})
+}
+
+// checkpointHandler snapshots the containers of a running pod that opted in
+// with the checkpoint-restore annotation, replacing any earlier snapshot
+func (a *Agent) checkpointHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	podKey := fmt.Sprintf("%s/%s", vars["namespace"], vars["name"])
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	podState, exists := a.pods[podKey]
+	if !exists || podState.Status.Phase != api.PodRunning {
+		http.Error(w, fmt.Sprintf("pod %s is not running on node %s", podKey, a.nodeName), http.StatusNotFound)
+		return
+	}
+	if !wantsCheckpointRestore(podState.Pod) {
+		http.Error(w, fmt.Sprintf("pod %s is not annotated with %s=true", podKey, api.AnnotationCheckpointRestore), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.snapshotPod(r.Context(), podState); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// containerLogsHandler serves the log of one of the pod's containers, or with
+// previous=true the log of the container it replaced
+func (a *Agent) containerLogsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	name := vars["name"]
+	query := r.URL.Query()
+	container := query.Get("container")
+
+	previous := false
+	if value := query.Get("previous"); value != "" {
+		var err error
+		if previous, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, fmt.Sprintf("invalid previous %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var path string
+	if previous {
+		var err error
+		if path, err = a.previousLogFile(r.Context(), namespace, name, container); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	} else {
+		runtime, containerID, err := a.findContainer(namespace, name, container)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		status, err := runtime.GetContainerStatus(r.Context(), containerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if status.LogPath == "" {
+			http.Error(w, "the container runtime doesn't keep logs of this container", http.StatusNotFound)
+			return
+		}
+		path = status.LogPath
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.Copy(w, file)
+}
+
+// findContainer returns the runtime and ID of a container of a pod running on
+// this node, defaulting to the pod's first container when none is named
+func (a *Agent) findContainer(namespace, name, container string) (CRIRuntime, string, error) {
+	podKey := fmt.Sprintf("%s/%s", namespace, name)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	podState, exists := a.pods[podKey]
+	if !exists {
+		return nil, "", fmt.Errorf("pod %s is not running on node %s", podKey, a.nodeName)
+	}
+
+	if container == "" {
+		if len(podState.Pod.Spec.Containers) == 0 {
+			return nil, "", fmt.Errorf("pod %s has no containers", podKey)
+		}
+		container = podState.Pod.Spec.Containers[0].Name
+	}
+
+	state, exists := podState.Containers[container]
+	if !exists || state.ID == "" {
+		return nil, "", fmt.Errorf("container %s not found in pod %s", container, podKey)
+	}
+	return a.podRuntime(podState), state.ID, nil
+}
+
+// findContainerSpec returns the spec of a container of a pod running on this
+// node, defaulting to the pod's first container when none is named
+func (a *Agent) findContainerSpec(namespace, name, container string) *api.Container {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	podState, exists := a.pods[fmt.Sprintf("%s/%s", namespace, name)]
+	if !exists || len(podState.Pod.Spec.Containers) == 0 {
+		return nil
+	}
+	if container == "" {
+		return &podState.Pod.Spec.Containers[0]
+	}
+	for i := range podState.Pod.Spec.Containers {
+		if podState.Pod.Spec.Containers[i].Name == container {
+			return &podState.Pod.Spec.Containers[i]
+		}
+	}
+	return nil
+}
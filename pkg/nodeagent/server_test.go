@@ -0,0 +1,177 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/remotecommand"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent_Exec(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{Name: "app", Image: "nginx:latest"},
+				{Name: "sidecar", Image: "busybox:latest"},
+			},
+		},
+	}
+	require.NoError(t, store.Create(ctx, pod))
+
+	runtime := NewMockCRIRuntime()
+	var execContainer string
+	runtime.SetExecHandler(func(ctx context.Context, containerID string, req *ExecRequest) (int, error) {
+		execContainer = containerID
+		data, err := io.ReadAll(req.Stdin)
+		if err != nil {
+			return -1, err
+		}
+		io.WriteString(req.Stdout, strings.ToUpper(string(data)))
+		io.WriteString(req.Stderr, strings.Join(req.Cmd, " "))
+		return 0, nil
+	})
+
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	})
+	require.NoError(t, agent.syncPods(ctx))
+
+	server := httptest.NewServer(agent.serverHandler())
+	defer server.Close()
+
+	agent.mu.RLock()
+	sidecarID := agent.pods["default/test-pod"].Containers["sidecar"].ID
+	agent.mu.RUnlock()
+
+	opts := &remotecommand.Options{Container: "sidecar", Command: []string{"tr", "a-z", "A-Z"}, Stdin: true, Stdout: true, Stderr: true}
+	var stdout, stderr bytes.Buffer
+	exitCode, err := remotecommand.Stream(ctx, server.URL+"/exec/default/test-pod?"+opts.Query().Encode(), nil, remotecommand.Streams{
+		Stdin:  strings.NewReader("hello"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "HELLO", stdout.String())
+	assert.Equal(t, "tr a-z A-Z", stderr.String())
+	assert.Equal(t, sidecarID, execContainer)
+
+	// Unknown containers are rejected before the connection is upgraded
+	opts.Container = "missing"
+	_, err = remotecommand.Stream(ctx, server.URL+"/exec/default/test-pod?"+opts.Query().Encode(), nil, remotecommand.Streams{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestAgent_Attach(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"},
+		Spec: api.PodSpec{
+			NodeName: "test-node",
+			Containers: []api.Container{
+				{Name: "shell", Image: "busybox:latest", Stdin: true},
+				{Name: "web", Image: "nginx:latest"},
+			},
+		},
+	}
+	require.NoError(t, store.Create(ctx, pod))
+
+	runtime := NewMockCRIRuntime()
+	runtime.SetAttachHandler(func(ctx context.Context, containerID string, req *AttachRequest) error {
+		_, err := io.Copy(req.Stdout, req.Stdin)
+		return err
+	})
+
+	agent := NewAgent(&Config{
+		NodeName:          "test-node",
+		APIServerURL:      "http://localhost:8080",
+		Store:             store,
+		CRIRuntime:        runtime,
+		NetworkManager:    &MockNetworkManager{},
+		VolumeManager:     &MockVolumeManager{},
+		HeartbeatInterval: 30 * time.Second,
+	})
+	require.NoError(t, agent.syncPods(ctx))
+
+	server := httptest.NewServer(agent.serverHandler())
+	defer server.Close()
+
+	opts := &remotecommand.Options{Stdin: true, Stdout: true, Stderr: true}
+	var stdout bytes.Buffer
+	exitCode, err := remotecommand.Stream(ctx, server.URL+"/attach/default/test-pod?"+opts.Query().Encode(), nil, remotecommand.Streams{
+		Stdin:  strings.NewReader("ls\n"),
+		Stdout: &stdout,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "ls\n", stdout.String())
+
+	// Containers started without stdin can't take input
+	opts.Container = "web"
+	_, err = remotecommand.Stream(ctx, server.URL+"/attach/default/test-pod?"+opts.Query().Encode(), nil, remotecommand.Streams{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stdin")
+}
+
+func TestAgent_ServerToken(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          store,
+		CRIRuntime:     NewMockCRIRuntime(),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+		ServerToken:    "node-secret",
+	})
+	server := httptest.NewServer(agent.serverHandler())
+	defer server.Close()
+
+	get := func(authorization string) int {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/containerLogs/default/missing", nil)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, get(""))
+	assert.Equal(t, http.StatusUnauthorized, get("Bearer wrong"))
+	assert.Equal(t, http.StatusNotFound, get("Bearer node-secret"), "requests with the token reach the handler")
+
+	// Without a token the agent only listens on loopback addresses
+	exposed := NewAgent(&Config{NodeName: "test-node", Store: store, ServerAddress: "0.0.0.0", ServerPort: 1})
+	assert.ErrorContains(t, exposed.startServer(), "requires a server token")
+}
@@ -0,0 +1,118 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// serviceEnv returns discovery environment variables for the services in the pod's
+// namespace. Only services that exist when the pod is created are visible.
+func (a *Agent) serviceEnv(ctx context.Context, pod *api.Pod) ([]api.EnvVar, error) {
+	if pod.Spec.EnableServiceLinks != nil && !*pod.Spec.EnableServiceLinks {
+		return nil, nil
+	}
+
+	objs, err := a.store.List(ctx, "Service", pod.Namespace)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	var services []*api.Service
+	for _, obj := range objs {
+		if service, ok := obj.(*api.Service); ok {
+			services = append(services, service)
+		}
+	}
+	return serviceEnvVars(services), nil
+}
+
+// serviceEnvVars renders {SVC}_SERVICE_HOST/{SVC}_SERVICE_PORT variables along with
+// Docker link style {SVC}_PORT_* variables for every service with a cluster IP
+func serviceEnvVars(services []*api.Service) []api.EnvVar {
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	var env []api.EnvVar
+	for _, service := range services {
+		ip := service.Spec.ClusterIP
+		if ip == "" || ip == api.ClusterIPNone || len(service.Spec.Ports) == 0 {
+			continue
+		}
+
+		prefix := serviceEnvName(service.Name)
+		first := service.Spec.Ports[0]
+		env = append(env,
+			api.EnvVar{Name: prefix + "_SERVICE_HOST", Value: ip},
+			api.EnvVar{Name: prefix + "_SERVICE_PORT", Value: fmt.Sprint(first.Port)},
+		)
+		for _, port := range service.Spec.Ports {
+			if port.Name != "" {
+				env = append(env, api.EnvVar{Name: prefix + "_SERVICE_PORT_" + serviceEnvName(port.Name), Value: fmt.Sprint(port.Port)})
+			}
+		}
+
+		env = append(env, api.EnvVar{Name: prefix + "_PORT", Value: serviceURL(ip, first)})
+		for _, port := range service.Spec.Ports {
+			protocol := servicePortProtocol(port)
+			portPrefix := fmt.Sprintf("%s_PORT_%d_%s", prefix, port.Port, protocol)
+			env = append(env,
+				api.EnvVar{Name: portPrefix, Value: serviceURL(ip, port)},
+				api.EnvVar{Name: portPrefix + "_PROTO", Value: strings.ToLower(protocol)},
+				api.EnvVar{Name: portPrefix + "_PORT", Value: fmt.Sprint(port.Port)},
+				api.EnvVar{Name: portPrefix + "_ADDR", Value: ip},
+			)
+		}
+	}
+	return env
+}
+
+// withServiceEnv returns a copy of the container with service variables added.
+// Variables the container defines itself take precedence.
+func withServiceEnv(container *api.Container, serviceEnv []api.EnvVar) *api.Container {
+	if len(serviceEnv) == 0 {
+		return container
+	}
+
+	defined := make(map[string]bool, len(container.Env))
+	for _, env := range container.Env {
+		defined[env.Name] = true
+	}
+
+	merged := *container
+	merged.Env = make([]api.EnvVar, 0, len(serviceEnv)+len(container.Env))
+	for _, env := range serviceEnv {
+		if !defined[env.Name] {
+			merged.Env = append(merged.Env, env)
+		}
+	}
+	merged.Env = append(merged.Env, container.Env...)
+	return &merged
+}
+
+// serviceEnvName converts a service or port name to an environment variable name
+func serviceEnvName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// servicePortProtocol returns the port's protocol, defaulting to TCP
+func servicePortProtocol(port api.ServicePort) string {
+	if port.Protocol == "" {
+		return api.ProtocolTCP
+	}
+	return strings.ToUpper(port.Protocol)
+}
+
+// serviceURL renders a Docker link style address such as tcp://10.96.0.10:53
+func serviceURL(ip string, port api.ServicePort) string {
+	return fmt.Sprintf("%s://%s:%d", strings.ToLower(servicePortProtocol(port)), ip, port.Port)
+}
@@ -0,0 +1,150 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// DefaultShutdownGracePeriod bounds how long Shutdown waits for the node's
+// pods to stop
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// PodReasonTerminated is the status reason of pods stopped because their
+// node shut down
+const PodReasonTerminated = "Terminated"
+
+// NodeReasonShutdown is the reason of the False Ready condition of a node
+// whose agent is shutting down
+const NodeReasonShutdown = "NodeShutdown"
+
+// Shutdown prepares the node for going away: it marks the node NotReady and
+// taints it so no new pods are scheduled to it, stops starting pods itself,
+// then stops the node's pods, giving each its termination grace period but
+// no more than the shutdown grace period in all, and marks them Failed. Pods
+// asking to be checkpointed are left running, so Stop snapshots them. Call
+// Stop afterwards.
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.running || a.shuttingDown {
+		a.mu.Unlock()
+		return nil
+	}
+	a.shuttingDown = true
+	a.mu.Unlock()
+
+	fmt.Printf("Shutting down node %s, stopping its pods within %v\n", a.nodeName, a.shutdownGracePeriod)
+
+	var errs []error
+	if err := a.markNodeShutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Containers still running when the grace period ends are killed, but
+	// the pods' statuses are written regardless
+	stopCtx, cancel := context.WithTimeout(ctx, a.shutdownGracePeriod)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, podState := range a.podsToShutDown() {
+		wg.Add(1)
+		go func(podState *PodState) {
+			defer wg.Done()
+			pod := podState.Pod
+			err := a.deletePod(stopCtx, pod.Namespace, pod.Name)
+			if err == nil {
+				err = a.markPodFailed(ctx, pod, PodReasonTerminated, "Pod was terminated in response to imminent node shutdown.")
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("pod %s/%s: %w", pod.Namespace, pod.Name, err))
+				mu.Unlock()
+			}
+		}(podState)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// isShuttingDown reports whether Shutdown started
+func (a *Agent) isShuttingDown() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.shuttingDown
+}
+
+// podsToShutDown returns the pods Shutdown stops
+func (a *Agent) podsToShutDown() []*PodState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var pods []*PodState
+	for _, podState := range a.pods {
+		if podState.Pod.Annotations[api.AnnotationCheckpointRestore] == "true" {
+			continue
+		}
+		pods = append(pods, podState)
+	}
+	return pods
+}
+
+// markNodeShutdown sets the node's Ready condition to False and adds the
+// shutdown taint
+func (a *Agent) markNodeShutdown(ctx context.Context) error {
+	now := time.Now()
+	a.mu.Lock()
+	for i := range a.nodeStatus.Conditions {
+		condition := &a.nodeStatus.Conditions[i]
+		if condition.Type != api.NodeReady {
+			continue
+		}
+		condition.Status = api.ConditionFalse
+		condition.Reason = NodeReasonShutdown
+		condition.Message = "Node agent is shutting down."
+		condition.LastHeartbeatTime = now
+		condition.LastTransitionTime = now
+	}
+	status := *a.nodeStatus
+	a.mu.Unlock()
+
+	obj, err := a.store.Get(ctx, "Node", "", a.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+	node, ok := obj.(*api.Node)
+	if !ok {
+		return fmt.Errorf("stored object is not a node")
+	}
+
+	node.Status = status
+	taint := api.Taint{Key: api.TaintNodeShutdown, Effect: api.TaintEffectNoSchedule, TimeAdded: &now}
+	if !hasTaint(node, taint) {
+		node.Spec.Taints = append(node.Spec.Taints, taint)
+	}
+	if err := a.store.Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to mark node shutting down: %w", err)
+	}
+	return nil
+}
+
+// removeShutdownTaint removes the taint Shutdown added to node, reporting
+// whether it had it
+func removeShutdownTaint(node *api.Node) bool {
+	kept := node.Spec.Taints[:0:0]
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != api.TaintNodeShutdown {
+			kept = append(kept, taint)
+		}
+	}
+	removed := len(kept) != len(node.Spec.Taints)
+	if removed {
+		node.Spec.Taints = kept
+	}
+	return removed
+}
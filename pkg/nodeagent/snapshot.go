@@ -0,0 +1,89 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// wantsCheckpointRestore reports whether a pod opted into snapshots with the
+// checkpoint-restore annotation
+func wantsCheckpointRestore(pod *api.Pod) bool {
+	return pod.Annotations[api.AnnotationCheckpointRestore] == "true"
+}
+
+// snapshotPod writes a snapshot of every container of the pod. The snapshot
+// is assembled next to its final location and renamed into place, so a
+// crash midway never leaves a partial snapshot to restore from.
+func (a *Agent) snapshotPod(ctx context.Context, podState *PodState) error {
+	if a.checkpoints == nil {
+		return fmt.Errorf("checkpointing is disabled on this node")
+	}
+	checkpointer, ok := a.podRuntime(podState).(ContainerCheckpointer)
+	if !ok {
+		return fmt.Errorf("the container runtime doesn't support checkpoints")
+	}
+
+	dir := a.checkpoints.SnapshotDir(podState.Pod.UID)
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return fmt.Errorf("failed to clear snapshot dir: %w", err)
+	}
+	for name, container := range podState.Containers {
+		if err := checkpointer.CheckpointContainer(ctx, container.ID, filepath.Join(tmp, name)); err != nil {
+			os.RemoveAll(tmp)
+			return fmt.Errorf("container %s: %w", name, err)
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return os.Rename(tmp, dir)
+}
+
+// snapshotPods snapshots every running pod that opted in. Called on shutdown,
+// with a.mu held.
+func (a *Agent) snapshotPods(ctx context.Context) {
+	for podKey, podState := range a.pods {
+		if !wantsCheckpointRestore(podState.Pod) || podState.Status.Phase != api.PodRunning {
+			continue
+		}
+		if err := a.snapshotPod(ctx, podState); err != nil {
+			fmt.Printf("Error snapshotting pod %s: %v\n", podKey, err)
+			continue
+		}
+		fmt.Printf("Snapshotted pod %s\n", podKey)
+	}
+}
+
+// restoreSnapshot returns the snapshot a pod's containers should be created
+// from in runtime, or "" to create them from scratch
+func (a *Agent) restoreSnapshot(pod *api.Pod, runtime CRIRuntime) string {
+	if a.checkpoints == nil || !wantsCheckpointRestore(pod) || pod.UID == "" {
+		return ""
+	}
+	if _, ok := runtime.(ContainerCheckpointer); !ok {
+		return ""
+	}
+	if !a.checkpoints.HasSnapshot(pod.UID) {
+		return ""
+	}
+	return a.checkpoints.SnapshotDir(pod.UID)
+}
+
+// createContainer creates one container of a pod, from its snapshot when
+// there is one
+func (a *Agent) createContainer(ctx context.Context, runtime CRIRuntime, pod *api.Pod, container *api.Container, attempt uint32, snapshot string) (string, error) {
+	if snapshot != "" {
+		dir := filepath.Join(snapshot, container.Name)
+		if _, err := os.Stat(dir); err == nil {
+			return runtime.(ContainerCheckpointer).RestoreContainer(ctx, pod, container, attempt, dir)
+		}
+	}
+	return runtime.CreateContainer(ctx, pod, container, attempt)
+}
@@ -0,0 +1,120 @@
+package nodeagent
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// DefaultStatusMaxStaleness is how long an unchanged node or pod status goes
+// without being written to the store
+const DefaultStatusMaxStaleness = 5 * time.Minute
+
+// statusReport remembers the last status written for an object, so unchanged
+// statuses aren't written again on every tick
+type statusReport struct {
+	data []byte
+	at   time.Time
+}
+
+// needsUpdate reports whether status differs from the last one written or
+// the last write is older than maxStaleness. A negative maxStaleness writes
+// every time.
+func (r *statusReport) needsUpdate(status []byte, now time.Time, maxStaleness time.Duration) bool {
+	if maxStaleness < 0 || r.data == nil {
+		return true
+	}
+	return !bytes.Equal(r.data, status) || now.Sub(r.at) >= maxStaleness
+}
+
+// record notes that status was written at now
+func (r *statusReport) record(status []byte, now time.Time) {
+	r.data = status
+	r.at = now
+}
+
+// nodeStatusKey encodes a node status for comparison. Heartbeat times change
+// on every heartbeat, so they're left out; the max-staleness refresh keeps
+// them reasonably current.
+func nodeStatusKey(status *api.NodeStatus) ([]byte, error) {
+	copied := *status
+	copied.Conditions = make([]api.NodeCondition, len(status.Conditions))
+	for i, condition := range status.Conditions {
+		condition.LastHeartbeatTime = time.Time{}
+		copied.Conditions[i] = condition
+	}
+	return json.Marshal(&copied)
+}
+
+// podStatusKey encodes a pod status for comparison. Usage samples change on
+// every sync, so they're left out; the max-staleness refresh keeps the
+// reported usage reasonably current.
+func podStatusKey(status *api.PodStatus) ([]byte, error) {
+	copied := *status
+	copied.ContainerStatuses = make([]api.ContainerStatus, len(status.ContainerStatuses))
+	for i, container := range status.ContainerStatuses {
+		container.Usage = nil
+		copied.ContainerStatuses[i] = container
+	}
+	return json.Marshal(&copied)
+}
+
+// syncReadinessGates copies the conditions named by the pod's readiness
+// gates from source, the stored status other controllers post them to, and
+// works the Ready condition of a running pod out again
+func syncReadinessGates(podState *PodState, source *api.PodStatus) {
+	spec := &podState.Pod.Spec
+	if len(spec.ReadinessGates) == 0 {
+		return
+	}
+	for _, gate := range spec.ReadinessGates {
+		if condition := api.GetPodCondition(source, gate.ConditionType); condition != nil {
+			api.SetPodCondition(podState.Status, *condition)
+		}
+	}
+	if podState.Status.Phase == api.PodRunning {
+		setPodReady(podState.Status, spec, time.Now())
+	}
+}
+
+// setPodReady sets the Ready condition of a pod whose containers are ready:
+// it's True once the conditions of all readiness gates are True too
+func setPodReady(status *api.PodStatus, spec *api.PodSpec, now time.Time) {
+	if !api.IsPodConditionTrue(status, api.PodConditionContainersReady) {
+		return
+	}
+	condition := api.PodCondition{Type: api.PodConditionReady, Status: api.ConditionTrue, LastTransitionTime: now}
+	if ready, message := api.PodReadinessGatesReady(spec, status); !ready {
+		condition.Status = api.ConditionFalse
+		condition.Reason = api.ReasonReadinessGatesNotReady
+		condition.Message = message
+	}
+	api.SetPodCondition(status, condition)
+}
+
+// trackStatusWrite records written, the pod the agent just stored its status
+// on, as the version of the pod it runs. Otherwise the next sync would take
+// the agent's own status write for a spec change and recreate the pod.
+// previous is the pod the status was written over; if its spec was edited
+// since the agent last synced the pod, the version isn't recorded, so the
+// next sync still applies the edit. podState.Pod came from the store, so it
+// is replaced rather than changed in place.
+func (a *Agent) trackStatusWrite(podState *PodState, previous, written *api.Pod) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	tracked := *podState.Pod
+	tracked.Status = written.Status
+	if previous.ResourceVersion == podState.Pod.ResourceVersion || statusOnlyChange(podState.Pod, previous) {
+		tracked.ResourceVersion = written.ResourceVersion
+	}
+	podState.Pod = &tracked
+}
+
+// statusOnlyChange reports whether updated differs from current only in its
+// status and metadata, so the pod's containers can keep running
+func statusOnlyChange(current, updated *api.Pod) bool {
+	return current.UID == updated.UID && reflect.DeepEqual(current.Spec, updated.Spec)
+}
@@ -0,0 +1,283 @@
+package nodeagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusReport_NeedsUpdate(t *testing.T) {
+	now := time.Now()
+	var report statusReport
+
+	assert.True(t, report.needsUpdate([]byte("a"), now, time.Minute), "nothing written yet")
+	report.record([]byte("a"), now)
+
+	assert.False(t, report.needsUpdate([]byte("a"), now.Add(30*time.Second), time.Minute))
+	assert.True(t, report.needsUpdate([]byte("b"), now.Add(30*time.Second), time.Minute), "status changed")
+	assert.True(t, report.needsUpdate([]byte("a"), now.Add(time.Minute), time.Minute), "status is stale")
+	assert.True(t, report.needsUpdate([]byte("a"), now, -1), "dedup disabled")
+}
+
+func TestAgent_SyncPodStatus_SkipsUnchanged(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "nginx:latest"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          st,
+		CRIRuntime:     NewMockCRIRuntime(),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+	})
+	require.NoError(t, agent.syncPod(ctx, pod))
+	podState := agent.pods["default/test-pod"]
+
+	resourceVersion := func() string {
+		obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+		require.NoError(t, err)
+		return obj.GetResourceVersion()
+	}
+
+	require.NoError(t, agent.syncPodStatus(ctx, pod, podState))
+	written := resourceVersion()
+
+	// Nothing changed, so nothing is written
+	require.NoError(t, agent.syncPodStatus(ctx, pod, podState))
+	assert.Equal(t, written, resourceVersion())
+
+	// A stale status is written again even though it didn't change
+	podState.report.at = podState.report.at.Add(-DefaultStatusMaxStaleness)
+	require.NoError(t, agent.syncPodStatus(ctx, pod, podState))
+	assert.NotEqual(t, written, resourceVersion())
+}
+
+func TestAgent_ReportNodeStatus_IgnoresHeartbeats(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          st,
+		CRIRuntime:     NewMockCRIRuntime(),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+	})
+	require.NoError(t, agent.initializeNodeStatus())
+	require.NoError(t, agent.registerNode(ctx))
+
+	resourceVersion := func() string {
+		obj, err := st.Get(ctx, "Node", "", "test-node")
+		require.NoError(t, err)
+		return obj.GetResourceVersion()
+	}
+
+	require.NoError(t, agent.reportNodeStatus(ctx))
+	written := resourceVersion()
+
+	require.NoError(t, agent.sendHeartbeat(ctx))
+	require.NoError(t, agent.reportNodeStatus(ctx))
+	assert.Equal(t, written, resourceVersion(), "a heartbeat alone isn't written")
+
+	agent.nodeStatus.Capacity[api.ResourceCPU] = "8"
+	require.NoError(t, agent.reportNodeStatus(ctx))
+	assert.NotEqual(t, written, resourceVersion())
+}
+
+func TestAgent_SyncPodStatus_KeepsUserSpecEdits(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "uid-1"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "nginx:1.25"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          st,
+		CRIRuntime:     NewMockCRIRuntime(),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+	})
+	require.NoError(t, agent.syncPod(ctx, pod))
+	podState := agent.pods["default/test-pod"]
+
+	// The user changes the image after the agent read the pod
+	edited := *pod
+	edited.Spec.Containers = []api.Container{{Name: "test", Image: "nginx:1.27"}}
+	require.NoError(t, st.Update(ctx, &edited))
+
+	require.NoError(t, agent.syncPodStatus(ctx, pod, podState))
+
+	obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	stored := obj.(*api.Pod)
+	assert.Equal(t, "nginx:1.27", stored.Spec.Containers[0].Image, "status report undid the user's edit")
+	assert.Equal(t, podState.Status.Phase, stored.Status.Phase)
+}
+
+func TestAgent_SyncPodStatus_ReadinessGates(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "uid-1"},
+		Spec: api.PodSpec{
+			NodeName:       "test-node",
+			Containers:     []api.Container{{Name: "test", Image: "nginx:1.25"}},
+			ReadinessGates: []api.PodReadinessGate{{ConditionType: "example.com/lb-ready"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:       "test-node",
+		Store:          st,
+		CRIRuntime:     NewMockCRIRuntime(),
+		NetworkManager: &MockNetworkManager{},
+		VolumeManager:  &MockVolumeManager{},
+	})
+	require.NoError(t, agent.syncPod(ctx, pod))
+	podState := agent.pods["default/test-pod"]
+	assert.True(t, api.IsPodConditionTrue(podState.Status, api.PodConditionContainersReady))
+	ready := api.GetPodCondition(podState.Status, api.PodConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, api.ConditionFalse, ready.Status)
+	assert.Equal(t, api.ReasonReadinessGatesNotReady, ready.Reason)
+
+	// A load balancer controller posts the gate's condition
+	obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	posted := *obj.(*api.Pod)
+	api.SetPodCondition(&posted.Status, api.PodCondition{Type: "example.com/lb-ready", Status: api.ConditionTrue, LastTransitionTime: time.Now()})
+	require.NoError(t, st.Update(ctx, &posted))
+
+	require.NoError(t, agent.syncPod(ctx, &posted))
+	assert.Same(t, podState, agent.pods["default/test-pod"], "status-only change recreated the pod")
+
+	obj, err = st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	stored := obj.(*api.Pod)
+	assert.True(t, api.IsPodConditionTrue(&stored.Status, api.PodConditionReady))
+	assert.True(t, api.IsPodConditionTrue(&stored.Status, "example.com/lb-ready"))
+}
+
+func TestAgent_SyncPods_KeepsRunningPods(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "uid-1"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "nginx:1.25"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	// Every sync writes the pod's status
+	agent := NewAgent(&Config{
+		NodeName:           "test-node",
+		Store:              st,
+		CRIRuntime:         NewMockCRIRuntime(),
+		NetworkManager:     &MockNetworkManager{},
+		VolumeManager:      &MockVolumeManager{},
+		StatusMaxStaleness: -1,
+	})
+	containerID := func() string {
+		agent.mu.RLock()
+		defer agent.mu.RUnlock()
+		podState, ok := agent.pods["default/test-pod"]
+		require.True(t, ok, "pod was torn down")
+		return podState.Containers["test"].ID
+	}
+
+	trackedVersion := func() string {
+		agent.mu.RLock()
+		defer agent.mu.RUnlock()
+		return agent.pods["default/test-pod"].Pod.ResourceVersion
+	}
+
+	require.NoError(t, agent.syncPods(ctx))
+	started := containerID()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, agent.syncPods(ctx))
+		assert.Equal(t, started, containerID(), "sync %d recreated the pod after its own status write", i+2)
+
+		obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+		require.NoError(t, err)
+		assert.Equal(t, obj.GetResourceVersion(), trackedVersion(), "the agent's own status write isn't tracked")
+	}
+}
+
+func TestAgent_SyncPods_AppliesSpecEditsMadeBeforeStatusWrite(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "uid-1"},
+		Spec: api.PodSpec{
+			NodeName:   "test-node",
+			Containers: []api.Container{{Name: "test", Image: "nginx:1"}},
+		},
+	}
+	require.NoError(t, st.Create(ctx, pod))
+
+	agent := NewAgent(&Config{
+		NodeName:           "test-node",
+		Store:              st,
+		CRIRuntime:         NewMockCRIRuntime(),
+		NetworkManager:     &MockNetworkManager{},
+		VolumeManager:      &MockVolumeManager{},
+		StatusMaxStaleness: -1,
+	})
+	require.NoError(t, agent.syncPods(ctx))
+	podState := agent.pods["default/test-pod"]
+
+	// The user changes the image after the agent listed the pod but before
+	// it writes the pod's status
+	obj, err := st.Get(ctx, "Pod", "default", "test-pod")
+	require.NoError(t, err)
+	edited := *obj.(*api.Pod)
+	edited.Spec.Containers = []api.Container{{Name: "test", Image: "nginx:2"}}
+	require.NoError(t, st.Update(ctx, &edited))
+	require.NoError(t, agent.writePodStatus(ctx, podState))
+
+	// The next syncs run the edited spec
+	require.NoError(t, agent.syncPods(ctx))
+	require.NoError(t, agent.syncPods(ctx))
+	agent.mu.RLock()
+	defer agent.mu.RUnlock()
+	require.Contains(t, agent.pods, "default/test-pod")
+	assert.Equal(t, "nginx:2", agent.pods["default/test-pod"].Pod.Spec.Containers[0].Image)
+}
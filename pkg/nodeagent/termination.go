@@ -0,0 +1,100 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// PodReasonTerminating is the reason of the False Ready condition of a pod
+// whose containers are being stopped because it was deleted
+const PodReasonTerminating = "PodTerminating"
+
+// startTermination stops a deleted pod in the background, since its
+// containers may take their whole grace period to exit, unless that's
+// already underway
+func (a *Agent) startTermination(ctx context.Context, pod *api.Pod) {
+	a.mu.Lock()
+	if a.terminating[pod.UID] {
+		a.mu.Unlock()
+		return
+	}
+	a.terminating[pod.UID] = true
+	a.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			delete(a.terminating, pod.UID)
+			a.mu.Unlock()
+		}()
+		if err := a.terminatePod(ctx, pod); err != nil {
+			fmt.Printf("Error terminating pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		}
+	}()
+}
+
+// terminatePod carries out the node's part of deleting a pod: it stops the
+// containers within the grace period the pod was deleted with, reports how
+// they exited through the pod's status, tears the pod down, and only then
+// removes it from the store
+func (a *Agent) terminatePod(ctx context.Context, pod *api.Pod) error {
+	podKey := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+
+	a.mu.Lock()
+	podState, exists := a.pods[podKey]
+	if exists && podState.Pod.UID == pod.UID {
+		podState.Pod = pod
+	}
+	a.mu.Unlock()
+
+	if exists && podState.Pod.UID == pod.UID {
+		fmt.Printf("Pod %s was deleted, stopping its containers within %ds\n", podKey, pod.TerminationGracePeriod())
+		runtime := a.podRuntime(podState)
+		for name, container := range podState.Containers {
+			if err := runtime.StopContainer(ctx, container.ID, pod.TerminationGracePeriod()); err != nil {
+				fmt.Printf("Error stopping container %s of pod %s: %v\n", name, podKey, err)
+			}
+		}
+
+		// Confirm the containers stopped before the pod goes away
+		if err := a.updateContainerStatuses(ctx, podState); err != nil {
+			fmt.Printf("Error reading container statuses of pod %s: %v\n", podKey, err)
+		}
+		for _, conditionType := range []api.PodConditionType{api.PodConditionContainersReady, api.PodConditionReady} {
+			api.SetPodCondition(podState.Status, api.PodCondition{Type: conditionType, Status: api.ConditionFalse, Reason: PodReasonTerminating, LastTransitionTime: time.Now()})
+		}
+		if err := a.writePodStatus(ctx, podState); err != nil && !errors.Is(err, store.ErrNotFound) {
+			fmt.Printf("Error writing final status of pod %s: %v\n", podKey, err)
+		}
+	}
+
+	// Also tears down a different pod of the same name the agent still runs
+	if err := a.deletePod(ctx, pod.Namespace, pod.Name); err != nil {
+		return err
+	}
+	return a.removeTerminatedPod(ctx, pod)
+}
+
+// removeTerminatedPod removes a deleted pod from the store once its node
+// stopped it, unless it was force-deleted, and maybe recreated, meanwhile
+func (a *Agent) removeTerminatedPod(ctx context.Context, pod *api.Pod) error {
+	obj, err := a.store.Get(ctx, "Pod", pod.Namespace, pod.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	if obj.GetUID() != pod.UID {
+		return nil
+	}
+	if err := a.store.Delete(ctx, "Pod", pod.Namespace, pod.Name); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to remove pod: %w", err)
+	}
+	return nil
+}
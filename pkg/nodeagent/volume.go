@@ -0,0 +1,320 @@
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/httpclient"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultVolumeRootDir is where the node agent keeps per-pod volume directories
+const DefaultVolumeRootDir = defaultStateDir
+
+// VolumePlugin provides one kind of volume source to pods
+type VolumePlugin interface {
+	// Name returns the plugin name, e.g. "emptyDir"
+	Name() string
+	// CanSupport reports whether the plugin handles the volume's source
+	CanSupport(volume *api.Volume) bool
+	// GetPath returns the host path backing the volume; dir is the directory
+	// the manager reserved for this pod and volume
+	GetPath(pod *api.Pod, volume *api.Volume, dir string) string
+	// SetUp makes the volume available on the host
+	SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error
+	// TearDown releases the volume once the pod is gone
+	TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error
+}
+
+// VolumeUsagePlugin is implemented by plugins that can report how much space a volume uses
+type VolumeUsagePlugin interface {
+	// Usage returns the bytes used by the volume and its size limit, zero if unlimited
+	Usage(pod *api.Pod, volume *api.Volume, dir string) (used int64, limit int64, err error)
+}
+
+// RefreshableVolumePlugin is implemented by plugins whose content goes stale, such as
+// projected service account tokens, and must be rewritten while the pod runs
+type RefreshableVolumePlugin interface {
+	NeedsRefresh(volume *api.Volume, dir string) bool
+}
+
+// VolumeRefresher is implemented by volume managers that can refresh stale volumes
+type VolumeRefresher interface {
+	RefreshVolumes(ctx context.Context, pod *api.Pod) error
+}
+
+// Mounter performs filesystem mounts on the host
+type Mounter interface {
+	Mount(source, target, fstype string, options []string) error
+	Unmount(target string) error
+}
+
+// VolumePluginConfig holds the configuration for the volume plugin manager
+type VolumePluginConfig struct {
+	// RootDir holds per-pod volume directories
+	RootDir string
+	// Store is used to read config maps and secrets; their plugins are disabled without it
+	Store store.Store
+	// APIServerURL is where projected service account tokens are requested from
+	APIServerURL string
+	// HTTPClient makes requests to the API server
+	HTTPClient *httpclient.Client
+	// Mounter performs nfs and tmpfs mounts
+	Mounter Mounter
+	// CSIDrivers maps CSI-lite driver names to their endpoints, e.g. unix:///run/driver.sock
+	CSIDrivers map[string]string
+}
+
+// VolumePluginManager is a VolumeManager that dispatches to registered volume plugins
+type VolumePluginManager struct {
+	mu      sync.RWMutex
+	rootDir string
+	plugins []VolumePlugin
+}
+
+// NewVolumePluginManager creates a volume manager with the built-in plugins registered
+func NewVolumePluginManager(config *VolumePluginConfig) *VolumePluginManager {
+	if config.RootDir == "" {
+		config.RootDir = DefaultVolumeRootDir
+	}
+	if config.Mounter == nil {
+		config.Mounter = NewMounter()
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = httpclient.New(nil)
+	}
+
+	m := &VolumePluginManager{rootDir: config.RootDir}
+	m.RegisterPlugin(&hostPathPlugin{})
+	m.RegisterPlugin(&emptyDirPlugin{mounter: config.Mounter})
+	if config.Store != nil {
+		m.RegisterPlugin(&configMapPlugin{store: config.Store})
+		m.RegisterPlugin(&secretPlugin{store: config.Store})
+		m.RegisterPlugin(newProjectedPlugin(config.Store, config.APIServerURL, config.HTTPClient))
+	}
+	m.RegisterPlugin(&nfsPlugin{mounter: config.Mounter})
+	m.RegisterPlugin(&csiPlugin{drivers: config.CSIDrivers})
+	return m
+}
+
+// RegisterPlugin adds a volume plugin, replacing any plugin with the same name
+func (m *VolumePluginManager) RegisterPlugin(plugin VolumePlugin) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.plugins {
+		if existing.Name() == plugin.Name() {
+			m.plugins[i] = plugin
+			return
+		}
+	}
+	m.plugins = append(m.plugins, plugin)
+}
+
+// FindPlugin returns the plugin that supports the volume
+func (m *VolumePluginManager) FindPlugin(volume *api.Volume) (VolumePlugin, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, plugin := range m.plugins {
+		if plugin.CanSupport(volume) {
+			return plugin, nil
+		}
+	}
+	return nil, fmt.Errorf("no volume plugin supports volume %s", volume.Name)
+}
+
+// MountVolume sets up a volume for a pod
+func (m *VolumePluginManager) MountVolume(ctx context.Context, pod *api.Pod, volume *api.Volume, podState *PodState) error {
+	plugin, err := m.FindPlugin(volume)
+	if err != nil {
+		return err
+	}
+
+	if err := plugin.SetUp(ctx, pod, volume, m.volumeDir(pod, plugin, volume)); err != nil {
+		return fmt.Errorf("failed to set up %s volume %s: %w", plugin.Name(), volume.Name, err)
+	}
+	return nil
+}
+
+// UnmountVolume tears down a volume of a pod
+func (m *VolumePluginManager) UnmountVolume(ctx context.Context, podState *PodState, volumeName string) error {
+	pod := podState.Pod
+	for i := range pod.Spec.Volumes {
+		volume := &pod.Spec.Volumes[i]
+		if volume.Name != volumeName {
+			continue
+		}
+
+		plugin, err := m.FindPlugin(volume)
+		if err != nil {
+			return err
+		}
+		if err := plugin.TearDown(ctx, pod, volume, m.volumeDir(pod, plugin, volume)); err != nil {
+			return fmt.Errorf("failed to tear down %s volume %s: %w", plugin.Name(), volume.Name, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("volume %s not found in pod %s/%s", volumeName, pod.Namespace, pod.Name)
+}
+
+// GetVolumePath returns the host path backing a pod volume
+func (m *VolumePluginManager) GetVolumePath(ctx context.Context, pod *api.Pod, volume *api.Volume) (string, error) {
+	plugin, err := m.FindPlugin(volume)
+	if err != nil {
+		return "", err
+	}
+	return plugin.GetPath(pod, volume, m.volumeDir(pod, plugin, volume)), nil
+}
+
+// RefreshVolumes sets up again the pod's volumes whose content has gone stale
+func (m *VolumePluginManager) RefreshVolumes(ctx context.Context, pod *api.Pod) error {
+	for i := range pod.Spec.Volumes {
+		volume := &pod.Spec.Volumes[i]
+		plugin, err := m.FindPlugin(volume)
+		if err != nil {
+			return err
+		}
+
+		refreshable, ok := plugin.(RefreshableVolumePlugin)
+		dir := m.volumeDir(pod, plugin, volume)
+		if !ok || !refreshable.NeedsRefresh(volume, dir) {
+			continue
+		}
+		if err := plugin.SetUp(ctx, pod, volume, dir); err != nil {
+			return fmt.Errorf("failed to refresh %s volume %s: %w", plugin.Name(), volume.Name, err)
+		}
+	}
+	return nil
+}
+
+// ListVolumes lists the volumes of a pod
+func (m *VolumePluginManager) ListVolumes(ctx context.Context, pod *api.Pod) ([]*VolumeInfo, error) {
+	var volumes []*VolumeInfo
+	for i := range pod.Spec.Volumes {
+		volume := &pod.Spec.Volumes[i]
+		plugin, err := m.FindPlugin(volume)
+		if err != nil {
+			return nil, err
+		}
+
+		info := &VolumeInfo{
+			Name: volume.Name,
+			Path: plugin.GetPath(pod, volume, m.volumeDir(pod, plugin, volume)),
+			Type: plugin.Name(),
+		}
+		if stat, err := os.Stat(info.Path); err == nil {
+			info.Mounted = true
+			info.MountTime = stat.ModTime().Unix()
+		}
+		if usagePlugin, ok := plugin.(VolumeUsagePlugin); ok && info.Mounted {
+			used, limit, err := usagePlugin.Usage(pod, volume, info.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get usage of volume %s: %w", volume.Name, err)
+			}
+			info.Used = used
+			info.Size = limit
+			if limit > 0 && limit > used {
+				info.Available = limit - used
+			}
+		}
+		volumes = append(volumes, info)
+	}
+	return volumes, nil
+}
+
+// ValidateVolume checks that exactly one source is set and a plugin supports it
+func (m *VolumePluginManager) ValidateVolume(ctx context.Context, volume *api.Volume) error {
+	if volume.Name == "" {
+		return fmt.Errorf("volume name is required")
+	}
+
+	source := volume.VolumeSource
+	sources := 0
+	for _, set := range []bool{
+		source.HostPath != nil,
+		source.EmptyDir != nil,
+		source.ConfigMap != nil,
+		source.Secret != nil,
+		source.NFS != nil,
+		source.CSI != nil,
+		source.Projected != nil,
+	} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("volume %s must specify exactly one source, got %d", volume.Name, sources)
+	}
+
+	_, err := m.FindPlugin(volume)
+	return err
+}
+
+// dirUsage returns the total size of the regular files under dir
+func dirUsage(dir string) (int64, error) {
+	var used int64
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			// Files may disappear while the pod is running
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			used += info.Size()
+		}
+		return nil
+	})
+	return used, err
+}
+
+// parseQuantity parses a byte quantity such as "64Mi", "1G" or "1024"
+func parseQuantity(quantity string) (int64, error) {
+	multipliers := []struct {
+		suffix string
+		factor int64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+	}
+
+	number, factor := quantity, int64(1)
+	for _, m := range multipliers {
+		if strings.HasSuffix(quantity, m.suffix) {
+			number, factor = strings.TrimSuffix(quantity, m.suffix), m.factor
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid quantity %q", quantity)
+	}
+	return value * factor, nil
+}
+
+// volumeDir returns the directory reserved for a pod volume
+func (m *VolumePluginManager) volumeDir(pod *api.Pod, plugin VolumePlugin, volume *api.Volume) string {
+	podDir := pod.UID
+	if podDir == "" {
+		podDir = fmt.Sprintf("%s_%s", pod.Namespace, pod.Name)
+	}
+	return filepath.Join(m.rootDir, "pods", podDir, "volumes", plugin.Name(), volume.Name)
+}
@@ -0,0 +1,536 @@
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/csilite"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// HostPath volume types
+const (
+	HostPathDirectoryOrCreate = "DirectoryOrCreate"
+	HostPathDirectory         = "Directory"
+	HostPathFileOrCreate      = "FileOrCreate"
+	HostPathFile              = "File"
+)
+
+// defaultFileMode is used for projected config map and secret files without an explicit mode
+const defaultFileMode = 0o644
+
+// hostPathPlugin exposes an existing host path to the pod
+type hostPathPlugin struct{}
+
+// Name returns the plugin name
+func (p *hostPathPlugin) Name() string {
+	return "hostPath"
+}
+
+// CanSupport reports whether the volume is a host path
+func (p *hostPathPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.HostPath != nil
+}
+
+// GetPath returns the host path itself
+func (p *hostPathPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return volume.VolumeSource.HostPath.Path
+}
+
+// SetUp checks the host path against its declared type, creating it if asked to
+func (p *hostPathPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.HostPath
+	if source.Path == "" {
+		return fmt.Errorf("hostPath path is required")
+	}
+
+	switch source.Type {
+	case "":
+		return nil
+	case HostPathDirectoryOrCreate:
+		return os.MkdirAll(source.Path, 0o755)
+	case HostPathDirectory:
+		stat, err := os.Stat(source.Path)
+		if err != nil {
+			return err
+		}
+		if !stat.IsDir() {
+			return fmt.Errorf("%s is not a directory", source.Path)
+		}
+		return nil
+	case HostPathFileOrCreate:
+		if err := os.MkdirAll(filepath.Dir(source.Path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(source.Path, os.O_CREATE|os.O_RDONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	case HostPathFile:
+		stat, err := os.Stat(source.Path)
+		if err != nil {
+			return err
+		}
+		if !stat.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a file", source.Path)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported hostPath type %q", source.Type)
+	}
+}
+
+// TearDown leaves host paths untouched
+func (p *hostPathPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	return nil
+}
+
+// emptyDirPlugin provides a scratch directory that lives as long as the pod. Memory
+// backed empty dirs are tmpfs mounts capped at their size limit.
+type emptyDirPlugin struct {
+	mounter Mounter
+}
+
+// Name returns the plugin name
+func (p *emptyDirPlugin) Name() string {
+	return "emptyDir"
+}
+
+// CanSupport reports whether the volume is an empty dir
+func (p *emptyDirPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.EmptyDir != nil
+}
+
+// GetPath returns the pod's volume directory
+func (p *emptyDirPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return dir
+}
+
+// SetUp creates the directory, mounting a tmpfs on it for the memory medium
+func (p *emptyDirPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.EmptyDir
+
+	limit, err := p.sizeLimit(volume)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return err
+	}
+
+	switch source.Medium {
+	case api.StorageMediumDefault:
+		return nil
+	case api.StorageMediumMemory:
+		var options []string
+		if limit > 0 {
+			options = append(options, fmt.Sprintf("size=%d", limit))
+		}
+		if err := p.mounter.Mount("tmpfs", dir, "tmpfs", options); err != nil {
+			os.Remove(dir)
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported emptyDir medium %q", source.Medium)
+	}
+}
+
+// TearDown unmounts the tmpfs, if any, and deletes the directory and its contents
+func (p *emptyDirPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	if volume.VolumeSource.EmptyDir.Medium == api.StorageMediumMemory {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil
+		}
+		if err := p.mounter.Unmount(dir); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// Usage reports the bytes written to the directory and the volume's size limit
+func (p *emptyDirPlugin) Usage(pod *api.Pod, volume *api.Volume, dir string) (int64, int64, error) {
+	limit, err := p.sizeLimit(volume)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	used, err := dirUsage(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	return used, limit, nil
+}
+
+// sizeLimit returns the volume's size limit in bytes, zero if unlimited
+func (p *emptyDirPlugin) sizeLimit(volume *api.Volume) (int64, error) {
+	sizeLimit := volume.VolumeSource.EmptyDir.SizeLimit
+	if sizeLimit == "" {
+		return 0, nil
+	}
+
+	limit, err := parseQuantity(sizeLimit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid emptyDir sizeLimit: %w", err)
+	}
+	return limit, nil
+}
+
+// configMapPlugin projects config map keys into the pod as files
+type configMapPlugin struct {
+	store store.Store
+}
+
+// Name returns the plugin name
+func (p *configMapPlugin) Name() string {
+	return "configMap"
+}
+
+// CanSupport reports whether the volume is a config map
+func (p *configMapPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.ConfigMap != nil
+}
+
+// GetPath returns the pod's volume directory
+func (p *configMapPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return dir
+}
+
+// SetUp writes the config map's keys into the directory
+func (p *configMapPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.ConfigMap
+
+	data, err := getConfigMapData(ctx, p.store, pod.Namespace, source.Name, source.Optional)
+	if err != nil {
+		return err
+	}
+
+	files, err := projectKeys(data, source.Items, fileMode(source.DefaultMode), source.Optional)
+	if err != nil {
+		return err
+	}
+	return writeProjectedFiles(dir, files)
+}
+
+// TearDown deletes the projected files
+func (p *configMapPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// secretPlugin projects secret keys into the pod as files
+type secretPlugin struct {
+	store store.Store
+}
+
+// Name returns the plugin name
+func (p *secretPlugin) Name() string {
+	return "secret"
+}
+
+// CanSupport reports whether the volume is a secret
+func (p *secretPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.Secret != nil
+}
+
+// GetPath returns the pod's volume directory
+func (p *secretPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return dir
+}
+
+// SetUp writes the secret's keys into the directory
+func (p *secretPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.Secret
+
+	data, err := getSecretData(ctx, p.store, pod.Namespace, source.SecretName, source.Optional)
+	if err != nil {
+		return err
+	}
+
+	files, err := projectKeys(data, source.Items, fileMode(source.DefaultMode), source.Optional)
+	if err != nil {
+		return err
+	}
+	return writeProjectedFiles(dir, files)
+}
+
+// TearDown deletes the projected files
+func (p *secretPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// getConfigMapData returns a config map's keys; a missing optional config map has none
+func getConfigMapData(ctx context.Context, s store.Store, namespace, name string, optional bool) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+
+	obj, err := s.Get(ctx, "ConfigMap", namespace, name)
+	if err != nil {
+		if optional && errors.Is(err, store.ErrNotFound) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("failed to get config map %s: %w", name, err)
+	}
+
+	if configMap, ok := obj.(*api.ConfigMap); ok {
+		for key, value := range configMap.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			data[key] = value
+		}
+	}
+	return data, nil
+}
+
+// getSecretData returns a secret's keys; a missing optional secret has none
+func getSecretData(ctx context.Context, s store.Store, namespace, name string, optional bool) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+
+	obj, err := s.Get(ctx, "Secret", namespace, name)
+	if err != nil {
+		if optional && errors.Is(err, store.ErrNotFound) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+
+	if secret, ok := obj.(*api.Secret); ok {
+		for key, value := range secret.Data {
+			data[key] = value
+		}
+		for key, value := range secret.StringData {
+			data[key] = []byte(value)
+		}
+	}
+	return data, nil
+}
+
+// projectedFile is the content and mode of a file written into a volume
+type projectedFile struct {
+	data []byte
+	mode os.FileMode
+}
+
+// fileMode returns the mode for projected files, falling back to defaultFileMode
+func fileMode(mode *int32) os.FileMode {
+	if mode == nil {
+		return defaultFileMode
+	}
+	return os.FileMode(*mode)
+}
+
+// projectKeys maps keys to files. When items are given only those keys are
+// projected, at the requested relative paths; otherwise every key is. Missing
+// keys are an error unless the source is optional.
+func projectKeys(data map[string][]byte, items []api.KeyToPath, mode os.FileMode, optional bool) (map[string]projectedFile, error) {
+	files := make(map[string]projectedFile)
+	if len(items) == 0 {
+		for key, value := range data {
+			files[key] = projectedFile{data: value, mode: mode}
+		}
+		return files, nil
+	}
+
+	for _, item := range items {
+		value, ok := data[item.Key]
+		if !ok {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("key %s not found", item.Key)
+		}
+		itemMode := mode
+		if item.Mode != nil {
+			itemMode = os.FileMode(*item.Mode)
+		}
+		files[item.Path] = projectedFile{data: value, mode: itemMode}
+	}
+	return files, nil
+}
+
+// writeProjectedFiles makes dir contain exactly the given files. Each file is
+// replaced atomically so readers never see a partially written file.
+func writeProjectedFiles(dir string, files map[string]projectedFile) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create volume dir: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(files))
+	for path, file := range files {
+		clean := filepath.Clean(path)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid path %q", path)
+		}
+
+		target := filepath.Join(dir, clean)
+		wanted[target] = true
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		tmp := target + ".tmp"
+		if err := os.WriteFile(tmp, file.data, file.mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		// WriteFile's mode is subject to the umask
+		if err := os.Chmod(tmp, file.mode); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, target); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	// Remove files of keys that no longer exist
+	return filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && !wanted[path] {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// nfsPlugin mounts an NFS export into the pod's volume directory
+type nfsPlugin struct {
+	mounter Mounter
+}
+
+// Name returns the plugin name
+func (p *nfsPlugin) Name() string {
+	return "nfs"
+}
+
+// CanSupport reports whether the volume is an NFS export
+func (p *nfsPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.NFS != nil
+}
+
+// GetPath returns the pod's volume directory
+func (p *nfsPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return dir
+}
+
+// SetUp mounts the export
+func (p *nfsPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.NFS
+	if source.Server == "" || source.Path == "" {
+		return fmt.Errorf("nfs server and path are required")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	var options []string
+	if source.ReadOnly {
+		options = append(options, "ro")
+	}
+	return p.mounter.Mount(fmt.Sprintf("%s:%s", source.Server, source.Path), dir, "nfs", options)
+}
+
+// TearDown unmounts the export and removes the mount point
+func (p *nfsPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := p.mounter.Unmount(dir); err != nil {
+		return err
+	}
+	return os.Remove(dir)
+}
+
+// csiPlugin delegates volumes to external CSI-lite drivers
+type csiPlugin struct {
+	drivers map[string]string
+}
+
+// Name returns the plugin name
+func (p *csiPlugin) Name() string {
+	return "csi"
+}
+
+// CanSupport reports whether the volume is provided by a CSI-lite driver
+func (p *csiPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.CSI != nil
+}
+
+// GetPath returns the pod's volume directory
+func (p *csiPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return dir
+}
+
+// SetUp asks the driver to publish the volume into the directory
+func (p *csiPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.CSI
+
+	client, err := p.dial(source.Driver)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	volumeContext := map[string]string{
+		csilite.VolumeContextPodName:      pod.Name,
+		csilite.VolumeContextPodNamespace: pod.Namespace,
+		csilite.VolumeContextPodUID:       pod.UID,
+	}
+	for key, value := range source.VolumeAttributes {
+		volumeContext[key] = value
+	}
+
+	return client.NodePublishVolume(ctx, &csilite.NodePublishVolumeRequest{
+		VolumeID:      csiVolumeID(pod, volume),
+		TargetPath:    dir,
+		ReadOnly:      source.ReadOnly,
+		FSType:        source.FSType,
+		VolumeContext: volumeContext,
+	})
+}
+
+// TearDown asks the driver to unpublish the volume and removes the directory
+func (p *csiPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	client, err := p.dial(volume.VolumeSource.CSI.Driver)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.NodeUnpublishVolume(ctx, &csilite.NodeUnpublishVolumeRequest{
+		VolumeID:   csiVolumeID(pod, volume),
+		TargetPath: dir,
+	}); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// dial connects to a registered driver
+func (p *csiPlugin) dial(driver string) (*csilite.Client, error) {
+	endpoint, ok := p.drivers[driver]
+	if !ok {
+		return nil, fmt.Errorf("csi driver %s is not registered", driver)
+	}
+	return csilite.Dial(endpoint)
+}
+
+// csiVolumeID returns the volume handle, or a pod-scoped ID for ephemeral volumes
+func csiVolumeID(pod *api.Pod, volume *api.Volume) string {
+	if handle := volume.VolumeSource.CSI.VolumeHandle; handle != "" {
+		return handle
+	}
+	return fmt.Sprintf("%s-%s", pod.UID, volume.Name)
+}
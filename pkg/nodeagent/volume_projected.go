@@ -0,0 +1,259 @@
+package nodeagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/httpclient"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// tokenRefreshFraction is how far into a token's lifetime it gets replaced
+const tokenRefreshFraction = 0.8
+
+// projectedPlugin combines secrets, config maps, pod metadata and service account
+// tokens into one directory. Tokens are requested from the API server and rewritten
+// before they expire.
+type projectedPlugin struct {
+	store        store.Store
+	apiServerURL string
+	httpClient   *httpclient.Client
+
+	mu        sync.Mutex
+	refreshAt map[string]time.Time // volume dir -> when its token must be renewed
+}
+
+// newProjectedPlugin creates the projected volume plugin
+func newProjectedPlugin(store store.Store, apiServerURL string, httpClient *httpclient.Client) *projectedPlugin {
+	return &projectedPlugin{
+		store:        store,
+		apiServerURL: strings.TrimSuffix(apiServerURL, "/"),
+		httpClient:   httpClient,
+		refreshAt:    make(map[string]time.Time),
+	}
+}
+
+// Name returns the plugin name
+func (p *projectedPlugin) Name() string {
+	return "projected"
+}
+
+// CanSupport reports whether the volume is projected
+func (p *projectedPlugin) CanSupport(volume *api.Volume) bool {
+	return volume.VolumeSource.Projected != nil
+}
+
+// GetPath returns the pod's volume directory
+func (p *projectedPlugin) GetPath(pod *api.Pod, volume *api.Volume, dir string) string {
+	return dir
+}
+
+// SetUp writes every source of the volume into the directory
+func (p *projectedPlugin) SetUp(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	source := volume.VolumeSource.Projected
+	mode := fileMode(source.DefaultMode)
+
+	files := make(map[string]projectedFile)
+	var refreshAt time.Time
+	for _, projection := range source.Sources {
+		var projected map[string]projectedFile
+		var err error
+
+		switch {
+		case projection.Secret != nil:
+			var data map[string][]byte
+			data, err = getSecretData(ctx, p.store, pod.Namespace, projection.Secret.Name, projection.Secret.Optional)
+			if err == nil {
+				projected, err = projectKeys(data, projection.Secret.Items, mode, projection.Secret.Optional)
+			}
+		case projection.ConfigMap != nil:
+			var data map[string][]byte
+			data, err = getConfigMapData(ctx, p.store, pod.Namespace, projection.ConfigMap.Name, projection.ConfigMap.Optional)
+			if err == nil {
+				projected, err = projectKeys(data, projection.ConfigMap.Items, mode, projection.ConfigMap.Optional)
+			}
+		case projection.DownwardAPI != nil:
+			projected, err = projectPodFields(pod, projection.DownwardAPI.Items, mode)
+		case projection.ServiceAccountToken != nil:
+			var token string
+			var expiresAt time.Time
+			token, expiresAt, err = p.requestToken(ctx, pod, projection.ServiceAccountToken.ExpirationSeconds)
+			if err == nil {
+				projected = map[string]projectedFile{
+					projection.ServiceAccountToken.Path: {data: []byte(token), mode: mode},
+				}
+				lifetime := time.Until(expiresAt)
+				refreshAt = time.Now().Add(time.Duration(float64(lifetime) * tokenRefreshFraction))
+			}
+		default:
+			err = fmt.Errorf("projected volume source has no source set")
+		}
+		if err != nil {
+			return err
+		}
+
+		for path, file := range projected {
+			if _, exists := files[path]; exists {
+				return fmt.Errorf("conflicting projected path %s", path)
+			}
+			files[path] = file
+		}
+	}
+
+	if err := writeProjectedFiles(dir, files); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if refreshAt.IsZero() {
+		delete(p.refreshAt, dir)
+	} else {
+		p.refreshAt[dir] = refreshAt
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+// TearDown deletes the projected files
+func (p *projectedPlugin) TearDown(ctx context.Context, pod *api.Pod, volume *api.Volume, dir string) error {
+	p.mu.Lock()
+	delete(p.refreshAt, dir)
+	p.mu.Unlock()
+
+	return os.RemoveAll(dir)
+}
+
+// NeedsRefresh reports whether the volume's token is due for renewal. A
+// token in a directory set up before the agent restarted is renewed right
+// away, since when it expires isn't known.
+func (p *projectedPlugin) NeedsRefresh(volume *api.Volume, dir string) bool {
+	p.mu.Lock()
+	refreshAt, ok := p.refreshAt[dir]
+	p.mu.Unlock()
+	if ok {
+		return !time.Now().Before(refreshAt)
+	}
+
+	if !hasTokenSource(volume.VolumeSource.Projected) {
+		return false
+	}
+	_, err := os.Stat(dir)
+	return err == nil
+}
+
+// hasTokenSource reports whether source projects a service account token
+func hasTokenSource(source *api.ProjectedVolumeSource) bool {
+	if source == nil {
+		return false
+	}
+	for _, projection := range source.Sources {
+		if projection.ServiceAccountToken != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// requestToken asks the API server for a token for the pod's service account, bound to the pod
+func (p *projectedPlugin) requestToken(ctx context.Context, pod *api.Pod, expirationSeconds int64) (string, time.Time, error) {
+	if p.apiServerURL == "" {
+		return "", time.Time{}, fmt.Errorf("no API server configured to request service account tokens from")
+	}
+
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = api.DefaultServiceAccountName
+	}
+
+	body, err := json.Marshal(&api.TokenRequest{
+		TypeMeta: api.TypeMeta{Kind: "TokenRequest", APIVersion: "v1alpha1"},
+		Spec: api.TokenRequestSpec{
+			ExpirationSeconds: expirationSeconds,
+			BoundObjectRef: &api.BoundObjectReference{
+				Kind: "Pod",
+				Name: pod.Name,
+				UID:  pod.UID,
+			},
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1alpha1/namespaces/%s/serviceaccounts/%s/token", p.apiServerURL, pod.Namespace, serviceAccount)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to request service account token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		var msg bytes.Buffer
+		msg.ReadFrom(resp.Body)
+		return "", time.Time{}, fmt.Errorf("failed to request service account token: %s: %s", resp.Status, strings.TrimSpace(msg.String()))
+	}
+
+	var tokenRequest api.TokenRequest
+	if err := json.NewDecoder(resp.Body).Decode(&tokenRequest); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	return tokenRequest.Status.Token, tokenRequest.Status.ExpirationTimestamp, nil
+}
+
+// projectPodFields renders pod metadata fields as files
+func projectPodFields(pod *api.Pod, items []api.DownwardAPIVolumeFile, mode os.FileMode) (map[string]projectedFile, error) {
+	files := make(map[string]projectedFile)
+	for _, item := range items {
+		var value string
+		switch item.FieldPath {
+		case "metadata.name":
+			value = pod.Name
+		case "metadata.namespace":
+			value = pod.Namespace
+		case "metadata.uid":
+			value = pod.UID
+		case "metadata.labels":
+			value = formatMap(pod.Labels)
+		case "metadata.annotations":
+			value = formatMap(pod.Annotations)
+		case "spec.nodeName":
+			value = pod.Spec.NodeName
+		case "spec.serviceAccountName":
+			value = pod.Spec.ServiceAccountName
+		default:
+			return nil, fmt.Errorf("unsupported fieldPath %q", item.FieldPath)
+		}
+		files[item.Path] = projectedFile{data: []byte(value), mode: mode}
+	}
+	return files, nil
+}
+
+// formatMap renders a map as sorted key="value" lines
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=%q\n", key, m[key])
+	}
+	return b.String()
+}
@@ -0,0 +1,549 @@
+package nodeagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/csilite"
+	"github.com/minik8s/minik8s/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMounter records mounts instead of performing them
+type fakeMounter struct {
+	mu     sync.Mutex
+	mounts map[string]string // target -> source
+}
+
+func newFakeMounter() *fakeMounter {
+	return &fakeMounter{mounts: make(map[string]string)}
+}
+
+func (m *fakeMounter) Mount(source, target, fstype string, options []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mounts[target] = source
+	return nil
+}
+
+func (m *fakeMounter) Unmount(target string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.mounts, target)
+	return nil
+}
+
+// fakeCSIDriver records published volumes
+type fakeCSIDriver struct {
+	mu        sync.Mutex
+	published map[string]*csilite.NodePublishVolumeRequest
+}
+
+func (d *fakeCSIDriver) GetPluginInfo(ctx context.Context, req *csilite.GetPluginInfoRequest) (*csilite.GetPluginInfoResponse, error) {
+	return &csilite.GetPluginInfoResponse{Name: "fake.csi.minik8s.io"}, nil
+}
+
+func (d *fakeCSIDriver) NodePublishVolume(ctx context.Context, req *csilite.NodePublishVolumeRequest) (*csilite.NodePublishVolumeResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.published[req.TargetPath] = req
+	return &csilite.NodePublishVolumeResponse{}, nil
+}
+
+func (d *fakeCSIDriver) NodeUnpublishVolume(ctx context.Context, req *csilite.NodeUnpublishVolumeRequest) (*csilite.NodeUnpublishVolumeResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.published, req.TargetPath)
+	return &csilite.NodeUnpublishVolumeResponse{}, nil
+}
+
+func newVolumeTestPod(volumes ...api.Volume) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+			UID:       "test-uid",
+		},
+		Spec: api.PodSpec{
+			Volumes: volumes,
+		},
+	}
+}
+
+func TestVolumePluginManager_EmptyDir(t *testing.T) {
+	rootDir := t.TempDir()
+	manager := NewVolumePluginManager(&VolumePluginConfig{RootDir: rootDir, Mounter: newFakeMounter()})
+
+	pod := newVolumeTestPod(api.Volume{
+		Name:         "scratch",
+		VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}},
+	})
+	podState := &PodState{Pod: pod}
+	ctx := context.Background()
+
+	err := manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	require.NoError(t, err)
+
+	path, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(rootDir, "pods", "test-uid", "volumes", "emptyDir", "scratch"), path)
+	assert.DirExists(t, path)
+
+	err = manager.UnmountVolume(ctx, podState, "scratch")
+	require.NoError(t, err)
+	assert.NoDirExists(t, path)
+}
+
+func TestVolumePluginManager_HostPath(t *testing.T) {
+	manager := NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Mounter: newFakeMounter()})
+
+	hostDir := filepath.Join(t.TempDir(), "data")
+	pod := newVolumeTestPod(api.Volume{
+		Name: "data",
+		VolumeSource: api.VolumeSource{HostPath: &api.HostPathVolumeSource{
+			Path: hostDir,
+			Type: HostPathDirectoryOrCreate,
+		}},
+	})
+	podState := &PodState{Pod: pod}
+	ctx := context.Background()
+
+	err := manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	require.NoError(t, err)
+	assert.DirExists(t, hostDir)
+
+	path, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	assert.Equal(t, hostDir, path)
+
+	// Host paths are never deleted
+	err = manager.UnmountVolume(ctx, podState, "data")
+	require.NoError(t, err)
+	assert.DirExists(t, hostDir)
+
+	// A missing directory is an error when it must already exist
+	pod.Spec.Volumes[0].VolumeSource.HostPath = &api.HostPathVolumeSource{
+		Path: filepath.Join(t.TempDir(), "missing"),
+		Type: HostPathDirectory,
+	}
+	err = manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	assert.Error(t, err)
+}
+
+func TestVolumePluginManager_ConfigMapAndSecret(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	err := store.Create(ctx, &api.ConfigMap{
+		TypeMeta:   api.TypeMeta{Kind: "ConfigMap", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "app-config", Namespace: "default"},
+		Data: map[string]string{
+			"app.conf": "debug=true",
+			"other":    "ignored",
+		},
+	})
+	require.NoError(t, err)
+
+	err = store.Create(ctx, &api.Secret{
+		TypeMeta:   api.TypeMeta{Kind: "Secret", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "app-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"password": []byte("hunter2"),
+		},
+	})
+	require.NoError(t, err)
+
+	manager := NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Store: store, Mounter: newFakeMounter()})
+
+	secretMode := int32(0o400)
+	pod := newVolumeTestPod(
+		api.Volume{
+			Name: "config",
+			VolumeSource: api.VolumeSource{ConfigMap: &api.ConfigMapVolumeSource{
+				Name:  "app-config",
+				Items: []api.KeyToPath{{Key: "app.conf", Path: "conf/app.conf"}},
+			}},
+		},
+		api.Volume{
+			Name: "secret",
+			VolumeSource: api.VolumeSource{Secret: &api.SecretVolumeSource{
+				SecretName:  "app-secret",
+				DefaultMode: &secretMode,
+			}},
+		},
+		api.Volume{
+			Name: "missing",
+			VolumeSource: api.VolumeSource{ConfigMap: &api.ConfigMapVolumeSource{
+				Name:     "does-not-exist",
+				Optional: true,
+			}},
+		},
+	)
+	podState := &PodState{Pod: pod}
+
+	for i := range pod.Spec.Volumes {
+		err := manager.MountVolume(ctx, pod, &pod.Spec.Volumes[i], podState)
+		require.NoError(t, err)
+	}
+
+	configPath, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(configPath, "conf", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "debug=true", string(content))
+	assert.NoFileExists(t, filepath.Join(configPath, "other"))
+
+	secretPath, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[1])
+	require.NoError(t, err)
+	stat, err := os.Stat(filepath.Join(secretPath, "password"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o400), stat.Mode().Perm())
+
+	// A required config map that doesn't exist fails the mount
+	pod.Spec.Volumes[2].VolumeSource.ConfigMap.Optional = false
+	err = manager.MountVolume(ctx, pod, &pod.Spec.Volumes[2], podState)
+	assert.Error(t, err)
+}
+
+func TestVolumePluginManager_NFS(t *testing.T) {
+	mounter := newFakeMounter()
+	manager := NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Mounter: mounter})
+
+	pod := newVolumeTestPod(api.Volume{
+		Name: "shared",
+		VolumeSource: api.VolumeSource{NFS: &api.NFSVolumeSource{
+			Server: "nfs.example.com",
+			Path:   "/exports/shared",
+		}},
+	})
+	podState := &PodState{Pod: pod}
+	ctx := context.Background()
+
+	err := manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	require.NoError(t, err)
+
+	path, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	assert.Equal(t, "nfs.example.com:/exports/shared", mounter.mounts[path])
+
+	err = manager.UnmountVolume(ctx, podState, "shared")
+	require.NoError(t, err)
+	assert.Empty(t, mounter.mounts)
+}
+
+func TestVolumePluginManager_CSI(t *testing.T) {
+	driver := &fakeCSIDriver{published: make(map[string]*csilite.NodePublishVolumeRequest)}
+	endpoint := "unix://" + filepath.Join(t.TempDir(), "csi.sock")
+
+	listener, err := csilite.Listen(endpoint)
+	require.NoError(t, err)
+	server := csilite.NewServer(driver)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	manager := NewVolumePluginManager(&VolumePluginConfig{
+		RootDir:    t.TempDir(),
+		Mounter:    newFakeMounter(),
+		CSIDrivers: map[string]string{"fake.csi.minik8s.io": endpoint},
+	})
+
+	pod := newVolumeTestPod(api.Volume{
+		Name: "external",
+		VolumeSource: api.VolumeSource{CSI: &api.CSIVolumeSource{
+			Driver:           "fake.csi.minik8s.io",
+			VolumeAttributes: map[string]string{"size": "1Gi"},
+		}},
+	})
+	podState := &PodState{Pod: pod}
+	ctx := context.Background()
+
+	err = manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	require.NoError(t, err)
+
+	path, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	req, ok := driver.published[path]
+	require.True(t, ok)
+	assert.Equal(t, "test-uid-external", req.VolumeID)
+	assert.Equal(t, "1Gi", req.VolumeContext["size"])
+	assert.Equal(t, "test-pod", req.VolumeContext[csilite.VolumeContextPodName])
+
+	err = manager.UnmountVolume(ctx, podState, "external")
+	require.NoError(t, err)
+	assert.Empty(t, driver.published)
+
+	// Volumes for unregistered drivers can't be mounted
+	pod.Spec.Volumes[0].VolumeSource.CSI.Driver = "unknown"
+	err = manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	assert.Error(t, err)
+}
+
+func TestVolumePluginManager_ValidateVolume(t *testing.T) {
+	manager := NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Mounter: newFakeMounter()})
+	ctx := context.Background()
+
+	err := manager.ValidateVolume(ctx, &api.Volume{
+		Name:         "scratch",
+		VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}},
+	})
+	assert.NoError(t, err)
+
+	err = manager.ValidateVolume(ctx, &api.Volume{Name: "nothing"})
+	assert.Error(t, err)
+
+	err = manager.ValidateVolume(ctx, &api.Volume{
+		Name: "both",
+		VolumeSource: api.VolumeSource{
+			EmptyDir: &api.EmptyDirVolumeSource{},
+			HostPath: &api.HostPathVolumeSource{Path: "/tmp"},
+		},
+	})
+	assert.Error(t, err)
+
+	// Config maps need a store
+	err = manager.ValidateVolume(ctx, &api.Volume{
+		Name:         "config",
+		VolumeSource: api.VolumeSource{ConfigMap: &api.ConfigMapVolumeSource{Name: "app-config"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestVolumePluginManager_EmptyDirMemory(t *testing.T) {
+	mounter := newFakeMounter()
+	manager := NewVolumePluginManager(&VolumePluginConfig{RootDir: t.TempDir(), Mounter: mounter})
+
+	pod := newVolumeTestPod(api.Volume{
+		Name: "cache",
+		VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{
+			Medium:    api.StorageMediumMemory,
+			SizeLimit: "64Mi",
+		}},
+	})
+	podState := &PodState{Pod: pod}
+	ctx := context.Background()
+
+	err := manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	require.NoError(t, err)
+
+	path, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	assert.Equal(t, "tmpfs", mounter.mounts[path])
+
+	volumes, err := manager.ListVolumes(ctx, pod)
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, int64(64<<20), volumes[0].Size)
+
+	err = manager.UnmountVolume(ctx, podState, "cache")
+	require.NoError(t, err)
+	assert.Empty(t, mounter.mounts)
+	assert.NoDirExists(t, path)
+
+	// Unknown media are rejected
+	pod.Spec.Volumes[0].VolumeSource.EmptyDir.Medium = "HugePages"
+	err = manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	assert.Error(t, err)
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		quantity string
+		expected int64
+		wantErr  bool
+	}{
+		{"1024", 1024, false},
+		{"64Mi", 64 << 20, false},
+		{"1Gi", 1 << 30, false},
+		{"2k", 2000, false},
+		{"1G", 1000000000, false},
+		{"", 0, true},
+		{"Mi", 0, true},
+		{"-1Mi", 0, true},
+		{"1.5Gi", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.quantity, func(t *testing.T) {
+			got, err := parseQuantity(tt.quantity)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestVolumePluginManager_Projected(t *testing.T) {
+	store := store.NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	err := store.Create(ctx, &api.ConfigMap{
+		TypeMeta:   api.TypeMeta{Kind: "ConfigMap", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: api.RootCAConfigMapName, Namespace: "default"},
+		Data:       map[string]string{api.RootCAConfigMapKey: "ca-bundle"},
+	})
+	require.NoError(t, err)
+
+	var tokenRequests int
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1alpha1/namespaces/default/serviceaccounts/builder/token", r.URL.Path)
+
+		var req api.TokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-uid", req.Spec.BoundObjectRef.UID)
+
+		tokenRequests++
+		req.Status.Token = fmt.Sprintf("token-%d", tokenRequests)
+		// Issue an already stale token so the next refresh replaces it
+		req.Status.ExpirationTimestamp = time.Now()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(req)
+	}))
+	defer apiServer.Close()
+
+	manager := NewVolumePluginManager(&VolumePluginConfig{
+		RootDir:      t.TempDir(),
+		Store:        store,
+		APIServerURL: apiServer.URL,
+		Mounter:      newFakeMounter(),
+	})
+
+	pod := newVolumeTestPod(api.Volume{
+		Name: api.ServiceAccountVolumeName,
+		VolumeSource: api.VolumeSource{Projected: &api.ProjectedVolumeSource{
+			Sources: []api.VolumeProjection{
+				{ServiceAccountToken: &api.ServiceAccountTokenProjection{Path: "token"}},
+				{ConfigMap: &api.ConfigMapProjection{
+					Name:  api.RootCAConfigMapName,
+					Items: []api.KeyToPath{{Key: api.RootCAConfigMapKey, Path: api.RootCAConfigMapKey}},
+				}},
+				{DownwardAPI: &api.DownwardAPIProjection{
+					Items: []api.DownwardAPIVolumeFile{{Path: "namespace", FieldPath: "metadata.namespace"}},
+				}},
+			},
+		}},
+	})
+	pod.Spec.ServiceAccountName = "builder"
+	podState := &PodState{Pod: pod}
+
+	err = manager.MountVolume(ctx, pod, &pod.Spec.Volumes[0], podState)
+	require.NoError(t, err)
+
+	path, err := manager.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+	require.NoError(t, err)
+	for file, expected := range map[string]string{
+		"token":     "token-1",
+		"ca.crt":    "ca-bundle",
+		"namespace": "default",
+	} {
+		content, err := os.ReadFile(filepath.Join(path, file))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+
+	// The expired token is replaced on refresh
+	err = manager.RefreshVolumes(ctx, pod)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(path, "token"))
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", string(content))
+}
+
+func TestAgent_RestoredPodTokenRefreshed(t *testing.T) {
+	st := store.NewMemoryStore(nil)
+	defer st.Close()
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var tokenRequests int
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.TokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		mu.Lock()
+		tokenRequests++
+		req.Status.Token = fmt.Sprintf("token-%d", tokenRequests)
+		mu.Unlock()
+		// Issue a token that is far from stale
+		req.Status.ExpirationTimestamp = time.Now().Add(time.Hour)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(req)
+	}))
+	defer apiServer.Close()
+
+	pod := newVolumeTestPod(api.Volume{
+		Name: api.ServiceAccountVolumeName,
+		VolumeSource: api.VolumeSource{Projected: &api.ProjectedVolumeSource{
+			Sources: []api.VolumeProjection{
+				{ServiceAccountToken: &api.ServiceAccountTokenProjection{Path: "token"}},
+			},
+		}},
+	})
+	pod.TypeMeta = api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"}
+	pod.Spec.NodeName = "test-node"
+	pod.Spec.Containers = []api.Container{{Name: "test", Image: "nginx:latest"}}
+	require.NoError(t, st.Create(ctx, pod))
+
+	// The runtime and the volume directories outlive the agent
+	runtime := NewMockCRIRuntime()
+	rootDir := t.TempDir()
+	checkpointDir := t.TempDir()
+	newAgent := func() *Agent {
+		return NewAgent(&Config{
+			NodeName:       "test-node",
+			Store:          st,
+			CRIRuntime:     runtime,
+			NetworkManager: &MockNetworkManager{},
+			VolumeManager: NewVolumePluginManager(&VolumePluginConfig{
+				RootDir:      rootDir,
+				Store:        st,
+				APIServerURL: apiServer.URL,
+				Mounter:      newFakeMounter(),
+			}),
+			HeartbeatInterval: 30 * time.Second,
+			CheckpointDir:     checkpointDir,
+		})
+	}
+	readToken := func(agent *Agent) string {
+		path, err := agent.volumeMgr.GetVolumePath(ctx, pod, &pod.Spec.Volumes[0])
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(path, "token"))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	agent := newAgent()
+	require.NoError(t, agent.syncPods(ctx))
+	assert.Equal(t, "token-1", readToken(agent))
+
+	// A fresh token isn't renewed
+	agent.refreshPodVolumes(ctx)
+	assert.Equal(t, "token-1", readToken(agent))
+
+	// A restarted agent doesn't know when the adopted token expires, so it
+	// renews it
+	restarted := newAgent()
+	restarted.mu.Lock()
+	restarted.restoreCheckpoints(ctx)
+	restarted.mu.Unlock()
+	require.Contains(t, restarted.pods, "default/test-pod")
+
+	restarted.refreshPodVolumes(ctx)
+	assert.Equal(t, "token-2", readToken(restarted))
+	restarted.refreshPodVolumes(ctx)
+	assert.Equal(t, "token-2", readToken(restarted))
+}
@@ -0,0 +1,51 @@
+// Package profiling serves the Go runtime profiles of a component over HTTP
+package profiling
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// PathPrefix is where the profiling endpoints are served
+const PathPrefix = "/debug/pprof/"
+
+// DefaultControllerManagerAddress is where the controller manager, which
+// also runs the scheduler, serves its profiles
+const DefaultControllerManagerAddress = "localhost:10252"
+
+// DefaultNodeAgentAddress is where a node agent serves its profiles, apart
+// from the server it streams exec and attach sessions on
+const DefaultNodeAgentAddress = "localhost:10248"
+
+// Handler returns the net/http/pprof endpoints rooted at PathPrefix. Named
+// profiles such as heap and goroutine are served under PathPrefix by name.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathPrefix, pprof.Index)
+	mux.HandleFunc(PathPrefix+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PathPrefix+"profile", pprof.Profile)
+	mux.HandleFunc(PathPrefix+"symbol", pprof.Symbol)
+	mux.HandleFunc(PathPrefix+"trace", pprof.Trace)
+	return mux
+}
+
+// Serve serves Handler on addr in the background, for components without an
+// HTTP server of their own. It returns once the address is bound.
+func Serve(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	server := &http.Server{Handler: Handler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error serving profiles: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("Serving profiles on http://%s%s\n", listener.Addr(), PathPrefix)
+	return server, nil
+}
@@ -0,0 +1,51 @@
+package profiling
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{PathPrefix, "goroutine"},
+		{PathPrefix + "goroutine?debug=1", "goroutine profile"},
+		{PathPrefix + "cmdline", ""},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", tt.path, resp.StatusCode)
+		}
+		if !strings.Contains(string(body), tt.want) {
+			t.Errorf("GET %s: expected body to contain %q, got %q", tt.path, tt.want, body)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	server, err := Serve("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	defer server.Close()
+
+	if _, err := Serve("256.0.0.1:0"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
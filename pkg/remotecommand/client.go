@@ -0,0 +1,191 @@
+package remotecommand
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Stream upgrades a request to rawURL and pumps streams over it until the
+// remote command exits, returning the command's exit code
+func Stream(ctx context.Context, rawURL string, header http.Header, streams Streams) (int, error) {
+	return stream(ctx, rawURL, header, streams, dial)
+}
+
+// StreamUnix is Stream over the unix domain socket at socketPath instead of
+// a connection to the host rawURL names
+func StreamUnix(ctx context.Context, socketPath, rawURL string, header http.Header, streams Streams) (int, error) {
+	return stream(ctx, rawURL, header, streams, func(ctx context.Context, u *url.URL) (net.Conn, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "unix", socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", socketPath, err)
+		}
+		return conn, nil
+	})
+}
+
+func stream(ctx context.Context, rawURL string, header http.Header, streams Streams, dial dialFunc) (int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return -1, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	conn, err := dialUpgrade(ctx, u, req, dial)
+	if err != nil {
+		return -1, err
+	}
+	defer conn.Close()
+
+	// Unblock ReadFrame when the caller gives up
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if streams.Stdin != nil {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := streams.Stdin.Read(buf)
+				if n > 0 {
+					if werr := conn.WriteFrame(ChannelStdin, buf[:n]); werr != nil {
+						return
+					}
+				}
+				if err != nil {
+					// An empty frame tells the remote side input is done
+					conn.WriteFrame(ChannelStdin, nil)
+					return
+				}
+			}
+		}()
+	}
+
+	if streams.Resize != nil {
+		go func() {
+			for size := range streams.Resize {
+				data, err := json.Marshal(size)
+				if err != nil {
+					continue
+				}
+				if err := conn.WriteFrame(ChannelResize, data); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		ch, payload, err := conn.ReadFrame()
+		if err != nil {
+			if ctx.Err() != nil {
+				return -1, ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return -1, fmt.Errorf("stream closed before the command exited")
+			}
+			return -1, err
+		}
+
+		switch ch {
+		case ChannelStdout:
+			if streams.Stdout != nil {
+				if _, err := streams.Stdout.Write(payload); err != nil {
+					return -1, fmt.Errorf("failed to write stdout: %w", err)
+				}
+			}
+		case ChannelStderr:
+			if streams.Stderr != nil {
+				if _, err := streams.Stderr.Write(payload); err != nil {
+					return -1, fmt.Errorf("failed to write stderr: %w", err)
+				}
+			}
+		case ChannelStatus:
+			var status Status
+			if err := json.Unmarshal(payload, &status); err != nil {
+				return -1, fmt.Errorf("failed to decode status: %w", err)
+			}
+			if status.Error != "" {
+				return status.ExitCode, errors.New(status.Error)
+			}
+			return status.ExitCode, nil
+		}
+	}
+}
+
+// dialFunc opens the connection a request to u is sent over
+type dialFunc func(ctx context.Context, u *url.URL) (net.Conn, error)
+
+// dialUpgrade connects to u, sends req as an upgrade request and waits for the switch
+func dialUpgrade(ctx context.Context, u *url.URL, req *http.Request, dial dialFunc) (*Conn, error) {
+	netConn, err := dial(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", ProtocolName)
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("failed to send upgrade request: %w", err)
+	}
+
+	reader := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("failed to read upgrade response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer netConn.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return newConn(netConn, reader), nil
+}
+
+// dial opens a TCP connection, wrapped in TLS for https URLs
+func dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", host, err)
+	}
+
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with %s failed: %w", host, err)
+		}
+		return tlsConn, nil
+	}
+	return conn, nil
+}
@@ -0,0 +1,200 @@
+// Package remotecommand implements the streaming protocol used to run commands
+// inside containers. A client upgrades an HTTP request to ProtocolName and then
+// both sides exchange frames multiplexing stdin, stdout, stderr and the final
+// exit status over the one connection.
+package remotecommand
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// ProtocolName is the value of the Upgrade header selecting the stream protocol
+const ProtocolName = "minik8s-stream/v1"
+
+// maxFrameSize bounds the payload of a single frame
+const maxFrameSize = 1 << 20
+
+// Channel identifies the stream a frame belongs to
+type Channel byte
+
+const (
+	ChannelStdin Channel = iota
+	ChannelStdout
+	ChannelStderr
+	// ChannelStatus carries the JSON encoded Status and ends the session
+	ChannelStatus
+	// ChannelResize carries a JSON encoded TerminalSize from the client
+	ChannelResize
+)
+
+// Status reports how the remote command finished
+type Status struct {
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// TerminalSize is the size of the client's terminal in characters
+type TerminalSize struct {
+	Width  uint16 `json:"width"`
+	Height uint16 `json:"height"`
+}
+
+// Options describe the command to run and which streams to attach. Attach
+// requests leave Command empty.
+type Options struct {
+	Container string
+	Command   []string
+	Stdin     bool
+	Stdout    bool
+	Stderr    bool
+	TTY       bool
+}
+
+// Query encodes the options as URL query parameters
+func (o *Options) Query() url.Values {
+	query := url.Values{}
+	if o.Container != "" {
+		query.Set("container", o.Container)
+	}
+	for _, arg := range o.Command {
+		query.Add("command", arg)
+	}
+	query.Set("stdin", strconv.FormatBool(o.Stdin))
+	query.Set("stdout", strconv.FormatBool(o.Stdout))
+	query.Set("stderr", strconv.FormatBool(o.Stderr))
+	query.Set("tty", strconv.FormatBool(o.TTY))
+	return query
+}
+
+// ParseOptions decodes options from URL query parameters
+func ParseOptions(query url.Values) (*Options, error) {
+	opts := &Options{
+		Container: query.Get("container"),
+		Command:   query["command"],
+	}
+
+	for name, dst := range map[string]*bool{
+		"stdin":  &opts.Stdin,
+		"stdout": &opts.Stdout,
+		"stderr": &opts.Stderr,
+		"tty":    &opts.TTY,
+	} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s parameter %q", name, value)
+		}
+		*dst = parsed
+	}
+
+	// A terminal merges stderr into stdout
+	if opts.TTY {
+		opts.Stderr = false
+	}
+	if !opts.Stdin && !opts.Stdout && !opts.Stderr {
+		return nil, fmt.Errorf("at least one of stdin, stdout or stderr must be requested")
+	}
+
+	return opts, nil
+}
+
+// Conn is an upgraded connection carrying frames. Writes are safe for
+// concurrent use; reads must come from a single goroutine.
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// newConn wraps conn, reading through reader which may hold buffered bytes
+func newConn(conn net.Conn, reader *bufio.Reader) *Conn {
+	if reader == nil {
+		reader = bufio.NewReader(conn)
+	}
+	return &Conn{conn: conn, reader: reader}
+}
+
+// WriteFrame sends payload on channel ch. An empty stdin frame signals end of input.
+func (c *Conn) WriteFrame(ch Channel, payload []byte) error {
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds limit of %d", len(payload), maxFrameSize)
+	}
+
+	header := make([]byte, 5)
+	header[0] = byte(ch)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	return nil
+}
+
+// ReadFrame reads the next frame
+func (c *Conn) ReadFrame() (Channel, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit of %d", size, maxFrameSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return 0, nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+	return Channel(header[0]), payload, nil
+}
+
+// WriteStatus sends the final status of the command
+func (c *Conn) WriteStatus(status *Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+	return c.WriteFrame(ChannelStatus, data)
+}
+
+// Close closes the underlying connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// frameWriter adapts one output channel of a Conn to an io.Writer
+type frameWriter struct {
+	conn    *Conn
+	channel Channel
+}
+
+// Write sends p as one or more frames
+func (w *frameWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxFrameSize {
+			chunk = chunk[:maxFrameSize]
+		}
+		if err := w.conn.WriteFrame(w.channel, chunk); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
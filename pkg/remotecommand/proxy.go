@@ -0,0 +1,108 @@
+package remotecommand
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Proxy forwards the upgrade request r to backend and, once the backend
+// switches protocols, splices the client and backend connections together.
+// Error responses from the backend are relayed to the client unchanged. The
+// backend is sent token as its bearer token, if set.
+func Proxy(w http.ResponseWriter, r *http.Request, backend *url.URL, token string) {
+	if !IsUpgradeRequest(r) {
+		http.Error(w, fmt.Sprintf("expected upgrade to %s", ProtocolName), http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	backendConn, err := dial(r.Context(), backend)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer backendConn.Close()
+
+	// Credentials for the API server are not meant for the backend, which
+	// gets its own
+	outReq := r.Clone(r.Context())
+	outReq.URL = backend
+	outReq.Host = backend.Host
+	outReq.RequestURI = ""
+	outReq.Body = http.NoBody
+	outReq.ContentLength = 0
+	outReq.Header.Del("Authorization")
+	if token != "" {
+		outReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	if err := outReq.Write(backendConn); err != nil {
+		http.Error(w, fmt.Sprintf("failed to forward request: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	backendReader := bufio.NewReader(backendConn)
+	resp, err := http.ReadResponse(backendReader, outReq)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read backend response: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		for name, values := range resp.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	clientConn, clientRW, err := hijacker.Hijack()
+	if err != nil {
+		fmt.Printf("Error hijacking connection: %v\n", err)
+		return
+	}
+	defer clientConn.Close()
+
+	// Lift any deadlines left by the server's timeouts; the session lasts as long as it needs
+	clientConn.SetDeadline(time.Time{})
+
+	fmt.Fprintf(clientConn, "HTTP/1.1 %s\r\n", resp.Status)
+	resp.Header.Write(clientConn)
+	if _, err := io.WriteString(clientConn, "\r\n"); err != nil {
+		return
+	}
+
+	// Copy until either side hangs up, then close both to release the other copy
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			clientConn.Close()
+			backendConn.Close()
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+		io.Copy(backendConn, clientRW.Reader)
+	}()
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+		io.Copy(clientConn, backendReader)
+	}()
+	wg.Wait()
+}
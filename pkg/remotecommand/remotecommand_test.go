@@ -0,0 +1,141 @@
+package remotecommand
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler serves a command that copies stdin to stdout and exits with 3
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	opts, err := ParseOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	Serve(w, r, opts, func(ctx context.Context, streams Streams) (int, error) {
+		if _, err := io.Copy(streams.Stdout, streams.Stdin); err != nil {
+			return -1, err
+		}
+		fmt.Fprintf(streams.Stderr, "ran %s", strings.Join(opts.Command, " "))
+		return 3, nil
+	})
+}
+
+func TestOptions_RoundTrip(t *testing.T) {
+	opts := &Options{Container: "app", Command: []string{"tar", "cf", "-"}, Stdin: true, Stdout: true, Stderr: true}
+
+	parsed, err := ParseOptions(opts.Query())
+	require.NoError(t, err)
+	assert.Equal(t, opts, parsed)
+
+	_, err = ParseOptions(url.Values{})
+	assert.Error(t, err, "a request without any stream should be rejected")
+
+	tty, err := ParseOptions((&Options{Stdout: true, Stderr: true, TTY: true}).Query())
+	require.NoError(t, err)
+	assert.False(t, tty.Stderr, "a terminal merges stderr into stdout")
+}
+
+func TestStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer server.Close()
+
+	opts := &Options{Command: []string{"cat"}, Stdin: true, Stdout: true, Stderr: true}
+	input := bytes.Repeat([]byte("minik8s"), 100000)
+	var stdout, stderr bytes.Buffer
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exitCode, err := Stream(ctx, server.URL+"?"+opts.Query().Encode(), nil, Streams{
+		Stdin:  bytes.NewReader(input),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+	assert.Equal(t, input, stdout.Bytes())
+	assert.Equal(t, "ran cat", stderr.String())
+}
+
+func TestStream_RejectedUpgrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer server.Close()
+
+	_, err := Stream(context.Background(), server.URL, nil, Streams{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+}
+
+func TestProxy(t *testing.T) {
+	var forwardedAuth string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedAuth = r.Header.Get("Authorization")
+		echoHandler(w, r)
+	}))
+	defer backend.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, _ := url.Parse(backend.URL)
+		target.RawQuery = r.URL.RawQuery
+		Proxy(w, r, target, "node-secret")
+	}))
+	defer proxy.Close()
+
+	opts := &Options{Command: []string{"cat"}, Stdin: true, Stdout: true, Stderr: true}
+	var stdout, stderr bytes.Buffer
+	header := http.Header{"Authorization": []string{"Bearer secret"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exitCode, err := Stream(ctx, proxy.URL+"?"+opts.Query().Encode(), header, Streams{
+		Stdin:  strings.NewReader("hello"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+	assert.Equal(t, "hello", stdout.String())
+	assert.Equal(t, "Bearer node-secret", forwardedAuth, "API server credentials must not reach the backend")
+}
+
+func TestStream_Resize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := ParseOptions(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Serve(w, r, opts, func(ctx context.Context, streams Streams) (int, error) {
+			size := <-streams.Resize
+			fmt.Fprintf(streams.Stdout, "%dx%d", size.Width, size.Height)
+			return 0, nil
+		})
+	}))
+	defer server.Close()
+
+	resize := make(chan TerminalSize, 1)
+	resize <- TerminalSize{Width: 120, Height: 40}
+
+	opts := &Options{Stdin: true, Stdout: true, TTY: true}
+	var stdout bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exitCode, err := Stream(ctx, server.URL+"?"+opts.Query().Encode(), nil, Streams{
+		Stdout: &stdout,
+		Resize: resize,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "120x40", stdout.String())
+}
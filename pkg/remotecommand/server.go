@@ -0,0 +1,154 @@
+package remotecommand
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Streams are the local ends of the remote command's standard streams; nil streams are not attached.
+// Resize delivers terminal size changes and is only set for TTY sessions.
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Resize <-chan TerminalSize
+}
+
+// Executor runs a command against streams and returns its exit code
+type Executor func(ctx context.Context, streams Streams) (int, error)
+
+// IsUpgradeRequest reports whether r asks to switch to the stream protocol
+func IsUpgradeRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), ProtocolName) &&
+		headerContainsToken(r.Header, "Connection", "upgrade")
+}
+
+// Upgrade takes over the connection of r and switches it to the stream protocol
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if !IsUpgradeRequest(r) {
+		http.Error(w, fmt.Sprintf("expected upgrade to %s", ProtocolName), http.StatusBadRequest)
+		return nil, fmt.Errorf("request is not an upgrade to %s", ProtocolName)
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hijack connection: %w", err)
+	}
+
+	// Lift any deadlines left by the server's timeouts; the session lasts as long as it needs
+	conn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: " + ProtocolName + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to write upgrade response: %w", err)
+	}
+
+	return newConn(conn, rw.Reader), nil
+}
+
+// Serve upgrades r and runs exec with streams wired to the connection as
+// requested by opts. The exit status is sent to the client before closing.
+func Serve(w http.ResponseWriter, r *http.Request, opts *Options, exec Executor) {
+	conn, err := Upgrade(w, r)
+	if err != nil {
+		fmt.Printf("Error upgrading stream request: %v\n", err)
+		return
+	}
+	defer conn.Close()
+
+	// The server no longer watches a hijacked connection, so the frame reader
+	// cancels ctx when the client goes away
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var streams Streams
+	var stdinWriter *io.PipeWriter
+	if opts.Stdin {
+		var stdinReader *io.PipeReader
+		stdinReader, stdinWriter = io.Pipe()
+		defer stdinReader.Close()
+		streams.Stdin = stdinReader
+	}
+	if opts.Stdout {
+		streams.Stdout = &frameWriter{conn: conn, channel: ChannelStdout}
+	}
+	if opts.Stderr {
+		streams.Stderr = &frameWriter{conn: conn, channel: ChannelStderr}
+	}
+	var resize chan TerminalSize
+	if opts.TTY {
+		resize = make(chan TerminalSize, 1)
+		streams.Resize = resize
+	}
+
+	go func() {
+		defer cancel()
+		for {
+			ch, payload, err := conn.ReadFrame()
+			if err != nil {
+				if stdinWriter != nil {
+					stdinWriter.CloseWithError(io.ErrUnexpectedEOF)
+				}
+				return
+			}
+			if ch == ChannelResize && resize != nil {
+				var size TerminalSize
+				if err := json.Unmarshal(payload, &size); err == nil {
+					// Only the latest size matters, replace a pending one
+					select {
+					case <-resize:
+					default:
+					}
+					resize <- size
+				}
+				continue
+			}
+			if ch != ChannelStdin || stdinWriter == nil {
+				continue
+			}
+			if len(payload) == 0 {
+				stdinWriter.Close()
+				continue
+			}
+			if _, err := stdinWriter.Write(payload); err != nil {
+				// The command stopped reading; drop the rest of its input
+				continue
+			}
+		}
+	}()
+
+	exitCode, err := exec(ctx, streams)
+	status := &Status{ExitCode: exitCode}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if err := conn.WriteStatus(status); err != nil {
+		fmt.Printf("Error writing stream status: %v\n", err)
+	}
+}
+
+// headerContainsToken reports whether the comma-separated header contains token
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+	assert.Len(t, a, 32)
+	assert.True(t, Valid(a))
+	assert.NotEqual(t, a, b)
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("3f2a-b_c.d:e"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("has space"))
+	assert.False(t, Valid("line\nbreak"))
+	assert.False(t, Valid(strings.Repeat("a", 129)))
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, "", From(context.Background()))
+	assert.Equal(t, "abc", From(WithID(context.Background(), "abc")))
+}
@@ -0,0 +1,306 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// DefaultAssumedPodTTL is how long a pod assumed onto a node keeps its place
+// in the cache after binding it, waiting for the store to report the binding
+const DefaultAssumedPodTTL = 30 * time.Second
+
+// nodeInfo is what the scheduler knows about a node: the node itself and the
+// pods bound or assumed to it, with the resources they request
+type nodeInfo struct {
+	// node is nil while pods are bound to a node the cache hasn't seen
+	node *api.Node
+	pods map[string]*api.Pod
+
+	// requested sums the pods' requests of each of api.StandardResources
+	requested map[api.ResourceName]float64
+}
+
+func newNodeInfo(node *api.Node) *nodeInfo {
+	return &nodeInfo{node: node, pods: make(map[string]*api.Pod), requested: make(map[api.ResourceName]float64)}
+}
+
+func (n *nodeInfo) addPod(key string, pod *api.Pod) {
+	n.pods[key] = pod
+	for _, name := range api.StandardResources {
+		n.requested[name] += api.PodRequest(pod, name)
+	}
+}
+
+func (n *nodeInfo) removePod(key string) {
+	pod, ok := n.pods[key]
+	if !ok {
+		return
+	}
+	delete(n.pods, key)
+	for _, name := range api.StandardResources {
+		n.requested[name] -= api.PodRequest(pod, name)
+	}
+}
+
+// clone copies n, so a snapshot isn't changed by later events
+func (n *nodeInfo) clone() *nodeInfo {
+	copied := *n
+	copied.pods = make(map[string]*api.Pod, len(n.pods))
+	for key, pod := range n.pods {
+		copied.pods[key] = pod
+	}
+	copied.requested = make(map[api.ResourceName]float64, len(n.requested))
+	for name, quantity := range n.requested {
+		copied.requested[name] = quantity
+	}
+	return &copied
+}
+
+// cachedPod is a pod the cache counts against a node
+type cachedPod struct {
+	pod *api.Pod
+
+	// assumed pods were placed by this scheduler but their binding hasn't
+	// been seen in the store yet. Once bound, they expire at deadline.
+	assumed  bool
+	bound    bool
+	deadline time.Time
+}
+
+// schedulerCache keeps the nodes and the pods placed on them up to date from
+// store events, so scheduling a pod doesn't list the cluster. Pods the
+// scheduler assumed onto a node count against it right away, so pods placed
+// back to back in one cycle see each other's resource requests.
+type schedulerCache struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	nodes map[string]*nodeInfo
+	pods  map[string]*cachedPod
+
+	nodesSynced bool
+	podsSynced  bool
+}
+
+func newSchedulerCache(ttl time.Duration) *schedulerCache {
+	return &schedulerCache{
+		ttl:   ttl,
+		nodes: make(map[string]*nodeInfo),
+		pods:  make(map[string]*cachedPod),
+	}
+}
+
+func podKey(pod *api.Pod) string {
+	return fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+}
+
+// synced reports whether both nodes and pods have been listed
+func (c *schedulerCache) synced() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nodesSynced && c.podsSynced
+}
+
+// replaceNodes resets the cached nodes to those listed
+func (c *schedulerCache) replaceNodes(objs []store.Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listed := make(map[string]bool, len(objs))
+	for _, obj := range objs {
+		if node, ok := obj.(*api.Node); ok {
+			listed[node.Name] = true
+			c.setNodeLocked(node)
+		}
+	}
+	for name := range c.nodes {
+		if !listed[name] {
+			c.removeNodeLocked(name)
+		}
+	}
+	c.nodesSynced = true
+}
+
+// replacePods resets the cached pods to those listed. Assumed pods the list
+// doesn't show bound yet are kept until they expire.
+func (c *schedulerCache) replacePods(objs []store.Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listed := make(map[string]bool, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*api.Pod); ok {
+			listed[podKey(pod)] = true
+			c.setPodLocked(pod)
+		}
+	}
+	for key, cached := range c.pods {
+		if !listed[key] && !cached.assumed {
+			c.removePodLocked(key)
+		}
+	}
+	c.podsSynced = true
+}
+
+// apply updates the cache with a watch event for a node or pod
+func (c *schedulerCache) apply(event store.WatchEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch obj := event.Object.(type) {
+	case *api.Node:
+		if event.Type == store.Deleted {
+			c.removeNodeLocked(obj.Name)
+		} else {
+			c.setNodeLocked(obj)
+		}
+	case *api.Pod:
+		if event.Type == store.Deleted {
+			c.removePodLocked(podKey(obj))
+		} else {
+			c.setPodLocked(obj)
+		}
+	}
+}
+
+func (c *schedulerCache) setNodeLocked(node *api.Node) {
+	if info, ok := c.nodes[node.Name]; ok {
+		info.node = node
+		return
+	}
+	c.nodes[node.Name] = newNodeInfo(node)
+}
+
+// removeNodeLocked forgets a node. Its pods are kept until they're deleted
+// too, in case the node registers again.
+func (c *schedulerCache) removeNodeLocked(name string) {
+	info, ok := c.nodes[name]
+	if !ok {
+		return
+	}
+	if len(info.pods) == 0 {
+		delete(c.nodes, name)
+		return
+	}
+	info.node = nil
+}
+
+// setPodLocked records pod as the store reports it. Pods that aren't bound,
+// or have finished, don't take up a node.
+func (c *schedulerCache) setPodLocked(pod *api.Pod) {
+	key := podKey(pod)
+	if cached, ok := c.pods[key]; ok && cached.assumed && pod.Spec.NodeName == "" {
+		// An event from before the binding was written
+		return
+	}
+	c.removePodLocked(key)
+	if pod.Spec.NodeName == "" || pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+		return
+	}
+	c.addPodLocked(key, &cachedPod{pod: pod})
+}
+
+func (c *schedulerCache) addPodLocked(key string, cached *cachedPod) {
+	info, ok := c.nodes[cached.pod.Spec.NodeName]
+	if !ok {
+		info = newNodeInfo(nil)
+		c.nodes[cached.pod.Spec.NodeName] = info
+	}
+	info.addPod(key, cached.pod)
+	c.pods[key] = cached
+}
+
+func (c *schedulerCache) removePodLocked(key string) {
+	cached, ok := c.pods[key]
+	if !ok {
+		return
+	}
+	delete(c.pods, key)
+	nodeName := cached.pod.Spec.NodeName
+	if info, ok := c.nodes[nodeName]; ok {
+		info.removePod(key)
+		if info.node == nil && len(info.pods) == 0 {
+			delete(c.nodes, nodeName)
+		}
+	}
+}
+
+// assumePod counts pod, already given a node name, against that node before
+// its binding is written, replacing whatever the cache knew of it
+func (c *schedulerCache) assumePod(pod *api.Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := podKey(pod)
+	c.removePodLocked(key)
+	c.addPodLocked(key, &cachedPod{pod: pod, assumed: true})
+}
+
+// finishBinding starts the expiry of an assumed pod whose binding was
+// written: if the store doesn't report it bound within the TTL, the binding
+// is taken to have been lost
+func (c *schedulerCache) finishBinding(pod *api.Pod, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.pods[podKey(pod)]; ok && cached.assumed {
+		cached.bound = true
+		cached.deadline = now.Add(c.ttl)
+	}
+}
+
+// forgetPod drops an assumed pod whose binding failed
+func (c *schedulerCache) forgetPod(pod *api.Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := podKey(pod)
+	if cached, ok := c.pods[key]; ok && cached.assumed {
+		c.removePodLocked(key)
+	}
+}
+
+// expireAssumedPods drops bound assumed pods whose deadline has passed
+func (c *schedulerCache) expireAssumedPods(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, cached := range c.pods {
+		if cached.assumed && cached.bound && now.After(cached.deadline) {
+			c.removePodLocked(key)
+		}
+	}
+}
+
+// snapshot returns copies of the nodes the cache has seen, by name
+func (c *schedulerCache) snapshot() []*nodeInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	nodes := make([]*nodeInfo, 0, len(c.nodes))
+	for _, info := range c.nodes {
+		if info.node != nil {
+			nodes = append(nodes, info.clone())
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].node.Name < nodes[j].node.Name })
+	return nodes
+}
+
+// watchCache keeps the cache's objects of kind up to date: it lists them,
+// then applies watch events, and lists again whenever the watch fails
+func (s *Scheduler) watchCache(ctx context.Context, kind string, replace func([]store.Object)) {
+	reflector := &store.Reflector{
+		Name:    "scheduler",
+		Store:   s.store,
+		Kind:    kind,
+		Replace: replace,
+		Apply:   s.cache.apply,
+	}
+	reflector.Run(ctx, s.stopCh)
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// reservation is a pod assumed onto a node but not bound yet
+type reservation struct {
+	pod  *api.Pod
+	node *api.Node
+}
+
+// schedulePodGroup schedules the pending pods of one pod group all or
+// nothing. Each pod is reserved on a node in turn, so they're checked against
+// each other's requests; unless enough of them fit to bring the group to its
+// minMember, the reservations are released and none is bound.
+func (s *Scheduler) schedulePodGroup(ctx context.Context, pending []*api.Pod) error {
+	namespace, name := pending[0].Namespace, api.PodGroupName(pending[0])
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Name < pending[j].Name })
+
+	obj, err := s.store.Get(ctx, "PodGroup", namespace, name)
+	if errors.Is(err, store.ErrNotFound) {
+		s.markGroupUnschedulable(ctx, pending, fmt.Sprintf("pod group %s not found", name))
+		return fmt.Errorf("failed to get pod group %s: %w", name, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to get pod group %s: %w", name, err)
+	}
+	group, ok := obj.(*api.PodGroup)
+	if !ok {
+		return fmt.Errorf("object %s is not a PodGroup", name)
+	}
+
+	bound := s.cache.podGroupMembers(namespace, name)
+	needed := int(group.Spec.MinMember) - bound
+	if len(pending) < needed {
+		message := fmt.Sprintf("pod group %s has %d of the %d pods it needs", name, bound+len(pending), group.Spec.MinMember)
+		s.markGroupUnschedulable(ctx, pending, message)
+		s.updatePodGroupStatus(ctx, group, bound, message)
+		return nil
+	}
+
+	var reserved []reservation
+	unfit := make(map[*api.Pod]error)
+	for _, pod := range pending {
+		node, err := s.reservePod(ctx, pod)
+		if err != nil {
+			unfit[pod] = err
+			continue
+		}
+		reserved = append(reserved, reservation{pod: pod, node: node})
+	}
+	if len(reserved) < needed {
+		// Hold nothing for a group that can't run yet
+		for _, r := range reserved {
+			s.cache.forgetPod(r.pod)
+		}
+		message := fmt.Sprintf("only %d of the %d pods pod group %s needs fit on the nodes", bound+len(reserved), group.Spec.MinMember, name)
+		s.markGroupUnschedulable(ctx, pending, message)
+		s.updatePodGroupStatus(ctx, group, bound, message)
+		return errors.New(message)
+	}
+
+	for _, r := range reserved {
+		if err := s.bindPod(ctx, r.pod, r.node); err != nil {
+			fmt.Printf("Failed to bind pod %s of group %s: %v\n", r.pod.Name, name, err)
+			continue
+		}
+		bound++
+	}
+	// Members beyond minMember that didn't fit wait like any other pod
+	for _, pod := range pending {
+		if err, ok := unfit[pod]; ok {
+			s.markUnschedulable(ctx, pod, err.Error())
+		}
+	}
+	s.updatePodGroupStatus(ctx, group, bound, "")
+	return nil
+}
+
+// markGroupUnschedulable marks each of pods unschedulable for the same reason
+func (s *Scheduler) markGroupUnschedulable(ctx context.Context, pods []*api.Pod, message string) {
+	for _, pod := range pods {
+		s.markUnschedulable(ctx, pod, message)
+	}
+}
+
+// updatePodGroupStatus records how many of group's pods are bound, writing
+// the status only when it changed
+func (s *Scheduler) updatePodGroupStatus(ctx context.Context, group *api.PodGroup, bound int, message string) {
+	status := api.PodGroupStatus{Phase: api.PodGroupPending, Scheduled: int32(bound), Message: message}
+	if status.Scheduled >= group.Spec.MinMember {
+		status = api.PodGroupStatus{Phase: api.PodGroupScheduled, Scheduled: int32(bound)}
+	}
+	if group.Status == status {
+		return
+	}
+	group.Status = status
+	if err := s.store.Update(ctx, group); err != nil {
+		fmt.Printf("Failed to update status of pod group %s: %v\n", group.Name, err)
+	}
+}
+
+// podGroupMembers returns how many pods of the named group in namespace are
+// bound or assumed to a node
+func (c *schedulerCache) podGroupMembers(namespace, name string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	members := 0
+	for _, cached := range c.pods {
+		if cached.pod.Namespace == namespace && api.PodGroupName(cached.pod) == name {
+			members++
+		}
+	}
+	return members
+}
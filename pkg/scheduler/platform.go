@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// archRepositories maps the per-architecture namespaces of the Docker
+// official images to the architecture they're built for
+var archRepositories = map[string]string{
+	"amd64":   "amd64",
+	"arm64v8": "arm64",
+	"arm32v7": "arm",
+	"arm32v6": "arm",
+	"i386":    "386",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+}
+
+// archTagSuffixes are tag suffixes commonly used for single-architecture images
+var archTagSuffixes = []string{"amd64", "arm64", "arm", "386", "ppc64le", "s390x"}
+
+// nodeLabel returns the value of a node label. Nodes registered before the
+// agent started labeling them fall back to the os and architecture they
+// report in their status.
+func nodeLabel(node *api.Node, key string) (string, bool) {
+	if value, ok := node.Labels[key]; ok {
+		return value, true
+	}
+
+	var value string
+	switch key {
+	case api.LabelArch:
+		value = node.Status.NodeInfo.Architecture
+	case api.LabelOS:
+		value = node.Status.NodeInfo.OperatingSystem
+	}
+	return value, value != ""
+}
+
+// matchesPlatform checks that every image in the pod can run on the node's
+// architecture. Multi-architecture images and nodes that don't report an
+// architecture always match.
+func (s *Scheduler) matchesPlatform(pod *api.Pod, node *api.Node) bool {
+	nodeArch, ok := nodeLabel(node, api.LabelArch)
+	if !ok {
+		return true
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if arch := imageArchitecture(container.Image); arch != "" && arch != nodeArch {
+			return false
+		}
+	}
+	return true
+}
+
+// imageArchitecture guesses the architecture a single-architecture image was
+// built for from its reference, e.g. arm64v8/nginx or app:1.0-amd64. It
+// returns "" for images that don't name one, which are assumed to be
+// multi-architecture.
+func imageArchitecture(image string) string {
+	// Drop the digest and split off the tag
+	image, _, _ = strings.Cut(image, "@")
+	repository, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository, tag = image[:i], image[i+1:]
+	}
+
+	// Skip the registry host, which is the first component when it looks like one
+	components := strings.Split(repository, "/")
+	if len(components) > 1 && strings.ContainsAny(components[0], ".:") {
+		components = components[1:]
+	}
+	if len(components) > 1 {
+		if arch, ok := archRepositories[components[0]]; ok {
+			return arch
+		}
+	}
+
+	for _, arch := range archTagSuffixes {
+		if tag == arch || strings.HasSuffix(tag, "-"+arch) {
+			return arch
+		}
+	}
+	return ""
+}
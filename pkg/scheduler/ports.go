@@ -0,0 +1,32 @@
+package scheduler
+
+import "github.com/minik8s/minik8s/pkg/api"
+
+// podsByNode groups the pods that are bound to a node, still running and
+// using host ports by node
+func podsByNode(pods []*api.Pod) map[string][]*api.Pod {
+	byNode := make(map[string][]*api.Pod)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		if len(api.PodHostPorts(pod)) > 0 {
+			byNode[pod.Spec.NodeName] = append(byNode[pod.Spec.NodeName], pod)
+		}
+	}
+	return byNode
+}
+
+// hasFreeHostPorts checks that no pod on node already takes one of pod's
+// host ports. nodePods are the pods using host ports on each node.
+func (s *Scheduler) hasFreeHostPorts(pod *api.Pod, node *api.Node, nodePods map[string][]*api.Pod) bool {
+	for _, other := range nodePods[node.Name] {
+		if other.Namespace == pod.Namespace && other.Name == pod.Name {
+			continue
+		}
+		if _, conflict := api.HostPortConflict(pod, other); conflict {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// withRuntimeClass returns pod with the node selector of its RuntimeClass
+// added to its own, so it only lands on nodes that have the runtime. Pods
+// without a class, or whose class doesn't constrain scheduling, are returned
+// as they are.
+func (s *Scheduler) withRuntimeClass(ctx context.Context, pod *api.Pod) (*api.Pod, error) {
+	if pod.Spec.RuntimeClassName == "" {
+		return pod, nil
+	}
+	obj, err := s.store.Get(ctx, "RuntimeClass", "", pod.Spec.RuntimeClassName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RuntimeClass %s: %w", pod.Spec.RuntimeClassName, err)
+	}
+	runtimeClass, ok := obj.(*api.RuntimeClass)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a RuntimeClass", pod.Spec.RuntimeClassName)
+	}
+	if runtimeClass.Scheduling == nil || len(runtimeClass.Scheduling.NodeSelector) == 0 {
+		return pod, nil
+	}
+
+	selector := make(map[string]string, len(pod.Spec.NodeSelector)+len(runtimeClass.Scheduling.NodeSelector))
+	for key, value := range pod.Spec.NodeSelector {
+		selector[key] = value
+	}
+	for key, value := range runtimeClass.Scheduling.NodeSelector {
+		if existing, exists := selector[key]; exists && existing != value {
+			return nil, fmt.Errorf("node selector %s=%s conflicts with %s=%s of RuntimeClass %s", key, existing, key, value, runtimeClass.Name)
+		}
+		selector[key] = value
+	}
+
+	scheduled := *pod
+	scheduled.Spec.NodeSelector = selector
+	return &scheduled, nil
+}
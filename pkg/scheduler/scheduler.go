@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/events"
 	"github.com/minik8s/minik8s/pkg/store"
 )
 
@@ -15,11 +16,14 @@ type Scheduler struct {
 	mu sync.RWMutex
 
 	// Configuration
-	store store.Store
+	store    store.Store
+	recorder *events.Recorder
+	cache    *schedulerCache
 
 	// State
 	running       bool
 	stopCh        chan struct{}
+	loop          sync.WaitGroup
 	scheduledPods map[string]*ScheduledPod
 
 	// Scheduling configuration
@@ -40,6 +44,13 @@ type Config struct {
 	Store               store.Store
 	DefaultNodeSelector map[string]string
 	SchedulingInterval  time.Duration
+
+	// Recorder records scheduling outcomes as events on pods; nil disables them
+	Recorder *events.Recorder
+
+	// AssumedPodTTL is how long a pod the scheduler bound counts against its
+	// node before the store reports the binding; DefaultAssumedPodTTL if 0
+	AssumedPodTTL time.Duration
 }
 
 // NewScheduler creates a new scheduler
@@ -47,9 +58,14 @@ func NewScheduler(config *Config) *Scheduler {
 	if config.SchedulingInterval == 0 {
 		config.SchedulingInterval = 10 * time.Second
 	}
+	if config.AssumedPodTTL == 0 {
+		config.AssumedPodTTL = DefaultAssumedPodTTL
+	}
 
 	return &Scheduler{
 		store:               config.Store,
+		recorder:            config.Recorder,
+		cache:               newSchedulerCache(config.AssumedPodTTL),
 		defaultNodeSelector: config.DefaultNodeSelector,
 		schedulingInterval:  config.SchedulingInterval,
 		scheduledPods:       make(map[string]*ScheduledPod),
@@ -67,23 +83,32 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	}
 
 	// Start background goroutines
-	go s.schedulingLoop(ctx)
+	go s.watchCache(ctx, "Node", s.cache.replaceNodes)
+	go s.watchCache(ctx, "Pod", s.cache.replacePods)
+	s.loop.Add(1)
+	go func() {
+		defer s.loop.Done()
+		s.schedulingLoop(ctx)
+	}()
 
 	s.running = true
 	return nil
 }
 
-// Stop stops the scheduler
+// Stop stops the scheduler, returning once the pods it's scheduling have
+// been bound
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.running {
+		s.mu.Unlock()
 		return
 	}
 
 	close(s.stopCh)
 	s.running = false
+	s.mu.Unlock()
+
+	s.loop.Wait()
 }
 
 // schedulingLoop continuously processes unscheduled pods
@@ -106,25 +131,26 @@ func (s *Scheduler) schedulingLoop(ctx context.Context) {
 	}
 }
 
-// processUnscheduledPods finds and schedules unscheduled pods
+// processUnscheduledPods finds and schedules unscheduled pods. Nodes and the
+// pods on them come from the cache, so nothing is scheduled until it has
+// listed them.
 func (s *Scheduler) processUnscheduledPods(ctx context.Context) error {
-	// Get all pods
-	pods, err := s.store.List(ctx, "Pod", "")
-	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+	if !s.cache.synced() {
+		return nil
 	}
+	s.cache.expireAssumedPods(time.Now())
 
-	// Get all nodes
-	nodes, err := s.store.List(ctx, "Node", "")
+	// Get all pods
+	pods, err := s.store.List(ctx, "Pod", store.AllNamespaces)
 	if err != nil {
-		return fmt.Errorf("failed to list nodes: %w", err)
+		return fmt.Errorf("failed to list pods: %w", err)
 	}
 
 	// Filter unscheduled pods
 	var unscheduledPods []*api.Pod
 	for _, obj := range pods {
 		if pod, ok := obj.(*api.Pod); ok {
-			if pod.Spec.NodeName == "" && pod.Status.Phase == string(api.PodPending) {
+			if pod.Spec.NodeName == "" && pod.Status.Phase == api.PodPending {
 				unscheduledPods = append(unscheduledPods, pod)
 			}
 		}
@@ -136,39 +162,91 @@ func (s *Scheduler) processUnscheduledPods(ctx context.Context) error {
 
 	fmt.Printf("Found %d unscheduled pods\n", len(unscheduledPods))
 
-	// Try to schedule each pod
+	// Try to schedule each pod. Pods of a pod group are scheduled together
+	// once the others have been.
+	groups := make(map[string][]*api.Pod)
+	var groupKeys []string
 	for _, pod := range unscheduledPods {
-		if err := s.schedulePod(ctx, pod, nodes); err != nil {
+		if name := api.PodGroupName(pod); name != "" {
+			key := fmt.Sprintf("%s/%s", pod.Namespace, name)
+			if _, seen := groups[key]; !seen {
+				groupKeys = append(groupKeys, key)
+			}
+			groups[key] = append(groups[key], pod)
+			continue
+		}
+		if err := s.schedulePod(ctx, pod); err != nil {
 			fmt.Printf("Failed to schedule pod %s: %v\n", pod.Name, err)
 		}
 	}
+	for _, key := range groupKeys {
+		if err := s.schedulePodGroup(ctx, groups[key]); err != nil {
+			fmt.Printf("Failed to schedule pod group %s: %v\n", key, err)
+		}
+	}
 
 	return nil
 }
 
 // schedulePod attempts to schedule a pod to a node
-func (s *Scheduler) schedulePod(ctx context.Context, pod *api.Pod, nodes []store.Object) error {
-	// Find the best node for this pod
-	node, err := s.findBestNode(pod, nodes)
+func (s *Scheduler) schedulePod(ctx context.Context, pod *api.Pod) error {
+	node, err := s.reservePod(ctx, pod)
 	if err != nil {
+		s.markUnschedulable(ctx, pod, err.Error())
 		return fmt.Errorf("failed to find suitable node: %w", err)
 	}
+	return s.bindPod(ctx, pod, node)
+}
+
+// reservePod finds the best node for pod and assumes the pod onto it in the
+// cache, so the next pod sees it there whether or not the store has reported
+// the binding yet. The reservation is released with forgetPod.
+func (s *Scheduler) reservePod(ctx context.Context, pod *api.Pod) (*api.Node, error) {
+	node, err := s.findBestNode(ctx, pod, s.cache.snapshot())
+	if err != nil {
+		return nil, err
+	}
+	assumed := *pod
+	assumed.Spec.NodeName = node.Name
+	s.cache.assumePod(&assumed)
+	return node, nil
+}
+
+// markUnschedulable records why pod can't be scheduled, which is what the
+// cluster autoscaler adds nodes for
+func (s *Scheduler) markUnschedulable(ctx context.Context, pod *api.Pod, message string) {
+	s.recorder.Eventf(ctx, pod, api.EventTypeWarning, "FailedScheduling", "%s", message)
+	if api.SetPodCondition(&pod.Status, api.PodCondition{
+		Type:    api.PodConditionScheduled,
+		Status:  api.ConditionFalse,
+		Reason:  api.ReasonUnschedulable,
+		Message: message,
+	}) {
+		if err := s.store.Update(ctx, pod); err != nil {
+			fmt.Printf("Failed to mark pod %s unschedulable: %v\n", pod.Name, err)
+		}
+	}
+}
 
+// bindPod assigns pod to the node it was reserved on. The pod stays
+// Pending until its node agent starts it; the PodScheduled condition tells
+// it was bound.
+func (s *Scheduler) bindPod(ctx context.Context, pod *api.Pod, node *api.Node) error {
 	// Assign the pod to the node
 	pod.Spec.NodeName = node.GetName()
-	pod.Status.Phase = string(api.PodScheduled)
-	pod.Status.Conditions = append(pod.Status.Conditions, api.PodCondition{
-		Type:               "PodScheduled",
-		Status:             "True",
-		LastTransitionTime: time.Now(),
-		Reason:             "Scheduled",
-		Message:            fmt.Sprintf("Pod scheduled to node %s", node.GetName()),
+	api.SetPodCondition(&pod.Status, api.PodCondition{
+		Type:    api.PodConditionScheduled,
+		Status:  api.ConditionTrue,
+		Reason:  "Scheduled",
+		Message: fmt.Sprintf("Pod scheduled to node %s", node.GetName()),
 	})
 
 	// Update the pod in the store
 	if err := s.store.Update(ctx, pod); err != nil {
+		s.cache.forgetPod(pod)
 		return fmt.Errorf("failed to update pod: %w", err)
 	}
+	s.cache.finishBinding(pod, time.Now())
 
 	// Track the scheduled pod
 	s.mu.Lock()
@@ -180,66 +258,28 @@ func (s *Scheduler) schedulePod(ctx context.Context, pod *api.Pod, nodes []store
 	}
 	s.mu.Unlock()
 
+	s.recorder.Eventf(ctx, pod, api.EventTypeNormal, "Scheduled", "Successfully assigned %s/%s to %s", pod.Namespace, pod.Name, node.GetName())
 	fmt.Printf("Pod %s/%s scheduled to node %s\n", pod.Namespace, pod.Name, node.GetName())
 	return nil
 }
 
-// findBestNode finds the best node for a pod
-func (s *Scheduler) findBestNode(pod *api.Pod, nodes []store.Object) (store.Object, error) {
-	var bestNode store.Object
-	var bestScore float64
-	var suitableNodes []*api.Node
-
-	// First pass: find all suitable nodes
-	for _, obj := range nodes {
-		node, ok := obj.(*api.Node)
-		if !ok {
-			continue
-		}
-
-		// Check if node is ready
-		if !s.isNodeReady(node) {
-			continue
-		}
-
-		// Check node selector
-		if !s.matchesNodeSelector(pod, node) {
-			continue
-		}
-
-		// Check resource requirements
-		if !s.hasSufficientResources(pod, node) {
-			continue
-		}
-
-		// Check taints and tolerations (basic implementation)
-		if !s.matchesTaintsAndTolerations(pod, node) {
-			continue
-		}
-
-		suitableNodes = append(suitableNodes, node)
+// findBestNode finds the best of nodes for a pod
+func (s *Scheduler) findBestNode(ctx context.Context, pod *api.Pod, nodes []*nodeInfo) (*api.Node, error) {
+	pod, err := s.withRuntimeClass(ctx, pod)
+	if err != nil {
+		return nil, err
 	}
-
-	if len(suitableNodes) == 0 {
+	best := bestNode(s.evaluateNodes(pod, nodes))
+	if best == nil {
 		return nil, fmt.Errorf("no suitable node found for pod %s", pod.Name)
 	}
-
-	// Second pass: score suitable nodes
-	for _, node := range suitableNodes {
-		score := s.calculateNodeScore(pod, node)
-		if score > bestScore {
-			bestScore = score
-			bestNode = node
-		}
-	}
-
-	return bestNode, nil
+	return best, nil
 }
 
 // isNodeReady checks if a node is ready
 func (s *Scheduler) isNodeReady(node *api.Node) bool {
 	for _, condition := range node.Status.Conditions {
-		if condition.Type == "Ready" && condition.Status == "True" {
+		if condition.Type == api.NodeReady && condition.Status == api.ConditionTrue {
 			return true
 		}
 	}
@@ -255,7 +295,7 @@ func (s *Scheduler) matchesNodeSelector(pod *api.Pod, node *api.Node) bool {
 	}
 
 	for key, value := range pod.Spec.NodeSelector {
-		if nodeValue, exists := node.Labels[key]; !exists || nodeValue != value {
+		if nodeValue, exists := nodeLabel(node, key); !exists || nodeValue != value {
 			return false
 		}
 	}
@@ -263,87 +303,40 @@ func (s *Scheduler) matchesNodeSelector(pod *api.Pod, node *api.Node) bool {
 	return true
 }
 
-// matchesTaintsAndTolerations checks if a pod can tolerate node taints
+// matchesTaintsAndTolerations checks if a pod can tolerate node taints.
+// Cordoned nodes, those with NoSchedule or NoExecute taints, only take pods
+// tolerating them; PreferNoSchedule taints are ignored.
 func (s *Scheduler) matchesTaintsAndTolerations(pod *api.Pod, node *api.Node) bool {
-	// Basic implementation - in a real system, you'd want proper taint/toleration logic
-	// For now, just return true to allow all pods
-	return true
+	return api.ToleratesNode(pod, node)
 }
 
-// hasSufficientResources checks if a node has sufficient resources
-func (s *Scheduler) hasSufficientResources(pod *api.Pod, node *api.Node) bool {
-	// Calculate total resource requests for the pod
-	var totalCPU, totalMemory float64
-
-	for _, container := range pod.Spec.Containers {
-		if container.Resources.Requests != nil {
-			if cpu, exists := container.Resources.Requests[api.ResourceCPU]; exists {
-				if cpuValue, err := parseCPU(cpu); err == nil {
-					totalCPU += cpuValue
-				}
-			}
-			if memory, exists := container.Resources.Requests[api.ResourceMemory]; exists {
-				if memoryValue, err := parseMemory(memory); err == nil {
-					totalMemory += memoryValue
-				}
-			}
+// hasSufficientResources checks if what's left of a node's allocatable
+// resources, after the requests of the pods bound or assumed to it, covers
+// pod's requests, overhead included. Resources a node doesn't report aren't
+// checked, except huge pages, which a node has none of unless it says so.
+func (s *Scheduler) hasSufficientResources(pod *api.Pod, info *nodeInfo) bool {
+	for _, name := range api.StandardResources {
+		requested := api.PodRequest(pod, name)
+		if requested <= 0 {
+			continue
 		}
-	}
-
-	// Check if node has sufficient resources
-	if totalCPU > 0 {
-		if nodeCPU, exists := node.Status.Allocatable[api.ResourceCPU]; exists {
-			if availableCPU, err := parseCPU(nodeCPU); err == nil {
-				if totalCPU > availableCPU {
-					return false
-				}
+		allocatable, exists := info.node.Status.Allocatable[name]
+		if !exists {
+			if name == api.ResourceHugePages2Mi {
+				return false
 			}
+			continue
 		}
-	}
-
-	if totalMemory > 0 {
-		if nodeMemory, exists := node.Status.Allocatable[api.ResourceMemory]; exists {
-			if availableMemory, err := parseMemory(nodeMemory); err == nil {
-				if totalMemory > availableMemory {
-					return false
-				}
-			}
+		if available, err := api.ParseQuantity(name, allocatable); err == nil && requested > available-info.requested[name] {
+			return false
 		}
 	}
-
 	return true
 }
 
 // calculateNodeScore calculates a score for a node
-func (s *Scheduler) calculateNodeScore(pod *api.Pod, node *api.Node) float64 {
-	score := 0.0
-
-	// Prefer nodes with more available resources
-	if allocatable, exists := node.Status.Allocatable[api.ResourceCPU]; exists {
-		if cpu, err := parseCPU(allocatable); err == nil {
-			score += cpu
-		}
-	}
-
-	if allocatable, exists := node.Status.Allocatable[api.ResourceMemory]; exists {
-		if memory, err := parseMemory(allocatable); err == nil {
-			score += memory / (1024 * 1024 * 1024) // Convert to GB
-		}
-	}
-
-	// Prefer nodes with fewer pods
-	s.mu.RLock()
-	nodePodCount := 0
-	for _, scheduledPod := range s.scheduledPods {
-		if scheduledPod.NodeName == node.GetName() {
-			nodePodCount++
-		}
-	}
-	s.mu.RUnlock()
-
-	score -= float64(nodePodCount)
-
-	return score
+func (s *Scheduler) calculateNodeScore(pod *api.Pod, info *nodeInfo) float64 {
+	return scoreNode(info.node, len(info.pods)).Total
 }
 
 // GetScheduledPods returns all scheduled pods
@@ -357,56 +350,3 @@ func (s *Scheduler) GetScheduledPods() map[string]*ScheduledPod {
 	}
 	return result
 }
-
-// Helper functions for resource parsing
-func parseCPU(cpu string) (float64, error) {
-	// Simple CPU parsing - in a real implementation, you'd want more robust parsing
-	if cpu == "" {
-		return 0, nil
-	}
-
-	// Handle millicores (e.g., "100m" = 0.1)
-	if len(cpu) > 1 && cpu[len(cpu)-1] == 'm' {
-		if value, err := parseFloat(cpu[:len(cpu)-1]); err == nil {
-			return value / 1000, nil
-		}
-	}
-
-	// Handle cores (e.g., "1", "0.5")
-	return parseFloat(cpu)
-}
-
-func parseMemory(memory string) (float64, error) {
-	// Simple memory parsing - in a real implementation, you'd want more robust parsing
-	if memory == "" {
-		return 0, nil
-	}
-
-	// Handle bytes (e.g., "1Gi", "512Mi")
-	if len(memory) > 2 {
-		suffix := memory[len(memory)-2:]
-		value, err := parseFloat(memory[:len(memory)-2])
-		if err != nil {
-			return 0, err
-		}
-
-		switch suffix {
-		case "Ki":
-			return value * 1024, nil
-		case "Mi":
-			return value * 1024 * 1024, nil
-		case "Gi":
-			return value * 1024 * 1024 * 1024, nil
-		}
-	}
-
-	// Assume bytes
-	return parseFloat(memory)
-}
-
-func parseFloat(s string) (float64, error) {
-	// Simple float parsing - in a real implementation, you'd want more robust parsing
-	var result float64
-	_, err := fmt.Sscanf(s, "%f", &result)
-	return result, err
-}
@@ -9,6 +9,20 @@ import (
 	"github.com/minik8s/minik8s/pkg/store"
 )
 
+// snapshotOf returns what the scheduler cache holds for nodes and the pods
+// in st
+func snapshotOf(t *testing.T, st store.Store, nodes []store.Object) []*nodeInfo {
+	t.Helper()
+	pods, err := st.List(context.Background(), "Pod", "")
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	cache := newSchedulerCache(DefaultAssumedPodTTL)
+	cache.replaceNodes(nodes)
+	cache.replacePods(pods)
+	return cache.snapshot()
+}
+
 func TestScheduler(t *testing.T) {
 	// Create mock store
 	mockStore := store.NewMemoryStore(store.DefaultOptions())
@@ -129,13 +143,13 @@ func TestScheduler_FindBestNode(t *testing.T) {
 			},
 		},
 		Status: api.PodStatus{
-			Phase: string(api.PodPending),
+			Phase: api.PodPending,
 		},
 	}
 
 	// Test finding best node
 	nodes := []store.Object{node1, node2}
-	bestNode, err := sched.findBestNode(pod, nodes)
+	bestNode, err := sched.findBestNode(context.Background(), pod, snapshotOf(t, mockStore, nodes))
 	if err != nil {
 		t.Fatalf("Failed to find best node: %v", err)
 	}
@@ -323,7 +337,7 @@ func TestScheduler_ResourceRequirements(t *testing.T) {
 		},
 	}
 
-	if !sched.hasSufficientResources(podWithAcceptableResources, node) {
+	if !sched.hasSufficientResources(podWithAcceptableResources, newNodeInfo(node)) {
 		t.Error("Pod should have sufficient resources")
 	}
 
@@ -353,7 +367,7 @@ func TestScheduler_ResourceRequirements(t *testing.T) {
 		},
 	}
 
-	if sched.hasSufficientResources(podWithExcessiveResources, node) {
+	if sched.hasSufficientResources(podWithExcessiveResources, newNodeInfo(node)) {
 		t.Error("Pod should not have sufficient resources")
 	}
 }
@@ -438,11 +452,704 @@ func TestScheduler_NodeScoring(t *testing.T) {
 	}
 
 	// Test node scoring
-	score1 := sched.calculateNodeScore(pod, node1)
-	score2 := sched.calculateNodeScore(pod, node2)
+	score1 := sched.calculateNodeScore(pod, newNodeInfo(node1))
+	score2 := sched.calculateNodeScore(pod, newNodeInfo(node2))
 
 	// Node 2 should have a higher score due to more resources
 	if score2 <= score1 {
 		t.Errorf("Expected node2 score (%f) to be higher than node1 score (%f)", score2, score1)
 	}
 }
+
+func TestImageArchitecture(t *testing.T) {
+	tests := map[string]string{
+		"nginx:1.25":                        "",
+		"arm64v8/nginx:1.25":                "arm64",
+		"docker.io/amd64/busybox":           "amd64",
+		"registry.lab:5000/app:1.0-arm64":   "arm64",
+		"registry.lab:5000/app:1.0":         "",
+		"ghcr.io/lab/agent:v2-amd64@sha256": "amd64",
+		"lab/arm64v8":                       "",
+	}
+
+	for image, want := range tests {
+		if got := imageArchitecture(image); got != want {
+			t.Errorf("imageArchitecture(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestScheduler_PlatformMatching(t *testing.T) {
+	sched := NewScheduler(&Config{
+		Store:              store.NewMemoryStore(store.DefaultOptions()),
+		SchedulingInterval: 10 * time.Second,
+	})
+
+	ready := []api.NodeCondition{{Type: "Ready", Status: "True"}}
+	allocatable := api.ResourceList{api.ResourceCPU: "4", api.ResourceMemory: "8Gi"}
+	amd64Node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "amd64-node", Labels: map[string]string{api.LabelArch: "amd64", api.LabelOS: "linux"}},
+		Status:     api.NodeStatus{Conditions: ready, Allocatable: allocatable},
+	}
+	// Registered by hand, so only the status reports the platform
+	arm64Node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "arm64-node"},
+		Status: api.NodeStatus{
+			Conditions:  ready,
+			Allocatable: allocatable,
+			NodeInfo:    api.NodeSystemInfo{Architecture: "arm64", OperatingSystem: "linux"},
+		},
+	}
+	nodes := []store.Object{amd64Node, arm64Node}
+
+	newPod := func(image string, selector map[string]string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default"},
+			Spec: api.PodSpec{
+				NodeSelector: selector,
+				Containers:   []api.Container{{Name: "app", Image: image}},
+			},
+		}
+	}
+
+	node, err := sched.findBestNode(context.Background(), newPod("arm64v8/nginx:1.25", nil), snapshotOf(t, sched.store, nodes))
+	if err != nil {
+		t.Fatalf("Failed to find node for arm64 image: %v", err)
+	}
+	if node.GetName() != "arm64-node" {
+		t.Errorf("Expected arm64 image on arm64-node, got %s", node.GetName())
+	}
+
+	node, err = sched.findBestNode(context.Background(), newPod("nginx:1.25", map[string]string{api.LabelArch: "arm64"}), snapshotOf(t, sched.store, nodes))
+	if err != nil {
+		t.Fatalf("Failed to find node for arm64 selector: %v", err)
+	}
+	if node.GetName() != "arm64-node" {
+		t.Errorf("Expected arm64 selector to pick arm64-node, got %s", node.GetName())
+	}
+
+	if _, err := sched.findBestNode(context.Background(), newPod("app:1.0-amd64", map[string]string{api.LabelArch: "arm64"}), snapshotOf(t, sched.store, nodes)); err == nil {
+		t.Error("Expected no node for an amd64 image pinned to arm64")
+	}
+}
+
+func TestScheduler_RescheduleKeepsOneCondition(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+
+	node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{
+			Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+			Allocatable: api.ResourceList{api.ResourceCPU: "2", api.ResourceMemory: "4Gi"},
+		},
+	}
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+	ctx := context.Background()
+	if err := mockStore.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	sched.cache.replaceNodes([]store.Object{node})
+	for i := 0; i < 3; i++ {
+		if err := sched.schedulePod(ctx, pod); err != nil {
+			t.Fatalf("Failed to schedule pod: %v", err)
+		}
+	}
+
+	if len(pod.Status.Conditions) != 1 {
+		t.Fatalf("Expected one condition after rescheduling, got %d", len(pod.Status.Conditions))
+	}
+	if !api.IsPodConditionTrue(&pod.Status, api.PodConditionScheduled) {
+		t.Error("Expected PodScheduled to be True")
+	}
+	if pod.Status.Phase != api.PodPending {
+		t.Errorf("Expected a bound pod to stay Pending, got %s", pod.Status.Phase)
+	}
+}
+
+func TestScheduler_MarksUnschedulable(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+		Status:     api.PodStatus{Phase: api.PodPending},
+	}
+	ctx := context.Background()
+	if err := mockStore.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	if err := sched.schedulePod(ctx, pod); err == nil {
+		t.Fatal("Expected scheduling to fail without nodes")
+	}
+
+	obj, err := mockStore.Get(ctx, "Pod", "default", "test-pod")
+	if err != nil {
+		t.Fatalf("Failed to get pod: %v", err)
+	}
+	stored := obj.(*api.Pod)
+	condition := api.GetPodCondition(&stored.Status, api.PodConditionScheduled)
+	if condition == nil || condition.Status != api.ConditionFalse || condition.Reason != api.ReasonUnschedulable {
+		t.Fatalf("Expected PodScheduled False/Unschedulable, got %+v", condition)
+	}
+	if stored.Status.Phase != api.PodPending {
+		t.Errorf("Expected the pod to stay Pending, got %s", stored.Status.Phase)
+	}
+}
+
+func TestScheduler_Simulate(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+	ctx := context.Background()
+
+	newNode := func(name, cpu string, labels map[string]string) *api.Node {
+		return &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Labels: labels},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: cpu, api.ResourceMemory: "4Gi"},
+			},
+		}
+	}
+	for _, node := range []*api.Node{
+		newNode("small", "2", map[string]string{"disk": "ssd"}),
+		newNode("large", "8", map[string]string{"disk": "ssd"}),
+		newNode("other", "16", nil),
+	} {
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+	}
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default"},
+		Spec: api.PodSpec{
+			NodeSelector: map[string]string{"disk": "ssd"},
+			Containers:   []api.Container{{Name: "app", Image: "nginx"}},
+		},
+	}
+
+	result, err := sched.Simulate(ctx, pod)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if result.SelectedNode != "large" {
+		t.Errorf("Expected node large to be selected, got %q", result.SelectedNode)
+	}
+	if len(result.Nodes) != 3 {
+		t.Fatalf("Expected three node evaluations, got %d", len(result.Nodes))
+	}
+	for _, evaluation := range result.Nodes {
+		if evaluation.Node != "other" {
+			if !evaluation.Feasible || evaluation.Score == nil {
+				t.Errorf("Expected node %s to be feasible and scored, got %+v", evaluation.Node, evaluation)
+			}
+			continue
+		}
+		if evaluation.Feasible || evaluation.Score != nil {
+			t.Errorf("Expected node other to be infeasible and unscored, got %+v", evaluation)
+		}
+		for _, filter := range evaluation.Filters {
+			if filter.Passed != (filter.Name != api.FilterNodeSelector) {
+				t.Errorf("Unexpected result of %s on node other: %v", filter.Name, filter.Passed)
+			}
+		}
+	}
+
+	// Simulating doesn't bind the pod
+	if pod.Spec.NodeName != "" {
+		t.Errorf("Expected the pod not to be bound, got node %s", pod.Spec.NodeName)
+	}
+
+	pod.Spec.NodeSelector = map[string]string{"disk": "nvme"}
+	result, err = sched.Simulate(ctx, pod)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if result.SelectedNode != "" || result.Message == "" {
+		t.Errorf("Expected no node to be selected, got %+v", result)
+	}
+}
+
+func TestScheduler_TaintsAndTopologySpread(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+	ctx := context.Background()
+
+	newNode := func(name, zone string, taints ...api.Taint) *api.Node {
+		return &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Labels: map[string]string{"zone": zone}},
+			Spec:       api.NodeSpec{Taints: taints},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: "4", api.ResourceMemory: "4Gi"},
+			},
+		}
+	}
+	var nodes []store.Object
+	for _, node := range []*api.Node{
+		newNode("node-a", "a"),
+		newNode("node-b", "b"),
+		newNode("node-c", "c", api.Taint{Key: "maintenance", Effect: api.TaintEffectNoSchedule}),
+	} {
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	newPod := func(name string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Spec: api.PodSpec{
+				Containers: []api.Container{{Name: "app", Image: "nginx"}},
+				TopologySpreadConstraints: []api.TopologySpreadConstraint{{
+					MaxSkew:       1,
+					TopologyKey:   "zone",
+					LabelSelector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				}},
+			},
+			Status: api.PodStatus{Phase: api.PodPending},
+		}
+	}
+
+	// Two pods already run in zone a, so the next can only go to zone b:
+	// zone c's node is cordoned
+	for _, name := range []string{"web-1", "web-2"} {
+		pod := newPod(name)
+		pod.Spec.NodeName = "node-a"
+		pod.Status.Phase = api.PodRunning
+		if err := mockStore.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+	node, err := sched.findBestNode(ctx, newPod("web-3"), snapshotOf(t, mockStore, nodes))
+	if err != nil {
+		t.Fatalf("Failed to find node: %v", err)
+	}
+	if node.GetName() != "node-b" {
+		t.Errorf("Expected node-b, got %s", node.GetName())
+	}
+
+	// Zone c doesn't count as an empty domain while its only node is
+	// cordoned, so zones a and b take pods again once they're even
+	for _, name := range []string{"web-3", "web-4"} {
+		pod := newPod(name)
+		pod.Spec.NodeName = "node-b"
+		pod.Status.Phase = api.PodRunning
+		if err := mockStore.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+	if node, err := sched.findBestNode(ctx, newPod("web-5"), snapshotOf(t, mockStore, nodes)); err != nil || node.GetName() == "node-c" {
+		t.Errorf("Expected a node other than the cordoned one, got %v, %v", node, err)
+	}
+
+	// Pods tolerating the taint count zone c as a domain, and go there to
+	// even out the spread
+	tolerating := newPod("web-5")
+	tolerating.Spec.Tolerations = []api.Toleration{{Key: "maintenance", Operator: api.TolerationOpExists, Effect: api.TaintEffectNoSchedule}}
+	if node, err := sched.findBestNode(ctx, tolerating, snapshotOf(t, mockStore, nodes)); err != nil || node.GetName() != "node-c" {
+		t.Errorf("Expected the pod tolerating the taint to go to node-c, got %v, %v", node, err)
+	}
+}
+
+func TestScheduler_RuntimeClassNodeSelector(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+	ctx := context.Background()
+
+	for _, node := range []*api.Node{
+		{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "plain"},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: "16", api.ResourceMemory: "4Gi"},
+			},
+		},
+		{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: "gvisor", Labels: map[string]string{"runtime": "gvisor"}},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: "2", api.ResourceMemory: "4Gi"},
+			},
+		},
+	} {
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+	}
+	runtimeClass := &api.RuntimeClass{
+		TypeMeta:   api.TypeMeta{Kind: "RuntimeClass", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "sandboxed"},
+		Handler:    "gvisor",
+		Scheduling: &api.RuntimeClassScheduling{NodeSelector: map[string]string{"runtime": "gvisor"}},
+	}
+	if err := mockStore.Create(ctx, runtimeClass); err != nil {
+		t.Fatalf("Failed to create runtime class: %v", err)
+	}
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "test-pod", Namespace: "default"},
+		Spec: api.PodSpec{
+			RuntimeClassName: "sandboxed",
+			Containers:       []api.Container{{Name: "app", Image: "nginx"}},
+		},
+	}
+
+	// The class's node selector overrides the larger node's better score
+	result, err := sched.Simulate(ctx, pod)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if result.SelectedNode != "gvisor" {
+		t.Errorf("Expected node gvisor to be selected, got %q", result.SelectedNode)
+	}
+	if len(pod.Spec.NodeSelector) != 0 {
+		t.Errorf("Expected the pod's own node selector to be left alone, got %v", pod.Spec.NodeSelector)
+	}
+
+	// A conflicting node selector of the pod's own makes it unschedulable
+	pod.Spec.NodeSelector = map[string]string{"runtime": "runc"}
+	if result, err = sched.Simulate(ctx, pod); err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if result.SelectedNode != "" || result.Message == "" {
+		t.Errorf("Expected the pod to be unschedulable, got %+v", result)
+	}
+}
+
+func TestScheduler_HostPorts(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+	ctx := context.Background()
+
+	var nodes []store.Object
+	for name, cpu := range map[string]string{"big": "16", "small": "2"} {
+		node := &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: cpu, api.ResourceMemory: "4Gi"},
+			},
+		}
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	newPod := func(name string, port api.ContainerPort) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: api.PodSpec{
+				Containers: []api.Container{{Name: "app", Image: "nginx", Ports: []api.ContainerPort{port}}},
+			},
+		}
+	}
+
+	// The larger node already serves host port 8080
+	web := newPod("web-1", api.ContainerPort{ContainerPort: 80, HostPort: 8080})
+	web.Spec.NodeName = "big"
+	web.Status.Phase = api.PodRunning
+	if err := mockStore.Create(ctx, web); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	node, err := sched.findBestNode(ctx, newPod("web-2", api.ContainerPort{ContainerPort: 80, HostPort: 8080, Protocol: "tcp"}), snapshotOf(t, mockStore, nodes))
+	if err != nil {
+		t.Fatalf("Failed to find node: %v", err)
+	}
+	if node.GetName() != "small" {
+		t.Errorf("Expected node small, got %s", node.GetName())
+	}
+
+	// The same port number over another protocol doesn't conflict
+	port := api.ContainerPort{ContainerPort: 53, HostPort: 8080, Protocol: "UDP"}
+	if node, err := sched.findBestNode(ctx, newPod("dns", port), snapshotOf(t, mockStore, nodes)); err != nil || node.GetName() != "big" {
+		t.Errorf("Expected node big, got %v, %v", node, err)
+	}
+
+	// Nor does a port of a pod that finished
+	web.Status.Phase = api.PodSucceeded
+	if err := mockStore.Update(ctx, web); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+	result, err := sched.Simulate(ctx, newPod("web-2", api.ContainerPort{ContainerPort: 80, HostPort: 8080}))
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if result.SelectedNode != "big" {
+		t.Errorf("Expected node big to be selected, got %q", result.SelectedNode)
+	}
+}
+
+func TestScheduler_AssumedPodsCountAgainstNodes(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+	ctx := context.Background()
+
+	for _, name := range []string{"node-1", "node-2"} {
+		node := &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: "2", api.ResourceMemory: "4Gi"},
+			},
+		}
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+	}
+	for _, name := range []string{"big-1", "big-2"} {
+		pod := &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: api.PodSpec{Containers: []api.Container{{
+				Name:      "app",
+				Image:     "nginx",
+				Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: "1500m"}},
+			}}},
+			Status: api.PodStatus{Phase: api.PodPending},
+		}
+		if err := mockStore.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	// Nothing is scheduled before the cache has listed nodes and pods
+	if err := sched.processUnscheduledPods(ctx); err != nil {
+		t.Fatalf("Failed to process pods: %v", err)
+	}
+	if len(sched.GetScheduledPods()) != 0 {
+		t.Fatal("Expected no pods to be scheduled before the cache synced")
+	}
+
+	// Without watching, the second pod only sees the first as assumed
+	nodes, _ := mockStore.List(ctx, "Node", "")
+	sched.cache.replaceNodes(nodes)
+	sched.cache.replacePods(nil)
+	if err := sched.processUnscheduledPods(ctx); err != nil {
+		t.Fatalf("Failed to process pods: %v", err)
+	}
+	scheduled := sched.GetScheduledPods()
+	if len(scheduled) != 2 {
+		t.Fatalf("Expected both pods to be scheduled, got %d", len(scheduled))
+	}
+	if scheduled["default/big-1"].NodeName == scheduled["default/big-2"].NodeName {
+		t.Errorf("Expected the pods on different nodes, both went to %s", scheduled["default/big-1"].NodeName)
+	}
+
+	// An assumed pod the store never reports bound expires
+	for _, info := range sched.cache.snapshot() {
+		if len(info.pods) != 1 {
+			t.Errorf("Expected one pod assumed on %s, got %d", info.node.Name, len(info.pods))
+		}
+	}
+	sched.cache.expireAssumedPods(time.Now().Add(DefaultAssumedPodTTL + time.Second))
+	for _, info := range sched.cache.snapshot() {
+		if len(info.pods) != 0 || info.requested[api.ResourceCPU] != 0 {
+			t.Errorf("Expected assumed pods on %s to expire, got %d", info.node.Name, len(info.pods))
+		}
+	}
+
+	// Once the store reports a binding the pod no longer expires
+	pods, _ := mockStore.List(ctx, "Pod", "")
+	sched.cache.replacePods(pods)
+	sched.cache.expireAssumedPods(time.Now().Add(DefaultAssumedPodTTL + time.Second))
+	count := 0
+	for _, info := range sched.cache.snapshot() {
+		count += len(info.pods)
+	}
+	if count != 2 {
+		t.Errorf("Expected both bound pods in the cache, got %d", count)
+	}
+}
+
+func TestSchedulerCache_Watch(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: time.Hour})
+	ctx := context.Background()
+	if err := sched.Start(ctx); err != nil {
+		t.Fatalf("Failed to start scheduler: %v", err)
+	}
+	defer sched.Stop()
+
+	node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+	}
+	if err := mockStore.Create(ctx, node); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       api.PodSpec{NodeName: "node-1", Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+		Status:     api.PodStatus{Phase: api.PodRunning},
+	}
+	if err := mockStore.Create(ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	waitFor := func(what string, cond func([]*nodeInfo) bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !sched.cache.synced() || !cond(sched.cache.snapshot()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("the pod on node-1", func(nodes []*nodeInfo) bool {
+		return len(nodes) == 1 && len(nodes[0].pods) == 1
+	})
+
+	// Finished pods don't take up the node
+	pod.Status.Phase = api.PodSucceeded
+	if err := mockStore.Update(ctx, pod); err != nil {
+		t.Fatalf("Failed to update pod: %v", err)
+	}
+	waitFor("the finished pod to leave node-1", func(nodes []*nodeInfo) bool {
+		return len(nodes) == 1 && len(nodes[0].pods) == 0
+	})
+
+	if err := mockStore.Delete(ctx, "Node", "", "node-1"); err != nil {
+		t.Fatalf("Failed to delete node: %v", err)
+	}
+	waitFor("node-1 to be removed", func(nodes []*nodeInfo) bool { return len(nodes) == 0 })
+}
+
+func TestScheduler_PodGroups(t *testing.T) {
+	mockStore := store.NewMemoryStore(store.DefaultOptions())
+	sched := NewScheduler(&Config{Store: mockStore, SchedulingInterval: 10 * time.Second})
+	ctx := context.Background()
+
+	for _, name := range []string{"node-1", "node-2"} {
+		node := &api.Node{
+			TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Status: api.NodeStatus{
+				Conditions:  []api.NodeCondition{{Type: "Ready", Status: "True"}},
+				Allocatable: api.ResourceList{api.ResourceCPU: "2", api.ResourceMemory: "4Gi"},
+			},
+		}
+		if err := mockStore.Create(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+	}
+	for name, minMember := range map[string]int32{"train": 3, "eval": 2} {
+		group := &api.PodGroup{
+			TypeMeta:   api.TypeMeta{Kind: "PodGroup", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       api.PodGroupSpec{MinMember: minMember},
+		}
+		if err := mockStore.Create(ctx, group); err != nil {
+			t.Fatalf("Failed to create pod group: %v", err)
+		}
+	}
+	createPod := func(name, group string) {
+		t.Helper()
+		pod := &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{api.LabelPodGroup: group}},
+			Spec: api.PodSpec{Containers: []api.Container{{
+				Name:      "worker",
+				Image:     "trainer",
+				Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: "1"}},
+			}}},
+			Status: api.PodStatus{Phase: api.PodPending},
+		}
+		if err := mockStore.Create(ctx, pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+	groupStatus := func(name string) api.PodGroupStatus {
+		t.Helper()
+		obj, err := mockStore.Get(ctx, "PodGroup", "default", name)
+		if err != nil {
+			t.Fatalf("Failed to get pod group: %v", err)
+		}
+		return obj.(*api.PodGroup).Status
+	}
+	requestedCPU := func() float64 {
+		var cpu float64
+		for _, info := range sched.cache.snapshot() {
+			cpu += info.requested[api.ResourceCPU]
+		}
+		return cpu
+	}
+	nodes, _ := mockStore.List(ctx, "Node", "")
+	sched.cache.replaceNodes(nodes)
+	sched.cache.replacePods(nil)
+
+	// Two of the three workers aren't enough to bind any
+	createPod("train-0", "train")
+	createPod("train-1", "train")
+	if err := sched.processUnscheduledPods(ctx); err != nil {
+		t.Fatalf("Failed to process pods: %v", err)
+	}
+	if len(sched.GetScheduledPods()) != 0 {
+		t.Fatalf("Expected no pods of an incomplete group to be scheduled, got %d", len(sched.GetScheduledPods()))
+	}
+	if status := groupStatus("train"); status.Phase != api.PodGroupPending || status.Message == "" {
+		t.Errorf("Expected group train to be Pending with a message, got %+v", status)
+	}
+	obj, _ := mockStore.Get(ctx, "Pod", "default", "train-0")
+	if condition := api.GetPodCondition(&obj.(*api.Pod).Status, api.PodConditionScheduled); condition == nil || condition.Reason != api.ReasonUnschedulable {
+		t.Errorf("Expected train-0 to be marked unschedulable, got %+v", condition)
+	}
+
+	// With the third they all fit, and are bound together
+	createPod("train-2", "train")
+	if err := sched.processUnscheduledPods(ctx); err != nil {
+		t.Fatalf("Failed to process pods: %v", err)
+	}
+	if len(sched.GetScheduledPods()) != 3 {
+		t.Fatalf("Expected the three pods of group train to be scheduled, got %d", len(sched.GetScheduledPods()))
+	}
+	if status := groupStatus("train"); status.Phase != api.PodGroupScheduled || status.Scheduled != 3 {
+		t.Errorf("Expected group train to be Scheduled with 3 pods, got %+v", status)
+	}
+
+	// One CPU is left, so neither pod of group eval is bound, and nothing
+	// stays reserved for them
+	createPod("eval-0", "eval")
+	createPod("eval-1", "eval")
+	if err := sched.processUnscheduledPods(ctx); err != nil {
+		t.Fatalf("Failed to process pods: %v", err)
+	}
+	if len(sched.GetScheduledPods()) != 3 {
+		t.Errorf("Expected no pod of group eval to be scheduled, got %d pods scheduled", len(sched.GetScheduledPods()))
+	}
+	if cpu := requestedCPU(); cpu != 3 {
+		t.Errorf("Expected only group train's 3 CPUs to be held, got %v", cpu)
+	}
+	if status := groupStatus("eval"); status.Phase != api.PodGroupPending {
+		t.Errorf("Expected group eval to be Pending, got %+v", status)
+	}
+}
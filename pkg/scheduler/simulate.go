@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/store"
+)
+
+// nodeEvaluation is an evaluation together with the node it's about
+type nodeEvaluation struct {
+	api.NodeEvaluation
+	node *api.Node
+}
+
+// evaluateNodes runs every check against every node for pod, and scores the
+// nodes that pass them all. The pods on the nodes are those counted for
+// resources, spread constraints and host ports.
+func (s *Scheduler) evaluateNodes(pod *api.Pod, nodes []*nodeInfo) []nodeEvaluation {
+	candidates := make([]*api.Node, 0, len(nodes))
+	infos := make(map[string]*nodeInfo, len(nodes))
+	var pods []*api.Pod
+	for _, info := range nodes {
+		candidates = append(candidates, info.node)
+		infos[info.node.Name] = info
+		for _, other := range info.pods {
+			pods = append(pods, other)
+		}
+	}
+	portPods := podsByNode(pods)
+
+	filters := []struct {
+		name  string
+		check func(*api.Pod, *api.Node) bool
+	}{
+		{api.FilterNodeReady, func(pod *api.Pod, node *api.Node) bool { return s.isNodeReady(node) }},
+		{api.FilterNodeSelector, s.matchesNodeSelector},
+		{api.FilterPlatform, s.matchesPlatform},
+		{api.FilterResources, func(pod *api.Pod, node *api.Node) bool {
+			return s.hasSufficientResources(pod, infos[node.Name])
+		}},
+		{api.FilterNodePorts, func(pod *api.Pod, node *api.Node) bool {
+			return s.hasFreeHostPorts(pod, node, portPods)
+		}},
+		{api.FilterTaints, s.matchesTaintsAndTolerations},
+		{api.FilterTopologySpread, func(pod *api.Pod, node *api.Node) bool {
+			return s.matchesTopologySpread(pod, node, candidates, pods)
+		}},
+	}
+
+	var evaluations []nodeEvaluation
+	for _, node := range candidates {
+		evaluation := nodeEvaluation{NodeEvaluation: api.NodeEvaluation{Node: node.Name, Feasible: true}, node: node}
+		for _, filter := range filters {
+			passed := filter.check(pod, node)
+			evaluation.Filters = append(evaluation.Filters, api.FilterResult{Name: filter.name, Passed: passed})
+			if !passed {
+				evaluation.Feasible = false
+			}
+		}
+		if evaluation.Feasible {
+			score := scoreNode(node, len(infos[node.Name].pods))
+			evaluation.Score = &score
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations
+}
+
+// scoreNode scores a node, preferring more allocatable resources and fewer
+// pods
+func scoreNode(node *api.Node, pods int) api.NodeScore {
+	var score api.NodeScore
+	if allocatable, exists := node.Status.Allocatable[api.ResourceCPU]; exists {
+		if cpu, err := api.ParseCPU(allocatable); err == nil {
+			score.CPU = cpu
+		}
+	}
+	if allocatable, exists := node.Status.Allocatable[api.ResourceMemory]; exists {
+		if memory, err := api.ParseMemory(allocatable); err == nil {
+			score.Memory = memory / (1024 * 1024 * 1024) // Convert to GB
+		}
+	}
+	score.Pods = pods
+	score.Total = score.CPU + score.Memory - float64(pods)
+	return score
+}
+
+// bestNode returns the feasible node with the highest score, or nil
+func bestNode(evaluations []nodeEvaluation) *api.Node {
+	var best *nodeEvaluation
+	for i := range evaluations {
+		evaluation := &evaluations[i]
+		if !evaluation.Feasible {
+			continue
+		}
+		if best == nil || evaluation.Score.Total > best.Score.Total {
+			best = evaluation
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.node
+}
+
+// Simulate runs the scheduling algorithm for pod against the nodes in the
+// store without binding it. Nodes and the pods on them are listed from the
+// store rather than taken from the cache, so the result doesn't depend on
+// whether this scheduler is running.
+func (s *Scheduler) Simulate(ctx context.Context, pod *api.Pod) (*api.SchedulingSimulation, error) {
+	nodes, err := s.store.List(ctx, "Node", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := s.store.List(ctx, "Pod", store.AllNamespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	cache := newSchedulerCache(0)
+	cache.replaceNodes(nodes)
+	cache.replacePods(pods)
+
+	pod, err = s.withRuntimeClass(ctx, pod)
+	if err != nil {
+		return &api.SchedulingSimulation{Nodes: []api.NodeEvaluation{}, Message: err.Error()}, nil
+	}
+	evaluations := s.evaluateNodes(pod, cache.snapshot())
+	result := &api.SchedulingSimulation{Nodes: make([]api.NodeEvaluation, 0, len(evaluations))}
+	for _, evaluation := range evaluations {
+		result.Nodes = append(result.Nodes, evaluation.NodeEvaluation)
+	}
+	if best := bestNode(evaluations); best != nil {
+		result.SelectedNode = best.Name
+	} else {
+		result.Message = fmt.Sprintf("no suitable node found for pod %s", pod.Name)
+	}
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package scheduler
+
+import "github.com/minik8s/minik8s/pkg/api"
+
+// matchesTopologySpread checks that placing pod on node keeps each of its
+// spread constraints within its maximum skew. The domains are those of the
+// Ready nodes matching pod's node selector whose taints it tolerates, so a
+// cordoned zone doesn't hold back the others; nodes outside every domain are
+// rejected.
+func (s *Scheduler) matchesTopologySpread(pod *api.Pod, node *api.Node, nodes []*api.Node, pods []*api.Pod) bool {
+	if len(pod.Spec.TopologySpreadConstraints) == 0 {
+		return true
+	}
+
+	var eligible []*api.Node
+	for _, candidate := range nodes {
+		if s.isNodeReady(candidate) && api.ToleratesNode(pod, candidate) && s.matchesNodeSelector(pod, candidate) {
+			eligible = append(eligible, candidate)
+		}
+	}
+
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		domain, ok := api.TopologyDomain(node, constraint.TopologyKey)
+		if !ok {
+			return false
+		}
+		domains := api.SpreadDomains(constraint, pod.Namespace, pods, eligible)
+		count := domains[domain]
+		if constraint.Selects(pod) {
+			count++
+		}
+		if count-api.MinDomainCount(domains) > constraint.AllowedSkew() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors wrapped by every store, so callers can branch on them with
+// errors.Is instead of matching messages
+var (
+	// ErrNotFound is wrapped by Get, Update and Delete when no object with
+	// the given kind, namespace and name is stored
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists is wrapped by Create when an object with the same
+	// kind, namespace and name is already stored
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrConflict is wrapped by Update when the object carries a UID or a
+	// resource version other than the stored object's: it was deleted and
+	// created again, or changed, since the caller read it
+	ErrConflict = errors.New("conflict")
+
+	// ErrInvalidKind is wrapped when an object's kind isn't one the store
+	// knows how to persist
+	ErrInvalidKind = errors.New("invalid kind")
+)
+
+// notFoundError returns the error for a missing object
+func notFoundError(kind, namespace, name string) error {
+	return fmt.Errorf("object %s/%s of kind %s %w", namespace, name, kind, ErrNotFound)
+}
+
+// alreadyExistsError returns the error for creating an object that exists
+func alreadyExistsError(obj Object) error {
+	return fmt.Errorf("object %s/%s of kind %s %w", obj.GetNamespace(), obj.GetName(), obj.GetKind(), ErrAlreadyExists)
+}
+
+// checkUID returns ErrConflict when updated names a UID other than that of
+// the stored object. Objects without a UID match any.
+func checkUID(stored, updated Object) error {
+	if stored.GetUID() == "" || updated.GetUID() == "" || stored.GetUID() == updated.GetUID() {
+		return nil
+	}
+	return fmt.Errorf("object %s/%s of kind %s has UID %s, not %s: %w",
+		stored.GetNamespace(), stored.GetName(), stored.GetKind(), stored.GetUID(), updated.GetUID(), ErrConflict)
+}
+
+// checkResourceVersion returns ErrConflict when updated carries a resource
+// version other than that of the stored object, so writers that read the
+// same version can't overwrite each other's changes. Objects without a
+// resource version update unconditionally.
+func checkResourceVersion(stored, updated Object) error {
+	if updated.GetResourceVersion() == "" || stored.GetResourceVersion() == updated.GetResourceVersion() {
+		return nil
+	}
+	return fmt.Errorf("object %s/%s of kind %s is at resourceVersion %s, not %s: %w",
+		stored.GetNamespace(), stored.GetName(), stored.GetKind(), stored.GetResourceVersion(), updated.GetResourceVersion(), ErrConflict)
+}
+
+// invalidKindError returns the error for a kind the store can't persist
+func invalidKindError(kind string) error {
+	return fmt.Errorf("unknown object kind %q: %w", kind, ErrInvalidKind)
+}
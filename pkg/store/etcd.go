@@ -28,8 +28,10 @@ type etcdStore struct {
 type etcdWatcher struct {
 	events     chan WatchEvent
 	stop       chan struct{}
+	queue      *watchQueue
 	kind       string
 	ns         string
+	name       string // the one object watched, if any
 	cancelFunc context.CancelFunc
 }
 
@@ -84,6 +86,9 @@ func NewEtcdStore(endpoints []string, prefix string, options *Options) (Store, e
 
 // Create creates a new object in etcd
 func (s *etcdStore) Create(ctx context.Context, obj Object) error {
+	if _, ok := newObject(obj.GetKind()); !ok {
+		return invalidKindError(obj.GetKind())
+	}
 	key := s.buildKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())
 
 	// Check if object already exists
@@ -93,7 +98,7 @@ func (s *etcdStore) Create(ctx context.Context, obj Object) error {
 	}
 
 	if len(resp.Kvs) > 0 {
-		return fmt.Errorf("object %s/%s of kind %s already exists", obj.GetNamespace(), obj.GetName(), obj.GetKind())
+		return alreadyExistsError(obj)
 	}
 
 	// Set metadata
@@ -118,6 +123,30 @@ func (s *etcdStore) Create(ctx context.Context, obj Object) error {
 	return nil
 }
 
+// Import stores obj as given, keeping its resource version. Imported objects
+// are written without the store's lease so they outlive the importing process.
+func (s *etcdStore) Import(ctx context.Context, obj Object) error {
+	key := s.buildKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to marshal object: %w", err)
+	}
+
+	resp, err := s.client.Put(ctx, key, string(data), clientv3.WithPrevKV())
+	if err != nil {
+		return fmt.Errorf("failed to store object: %w", err)
+	}
+
+	if resp.PrevKv != nil {
+		s.notifyWatchers(Modified, obj)
+	} else {
+		s.notifyWatchers(Added, obj)
+	}
+
+	return nil
+}
+
 // Get retrieves an object by name and namespace
 func (s *etcdStore) Get(ctx context.Context, kind, namespace, name string) (Object, error) {
 	key := s.buildKey(kind, namespace, name)
@@ -128,18 +157,13 @@ func (s *etcdStore) Get(ctx context.Context, kind, namespace, name string) (Obje
 	}
 
 	if len(resp.Kvs) == 0 {
-		return nil, fmt.Errorf("object %s/%s of kind %s not found", namespace, name, kind)
+		return nil, notFoundError(kind, namespace, name)
 	}
 
 	// Deserialize object
-	var obj Object
-	switch kind {
-	case "Pod":
-		obj = &api.Pod{}
-	case "Node":
-		obj = &api.Node{}
-	default:
-		return nil, fmt.Errorf("unknown object kind: %s", kind)
+	obj, ok := newObject(kind)
+	if !ok {
+		return nil, invalidKindError(kind)
 	}
 
 	err = json.Unmarshal(resp.Kvs[0].Value, obj)
@@ -152,7 +176,7 @@ func (s *etcdStore) Get(ctx context.Context, kind, namespace, name string) (Obje
 
 // List retrieves all objects of a given kind and namespace
 func (s *etcdStore) List(ctx context.Context, kind, namespace string) ([]Object, error) {
-	prefix := s.buildKey(kind, namespace, "")
+	prefix := s.listPrefix(kind, namespace)
 
 	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix())
 	if err != nil {
@@ -166,13 +190,8 @@ func (s *etcdStore) List(ctx context.Context, kind, namespace string) ([]Object,
 			continue
 		}
 
-		var obj Object
-		switch kind {
-		case "Pod":
-			obj = &api.Pod{}
-		case "Node":
-			obj = &api.Node{}
-		default:
+		obj, ok := newObject(kind)
+		if !ok {
 			continue
 		}
 
@@ -187,34 +206,59 @@ func (s *etcdStore) List(ctx context.Context, kind, namespace string) ([]Object,
 	return objects, nil
 }
 
-// Update updates an existing object
+// Update updates an existing object. The write is a transaction on the
+// key's mod revision, so an object changed since it was read here is
+// reported as a conflict rather than overwritten.
 func (s *etcdStore) Update(ctx context.Context, obj Object) error {
 	key := s.buildKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())
 
-	// Check if object exists
+	// Check if object exists, and is the one and version the caller read
 	resp, err := s.client.Get(ctx, key)
 	if err != nil {
-		return fmt.Errorf("failed to check existing object: %w", err)
+		return fmt.Errorf("failed to get object: %w", err)
 	}
-
 	if len(resp.Kvs) == 0 {
-		return fmt.Errorf("object %s/%s of kind %s not found", obj.GetNamespace(), obj.GetName(), obj.GetKind())
+		return notFoundError(obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	}
+	stored, ok := newObject(obj.GetKind())
+	if !ok {
+		return invalidKindError(obj.GetKind())
+	}
+	if err := json.Unmarshal(resp.Kvs[0].Value, stored); err != nil {
+		return fmt.Errorf("failed to unmarshal object: %w", err)
+	}
+	if err := checkUID(stored, obj); err != nil {
+		return err
+	}
+	if err := checkResourceVersion(stored, obj); err != nil {
+		return err
 	}
 
-	// Update resource version
+	// Update resource version, restoring the caller's if the write fails
+	read := obj.GetResourceVersion()
 	obj.SetResourceVersion(fmt.Sprintf("%d", time.Now().UnixNano()))
 
 	// Serialize object
 	data, err := json.Marshal(obj)
 	if err != nil {
+		obj.SetResourceVersion(read)
 		return fmt.Errorf("failed to marshal object: %w", err)
 	}
 
-	// Store with lease for TTL
-	_, err = s.client.Put(ctx, key, string(data), clientv3.WithLease(s.leaseID))
+	// Store with lease for TTL, unless the key changed since it was read
+	txn, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision)).
+		Then(clientv3.OpPut(key, string(data), clientv3.WithLease(s.leaseID))).
+		Commit()
 	if err != nil {
+		obj.SetResourceVersion(read)
 		return fmt.Errorf("failed to update object: %w", err)
 	}
+	if !txn.Succeeded {
+		obj.SetResourceVersion(read)
+		return fmt.Errorf("object %s/%s of kind %s was changed concurrently: %w",
+			obj.GetNamespace(), obj.GetName(), obj.GetKind(), ErrConflict)
+	}
 
 	// Notify watchers
 	s.notifyWatchers(Modified, obj)
@@ -246,47 +290,69 @@ func (s *etcdStore) Delete(ctx context.Context, kind, namespace, name string) er
 
 // Watch watches for changes to objects of a given kind and namespace
 func (s *etcdStore) Watch(ctx context.Context, kind, namespace string) (WatchResult, error) {
-	prefix := s.buildKey(kind, namespace, "")
+	return s.watch(ctx, kind, namespace, "")
+}
+
+// WatchObject watches for changes to one object with a watch on its key
+// alone, so etcd sends only its changes
+func (s *etcdStore) WatchObject(ctx context.Context, kind, namespace, name string) (WatchResult, error) {
+	return s.watch(ctx, kind, namespace, name)
+}
 
+// watch watches the keys of a namespace of kind, or the key of the object
+// named name in it if name is set
+func (s *etcdStore) watch(ctx context.Context, kind, namespace, name string) (WatchResult, error) {
 	// Create watcher
 	w := &etcdWatcher{
 		events: make(chan WatchEvent, s.options.WatchBufferSize),
 		stop:   make(chan struct{}),
 		kind:   kind,
 		ns:     namespace,
+		name:   name,
+	}
+
+	// Send initial events for existing objects
+	var initial []WatchEvent
+	var watchOpts []clientv3.OpOption
+	if name != "" {
+		if obj, err := s.Get(ctx, kind, namespace, name); err == nil {
+			initial = append(initial, WatchEvent{Type: Added, Object: obj})
+		}
+	} else {
+		objects, err := s.List(ctx, kind, namespace)
+		if err == nil {
+			for _, obj := range objects {
+				initial = append(initial, WatchEvent{Type: Added, Object: obj})
+			}
+		}
+		watchOpts = append(watchOpts, clientv3.WithPrefix())
 	}
+	w.queue = newWatchQueue(w.events, s.options.WatchQueueLimit, initial)
 
-	// Create context for etcd watch
+	// Create context for etcd watch. It isn't derived from ctx, which may
+	// carry values or deadlines meant for the initial list only; the watch
+	// is canceled below once ctx is done instead.
 	watchCtx, cancel := context.WithCancel(context.Background())
 	w.cancelFunc = cancel
 
 	// Start etcd watch
-	go s.startEtcdWatch(watchCtx, w, prefix)
+	key := s.buildKey(kind, namespace, name)
+	if name == "" {
+		key = s.listPrefix(kind, namespace)
+	}
+	go s.startEtcdWatch(watchCtx, w, key, watchOpts...)
 
 	// Add to watchers list
 	s.mu.Lock()
-	key := kind + "/" + namespace
+	key = watchKey(kind, namespace, name)
 	s.watchers[key] = append(s.watchers[key], w)
 	s.mu.Unlock()
 
-	// Send initial events for existing objects
-	objects, err := s.List(ctx, kind, namespace)
-	if err == nil {
-		for _, obj := range objects {
-			select {
-			case w.events <- WatchEvent{Type: Added, Object: obj}:
-			default:
-				// Channel is full, skip this event
-			}
-		}
-	}
-
-	// Start cleanup goroutine
-	go func() {
-		<-w.stop
+	// Release the etcd watch once the watcher is stopped or ctx is done
+	w.queue.endWith(ctx, w.stop, func() {
 		s.removeWatcher(w)
 		cancel()
-	}()
+	})
 
 	return WatchResult{
 		Events: w.events,
@@ -299,10 +365,10 @@ func (s *etcdStore) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Stop all watchers
+	// End all watches; their consumers see their events channel closed
 	for _, watchers := range s.watchers {
 		for _, w := range watchers {
-			close(w.stop)
+			w.queue.end()
 		}
 	}
 
@@ -322,19 +388,23 @@ func (s *etcdStore) buildKey(kind, namespace, name string) string {
 	return path.Join(s.prefix, kind, namespace, name)
 }
 
-// startEtcdWatch starts the etcd watch for a specific watcher
-func (s *etcdStore) startEtcdWatch(ctx context.Context, w *etcdWatcher, prefix string) {
-	watchChan := s.client.Watch(ctx, prefix, clientv3.WithPrefix())
+// listPrefix returns the prefix of the keys of the objects of kind in
+// namespace, or of every namespace given AllNamespaces. It ends in a slash so
+// kinds and namespaces sharing a prefix, such as Pod and PodGroup, don't mix.
+func (s *etcdStore) listPrefix(kind, namespace string) string {
+	return s.buildKey(kind, namespace, "") + "/"
+}
+
+// startEtcdWatch starts the etcd watch of key for a specific watcher
+func (s *etcdStore) startEtcdWatch(ctx context.Context, w *etcdWatcher, key string, opts ...clientv3.OpOption) {
+	watchChan := s.client.Watch(ctx, key, opts...)
 
 	for {
 		select {
 		case resp := <-watchChan:
 			if resp.Err() != nil {
 				// Send error event
-				select {
-				case w.events <- WatchEvent{Type: Error, Object: nil}:
-				default:
-				}
+				w.queue.push(WatchEvent{Type: Error, Object: nil})
 				continue
 			}
 
@@ -363,12 +433,8 @@ func (s *etcdStore) startEtcdWatch(ctx context.Context, w *etcdWatcher, prefix s
 					}
 
 					// Deserialize object
-					switch kind {
-					case "Pod":
-						obj = &api.Pod{}
-					case "Node":
-						obj = &api.Node{}
-					default:
+					var ok bool
+					if obj, ok = newObject(kind); !ok {
 						continue
 					}
 
@@ -381,31 +447,18 @@ func (s *etcdStore) startEtcdWatch(ctx context.Context, w *etcdWatcher, prefix s
 					eventType = Deleted
 					// For delete events, we can't reconstruct the full object
 					// We'll create a minimal object with just the metadata
-					switch kind {
-					case "Pod":
-						obj = &api.Pod{
-							ObjectMeta: api.ObjectMeta{
-								Name:      parts[len(parts)-1],
-								Namespace: parts[1],
-							},
-						}
-					case "Node":
-						obj = &api.Node{
-							ObjectMeta: api.ObjectMeta{
-								Name: parts[1],
-							},
-						}
-					default:
+					meta := api.ObjectMeta{Name: parts[len(parts)-1]}
+					if len(parts) > 2 {
+						meta.Namespace = parts[1]
+					}
+					var ok bool
+					if obj, ok = newObjectWithMeta(kind, meta); !ok {
 						continue
 					}
 				}
 
 				// Send event
-				select {
-				case w.events <- WatchEvent{Type: eventType, Object: obj}:
-				default:
-					// Channel is full, skip this event
-				}
+				w.queue.push(WatchEvent{Type: eventType, Object: obj})
 			}
 
 		case <-ctx.Done():
@@ -414,22 +467,22 @@ func (s *etcdStore) startEtcdWatch(ctx context.Context, w *etcdWatcher, prefix s
 	}
 }
 
-// notifyWatchers notifies all watchers of a change
+// notifyWatchers queues a change for all watchers; their queues deliver it,
+// so this never blocks on a slow consumer
 func (s *etcdStore) notifyWatchers(eventType EventType, obj Object) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	kind := obj.GetKind()
 	namespace := obj.GetNamespace()
-	key := kind + "/" + namespace
 
-	watchers := s.watchers[key]
+	watchers := s.watchers[watchKey(kind, namespace, "")]
+	if namespace != AllNamespaces {
+		watchers = append(watchers[:len(watchers):len(watchers)], s.watchers[watchKey(kind, AllNamespaces, "")]...)
+	}
+	watchers = append(watchers[:len(watchers):len(watchers)], s.watchers[watchKey(kind, namespace, obj.GetName())]...)
 	for _, w := range watchers {
-		select {
-		case w.events <- WatchEvent{Type: eventType, Object: obj}:
-		default:
-			// Channel is full, skip this event
-		}
+		w.queue.push(WatchEvent{Type: eventType, Object: obj})
 	}
 }
 
@@ -438,7 +491,7 @@ func (s *etcdStore) removeWatcher(w *etcdWatcher) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := w.kind + "/" + w.ns
+	key := watchKey(w.kind, w.ns, w.name)
 	watchers := s.watchers[key]
 
 	for i, watcher := range watchers {
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -230,3 +231,20 @@ func TestEtcdStore_KeyBuilding(t *testing.T) {
 	key = store.buildKey("Node", "", "test-node")
 	assert.Equal(t, "/minik8s/Node/test-node", key)
 }
+
+// TestEtcdStore_ListPrefix tests that list prefixes don't match kinds or
+// namespaces whose names merely start the same way
+func TestEtcdStore_ListPrefix(t *testing.T) {
+	store := &etcdStore{
+		prefix: "/minik8s",
+	}
+
+	allPods := store.listPrefix("Pod", AllNamespaces)
+	assert.Equal(t, "/minik8s/Pod/", allPods)
+	assert.True(t, strings.HasPrefix(store.buildKey("Pod", "team-a", "web"), allPods))
+	assert.False(t, strings.HasPrefix(store.buildKey("PodGroup", "team-a", "web"), allPods))
+
+	defaultPods := store.listPrefix("Pod", "default")
+	assert.True(t, strings.HasPrefix(store.buildKey("Pod", "default", "web"), defaultPods))
+	assert.False(t, strings.HasPrefix(store.buildKey("Pod", "default-2", "web"), defaultPods))
+}
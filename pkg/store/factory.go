@@ -22,6 +22,9 @@ type StoreConfig struct {
 	Endpoints []string
 	Prefix    string
 	Options   *Options
+	// Routes send some kinds or namespaces to other backends; everything
+	// else goes to the backend described above
+	Routes []StoreRoute
 }
 
 // NewStore creates a new store based on configuration
@@ -34,6 +37,10 @@ func NewStore(config *StoreConfig) (Store, error) {
 		}
 	}
 
+	if len(config.Routes) > 0 {
+		return newRoutedStore(config, NewStore)
+	}
+
 	switch config.Type {
 	case StoreTypeMemory:
 		return NewMemoryStore(config.Options), nil
@@ -68,11 +75,17 @@ func NewStoreFromEnv() (Store, error) {
 		prefix = "/minik8s"
 	}
 
+	routes, err := ParseStoreRoutes(os.Getenv("MINIK8S_STORE_ROUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MINIK8S_STORE_ROUTES: %w", err)
+	}
+
 	config := &StoreConfig{
 		Type:      storeType,
 		Endpoints: endpoints,
 		Prefix:    prefix,
 		Options:   DefaultOptions(),
+		Routes:    routes,
 	}
 
 	return NewStore(config)
@@ -80,6 +93,10 @@ func NewStoreFromEnv() (Store, error) {
 
 // NewStoreWithFallback creates a store with fallback to in-memory if etcd fails
 func NewStoreWithFallback(config *StoreConfig) (Store, error) {
+	if len(config.Routes) > 0 {
+		return newRoutedStore(config, NewStoreWithFallback)
+	}
+
 	if config.Type == StoreTypeEtcd {
 		store, err := NewEtcdStore(config.Endpoints, config.Prefix, config.Options)
 		if err != nil {
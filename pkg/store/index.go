@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// Secondary indexes objects can be listed by
+const (
+	// IndexLabel indexes objects by each of their labels, as key=value
+	IndexLabel = "label"
+	// IndexNodeName indexes pods by the node they're bound to
+	IndexNodeName = "nodeName"
+	// IndexOwner indexes objects by the UID of each of their owners
+	IndexOwner = "owner"
+)
+
+// indexes are the secondary indexes stores keep
+var indexes = []string{IndexLabel, IndexNodeName, IndexOwner}
+
+// Indexer is implemented by stores that keep secondary indexes, so objects
+// can be listed without going through every object of their kind
+type Indexer interface {
+	ListByIndex(ctx context.Context, kind, namespace, index, value string) ([]Object, error)
+}
+
+// LabelIndexValue returns the IndexLabel value of a label
+func LabelIndexValue(key, value string) string {
+	return key + "=" + value
+}
+
+// IndexValues returns the values obj is indexed under in index
+func IndexValues(obj Object, index string) []string {
+	var values []string
+	switch index {
+	case IndexLabel:
+		if labeled, ok := obj.(interface{ GetLabels() map[string]string }); ok {
+			for key, value := range labeled.GetLabels() {
+				values = append(values, LabelIndexValue(key, value))
+			}
+		}
+	case IndexNodeName:
+		if pod, ok := obj.(*api.Pod); ok && pod.Spec.NodeName != "" {
+			values = append(values, pod.Spec.NodeName)
+		}
+	case IndexOwner:
+		if owned, ok := obj.(interface{ GetOwnerReferences() []api.OwnerReference }); ok {
+			for _, owner := range owned.GetOwnerReferences() {
+				if owner.UID != "" {
+					values = append(values, owner.UID)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// ListByIndex lists the objects of kind in namespace, or in all namespaces
+// when it's empty, that are indexed under value in index. Stores that don't
+// keep indexes are listed in full and filtered.
+func ListByIndex(ctx context.Context, s Store, kind, namespace, index, value string) ([]Object, error) {
+	if indexer, ok := s.(Indexer); ok {
+		return indexer.ListByIndex(ctx, kind, namespace, index, value)
+	}
+
+	objs, err := s.List(ctx, kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var matched []Object
+	for _, obj := range objs {
+		for _, v := range IndexValues(obj, index) {
+			if v == value {
+				matched = append(matched, obj)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// indexKey identifies the objects of a kind with one value in one index
+type indexKey struct {
+	kind, index, value string
+}
+
+// indexObject adds obj, stored under key, to the indexes. Called with s.mu
+// held.
+func (s *memoryStore) indexObject(key string, obj Object) {
+	objectKey := obj.GetKind() + "/" + key
+	var entries []indexKey
+	for _, index := range indexes {
+		for _, value := range IndexValues(obj, index) {
+			entry := indexKey{kind: obj.GetKind(), index: index, value: value}
+			if s.indexes[entry] == nil {
+				s.indexes[entry] = make(map[string]Object)
+			}
+			s.indexes[entry][key] = obj
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) > 0 {
+		s.indexed[objectKey] = entries
+	}
+}
+
+// unindexObject removes the object of kind stored under key from the
+// indexes. The entries recorded when it was indexed are used, since the
+// object may have been changed in place since. Called with s.mu held.
+func (s *memoryStore) unindexObject(kind, key string) {
+	objectKey := kind + "/" + key
+	for _, entry := range s.indexed[objectKey] {
+		delete(s.indexes[entry], key)
+		if len(s.indexes[entry]) == 0 {
+			delete(s.indexes, entry)
+		}
+	}
+	delete(s.indexed, objectKey)
+}
+
+// ListByIndex lists the objects of kind in namespace indexed under value in
+// index
+func (s *memoryStore) ListByIndex(ctx context.Context, kind, namespace, index, value string) ([]Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var objects []Object
+	for key, obj := range s.indexes[indexKey{kind: kind, index: index, value: value}] {
+		if namespace == "" || strings.HasPrefix(key, namespace+"/") {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func indexedPod(namespace, name, node, app, owner string) *api.Pod {
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+		Spec:       api.PodSpec{NodeName: node},
+	}
+	if owner != "" {
+		pod.OwnerReferences = []api.OwnerReference{{Kind: "ReplicaSet", Name: owner, UID: owner + "-uid"}}
+	}
+	return pod
+}
+
+func names(objs []Object) []string {
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetNamespace()+"/"+obj.GetName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestMemoryStore_ListByIndex(t *testing.T) {
+	store := NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	for _, pod := range []*api.Pod{
+		indexedPod("default", "web-1", "node-1", "web", "web"),
+		indexedPod("default", "web-2", "node-2", "web", "web"),
+		indexedPod("default", "db", "node-1", "db", ""),
+		indexedPod("other", "web-1", "node-1", "web", ""),
+	} {
+		require.NoError(t, store.Create(ctx, pod))
+	}
+
+	list := func(namespace, index, value string) []string {
+		objs, err := ListByIndex(ctx, store, "Pod", namespace, index, value)
+		require.NoError(t, err)
+		return names(objs)
+	}
+	assert.Equal(t, []string{"default/db", "default/web-1", "other/web-1"}, list("", IndexNodeName, "node-1"))
+	assert.Equal(t, []string{"default/web-1", "default/web-2"}, list("default", IndexLabel, LabelIndexValue("app", "web")))
+	assert.Equal(t, []string{"default/web-1", "default/web-2"}, list("", IndexOwner, "web-uid"))
+	assert.Empty(t, list("", IndexNodeName, "node-3"))
+
+	// Updates move objects between index values, even when the object was
+	// changed in place before being written back
+	obj, err := store.Get(ctx, "Pod", "default", "web-2")
+	require.NoError(t, err)
+	pod := obj.(*api.Pod)
+	pod.Spec.NodeName = "node-1"
+	pod.Labels = nil
+	require.NoError(t, store.Update(ctx, pod))
+	assert.Equal(t, []string{"default/db", "default/web-1", "default/web-2", "other/web-1"}, list("", IndexNodeName, "node-1"))
+	assert.Empty(t, list("", IndexNodeName, "node-2"))
+	assert.Equal(t, []string{"default/web-1"}, list("default", IndexLabel, LabelIndexValue("app", "web")))
+
+	require.NoError(t, store.Delete(ctx, "Pod", "default", "web-1"))
+	assert.Equal(t, []string{"default/web-2"}, list("", IndexOwner, "web-uid"))
+}
+
+func TestListByIndex_WithoutIndexer(t *testing.T) {
+	// Hiding the memory store's ListByIndex makes it list everything and filter
+	backend := NewMemoryStore(nil)
+	defer backend.Close()
+	ctx := context.Background()
+
+	require.NoError(t, backend.Create(ctx, indexedPod("default", "a", "node-1", "web", "")))
+	require.NoError(t, backend.Create(ctx, indexedPod("default", "b", "node-2", "web", "")))
+
+	objs, err := ListByIndex(ctx, struct{ Store }{backend}, "Pod", "", IndexNodeName, "node-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default/b"}, names(objs))
+}
+
+// BenchmarkListPodsOfNode compares finding the pods of one node among 10k
+// with a full List and with the node name index
+func BenchmarkListPodsOfNode(b *testing.B) {
+	store := NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+	for i := 0; i < 10000; i++ {
+		pod := indexedPod("default", fmt.Sprintf("pod-%d", i), fmt.Sprintf("node-%d", i%100), "web", "")
+		if err := store.Create(ctx, pod); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("list", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			objs, _ := store.List(ctx, "Pod", "")
+			var onNode int
+			for _, obj := range objs {
+				if obj.(*api.Pod).Spec.NodeName == "node-7" {
+					onNode++
+				}
+			}
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ListByIndex(ctx, store, "Pod", "", IndexNodeName, "node-7")
+		}
+	})
+}
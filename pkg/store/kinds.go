@@ -0,0 +1,44 @@
+package store
+
+import (
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// kinds maps each kind persisted by the store to a constructor for an empty object.
+// Stores that serialize objects use it to decode them back into their concrete type.
+var kinds = map[string]func(meta api.ObjectMeta) Object{
+	"Pod":                     func(meta api.ObjectMeta) Object { return &api.Pod{ObjectMeta: meta} },
+	"Node":                    func(meta api.ObjectMeta) Object { return &api.Node{ObjectMeta: meta} },
+	"Deployment":              func(meta api.ObjectMeta) Object { return &api.Deployment{ObjectMeta: meta} },
+	"ReplicaSet":              func(meta api.ObjectMeta) Object { return &api.ReplicaSet{ObjectMeta: meta} },
+	"Job":                     func(meta api.ObjectMeta) Object { return &api.Job{ObjectMeta: meta} },
+	"CronJob":                 func(meta api.ObjectMeta) Object { return &api.CronJob{ObjectMeta: meta} },
+	"ConfigMap":               func(meta api.ObjectMeta) Object { return &api.ConfigMap{ObjectMeta: meta} },
+	"Secret":                  func(meta api.ObjectMeta) Object { return &api.Secret{ObjectMeta: meta} },
+	"ServiceAccount":          func(meta api.ObjectMeta) Object { return &api.ServiceAccount{ObjectMeta: meta} },
+	"Service":                 func(meta api.ObjectMeta) Object { return &api.Service{ObjectMeta: meta} },
+	"Endpoints":               func(meta api.ObjectMeta) Object { return &api.Endpoints{ObjectMeta: meta} },
+	"Event":                   func(meta api.ObjectMeta) Object { return &api.Event{ObjectMeta: meta} },
+	"Namespace":               func(meta api.ObjectMeta) Object { return &api.Namespace{ObjectMeta: meta} },
+	"HorizontalPodAutoscaler": func(meta api.ObjectMeta) Object { return &api.HorizontalPodAutoscaler{ObjectMeta: meta} },
+	"RuntimeClass":            func(meta api.ObjectMeta) Object { return &api.RuntimeClass{ObjectMeta: meta} },
+	"PodGroup":                func(meta api.ObjectMeta) Object { return &api.PodGroup{ObjectMeta: meta} },
+	"Lease":                   func(meta api.ObjectMeta) Object { return &api.Lease{ObjectMeta: meta} },
+	"CertificateSigningRequest": func(meta api.ObjectMeta) Object {
+		return &api.CertificateSigningRequest{ObjectMeta: meta}
+	},
+}
+
+// newObject returns an empty object of the given kind
+func newObject(kind string) (Object, bool) {
+	return newObjectWithMeta(kind, api.ObjectMeta{})
+}
+
+// newObjectWithMeta returns an object of the given kind carrying only metadata
+func newObjectWithMeta(kind string, meta api.ObjectMeta) (Object, bool) {
+	newFn, ok := kinds[kind]
+	if !ok {
+		return nil, false
+	}
+	return newFn(meta), true
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/requestid"
+)
+
+// DefaultSlowThreshold is the latency above which NewLoggingStore logs a call
+const DefaultSlowThreshold = 500 * time.Millisecond
+
+// loggingStore logs store calls that fail or are slow, tagged with the
+// request ID from their context so they can be matched with access logs
+type loggingStore struct {
+	Store
+	slowThreshold time.Duration
+}
+
+// NewLoggingStore wraps s so that calls failing or taking longer than
+// slowThreshold are logged; zero or negative uses DefaultSlowThreshold
+func NewLoggingStore(s Store, slowThreshold time.Duration) Store {
+	if slowThreshold <= 0 {
+		slowThreshold = DefaultSlowThreshold
+	}
+	return &loggingStore{Store: s, slowThreshold: slowThreshold}
+}
+
+// Create creates a new object in the store
+func (s *loggingStore) Create(ctx context.Context, obj Object) error {
+	start := time.Now()
+	err := s.Store.Create(ctx, obj)
+	s.log(ctx, "create", obj.GetKind(), obj.GetNamespace(), obj.GetName(), start, err)
+	return err
+}
+
+// Get retrieves an object by name and namespace
+func (s *loggingStore) Get(ctx context.Context, kind, namespace, name string) (Object, error) {
+	start := time.Now()
+	obj, err := s.Store.Get(ctx, kind, namespace, name)
+	s.log(ctx, "get", kind, namespace, name, start, err)
+	return obj, err
+}
+
+// List retrieves all objects of a given kind and namespace
+func (s *loggingStore) List(ctx context.Context, kind, namespace string) ([]Object, error) {
+	start := time.Now()
+	objs, err := s.Store.List(ctx, kind, namespace)
+	s.log(ctx, "list", kind, namespace, "", start, err)
+	return objs, err
+}
+
+// ListByIndex lists the objects of kind in namespace indexed under value in
+// index
+func (s *loggingStore) ListByIndex(ctx context.Context, kind, namespace, index, value string) ([]Object, error) {
+	start := time.Now()
+	objs, err := ListByIndex(ctx, s.Store, kind, namespace, index, value)
+	s.log(ctx, "list", kind, namespace, index+"="+value, start, err)
+	return objs, err
+}
+
+// Update updates an existing object
+func (s *loggingStore) Update(ctx context.Context, obj Object) error {
+	start := time.Now()
+	err := s.Store.Update(ctx, obj)
+	s.log(ctx, "update", obj.GetKind(), obj.GetNamespace(), obj.GetName(), start, err)
+	return err
+}
+
+// Delete deletes an object by name and namespace
+func (s *loggingStore) Delete(ctx context.Context, kind, namespace, name string) error {
+	start := time.Now()
+	err := s.Store.Delete(ctx, kind, namespace, name)
+	s.log(ctx, "delete", kind, namespace, name, start, err)
+	return err
+}
+
+// Watch watches for changes to objects of a given kind and namespace
+func (s *loggingStore) Watch(ctx context.Context, kind, namespace string) (WatchResult, error) {
+	start := time.Now()
+	result, err := s.Store.Watch(ctx, kind, namespace)
+	s.log(ctx, "watch", kind, namespace, "", start, err)
+	return result, err
+}
+
+// WatchObject watches for changes to one object
+func (s *loggingStore) WatchObject(ctx context.Context, kind, namespace, name string) (WatchResult, error) {
+	start := time.Now()
+	result, err := s.Store.WatchObject(ctx, kind, namespace, name)
+	s.log(ctx, "watch", kind, namespace, name, start, err)
+	return result, err
+}
+
+// log prints a call if it failed or was slow
+func (s *loggingStore) log(ctx context.Context, op, kind, namespace, name string, start time.Time, err error) {
+	latency := time.Since(start)
+	if err == nil && latency < s.slowThreshold {
+		return
+	}
+
+	id := requestid.From(ctx)
+	if id == "" {
+		id = "-"
+	}
+	key := kind + " " + namespace + "/" + name
+	if namespace == "" {
+		key = kind + " " + name
+	}
+
+	if err != nil {
+		fmt.Printf("[%s] store %s %s failed after %s: %v\n", id, op, key, latency, err)
+		return
+	}
+	fmt.Printf("[%s] store %s %s took %s\n", id, op, key, latency)
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/minik8s/minik8s/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout returns what fn prints
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestLoggingStore(t *testing.T) {
+	store := NewLoggingStore(NewMemoryStore(nil), 0)
+	defer store.Close()
+
+	ctx := requestid.WithID(context.Background(), "req-1")
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+	}
+
+	out := captureStdout(t, func() {
+		require.NoError(t, store.Create(ctx, pod))
+		obj, err := store.Get(ctx, "Pod", "default", "web")
+		require.NoError(t, err)
+		assert.Equal(t, "web", obj.GetName())
+	})
+	assert.Empty(t, out, "fast successful calls aren't logged")
+
+	out = captureStdout(t, func() {
+		assert.Error(t, store.Delete(ctx, "Pod", "default", "missing"))
+	})
+	assert.Contains(t, out, "[req-1] store delete Pod default/missing failed")
+}
@@ -16,16 +16,32 @@ type memoryStore struct {
 	objects  map[string]map[string]Object // kind -> namespace -> name -> object
 	watchers map[string][]*watcher        // kind -> watchers
 	options  *Options
+
+	// indexes holds the objects with each value of each secondary index, by
+	// namespace/name; indexed holds the entries of each object, by
+	// kind/namespace/name, so they can be removed when it changes
+	indexes map[indexKey]map[string]Object
+	indexed map[string][]indexKey
 }
 
 // watcher represents a single watch subscription
 type watcher struct {
 	events chan WatchEvent
 	stop   chan struct{}
+	queue  *watchQueue
 	kind   string
 	ns     string
-	closed bool
-	mu     sync.Mutex
+	// name is the one object watched, if any
+	name string
+}
+
+// watchKey identifies the watchers of one namespace of a kind, or of one
+// object when name is set
+func watchKey(kind, namespace, name string) string {
+	if name == "" {
+		return kind + "/" + namespace
+	}
+	return kind + "/" + namespace + "/" + name
 }
 
 // NewMemoryStore creates a new in-memory store
@@ -38,6 +54,8 @@ func NewMemoryStore(options *Options) Store {
 		objects:  make(map[string]map[string]Object),
 		watchers: make(map[string][]*watcher),
 		options:  options,
+		indexes:  make(map[indexKey]map[string]Object),
+		indexed:  make(map[string][]indexKey),
 	}
 
 	// Start garbage collection
@@ -54,6 +72,9 @@ func (s *memoryStore) Create(ctx context.Context, obj Object) error {
 	kind := obj.GetKind()
 	namespace := obj.GetNamespace()
 	name := obj.GetName()
+	if _, ok := kinds[kind]; !ok {
+		return invalidKindError(kind)
+	}
 
 	// Initialize namespace map if it doesn't exist
 	if s.objects[kind] == nil {
@@ -62,7 +83,7 @@ func (s *memoryStore) Create(ctx context.Context, obj Object) error {
 
 	// Check if object already exists
 	if _, exists := s.objects[kind][namespace+"/"+name]; exists {
-		return fmt.Errorf("object %s/%s of kind %s already exists", namespace, name, kind)
+		return alreadyExistsError(obj)
 	}
 
 	// Set metadata
@@ -72,6 +93,7 @@ func (s *memoryStore) Create(ctx context.Context, obj Object) error {
 	// Store the object
 	key := namespace + "/" + name
 	s.objects[kind][key] = obj
+	s.indexObject(key, obj)
 
 	// Notify watchers
 	s.notifyWatchers(Added, obj)
@@ -79,19 +101,37 @@ func (s *memoryStore) Create(ctx context.Context, obj Object) error {
 	return nil
 }
 
+// Import stores obj as given, keeping its resource version
+func (s *memoryStore) Import(ctx context.Context, obj Object) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kind := obj.GetKind()
+	if s.objects[kind] == nil {
+		s.objects[kind] = make(map[string]Object)
+	}
+
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	eventType := Added
+	if _, exists := s.objects[kind][key]; exists {
+		eventType = Modified
+	}
+	s.unindexObject(kind, key)
+	s.objects[kind][key] = obj
+	s.indexObject(key, obj)
+	s.notifyWatchers(eventType, obj)
+
+	return nil
+}
+
 // Get retrieves an object by name and namespace
 func (s *memoryStore) Get(ctx context.Context, kind, namespace, name string) (Object, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.objects[kind] == nil {
-		return nil, fmt.Errorf("no objects of kind %s found", kind)
-	}
-
-	key := namespace + "/" + name
-	obj, exists := s.objects[kind][key]
+	obj, exists := s.objects[kind][namespace+"/"+name]
 	if !exists {
-		return nil, fmt.Errorf("object %s/%s of kind %s not found", namespace, name, kind)
+		return nil, notFoundError(kind, namespace, name)
 	}
 
 	return obj, nil
@@ -108,8 +148,7 @@ func (s *memoryStore) List(ctx context.Context, kind, namespace string) ([]Objec
 
 	var objects []Object
 	for key, obj := range s.objects[kind] {
-		// If namespace is empty, return all objects of this kind
-		if namespace == "" {
+		if namespace == AllNamespaces {
 			objects = append(objects, obj)
 		} else {
 			// Parse namespace from key (format: namespace/name)
@@ -131,20 +170,25 @@ func (s *memoryStore) Update(ctx context.Context, obj Object) error {
 	namespace := obj.GetNamespace()
 	name := obj.GetName()
 
-	if s.objects[kind] == nil {
-		return fmt.Errorf("no objects of kind %s found", kind)
-	}
-
 	key := namespace + "/" + name
-	if _, exists := s.objects[kind][key]; !exists {
-		return fmt.Errorf("object %s/%s of kind %s not found", namespace, name, kind)
+	stored, exists := s.objects[kind][key]
+	if !exists {
+		return notFoundError(kind, namespace, name)
+	}
+	if err := checkUID(stored, obj); err != nil {
+		return err
+	}
+	if err := checkResourceVersion(stored, obj); err != nil {
+		return err
 	}
 
 	// Update resource version
 	obj.SetResourceVersion(fmt.Sprintf("%d", time.Now().UnixNano()))
 
 	// Store the updated object
+	s.unindexObject(kind, key)
 	s.objects[kind][key] = obj
+	s.indexObject(key, obj)
 
 	// Notify watchers
 	s.notifyWatchers(Modified, obj)
@@ -157,14 +201,10 @@ func (s *memoryStore) Delete(ctx context.Context, kind, namespace, name string)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.objects[kind] == nil {
-		return fmt.Errorf("no objects of kind %s found", kind)
-	}
-
 	key := namespace + "/" + name
 	obj, exists := s.objects[kind][key]
 	if !exists {
-		return fmt.Errorf("object %s/%s of kind %s not found", namespace, name, kind)
+		return notFoundError(kind, namespace, name)
 	}
 
 	// Notify watchers before deletion
@@ -172,6 +212,7 @@ func (s *memoryStore) Delete(ctx context.Context, kind, namespace, name string)
 
 	// Delete the object
 	delete(s.objects[kind], key)
+	s.unindexObject(kind, key)
 
 	// Clean up empty namespace maps
 	if len(s.objects[kind]) == 0 {
@@ -183,6 +224,18 @@ func (s *memoryStore) Delete(ctx context.Context, kind, namespace, name string)
 
 // Watch watches for changes to objects of a given kind and namespace
 func (s *memoryStore) Watch(ctx context.Context, kind, namespace string) (WatchResult, error) {
+	return s.watch(ctx, kind, namespace, "")
+}
+
+// WatchObject watches for changes to one object. Only changes to it are
+// queued for the watcher, however busy its kind is.
+func (s *memoryStore) WatchObject(ctx context.Context, kind, namespace, name string) (WatchResult, error) {
+	return s.watch(ctx, kind, namespace, name)
+}
+
+// watch subscribes to the changes of a namespace of kind, or of the object
+// named name in it if name is set
+func (s *memoryStore) watch(ctx context.Context, kind, namespace, name string) (WatchResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -192,30 +245,31 @@ func (s *memoryStore) Watch(ctx context.Context, kind, namespace string) (WatchR
 		stop:   make(chan struct{}),
 		kind:   kind,
 		ns:     namespace,
+		name:   name,
 	}
 
-	// Add to watchers list
-	key := kind + "/" + namespace
-	s.watchers[key] = append(s.watchers[key], w)
-
-	// Send initial events for existing objects
-	if s.objects[kind] != nil {
+	// Send initial events for existing objects; like List, an empty
+	// namespace covers all of them
+	var initial []WatchEvent
+	if name != "" {
+		if obj, exists := s.objects[kind][namespace+"/"+name]; exists {
+			initial = append(initial, WatchEvent{Type: Added, Object: obj})
+		}
+	} else if s.objects[kind] != nil {
 		for objKey, obj := range s.objects[kind] {
-			if len(objKey) > len(namespace)+1 && objKey[:len(namespace)] == namespace && objKey[len(namespace)] == '/' {
-				select {
-				case w.events <- WatchEvent{Type: Added, Object: obj}:
-				default:
-					// Channel is full, skip this event
-				}
+			if namespace == "" || len(objKey) > len(namespace)+1 && objKey[:len(namespace)] == namespace && objKey[len(namespace)] == '/' {
+				initial = append(initial, WatchEvent{Type: Added, Object: obj})
 			}
 		}
 	}
+	w.queue = newWatchQueue(w.events, s.options.WatchQueueLimit, initial)
 
-	// Start cleanup goroutine
-	go func() {
-		<-w.stop
-		s.removeWatcher(w)
-	}()
+	// Add to watchers list
+	key := watchKey(kind, namespace, name)
+	s.watchers[key] = append(s.watchers[key], w)
+
+	// Remove the watcher once it's stopped or ctx is done
+	w.queue.endWith(ctx, w.stop, func() { s.removeWatcher(w) })
 
 	return WatchResult{
 		Events: w.events,
@@ -228,38 +282,37 @@ func (s *memoryStore) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Stop all watchers
+	// End all watches; their consumers see their events channel closed
 	for _, watchers := range s.watchers {
 		for _, w := range watchers {
-			w.mu.Lock()
-			if !w.closed {
-				close(w.stop)
-				w.closed = true
-			}
-			w.mu.Unlock()
+			w.queue.end()
 		}
 	}
 
 	// Clear objects and watchers
 	s.objects = make(map[string]map[string]Object)
 	s.watchers = make(map[string][]*watcher)
+	s.indexes = make(map[indexKey]map[string]Object)
+	s.indexed = make(map[string][]indexKey)
 
 	return nil
 }
 
-// notifyWatchers notifies all watchers of a change
+// notifyWatchers queues a change for all watchers; their queues deliver it,
+// so this never blocks on a slow consumer
 func (s *memoryStore) notifyWatchers(eventType EventType, obj Object) {
 	kind := obj.GetKind()
 	namespace := obj.GetNamespace()
-	key := kind + "/" + namespace
+	key := watchKey(kind, namespace, "")
 
 	watchers := s.watchers[key]
+	if namespace != AllNamespaces {
+		watchers = append(watchers[:len(watchers):len(watchers)], s.watchers[watchKey(kind, AllNamespaces, "")]...)
+	}
+	// Watches of the object itself
+	watchers = append(watchers[:len(watchers):len(watchers)], s.watchers[watchKey(kind, namespace, obj.GetName())]...)
 	for _, w := range watchers {
-		select {
-		case w.events <- WatchEvent{Type: eventType, Object: obj}:
-		default:
-			// Channel is full, skip this event
-		}
+		w.queue.push(WatchEvent{Type: eventType, Object: obj})
 	}
 }
 
@@ -268,7 +321,7 @@ func (s *memoryStore) removeWatcher(w *watcher) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := w.kind + "/" + w.ns
+	key := watchKey(w.kind, w.ns, w.name)
 	watchers := s.watchers[key]
 
 	for i, watcher := range watchers {
@@ -305,7 +358,7 @@ func (s *memoryStore) gc() {
 		var active []*watcher
 		for _, w := range watchers {
 			select {
-			case <-w.stop:
+			case <-w.queue.done:
 				// Watcher is stopped, skip it
 			default:
 				active = append(active, w)
@@ -335,7 +388,7 @@ func DeepCopy(obj Object) (Object, error) {
 	case "Node":
 		copy = &api.Node{}
 	default:
-		return nil, fmt.Errorf("unknown object kind: %s", obj.GetKind())
+		return nil, invalidKindError(obj.GetKind())
 	}
 
 	err = json.Unmarshal(data, copy)
@@ -278,6 +278,79 @@ func TestMemoryStore_Watch(t *testing.T) {
 	// Don't manually close the channel - let the store handle it
 }
 
+func TestMemoryStore_WatchAllNamespaces(t *testing.T) {
+	store := NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	newPod := func(namespace, name string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: namespace},
+		}
+	}
+	require.NoError(t, store.Create(ctx, newPod("default", "existing")))
+
+	// Like List, an empty namespace covers every namespace
+	watchResult, err := store.Watch(ctx, "Pod", "")
+	require.NoError(t, err)
+	require.NoError(t, store.Create(ctx, newPod("team-a", "api")))
+	require.NoError(t, store.Delete(ctx, "Pod", "default", "existing"))
+
+	for _, expected := range []struct {
+		eventType EventType
+		name      string
+	}{{Added, "existing"}, {Added, "api"}, {Deleted, "existing"}} {
+		select {
+		case event := <-watchResult.Events:
+			assert.Equal(t, expected.eventType, event.Type)
+			assert.Equal(t, expected.name, event.Object.GetName())
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for the %s event of %s", expected.eventType, expected.name)
+		}
+	}
+}
+
+func TestMemoryStore_WatchObject(t *testing.T) {
+	store := NewMemoryStore(nil)
+	defer store.Close()
+	ctx := context.Background()
+
+	newPod := func(namespace, name string) *api.Pod {
+		return &api.Pod{
+			TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: namespace},
+		}
+	}
+	require.NoError(t, store.Create(ctx, newPod("default", "web")))
+	require.NoError(t, store.Create(ctx, newPod("default", "other")))
+
+	watchResult, err := store.WatchObject(ctx, "Pod", "default", "web")
+	require.NoError(t, err)
+	defer close(watchResult.Stop)
+
+	// Neither other pods nor a pod of the same name elsewhere are queued
+	require.NoError(t, store.Create(ctx, newPod("team-a", "web")))
+	require.NoError(t, store.Delete(ctx, "Pod", "default", "other"))
+	require.NoError(t, store.Delete(ctx, "Pod", "default", "web"))
+
+	for _, expected := range []EventType{Added, Deleted} {
+		select {
+		case event := <-watchResult.Events:
+			assert.Equal(t, expected, event.Type)
+			assert.Equal(t, "default", event.Object.GetNamespace())
+			assert.Equal(t, "web", event.Object.GetName())
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for the %s event", expected)
+		}
+	}
+	select {
+	case event := <-watchResult.Events:
+		t.Errorf("Unexpected %s event for %s", event.Type, event.Object.GetName())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func TestMemoryStore_DuplicateCreate(t *testing.T) {
 	store := NewMemoryStore(nil)
 	defer store.Close()
@@ -312,3 +385,67 @@ func TestMemoryStore_DuplicateCreate(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "already exists")
 }
+
+func TestMemoryStore_TypedErrors(t *testing.T) {
+	store := NewMemoryStore(nil)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"},
+	}
+	require.NoError(t, store.Create(ctx, pod))
+	assert.ErrorIs(t, store.Create(ctx, pod), ErrAlreadyExists)
+
+	_, err := store.Get(ctx, "Pod", "default", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete(ctx, "Pod", "default", "missing"), ErrNotFound)
+
+	// An update from a pod that was deleted and created again conflicts
+	recreated := *pod
+	recreated.UID = "uid-2"
+	assert.ErrorIs(t, store.Update(ctx, &recreated), ErrConflict)
+	recreated.UID = ""
+	assert.NoError(t, store.Update(ctx, &recreated))
+
+	unknown := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Gadget", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "g", Namespace: "default"},
+	}
+	assert.ErrorIs(t, store.Create(ctx, unknown), ErrInvalidKind)
+}
+
+func TestMemoryStore_UpdateResourceVersion(t *testing.T) {
+	store := NewMemoryStore(nil)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+	}
+	require.NoError(t, store.Create(ctx, pod))
+
+	// Two writers read the same version; the second one's update conflicts
+	first, second := *pod, *pod
+	first.Labels = map[string]string{"writer": "first"}
+	second.Labels = map[string]string{"writer": "second"}
+	require.NoError(t, store.Update(ctx, &first))
+	assert.NotEqual(t, pod.ResourceVersion, first.ResourceVersion)
+	assert.ErrorIs(t, store.Update(ctx, &second), ErrConflict)
+
+	stored, err := store.Get(ctx, "Pod", "default", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "first", stored.(*api.Pod).Labels["writer"])
+
+	// Updating from the current version succeeds, and one without a
+	// version overwrites whatever is stored
+	second.ResourceVersion = first.ResourceVersion
+	require.NoError(t, store.Update(ctx, &second))
+	unconditional := *pod
+	unconditional.ResourceVersion = ""
+	assert.NoError(t, store.Update(ctx, &unconditional))
+}
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// Importer is implemented by stores that can write an object exactly as
+// given, keeping its resource version and creation timestamp instead of
+// assigning new ones. An existing object with the same key is replaced.
+type Importer interface {
+	Import(ctx context.Context, obj Object) error
+}
+
+// Kinds returns the kinds persisted by the store, sorted by name
+func Kinds() []string {
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MigrateResult counts the objects copied by Migrate, by kind
+type MigrateResult struct {
+	Objects map[string]int
+	// PreservedVersions is false when the destination can't import objects,
+	// so they were created with new resource versions
+	PreservedVersions bool
+}
+
+// Total returns the number of objects copied
+func (r *MigrateResult) Total() int {
+	total := 0
+	for _, n := range r.Objects {
+		total += n
+	}
+	return total
+}
+
+// Migrate copies every object of every kind from one store to another. If
+// the destination is an Importer, objects keep their resource versions;
+// otherwise they are created, or updated when they already exist.
+func Migrate(ctx context.Context, from, to Store) (*MigrateResult, error) {
+	importer, preserve := to.(Importer)
+	result := &MigrateResult{Objects: make(map[string]int), PreservedVersions: preserve}
+
+	for _, kind := range Kinds() {
+		objects, err := from.List(ctx, kind, "")
+		if err != nil {
+			return result, fmt.Errorf("failed to list %s: %w", kind, err)
+		}
+
+		for _, obj := range objects {
+			if preserve {
+				err = importer.Import(ctx, obj)
+			} else if err = to.Create(ctx, obj); errors.Is(err, ErrAlreadyExists) {
+				err = to.Update(ctx, obj)
+			}
+			if err != nil {
+				return result, fmt.Errorf("failed to copy %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+			}
+			result.Objects[kind]++
+		}
+	}
+
+	return result, nil
+}
+
+// Verify compares every object in from with its copy in to and returns a
+// sorted description of each one that is missing or differs. Resource
+// versions are only compared when checkVersions is set.
+func Verify(ctx context.Context, from, to Store, checkVersions bool) ([]string, error) {
+	var problems []string
+	for _, kind := range Kinds() {
+		objects, err := from.List(ctx, kind, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+		}
+
+		for _, obj := range objects {
+			id := fmt.Sprintf("%s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+			copied, err := to.Get(ctx, kind, obj.GetNamespace(), obj.GetName())
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: missing: %v", id, err))
+				continue
+			}
+			if checkVersions && copied.GetResourceVersion() != obj.GetResourceVersion() {
+				problems = append(problems, fmt.Sprintf("%s: resourceVersion %s, want %s", id, copied.GetResourceVersion(), obj.GetResourceVersion()))
+				continue
+			}
+			if !sameContent(obj, copied, checkVersions) {
+				problems = append(problems, fmt.Sprintf("%s: content differs", id))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// sameContent reports whether two objects serialize to the same JSON,
+// ignoring metadata the destination assigns unless checkVersions is set
+func sameContent(a, b Object, checkVersions bool) bool {
+	var left, right map[string]interface{}
+	for _, pair := range []struct {
+		obj Object
+		out *map[string]interface{}
+	}{{a, &left}, {b, &right}} {
+		data, err := json.Marshal(pair.obj)
+		if err != nil || json.Unmarshal(data, pair.out) != nil {
+			return false
+		}
+		if !checkVersions {
+			if metadata, ok := (*pair.out)["metadata"].(map[string]interface{}); ok {
+				delete(metadata, "resourceVersion")
+				delete(metadata, "creationTimestamp")
+			}
+		}
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// snapshotEntry is one object in a snapshot
+type snapshotEntry struct {
+	Kind   string          `json:"kind"`
+	Object json.RawMessage `json:"object"`
+}
+
+// SaveSnapshot writes every object in s to w, one JSON line per object
+func SaveSnapshot(ctx context.Context, s Store, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, kind := range Kinds() {
+		objects, err := s.List(ctx, kind, "")
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", kind, err)
+		}
+		for _, obj := range objects {
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+			}
+			if err := encoder.Encode(snapshotEntry{Kind: kind, Object: data}); err != nil {
+				return fmt.Errorf("failed to write snapshot: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot into s, which must
+// be an Importer so that objects keep their resource versions
+func LoadSnapshot(ctx context.Context, s Store, r io.Reader) (int, error) {
+	importer, ok := s.(Importer)
+	if !ok {
+		return 0, fmt.Errorf("store does not support importing objects")
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	count := 0
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry snapshotEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return count, fmt.Errorf("failed to parse snapshot line %d: %w", count+1, err)
+		}
+		obj, ok := newObject(entry.Kind)
+		if !ok {
+			return count, fmt.Errorf("unknown object kind in snapshot: %s", entry.Kind)
+		}
+		if err := json.Unmarshal(entry.Object, obj); err != nil {
+			return count, fmt.Errorf("failed to unmarshal %s: %w", entry.Kind, err)
+		}
+		if err := importer.Import(ctx, obj); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return count, nil
+}
+
+// SaveSnapshotFile writes a snapshot of s to path, replacing the file only
+// once the snapshot is complete
+func SaveSnapshotFile(ctx context.Context, s Store, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := SaveSnapshot(ctx, s, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshotFile reads the snapshot at path into s
+func LoadSnapshotFile(ctx context.Context, s Store, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+	return LoadSnapshot(ctx, s, f)
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createOnlyStore hides the Importer of the store it wraps
+type createOnlyStore struct {
+	Store
+}
+
+func seedStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	require.NoError(t, s.Create(ctx, newTestPod("default", "web")))
+	require.NoError(t, s.Create(ctx, newTestPod("team-a", "api")))
+	require.NoError(t, s.Create(ctx, &api.Namespace{
+		TypeMeta:   api.TypeMeta{Kind: "Namespace", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "team-a"},
+	}))
+}
+
+func TestMigrate_PreservesResourceVersions(t *testing.T) {
+	ctx := context.Background()
+	from := NewMemoryStore(nil)
+	defer from.Close()
+	to := NewMemoryStore(nil)
+	defer to.Close()
+	seedStore(t, from)
+
+	result, err := Migrate(ctx, from, to)
+	require.NoError(t, err)
+	assert.True(t, result.PreservedVersions)
+	assert.Equal(t, 3, result.Total())
+	assert.Equal(t, 2, result.Objects["Pod"])
+
+	original, err := from.Get(ctx, "Pod", "team-a", "api")
+	require.NoError(t, err)
+	copied, err := to.Get(ctx, "Pod", "team-a", "api")
+	require.NoError(t, err)
+	assert.Equal(t, original.GetResourceVersion(), copied.GetResourceVersion())
+
+	problems, err := Verify(ctx, from, to, true)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestMigrate_WithoutImporter(t *testing.T) {
+	ctx := context.Background()
+	from := NewMemoryStore(nil)
+	defer from.Close()
+	to := createOnlyStore{NewMemoryStore(nil)}
+	defer to.Close()
+	seedStore(t, from)
+
+	result, err := Migrate(ctx, from, to)
+	require.NoError(t, err)
+	assert.False(t, result.PreservedVersions)
+	assert.Equal(t, 3, result.Total())
+
+	// Content matches even though the destination assigned new versions
+	problems, err := Verify(ctx, from, to, false)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// Objects already in the destination are updated
+	_, err = Migrate(ctx, from, to)
+	require.NoError(t, err)
+}
+
+func TestVerify_ReportsMissingAndChangedObjects(t *testing.T) {
+	ctx := context.Background()
+	from := NewMemoryStore(nil)
+	defer from.Close()
+	to := NewMemoryStore(nil)
+	defer to.Close()
+	seedStore(t, from)
+
+	_, err := Migrate(ctx, from, to)
+	require.NoError(t, err)
+	require.NoError(t, to.Delete(ctx, "Pod", "default", "web"))
+	changed := newTestPod("team-a", "api")
+	changed.Spec.NodeName = "node-1"
+	require.NoError(t, to.Update(ctx, changed))
+
+	problems, err := Verify(ctx, from, to, false)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "Pod default/web: missing")
+	assert.Contains(t, problems[1], "Pod team-a/api: content differs")
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	from := NewMemoryStore(nil)
+	defer from.Close()
+	seedStore(t, from)
+
+	var buf bytes.Buffer
+	require.NoError(t, SaveSnapshot(ctx, from, &buf))
+
+	to := NewMemoryStore(nil)
+	defer to.Close()
+	n, err := LoadSnapshot(ctx, to, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	problems, err := Verify(ctx, from, to, true)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
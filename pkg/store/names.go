@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// maxGenerateNameAttempts bounds how often CreateWithGeneratedName picks a
+// new name after a conflict
+const maxGenerateNameAttempts = 8
+
+// CreateWithGeneratedName creates obj, whose metadata is meta. If meta has no
+// name but a generateName, the name is generated from it, and a fresh one is
+// tried whenever the generated name is already taken.
+func CreateWithGeneratedName(ctx context.Context, s Store, obj Object, meta *api.ObjectMeta) error {
+	if meta.Name != "" || meta.GenerateName == "" {
+		return s.Create(ctx, obj)
+	}
+
+	for attempt := 0; attempt < maxGenerateNameAttempts; attempt++ {
+		meta.Name = api.GenerateName(meta.GenerateName)
+		err := s.Create(ctx, obj)
+		if !errors.Is(err, ErrAlreadyExists) {
+			return err
+		}
+	}
+
+	meta.Name = ""
+	return fmt.Errorf("failed to generate a unique name from %q after %d attempts: %w",
+		meta.GenerateName, maxGenerateNameAttempts, ErrAlreadyExists)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictingStore reports the first conflicts creates as name conflicts
+type conflictingStore struct {
+	Store
+	conflicts int
+	attempts  []string
+}
+
+func (s *conflictingStore) Create(ctx context.Context, obj Object) error {
+	s.attempts = append(s.attempts, obj.GetName())
+	if len(s.attempts) <= s.conflicts {
+		return ErrAlreadyExists
+	}
+	return s.Store.Create(ctx, obj)
+}
+
+func newGeneratedPod(name, generateName string) *api.Pod {
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, GenerateName: generateName, Namespace: "default"},
+	}
+}
+
+func TestCreateWithGeneratedName(t *testing.T) {
+	s := NewMemoryStore(nil)
+	defer s.Close()
+	ctx := context.Background()
+
+	pod := newGeneratedPod("", "web-")
+	require.NoError(t, CreateWithGeneratedName(ctx, s, pod, &pod.ObjectMeta))
+	assert.True(t, strings.HasPrefix(pod.Name, "web-"))
+	assert.Len(t, pod.Name, len("web-")+5)
+
+	_, err := s.Get(ctx, "Pod", "default", pod.Name)
+	assert.NoError(t, err)
+}
+
+func TestCreateWithGeneratedName_KeepsExplicitName(t *testing.T) {
+	s := NewMemoryStore(nil)
+	defer s.Close()
+	ctx := context.Background()
+
+	pod := newGeneratedPod("web", "web-")
+	require.NoError(t, CreateWithGeneratedName(ctx, s, pod, &pod.ObjectMeta))
+	assert.Equal(t, "web", pod.Name)
+
+	// An explicit name that's taken is a conflict, not a reason to generate one
+	again := newGeneratedPod("web", "web-")
+	err := CreateWithGeneratedName(ctx, s, again, &again.ObjectMeta)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+}
+
+func TestCreateWithGeneratedName_RetriesConflicts(t *testing.T) {
+	s := &conflictingStore{Store: NewMemoryStore(nil), conflicts: 2}
+	defer s.Close()
+
+	pod := newGeneratedPod("", "web-")
+	require.NoError(t, CreateWithGeneratedName(context.Background(), s, pod, &pod.ObjectMeta))
+	require.Len(t, s.attempts, 3)
+	assert.Equal(t, s.attempts[2], pod.Name)
+}
+
+func TestCreateWithGeneratedName_GivesUp(t *testing.T) {
+	s := &conflictingStore{Store: NewMemoryStore(nil), conflicts: 100}
+	defer s.Close()
+
+	pod := newGeneratedPod("", "web-")
+	err := CreateWithGeneratedName(context.Background(), s, pod, &pod.ObjectMeta)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	assert.Len(t, s.attempts, maxGenerateNameAttempts)
+	assert.Empty(t, pod.Name)
+}
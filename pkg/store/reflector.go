@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Backoff between a failed or ended watch and the next list and watch
+const (
+	DefaultReflectorInitialBackoff = time.Second
+	DefaultReflectorMaxBackoff     = 30 * time.Second
+
+	// reflectorBackoffJitter is the maximum fraction of a backoff added to
+	// it at random, so consumers cut off by the same outage don't all list
+	// again at once
+	reflectorBackoffJitter = 0.5
+)
+
+// Reflector keeps a consumer of one kind of object in sync with a store. It
+// lists the objects and hands them to Replace, then applies watch events
+// until the watch sends an Error event or ends, and starts over: listing
+// again is the only way to learn what changed while it wasn't watching.
+// Consecutive failures back off exponentially, with jitter.
+type Reflector struct {
+	// Name identifies the consumer in logs and relist metrics
+	Name string
+
+	Store     Store
+	Kind      string
+	Namespace string
+
+	// Replace is called with all objects after every list
+	Replace func([]Object)
+	// Apply is called with every Added, Modified and Deleted event
+	Apply func(WatchEvent)
+
+	// InitialBackoff and MaxBackoff bound the wait before listing again.
+	// Zero uses the defaults.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Run lists and watches until ctx is done or stop is closed
+func (r *Reflector) Run(ctx context.Context, stop <-chan struct{}) {
+	initial, max := r.InitialBackoff, r.MaxBackoff
+	if initial <= 0 {
+		initial = DefaultReflectorInitialBackoff
+	}
+	if max <= 0 {
+		max = DefaultReflectorMaxBackoff
+	}
+
+	backoff := initial
+	for listed := false; ; listed = true {
+		if listed {
+			countRelist(r.Name, r.Kind)
+		}
+		progressed, err := r.listAndWatch(ctx, stop)
+		if err != nil {
+			fmt.Printf("Error watching %ss for %s, listing again: %v\n", r.Kind, r.Name, err)
+		}
+		if progressed {
+			backoff = initial
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-time.After(jitter(backoff)):
+		}
+		if backoff *= 2; backoff > max {
+			backoff = max
+		}
+	}
+}
+
+// listAndWatch lists the objects into the consumer and applies watch events
+// until the watch fails or ends. progressed reports whether the watch
+// delivered any change, meaning the store was healthy for a while.
+func (r *Reflector) listAndWatch(ctx context.Context, stop <-chan struct{}) (progressed bool, err error) {
+	result, err := r.Store.Watch(ctx, r.Kind, r.Namespace)
+	if err != nil {
+		return false, err
+	}
+	defer close(result.Stop)
+
+	// Listing after the watch started means no change falls in between
+	objs, err := r.Store.List(ctx, r.Kind, r.Namespace)
+	if err != nil {
+		return false, err
+	}
+	r.Replace(objs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return progressed, nil
+		case <-stop:
+			return progressed, nil
+		case event, ok := <-result.Events:
+			if !ok {
+				return progressed, fmt.Errorf("watch ended")
+			}
+			switch event.Type {
+			case Added, Modified, Deleted:
+				r.Apply(event)
+				progressed = true
+			case Error:
+				return progressed, fmt.Errorf("watch failed")
+			}
+		}
+	}
+}
+
+// jitter adds up to reflectorBackoffJitter of d to it at random
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Float64()*reflectorBackoffJitter*float64(d))
+}
+
+// relistKey identifies a series of the relist counter
+type relistKey struct {
+	name, kind string
+}
+
+var (
+	relistsMu sync.Mutex
+	relists   = make(map[relistKey]int64)
+)
+
+// countRelist counts a list made after a watch failed or ended
+func countRelist(name, kind string) {
+	relistsMu.Lock()
+	defer relistsMu.Unlock()
+	relists[relistKey{name: name, kind: kind}]++
+}
+
+// RelistCount is the number of times one reflector listed again
+type RelistCount struct {
+	Name  string
+	Kind  string
+	Count int64
+}
+
+// Relists returns how often the reflectors of this process listed again
+// after their watch failed or ended, ordered by name and kind
+func Relists() []RelistCount {
+	relistsMu.Lock()
+	defer relistsMu.Unlock()
+
+	counts := make([]RelistCount, 0, len(relists))
+	for key, count := range relists {
+		counts = append(counts, RelistCount{Name: key.name, Kind: key.kind, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Name != counts[j].Name {
+			return counts[i].Name < counts[j].Name
+		}
+		return counts[i].Kind < counts[j].Kind
+	})
+	return counts
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingWatchStore fails its first watch with an Error event, like a watch
+// cut off by an etcd outage
+type failingWatchStore struct {
+	Store
+	watches atomic.Int32
+}
+
+func (s *failingWatchStore) Watch(ctx context.Context, kind, namespace string) (WatchResult, error) {
+	if s.watches.Add(1) > 1 {
+		return s.Store.Watch(ctx, kind, namespace)
+	}
+	result := WatchResult{Events: make(chan WatchEvent, 1), Stop: make(chan struct{})}
+	result.Events <- WatchEvent{Type: Error}
+	return result, nil
+}
+
+func TestReflector_RelistsAfterWatchError(t *testing.T) {
+	memory := NewMemoryStore(nil)
+	defer memory.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, memory.Create(ctx, &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+	}))
+
+	before := relistCount("test", "Pod")
+	lists := make(chan int, 4)
+	applied := make(chan WatchEvent, 4)
+	reflector := &Reflector{
+		Name:           "test",
+		Store:          &failingWatchStore{Store: memory},
+		Kind:           "Pod",
+		Replace:        func(objs []Object) { lists <- len(objs) },
+		Apply:          func(event WatchEvent) { applied <- event },
+		InitialBackoff: 10 * time.Millisecond,
+	}
+	go reflector.Run(ctx, nil)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case n := <-lists:
+			assert.Equal(t, 1, n)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the reflector to list again after the watch failed")
+		}
+	}
+
+	require.NoError(t, memory.Create(ctx, &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "api", Namespace: "default"},
+	}))
+	// The watch replays the stored pods before the new one
+	for created := false; !created; {
+		select {
+		case event := <-applied:
+			assert.Equal(t, Added, event.Type)
+			created = event.Object.GetName() == "api"
+		case <-time.After(time.Second):
+			t.Fatal("Expected the new watch to deliver events")
+		}
+	}
+
+	assert.Equal(t, before+1, relistCount("test", "Pod"))
+}
+
+// relistCount returns the relists counted for one reflector so far
+func relistCount(name, kind string) int64 {
+	for _, relist := range Relists() {
+		if relist.Name == name && relist.Kind == kind {
+			return relist.Count
+		}
+	}
+	return 0
+}
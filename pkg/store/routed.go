@@ -0,0 +1,307 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// StoreRoute sends objects of one kind, optionally only those in one
+// namespace, to their own backend. Routes let high-churn kinds such as
+// Events live in a cheaper backend, or give namespaces their own etcd prefix.
+type StoreRoute struct {
+	Kind string
+	// Namespace limits the route to one namespace; empty matches all of them
+	Namespace string
+	Type      StoreType
+	// Endpoints and Prefix default to those of the enclosing StoreConfig
+	Endpoints []string
+	Prefix    string
+}
+
+// ParseStoreRoutes parses a comma-separated list of routes of the form
+// Kind[/namespace]=type[:prefix], e.g. Event=memory,Pod/team-a=etcd:/team-a
+func ParseStoreRoutes(spec string) ([]StoreRoute, error) {
+	var routes []StoreRoute
+	if spec == "" {
+		return routes, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		target, backend, ok := strings.Cut(entry, "=")
+		if !ok || target == "" || backend == "" {
+			return nil, fmt.Errorf("expected Kind[/namespace]=type[:prefix], got %q", entry)
+		}
+
+		var route StoreRoute
+		route.Kind, route.Namespace, _ = strings.Cut(target, "/")
+		storeType, prefix, _ := strings.Cut(backend, ":")
+		route.Type = StoreType(storeType)
+		route.Prefix = prefix
+		if _, known := kinds[route.Kind]; !known {
+			return nil, fmt.Errorf("unknown kind %q in store route %q", route.Kind, entry)
+		}
+		if route.Type != StoreTypeMemory && route.Type != StoreTypeEtcd {
+			return nil, fmt.Errorf("unknown store type %q in store route %q", route.Type, entry)
+		}
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// routedBackend is a route and the store it resolved to
+type routedBackend struct {
+	kind      string
+	namespace string
+	store     Store
+}
+
+// routedStore dispatches each call to the backend routed for the object's
+// kind and namespace, falling back to a default backend
+type routedStore struct {
+	defaultStore Store
+	routes       []routedBackend
+	stores       []Store
+}
+
+// newRoutedStore builds the backends of config with build and routes between
+// them. Routes resolving to the same type, endpoints and prefix share one
+// backend.
+func newRoutedStore(config *StoreConfig, build func(*StoreConfig) (Store, error)) (Store, error) {
+	r := &routedStore{}
+	byKey := make(map[string]Store)
+	backend := func(c *StoreConfig) (Store, error) {
+		key := fmt.Sprintf("%s|%s|%s", c.Type, strings.Join(c.Endpoints, ","), c.Prefix)
+		if s, ok := byKey[key]; ok {
+			return s, nil
+		}
+		s, err := build(c)
+		if err != nil {
+			return nil, err
+		}
+		byKey[key] = s
+		r.stores = append(r.stores, s)
+		return s, nil
+	}
+
+	base := *config
+	base.Routes = nil
+	defaultStore, err := backend(&base)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	r.defaultStore = defaultStore
+
+	for _, route := range config.Routes {
+		routeConfig := base
+		routeConfig.Type = route.Type
+		if len(route.Endpoints) > 0 {
+			routeConfig.Endpoints = route.Endpoints
+		}
+		if route.Prefix != "" {
+			routeConfig.Prefix = route.Prefix
+		}
+
+		s, err := backend(&routeConfig)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to create store for %s: %w", route.Kind, err)
+		}
+		r.routes = append(r.routes, routedBackend{kind: route.Kind, namespace: route.Namespace, store: s})
+	}
+
+	return r, nil
+}
+
+// storeFor returns the backend holding objects of kind in namespace. A route
+// for the namespace wins over one for the whole kind.
+func (r *routedStore) storeFor(kind, namespace string) Store {
+	var kindStore Store
+	for _, route := range r.routes {
+		if route.kind != kind {
+			continue
+		}
+		if route.namespace == namespace && namespace != "" {
+			return route.store
+		}
+		if route.namespace == "" && kindStore == nil {
+			kindStore = route.store
+		}
+	}
+	if kindStore != nil {
+		return kindStore
+	}
+	return r.defaultStore
+}
+
+// storesFor returns every backend that may hold objects of kind in
+// namespace; listing all namespaces may span several backends
+func (r *routedStore) storesFor(kind, namespace string) []Store {
+	stores := []Store{r.storeFor(kind, namespace)}
+	if namespace != AllNamespaces {
+		return stores
+	}
+
+	for _, route := range r.routes {
+		if route.kind != kind || route.namespace == "" {
+			continue
+		}
+		seen := false
+		for _, s := range stores {
+			if s == route.store {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			stores = append(stores, route.store)
+		}
+	}
+	return stores
+}
+
+// Create creates a new object in the store
+func (r *routedStore) Create(ctx context.Context, obj Object) error {
+	return r.storeFor(obj.GetKind(), obj.GetNamespace()).Create(ctx, obj)
+}
+
+// Get retrieves an object by name and namespace
+func (r *routedStore) Get(ctx context.Context, kind, namespace, name string) (Object, error) {
+	return r.storeFor(kind, namespace).Get(ctx, kind, namespace, name)
+}
+
+// List retrieves all objects of a given kind and namespace
+func (r *routedStore) List(ctx context.Context, kind, namespace string) ([]Object, error) {
+	var objects []Object
+	for _, s := range r.storesFor(kind, namespace) {
+		objs, err := s.List(ctx, kind, namespace)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, objs...)
+	}
+	return objects, nil
+}
+
+// ListByIndex lists the objects of kind in namespace indexed under value in
+// index, from every backend holding them
+func (r *routedStore) ListByIndex(ctx context.Context, kind, namespace, index, value string) ([]Object, error) {
+	var objects []Object
+	for _, s := range r.storesFor(kind, namespace) {
+		objs, err := ListByIndex(ctx, s, kind, namespace, index, value)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, objs...)
+	}
+	return objects, nil
+}
+
+// Import stores obj as given in its routed backend
+func (r *routedStore) Import(ctx context.Context, obj Object) error {
+	importer, ok := r.storeFor(obj.GetKind(), obj.GetNamespace()).(Importer)
+	if !ok {
+		return fmt.Errorf("store for %s does not support importing objects", obj.GetKind())
+	}
+	return importer.Import(ctx, obj)
+}
+
+// Update updates an existing object
+func (r *routedStore) Update(ctx context.Context, obj Object) error {
+	return r.storeFor(obj.GetKind(), obj.GetNamespace()).Update(ctx, obj)
+}
+
+// Delete deletes an object by name and namespace
+func (r *routedStore) Delete(ctx context.Context, kind, namespace, name string) error {
+	return r.storeFor(kind, namespace).Delete(ctx, kind, namespace, name)
+}
+
+// Watch watches for changes to objects of a given kind and namespace. When
+// the objects span several backends, their events are merged into one stream.
+func (r *routedStore) Watch(ctx context.Context, kind, namespace string) (WatchResult, error) {
+	stores := r.storesFor(kind, namespace)
+	if len(stores) == 1 {
+		return stores[0].Watch(ctx, kind, namespace)
+	}
+
+	var results []WatchResult
+	for _, s := range stores {
+		result, err := s.Watch(ctx, kind, namespace)
+		if err != nil {
+			for _, started := range results {
+				close(started.Stop)
+			}
+			return WatchResult{}, err
+		}
+		results = append(results, result)
+	}
+
+	merged := WatchResult{
+		Events: make(chan WatchEvent, cap(results[0].Events)),
+		Stop:   make(chan struct{}),
+	}
+	var once sync.Once
+	stopAll := func() {
+		once.Do(func() {
+			// A backend that was closed already stopped its watch
+			for _, result := range results {
+				select {
+				case <-result.Stop:
+				default:
+					close(result.Stop)
+				}
+			}
+		})
+	}
+	// The merged watch ends once every backend watch has ended
+	var wg sync.WaitGroup
+	wg.Add(len(results))
+	go func() {
+		wg.Wait()
+		close(merged.Events)
+	}()
+	for _, result := range results {
+		go func(result WatchResult) {
+			defer wg.Done()
+			for {
+				select {
+				case <-merged.Stop:
+					stopAll()
+					return
+				case event, ok := <-result.Events:
+					if !ok {
+						return
+					}
+					select {
+					case merged.Events <- event:
+					case <-merged.Stop:
+						stopAll()
+						return
+					}
+				}
+			}
+		}(result)
+	}
+
+	return merged, nil
+}
+
+// WatchObject watches for changes to one object in its routed backend
+func (r *routedStore) WatchObject(ctx context.Context, kind, namespace, name string) (WatchResult, error) {
+	return r.storeFor(kind, namespace).WatchObject(ctx, kind, namespace, name)
+}
+
+// Close closes every backend
+func (r *routedStore) Close() error {
+	var firstErr error
+	for _, s := range r.stores {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPod(namespace, name string) *api.Pod {
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+// newTestRoutedStore builds a routed store of memory backends and returns the
+// backends it created by prefix
+func newTestRoutedStore(t *testing.T, routes []StoreRoute) (Store, map[string]Store) {
+	backends := make(map[string]Store)
+	s, err := newRoutedStore(&StoreConfig{Type: StoreTypeMemory, Prefix: "/minik8s", Routes: routes},
+		func(c *StoreConfig) (Store, error) {
+			backend := NewMemoryStore(nil)
+			backends[c.Prefix] = backend
+			return backend, nil
+		})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, backends
+}
+
+func TestParseStoreRoutes(t *testing.T) {
+	routes, err := ParseStoreRoutes("Event=memory, Pod/team-a=etcd:/team-a")
+	require.NoError(t, err)
+	assert.Equal(t, []StoreRoute{
+		{Kind: "Event", Type: StoreTypeMemory},
+		{Kind: "Pod", Namespace: "team-a", Type: StoreTypeEtcd, Prefix: "/team-a"},
+	}, routes)
+
+	routes, err = ParseStoreRoutes("")
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	for _, spec := range []string{"Event", "Event=", "Widget=memory", "Event=sqlite"} {
+		_, err := ParseStoreRoutes(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRoutedStore_Routing(t *testing.T) {
+	ctx := context.Background()
+	s, backends := newTestRoutedStore(t, []StoreRoute{
+		{Kind: "Pod", Type: StoreTypeMemory, Prefix: "/pods"},
+		{Kind: "Pod", Namespace: "team-a", Type: StoreTypeMemory, Prefix: "/team-a"},
+		{Kind: "Event", Type: StoreTypeMemory, Prefix: "/pods"},
+	})
+	// Routes with the same backend settings share a store
+	require.Len(t, backends, 3)
+
+	require.NoError(t, s.Create(ctx, newTestPod("default", "web")))
+	require.NoError(t, s.Create(ctx, newTestPod("team-a", "api")))
+
+	_, err := backends["/pods"].Get(ctx, "Pod", "default", "web")
+	assert.NoError(t, err)
+	_, err = backends["/team-a"].Get(ctx, "Pod", "team-a", "api")
+	assert.NoError(t, err)
+	_, err = backends["/minik8s"].Get(ctx, "Pod", "default", "web")
+	assert.Error(t, err)
+
+	obj, err := s.Get(ctx, "Pod", "team-a", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "api", obj.GetName())
+
+	// Listing all namespaces spans the kind's backends
+	pods, err := s.List(ctx, "Pod", "")
+	require.NoError(t, err)
+	assert.Len(t, pods, 2)
+	pods, err = s.List(ctx, "Pod", "team-a")
+	require.NoError(t, err)
+	assert.Len(t, pods, 1)
+
+	require.NoError(t, s.Delete(ctx, "Pod", "team-a", "api"))
+	_, err = backends["/team-a"].Get(ctx, "Pod", "team-a", "api")
+	assert.Error(t, err)
+}
+
+func TestRoutedStore_WatchMergesBackends(t *testing.T) {
+	ctx := context.Background()
+	s, backends := newTestRoutedStore(t, []StoreRoute{
+		{Kind: "Node", Namespace: "edge", Type: StoreTypeMemory, Prefix: "/edge"},
+	})
+	require.Len(t, backends, 2)
+
+	watch, err := s.Watch(ctx, "Node", "")
+	require.NoError(t, err)
+
+	node := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+	}
+	require.NoError(t, s.Create(ctx, node))
+	// An object only the routed backend sees still reaches the watch
+	edge := &api.Node{
+		TypeMeta:   api.TypeMeta{Kind: "Node", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "node-2"},
+	}
+	require.NoError(t, backends["/edge"].Create(ctx, edge))
+
+	names := make(map[string]bool)
+	for len(names) < 2 {
+		select {
+		case event := <-watch.Events:
+			names[event.Object.GetName()] = true
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for watch events, got %v", names)
+		}
+	}
+	close(watch.Stop)
+}
+
+func TestRoutedStore_WatchEndsWithContext(t *testing.T) {
+	s, _ := newTestRoutedStore(t, []StoreRoute{
+		{Kind: "Pod", Namespace: "team-a", Type: StoreTypeMemory, Prefix: "/team-a"},
+	})
+	baseline := runtime.NumGoroutine()
+
+	// An all-namespaces watch spans both backends; it ends once both do
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := s.Watch(ctx, "Pod", "")
+	require.NoError(t, err)
+	cancel()
+
+	select {
+	case <-drain(result.Events):
+	case <-time.After(time.Second):
+		t.Fatal("Events wasn't closed after the context was canceled")
+	}
+	waitForGoroutines(t, baseline)
+}
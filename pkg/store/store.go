@@ -26,6 +26,10 @@ const (
 	Modified EventType = "MODIFIED"
 	Deleted  EventType = "DELETED"
 	Error    EventType = "ERROR"
+
+	// Bookmark events carry no object; the API server sends them as
+	// heartbeats on otherwise idle watch streams
+	Bookmark EventType = "BOOKMARK"
 )
 
 // WatchEvent represents a single watch event
@@ -40,6 +44,11 @@ type WatchResult struct {
 	Stop   chan struct{}
 }
 
+// AllNamespaces is the namespace List, ListByIndex and Watch are given to
+// span every namespace of a kind. Cluster-scoped objects have no namespace,
+// so for their kinds it covers every object as well.
+const AllNamespaces = ""
+
 // Store defines the interface for a data store
 type Store interface {
 	// Create creates a new object in the store
@@ -48,18 +57,26 @@ type Store interface {
 	// Get retrieves an object by name and namespace
 	Get(ctx context.Context, kind, namespace, name string) (Object, error)
 
-	// List retrieves all objects of a given kind and namespace
+	// List retrieves all objects of a given kind and namespace, or of every
+	// namespace given AllNamespaces
 	List(ctx context.Context, kind, namespace string) ([]Object, error)
 
-	// Update updates an existing object
+	// Update updates an existing object. Updates carrying a resource version
+	// other than the stored one fail with ErrConflict; those without one
+	// overwrite the object unconditionally.
 	Update(ctx context.Context, obj Object) error
 
 	// Delete deletes an object by name and namespace
 	Delete(ctx context.Context, kind, namespace, name string) error
 
-	// Watch watches for changes to objects of a given kind and namespace
+	// Watch watches for changes to objects of a given kind and namespace,
+	// or of every namespace given AllNamespaces
 	Watch(ctx context.Context, kind, namespace string) (WatchResult, error)
 
+	// WatchObject watches for changes to the one object of kind named name
+	// in namespace, starting with an Added event if it exists
+	WatchObject(ctx context.Context, kind, namespace, name string) (WatchResult, error)
+
 	// Close closes the store and releases resources
 	Close() error
 }
@@ -68,6 +85,9 @@ type Store interface {
 type Options struct {
 	// WatchBufferSize is the size of the buffer for watch events
 	WatchBufferSize int
+	// WatchQueueLimit is how many events may wait for a slow watcher
+	// before its watch is ended
+	WatchQueueLimit int
 	// GCInterval is the interval for garbage collection
 	GCInterval time.Duration
 }
@@ -76,6 +96,7 @@ type Options struct {
 func DefaultOptions() *Options {
 	return &Options{
 		WatchBufferSize: 100,
+		WatchQueueLimit: DefaultWatchQueueLimit,
 		GCInterval:      5 * time.Minute,
 	}
 }
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultOperationTimeout is how long NewTimeoutStore lets a call run
+const DefaultOperationTimeout = 10 * time.Second
+
+// timeoutStore bounds every call with a deadline, so a hung backend such as
+// an unreachable etcd fails calls instead of blocking their callers forever
+type timeoutStore struct {
+	Store
+	timeout time.Duration
+}
+
+// NewTimeoutStore wraps s so that every call but Watch and WatchObject is
+// cancelled after timeout; zero or negative uses DefaultOperationTimeout. Watches outlive any
+// single call and are bounded by their context alone.
+func NewTimeoutStore(s Store, timeout time.Duration) Store {
+	if timeout <= 0 {
+		timeout = DefaultOperationTimeout
+	}
+	return &timeoutStore{Store: s, timeout: timeout}
+}
+
+// Create creates a new object in the store
+func (s *timeoutStore) Create(ctx context.Context, obj Object) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.check(ctx, "create", s.Store.Create(ctx, obj))
+}
+
+// Get retrieves an object by name and namespace
+func (s *timeoutStore) Get(ctx context.Context, kind, namespace, name string) (Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	obj, err := s.Store.Get(ctx, kind, namespace, name)
+	return obj, s.check(ctx, "get", err)
+}
+
+// List retrieves all objects of a given kind and namespace
+func (s *timeoutStore) List(ctx context.Context, kind, namespace string) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	objs, err := s.Store.List(ctx, kind, namespace)
+	return objs, s.check(ctx, "list", err)
+}
+
+// ListByIndex lists the objects of kind in namespace indexed under value in
+// index
+func (s *timeoutStore) ListByIndex(ctx context.Context, kind, namespace, index, value string) ([]Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	objs, err := ListByIndex(ctx, s.Store, kind, namespace, index, value)
+	return objs, s.check(ctx, "list", err)
+}
+
+// Update updates an existing object
+func (s *timeoutStore) Update(ctx context.Context, obj Object) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.check(ctx, "update", s.Store.Update(ctx, obj))
+}
+
+// Delete deletes an object by name and namespace
+func (s *timeoutStore) Delete(ctx context.Context, kind, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.check(ctx, "delete", s.Store.Delete(ctx, kind, namespace, name))
+}
+
+// check returns the error of a call made with ctx, marking it as a timeout
+// when the call failed because ctx's deadline passed
+func (s *timeoutStore) check(ctx context.Context, op string, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if tracker, ok := ctx.Value(timeoutTrackerKey{}).(*atomic.Bool); ok {
+		tracker.Store(true)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("store %s timed out: %w", op, err)
+	}
+	return fmt.Errorf("store %s timed out: %w: %v", op, context.DeadlineExceeded, err)
+}
+
+type timeoutTrackerKey struct{}
+
+// WithTimeoutTracking returns a context under which TimedOut reports whether
+// a store call made with it ran out of time
+func WithTimeoutTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timeoutTrackerKey{}, new(atomic.Bool))
+}
+
+// TimedOut reports whether a store call made with ctx, which must come from
+// WithTimeoutTracking, failed because its deadline passed
+func TimedOut(ctx context.Context) bool {
+	tracker, ok := ctx.Value(timeoutTrackerKey{}).(*atomic.Bool)
+	return ok && tracker.Load()
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hungStore blocks Get until its context is done, like an unreachable etcd
+type hungStore struct {
+	Store
+}
+
+func (s *hungStore) Get(ctx context.Context, kind, namespace, name string) (Object, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeoutStore(t *testing.T) {
+	store := NewTimeoutStore(&hungStore{Store: NewMemoryStore(nil)}, 20*time.Millisecond)
+	defer store.Close()
+
+	ctx := WithTimeoutTracking(context.Background())
+	pod := &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default"},
+	}
+	require.NoError(t, store.Create(ctx, pod))
+	assert.Error(t, store.Delete(ctx, "Pod", "default", "missing"))
+	assert.False(t, TimedOut(ctx), "calls failing in time aren't timeouts")
+
+	start := time.Now()
+	_, err := store.Get(ctx, "Pod", "default", "web")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, TimedOut(ctx))
+	assert.False(t, TimedOut(context.Background()))
+}
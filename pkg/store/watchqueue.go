@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWatchQueueLimit is how many events may wait for a watcher before
+// its watch is ended
+const DefaultWatchQueueLimit = 1000
+
+// watchQueue delivers the events of one watch, in order, from a goroutine of
+// its own. Store writes only append to the queue, so a consumer that reads
+// slowly never holds up writers or the other watchers. A consumer that falls
+// more than limit events behind gets an Error event and its events channel
+// is closed, so it lists again instead of silently missing changes.
+//
+// The events channel is also closed when the watch ends, whether its
+// consumer closed its stop channel, the context it was started with is done
+// or the store was closed.
+type watchQueue struct {
+	mu         sync.Mutex
+	pending    []WatchEvent
+	limit      int
+	overflowed bool
+
+	// ready is signalled when events are queued
+	ready  chan struct{}
+	events chan WatchEvent
+
+	// done is closed when the watch ends
+	done    chan struct{}
+	endOnce sync.Once
+}
+
+// newWatchQueue starts delivering to events, beginning with initial, which
+// doesn't count against limit
+func newWatchQueue(events chan WatchEvent, limit int, initial []WatchEvent) *watchQueue {
+	if limit <= 0 {
+		limit = DefaultWatchQueueLimit
+	}
+	q := &watchQueue{
+		pending: initial,
+		limit:   len(initial) + limit,
+		ready:   make(chan struct{}, 1),
+		events:  events,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// end ends the watch; it's safe to call more than once
+func (q *watchQueue) end() {
+	q.endOnce.Do(func() { close(q.done) })
+}
+
+// endWith ends the watch once stop is closed or ctx is done, whichever
+// comes first, and then calls release to let go of whatever the store
+// holds for it. It returns right away.
+func (q *watchQueue) endWith(ctx context.Context, stop <-chan struct{}, release func()) {
+	go func() {
+		select {
+		case <-stop:
+		case <-ctx.Done():
+		case <-q.done:
+		}
+		q.end()
+		release()
+	}()
+}
+
+// push queues an event without blocking
+func (q *watchQueue) push(event WatchEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.overflowed {
+		return
+	}
+	if len(q.pending) >= q.limit {
+		q.overflowed = true
+		q.pending = nil
+	} else {
+		q.pending = append(q.pending, event)
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next waits for the next event to deliver. It returns false when the watch
+// overflowed, and also when it was stopped, in which case stopped is true.
+func (q *watchQueue) next() (event WatchEvent, ok, stopped bool) {
+	for {
+		q.mu.Lock()
+		if q.overflowed {
+			q.mu.Unlock()
+			return WatchEvent{}, false, false
+		}
+		if len(q.pending) > 0 {
+			event = q.pending[0]
+			q.pending[0] = WatchEvent{}
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+			return event, true, false
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-q.done:
+			return WatchEvent{}, false, true
+		}
+	}
+}
+
+// run delivers queued events until the watch ends or overflows, and then
+// closes the events channel. An overflow ends the watch too.
+func (q *watchQueue) run() {
+	defer close(q.events)
+	defer q.end()
+	for {
+		event, ok, stopped := q.next()
+		if stopped {
+			return
+		}
+		if !ok {
+			event = WatchEvent{Type: Error}
+		}
+
+		select {
+		case q.events <- event:
+		case <-q.done:
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+}
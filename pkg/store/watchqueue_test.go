@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func watchedPod(name string) *api.Pod {
+	return &api.Pod{
+		TypeMeta:   api.TypeMeta{Kind: "Pod", APIVersion: "v1alpha1"},
+		ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default"},
+	}
+}
+
+func TestMemoryStore_WatchDeliversInOrder(t *testing.T) {
+	// Far more events than the channel holds, none of them dropped
+	store := NewMemoryStore(&Options{WatchBufferSize: 1, WatchQueueLimit: 1000, GCInterval: time.Minute})
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.Create(ctx, watchedPod("existing")))
+	result, err := store.Watch(ctx, "Pod", "default")
+	require.NoError(t, err)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, store.Create(ctx, watchedPod(fmt.Sprintf("pod-%d", i))))
+	}
+
+	expected := []string{"existing"}
+	for i := 0; i < 200; i++ {
+		expected = append(expected, fmt.Sprintf("pod-%d", i))
+	}
+	for _, name := range expected {
+		select {
+		case event := <-result.Events:
+			require.Equal(t, Added, event.Type)
+			require.Equal(t, name, event.Object.GetName())
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout waiting for the event of %s", name)
+		}
+	}
+}
+
+func TestMemoryStore_WatchOverflow(t *testing.T) {
+	store := NewMemoryStore(&Options{WatchBufferSize: 1, WatchQueueLimit: 10, GCInterval: time.Minute})
+	defer store.Close()
+	ctx := context.Background()
+
+	// A watcher that never reads doesn't hold up writes
+	stalled, err := store.Watch(ctx, "Pod", "default")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			store.Create(ctx, watchedPod(fmt.Sprintf("pod-%d", i)))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Writes blocked on a stalled watcher")
+	}
+
+	// Once it reads again it gets what it had buffered, then an Error
+	// event, and the channel is closed so it knows to list again
+	var last WatchEvent
+	count := 0
+	for event := range stalled.Events {
+		last = event
+		count++
+	}
+	assert.Equal(t, Error, last.Type)
+	assert.Less(t, count, 100)
+}
+
+// waitForGoroutines waits for the number of goroutines to drop back to
+// baseline, failing the test if it doesn't within a few seconds
+func waitForGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines leaked:\n%s", runtime.NumGoroutine()-baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMemoryStore_WatchEndsWithContext(t *testing.T) {
+	store := NewMemoryStore(&Options{WatchBufferSize: 1, GCInterval: time.Minute})
+	defer store.Close()
+	ms := store.(*memoryStore)
+	require.NoError(t, store.Create(context.Background(), watchedPod("existing")))
+	baseline := runtime.NumGoroutine()
+
+	// Cancelling the context, as an HTTP client disconnecting does, ends
+	// the watch without its consumer closing Stop
+	var results []WatchResult
+	var cancels []context.CancelFunc
+	for i := 0; i < 50; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		result, err := store.Watch(ctx, "Pod", "default")
+		require.NoError(t, err)
+		results = append(results, result)
+		cancels = append(cancels, cancel)
+	}
+	for _, cancel := range cancels {
+		cancel()
+	}
+
+	for _, result := range results {
+		select {
+		case <-drain(result.Events):
+		case <-time.After(time.Second):
+			t.Fatal("Events wasn't closed after the context was canceled")
+		}
+	}
+	waitForGoroutines(t, baseline)
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	assert.Empty(t, ms.watchers)
+}
+
+func TestMemoryStore_WatchEndsWithStop(t *testing.T) {
+	store := NewMemoryStore(&Options{WatchBufferSize: 1, GCInterval: time.Minute})
+	defer store.Close()
+	ms := store.(*memoryStore)
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, watchedPod("existing")))
+	baseline := runtime.NumGoroutine()
+
+	// Watchers that stopped reading halfway still release everything
+	for i := 0; i < 50; i++ {
+		result, err := store.Watch(ctx, "Pod", "default")
+		require.NoError(t, err)
+		close(result.Stop)
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.Create(ctx, watchedPod(fmt.Sprintf("pod-%d", i))))
+	}
+	waitForGoroutines(t, baseline)
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	assert.Empty(t, ms.watchers)
+}
+
+// drain reads events until the channel is closed, and then closes the
+// channel it returns
+func drain(events <-chan WatchEvent) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range events {
+		}
+		close(done)
+	}()
+	return done
+}
+
+// BenchmarkMemoryStore_UpdateWithWatchers measures write throughput with
+// ten watchers, one of which never reads its events
+func BenchmarkMemoryStore_UpdateWithWatchers(b *testing.B) {
+	store := NewMemoryStore(DefaultOptions())
+	defer store.Close()
+	ctx := context.Background()
+
+	pod := watchedPod("web")
+	if err := store.Create(ctx, pod); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		result, err := store.Watch(ctx, "Pod", "default")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if i == 0 {
+			continue // The stalled watcher
+		}
+		go func() {
+			for range result.Events {
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.Update(ctx, pod); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
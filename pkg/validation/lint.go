@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// Warnings returns best-practice problems with the containers of obj that
+// the API server accepts but that tend to cause trouble in production:
+// missing probes, missing resource requests or limits, and images without a
+// pinned tag
+func Warnings(obj interface{}) []string {
+	var spec *api.PodSpec
+	var path string
+	switch obj := obj.(type) {
+	case *api.Pod:
+		spec, path = &obj.Spec, "spec"
+	case *api.Deployment:
+		spec, path = &obj.Spec.Template.Spec, "spec.template.spec"
+	case *api.ReplicaSet:
+		spec, path = &obj.Spec.Template.Spec, "spec.template.spec"
+	case *api.Job:
+		spec, path = &obj.Spec.Template.Spec, "spec.template.spec"
+	case *api.CronJob:
+		spec, path = &obj.Spec.JobTemplate.Spec.Template.Spec, "spec.jobTemplate.spec.template.spec"
+	default:
+		return nil
+	}
+
+	// Pods of jobs run to completion, so they aren't probed for readiness
+	// or liveness
+	_, batch := obj.(*api.Job)
+	if _, ok := obj.(*api.CronJob); ok {
+		batch = true
+	}
+
+	var warnings []string
+	for _, container := range spec.Containers {
+		prefix := fmt.Sprintf("%s.containers[%s]", path, container.Name)
+		if !batch && container.ReadinessProbe == nil {
+			warnings = append(warnings, prefix+": no readinessProbe")
+		}
+		if !batch && container.LivenessProbe == nil {
+			warnings = append(warnings, prefix+": no livenessProbe")
+		}
+		if len(container.Resources.Requests) == 0 {
+			warnings = append(warnings, prefix+": no resources.requests")
+		}
+		if len(container.Resources.Limits) == 0 {
+			warnings = append(warnings, prefix+": no resources.limits")
+		}
+		if tag := imageTag(container.Image); tag == "" || tag == "latest" {
+			warnings = append(warnings, fmt.Sprintf("%s: image %q has no tag or uses latest", prefix, container.Image))
+		}
+	}
+	return warnings
+}
+
+// imageTag returns the tag of image, or the digest when it's pinned to one.
+// A colon before the last slash belongs to a registry port.
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,396 @@
+// Package validation holds the rules the API server checks objects against
+// before storing them, so clients such as cli validate can run the same
+// checks without a server.
+package validation
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+// Object validates obj by its kind. Kinds without rules are always valid.
+func Object(obj interface{}) error {
+	switch obj := obj.(type) {
+	case *api.Pod:
+		return Pod(obj)
+	case *api.Deployment:
+		return Deployment(obj)
+	case *api.ReplicaSet:
+		return ReplicaSet(obj)
+	case *api.Job:
+		return Job(obj)
+	case *api.CronJob:
+		return CronJob(obj)
+	case *api.Node:
+		return Node(obj)
+	case *api.HorizontalPodAutoscaler:
+		return HorizontalPodAutoscaler(obj)
+	case *api.PodGroup:
+		return PodGroup(obj)
+	case *api.RuntimeClass:
+		return RuntimeClass(obj)
+	case *api.Service:
+		return Service(obj)
+	}
+	return nil
+}
+
+// Pod validates a pod
+func Pod(pod *api.Pod) error {
+	if err := PodSpec(&pod.Spec); err != nil {
+		return err
+	}
+	return PodStatus(&pod.Status)
+}
+
+// PodStatus checks a pod's phase and the statuses of its conditions. An
+// empty phase is allowed, and read as Pending.
+func PodStatus(status *api.PodStatus) error {
+	if status.Phase != "" {
+		if err := api.ValidatePodPhase(status.Phase); err != nil {
+			return fmt.Errorf("status.phase: %w", err)
+		}
+	}
+	for i, condition := range status.Conditions {
+		if condition.Type == "" {
+			return fmt.Errorf("status.conditions[%d].type is required", i)
+		}
+		if err := api.ValidateConditionStatus(condition.Status); err != nil {
+			return fmt.Errorf("status.conditions[%s]: %w", condition.Type, err)
+		}
+	}
+	return nil
+}
+
+// PodSpec validates the spec of a pod or pod template
+func PodSpec(spec *api.PodSpec) error {
+	if deadline := spec.ActiveDeadlineSeconds; deadline != nil && *deadline <= 0 {
+		return fmt.Errorf("spec.activeDeadlineSeconds must be positive")
+	}
+	if grace := spec.TerminationGracePeriodSeconds; grace != nil && *grace < 0 {
+		return fmt.Errorf("spec.terminationGracePeriodSeconds must not be negative")
+	}
+	for _, container := range spec.Containers {
+		if err := resources(fmt.Sprintf("spec.containers[%s].resources", container.Name), container.Resources); err != nil {
+			return err
+		}
+	}
+	if err := resourceList("spec.overhead", spec.Overhead); err != nil {
+		return err
+	}
+	for i, gate := range spec.ReadinessGates {
+		if gate.ConditionType == "" {
+			return fmt.Errorf("spec.readinessGates[%d].conditionType is required", i)
+		}
+		if api.IsStandardPodCondition(gate.ConditionType) {
+			return fmt.Errorf("spec.readinessGates[%d].conditionType %q is managed by the cluster", i, gate.ConditionType)
+		}
+	}
+	return nil
+}
+
+// resources checks a container's requests and limits. Huge pages can't be
+// overcommitted, so a container requesting them must set a limit, and
+// request as much as the limit.
+func resources(path string, requirements api.ResourceRequirements) error {
+	if err := resourceList(path+".requests", requirements.Requests); err != nil {
+		return err
+	}
+	if err := resourceList(path+".limits", requirements.Limits); err != nil {
+		return err
+	}
+	for name, request := range requirements.Requests {
+		limit, ok := requirements.Limits[name]
+		if !ok {
+			if name == api.ResourceHugePages2Mi {
+				return fmt.Errorf("%s.limits.%s is required when requesting huge pages", path, name)
+			}
+			continue
+		}
+		requested, _ := api.ParseQuantity(name, request)
+		limited, _ := api.ParseQuantity(name, limit)
+		if name == api.ResourceHugePages2Mi && requested != limited {
+			return fmt.Errorf("%s.requests.%s must equal its limit", path, name)
+		}
+		if requested > limited {
+			return fmt.Errorf("%s.requests.%s must not exceed its limit", path, name)
+		}
+	}
+	return nil
+}
+
+// resourceList checks that every resource is a standard one, or an extended
+// resource qualified by a domain such as example.com/gpu, and that its
+// quantity parses and isn't negative
+func resourceList(path string, list api.ResourceList) error {
+	for name, quantity := range list {
+		standard := false
+		for _, known := range api.StandardResources {
+			standard = standard || name == known
+		}
+		if !standard {
+			if !strings.Contains(string(name), "/") {
+				return fmt.Errorf("%s: unknown resource %q", path, name)
+			}
+			continue
+		}
+		value, err := api.ParseQuantity(name, quantity)
+		if err != nil {
+			return fmt.Errorf("%s.%s: invalid quantity %q", path, name, quantity)
+		}
+		if value < 0 {
+			return fmt.Errorf("%s.%s must not be negative", path, name)
+		}
+	}
+	return nil
+}
+
+// Deployment validates a deployment being created, updated or patched
+func Deployment(deployment *api.Deployment) error {
+	if deadline := deployment.Spec.ProgressDeadlineSeconds; deadline < 0 || (deadline > 0 && deadline <= deployment.Spec.MinReadySeconds) {
+		return fmt.Errorf("spec.progressDeadlineSeconds must be greater than spec.minReadySeconds")
+	}
+	if err := strategy(deployment.Spec.Strategy); err != nil {
+		return err
+	}
+	return workload(deployment.Spec.Replicas, deployment.Spec.MinReadySeconds, deployment.Spec.Selector, &deployment.Spec.Template)
+}
+
+// strategy checks a deployment's rollout strategy
+func strategy(strategy *api.DeploymentStrategy) error {
+	if strategy == nil {
+		return nil
+	}
+	switch strategy.Type {
+	case "":
+		return nil
+	case api.DeploymentStrategyCanary:
+	case api.DeploymentStrategyBlueGreen:
+		blueGreen := strategy.BlueGreen
+		if blueGreen == nil || blueGreen.ActiveService == "" {
+			return fmt.Errorf("spec.strategy.blueGreen.activeService is required for the BlueGreen strategy")
+		}
+		if blueGreen.PreviewService == blueGreen.ActiveService {
+			return fmt.Errorf("spec.strategy.blueGreen.previewService must differ from activeService")
+		}
+		return nil
+	default:
+		return fmt.Errorf("spec.strategy.type %q is not supported", strategy.Type)
+	}
+
+	if strategy.Canary == nil || len(strategy.Canary.Steps) == 0 {
+		return fmt.Errorf("spec.strategy.canary.steps is required for the Canary strategy")
+	}
+	for i, step := range strategy.Canary.Steps {
+		if (step.SetWeight != nil) == step.Pause {
+			return fmt.Errorf("spec.strategy.canary.steps[%d] must either set a weight or pause", i)
+		}
+		if step.SetWeight != nil && (*step.SetWeight < 0 || *step.SetWeight > 100) {
+			return fmt.Errorf("spec.strategy.canary.steps[%d].setWeight must be between 0 and 100", i)
+		}
+	}
+	return nil
+}
+
+// ReplicaSet validates a replicaset being created, updated or patched
+func ReplicaSet(replicaSet *api.ReplicaSet) error {
+	return workload(replicaSet.Spec.Replicas, replicaSet.Spec.MinReadySeconds, replicaSet.Spec.Selector, &replicaSet.Spec.Template)
+}
+
+// workload checks the parts of a deployment or replicaset spec the
+// controllers rely on
+func workload(replicas, minReadySeconds int32, selector *api.LabelSelector, template *api.PodTemplateSpec) error {
+	if replicas < 0 {
+		return fmt.Errorf("spec.replicas must not be negative")
+	}
+	if minReadySeconds < 0 {
+		return fmt.Errorf("spec.minReadySeconds must not be negative")
+	}
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return fmt.Errorf("spec.selector.matchLabels is required")
+	}
+	for key, value := range selector.MatchLabels {
+		if template.Labels[key] != value {
+			return fmt.Errorf("spec.template.metadata.labels must match spec.selector (missing %s=%s)", key, value)
+		}
+	}
+	if len(template.Spec.Containers) == 0 {
+		return fmt.Errorf("spec.template.spec.containers must not be empty")
+	}
+	if err := PodSpec(&template.Spec); err != nil {
+		return fmt.Errorf("spec.template: %w", err)
+	}
+	return nil
+}
+
+// Job checks the counts and completion mode of a job
+func Job(job *api.Job) error {
+	if job.Spec.Parallelism != nil && *job.Spec.Parallelism < 0 {
+		return fmt.Errorf("spec.parallelism must not be negative")
+	}
+	if job.Spec.Completions != nil && *job.Spec.Completions < 0 {
+		return fmt.Errorf("spec.completions must not be negative")
+	}
+	if job.Spec.BackoffLimit != nil && *job.Spec.BackoffLimit < 0 {
+		return fmt.Errorf("spec.backoffLimit must not be negative")
+	}
+	switch job.Spec.CompletionMode {
+	case "", api.JobCompletionNonIndexed:
+	case api.JobCompletionIndexed:
+		if job.Spec.Completions == nil {
+			return fmt.Errorf("spec.completions is required for Indexed jobs")
+		}
+	default:
+		return fmt.Errorf("spec.completionMode must be %s or %s", api.JobCompletionNonIndexed, api.JobCompletionIndexed)
+	}
+	if policy := job.Spec.Template.Spec.RestartPolicy; policy != "" && policy != "Never" && policy != "OnFailure" {
+		return fmt.Errorf("spec.template.spec.restartPolicy must be Never or OnFailure")
+	}
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("spec.template.spec.containers must not be empty")
+	}
+	if err := PodSpec(&job.Spec.Template.Spec); err != nil {
+		return fmt.Errorf("spec.template: %w", err)
+	}
+	return nil
+}
+
+// CronJob validates a cronjob being created, updated or patched
+func CronJob(cronJob *api.CronJob) error {
+	if _, err := api.ParseCronSchedule(cronJob.Spec.Schedule); err != nil {
+		return fmt.Errorf("spec.schedule: %w", err)
+	}
+	if _, err := cronJob.Location(time.UTC); err != nil {
+		return fmt.Errorf("spec.timeZone: %w", err)
+	}
+	switch cronJob.Spec.ConcurrencyPolicy {
+	case "", api.ConcurrencyAllow, api.ConcurrencyForbid, api.ConcurrencyReplace:
+	default:
+		return fmt.Errorf("spec.concurrencyPolicy must be %s, %s or %s", api.ConcurrencyAllow, api.ConcurrencyForbid, api.ConcurrencyReplace)
+	}
+	if deadline := cronJob.Spec.StartingDeadlineSeconds; deadline != nil && *deadline < 0 {
+		return fmt.Errorf("spec.startingDeadlineSeconds must not be negative")
+	}
+	if limit := cronJob.Spec.SuccessfulJobsHistoryLimit; limit != nil && *limit < 0 {
+		return fmt.Errorf("spec.successfulJobsHistoryLimit must not be negative")
+	}
+	if limit := cronJob.Spec.FailedJobsHistoryLimit; limit != nil && *limit < 0 {
+		return fmt.Errorf("spec.failedJobsHistoryLimit must not be negative")
+	}
+	job := api.Job{Spec: cronJob.Spec.JobTemplate.Spec}
+	if err := Job(&job); err != nil {
+		return fmt.Errorf("spec.jobTemplate: %w", err)
+	}
+	return nil
+}
+
+// Node checks a node's taints
+func Node(node *api.Node) error {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == "" {
+			return fmt.Errorf("spec.taints: key is required")
+		}
+		if err := api.ValidateTaintEffect(taint.Effect); err != nil {
+			return fmt.Errorf("spec.taints[%s]: %w", taint.Key, err)
+		}
+	}
+	return nil
+}
+
+// HorizontalPodAutoscaler checks the parts of an autoscaler spec the
+// controller relies on
+func HorizontalPodAutoscaler(hpa *api.HorizontalPodAutoscaler) error {
+	spec := &hpa.Spec
+	switch spec.ScaleTargetRef.Kind {
+	case "Deployment", "ReplicaSet":
+	default:
+		return fmt.Errorf("spec.scaleTargetRef.kind must be Deployment or ReplicaSet")
+	}
+	if spec.ScaleTargetRef.Name == "" {
+		return fmt.Errorf("spec.scaleTargetRef.name is required")
+	}
+	if spec.MinReplicas != nil && *spec.MinReplicas < 0 {
+		return fmt.Errorf("spec.minReplicas must not be negative")
+	}
+	minReplicas := int32(1)
+	if spec.MinReplicas != nil {
+		minReplicas = *spec.MinReplicas
+	}
+	if spec.MaxReplicas < 1 || spec.MaxReplicas < minReplicas {
+		return fmt.Errorf("spec.maxReplicas must be at least 1 and at least spec.minReplicas")
+	}
+	if spec.ScaleToZeroAfterSeconds < 0 {
+		return fmt.Errorf("spec.scaleToZeroAfterSeconds must not be negative")
+	}
+	if len(spec.Metrics) == 0 {
+		return fmt.Errorf("spec.metrics must not be empty")
+	}
+	for i, metric := range spec.Metrics {
+		if metric.Type != api.MetricSourceExternal || metric.External == nil {
+			return fmt.Errorf("spec.metrics[%d] must be an External metric", i)
+		}
+		if metric.External.MetricName == "" {
+			return fmt.Errorf("spec.metrics[%d].external.metricName is required", i)
+		}
+		if metric.External.TargetAverageValue <= 0 {
+			return fmt.Errorf("spec.metrics[%d].external.targetAverageValue must be positive", i)
+		}
+	}
+	return nil
+}
+
+// PodGroup validates a pod group
+func PodGroup(group *api.PodGroup) error {
+	if group.Spec.MinMember < 1 {
+		return fmt.Errorf("spec.minMember must be at least 1")
+	}
+	return nil
+}
+
+// RuntimeClass validates a runtime class
+func RuntimeClass(runtimeClass *api.RuntimeClass) error {
+	if runtimeClass.Overhead == nil {
+		return nil
+	}
+	return resourceList("overhead.podFixed", runtimeClass.Overhead.PodFixed)
+}
+
+// Service validates a service. Its cluster IP, when set by hand, must be an
+// IPv4 address or None; the service controller checks it's in range.
+func Service(service *api.Service) error {
+	spec := &service.Spec
+	if spec.Type != "" && spec.Type != api.ServiceTypeClusterIP {
+		return fmt.Errorf("spec.type must be %s", api.ServiceTypeClusterIP)
+	}
+	if spec.ClusterIP != "" && spec.ClusterIP != api.ClusterIPNone {
+		if ip := net.ParseIP(spec.ClusterIP); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("spec.clusterIP must be an IPv4 address or %s", api.ClusterIPNone)
+		}
+	}
+	seen := make(map[string]bool)
+	for i, port := range spec.Ports {
+		if port.Port < 1 || port.Port > 65535 {
+			return fmt.Errorf("spec.ports[%d].port must be between 1 and 65535", i)
+		}
+		if port.TargetPort < 0 || port.TargetPort > 65535 {
+			return fmt.Errorf("spec.ports[%d].targetPort must be between 1 and 65535", i)
+		}
+		switch strings.ToUpper(port.Protocol) {
+		case "", "TCP", "UDP", "SCTP":
+		default:
+			return fmt.Errorf("spec.ports[%d].protocol must be TCP, UDP or SCTP", i)
+		}
+		if len(spec.Ports) > 1 && port.Name == "" {
+			return fmt.Errorf("spec.ports[%d].name is required when a service has several ports", i)
+		}
+		if seen[port.Name] {
+			return fmt.Errorf("spec.ports[%d].name %q is used twice", i, port.Name)
+		}
+		seen[port.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minik8s/minik8s/pkg/api"
+)
+
+func deployment() *api.Deployment {
+	return &api.Deployment{
+		Spec: api.DeploymentSpec{
+			Replicas: 2,
+			Selector: &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       api.PodSpec{Containers: []api.Container{{Name: "web", Image: "nginx:1.27"}}},
+			},
+		},
+	}
+}
+
+func TestObject(t *testing.T) {
+	deadline := int64(0)
+	weight := int32(150)
+	tests := []struct {
+		name    string
+		obj     interface{}
+		wantErr bool
+	}{
+		{name: "valid deployment", obj: deployment()},
+		{name: "selector not matching template", obj: func() interface{} {
+			d := deployment()
+			d.Spec.Template.Labels = map[string]string{"app": "api"}
+			return d
+		}(), wantErr: true},
+		{name: "canary weight out of range", obj: func() interface{} {
+			d := deployment()
+			d.Spec.Strategy = &api.DeploymentStrategy{
+				Type:   api.DeploymentStrategyCanary,
+				Canary: &api.CanaryStrategy{Steps: []api.CanaryStep{{SetWeight: &weight}}},
+			}
+			return d
+		}(), wantErr: true},
+		{name: "template with zero active deadline", obj: func() interface{} {
+			d := deployment()
+			d.Spec.Template.Spec.ActiveDeadlineSeconds = &deadline
+			return d
+		}(), wantErr: true},
+		{name: "negative termination grace period", obj: &api.Pod{Spec: api.PodSpec{
+			TerminationGracePeriodSeconds: func() *int64 { grace := int64(-1); return &grace }(),
+		}}, wantErr: true},
+		{name: "readiness gate on a standard condition", obj: &api.Pod{Spec: api.PodSpec{
+			ReadinessGates: []api.PodReadinessGate{{ConditionType: api.PodConditionReady}},
+		}}, wantErr: true},
+		{name: "pod with an unknown phase", obj: &api.Pod{Status: api.PodStatus{Phase: "Starting"}}, wantErr: true},
+		{name: "pod condition with a bad status", obj: &api.Pod{Status: api.PodStatus{
+			Phase:      api.PodRunning,
+			Conditions: []api.PodCondition{{Type: api.PodConditionReady, Status: "Yes"}},
+		}}, wantErr: true},
+		{name: "indexed job without completions", obj: &api.Job{Spec: api.JobSpec{
+			CompletionMode: api.JobCompletionIndexed,
+			Template:       api.PodTemplateSpec{Spec: api.PodSpec{Containers: []api.Container{{Name: "work", Image: "busybox:1.36"}}}},
+		}}, wantErr: true},
+		{name: "cronjob with bad schedule", obj: &api.CronJob{Spec: api.CronJobSpec{Schedule: "61 * * * *"}}, wantErr: true},
+		{name: "node taint with bad effect", obj: &api.Node{Spec: api.NodeSpec{Taints: []api.Taint{{Key: "gpu", Effect: "Sometimes"}}}}, wantErr: true},
+		{name: "unknown resource", obj: &api.Pod{Spec: api.PodSpec{Containers: []api.Container{{
+			Name:      "app",
+			Resources: api.ResourceRequirements{Requests: api.ResourceList{"gpu": "1"}},
+		}}}}, wantErr: true},
+		{name: "extended resource", obj: &api.Pod{Spec: api.PodSpec{Containers: []api.Container{{
+			Name:      "app",
+			Resources: api.ResourceRequirements{Limits: api.ResourceList{"example.com/gpu": "1"}},
+		}}}}},
+		{name: "huge pages without a limit", obj: &api.Pod{Spec: api.PodSpec{Containers: []api.Container{{
+			Name:      "app",
+			Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceHugePages2Mi: "64Mi"}},
+		}}}}, wantErr: true},
+		{name: "ephemeral storage request above its limit", obj: &api.Pod{Spec: api.PodSpec{Containers: []api.Container{{
+			Name: "app",
+			Resources: api.ResourceRequirements{
+				Requests: api.ResourceList{api.ResourceEphemeralStorage: "2Gi"},
+				Limits:   api.ResourceList{api.ResourceEphemeralStorage: "1G"},
+			},
+		}}}}, wantErr: true},
+		{name: "pod group without members", obj: &api.PodGroup{}, wantErr: true},
+		{name: "service", obj: &api.Service{Spec: api.ServiceSpec{
+			Selector: map[string]string{"app": "web"},
+			Ports:    []api.ServicePort{{Name: "http", Port: 80, TargetPort: 8080}, {Name: "dns", Protocol: "udp", Port: 53}},
+		}}},
+		{name: "service with a bad cluster IP", obj: &api.Service{Spec: api.ServiceSpec{ClusterIP: "10.96.0.300"}}, wantErr: true},
+		{name: "service with unnamed ports", obj: &api.Service{Spec: api.ServiceSpec{Ports: []api.ServicePort{{Port: 80}, {Port: 443}}}}, wantErr: true},
+		{name: "kind without rules", obj: &api.Namespace{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Object(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Object() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	d := deployment()
+	d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, api.Container{
+		Name:           "sidecar",
+		Image:          "registry.local:5000/proxy",
+		LivenessProbe:  &api.Probe{},
+		ReadinessProbe: &api.Probe{},
+		Resources: api.ResourceRequirements{
+			Requests: api.ResourceList{"cpu": "100m"},
+			Limits:   api.ResourceList{"cpu": "1"},
+		},
+	})
+
+	want := []string{
+		"spec.template.spec.containers[web]: no readinessProbe",
+		"spec.template.spec.containers[web]: no livenessProbe",
+		"spec.template.spec.containers[web]: no resources.requests",
+		"spec.template.spec.containers[web]: no resources.limits",
+		`spec.template.spec.containers[sidecar]: image "registry.local:5000/proxy" has no tag or uses latest`,
+	}
+	if got := Warnings(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+
+	// Job pods run to completion, so they aren't expected to have probes
+	job := &api.Job{Spec: api.JobSpec{Template: api.PodTemplateSpec{Spec: api.PodSpec{Containers: []api.Container{{
+		Name:  "work",
+		Image: "busybox@sha256:abc",
+		Resources: api.ResourceRequirements{
+			Requests: api.ResourceList{"cpu": "100m"},
+			Limits:   api.ResourceList{"cpu": "1"},
+		},
+	}}}}}}
+	if got := Warnings(job); len(got) != 0 {
+		t.Errorf("Warnings() = %q, want none", got)
+	}
+}
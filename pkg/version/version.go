@@ -0,0 +1,89 @@
+// Package version reports the build a minik8s component is running. The
+// Makefile sets the version, commit and date with -ldflags, e.g.
+//
+//	-X github.com/minik8s/minik8s/pkg/version.gitVersion=v0.3.0
+//
+// and builds without them fall back to the VCS information Go embeds.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X at build time
+var (
+	gitVersion = ""
+	gitCommit  = ""
+	buildDate  = ""
+)
+
+// Info describes the build of a component
+type Info struct {
+	GitVersion string `json:"gitVersion"`
+	GitCommit  string `json:"gitCommit"`
+	BuildDate  string `json:"buildDate"`
+	GoVersion  string `json:"goVersion"`
+	Platform   string `json:"platform"`
+
+	// Components lists the versions of the cluster's components, as
+	// reported by the API server
+	Components []Component `json:"components,omitempty"`
+}
+
+// Component is the version of one component of a cluster
+type Component struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// String returns the version, with the commit when it's known
+func (i Info) String() string {
+	if i.GitCommit == "" {
+		return i.GitVersion
+	}
+	return fmt.Sprintf("%s (%s)", i.GitVersion, i.GitCommit)
+}
+
+// Get returns the build of the running binary
+func Get() Info {
+	info := Info{
+		GitVersion: gitVersion,
+		GitCommit:  gitCommit,
+		BuildDate:  buildDate,
+		GoVersion:  runtime.Version(),
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		modified := false
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.GitCommit == "" {
+					info.GitCommit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if info.GitVersion == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.GitVersion = build.Main.Version
+		}
+		if info.GitVersion == "" && info.GitCommit != "" && len(info.GitCommit) >= 12 {
+			info.GitVersion = "v0.0.0-" + info.GitCommit[:12]
+			if modified {
+				info.GitVersion += "-dirty"
+			}
+		}
+	}
+	if info.GitVersion == "" {
+		info.GitVersion = "v0.0.0-dev"
+	}
+	return info
+}
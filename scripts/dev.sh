@@ -28,7 +28,7 @@ go test -v ./...
 # Build binaries
 echo "🔨 Building binaries..."
 go build -o bin/apiserver cmd/apiserver/main.go
-go build -o bin/cli cmd/cli/main.go
+go build -o bin/cli ./cmd/cli
 
 echo "✅ Build complete!"
 echo ""
@@ -60,24 +60,24 @@ echo "Testing basic operations..."
 
 # Create a pod
 echo "Creating pod..."
-go run cmd/cli/main.go create -f examples/pod.yaml
+go run ./cmd/cli create -f examples/pod.yaml
 
 # List pods
 echo "Listing pods..."
-go run cmd/cli/main.go get pods
+go run ./cmd/cli get pods
 
 # Create a node
 echo "Creating node..."
-go run cmd/cli/main.go create -f examples/node.yaml
+go run ./cmd/cli create -f examples/node.yaml
 
 # List nodes
 echo "Listing nodes..."
-go run cmd/cli/main.go get nodes
+go run ./cmd/cli get nodes
 
 # Clean up
 echo "Cleaning up..."
-go run cmd/cli/main.go delete pods nginx-pod
-go run cmd/cli/main.go delete nodes worker-node-1
+go run ./cmd/cli delete pods nginx-pod
+go run ./cmd/cli delete nodes worker-node-1
 
 # Stop API server
 echo "Stopping API server..."